@@ -0,0 +1,133 @@
+package chainlib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	sdkerrors "cosmossdk.io/errors"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/merkle"
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+)
+
+var InvalidABCIQueryProofError = sdkerrors.New("InvalidABCIQueryProof Error", 1109, "abci query proof doesn't match the app hash")
+
+const ABCIQueryMethod = "abci_query"
+
+// ABCIProofRequired returns true when the endpoint opted in to verifying proofs of the abci query path, patterns are
+// like the method filter's
+func ABCIProofRequired(patterns []string, path string) bool {
+	_, ok := matchMethodPattern(patterns, path)
+	return ok
+}
+
+// ABCIQueryParams returns the path of an abci_query request and whether it asks for a proof, from its jsonrpc params,
+// named or positional, or from the uri when the request has no body
+func ABCIQueryParams(data []byte, apiUrl string) (path string, prove bool) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		urlObj, err := url.Parse(apiUrl)
+		if err != nil {
+			return "", false
+		}
+		query := urlObj.Query()
+		return strings.Trim(query.Get("path"), `"`), parseProveParam([]byte(query.Get("prove")))
+	}
+	var request struct {
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return "", false
+	}
+	var namedParams struct {
+		Path  string          `json:"path"`
+		Prove json.RawMessage `json:"prove"`
+	}
+	if err := json.Unmarshal(request.Params, &namedParams); err == nil {
+		return namedParams.Path, parseProveParam(namedParams.Prove)
+	}
+	var positionalParams []json.RawMessage
+	if err := json.Unmarshal(request.Params, &positionalParams); err != nil || len(positionalParams) == 0 {
+		return "", false
+	}
+	if err := json.Unmarshal(positionalParams[0], &path); err != nil {
+		return "", false
+	}
+	if len(positionalParams) > 3 {
+		prove = parseProveParam(positionalParams[3])
+	}
+	return path, prove
+}
+
+// prove is a bool, tendermint also accepts it quoted
+func parseProveParam(value []byte) bool {
+	prove, err := strconv.ParseBool(strings.Trim(string(bytes.TrimSpace(value)), `"`))
+	return err == nil && prove
+}
+
+// DecodeABCIQueryReply decodes the response of an abci_query jsonrpc reply, value and proofs included
+func DecodeABCIQueryReply(data []byte) (*abci.ResponseQuery, error) {
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, err
+	}
+	if len(reply.Result) == 0 {
+		return nil, fmt.Errorf("abci_query reply has no result")
+	}
+	result := &coretypes.ResultABCIQuery{}
+	if err := cmtjson.Unmarshal(reply.Result, result); err != nil {
+		return nil, err
+	}
+	return &result.Response, nil
+}
+
+// VerifyABCIQueryProof verifies the proof of a /store/<name>/key query response against appHash, the app hash in the
+// header of the block after the response's height. an empty value is verified as absent
+func VerifyABCIQueryProof(path string, response *abci.ResponseQuery, appHash []byte) error {
+	if response.ProofOps == nil || len(response.ProofOps.Ops) == 0 {
+		return sdkerrors.Wrapf(InvalidABCIQueryProofError, "response to %s has no proof", path)
+	}
+	storeName, subPath, ok := strings.Cut(strings.TrimPrefix(path, "/store/"), "/")
+	if !ok || storeName == "" || !rootmulti.RequireProof("/"+subPath) {
+		return sdkerrors.Wrapf(InvalidABCIQueryProofError, "path %s isn't a store key query", path)
+	}
+	keyPath := merkle.KeyPath{}.AppendKey([]byte(storeName), merkle.KeyEncodingURL).AppendKey(response.Key, merkle.KeyEncodingURL).String()
+	var err error
+	if len(response.Value) == 0 {
+		err = rootmulti.DefaultProofRuntime().VerifyAbsence(response.ProofOps, appHash, keyPath)
+	} else {
+		err = rootmulti.DefaultProofRuntime().VerifyValue(response.ProofOps, appHash, keyPath, response.Value)
+	}
+	if err != nil {
+		return sdkerrors.Wrapf(InvalidABCIQueryProofError, "path: %s, height: %d, error: %s", path, response.Height, err.Error())
+	}
+	return nil
+}
+
+// AppHashFromCommitReply returns the app hash of the header in a commit jsonrpc reply
+func AppHashFromCommitReply(data []byte) ([]byte, error) {
+	var reply struct {
+		Result struct {
+			SignedHeader struct {
+				Header struct {
+					AppHash string `json:"app_hash"`
+				} `json:"header"`
+			} `json:"signed_header"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Result.SignedHeader.Header.AppHash == "" {
+		return nil, fmt.Errorf("commit reply has no app hash")
+	}
+	return hex.DecodeString(reply.Result.SignedHeader.Header.AppHash)
+}
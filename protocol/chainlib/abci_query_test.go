@@ -0,0 +1,122 @@
+package chainlib
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	dbm "github.com/cometbft/cometbft-db"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	"github.com/cometbft/cometbft/libs/log"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cosmos/cosmos-sdk/store/rootmulti"
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
+	"github.com/stretchr/testify/require"
+)
+
+// abciQueryReply queries a committed store with a proof and returns it as a tendermint rpc reply, with the app hash
+func abciQueryReply(t *testing.T, key string) ([]byte, []byte) {
+	store := rootmulti.NewStore(dbm.NewMemDB(), log.NewNopLogger())
+	bankKey := storetypes.NewKVStoreKey("bank")
+	store.MountStoreWithDB(bankKey, storetypes.StoreTypeIAVL, nil)
+	store.MountStoreWithDB(storetypes.NewKVStoreKey("staking"), storetypes.StoreTypeIAVL, nil)
+	require.NoError(t, store.LoadLatestVersion())
+	store.GetKVStore(bankKey).Set([]byte("balance"), []byte("100"))
+	store.GetKVStore(bankKey).Set([]byte("other"), []byte("5"))
+	commitID := store.Commit()
+	response := store.Query(abci.RequestQuery{Path: "/bank/key", Data: []byte(key), Prove: true, Height: commitID.Version})
+	require.Zero(t, response.Code, response.Log)
+	result, err := cmtjson.Marshal(&coretypes.ResultABCIQuery{Response: response})
+	require.NoError(t, err)
+	return []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":%s}`, result)), commitID.Hash
+}
+
+func TestABCIQueryParams(t *testing.T) {
+	path, prove := ABCIQueryParams([]byte(`{"jsonrpc":"2.0","id":1,"method":"abci_query","params":{"path":"/store/bank/key","data":"0x01","prove":true}}`), "")
+	require.Equal(t, "/store/bank/key", path)
+	require.True(t, prove)
+	path, prove = ABCIQueryParams([]byte(`{"jsonrpc":"2.0","id":1,"method":"abci_query","params":["/store/bank/key","0x01","0",true]}`), "")
+	require.Equal(t, "/store/bank/key", path)
+	require.True(t, prove)
+	path, prove = ABCIQueryParams(nil, `/abci_query?path="/store/bank/key"&data=0x01&prove=true`)
+	require.Equal(t, "/store/bank/key", path)
+	require.True(t, prove)
+	path, prove = ABCIQueryParams([]byte(`{"jsonrpc":"2.0","id":1,"method":"abci_query","params":{"path":"/store/bank/key","data":"0x01"}}`), "")
+	require.Equal(t, "/store/bank/key", path)
+	require.False(t, prove)
+	path, prove = ABCIQueryParams([]byte(`{"jsonrpc":"2.0","id":1,"method":"abci_query","params":["/store/bank/key","0x01","0","false"]}`), "")
+	require.Equal(t, "/store/bank/key", path)
+	require.False(t, prove)
+	path, _ = ABCIQueryParams([]byte(`{"jsonrpc":"2.0","id":1,"method":"abci_query","params":[]}`), "")
+	require.Empty(t, path)
+
+	require.True(t, ABCIProofRequired([]string{"/store/bank/*"}, "/store/bank/key"))
+	require.False(t, ABCIProofRequired([]string{"/store/bank/*"}, "/store/staking/key"))
+	require.False(t, ABCIProofRequired(nil, "/store/bank/key"))
+}
+
+func TestVerifyABCIQueryProof(t *testing.T) {
+	reply, appHash := abciQueryReply(t, "balance")
+	response, err := DecodeABCIQueryReply(reply)
+	require.NoError(t, err)
+	require.Equal(t, []byte("100"), response.Value)
+
+	t.Run("valid proof", func(t *testing.T) {
+		require.NoError(t, VerifyABCIQueryProof("/store/bank/key", response, appHash))
+	})
+
+	t.Run("absence proof", func(t *testing.T) {
+		absentReply, absentAppHash := abciQueryReply(t, "missing")
+		absent, err := DecodeABCIQueryReply(absentReply)
+		require.NoError(t, err)
+		require.Empty(t, absent.Value)
+		require.NoError(t, VerifyABCIQueryProof("/store/bank/key", absent, absentAppHash))
+	})
+
+	t.Run("tampered value", func(t *testing.T) {
+		tampered, err := DecodeABCIQueryReply(reply)
+		require.NoError(t, err)
+		tampered.Value = []byte("1000000")
+		err = VerifyABCIQueryProof("/store/bank/key", tampered, appHash)
+		require.True(t, InvalidABCIQueryProofError.Is(err))
+		// claiming the key doesn't exist
+		tampered.Value = nil
+		require.True(t, InvalidABCIQueryProofError.Is(VerifyABCIQueryProof("/store/bank/key", tampered, appHash)))
+	})
+
+	t.Run("tampered proof", func(t *testing.T) {
+		tampered, err := DecodeABCIQueryReply(reply)
+		require.NoError(t, err)
+		data := tampered.ProofOps.Ops[0].Data
+		data[len(data)-1] ^= 0xff
+		require.True(t, InvalidABCIQueryProofError.Is(VerifyABCIQueryProof("/store/bank/key", tampered, appHash)))
+	})
+
+	t.Run("different app hash", func(t *testing.T) {
+		otherAppHash := append([]byte{}, appHash...)
+		otherAppHash[0] ^= 0xff
+		require.True(t, InvalidABCIQueryProofError.Is(VerifyABCIQueryProof("/store/bank/key", response, otherAppHash)))
+	})
+
+	t.Run("wrong store", func(t *testing.T) {
+		require.True(t, InvalidABCIQueryProofError.Is(VerifyABCIQueryProof("/store/staking/key", response, appHash)))
+		require.True(t, InvalidABCIQueryProofError.Is(VerifyABCIQueryProof("/cosmos.bank.v1beta1.Query/Balance", response, appHash)))
+	})
+
+	t.Run("no proof", func(t *testing.T) {
+		unproven, err := DecodeABCIQueryReply(reply)
+		require.NoError(t, err)
+		unproven.ProofOps = nil
+		require.True(t, InvalidABCIQueryProofError.Is(VerifyABCIQueryProof("/store/bank/key", unproven, appHash)))
+	})
+}
+
+func TestAppHashFromCommitReply(t *testing.T) {
+	appHash, err := AppHashFromCommitReply([]byte(`{"jsonrpc":"2.0","id":1,"result":{"signed_header":{"header":{"height":"11","app_hash":"` + strings.ToUpper(hex.EncodeToString([]byte("apphash"))) + `"},"commit":{}},"canonical":true}}`))
+	require.NoError(t, err)
+	require.Equal(t, []byte("apphash"), appHash)
+	_, err = AppHashFromCommitReply([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"height 12 must be less than or equal to the current blockchain height 11"}}`))
+	require.Error(t, err)
+}
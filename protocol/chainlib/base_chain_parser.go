@@ -8,7 +8,9 @@ import (
 	"sync"
 	"time"
 
+	sdkerrors "cosmossdk.io/errors"
 	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/parser"
 	"github.com/lavanet/lava/utils"
 	epochstorage "github.com/lavanet/lava/x/epochstorage/types"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
@@ -30,9 +32,15 @@ type BaseChainParser struct {
 	verifications   map[VerificationKey][]VerificationContainer
 	allowedAddons   map[string]bool
 	extensionParser extensionslib.ExtensionParser
+	blockParsers    parser.BlockParserRegistry
 	active          bool
 }
 
+// RegisterBlockParser sets a custom requested block parser for an api of this chain parser, nil restores the spec's block parsing
+func (bcp *BaseChainParser) RegisterBlockParser(apiName string, blockParser parser.BlockNumberParser) {
+	bcp.blockParsers.Register(apiName, blockParser)
+}
+
 func (bcp *BaseChainParser) Activate() {
 	bcp.active = true
 }
@@ -60,6 +68,10 @@ func (bcp *BaseChainParser) HandleHeaders(metadata []pairingtypes.Metadata, apiC
 		apiKey := ApiKey{Name: headerName, ConnectionType: apiCollection.CollectionData.Type}
 		headerDirective, ok := bcp.headers[apiKey]
 		if !ok {
+			if headersDirection == spectypes.Header_pass_reply && isPassThroughReplyHeader(apiCollection, headerName) {
+				// not signed so consumers that filter it out still verify the reply
+				ignoredMetadata = append(ignoredMetadata, header)
+			}
 			// this header is not handled
 			continue
 		}
@@ -76,6 +88,11 @@ func (bcp *BaseChainParser) HandleHeaders(metadata []pairingtypes.Metadata, apiC
 	return retMetadata, overwriteRequestedBlock, ignoredMetadata
 }
 
+// rest replies keep their content type even when the spec doesn't list it, so clients can handle non json replies
+func isPassThroughReplyHeader(apiCollection *spectypes.ApiCollection, headerName string) bool {
+	return apiCollection.CollectionData.ApiInterface == spectypes.APIInterfaceRest && headerName == restContentTypeHeader
+}
+
 func (bcp *BaseChainParser) isAddon(addon string) bool {
 	_, ok := bcp.allowedAddons[addon]
 	return ok
@@ -349,7 +366,7 @@ func getServiceApis(spec spectypes.Spec, rpcInterface string) (retServerApis map
 	verifications := map[VerificationKey][]VerificationContainer{}
 	if spec.Enabled {
 		for _, apiCollection := range spec.ApiCollections {
-			if !apiCollection.Enabled {
+			if apiCollection == nil || !apiCollection.Enabled {
 				continue
 			}
 			if apiCollection.CollectionData.ApiInterface != rpcInterface {
@@ -367,7 +384,12 @@ func getServiceApis(spec spectypes.Spec, rpcInterface string) (retServerApis map
 				}
 			}
 
-			for _, api := range apiCollection.Apis {
+			for idx, api := range apiCollection.Apis {
+				if err := validateSpecApi(api); err != nil {
+					// malformed entries are dropped when the spec is loaded instead of failing the relays that match them
+					utils.LavaFormatError("Bad api definition", err, utils.LogAttr("spec", spec.Index), utils.LogAttr("apiInterface", rpcInterface), utils.LogAttr("index", idx), utils.LogAttr("api", api.GetName()))
+					continue
+				}
 				if !api.Enabled {
 					continue
 				}
@@ -444,6 +466,23 @@ func (bcp *BaseChainParser) ExtensionsParser() *extensionslib.ExtensionParser {
 	return &bcp.extensionParser
 }
 
+// SpecVersion returns the block the spec was last updated in, consumers and providers running the same spec agree on it
+func (bcp *BaseChainParser) SpecVersion() uint64 {
+	bcp.rwLock.RLock()
+	defer bcp.rwLock.RUnlock()
+	return bcp.spec.BlockLastUpdated
+}
+
+func validateSpecApi(api *spectypes.Api) error {
+	if api == nil {
+		return sdkerrors.Wrap(InvalidApiConfigurationError, "api entry is nil")
+	}
+	if api.Name == "" {
+		return sdkerrors.Wrap(InvalidApiConfigurationError, "api entry has no name")
+	}
+	return nil
+}
+
 // matchSpecApiByName returns service api which match given name
 func matchSpecApiByName(name, connectionType string, serverApis map[ApiKey]ApiContainer) (*ApiContainer, bool) {
 	// TODO: make it faster and better by not doing a regex instead using a better algorithm
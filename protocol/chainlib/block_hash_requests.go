@@ -0,0 +1,95 @@
+package chainlib
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+// BlockHashParamMethods are the jsonrpc methods identifying their block by hash, mapped to the position of the hash param
+var BlockHashParamMethods = map[string]int{
+	"eth_getBlockByHash":                    0,
+	"eth_getBlockTransactionCountByHash":    0,
+	"eth_getTransactionByBlockHashAndIndex": 0,
+	"eth_getUncleCountByBlockHash":          0,
+	"eth_getUncleByBlockHashAndIndex":       0,
+}
+
+// BlockHashResolver returns the number of the block with the given hash, ok is false when the hash isn't known
+type BlockHashResolver func(hash string) (blockNum int64, ok bool)
+
+// IsBlockHash returns true for a 0x prefixed 32 bytes hex string
+func IsBlockHash(str string) bool {
+	hash, ok := strings.CutPrefix(str, "0x")
+	if !ok || len(hash) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// RequestedBlockHash returns the block hash a jsonrpc request refers to, either as the hash param of a by-hash method
+// or as an EIP-1898 {"blockHash": ...} block param
+func RequestedBlockHash(msg *rpcInterfaceMessages.JsonrpcMessage) (string, bool) {
+	params, ok := msg.Params.([]interface{})
+	if !ok {
+		return "", false
+	}
+	if idx, ok := BlockHashParamMethods[msg.Method]; ok {
+		if idx < len(params) {
+			if hash, ok := params[idx].(string); ok && IsBlockHash(hash) {
+				return hash, true
+			}
+		}
+		return "", false
+	}
+	for _, param := range params {
+		if blockParam, ok := param.(map[string]interface{}); ok {
+			if hash, ok := blockParam["blockHash"].(string); ok && IsBlockHash(hash) {
+				return hash, true
+			}
+		}
+	}
+	return "", false
+}
+
+// MessageRequestedBlockHash returns the block hash a parsed message refers to, only jsonrpc messages carry one
+func MessageRequestedBlockHash(chainMessage ChainMessageForSend) (string, bool) {
+	msg, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+	if !ok {
+		return "", false
+	}
+	return RequestedBlockHash(msg)
+}
+
+// SetBlockHashResolver sets the lookup used to turn a requested block hash into a block number, so requests by hash
+// to known blocks are handled like requests by number for data reliability and caching
+func (apip *JsonRPCChainParser) SetBlockHashResolver(resolver BlockHashResolver) {
+	apip.rwLock.Lock()
+	defer apip.rwLock.Unlock()
+	apip.blockHashResolver = resolver
+}
+
+// resolves the requested block of a message that refers to its block by hash, requestedBlock is returned as is when
+// the message has a block number or the hash is unknown
+func (apip *JsonRPCChainParser) resolveRequestedBlockHash(msg *rpcInterfaceMessages.JsonrpcMessage, requestedBlock int64) int64 {
+	if requestedBlock != spectypes.LATEST_BLOCK && requestedBlock != spectypes.NOT_APPLICABLE {
+		return requestedBlock
+	}
+	apip.rwLock.RLock()
+	resolver := apip.blockHashResolver
+	apip.rwLock.RUnlock()
+	if resolver == nil {
+		return requestedBlock
+	}
+	hash, ok := RequestedBlockHash(msg)
+	if !ok {
+		return requestedBlock
+	}
+	if blockNum, ok := resolver(hash); ok {
+		return blockNum
+	}
+	return requestedBlock
+}
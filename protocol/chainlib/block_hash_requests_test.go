@@ -0,0 +1,58 @@
+package chainlib
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestedBlockByHash(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	apip, err := NewJrpcChainParser()
+	require.NoError(t, err)
+	apip.SetSpec(spec)
+
+	const (
+		knownHash   = "0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"
+		unknownHash = "0x1111111111111111111111111111111111111111111111111111111111111111"
+	)
+	parseRequestedBlock := func(request string) int64 {
+		chainMessage, err := apip.ParseMsg("", []byte(request), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+		require.NoError(t, err, request)
+		requestedBlock, _ := chainMessage.RequestedBlock()
+		return requestedBlock
+	}
+	byNumber := `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x2a",false]}`
+	byHash := `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByHash","params":["` + knownHash + `",false]}`
+	byUnknownHash := `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByHash","params":["` + unknownHash + `",false]}`
+
+	// without a resolver a block hash can't be placed
+	require.Equal(t, int64(42), parseRequestedBlock(byNumber))
+	require.Equal(t, spectypes.LATEST_BLOCK, parseRequestedBlock(byHash))
+
+	apip.SetBlockHashResolver(func(hash string) (int64, bool) {
+		if hash == knownHash {
+			return 42, true
+		}
+		return 0, false
+	})
+	require.Equal(t, int64(42), parseRequestedBlock(byNumber))
+	require.Equal(t, int64(42), parseRequestedBlock(byHash))
+	require.Equal(t, spectypes.LATEST_BLOCK, parseRequestedBlock(byUnknownHash))
+	// EIP-1898 block params
+	require.Equal(t, int64(42), parseRequestedBlock(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x00000000000000000000000000000000000000aa",{"blockHash":"`+knownHash+`"}]}`))
+}
+
+func TestIsBlockHash(t *testing.T) {
+	require.True(t, IsBlockHash("0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"))
+	require.True(t, IsBlockHash("0x88E96D4537BEA4D9C05D12549907B32561D3BF31F45AAE734CDC119F13406CB6"))
+	require.False(t, IsBlockHash("88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"))
+	require.False(t, IsBlockHash("0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406c"))
+	require.False(t, IsBlockHash("0xzze96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"))
+	require.False(t, IsBlockHash("latest"))
+}
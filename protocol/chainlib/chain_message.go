@@ -97,6 +97,14 @@ func (pm *baseChainMessageContainer) UpdateLatestBlockInMessage(latestBlock int6
 	return false
 }
 
+// Clone returns a copy of the message that can be relayed on its own, updating the copy's requested block or
+// extensions leaves the message as parsed. the rpc message is shared, relaying it doesn't modify its content
+func (pm *baseChainMessageContainer) Clone() ChainMessage {
+	clone := *pm
+	clone.extensions = append([]*spectypes.Extension(nil), pm.extensions...)
+	return &clone
+}
+
 func (pm *baseChainMessageContainer) GetExtensions() []*spectypes.Extension {
 	return pm.extensions
 }
@@ -1,27 +1,75 @@
 package chainlib
 
-import "github.com/lavanet/lava/protocol/common"
+import (
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+	"github.com/lavanet/lava/protocol/common"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+var InvalidApiConfigurationError = sdkerrors.New("InvalidApiConfiguration Error", 1100, "chain message is missing its spec api configuration")
+
+// ValidateChainMessage makes sure the spec configuration the queries below rely on is set
+func ValidateChainMessage(chainMessage ChainMessage) error {
+	if chainMessage == nil {
+		return sdkerrors.Wrap(InvalidApiConfigurationError, "chain message is nil")
+	}
+	api := chainMessage.GetApi()
+	if api == nil {
+		return sdkerrors.Wrap(InvalidApiConfigurationError, "chain message has no api")
+	}
+	if chainMessage.GetApiCollection() == nil {
+		return sdkerrors.Wrapf(InvalidApiConfigurationError, "api %s has no api collection", api.Name)
+	}
+	return nil
+}
+
+// a missing api is treated like an api with an empty category, which is the safe default
+func getCategory(chainMessage ChainMessage) spectypes.SpecCategory {
+	api := chainMessage.GetApi()
+	if api == nil {
+		return spectypes.SpecCategory{}
+	}
+	return api.Category
+}
 
 func ShouldSendToAllProviders(chainMessage ChainMessage) bool {
-	return chainMessage.GetApi().Category.Stateful == common.CONSISTENCY_SELECT_ALLPROVIDERS
+	return getCategory(chainMessage).Stateful == common.CONSISTENCY_SELECT_ALLPROVIDERS
 }
 
 func GetAddon(chainMessage ChainMessage) string {
-	return chainMessage.GetApiCollection().CollectionData.AddOn
+	apiCollection := chainMessage.GetApiCollection()
+	if apiCollection == nil {
+		return ""
+	}
+	return apiCollection.CollectionData.AddOn
 }
 
 func IsSubscription(chainMessage ChainMessage) bool {
-	return chainMessage.GetApi().Category.Subscription
+	return getCategory(chainMessage).Subscription
+}
+
+// IsNotification returns true for a single jsonrpc request without an id, other interfaces and batches can't express
+// notifications and are sent as regular requests
+func IsNotification(chainMessage ChainMessageForSend) bool {
+	jsonrpcMessage, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+	return ok && jsonrpcMessage.IsNotification()
+}
+
+// IsReadOnly returns true for apis without side effects, unlike state changing apis sent to all providers and subscriptions
+func IsReadOnly(chainMessage ChainMessage) bool {
+	category := getCategory(chainMessage)
+	return category.Stateful == 0 && !category.Subscription
 }
 
 func IsHangingApi(chainMessage ChainMessage) bool {
-	return chainMessage.GetApi().Category.HangingApi
+	return getCategory(chainMessage).HangingApi
 }
 
 func GetComputeUnits(chainMessage ChainMessage) uint64 {
-	return chainMessage.GetApi().ComputeUnits
+	return chainMessage.GetApi().GetComputeUnits()
 }
 
 func GetStateful(chainMessage ChainMessage) uint32 {
-	return chainMessage.GetApi().Category.Stateful
+	return getCategory(chainMessage).Stateful
 }
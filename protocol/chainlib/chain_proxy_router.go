@@ -0,0 +1,221 @@
+package chainlib
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/metrics"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+var UnknownChainError = sdkerrors.New("UnknownChain Error", 1104, "no chain is registered for the requested chain id")
+
+// ChainProxyRouter lets a portal serve several chains from one listener, routing each request to the relay sender of
+// the chain it selects, with the chain id header or a /<chainID> path prefix. the relay senders are the consumer's
+// per chain servers, so routed requests are relayed through the lava protocol like any other request. the selector
+// is removed before the request is relayed
+type ChainProxyRouter struct {
+	lock   sync.RWMutex
+	chains map[string]RelaySender // chainID -> relay sender
+}
+
+func NewChainProxyRouter() *ChainProxyRouter {
+	return &ChainProxyRouter{chains: map[string]RelaySender{}}
+}
+
+// AddChain routes the requests selecting chainID to relaySender
+func (cpr *ChainProxyRouter) AddChain(chainID string, relaySender RelaySender) error {
+	if chainID == "" {
+		return utils.LavaFormatError("can't route to a chain without a chain id", nil)
+	}
+	cpr.lock.Lock()
+	defer cpr.lock.Unlock()
+	if _, ok := cpr.chains[chainID]; ok {
+		return utils.LavaFormatError("a relay sender is already registered for this chain", nil, utils.LogAttr("chainID", chainID))
+	}
+	cpr.chains[chainID] = relaySender
+	return nil
+}
+
+func (cpr *ChainProxyRouter) RemoveChain(chainID string) {
+	cpr.lock.Lock()
+	defer cpr.lock.Unlock()
+	delete(cpr.chains, chainID)
+}
+
+func (cpr *ChainProxyRouter) ChainIDs() []string {
+	cpr.lock.RLock()
+	defer cpr.lock.RUnlock()
+	chainIDs := make([]string, 0, len(cpr.chains))
+	for chainID := range cpr.chains {
+		chainIDs = append(chainIDs, chainID)
+	}
+	return chainIDs
+}
+
+// ResolveChain returns the chain a request selects with its relay sender, the url without the chain prefix and the
+// headers without the chain id header. the header takes precedence over the path prefix, the url is kept as is when
+// the header selects the chain
+func (cpr *ChainProxyRouter) ResolveChain(url string, headers []pairingtypes.Metadata) (chainID string, relaySender RelaySender, strippedUrl string, strippedHeaders []pairingtypes.Metadata, err error) {
+	cpr.lock.RLock()
+	defer cpr.lock.RUnlock()
+	for idx, header := range headers {
+		if strings.EqualFold(header.Name, common.CHAIN_ID_HEADER_NAME) {
+			relaySender, ok := cpr.chains[header.Value]
+			if !ok {
+				return "", nil, url, headers, utils.LavaFormatWarning("requested chain is not served", UnknownChainError, utils.LogAttr("chainID", header.Value))
+			}
+			strippedHeaders = append(append(strippedHeaders, headers[:idx]...), headers[idx+1:]...)
+			return header.Value, relaySender, url, strippedHeaders, nil
+		}
+	}
+	chainID, rest, _ := strings.Cut(strings.TrimPrefix(url, "/"), "/")
+	chainID, query, hasQuery := strings.Cut(chainID, "?")
+	relaySender, ok := cpr.chains[chainID]
+	if !ok {
+		return "", nil, url, headers, utils.LavaFormatWarning("request doesn't select a served chain", UnknownChainError, utils.LogAttr("url", url))
+	}
+	strippedUrl = "/" + rest
+	if hasQuery {
+		strippedUrl += "?" + query
+	}
+	return chainID, relaySender, strippedUrl, headers, nil
+}
+
+// SendRelay resolves the chain a request selects and relays it without the chain selector with that chain's relay
+// sender
+func (cpr *ChainProxyRouter) SendRelay(ctx context.Context, url string, req string, connectionType string, dappID string, consumerIp string, analytics *metrics.RelayMetrics, metadataValues []pairingtypes.Metadata) (*common.RelayResult, error) {
+	_, relaySender, strippedUrl, strippedHeaders, err := cpr.ResolveChain(url, metadataValues)
+	if err != nil {
+		return nil, err
+	}
+	return relaySender.SendRelay(ctx, strippedUrl, req, connectionType, dappID, consumerIp, analytics, strippedHeaders)
+}
+
+// IsHealthy reports whether every routed chain is healthy, the router isn't healthy before a chain is added
+func (cpr *ChainProxyRouter) IsHealthy() bool {
+	cpr.lock.RLock()
+	defer cpr.lock.RUnlock()
+	if len(cpr.chains) == 0 {
+		return false
+	}
+	for _, relaySender := range cpr.chains {
+		if healthReporter, ok := relaySender.(HealthReporter); ok && !healthReporter.IsHealthy() {
+			return false
+		}
+	}
+	return true
+}
+
+// ChainRouterListener serves the chains of a ChainProxyRouter over http, every chain is served with the same api
+// interface. websockets aren't supported
+type ChainRouterListener struct {
+	listenAddress string
+	router        *ChainProxyRouter
+	logger        *metrics.RPCConsumerLogs
+	// the api interface of the served chains, it sets how the request is relayed
+	apiInterface string
+}
+
+func NewChainRouterListener(listenAddress string, apiInterface string, router *ChainProxyRouter, rpcConsumerLogs *metrics.RPCConsumerLogs) (*ChainRouterListener, error) {
+	switch apiInterface {
+	case spectypes.APIInterfaceJsonRPC, spectypes.APIInterfaceTendermintRPC, spectypes.APIInterfaceRest:
+	default:
+		return nil, utils.LavaFormatError("the chain router doesn't support this api interface", nil, utils.LogAttr("apiInterface", apiInterface))
+	}
+	return &ChainRouterListener{listenAddress: listenAddress, router: router, logger: rpcConsumerLogs, apiInterface: apiInterface}, nil
+}
+
+// routedRelay returns the arguments a request is relayed with, the same ones the api interface's chain listener uses
+func (crl *ChainRouterListener) routedRelay(httpMethod string, url string, body string) (relayUrl string, req string, connectionType string, err error) {
+	switch crl.apiInterface {
+	case spectypes.APIInterfaceJsonRPC:
+		if httpMethod != http.MethodPost {
+			return "", "", "", fiber.ErrMethodNotAllowed
+		}
+		return "", body, http.MethodPost, nil
+	case spectypes.APIInterfaceTendermintRPC:
+		if httpMethod == http.MethodPost {
+			return "", body, "", nil
+		}
+		return url, "", "", nil
+	default:
+		if httpMethod == http.MethodPost {
+			return url, body, http.MethodPost, nil
+		}
+		return url, "", httpMethod, nil
+	}
+}
+
+func (crl *ChainRouterListener) handle(fiberCtx *fiber.Ctx) error {
+	fiberCtx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	startTime := time.Now()
+	endTx := crl.logger.LogStartTransaction("chain-router-http")
+	defer endTx()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	guid := utils.GenerateUniqueIdentifier()
+	ctx = utils.WithUniqueIdentifier(ctx, guid)
+	msgSeed := strconv.FormatUint(guid, 10)
+
+	url := "/" + fiberCtx.Params("*")
+	if query := fiberCtx.Request().URI().QueryString(); len(query) > 0 {
+		url += "?" + string(query)
+	}
+	chainID, relaySender, strippedUrl, headers, err := crl.router.ResolveChain(url, convertToMetadataMap(fiberCtx.GetReqHeaders()))
+	if err != nil {
+		fiberCtx.Status(fiber.StatusNotFound)
+		return fiberCtx.SendString(convertToJsonError(UnknownChainError.Error()))
+	}
+	relayUrl, req, connectionType, err := crl.routedRelay(fiberCtx.Method(), strippedUrl, string(fiberCtx.Body()))
+	if err != nil {
+		fiberCtx.Status(fiber.StatusMethodNotAllowed)
+		return fiberCtx.SendString(convertToJsonError("method not allowed"))
+	}
+	dappID := extractDappIDFromFiberContext(fiberCtx)
+	analytics := metrics.NewRelayAnalytics(dappID, chainID, crl.apiInterface)
+	utils.LavaFormatDebug("in <<<",
+		utils.LogAttr("GUID", ctx),
+		utils.LogAttr("seed", msgSeed),
+		utils.LogAttr("chainID", chainID),
+		utils.LogAttr("url", relayUrl),
+		utils.LogAttr("dappID", dappID),
+	)
+	relayResult, err := relaySender.SendRelay(ctx, relayUrl, req, connectionType, dappID, fiberCtx.Get(common.IP_FORWARDING_HEADER_NAME, fiberCtx.IP()), analytics, headers)
+	reply := relayResult.GetReply()
+	go crl.logger.AddMetricForHttp(analytics, err, fiberCtx.GetReqHeaders())
+	if err != nil {
+		errMasking := crl.logger.GetUniqueGuidResponseForError(err, msgSeed)
+		crl.logger.LogRequestAndResponse("chain router http", true, fiberCtx.Method(), url, req, errMasking, msgSeed, time.Since(startTime), err)
+		if relayResult.GetStatusCode() != 0 {
+			fiberCtx.Status(relayResult.StatusCode)
+		} else {
+			fiberCtx.Status(fiber.StatusInternalServerError)
+		}
+		return addHeadersAndSendString(fiberCtx, reply.GetMetadata(), convertToJsonError(errMasking))
+	}
+	crl.logger.LogRequestAndResponse("chain router http", false, fiberCtx.Method(), url, req, string(reply.GetData()), msgSeed, time.Since(startTime), nil)
+	if relayResult.GetStatusCode() != 0 {
+		fiberCtx.Status(relayResult.StatusCode)
+	}
+	return addHeadersAndSendString(fiberCtx, reply.GetMetadata(), string(reply.GetData()))
+}
+
+// Serve listens for requests of every routed chain
+func (crl *ChainRouterListener) Serve(ctx context.Context, cmdFlags common.ConsumerCmdFlags) {
+	if crl == nil {
+		return
+	}
+	app := createAndSetupBaseAppListener(cmdFlags, common.DEFAULT_HEALTH_PATH, crl.router)
+	app.All("/*", crl.handle)
+	ListenWithRetry(app, crl.listenAddress)
+}
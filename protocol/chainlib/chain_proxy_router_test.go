@@ -0,0 +1,115 @@
+package chainlib
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/metrics"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+// chainIDRelaySender replies with its chain id and the url, request and connection type it was sent
+type chainIDRelaySender struct {
+	chainID string
+	err     error
+}
+
+func (rs chainIDRelaySender) SendRelay(ctx context.Context, url string, req string, connectionType string, dappID string, consumerIp string, analytics *metrics.RelayMetrics, metadataValues []pairingtypes.Metadata) (*common.RelayResult, error) {
+	reply := &pairingtypes.RelayReply{Data: []byte(strings.Join([]string{rs.chainID, url, req, connectionType}, " ")), Metadata: metadataValues}
+	if rs.err != nil {
+		return &common.RelayResult{Reply: &pairingtypes.RelayReply{Metadata: []pairingtypes.Metadata{{Name: "Lava-Provider-Address", Value: "lava@provider"}}}, StatusCode: http.StatusTooManyRequests}, rs.err
+	}
+	return &common.RelayResult{Reply: reply, StatusCode: http.StatusAccepted}, nil
+}
+
+func TestChainProxyRouter(t *testing.T) {
+	ctx := context.Background()
+	router := NewChainProxyRouter()
+	require.False(t, router.IsHealthy())
+	require.NoError(t, router.AddChain("LAV1", chainIDRelaySender{chainID: "LAV1"}))
+	require.NoError(t, router.AddChain("LAV2", chainIDRelaySender{chainID: "LAV2"}))
+	require.Error(t, router.AddChain("LAV2", chainIDRelaySender{chainID: "LAV2"}))
+	require.Error(t, router.AddChain("", chainIDRelaySender{}))
+	require.ElementsMatch(t, []string{"LAV1", "LAV2"}, router.ChainIDs())
+	require.True(t, router.IsHealthy())
+
+	const apiPath = "/cosmos/base/tendermint/v1beta1/blocks/latest"
+	// path prefix, the chain's relay sender gets the url without it
+	chainID, _, strippedUrl, _, err := router.ResolveChain("/LAV2"+apiPath+"?a=1", nil)
+	require.NoError(t, err)
+	require.Equal(t, "LAV2", chainID)
+	require.Equal(t, apiPath+"?a=1", strippedUrl)
+	_, _, strippedUrl, _, err = router.ResolveChain("/LAV2?a=1", nil)
+	require.NoError(t, err)
+	require.Equal(t, "/?a=1", strippedUrl)
+	relayResult, err := router.SendRelay(ctx, "/LAV1"+apiPath, "", http.MethodGet, "", "", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "LAV1 "+apiPath+"  GET", string(relayResult.Reply.Data))
+
+	// the header takes precedence over the path and isn't relayed
+	chainIDHeader := pairingtypes.Metadata{Name: common.CHAIN_ID_HEADER_NAME, Value: "LAV2"}
+	_, _, _, strippedHeaders, err := router.ResolveChain(apiPath, []pairingtypes.Metadata{{Name: "a", Value: "1"}, chainIDHeader, {Name: "b", Value: "2"}})
+	require.NoError(t, err)
+	require.Equal(t, []pairingtypes.Metadata{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, strippedHeaders)
+	relayResult, err = router.SendRelay(ctx, apiPath, "", http.MethodGet, "", "", nil, []pairingtypes.Metadata{chainIDHeader})
+	require.NoError(t, err)
+	require.Equal(t, "LAV2 "+apiPath+"  GET", string(relayResult.Reply.Data))
+	require.Empty(t, relayResult.Reply.Metadata)
+
+	_, err = router.SendRelay(ctx, "/LAV1"+apiPath, "", http.MethodGet, "", "", nil, []pairingtypes.Metadata{{Name: common.CHAIN_ID_HEADER_NAME, Value: "BTC"}})
+	require.True(t, UnknownChainError.Is(err))
+	_, err = router.SendRelay(ctx, "/", "", http.MethodGet, "", "", nil, nil)
+	require.True(t, UnknownChainError.Is(err))
+
+	router.RemoveChain("LAV1")
+	_, err = router.SendRelay(ctx, "/LAV1"+apiPath, "", http.MethodGet, "", "", nil, nil)
+	require.True(t, UnknownChainError.Is(err))
+}
+
+func TestChainRouterListener(t *testing.T) {
+	router := NewChainProxyRouter()
+	require.NoError(t, router.AddChain("ETH1", chainIDRelaySender{chainID: "ETH1"}))
+	require.NoError(t, router.AddChain("FAIL", chainIDRelaySender{chainID: "FAIL", err: io.ErrUnexpectedEOF}))
+	logger, err := metrics.NewRPCConsumerLogs(nil, nil)
+	require.NoError(t, err)
+	_, err = NewChainRouterListener("", spectypes.APIInterfaceGrpc, router, logger)
+	require.Error(t, err)
+	listener, err := NewChainRouterListener("", spectypes.APIInterfaceJsonRPC, router, logger)
+	require.NoError(t, err)
+	app := fiber.New()
+	app.All("/*", listener.handle)
+	send := func(method string, url string, body string) (int, http.Header, string) {
+		resp, err := app.Test(httptest.NewRequest(method, url, strings.NewReader(body)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header, string(data)
+	}
+
+	const request = `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	// relayed as a jsonrpc post, keeping the relay's status code
+	status, _, body := send(http.MethodPost, "/ETH1", request)
+	require.Equal(t, http.StatusAccepted, status)
+	require.Equal(t, "ETH1  "+request+" POST", body)
+
+	status, _, body = send(http.MethodPost, "/BTC", request)
+	require.Equal(t, http.StatusNotFound, status)
+	require.NotContains(t, body, "BTC")
+	status, _, _ = send(http.MethodGet, "/ETH1", "")
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+
+	// relay errors are masked and keep the relay's status code and headers
+	status, header, body := send(http.MethodPost, "/FAIL", request)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, "lava@provider", header.Get("Lava-Provider-Address"))
+	require.Contains(t, body, "Error_GUID")
+}
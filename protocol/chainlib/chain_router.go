@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavasession"
@@ -89,7 +90,7 @@ func batchNodeUrlsByServices(rpcProviderEndpoint lavasession.RPCProviderEndpoint
 	return returnedBatch
 }
 
-func newChainRouter(ctx context.Context, nConns uint, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser, proxyConstructor func(context.Context, uint, lavasession.RPCProviderEndpoint, ChainParser) (ChainProxy, error)) (ChainRouter, error) {
+func newChainRouter(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser, proxyConstructor func(context.Context, uint, chainproxy.KeepaliveOptions, lavasession.RPCProviderEndpoint, ChainParser) (ChainProxy, error)) (ChainRouter, error) {
 	chainProxyRouter := map[lavasession.RouterKey][]chainRouterEntry{}
 
 	requiredMap := map[requirementSt]struct{}{}
@@ -117,7 +118,7 @@ func newChainRouter(ctx context.Context, nConns uint, rpcProviderEndpoint lavase
 			return allExtensionsRouterKey
 		}
 		routerKey := updateRouteCombinations(extensions, addons)
-		chainProxy, err := proxyConstructor(ctx, nConns, rpcProviderEndpointEntry, chainParser)
+		chainProxy, err := proxyConstructor(ctx, nConns, keepalive, rpcProviderEndpointEntry, chainParser)
 		if err != nil {
 			// TODO: allow some urls to be down
 			return nil, err
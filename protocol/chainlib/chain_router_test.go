@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavasession"
 	testcommon "github.com/lavanet/lava/testutil/common"
@@ -332,7 +333,7 @@ func TestChainRouter(t *testing.T) {
 				nodeUrls = append(nodeUrls, nodeUrl)
 			}
 			endpoint.NodeUrls = nodeUrls
-			_, err := GetChainRouter(ctx, 1, endpoint, chainParser)
+			_, err := GetChainRouter(ctx, 1, chainproxy.KeepaliveOptions{}, endpoint, chainParser)
 			if play.success {
 				require.NoError(t, err)
 			} else {
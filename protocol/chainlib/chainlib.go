@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
 	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
@@ -67,6 +68,7 @@ type ChainParser interface {
 	UpdateBlockTime(newBlockTime time.Duration)
 	GetUniqueName() string
 	ExtensionsParser() *extensionslib.ExtensionParser
+	SpecVersion() uint64
 }
 
 type ChainMessage interface {
@@ -80,6 +82,7 @@ type ChainMessage interface {
 	GetForceCacheRefresh() bool
 	SetForceCacheRefresh(force bool) bool
 	CheckResponseError(data []byte, httpStatusCode int) (hasError bool, errorMessage string)
+	Clone() ChainMessage
 
 	ChainMessageForSend
 }
@@ -121,8 +124,8 @@ type ChainProxy interface {
 	SendNodeMsg(ctx context.Context, ch chan interface{}, chainMessage ChainMessageForSend) (relayReply *pairingtypes.RelayReply, subscriptionID string, relayReplyServer *rpcclient.ClientSubscription, err error) // has to be thread safe, reuse code within ParseMsg as common functionality
 }
 
-func GetChainRouter(ctx context.Context, nConns uint, rpcProviderEndpoint *lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainRouter, error) {
-	var proxyConstructor func(context.Context, uint, lavasession.RPCProviderEndpoint, ChainParser) (ChainProxy, error)
+func GetChainRouter(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, rpcProviderEndpoint *lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainRouter, error) {
+	var proxyConstructor func(context.Context, uint, chainproxy.KeepaliveOptions, lavasession.RPCProviderEndpoint, ChainParser) (ChainProxy, error)
 	switch rpcProviderEndpoint.ApiInterface {
 	case spectypes.APIInterfaceJsonRPC:
 		proxyConstructor = NewJrpcChainProxy
@@ -135,5 +138,5 @@ func GetChainRouter(ctx context.Context, nConns uint, rpcProviderEndpoint *lavas
 	default:
 		return nil, fmt.Errorf("chain proxy for apiInterface (%s) not found", rpcProviderEndpoint.ApiInterface)
 	}
-	return newChainRouter(ctx, nConns, *rpcProviderEndpoint, chainParser, proxyConstructor)
+	return newChainRouter(ctx, nConns, keepalive, *rpcProviderEndpoint, chainParser, proxyConstructor)
 }
@@ -0,0 +1,140 @@
+package chainproxy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+)
+
+const (
+	WeightedNodeConnectionsFlag = "weighted-node-connections"
+	connectionLatencyDecay      = 0.2 // weight of a new latency sample in a connection's average
+)
+
+// spread requests over the pool's connections by their observed latency instead of using the first free one,
+// useful when a node url resolves to backends with different capacity
+var WeightedNodeConnections = false
+
+type balancedConnection struct {
+	id      int
+	weight  float64 // static weight
+	latency float64 // ewma of the node's response time on this connection, 0 until the first sample
+	current float64 // smooth weighted round robin state
+}
+
+// connectionBalancer picks the connection to use with smooth weighted round robin. a connection's effective weight is
+// its static weight scaled by the pool's average latency over its own, so a connection twice as fast gets twice the
+// requests. it's not thread safe, the connector's lock guards it
+type connectionBalancer[T comparable] struct {
+	connections   map[T]*balancedConnection
+	nextID        int
+	staticWeights []float64 // by connection id, connections past its end weigh 1
+}
+
+func newConnectionBalancer[T comparable](staticWeights []float64) *connectionBalancer[T] {
+	return &connectionBalancer[T]{connections: map[T]*balancedConnection{}, staticWeights: staticWeights}
+}
+
+// validateConnectionWeights rejects static weights that would starve a connection or break the round robin
+func validateConnectionWeights(nodeUrl common.NodeUrl) error {
+	for idx, weight := range nodeUrl.ConnectionWeights {
+		if weight <= 0 {
+			return fmt.Errorf("connection weights must be positive, connection %d of %s has weight %v", idx, nodeUrl.UrlStr(), weight)
+		}
+	}
+	return nil
+}
+
+func (cb *connectionBalancer[T]) get(conn T) *balancedConnection {
+	balanced, ok := cb.connections[conn]
+	if !ok {
+		balanced = &balancedConnection{id: cb.nextID, weight: 1}
+		if cb.nextID < len(cb.staticWeights) {
+			balanced.weight = cb.staticWeights[cb.nextID]
+		}
+		cb.nextID++
+		cb.connections[conn] = balanced
+	}
+	return balanced
+}
+
+func (cb *connectionBalancer[T]) remove(conn T) {
+	delete(cb.connections, conn)
+}
+
+func (cb *connectionBalancer[T]) averageLatency() float64 {
+	var sum float64
+	count := 0
+	for _, balanced := range cb.connections {
+		if balanced.latency > 0 {
+			sum += balanced.latency
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+func (cb *connectionBalancer[T]) effectiveWeight(balanced *balancedConnection, averageLatency float64) float64 {
+	if balanced.latency <= 0 || averageLatency <= 0 {
+		// not measured yet, treated as an average connection
+		return balanced.weight
+	}
+	return balanced.weight * averageLatency / balanced.latency
+}
+
+// pick returns the index of the candidate to use and marks it in use
+func (cb *connectionBalancer[T]) pick(candidates []T) int {
+	averageLatency := cb.averageLatency()
+	selected := -1
+	var selectedConnection *balancedConnection
+	var total float64
+	for idx, conn := range candidates {
+		balanced := cb.get(conn)
+		weight := cb.effectiveWeight(balanced, averageLatency)
+		balanced.current += weight
+		total += weight
+		if selectedConnection == nil || balanced.current > selectedConnection.current {
+			selected = idx
+			selectedConnection = balanced
+		}
+	}
+	if selectedConnection != nil {
+		selectedConnection.current -= total
+	}
+	return selected
+}
+
+// observe records the node's response time on a connection. failed calls are skipped so a connection failing fast
+// doesn't look fast, timeouts are kept since they are the slowest answer
+func (cb *connectionBalancer[T]) observe(conn T, latency time.Duration, err error) {
+	if err != nil && !common.IsTimeout(err) {
+		return
+	}
+	balanced, ok := cb.connections[conn]
+	if !ok {
+		return
+	}
+	cb.observeLatency(balanced, latency)
+}
+
+func (cb *connectionBalancer[T]) observeLatency(balanced *balancedConnection, latency time.Duration) {
+	if balanced.latency == 0 {
+		balanced.latency = float64(latency)
+		return
+	}
+	balanced.latency = (1-connectionLatencyDecay)*balanced.latency + connectionLatencyDecay*float64(latency)
+}
+
+// effectiveWeights returns the current effective weight per connection id
+func (cb *connectionBalancer[T]) effectiveWeights() map[int]float64 {
+	averageLatency := cb.averageLatency()
+	weights := make(map[int]float64, len(cb.connections))
+	for _, balanced := range cb.connections {
+		weights[balanced.id] = cb.effectiveWeight(balanced, averageLatency)
+	}
+	return weights
+}
@@ -0,0 +1,59 @@
+package chainproxy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionBalancerStaticWeights(t *testing.T) {
+	// connections take the configured weights in the order they are added, the ones past the list weigh 1
+	balancer := newConnectionBalancer[string]([]float64{1, 2, 3})
+	candidates := []string{"a", "b", "c", "d"}
+	for _, conn := range candidates {
+		balancer.get(conn)
+	}
+	picks := map[string]int{}
+	for i := 0; i < 700; i++ {
+		picks[candidates[balancer.pick(candidates)]]++
+	}
+	require.Equal(t, map[string]int{"a": 100, "b": 200, "c": 300, "d": 100}, picks)
+
+	require.Error(t, validateConnectionWeights(common.NodeUrl{Url: "http://node", ConnectionWeights: []float64{1, 0}}))
+	require.NoError(t, validateConnectionWeights(common.NodeUrl{Url: "http://node", ConnectionWeights: []float64{1, 0.5}}))
+}
+
+func TestConnectionBalancerLatencyWeights(t *testing.T) {
+	balancer := newConnectionBalancer[string](nil)
+	candidates := []string{"fast", "slow"}
+	balancer.get("fast")
+	balancer.get("slow")
+	balancer.observe("fast", 10*time.Millisecond, nil)
+	balancer.observe("slow", 20*time.Millisecond, nil)
+	// a call failing fast says nothing about the node's latency
+	balancer.observe("slow", time.Microsecond, fmt.Errorf("connection refused"))
+	// unknown connections aren't tracked
+	balancer.observe("gone", time.Millisecond, nil)
+
+	weights := balancer.effectiveWeights()
+	require.InDelta(t, 2*weights[balancer.get("slow").id], weights[balancer.get("fast").id], 0.0001)
+
+	picks := map[string]int{}
+	for i := 0; i < 300; i++ {
+		picks[candidates[balancer.pick(candidates)]]++
+	}
+	require.Equal(t, map[string]int{"fast": 200, "slow": 100}, picks)
+
+	// only the free connections are candidates
+	require.Equal(t, 0, balancer.pick([]string{"slow"}))
+	require.Equal(t, -1, balancer.pick(nil))
+
+	// unmeasured connections count as average
+	balancer.get("new")
+	require.Equal(t, float64(1), balancer.effectiveWeights()[balancer.get("new").id])
+	balancer.remove("new")
+	require.Len(t, balancer.effectiveWeights(), 2)
+}
@@ -11,6 +11,7 @@ import (
 	"crypto/x509"
 	"errors"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
 	"sync"
@@ -40,13 +41,20 @@ type Connector struct {
 	freeClients []*rpcclient.Client
 	usedClients int64
 	nodeUrl     common.NodeUrl
+	balancer    *connectionBalancer[*rpcclient.Client]
+	transport   *http.Transport
 }
 
-func NewConnector(ctx context.Context, nConns uint, nodeUrl common.NodeUrl) (*Connector, error) {
+func NewConnector(ctx context.Context, nConns uint, keepalive KeepaliveOptions, nodeUrl common.NodeUrl) (*Connector, error) {
+	if err := validateConnectionWeights(nodeUrl); err != nil {
+		return nil, err
+	}
 	NumberOfParallelConnections = nConns // set number of parallel connections requested by user (or default.)
 	connector := &Connector{
 		freeClients: make([]*rpcclient.Client, 0, nConns),
 		nodeUrl:     nodeUrl,
+		balancer:    newConnectionBalancer[*rpcclient.Client](nodeUrl.ConnectionWeights),
+		transport:   keepalive.HttpTransport(common.OutboundProxyTransport(nodeUrl.OutboundProxy())),
 	}
 
 	rpcClient, err := connector.createConnection(ctx, nodeUrl, connector.numberOfFreeClients())
@@ -78,6 +86,7 @@ func addClientsAsynchronously(ctx context.Context, connector *Connector, nConns
 func (connector *Connector) addClient(client *rpcclient.Client) {
 	connector.lock.Lock()
 	defer connector.lock.Unlock()
+	connector.balancer.get(client) // connections take their static weight in the order they are added
 	connector.freeClients = append(connector.freeClients, client)
 }
 
@@ -87,6 +96,20 @@ func (connector *Connector) numberOfFreeClients() int {
 	return len(connector.freeClients)
 }
 
+// EffectiveWeights returns the current weight of each connection by its id, for observability
+func (connector *Connector) EffectiveWeights() map[int]float64 {
+	connector.lock.RLock()
+	defer connector.lock.RUnlock()
+	return connector.balancer.effectiveWeights()
+}
+
+// ObserveLatency records how long the node took to answer a call on the connection, it weighs the connection
+func (connector *Connector) ObserveLatency(rpc *rpcclient.Client, latency time.Duration, err error) {
+	connector.lock.Lock()
+	defer connector.lock.Unlock()
+	connector.balancer.observe(rpc, latency, err)
+}
+
 func (connector *Connector) numberOfUsedClients() int {
 	return int(atomic.LoadInt64(&connector.usedClients))
 }
@@ -109,7 +132,7 @@ func (connector *Connector) createConnection(ctx context.Context, nodeUrl common
 		timeout := common.AverageWorldLatency * (1 + time.Duration(numberOfConnectionAttempts))
 		nctx, cancel := nodeUrl.LowerContextTimeoutWithDuration(ctx, timeout)
 		// add auth path
-		rpcClient, err = rpcclient.DialContext(nctx, nodeUrl.AuthConfig.AddAuthPath(nodeUrl.Url))
+		rpcClient, err = rpcclient.DialContextWithTransport(nctx, nodeUrl.AuthConfig.AddAuthPath(nodeUrl.Url), connector.transport, nodeUrl.CheckRedirect)
 		if err != nil {
 			utils.LavaFormatWarning("Could not connect to the node, retrying", err, []utils.Attribute{
 				{Key: "Current Number Of Connections", Value: currentNumberOfConnections},
@@ -141,6 +164,7 @@ func (connector *Connector) Close() {
 			connector.freeClients[i].Close()
 		}
 		connector.freeClients = []*rpcclient.Client{}
+		connector.balancer = newConnectionBalancer[*rpcclient.Client](connector.nodeUrl.ConnectionWeights)
 
 		if connector.usedClients > 0 {
 			if i > 10 {
@@ -161,7 +185,7 @@ func (connector *Connector) increaseNumberOfClients(ctx context.Context, numberO
 	var err error
 	for connectionAttempt := 0; connectionAttempt < MaximumNumberOfParallelConnectionsAttempts; connectionAttempt++ {
 		nctx, cancel := connector.nodeUrl.LowerContextTimeoutWithDuration(ctx, common.AverageWorldLatency*2)
-		rpcClient, err = rpcclient.DialContext(nctx, connector.nodeUrl.Url)
+		rpcClient, err = rpcclient.DialContextWithTransport(nctx, connector.nodeUrl.Url, connector.transport, connector.nodeUrl.CheckRedirect)
 		if err != nil {
 			utils.LavaFormatDebug(
 				"could no increase number of connections to the node jsonrpc connector, retrying",
@@ -206,8 +230,12 @@ func (connector *Connector) GetRpc(ctx context.Context, block bool) (*rpcclient.
 		}
 	}
 
-	ret := connector.freeClients[0]
-	connector.freeClients = connector.freeClients[1:]
+	index := 0
+	if WeightedNodeConnections {
+		index = connector.balancer.pick(connector.freeClients)
+	}
+	ret := connector.freeClients[index]
+	connector.freeClients = append(connector.freeClients[:index], connector.freeClients[index+1:]...)
 	connector.usedClients++
 
 	return ret, nil
@@ -219,6 +247,7 @@ func (connector *Connector) ReturnRpc(rpc *rpcclient.Client) {
 
 	connector.usedClients--
 	if len(connector.freeClients) > (int(connector.usedClients) + int(NumberOfParallelConnections) /* the number we started with */) {
+		connector.balancer.remove(rpc)
 		rpc.Close() // close connection
 		return      // return without appending back to decrease idle connections
 	}
@@ -231,13 +260,20 @@ type GRPCConnector struct {
 	usedClients int64
 	credentials credentials.TransportCredentials
 	nodeUrl     common.NodeUrl
+	balancer    *connectionBalancer[*grpc.ClientConn]
+	keepalive   KeepaliveOptions
 }
 
-func NewGRPCConnector(ctx context.Context, nConns uint, nodeUrl common.NodeUrl) (*GRPCConnector, error) {
+func NewGRPCConnector(ctx context.Context, nConns uint, keepalive KeepaliveOptions, nodeUrl common.NodeUrl) (*GRPCConnector, error) {
+	if err := validateConnectionWeights(nodeUrl); err != nil {
+		return nil, err
+	}
 	NumberOfParallelConnections = nConns // set number of parallel connections requested by user (or default.)
 	connector := &GRPCConnector{
 		freeClients: make([]*grpc.ClientConn, 0, nConns),
 		nodeUrl:     nodeUrl,
+		balancer:    newConnectionBalancer[*grpc.ClientConn](nodeUrl.ConnectionWeights),
+		keepalive:   keepalive,
 	}
 
 	rpcClient, err := connector.createConnection(ctx, nodeUrl, connector.numberOfFreeClients())
@@ -301,6 +337,11 @@ func (connector *GRPCConnector) getTransportCredentials() grpc.DialOption {
 	return grpc.WithTransportCredentials(insecure.NewCredentials())
 }
 
+func (connector *GRPCConnector) dialOptions(transportCredentials grpc.DialOption) []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithBlock(), grpc.WithContextDialer(common.OutboundProxyDialer(connector.nodeUrl.OutboundProxy())), transportCredentials, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxCallRecvMsgSize))}
+	return append(opts, connector.keepalive.GrpcDialOptions()...)
+}
+
 func (connector *GRPCConnector) increaseNumberOfClients(ctx context.Context, numberOfFreeClients int) {
 	utils.LavaFormatDebug("increasing number of clients", utils.Attribute{Key: "numberOfFreeClients", Value: numberOfFreeClients},
 		utils.Attribute{Key: "url", Value: connector.nodeUrl.Url})
@@ -308,7 +349,7 @@ func (connector *GRPCConnector) increaseNumberOfClients(ctx context.Context, num
 	var err error
 	for connectionAttempt := 0; connectionAttempt < MaximumNumberOfParallelConnectionsAttempts; connectionAttempt++ {
 		nctx, cancel := connector.nodeUrl.LowerContextTimeoutWithDuration(ctx, common.AverageWorldLatency*2)
-		grpcClient, err = grpc.DialContext(nctx, connector.nodeUrl.Url, grpc.WithBlock(), connector.getTransportCredentials(), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxCallRecvMsgSize)))
+		grpcClient, err = grpc.DialContext(nctx, connector.nodeUrl.Url, connector.dialOptions(connector.getTransportCredentials())...)
 		if err != nil {
 			utils.LavaFormatDebug("increaseNumberOfClients, Could not connect to the node, retrying", []utils.Attribute{{Key: "err", Value: err.Error()}, {Key: "Number Of Attempts", Value: connectionAttempt}, {Key: "nodeUrl", Value: connector.nodeUrl.UrlStr()}}...)
 			cancel()
@@ -352,9 +393,13 @@ func (connector *GRPCConnector) GetRpc(ctx context.Context, block bool) (*grpc.C
 		}
 	}
 
-	ret := connector.freeClients[0]
+	index := 0
+	if WeightedNodeConnections {
+		index = connector.balancer.pick(connector.freeClients)
+	}
+	ret := connector.freeClients[index]
 	connector.usedClients++
-	connector.freeClients = connector.freeClients[1:]
+	connector.freeClients = append(connector.freeClients[:index], connector.freeClients[index+1:]...)
 
 	return ret, nil
 }
@@ -365,6 +410,7 @@ func (connector *GRPCConnector) ReturnRpc(rpc *grpc.ClientConn) {
 
 	connector.usedClients--
 	if len(connector.freeClients) > (int(connector.usedClients) + int(NumberOfParallelConnections) /* the number we started with */) {
+		connector.balancer.remove(rpc)
 		rpc.Close() // close connection
 		return      // return without appending back to decrease idle connections
 	}
@@ -384,6 +430,7 @@ func (connector *GRPCConnector) Close() {
 			connector.freeClients[i].Close()
 		}
 		connector.freeClients = []*grpc.ClientConn{}
+		connector.balancer = newConnectionBalancer[*grpc.ClientConn](connector.nodeUrl.ConnectionWeights)
 
 		if connector.usedClients > 0 {
 			if i > 10 {
@@ -417,6 +464,7 @@ func addClientsAsynchronouslyGrpc(ctx context.Context, connector *GRPCConnector,
 func (connector *GRPCConnector) addClient(client *grpc.ClientConn) {
 	connector.lock.Lock()
 	defer connector.lock.Unlock()
+	connector.balancer.get(client) // connections take their static weight in the order they are added
 	connector.freeClients = append(connector.freeClients, client)
 }
 
@@ -426,6 +474,20 @@ func (connector *GRPCConnector) numberOfFreeClients() int {
 	return len(connector.freeClients)
 }
 
+// EffectiveWeights returns the current weight of each connection by its id, for observability
+func (connector *GRPCConnector) EffectiveWeights() map[int]float64 {
+	connector.lock.RLock()
+	defer connector.lock.RUnlock()
+	return connector.balancer.effectiveWeights()
+}
+
+// ObserveLatency records how long the node took to answer a call on the connection, it weighs the connection
+func (connector *GRPCConnector) ObserveLatency(conn *grpc.ClientConn, latency time.Duration, err error) {
+	connector.lock.Lock()
+	defer connector.lock.Unlock()
+	connector.balancer.observe(conn, latency, err)
+}
+
 func (connector *GRPCConnector) numberOfUsedClients() int {
 	return int(atomic.LoadInt64(&connector.usedClients))
 }
@@ -456,7 +518,7 @@ func (connector *GRPCConnector) createConnection(ctx context.Context, nodeUrl co
 			return nil, ctx.Err()
 		}
 		nctx, cancel := connector.nodeUrl.LowerContextTimeoutWithDuration(ctx, common.AverageWorldLatency*2)
-		rpcClient, err = grpc.DialContext(nctx, addr, grpc.WithBlock(), connector.getTransportCredentials(), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxCallRecvMsgSize)))
+		rpcClient, err = grpc.DialContext(nctx, addr, connector.dialOptions(connector.getTransportCredentials())...)
 		cancel()
 		if err == nil {
 			return rpcClient, nil
@@ -470,7 +532,7 @@ func (connector *GRPCConnector) createConnection(ctx context.Context, nodeUrl co
 			}
 			nctx, cancel := connector.nodeUrl.LowerContextTimeoutWithDuration(ctx, common.AverageWorldLatency*2)
 			var errNew error
-			rpcClient, errNew = grpc.DialContext(nctx, addr, grpc.WithBlock(), grpc.WithTransportCredentials(credentialsToConnect), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxCallRecvMsgSize)))
+			rpcClient, errNew = grpc.DialContext(nctx, addr, connector.dialOptions(grpc.WithTransportCredentials(credentialsToConnect))...)
 			cancel()
 			if errNew == nil {
 				// this means our endpoint is TLS, and we support upgrading even if the config didn't explicitly say it
@@ -2,17 +2,25 @@ package chainproxy
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/rpc"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
+
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
 	"github.com/lavanet/lava/protocol/common"
 	pb_pkg "github.com/lavanet/lava/x/spec/types"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
@@ -88,7 +96,7 @@ func TestConnector(t *testing.T) {
 	listener := createRPCServer() // create a grpcServer so we can connect to its endpoint and validate everything works.
 	defer listener.Close()
 	ctx := context.Background()
-	conn, err := NewConnector(ctx, numberOfClients, common.NodeUrl{Url: listenerAddressTcp})
+	conn, err := NewConnector(ctx, numberOfClients, KeepaliveOptions{}, common.NodeUrl{Url: listenerAddressTcp})
 	require.NoError(t, err)
 	for { // wait for the routine to finish connecting
 		if len(conn.freeClients) == numberOfClients {
@@ -115,7 +123,7 @@ func TestConnectorGrpc(t *testing.T) {
 	server := createGRPCServer(t) // create a grpcServer so we can connect to its endpoint and validate everything works.
 	defer server.Stop()
 	ctx := context.Background()
-	conn, err := NewGRPCConnector(ctx, numberOfClients, common.NodeUrl{Url: listenerAddress})
+	conn, err := NewGRPCConnector(ctx, numberOfClients, KeepaliveOptions{}, common.NodeUrl{Url: listenerAddress})
 	require.NoError(t, err)
 	for { // wait for the routine to finish connecting
 		if len(conn.freeClients) == numberOfClients {
@@ -142,7 +150,7 @@ func TestConnectorGrpcAndInvoke(t *testing.T) {
 	server := createGRPCServerWithRegisteredProto(t) // create a grpcServer so we can connect to its endpoint and validate everything works.
 	defer server.Stop()
 	ctx := context.Background()
-	conn, err := NewGRPCConnector(ctx, numberOfClients, common.NodeUrl{Url: listenerAddress})
+	conn, err := NewGRPCConnector(ctx, numberOfClients, KeepaliveOptions{}, common.NodeUrl{Url: listenerAddress})
 	require.NoError(t, err)
 	for { // wait for the routine to finish connecting
 		if len(conn.freeClients) == numberOfClients {
@@ -167,3 +175,103 @@ func TestConnectorGrpcAndInvoke(t *testing.T) {
 	}
 	require.Equal(t, int(conn.usedClients), 0) // checking we dont have clients used
 }
+
+// stubProxy tunnels CONNECT requests and forwards plain http ones, recording the targets
+type stubProxy struct {
+	*httptest.Server
+	lock    sync.Mutex
+	targets []string
+}
+
+func newStubProxy(t *testing.T) *stubProxy {
+	proxy := &stubProxy{}
+	proxy.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxy.lock.Lock()
+		proxy.targets = append(proxy.targets, r.Method+" "+r.Host)
+		proxy.lock.Unlock()
+		if r.Method != http.MethodConnect {
+			r.RequestURI = ""
+			response, err := http.DefaultTransport.RoundTrip(r)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			defer response.Body.Close()
+			w.WriteHeader(response.StatusCode)
+			io.Copy(w, response.Body)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		client, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			io.Copy(target, client)
+			target.Close()
+		}()
+		go func() {
+			io.Copy(client, target)
+			client.Close()
+		}()
+	}))
+	return proxy
+}
+
+func (sp *stubProxy) proxied(target string) bool {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	return slices.Contains(sp.targets, target)
+}
+
+func TestConnectorOutboundProxy(t *testing.T) {
+	ctx := context.Background()
+	proxy := newStubProxy(t)
+	defer proxy.Close()
+
+	t.Run("http", func(t *testing.T) {
+		node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+		}))
+		defer node.Close()
+		conn, err := NewConnector(ctx, 1, KeepaliveOptions{}, common.NodeUrl{Url: node.URL, Proxy: proxy.URL})
+		require.NoError(t, err)
+		rpc, err := conn.GetRpc(ctx, true)
+		require.NoError(t, err)
+		defer conn.ReturnRpc(rpc)
+		reply, err := rpc.CallContext(ctx, []byte("1"), "eth_blockNumber", nil, true, true)
+		require.NoError(t, err)
+		require.Equal(t, `"0x10"`, string(reply.Result))
+		require.True(t, proxy.proxied(http.MethodPost+" "+strings.TrimPrefix(node.URL, "http://")))
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		upgrader := websocket.Upgrader{}
+		node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ws, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				ws.Close()
+			}
+		}))
+		defer node.Close()
+		nodeAddress := strings.TrimPrefix(node.URL, "http://")
+		client, err := rpcclient.DialContextWithTransport(ctx, "ws://"+nodeAddress, common.OutboundProxyTransport(proxy.URL), nil)
+		require.NoError(t, err)
+		client.Close()
+		require.True(t, proxy.proxied(http.MethodConnect+" "+nodeAddress))
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		server := createGRPCServer(t)
+		defer server.Stop()
+		conn, err := NewGRPCConnector(ctx, 1, KeepaliveOptions{}, common.NodeUrl{Url: listenerAddress, Proxy: proxy.URL})
+		require.NoError(t, err)
+		rpc, err := conn.GetRpc(ctx, true)
+		require.NoError(t, err)
+		conn.ReturnRpc(rpc)
+		require.True(t, proxy.proxied(http.MethodConnect+" "+listenerAddress))
+	})
+}
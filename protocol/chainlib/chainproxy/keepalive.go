@@ -0,0 +1,62 @@
+package chainproxy
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	NodeKeepaliveIntervalFlag = "node-keepalive-interval"
+	NodeKeepaliveTimeoutFlag  = "node-keepalive-timeout"
+	NodeMaxIdleFlag           = "node-max-idle"
+)
+
+// KeepaliveOptions configures how the connections to a node are kept warm. each of the nConns pooled connections is
+// kept alive on its own, so a pool pings the node nConns times per interval. the zero value keeps the defaults
+type KeepaliveOptions struct {
+	// ping idle connections this often so intermediaries don't silently drop them, 0 disables the pings. http
+	// connections use it as their tcp keepalive period
+	Interval time.Duration
+	// a grpc connection not answering a ping within this duration is closed and redialed
+	Timeout time.Duration
+	// connections without requests for this long are closed and reconnect lazily, 0 keeps the default
+	MaxIdle time.Duration
+}
+
+// the provider's node connections, set by its flags
+var NodeKeepalive = KeepaliveOptions{}
+
+// GrpcDialOptions returns the dial options applying the keepalive to a grpc connection
+func (ko KeepaliveOptions) GrpcDialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{}
+	if ko.Interval > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                ko.Interval,
+			Timeout:             ko.Timeout,
+			PermitWithoutStream: true, // idle connections are the ones intermediaries kill
+		}))
+	}
+	if ko.MaxIdle > 0 {
+		opts = append(opts, grpc.WithIdleTimeout(ko.MaxIdle))
+	}
+	return opts
+}
+
+// HttpTransport returns a copy of transport applying the keepalive, transport itself when there's nothing to apply
+func (ko KeepaliveOptions) HttpTransport(transport *http.Transport) *http.Transport {
+	if ko.Interval <= 0 && ko.MaxIdle <= 0 {
+		return transport
+	}
+	transport = transport.Clone()
+	if ko.Interval > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: ko.Interval}).DialContext
+	}
+	if ko.MaxIdle > 0 {
+		transport.IdleConnTimeout = ko.MaxIdle
+	}
+	return transport
+}
@@ -0,0 +1,26 @@
+package chainproxy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepaliveOptions(t *testing.T) {
+	base := http.DefaultTransport.(*http.Transport)
+
+	// the zero value keeps the defaults
+	require.Empty(t, KeepaliveOptions{}.GrpcDialOptions())
+	require.Same(t, base, KeepaliveOptions{}.HttpTransport(base))
+
+	keepalive := KeepaliveOptions{Interval: time.Minute, Timeout: 10 * time.Second, MaxIdle: 5 * time.Minute}
+	require.Len(t, keepalive.GrpcDialOptions(), 2)
+	transport := keepalive.HttpTransport(base)
+	require.NotSame(t, base, transport)
+	require.Equal(t, 5*time.Minute, transport.IdleConnTimeout)
+	require.NotNil(t, transport.DialContext)
+	// the shared transport isn't modified
+	require.NotEqual(t, 5*time.Minute, base.IdleConnTimeout)
+}
@@ -2,6 +2,7 @@ package mock_test
 
 import (
 	"context"
+	"time"
 
 	"google.golang.org/grpc"
 )
@@ -21,6 +22,9 @@ func (mc *MockGRPCConnector) Close() {
 func (mc *MockGRPCConnector) ReturnRpc(rpc *grpc.ClientConn) {
 }
 
+func (mc *MockGRPCConnector) ObserveLatency(rpc *grpc.ClientConn, latency time.Duration, err error) {
+}
+
 func (mc *MockGRPCConnector) GetRpc(ctx context.Context, block bool) (*grpc.ClientConn, error) {
 	return mc.conn, nil
 }
@@ -21,10 +21,15 @@ type JsonrpcMessage struct {
 	Error                  *rpcclient.JsonError `json:"error,omitempty"`
 	Result                 json.RawMessage      `json:"result,omitempty"`
 	chainproxy.BaseMessage `json:"-"`
+	notification           bool
 }
 
 // returns if error exists and
 func (jm JsonrpcMessage) CheckResponseError(data []byte, httpStatusCode int) (hasError bool, errorMessage string) {
+	if len(data) == 0 {
+		// notifications have no reply
+		return false, ""
+	}
 	result := &JsonrpcMessage{}
 	err := json.Unmarshal(data, result)
 	if err != nil {
@@ -37,6 +42,21 @@ func (jm JsonrpcMessage) CheckResponseError(data []byte, httpStatusCode int) (ha
 	return result.Error.Message != "", result.Error.Message
 }
 
+// IsNotification returns true for a request without an id, the node doesn't reply to it
+func (jm JsonrpcMessage) IsNotification() bool {
+	return jm.notification
+}
+
+// a null id unmarshals like a missing one but it's a request that expects a reply
+func hasIdMember(data []byte) bool {
+	members := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return true
+	}
+	_, ok := members["id"]
+	return ok
+}
+
 func ConvertJsonRPCMsg(rpcMsg *rpcclient.JsonrpcMessage) (*JsonrpcMessage, error) {
 	// Return an error if the message was not sent
 	if rpcMsg == nil {
@@ -60,12 +80,9 @@ func ConvertJsonRPCMsg(rpcMsg *rpcclient.JsonrpcMessage) (*JsonrpcMessage, error
 
 func ConvertBatchElement(batchElement rpcclient.BatchElemWithId) (JsonrpcMessage, error) {
 	var JsonError *rpcclient.JsonError
-	var ok bool
 	if batchElement.Error != nil {
-		JsonError, ok = batchElement.Error.(*rpcclient.JsonError)
-		if !ok {
-			return JsonrpcMessage{}, batchElement.Error
-		}
+		// element errors that aren't json-rpc errors (e.g. an unparsable result) are attached to this element only
+		JsonError = rpcclient.ToJsonError(batchElement.Error)
 	}
 	var result json.RawMessage
 	if batchElement.Result != nil {
@@ -137,6 +154,7 @@ func ParseJsonRPCMsg(data []byte) (msgRet []JsonrpcMessage, err error) {
 		}
 		return batch, nil
 	}
+	msg.notification = msg.ID == nil && !hasIdMember(data)
 	return []JsonrpcMessage{msg}, nil
 }
 
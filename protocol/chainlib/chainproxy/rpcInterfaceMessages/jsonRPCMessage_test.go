@@ -2,6 +2,7 @@ package rpcInterfaceMessages
 
 import (
 	"encoding/json"
+	"net/http"
 	"testing"
 
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
@@ -141,3 +142,20 @@ func TestParseJsonRPCBatch(t *testing.T) {
 		}
 	}
 }
+
+func TestParseJsonRPCNotification(t *testing.T) {
+	msgs, err := ParseJsonRPCMsg([]byte(`{"jsonrpc": "2.0", "method": "eth_subscribe", "params": []}`))
+	require.NoError(t, err)
+	require.True(t, msgs[0].IsNotification())
+	// a null id still expects a reply
+	msgs, err = ParseJsonRPCMsg([]byte(`{"jsonrpc": "2.0", "id": null, "method": "eth_subscribe", "params": []}`))
+	require.NoError(t, err)
+	require.False(t, msgs[0].IsNotification())
+	msgs, err = ParseJsonRPCMsg([]byte(`{"jsonrpc": "2.0", "id": 1, "method": "eth_subscribe", "params": []}`))
+	require.NoError(t, err)
+	require.False(t, msgs[0].IsNotification())
+
+	// the empty reply of a notification isn't a node error
+	hasError, _ := msgs[0].CheckResponseError(nil, http.StatusNoContent)
+	require.False(t, hasError)
+}
@@ -0,0 +1,70 @@
+package rpcInterfaceMessages
+
+import (
+	"fmt"
+	"strings"
+)
+
+// in units of the api's extra compute units
+const (
+	StateOverrideAccountComputeUnits   = 10 // per overridden account
+	StateOverrideSlotComputeUnits      = 1  // per overridden storage slot
+	StateOverrideCodeComputeUnitsPerKb = 5  // per started kilobyte of overridden code
+)
+
+// methods accepting a state override object, and its position in the ordered params
+var stateOverrideParamIndex = map[string]int{
+	"eth_call": 2,
+}
+
+// StateOverrideComputeUnits returns the size of the message's state override object, in units of the api's extra
+// compute units, 0 when there's none. the node executes the call against the modified state so the cost grows with the
+// size of the override. the override is only validated here, it's sent to the node as is, fields that don't add to the
+// cost, including ones added by newer nodes, are left for the node to check
+func (jm JsonrpcMessage) StateOverrideComputeUnits() (uint64, error) {
+	paramIndex, ok := stateOverrideParamIndex[jm.Method]
+	if !ok {
+		return 0, nil
+	}
+	params, ok := jm.Params.([]interface{})
+	if !ok || len(params) <= paramIndex || params[paramIndex] == nil {
+		return 0, nil
+	}
+	override, ok := params[paramIndex].(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("invalid state override in %s, expected an object of accounts, got %T", jm.Method, params[paramIndex])
+	}
+	var computeUnits uint64
+	for address, accountOverride := range override {
+		account, ok := accountOverride.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("invalid state override for account %s in %s, expected an object", address, jm.Method)
+		}
+		computeUnits += StateOverrideAccountComputeUnits
+		_, hasState := account["state"]
+		_, hasStateDiff := account["stateDiff"]
+		if hasState && hasStateDiff {
+			return 0, fmt.Errorf("invalid state override for account %s in %s, state and stateDiff are mutually exclusive", address, jm.Method)
+		}
+		for _, field := range []string{"state", "stateDiff"} {
+			slots, ok := account[field]
+			if !ok {
+				continue
+			}
+			slotsMap, ok := slots.(map[string]interface{})
+			if !ok {
+				return 0, fmt.Errorf("invalid state override for account %s in %s, %s must be an object of slots", address, jm.Method, field)
+			}
+			computeUnits += uint64(len(slotsMap)) * StateOverrideSlotComputeUnits
+		}
+		if code, ok := account["code"]; ok {
+			codeHex, ok := code.(string)
+			if !ok {
+				return 0, fmt.Errorf("invalid state override for account %s in %s, code must be a hex string", address, jm.Method)
+			}
+			codeBytes := uint64(len(strings.TrimPrefix(codeHex, "0x")) / 2)
+			computeUnits += (codeBytes + 1023) / 1024 * StateOverrideCodeComputeUnitsPerKb
+		}
+	}
+	return computeUnits, nil
+}
@@ -0,0 +1,65 @@
+package rpcInterfaceMessages
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateOverrideComputeUnits(t *testing.T) {
+	call := map[string]interface{}{"to": "0x00000000000000000000000000000000000000aa", "data": "0x"}
+	playbook := []struct {
+		name       string
+		msg        JsonrpcMessage
+		expectedCu uint64
+		valid      bool
+	}{
+		{name: "no override", msg: JsonrpcMessage{Method: "eth_call", Params: []interface{}{call, "latest"}}, valid: true},
+		{name: "null override", msg: JsonrpcMessage{Method: "eth_call", Params: []interface{}{call, "latest", nil}}, valid: true},
+		{name: "other method", msg: JsonrpcMessage{Method: "eth_getBalance", Params: []interface{}{"0xaa", "latest", "x"}}, valid: true},
+		{
+			name: "balance override",
+			msg: JsonrpcMessage{Method: "eth_call", Params: []interface{}{call, "latest", map[string]interface{}{
+				"0xaa": map[string]interface{}{"balance": "0x1"},
+			}}},
+			expectedCu: StateOverrideAccountComputeUnits,
+			valid:      true,
+		},
+		{
+			name: "slots and code",
+			msg: JsonrpcMessage{Method: "eth_call", Params: []interface{}{call, "latest", map[string]interface{}{
+				"0xaa": map[string]interface{}{"stateDiff": map[string]interface{}{"0x0": "0x1", "0x1": "0x1"}},
+				"0xbb": map[string]interface{}{"code": "0x" + strings.Repeat("60", 1500)},
+			}}},
+			expectedCu: 2*StateOverrideAccountComputeUnits + 2*StateOverrideSlotComputeUnits + 2*StateOverrideCodeComputeUnitsPerKb,
+			valid:      true,
+		},
+		{name: "not an object", msg: JsonrpcMessage{Method: "eth_call", Params: []interface{}{call, "latest", "0x1"}}},
+		{
+			name: "unknown field",
+			msg: JsonrpcMessage{Method: "eth_call", Params: []interface{}{call, "latest", map[string]interface{}{
+				"0xaa": map[string]interface{}{"movePrecompileToAddress": "0xbb"},
+			}}},
+			expectedCu: StateOverrideAccountComputeUnits,
+			valid:      true,
+		},
+		{
+			name: "state and state diff",
+			msg: JsonrpcMessage{Method: "eth_call", Params: []interface{}{call, "latest", map[string]interface{}{
+				"0xaa": map[string]interface{}{"state": map[string]interface{}{}, "stateDiff": map[string]interface{}{}},
+			}}},
+		},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			computeUnits, err := play.msg.StateOverrideComputeUnits()
+			if !play.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, play.expectedCu, computeUnits)
+		})
+	}
+}
@@ -21,6 +21,10 @@ func (jm RestMessage) CheckResponseError(data []byte, httpStatusCode int) (hasEr
 	if httpStatusCode >= 200 && httpStatusCode <= 300 { // valid code
 		return false, ""
 	}
+	if !json.Valid(data) {
+		// non json replies (plain text, protobuf) don't carry an error object
+		return false, ""
+	}
 	result := make(map[string]interface{}, 0)
 	err := json.Unmarshal(data, &result)
 	if err != nil {
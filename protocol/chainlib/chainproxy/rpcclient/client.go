@@ -17,10 +17,12 @@
 package rpcclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -202,6 +204,25 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 	}
 }
 
+// DialContextWithTransport is DialContext with http connections made by the transport, and websocket connections
+// through the transport's proxy. checkRedirect decides on http redirects like http.Client's, nil keeps the default
+func DialContextWithTransport(ctx context.Context, rawurl string, transport *http.Transport, checkRedirect func(*http.Request, []*http.Request) error) (*Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return DialHTTPWithClient(rawurl, &http.Client{Transport: transport, CheckRedirect: checkRedirect})
+	case "ws", "wss":
+		dialer := newWebsocketDialer()
+		dialer.Proxy = transport.Proxy
+		return DialWebsocketWithDialer(ctx, rawurl, "", dialer)
+	default:
+		return DialContext(ctx, rawurl)
+	}
+}
+
 // ClientFromContext retrieves the client from the context, if any. This can be used to perform
 // 'reverse calls' in a handler method.
 func ClientFromContext(ctx context.Context) (*Client, bool) {
@@ -288,16 +309,19 @@ func (c *Client) SetHeader(key, value string) {
 //
 // The result must be a pointer so that package json can unmarshal into it. You
 // can also pass nil, in which case the result is ignored.
+// CallContext sends the request to the node with an internal id that is unique for this client,
+// so requests with colliding ids multiplexed on the same connection can't get each other's responses.
+// the given id is restored on the returned message.
 func (c *Client) CallContext(ctx context.Context, id json.RawMessage, method string, params interface{}, isJsonRPC bool, strict bool) (*JsonrpcMessage, error) {
 	var msg *JsonrpcMessage
 	var err error
 	switch p := params.(type) {
 	case []interface{}:
-		msg, err = c.newMessageArrayWithID(method, id, p)
+		msg, err = c.newMessageArrayWithID(method, nil, p)
 	case map[string]interface{}:
-		msg, err = c.newMessageMapWithID(method, id, p)
+		msg, err = c.newMessageMapWithID(method, nil, p)
 	case nil:
-		msg, err = c.newMessageArrayWithID(method, id, (make([]interface{}, 0))) // in case of nil, we will send it as an empty array.
+		msg, err = c.newMessageArrayWithID(method, nil, (make([]interface{}, 0))) // in case of nil, we will send it as an empty array.
 	default:
 		return nil, fmt.Errorf("%s unknown parameters type %s", p, reflect.TypeOf(p))
 	}
@@ -323,7 +347,10 @@ func (c *Client) CallContext(ctx context.Context, id json.RawMessage, method str
 	if err != nil {
 		return nil, err
 	}
-
+	// a mismatching id is left as is so callers can detect it
+	if id != nil && bytes.Equal(resp.ID, msg.ID) {
+		resp.ID = id
+	}
 	return resp, nil
 }
 
@@ -456,6 +483,33 @@ func (c *Client) Notify(ctx context.Context, method string, args ...interface{})
 	return c.send(ctx, op, msg)
 }
 
+// NotifyContext sends a notification with params in the format CallContext accepts, it returns once the notification
+// was written without waiting for a reply
+func (c *Client) NotifyContext(ctx context.Context, method string, params interface{}, strict bool) error {
+	var msg *JsonrpcMessage
+	var err error
+	switch p := params.(type) {
+	case []interface{}:
+		msg, err = c.newMessageArrayWithID(method, nil, p)
+	case map[string]interface{}:
+		msg, err = c.newMessageMapWithID(method, nil, p)
+	case nil:
+		msg, err = c.newMessageArrayWithID(method, nil, (make([]interface{}, 0)))
+	default:
+		return fmt.Errorf("%s unknown parameters type %s", p, reflect.TypeOf(p))
+	}
+	if err != nil {
+		return err
+	}
+	msg.ID = nil
+
+	op := new(requestOp)
+	if c.isHTTP {
+		return c.sendHTTP(ctx, op, msg, true, strict)
+	}
+	return c.send(ctx, op, msg)
+}
+
 // Subscribe calls the "<namespace>_subscribe" method with the given arguments,
 // registering a subscription. Server notifications for the subscription are
 // sent to the given channel. The element type of the channel must match the
@@ -506,6 +560,7 @@ func (c *Client) Subscribe(ctx context.Context, id json.RawMessage, method strin
 		sub:   newClientSubscription(c, method, chanVal),
 		subId: subId,
 	}
+	op.sub.tendermintQuery = subId
 
 	// Send the subscription request.
 	// The arrival and validity of the response is signaled on sub.quit.
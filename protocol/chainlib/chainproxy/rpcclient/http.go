@@ -153,6 +153,10 @@ func (c *Client) sendHTTP(ctx context.Context, op *requestOp, msg interface{}, i
 		return err
 	}
 	defer respBody.Close()
+	if op.resp == nil {
+		// a notification, there's no reply to read
+		return nil
+	}
 
 	var respmsg JsonrpcMessage
 	if err := json.NewDecoder(respBody).Decode(&respmsg); err != nil {
@@ -176,9 +180,24 @@ func (c *Client) sendBatchHTTP(ctx context.Context, op *requestOp, msgs []*Jsonr
 	if err := json.NewDecoder(respBody).Decode(&respmsgs); err != nil {
 		return err
 	}
+	pending := make(map[string]struct{}, len(op.ids))
+	for _, id := range op.ids {
+		pending[string(id)] = struct{}{}
+	}
 	for i := 0; i < len(respmsgs); i++ {
+		if _, ok := pending[string(respmsgs[i].ID)]; !ok {
+			// unknown or duplicate id, it can't be matched to an element
+			continue
+		}
+		delete(pending, string(respmsgs[i].ID))
 		op.resp <- &respmsgs[i]
 	}
+	// the node dropped some elements, answer them with an error instead of failing the whole batch on timeout
+	for _, id := range op.ids {
+		if _, ok := pending[string(id)]; ok {
+			op.resp <- &JsonrpcMessage{Version: Vsn, ID: id, Error: &JsonError{Code: defaultErrorCode, Message: "missing response for batch element"}}
+		}
+	}
 	return nil
 }
 
@@ -32,13 +32,15 @@ import (
 )
 
 const (
-	Vsn                      = "2.0"
-	serviceMethodSeparator   = "_"
-	subscribeMethodSuffix    = "_subscribe"
-	unsubscribeMethodSuffix  = "_unsubscribe"
-	notificationMethodSuffix = "_subscription"
+	Vsn                         = "2.0"
+	serviceMethodSeparator      = "_"
+	subscribeMethodSuffix       = "_subscribe"
+	unsubscribeMethodSuffix     = "_unsubscribe"
+	notificationMethodSuffix    = "_subscription"
+	TendermintUnsubscribeMethod = "unsubscribe"
 
 	defaultWriteTimeout = 10 * time.Second // used if context has no deadline
+	unsubscribeTimeout  = 10 * time.Second // used when unsubscribing on the server side
 )
 
 var null = json.RawMessage("null")
@@ -141,6 +143,14 @@ func errorMessage(err error) *JsonrpcMessage {
 	return msg
 }
 
+// ToJsonError converts an error to a json-rpc error object, keeping the code and data when the error provides them
+func ToJsonError(err error) *JsonError {
+	if jsonErr, ok := err.(*JsonError); ok {
+		return jsonErr
+	}
+	return errorMessage(err).Error
+}
+
 type JsonError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -212,6 +212,9 @@ type ClientSubscription struct {
 	namespace string
 	subid     string
 
+	// tendermint subscriptions are identified by their query, it's used to unsubscribe on the node
+	tendermintQuery string
+
 	// The in channel receives notification values from client dispatcher.
 	in chan *JsonrpcMessage
 
@@ -294,12 +297,17 @@ func (sub *ClientSubscription) close(err error) {
 func (sub *ClientSubscription) run() {
 	defer close(sub.unsubDone)
 
-	_, err := sub.forward()
+	unsubscribeServer, err := sub.forward()
 
 	// The client's dispatch loop won't be able to execute the unsubscribe call if it is
 	// blocked in sub.deliver() or sub.close(). Closing forwardDone unblocks them.
 	close(sub.forwardDone)
 
+	// Call the unsubscribe method on the server.
+	if unsubscribeServer && sub.tendermintQuery != "" {
+		sub.requestTendermintUnsubscribe()
+	}
+
 	// Send the error.
 	if err != nil {
 		if err == ErrClientQuit {
@@ -311,6 +319,16 @@ func (sub *ClientSubscription) run() {
 	}
 }
 
+// requestTendermintUnsubscribe cleans up the subscription on the node side
+func (sub *ClientSubscription) requestTendermintUnsubscribe() {
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+	_, err := sub.client.CallContext(ctx, nil, TendermintUnsubscribeMethod, map[string]interface{}{"query": sub.tendermintQuery}, false, false)
+	if err != nil {
+		utils.LavaFormatDebug("failed unsubscribing from node", utils.LogAttr("query", sub.tendermintQuery), utils.LogAttr("error", err))
+	}
+}
+
 // forward is the forwarding loop. It takes in RPC notifications and sends them
 // on the subscription channel.
 func (sub *ClientSubscription) forward() (unsubscribeServer bool, err error) {
@@ -27,6 +27,7 @@ const (
 	debug                     = false
 	refererMatchString        = "refererMatch"
 	relayMsgLogMaxChars       = 200
+	restContentTypeHeader     = "content-type"
 )
 
 var InvalidResponses = []string{"null", "", "nil", "undefined"}
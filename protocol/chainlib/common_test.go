@@ -391,3 +391,39 @@ func TestGetServiceApis(t *testing.T) {
 		t.Errorf("Expected serverApis length to be 3, but got %d", len(serverApis))
 	}
 }
+
+func TestInvalidApiConfiguration(t *testing.T) {
+	spec := spectypes.Spec{
+		Index:   "TEST",
+		Enabled: true,
+		ApiCollections: []*spectypes.ApiCollection{
+			nil,
+			{
+				Enabled:        true,
+				CollectionData: spectypes.CollectionData{ApiInterface: spectypes.APIInterfaceJsonRPC, Type: "POST"},
+				Apis: []*spectypes.Api{
+					nil,
+					{Enabled: true, ComputeUnits: 10},
+					{Name: "eth_blockNumber", Enabled: true, ComputeUnits: 10},
+				},
+			},
+		},
+	}
+	// malformed entries are dropped when the spec is loaded
+	serverApis, _, _, _, _ := getServiceApis(spec, spectypes.APIInterfaceJsonRPC)
+	assert.Len(t, serverApis, 1)
+	apiCont, ok := serverApis[ApiKey{Name: "eth_blockNumber", ConnectionType: "POST"}]
+	assert.True(t, ok)
+	// the api has no category set, which is the non subscription default
+	chainMessage := &baseChainMessageContainer{api: apiCont.api, apiCollection: spec.ApiCollections[1]}
+	assert.NoError(t, ValidateChainMessage(chainMessage))
+	assert.False(t, IsSubscription(chainMessage))
+
+	// a chain message without its spec configuration doesn't panic and returns a typed error
+	chainMessage = &baseChainMessageContainer{}
+	assert.False(t, IsSubscription(chainMessage))
+	assert.Equal(t, uint32(0), GetStateful(chainMessage))
+	assert.Equal(t, "", GetAddon(chainMessage))
+	assert.True(t, InvalidApiConfigurationError.Is(ValidateChainMessage(chainMessage)))
+	assert.True(t, InvalidApiConfigurationError.Is(ValidateChainMessage(nil)))
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/cosmos/cosmos-sdk/server/grpc/gogoreflection"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/chaintracker"
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavasession"
@@ -136,7 +137,7 @@ func CreateChainLibMocks(ctx context.Context, specIndex string, apiInterface str
 			}
 		}()
 		time.Sleep(10 * time.Millisecond)
-		chainRouter, err = GetChainRouter(ctx, 1, endpoint, chainParser)
+		chainRouter, err = GetChainRouter(ctx, 1, chainproxy.KeepaliveOptions{}, endpoint, chainParser)
 		if err != nil {
 			return nil, nil, nil, closeServer, err
 		}
@@ -144,7 +145,7 @@ func CreateChainLibMocks(ctx context.Context, specIndex string, apiInterface str
 		mockServer := httptest.NewServer(serverCallback)
 		closeServer = mockServer.Close
 		endpoint.NodeUrls = append(endpoint.NodeUrls, common.NodeUrl{Url: mockServer.URL, Addons: addons})
-		chainRouter, err = GetChainRouter(ctx, 1, endpoint, chainParser)
+		chainRouter, err = GetChainRouter(ctx, 1, chainproxy.KeepaliveOptions{}, endpoint, chainParser)
 		if err != nil {
 			return nil, nil, nil, closeServer, err
 		}
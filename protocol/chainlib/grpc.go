@@ -8,16 +8,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
 	"github.com/lavanet/lava/protocol/chainlib/grpcproxy"
 	dyncodec "github.com/lavanet/lava/protocol/chainlib/grpcproxy/dyncodec"
-	"github.com/lavanet/lava/protocol/parser"
 	protocoltypes "github.com/lavanet/lava/x/protocol/types"
 
 	"google.golang.org/grpc"
@@ -42,6 +43,17 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+const GrpcReflectionDiscoveryFlagName = "grpc-reflection-discovery"
+
+// when enabled, the grpc chain proxy lists the node's methods via server reflection on startup and compares them with the spec
+var GrpcReflectionDiscovery = false
+
+const GrpcNodeCompressionFlagName = "grpc-node-compression"
+
+// when enabled, calls to grpc nodes are compressed and so are their replies, which are decompressed before being signed.
+// nodes that can't decompress are called uncompressed
+var GrpcNodeCompression = false
+
 type GrpcNodeErrorResponse struct {
 	ErrorMessage string `json:"error_message"`
 	ErrorCode    uint32 `json:"error_code"`
@@ -97,6 +109,21 @@ func (apip *GrpcChainParser) getSupportedApi(name, connectionType string) (*ApiC
 	return apip.BaseChainParser.getSupportedApi(name, connectionType)
 }
 
+// returns the names of all the enabled apis in the spec
+func (apip *GrpcChainParser) getSpecApiNames() []string {
+	apip.rwLock.RLock()
+	defer apip.rwLock.RUnlock()
+	names := map[string]struct{}{}
+	for apiKey := range apip.serverApis {
+		names[apiKey.Name] = struct{}{}
+	}
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	return ret
+}
+
 func (apip *GrpcChainParser) setupForConsumer(relayer grpcproxy.ProxyCallBack) {
 	apip.registry = dyncodec.NewRegistry(dyncodec.NewRelayerRemote(relayer))
 	apip.codec = dyncodec.NewCodec(apip.registry)
@@ -171,9 +198,9 @@ func (apip *GrpcChainParser) ParseMsg(url string, data []byte, connectionType st
 	blockParser := apiCont.api.BlockParsing
 	var requestedBlock int64
 	if overwriteReqBlock == "" {
-		requestedBlock, err = parser.ParseBlockFromParams(grpcMessage, blockParser)
+		requestedBlock, err = apip.blockParsers.ParseRequestedBlock(apiCont.api.Name, grpcMessage, blockParser)
 		if err != nil {
-			utils.LavaFormatError("ParseBlockFromParams failed parsing block", err,
+			utils.LavaFormatError("ParseRequestedBlock failed parsing block", err,
 				utils.LogAttr("chain", apip.spec.Name),
 				utils.LogAttr("blockParsing", apiCont.api.BlockParsing),
 				utils.LogAttr("apiName", apiCont.api.Name),
@@ -377,21 +404,24 @@ type GrpcChainProxy struct {
 	BaseChainProxy
 	conn             grpcConnectorInterface
 	descriptorsCache *grpcDescriptorCache
+	// set once the node rejected a compressed call
+	nodeCompressionUnsupported uint32
 }
 type grpcConnectorInterface interface {
 	Close()
 	GetRpc(ctx context.Context, block bool) (*grpc.ClientConn, error)
 	ReturnRpc(rpc *grpc.ClientConn)
+	ObserveLatency(rpc *grpc.ClientConn, latency time.Duration, err error)
 }
 
-func NewGrpcChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint lavasession.RPCProviderEndpoint, parser ChainParser) (ChainProxy, error) {
+func NewGrpcChainProxy(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, rpcProviderEndpoint lavasession.RPCProviderEndpoint, parser ChainParser) (ChainProxy, error) {
 	if len(rpcProviderEndpoint.NodeUrls) == 0 {
 		return nil, utils.LavaFormatError("rpcProviderEndpoint.NodeUrl list is empty missing node url", nil, utils.Attribute{Key: "chainID", Value: rpcProviderEndpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: rpcProviderEndpoint.ApiInterface})
 	}
 	_, averageBlockTime, _, _ := parser.ChainBlockStats()
 	nodeUrl := rpcProviderEndpoint.NodeUrls[0]
 	nodeUrl.Url = strings.TrimSuffix(nodeUrl.Url, "/") // remove suffix if exists
-	conn, err := chainproxy.NewGRPCConnector(ctx, nConns, nodeUrl)
+	conn, err := chainproxy.NewGRPCConnector(ctx, nConns, keepalive, nodeUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -423,9 +453,77 @@ func newGrpcChainProxy(ctx context.Context, averageBlockTime time.Duration, pars
 	if err != nil {
 		return nil, fmt.Errorf("grpc chain proxy: failed to setup parser: %w", err)
 	}
+	if GrpcReflectionDiscovery {
+		go cp.discoverNodeMethods(ctx, reflectionConnection, parser.(*GrpcChainParser))
+	}
 	return cp, nil
 }
 
+// discoverNodeMethods is a startup diagnostic, it lists the node's methods using grpc reflection
+// and warns on methods that exist on the node and not in the spec and vice versa
+func (cp *GrpcChainProxy) discoverNodeMethods(ctx context.Context, reflectionConnection *grpc.ClientConn, parser *GrpcChainParser) {
+	nodeMethods, err := listNodeMethods(ctx, reflectionConnection)
+	if err != nil {
+		utils.LavaFormatWarning("grpc reflection discovery failed listing node methods", err, utils.LogAttr("chainID", cp.ChainID))
+		return
+	}
+	missingInSpec, missingOnNode := compareNodeMethodsWithSpec(nodeMethods, parser.getSpecApiNames())
+	if len(missingInSpec) > 0 {
+		utils.LavaFormatWarning("grpc reflection discovery found node methods missing from the spec", nil, utils.LogAttr("chainID", cp.ChainID), utils.LogAttr("methods", strings.Join(missingInSpec, ",")))
+	}
+	if len(missingOnNode) > 0 {
+		utils.LavaFormatWarning("grpc reflection discovery found spec methods missing from the node", nil, utils.LogAttr("chainID", cp.ChainID), utils.LogAttr("methods", strings.Join(missingOnNode, ",")))
+	}
+	utils.LavaFormatInfo("grpc reflection discovery done", utils.LogAttr("chainID", cp.ChainID), utils.LogAttr("nodeMethods", len(nodeMethods)), utils.LogAttr("missingInSpec", len(missingInSpec)), utils.LogAttr("missingOnNode", len(missingOnNode)))
+}
+
+func listNodeMethods(ctx context.Context, reflectionConnection *grpc.ClientConn) ([]string, error) {
+	cl := grpcreflect.NewClient(ctx, reflectionpbo.NewServerReflectionClient(reflectionConnection))
+	defer cl.Reset()
+	services, err := cl.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	methods := []string{}
+	for _, service := range services {
+		if strings.HasPrefix(service, "grpc.reflection.") {
+			continue
+		}
+		serviceDescriptor, err := cl.ResolveService(service)
+		if err != nil {
+			utils.LavaFormatDebug("grpc reflection discovery failed resolving service", utils.LogAttr("service", service), utils.LogAttr("error", err))
+			continue
+		}
+		for _, method := range serviceDescriptor.GetMethods() {
+			methods = append(methods, service+"/"+method.GetName())
+		}
+	}
+	return methods, nil
+}
+
+// returns sorted lists of the methods that appear only on the node and only in the spec
+func compareNodeMethodsWithSpec(nodeMethods []string, specMethods []string) (missingInSpec []string, missingOnNode []string) {
+	nodeSet := make(map[string]struct{}, len(nodeMethods))
+	for _, method := range nodeMethods {
+		nodeSet[method] = struct{}{}
+	}
+	specSet := make(map[string]struct{}, len(specMethods))
+	for _, method := range specMethods {
+		specSet[method] = struct{}{}
+		if _, ok := nodeSet[method]; !ok {
+			missingOnNode = append(missingOnNode, method)
+		}
+	}
+	for method := range nodeSet {
+		if _, ok := specSet[method]; !ok {
+			missingInSpec = append(missingInSpec, method)
+		}
+	}
+	sort.Strings(missingInSpec)
+	sort.Strings(missingOnNode)
+	return missingInSpec, missingOnNode
+}
+
 func (cp *GrpcChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{}, chainMessage ChainMessageForSend) (relayReply *pairingtypes.RelayReply, subscriptionID string, relayReplyServer *rpcclient.ClientSubscription, err error) {
 	if ch != nil {
 		return nil, "", nil, utils.LavaFormatError("Subscribe is not allowed on grpc", nil, utils.Attribute{Key: "GUID", Value: ctx})
@@ -529,7 +627,21 @@ func (cp *GrpcChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 	response := msgFactory.NewMessage(methodDescriptor.GetOutputType())
 	connectCtx, cancel := cp.NodeUrl.LowerContextTimeout(ctx, chainMessage, cp.averageBlockTime)
 	defer cancel()
-	err = conn.Invoke(connectCtx, "/"+nodeMessage.Path, msg, response, grpc.Header(&respHeaders))
+	compressed := cp.compressesNodeCalls()
+	callOptions := []grpc.CallOption{grpc.Header(&respHeaders)}
+	if compressed {
+		callOptions = append(callOptions, grpc.UseCompressor(common.GrpcCompressor))
+	}
+	callStart := time.Now()
+	err = conn.Invoke(connectCtx, "/"+nodeMessage.Path, msg, response, callOptions...)
+	if err != nil && compressed && common.IsGrpcCompressionUnsupported(err) {
+		// the node rejected the call before handling it
+		atomic.StoreUint32(&cp.nodeCompressionUnsupported, 1)
+		utils.LavaFormatWarning("grpc node doesn't support compressed calls, calling it uncompressed", nil, utils.Attribute{Key: "GUID", Value: ctx})
+		callStart = time.Now()
+		err = conn.Invoke(connectCtx, "/"+nodeMessage.Path, msg, response, grpc.Header(&respHeaders))
+	}
+	cp.conn.ObserveLatency(conn, time.Since(callStart), err)
 	if err != nil {
 		// Validate if the error is related to the provider connection to the node or it is a valid error
 		// in case the error is valid (e.g. bad input parameters) the error will return in the form of a valid error reply
@@ -561,6 +673,10 @@ func (cp *GrpcChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 	return reply, "", nil, nil
 }
 
+func (cp *GrpcChainProxy) compressesNodeCalls() bool {
+	return GrpcNodeCompression && atomic.LoadUint32(&cp.nodeCompressionUnsupported) == 0
+}
+
 // This method assumes that the error is due to misuse of the request arguments, meaning the user would like to get
 // the response from the server to fix the request arguments. this method will make sure the user will get the response
 // from the node in the same format as expected.
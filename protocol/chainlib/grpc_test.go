@@ -291,3 +291,15 @@ func TestSettingBlocksHeadersGrpc(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareNodeMethodsWithSpec(t *testing.T) {
+	nodeMethods := []string{"cosmos.bank.v1beta1.Query/Balance", "cosmos.bank.v1beta1.Query/AllBalances", "cosmos.staking.v1beta1.Query/Params"}
+	specMethods := []string{"cosmos.bank.v1beta1.Query/Balance", "cosmos.gov.v1beta1.Query/Proposals"}
+	missingInSpec, missingOnNode := compareNodeMethodsWithSpec(nodeMethods, specMethods)
+	require.Equal(t, []string{"cosmos.bank.v1beta1.Query/AllBalances", "cosmos.staking.v1beta1.Query/Params"}, missingInSpec)
+	require.Equal(t, []string{"cosmos.gov.v1beta1.Query/Proposals"}, missingOnNode)
+
+	missingInSpec, missingOnNode = compareNodeMethodsWithSpec(specMethods, specMethods)
+	require.Empty(t, missingInSpec)
+	require.Empty(t, missingOnNode)
+}
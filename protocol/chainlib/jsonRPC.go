@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,7 +17,6 @@ import (
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/protocol/metrics"
-	"github.com/lavanet/lava/protocol/parser"
 
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
@@ -29,6 +29,18 @@ const SEP = "&"
 
 type JsonRPCChainParser struct {
 	BaseChainParser
+	methodAliases     map[string]string
+	paramsSignatures  map[string]*ParamsSignature // method -> declared params, methods without one aren't validated
+	blockHashResolver BlockHashResolver
+}
+
+// ApiNames returns the spec apis a message calls, a batch's combined api is split back into the apis of its elements
+func ApiNames(chainMessage ChainMessageForSend) []string {
+	apiName := chainMessage.GetApi().Name
+	if _, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcBatchMessage); !ok {
+		return []string{apiName}
+	}
+	return strings.Split(apiName, SEP)
 }
 
 // NewJrpcChainParser creates a new instance of JsonRPCChainParser
@@ -36,6 +48,46 @@ func NewJrpcChainParser() (chainParser *JsonRPCChainParser, err error) {
 	return &JsonRPCChainParser{}, nil
 }
 
+// SetMethodAliases configures client method names that resolve to a canonical spec method. the message keeps the
+// original method name, consumers and providers must share the aliases for an aliased relay to be served. to send the
+// canonical name instead, the consumer rewrites the request with NewMethodAliasTransform
+func (apip *JsonRPCChainParser) SetMethodAliases(aliases map[string]string) {
+	apip.rwLock.Lock()
+	defer apip.rwLock.Unlock()
+	apip.methodAliases = make(map[string]string, len(aliases))
+	for alias, canonical := range aliases {
+		apip.methodAliases[alias] = canonical
+	}
+}
+
+// returns the canonical method name for an alias, unknown methods are returned as is
+func (apip *JsonRPCChainParser) resolveMethodAlias(method string) string {
+	apip.rwLock.RLock()
+	defer apip.rwLock.RUnlock()
+	if canonical, ok := apip.methodAliases[method]; ok {
+		return canonical
+	}
+	return method
+}
+
+// SetParamsSignatures validates the params of requests for these methods while parsing, so malformed requests are
+// rejected before a session is used on them
+func (apip *JsonRPCChainParser) SetParamsSignatures(signatures map[string]*ParamsSignature) {
+	apip.rwLock.Lock()
+	defer apip.rwLock.Unlock()
+	apip.paramsSignatures = signatures
+}
+
+func (apip *JsonRPCChainParser) validateParams(method string, params interface{}) error {
+	apip.rwLock.RLock()
+	signature, ok := apip.paramsSignatures[method]
+	apip.rwLock.RUnlock()
+	if !ok {
+		return nil
+	}
+	return signature.Validate(params)
+}
+
 func (bcp *JsonRPCChainParser) GetUniqueName() string {
 	return "jsonrpc_chain_parser"
 }
@@ -103,10 +155,18 @@ func (apip *JsonRPCChainParser) ParseMsg(url string, data []byte, connectionType
 	var latestRequestedBlock, earliestRequestedBlock int64 = 0, 0
 	for idx, msg := range msgs {
 		var requestedBlockForMessage int64
+		method := apip.resolveMethodAlias(msg.Method)
 		// Check api is supported and save it in nodeMsg
-		apiCont, err := apip.getSupportedApi(msg.Method, connectionType)
+		apiCont, err := apip.getSupportedApi(method, connectionType)
+		if err != nil {
+			return nil, utils.LavaFormatInfo("getSupportedApi jsonrpc failed", utils.LogAttr("reason", err), utils.Attribute{Key: "method", Value: msg.Method}, utils.LogAttr("resolvedMethod", method))
+		}
+		if err = apip.validateParams(method, msg.Params); err != nil {
+			return nil, utils.LavaFormatLog("invalid jsonrpc params", err, []utils.Attribute{utils.LogAttr("method", msg.Method)}, utils.LAVA_LOG_INFO)
+		}
+		msgApi, err := apiWithDynamicComputeUnits(apiCont.api, &msg)
 		if err != nil {
-			return nil, utils.LavaFormatInfo("getSupportedApi jsonrpc failed", utils.LogAttr("reason", err), utils.Attribute{Key: "method", Value: msg.Method})
+			return nil, utils.LavaFormatInfo("invalid jsonrpc params", utils.LogAttr("reason", err), utils.Attribute{Key: "method", Value: msg.Method})
 		}
 
 		apiCollectionForMessage, err := apip.getApiCollection(connectionType, apiCont.collectionKey.InternalPath, apiCont.collectionKey.Addon)
@@ -120,9 +180,9 @@ func (apip *JsonRPCChainParser) ParseMsg(url string, data []byte, connectionType
 
 		if overwriteReqBlock == "" {
 			// Fetch requested block, it is used for data reliability
-			requestedBlockForMessage, err = parser.ParseBlockFromParams(msg, apiCont.api.BlockParsing)
+			requestedBlockForMessage, err = apip.blockParsers.ParseRequestedBlock(apiCont.api.Name, msg, apiCont.api.BlockParsing)
 			if err != nil {
-				utils.LavaFormatError("ParseBlockFromParams failed parsing block", err,
+				utils.LavaFormatError("ParseRequestedBlock failed parsing block", err,
 					utils.LogAttr("chain", apip.spec.Name),
 					utils.LogAttr("blockParsing", apiCont.api.BlockParsing),
 					utils.LogAttr("apiName", apiCont.api.Name),
@@ -130,6 +190,7 @@ func (apip *JsonRPCChainParser) ParseMsg(url string, data []byte, connectionType
 				)
 				requestedBlockForMessage = spectypes.NOT_APPLICABLE
 			}
+			requestedBlockForMessage = apip.resolveRequestedBlockHash(&msg, requestedBlockForMessage)
 		} else {
 			requestedBlockForMessage, err = msg.ParseBlock(overwriteReqBlock)
 			if err != nil {
@@ -139,7 +200,7 @@ func (apip *JsonRPCChainParser) ParseMsg(url string, data []byte, connectionType
 		}
 		if idx == 0 {
 			// on the first entry store them
-			api = apiCont.api
+			api = msgApi
 			apiCollection = apiCollectionForMessage
 			latestRequestedBlock = requestedBlockForMessage
 		} else {
@@ -154,7 +215,7 @@ func (apip *JsonRPCChainParser) ParseMsg(url string, data []byte, connectionType
 			// 4. we need to take the most comprehensive apiCollection (addon)
 			// 5. take the strictest category
 			category := api.GetCategory()
-			category = category.Combine(apiCont.api.GetCategory())
+			category = category.Combine(msgApi.GetCategory())
 			if apiCollectionForMessage.CollectionData.AddOn != "" && apiCollectionForMessage.CollectionData.AddOn != apiCollection.CollectionData.AddOn {
 				if apiCollection.CollectionData.AddOn != "" {
 					return nil, utils.LavaFormatError("unable to parse batch request with api from multiple addons", nil,
@@ -164,10 +225,10 @@ func (apip *JsonRPCChainParser) ParseMsg(url string, data []byte, connectionType
 				apiCollection = apiCollectionForMessage // overwrite apiColleciton to take the addon
 			}
 			api = &spectypes.Api{
-				Enabled:           api.Enabled && apiCont.api.Enabled,
-				Name:              api.Name + SEP + apiCont.api.Name,
-				ComputeUnits:      api.ComputeUnits + apiCont.api.ComputeUnits,
-				ExtraComputeUnits: api.ExtraComputeUnits + apiCont.api.ExtraComputeUnits,
+				Enabled:           api.Enabled && msgApi.Enabled,
+				Name:              api.Name + SEP + msgApi.Name,
+				ComputeUnits:      api.ComputeUnits + msgApi.ComputeUnits,
+				ExtraComputeUnits: api.ExtraComputeUnits + msgApi.ExtraComputeUnits,
 				Category:          category,
 				BlockParsing: spectypes.BlockParser{
 					ParserArg:    []string{},
@@ -192,6 +253,20 @@ func (apip *JsonRPCChainParser) ParseMsg(url string, data []byte, connectionType
 	return nodeMsg, apip.BaseChainParser.Validate(nodeMsg)
 }
 
+// the spec's compute units are static, params that make the node do more work, like eth_call's state override, add to them.
+// specs opt in by setting the api's extra compute units, the cost of each unit of work. apis without them keep their
+// static cost, so consumers and providers agree on it whatever their version until the spec changes
+func apiWithDynamicComputeUnits(api *spectypes.Api, msg *rpcInterfaceMessages.JsonrpcMessage) (*spectypes.Api, error) {
+	extraUnits, err := msg.StateOverrideComputeUnits()
+	if err != nil || extraUnits == 0 || api.ExtraComputeUnits == 0 {
+		return api, err
+	}
+	// the spec's api is shared, the cost is per message
+	withExtraComputeUnits := *api
+	withExtraComputeUnits.ComputeUnits += extraUnits * api.ExtraComputeUnits
+	return &withExtraComputeUnits, nil
+}
+
 func (*JsonRPCChainParser) newBatchChainMessage(serviceApi *spectypes.Api, requestedBlock int64, earliestRequestedBlock int64, msgs []rpcInterfaceMessages.JsonrpcMessage, apiCollection *spectypes.ApiCollection) (*baseChainMessageContainer, error) {
 	batchMessage, err := rpcInterfaceMessages.NewBatchMessage(msgs)
 	if err != nil {
@@ -373,6 +448,11 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 				apil.logger.AnalyzeWebSocketErrorAndWriteMessage(websockConn, messageType, err, msgSeed, msg, spectypes.APIInterfaceJsonRPC, time.Since(startTime))
 				continue
 			}
+			if relayResult.GetStatusCode() == http.StatusNoContent {
+				// a notification, nothing is written back
+				apil.logger.LogRequestAndResponse("jsonrpc ws msg", false, "ws", websockConn.LocalAddr().String(), string(msg), "", msgSeed, time.Since(startTime), nil)
+				continue
+			}
 			// If subscribe the first reply would contain the RPC ID that can be used for disconnect.
 			if replyServer != nil {
 				var reply pairingtypes.RelayReply
@@ -416,11 +496,11 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 	app.Get("/ws", websocketCallbackWithDappID)
 	app.Get("/websocket", websocketCallbackWithDappID) // catching http://HOST:PORT/1/websocket requests.
 
-	handlerPost := func(fiberCtx *fiber.Ctx) error {
+	relayHttp := func(fiberCtx *fiber.Ctx, msg string, httpMethod string) error {
 		// Set response header content-type to application/json
 		fiberCtx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
 		startTime := time.Now()
-		endTx := apil.logger.LogStartTransaction("jsonRpc-http post")
+		endTx := apil.logger.LogStartTransaction("jsonRpc-http " + strings.ToLower(httpMethod))
 		defer endTx()
 		dappID := extractDappIDFromFiberContext(fiberCtx)
 		metricsData := metrics.NewRelayAnalytics(dappID, chainID, apiInterface)
@@ -428,6 +508,10 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 		defer cancel()
 		guid := utils.GenerateUniqueIdentifier()
 		ctx = utils.WithUniqueIdentifier(ctx, guid)
+		if httpMethod == http.MethodGet {
+			// caches and proxies may repeat GET requests, only apis without side effects are relayed
+			ctx = ContextWithReadOnlyRelay(ctx)
+		}
 		msgSeed := strconv.FormatUint(guid, 10)
 		if test_mode {
 			apil.logger.LogTestMode(fiberCtx)
@@ -437,7 +521,6 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 		metadataValues := fiberCtx.GetReqHeaders()
 		headers := convertToMetadataMap(metadataValues)
 
-		msg := string(fiberCtx.Body())
 		logFormattedMsg := msg
 		if !cmdFlags.DebugRelays {
 			logFormattedMsg = utils.FormatLongString(logFormattedMsg, relayMsgLogMaxChars)
@@ -462,7 +545,7 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 			errMasking := apil.logger.GetUniqueGuidResponseForError(err, msgSeed)
 
 			// Log request and response
-			apil.logger.LogRequestAndResponse("jsonrpc http", true, "POST", fiberCtx.Request().URI().String(), msg, errMasking, msgSeed, time.Since(startTime), err)
+			apil.logger.LogRequestAndResponse("jsonrpc http", true, httpMethod, fiberCtx.Request().URI().String(), msg, errMasking, msgSeed, time.Since(startTime), err)
 
 			// Set status to internal error
 			if relayResult.GetStatusCode() != 0 {
@@ -471,6 +554,9 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 				fiberCtx.Status(fiber.StatusInternalServerError)
 			}
 
+			if httpMethod == http.MethodGet {
+				fiberCtx.Set(fiber.HeaderCacheControl, jsonrpcGetCacheControl(nil))
+			}
 			// Construct json response
 			response := convertToJsonError(errMasking)
 			// Return error json response
@@ -480,7 +566,7 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 		// Log request and response
 		apil.logger.LogRequestAndResponse("jsonrpc http",
 			false,
-			"POST",
+			httpMethod,
 			fiberCtx.Request().URI().String(),
 			msg,
 			response,
@@ -491,9 +577,25 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 		if relayResult.GetStatusCode() != 0 {
 			fiberCtx.Status(relayResult.StatusCode)
 		}
+		if httpMethod == http.MethodGet {
+			fiberCtx.Set(fiber.HeaderCacheControl, jsonrpcGetCacheControl(relayResult))
+		}
 		// Return json response
 		return addHeadersAndSendString(fiberCtx, reply.GetMetadata(), response)
 	}
+	handlerPost := func(fiberCtx *fiber.Ctx) error {
+		return relayHttp(fiberCtx, string(fiberCtx.Body()), http.MethodPost)
+	}
+	// jsonrpc over GET for edge caches, the request is encoded in the query string
+	handlerGet := func(fiberCtx *fiber.Ctx) error {
+		msg, err := jsonrpcGetRequestFromFiberContext(fiberCtx)
+		if err != nil {
+			fiberCtx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+			fiberCtx.Status(fiber.StatusBadRequest)
+			return fiberCtx.SendString(convertToJsonError(err.Error()))
+		}
+		return relayHttp(fiberCtx, msg, http.MethodGet)
+	}
 	if apil.refererData != nil && apil.refererData.Marker != "" {
 		app.Use("/"+apil.refererData.Marker+":"+refererMatchString+"/ws", func(c *fiber.Ctx) error {
 			if websocket.IsWebSocketUpgrade(c) {
@@ -506,8 +608,10 @@ func (apil *JsonRPCChainListener) Serve(ctx context.Context, cmdFlags common.Con
 		app.Get("/"+apil.refererData.Marker+":"+refererMatchString+"/ws", websocketCallbackWithDappIDAndReferer)
 		app.Get("/"+apil.refererData.Marker+":"+refererMatchString+"/websocket", websocketCallbackWithDappIDAndReferer)
 		app.Post("/"+apil.refererData.Marker+":"+refererMatchString+"/*", handlerPost)
+		app.Get("/"+apil.refererData.Marker+":"+refererMatchString+"/*", handlerGet)
 	}
 	app.Post("/*", handlerPost)
+	app.Get("/*", handlerGet)
 	// Go
 	ListenWithRetry(app, apil.endpoint.NetworkAddress)
 }
@@ -517,7 +621,7 @@ type JrpcChainProxy struct {
 	conn map[string]*chainproxy.Connector
 }
 
-func NewJrpcChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainProxy, error) {
+func NewJrpcChainProxy(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainProxy, error) {
 	if len(rpcProviderEndpoint.NodeUrls) == 0 {
 		return nil, utils.LavaFormatError("rpcProviderEndpoint.NodeUrl list is empty missing node url", nil, utils.Attribute{Key: "chainID", Value: rpcProviderEndpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: rpcProviderEndpoint.ApiInterface})
 	}
@@ -535,22 +639,22 @@ func NewJrpcChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint lav
 	}
 	internalPathsLength := len(internalPaths)
 	if internalPathsLength > 0 && internalPathsLength == len(rpcProviderEndpoint.NodeUrls) {
-		return cp, cp.startWithSpecificInternalPaths(ctx, nConns, rpcProviderEndpoint.NodeUrls, internalPaths)
+		return cp, cp.startWithSpecificInternalPaths(ctx, nConns, keepalive, rpcProviderEndpoint.NodeUrls, internalPaths)
 	} else if internalPathsLength > 0 && len(rpcProviderEndpoint.NodeUrls) > 1 {
 		// provider provided specific endpoints but not enough to fill all requirements
 		return nil, utils.LavaFormatError("Internal Paths specified but not all paths provided", nil, utils.Attribute{Key: "required", Value: internalPaths}, utils.Attribute{Key: "provided", Value: rpcProviderEndpoint.NodeUrls})
 	}
-	return cp, cp.start(ctx, nConns, nodeUrl, internalPaths)
+	return cp, cp.start(ctx, nConns, keepalive, nodeUrl, internalPaths)
 }
 
-func (cp *JrpcChainProxy) startWithSpecificInternalPaths(ctx context.Context, nConns uint, nodeUrls []common.NodeUrl, internalPaths map[string]struct{}) error {
+func (cp *JrpcChainProxy) startWithSpecificInternalPaths(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, nodeUrls []common.NodeUrl, internalPaths map[string]struct{}) error {
 	for _, url := range nodeUrls {
 		_, ok := internalPaths[url.InternalPath]
 		if !ok {
 			return utils.LavaFormatError("url.InternalPath was not found in internalPaths", nil, utils.Attribute{Key: "internalPaths", Value: internalPaths}, utils.Attribute{Key: "url.InternalPath", Value: url.InternalPath})
 		}
 		utils.LavaFormatDebug("connecting", utils.Attribute{Key: "url", Value: url.String()})
-		conn, err := chainproxy.NewConnector(ctx, nConns, url)
+		conn, err := chainproxy.NewConnector(ctx, nConns, keepalive, url)
 		if err != nil {
 			return err
 		}
@@ -562,14 +666,14 @@ func (cp *JrpcChainProxy) startWithSpecificInternalPaths(ctx context.Context, nC
 	return nil
 }
 
-func (cp *JrpcChainProxy) start(ctx context.Context, nConns uint, nodeUrl common.NodeUrl, internalPaths map[string]struct{}) error {
+func (cp *JrpcChainProxy) start(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, nodeUrl common.NodeUrl, internalPaths map[string]struct{}) error {
 	if len(internalPaths) == 0 {
 		internalPaths = map[string]struct{}{"": {}} // add default path
 	}
 	basePath := nodeUrl.Url
 	for path := range internalPaths {
 		nodeUrl.Url = basePath + path
-		conn, err := chainproxy.NewConnector(ctx, nConns, nodeUrl)
+		conn, err := chainproxy.NewConnector(ctx, nConns, keepalive, nodeUrl)
 		if err != nil {
 			return err
 		}
@@ -601,14 +705,16 @@ func (cp *JrpcChainProxy) sendBatchMessage(ctx context.Context, nodeMessage *rpc
 
 	cp.NodeUrl.SetIpForwardingIfNecessary(ctx, rpc.SetHeader)
 	batch := nodeMessage.GetBatch()
+	callStart := time.Now()
 	err = rpc.BatchCallContext(connectCtx, batch, nodeMessage.GetDisableErrorHandling())
+	cp.conn[internalPath].ObserveLatency(rpc, time.Since(callStart), err)
 	if err != nil {
 		// Validate if the error is related to the provider connection to the node or it is a valid error
 		// in case the error is valid (e.g. bad input parameters) the error will return in the form of a valid error reply
 		if parsedError := cp.HandleNodeError(ctx, err); parsedError != nil {
-			return nil, parsedError
+			return nil, withClientBatchRequest(parsedError, batch)
 		}
-		return nil, err
+		return nil, withClientBatchRequest(err, batch)
 	}
 	replyMsgs := make([]rpcInterfaceMessages.JsonrpcMessage, len(batch))
 	for idx, element := range batch {
@@ -672,17 +778,28 @@ func (cp *JrpcChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 		defer cancel()
 
 		cp.NodeUrl.SetIpForwardingIfNecessary(ctx, rpc.SetHeader)
+		if nodeMessage.IsNotification() {
+			// the node doesn't reply to notifications, there's nothing to wait for or validate
+			err = rpc.NotifyContext(connectCtx, nodeMessage.Method, nodeMessage.Params, nodeMessage.GetDisableErrorHandling())
+			if err != nil {
+				return nil, "", nil, utils.LavaFormatWarning("failed sending notification to the node", err, utils.LogAttr("GUID", ctx), utils.LogAttr("method", nodeMessage.Method))
+			}
+			return &pairingtypes.RelayReply{}, "", nil, nil
+		}
+		callStart := time.Now()
 		rpcMessage, err = rpc.CallContext(connectCtx, nodeMessage.ID, nodeMessage.Method, nodeMessage.Params, true, nodeMessage.GetDisableErrorHandling())
+		cp.conn[internalPath].ObserveLatency(rpc, time.Since(callStart), err)
 		if err != nil {
 			// here we are getting an error for every code that is not 200-300
 			if common.StatusCodeError504.Is(err) || common.StatusCodeError429.Is(err) || common.StatusCodeErrorStrict.Is(err) {
-				return nil, "", nil, utils.LavaFormatWarning("Received invalid status code", err, utils.Attribute{Key: "chainID", Value: cp.BaseChainProxy.ChainID}, utils.Attribute{Key: "apiName", Value: chainMessage.GetApi().Name})
+				return nil, "", nil, utils.LavaFormatWarning("Received invalid status code", withClientRequest(err, nodeMessage.ID, nodeMessage.Method), utils.Attribute{Key: "chainID", Value: cp.BaseChainProxy.ChainID}, utils.Attribute{Key: "apiName", Value: chainMessage.GetApi().Name})
 			}
 			// Validate if the error is related to the provider connection to the node or it is a valid error
 			// in case the error is valid (e.g. bad input parameters) the error will return in the form of a valid error reply
 			if parsedError := cp.HandleNodeError(ctx, err); parsedError != nil {
-				return nil, "", nil, parsedError
+				return nil, "", nil, withClientRequest(parsedError, nodeMessage.ID, nodeMessage.Method)
 			}
+			err = withClientRequest(err, nodeMessage.ID, nodeMessage.Method)
 		}
 	}
 
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
 	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
 	keepertest "github.com/lavanet/lava/testutil/keeper"
 	plantypes "github.com/lavanet/lava/x/plans/types"
 	spectypes "github.com/lavanet/lava/x/spec/types"
@@ -132,6 +134,75 @@ func TestJSONParseMessage(t *testing.T) {
 	assert.Equal(t, msg.GetApiCollection().CollectionData.ApiInterface, spectypes.APIInterfaceJsonRPC)
 }
 
+func TestJSONParseMessageMethodAliases(t *testing.T) {
+	apip := &JsonRPCChainParser{
+		BaseChainParser: BaseChainParser{
+			serverApis: map[ApiKey]ApiContainer{
+				{Name: "API1", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:         "API1",
+					Enabled:      true,
+					ComputeUnits: 20,
+					BlockParsing: spectypes.BlockParser{
+						ParserArg:  []string{"latest"},
+						ParserFunc: spectypes.PARSER_FUNC_DEFAULT,
+					},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+			},
+			apiCollections: map[CollectionKey]*spectypes.ApiCollection{{ConnectionType: connectionType_test}: {Enabled: true, CollectionData: spectypes.CollectionData{ApiInterface: spectypes.APIInterfaceJsonRPC}}},
+		},
+	}
+	apip.SetMethodAliases(map[string]string{"alias_api1": "API1", "alias_missing": "API2"})
+
+	parse := func(method string) (ChainMessage, error) {
+		marshalledData, err := json.Marshal(rpcInterfaceMessages.JsonrpcMessage{Method: method})
+		require.NoError(t, err)
+		return apip.ParseMsg("", marshalledData, connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	}
+	sentMethod := func(msg ChainMessage) string {
+		jsonMsg, ok := msg.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+		require.True(t, ok)
+		return jsonMsg.Method
+	}
+
+	// alias resolves to the canonical api and its compute units, the alias is still sent upstream
+	msg, err := parse("alias_api1")
+	require.NoError(t, err)
+	require.Equal(t, "API1", msg.GetApi().Name)
+	require.Equal(t, uint64(20), msg.GetApi().ComputeUnits)
+	require.Equal(t, "alias_api1", sentMethod(msg))
+
+	// canonical names keep working
+	msg, err = parse("API1")
+	require.NoError(t, err)
+	require.Equal(t, "API1", msg.GetApi().Name)
+
+	// methods without an alias fall through to regular matching
+	_, err = parse("unknown_method")
+	require.Error(t, err)
+	_, err = parse("alias_missing")
+	require.Error(t, err)
+
+	// the alias transform replaces the alias with the canonical name in the relayed data
+	transform := NewMethodAliasTransform(map[string]string{"alias_api1": "API1"})
+	data := []byte(`{"jsonrpc":"2.0","id":7,"method":"alias_api1","params":[]}`)
+	msg, err = apip.ParseMsg("", data, connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	transformed, err := transform(msg, data)
+	require.NoError(t, err)
+	msg, err = apip.ParseMsg("", transformed, connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.Equal(t, "API1", sentMethod(msg))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":7,"method":"API1","params":[]}`, string(transformed))
+
+	// canonical requests are left untouched
+	data = []byte(`{"jsonrpc":"2.0","id":7,"method":"API1","params":[]}`)
+	msg, err = apip.ParseMsg("", data, connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	transformed, err = transform(msg, data)
+	require.NoError(t, err)
+	require.Equal(t, data, transformed)
+}
+
 func TestJsonRpcChainProxy(t *testing.T) {
 	ctx := context.Background()
 	serverHandle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -263,6 +334,44 @@ func TestExtensions(t *testing.T) {
 	}
 }
 
+func TestArchiveRoutingByBlockParam(t *testing.T) {
+	ctx := context.Background()
+	serverHandle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x1"}`)
+	})
+	chainParser, _, _, closeServer, err := CreateChainLibMocks(ctx, "ETH1", spectypes.APIInterfaceJsonRPC, serverHandle, "../../", []string{"archive"})
+	require.NoError(t, err)
+	if closeServer != nil {
+		defer closeServer()
+	}
+	chainParser.SetPolicy(&plantypes.Policy{ChainPolicies: []plantypes.ChainPolicy{{ChainId: "ETH1", Requirements: []plantypes.ChainRequirement{{Collection: spectypes.CollectionData{ApiInterface: "jsonrpc"}, Extensions: []string{"archive"}}}}}}, "ETH1", "jsonrpc")
+	// the spec's archive rule keeps 6554 blocks on full nodes
+	latestBlock := uint64(1000000)
+	for request, archive := range map[string]bool{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","latest"]}`:                  false,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa"]}`:                           false,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0xf4230"]}`:                 false,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa",{"blockNumber":"0xf4230"}]}`: false,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`:                    true,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa",{"blockNumber":"0x10"}]}`:    true,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","earliest"]}`:                true,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getStorageAt","params":["0xaa","0x0","0xf4230"]}`:         false,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getStorageAt","params":["0xaa","0x0","0x10"]}`:            true,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0xaa"},"latest"]}`:                 false,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0xaa"},{"blockNumber":"0x10"}]}`:   true,
+	} {
+		chainMessage, err := chainParser.ParseMsg("", []byte(request), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: latestBlock})
+		require.NoError(t, err)
+		if archive {
+			require.Len(t, chainMessage.GetExtensions(), 1, request)
+			require.Equal(t, "archive", chainMessage.GetExtensions()[0].Name)
+		} else {
+			require.Empty(t, chainMessage.GetExtensions(), request)
+		}
+	}
+}
+
 func TestJsonRpcBatchCall(t *testing.T) {
 	ctx := context.Background()
 	gotCalled := false
@@ -301,6 +410,69 @@ func TestJsonRpcBatchCall(t *testing.T) {
 	}()
 }
 
+func TestJsonRpcBatchCallPartialResults(t *testing.T) {
+	ctx := context.Background()
+	batchCallData := `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]},{"jsonrpc":"2.0","id":2,"method":"eth_getBalance","params":["bad"]},{"jsonrpc":"2.0","id":3,"method":"eth_blockNumber","params":[]}]`
+	playbook := []struct {
+		name     string
+		response string
+		expected string
+	}{
+		{
+			name:     "mixed-success-and-error",
+			response: `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"invalid argument 0"}},{"jsonrpc":"2.0","id":3,"result":"0x114b56b"}]`,
+			expected: `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"invalid argument 0"}},{"jsonrpc":"2.0","id":3,"result":"0x114b56b"}]`,
+		},
+		{
+			name:     "out-of-order-replies-keep-request-order",
+			response: `[{"jsonrpc":"2.0","id":3,"result":"0x114b56b"},{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"invalid argument 0"}},{"jsonrpc":"2.0","id":1,"result":"0x1"}]`,
+			expected: `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"invalid argument 0"}},{"jsonrpc":"2.0","id":3,"result":"0x114b56b"}]`,
+		},
+		{
+			name:     "missing-element",
+			response: `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":3,"result":"0x114b56b"}]`,
+			expected: `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"missing response for batch element"}},{"jsonrpc":"2.0","id":3,"result":"0x114b56b"}]`,
+		},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			serverHandle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, play.response)
+			})
+			chainParser, chainProxy, _, closeServer, err := CreateChainLibMocks(ctx, "ETH1", spectypes.APIInterfaceJsonRPC, serverHandle, "../../", nil)
+			require.NoError(t, err)
+			defer func() {
+				if closeServer != nil {
+					closeServer()
+				}
+			}()
+			chainMessage, err := chainParser.ParseMsg("", []byte(batchCallData), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			relayReply, _, _, _, _, err := chainProxy.SendNodeMsg(ctx, nil, chainMessage, nil)
+			require.NoError(t, err)
+			require.Equal(t, play.expected, string(relayReply.Data))
+		})
+	}
+
+	// a failure of the whole batch still fails the relay
+	serverHandle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `not a json`)
+	})
+	chainParser, chainProxy, _, closeServer, err := CreateChainLibMocks(ctx, "ETH1", spectypes.APIInterfaceJsonRPC, serverHandle, "../../", nil)
+	require.NoError(t, err)
+	defer func() {
+		if closeServer != nil {
+			closeServer()
+		}
+	}()
+	chainMessage, err := chainParser.ParseMsg("", []byte(batchCallData), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	_, _, _, _, _, err = chainProxy.SendNodeMsg(ctx, nil, chainMessage, nil)
+	require.Error(t, err)
+}
+
 func TestJsonRpcBatchCallSameID(t *testing.T) {
 	ctx := context.Background()
 	gotCalled := false
@@ -341,3 +513,234 @@ func TestJsonRpcBatchCallSameID(t *testing.T) {
 		}
 	}()
 }
+
+func TestJsonRpcIdRemappingConcurrentCollidingIds(t *testing.T) {
+	ctx := context.Background()
+	var lock sync.Mutex
+	nodeIds := map[string]struct{}{}
+	serverHandle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := rpcInterfaceMessages.JsonrpcMessage{}
+		err := json.NewDecoder(r.Body).Decode(&msg)
+		require.NoError(t, err)
+		lock.Lock()
+		nodeIds[string(msg.ID)] = struct{}{}
+		lock.Unlock()
+		params, ok := msg.Params.([]interface{})
+		require.True(t, ok)
+		// reply with the node facing id and echo the requested block as the result
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"%s"}`, string(msg.ID), params[0])
+	})
+
+	chainParser, chainProxy, _, closeServer, err := CreateChainLibMocks(ctx, "ETH1", spectypes.APIInterfaceJsonRPC, serverHandle, "../../", nil)
+	require.NoError(t, err)
+	defer func() {
+		if closeServer != nil {
+			closeServer()
+		}
+	}()
+
+	const relays = 10
+	var wg sync.WaitGroup
+	wg.Add(relays)
+	for i := 0; i < relays; i++ {
+		go func(i int) {
+			defer wg.Done()
+			block := fmt.Sprintf("0x%x", i+1)
+			// all the clients use the same id
+			data := fmt.Sprintf(`{"jsonrpc":"2.0","id":"client-id","method":"eth_getBlockByNumber","params":["%s",false]}`, block)
+			chainMessage, err := chainParser.ParseMsg("", []byte(data), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			relayReply, _, _, _, _, err := chainProxy.SendNodeMsg(ctx, nil, chainMessage, nil)
+			require.NoError(t, err)
+			reply := rpcInterfaceMessages.JsonrpcMessage{}
+			require.NoError(t, json.Unmarshal(relayReply.Data, &reply))
+			require.Equal(t, `"client-id"`, string(reply.ID))
+			require.Equal(t, `"`+block+`"`, string(reply.Result))
+		}(i)
+	}
+	wg.Wait()
+	// the node only sees internal ids
+	require.NotEmpty(t, nodeIds)
+	require.NotContains(t, nodeIds, `"client-id"`)
+}
+
+func TestJsonRpcNodeErrorCarriesClientRequestId(t *testing.T) {
+	ctx := context.Background()
+	serverHandle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+	})
+	chainParser, chainProxy, _, closeServer, err := CreateChainLibMocks(ctx, "ETH1", spectypes.APIInterfaceJsonRPC, serverHandle, "../../", nil)
+	require.NoError(t, err)
+	defer func() {
+		if closeServer != nil {
+			closeServer()
+		}
+	}()
+
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":"client-id","method":"eth_blockNumber","params":[]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	_, _, _, _, _, err = chainProxy.SendNodeMsg(ctx, nil, chainMessage, nil)
+	require.Error(t, err)
+	require.True(t, common.StatusCodeError504.Is(err))
+	require.Contains(t, err.Error(), `request id "client-id", method eth_blockNumber`)
+
+	chainMessage, err = chainParser.ParseMsg("", []byte(`[{"jsonrpc":"2.0","id":7,"method":"eth_chainId","params":[]},{"jsonrpc":"2.0","id":8,"method":"eth_blockNumber","params":[]}]`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	_, _, _, _, _, err = chainProxy.SendNodeMsg(ctx, nil, chainMessage, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "batch request ids [7,8], methods [eth_chainId,eth_blockNumber]")
+}
+
+func TestJSONParseMessageStateOverrideComputeUnits(t *testing.T) {
+	apip := &JsonRPCChainParser{
+		BaseChainParser: BaseChainParser{
+			serverApis: map[ApiKey]ApiContainer{
+				{Name: "eth_call", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:              "eth_call",
+					Enabled:           true,
+					ComputeUnits:      10,
+					ExtraComputeUnits: 1,
+					BlockParsing: spectypes.BlockParser{
+						ParserArg:  []string{"1"},
+						ParserFunc: spectypes.PARSER_FUNC_PARSE_BY_ARG,
+					},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+			},
+			apiCollections: map[CollectionKey]*spectypes.ApiCollection{{ConnectionType: connectionType_test}: {Enabled: true, CollectionData: spectypes.CollectionData{ApiInterface: spectypes.APIInterfaceJsonRPC}}},
+		},
+	}
+	plainCall := `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x00000000000000000000000000000000000000aa","data":"0x"},"latest"]}`
+	msg, err := apip.ParseMsg("", []byte(plainCall), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), GetComputeUnits(msg))
+
+	slots := []string{}
+	for i := 0; i < 100; i++ {
+		slots = append(slots, fmt.Sprintf(`"0x%x":"0x1"`, i))
+	}
+	overrideCall := `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x00000000000000000000000000000000000000aa","data":"0x"},"latest",` +
+		`{"0x00000000000000000000000000000000000000aa":{"balance":"0x1","stateDiff":{` + strings.Join(slots, ",") + `}}}]}`
+	msg, err = apip.ParseMsg("", []byte(overrideCall), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10+rpcInterfaceMessages.StateOverrideAccountComputeUnits+100*rpcInterfaceMessages.StateOverrideSlotComputeUnits), GetComputeUnits(msg))
+	// the override is passed to the node unchanged and the spec's api keeps its static cost
+	jsonMsg, ok := msg.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+	require.True(t, ok)
+	require.Len(t, jsonMsg.Params, 3)
+	require.Equal(t, uint64(10), apip.serverApis[ApiKey{Name: "eth_call", ConnectionType: connectionType_test}].api.ComputeUnits)
+
+	// batches add up each message's cost
+	msg, err = apip.ParseMsg("", []byte("["+plainCall+","+overrideCall+"]"), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.Equal(t, uint64(20+rpcInterfaceMessages.StateOverrideAccountComputeUnits+100*rpcInterfaceMessages.StateOverrideSlotComputeUnits), GetComputeUnits(msg))
+
+	invalidOverride := `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x00000000000000000000000000000000000000aa"},"latest",{"0xaa":"0x1"}]}`
+	_, err = apip.ParseMsg("", []byte(invalidOverride), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.Error(t, err)
+
+	// apis the spec didn't opt in keep their static cost
+	apip.serverApis[ApiKey{Name: "eth_call", ConnectionType: connectionType_test}].api.ExtraComputeUnits = 0
+	msg, err = apip.ParseMsg("", []byte(overrideCall), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), GetComputeUnits(msg))
+}
+
+func TestJSONParseMessageParamsValidation(t *testing.T) {
+	apip := &JsonRPCChainParser{
+		BaseChainParser: BaseChainParser{
+			serverApis: map[ApiKey]ApiContainer{
+				{Name: "eth_getBalance", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:         "eth_getBalance",
+					Enabled:      true,
+					ComputeUnits: 10,
+					BlockParsing: spectypes.BlockParser{ParserFunc: spectypes.PARSER_FUNC_EMPTY},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+			},
+			apiCollections: map[CollectionKey]*spectypes.ApiCollection{{ConnectionType: connectionType_test}: {Enabled: true, CollectionData: spectypes.CollectionData{ApiInterface: spectypes.APIInterfaceJsonRPC}}},
+		},
+	}
+	invalidRequest := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":[]}`
+	// no signature, nothing is validated
+	_, err := apip.ParseMsg("", []byte(invalidRequest), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+
+	signatures, err := CompileParamsSignatures(map[string][]string{"eth_getBalance": {"string", "string?"}})
+	require.NoError(t, err)
+	apip.SetParamsSignatures(signatures)
+	_, err = apip.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","latest"]}`), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	_, err = apip.ParseMsg("", []byte(invalidRequest), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.True(t, InvalidParamsError.Is(err))
+}
+
+func TestJSONParseMessageBlockTags(t *testing.T) {
+	apip := &JsonRPCChainParser{
+		BaseChainParser: BaseChainParser{
+			serverApis: map[ApiKey]ApiContainer{
+				{Name: "eth_getBalance", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:         "eth_getBalance",
+					Enabled:      true,
+					ComputeUnits: 10,
+					BlockParsing: spectypes.BlockParser{ParserArg: []string{"1"}, ParserFunc: spectypes.PARSER_FUNC_PARSE_BY_ARG},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+			},
+			apiCollections: map[CollectionKey]*spectypes.ApiCollection{{ConnectionType: connectionType_test}: {Enabled: true, CollectionData: spectypes.CollectionData{ApiInterface: spectypes.APIInterfaceJsonRPC}}},
+		},
+	}
+	for tag, expected := range map[string]int64{
+		"latest":    spectypes.LATEST_BLOCK,
+		"safe":      spectypes.SAFE_BLOCK,
+		"finalized": spectypes.FINALIZED_BLOCK,
+		"0x10":      16,
+	} {
+		t.Run(tag, func(t *testing.T) {
+			chainMessage, err := apip.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","`+tag+`"]}`), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			requestedBlock, _ := chainMessage.RequestedBlock()
+			require.Equal(t, expected, requestedBlock)
+		})
+	}
+}
+
+func TestJsonRpcNotification(t *testing.T) {
+	ctx := context.Background()
+	received := make(chan map[string]interface{}, 1)
+	serverHandle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+		// nodes don't reply to notifications
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	chainParser, chainProxy, _, closeServer, err := CreateChainLibMocks(ctx, "ETH1", spectypes.APIInterfaceJsonRPC, serverHandle, "../../", nil)
+	require.NoError(t, err)
+	defer func() {
+		if closeServer != nil {
+			closeServer()
+		}
+	}()
+
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.False(t, IsNotification(chainMessage))
+	chainMessage, err = chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":null,"method":"eth_chainId","params":[]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.False(t, IsNotification(chainMessage))
+	// batches are sent as regular requests
+	chainMessage, err = chainParser.ParseMsg("", []byte(`[{"jsonrpc":"2.0","method":"eth_chainId","params":[]}]`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.False(t, IsNotification(chainMessage))
+
+	chainMessage, err = chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.True(t, IsNotification(chainMessage))
+	relayReply, _, _, _, _, err := chainProxy.SendNodeMsg(ctx, nil, chainMessage, nil)
+	require.NoError(t, err)
+	require.NotNil(t, relayReply)
+	require.Empty(t, relayReply.Data)
+	body := <-received
+	require.Equal(t, "eth_chainId", body["method"])
+	require.NotContains(t, body, "id")
+}
@@ -0,0 +1,82 @@
+package chainlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lavanet/lava/protocol/common"
+)
+
+const JsonRPCGetCacheMaxAgeFlag = "jsonrpc-get-cache-max-age"
+
+// how long shared caches may serve a jsonrpc GET reply for a finalized block, 0 marks every GET reply as not cacheable
+var JsonRPCGetCacheMaxAge = time.Hour
+
+var (
+	JsonRPCGetRequestError = sdkerrors.New("JsonRPCGetRequest Error", 1107, "invalid jsonrpc GET request")
+	ReadOnlyRelayError     = sdkerrors.New("ReadOnlyRelay Error", 1108, "api with side effects can't be relayed as a read only request")
+)
+
+type readOnlyRelayContextKey struct{}
+
+// ContextWithReadOnlyRelay marks a relay that may only reach apis without side effects, like jsonrpc requests sent
+// over http GET that caches and proxies are free to repeat
+func ContextWithReadOnlyRelay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyRelayContextKey{}, true)
+}
+
+func IsReadOnlyRelay(ctx context.Context) bool {
+	readOnly, ok := ctx.Value(readOnlyRelayContextKey{}).(bool)
+	return ok && readOnly
+}
+
+// decodeJsonrpcGetRequest builds the jsonrpc request a GET request encodes in its query string, method is the api,
+// params its json encoded params and id defaults to 1, e.g. ?method=eth_getBalance&params=["0xaa","0x10"]
+func decodeJsonrpcGetRequest(method string, params string, id string) (string, error) {
+	if method == "" {
+		return "", sdkerrors.Wrap(JsonRPCGetRequestError, "missing method")
+	}
+	request := struct {
+		Jsonrpc string          `json:"jsonrpc"`
+		Id      json.RawMessage `json:"id"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+	}{Jsonrpc: "2.0", Id: json.RawMessage("1"), Method: method}
+	if params != "" {
+		trimmedParams := bytes.TrimSpace([]byte(params))
+		if !json.Valid(trimmedParams) || (trimmedParams[0] != '[' && trimmedParams[0] != '{') {
+			return "", sdkerrors.Wrapf(JsonRPCGetRequestError, "params must be a json array or object: %s", params)
+		}
+		request.Params = trimmedParams
+	}
+	if id != "" {
+		if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+			request.Id = json.RawMessage(id)
+		} else {
+			request.Id, _ = json.Marshal(id)
+		}
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", sdkerrors.Wrap(JsonRPCGetRequestError, err.Error())
+	}
+	return string(data), nil
+}
+
+// replies for a finalized block requested by its number won't change, other replies can't be cached. a block tag, even
+// the finalized one, resolves to another block as the chain advances
+func jsonrpcGetCacheControl(relayResult *common.RelayResult) string {
+	if relayResult == nil || !relayResult.Finalized || relayResult.BlockTag || JsonRPCGetCacheMaxAge <= 0 {
+		return "no-store"
+	}
+	return "public, max-age=" + strconv.FormatInt(int64(JsonRPCGetCacheMaxAge/time.Second), 10)
+}
+
+func jsonrpcGetRequestFromFiberContext(fiberCtx *fiber.Ctx) (string, error) {
+	return decodeJsonrpcGetRequest(fiberCtx.Query("method"), fiberCtx.Query("params"), fiberCtx.Query("id"))
+}
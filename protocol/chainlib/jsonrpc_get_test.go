@@ -0,0 +1,77 @@
+package chainlib
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJsonrpcGetRequest(t *testing.T) {
+	playbook := []struct {
+		name     string
+		method   string
+		params   string
+		id       string
+		expected string
+	}{
+		{name: "method only", method: "eth_blockNumber", expected: `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`},
+		{name: "positional params", method: "eth_getBalance", params: `["0xaa","0x10"]`, id: "7", expected: `{"jsonrpc":"2.0","id":7,"method":"eth_getBalance","params":["0xaa","0x10"]}`},
+		{name: "named params", method: "getblock", params: ` {"height":"10"} `, expected: `{"jsonrpc":"2.0","id":1,"method":"getblock","params":{"height":"10"}}`},
+		{name: "string id", method: "eth_chainId", id: "abc", expected: `{"jsonrpc":"2.0","id":"abc","method":"eth_chainId"}`},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			request, err := decodeJsonrpcGetRequest(play.method, play.params, play.id)
+			require.NoError(t, err)
+			require.JSONEq(t, play.expected, request)
+		})
+	}
+	for _, params := range []string{`"0xaa"`, `[`, `10`} {
+		_, err := decodeJsonrpcGetRequest("eth_getBalance", params, "")
+		require.True(t, JsonRPCGetRequestError.Is(err), params)
+	}
+	_, err := decodeJsonrpcGetRequest("", "", "")
+	require.True(t, JsonRPCGetRequestError.Is(err))
+}
+
+func TestJsonrpcGetRequestParsing(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+
+	request, err := decodeJsonrpcGetRequest("eth_getBalance", `["0xaa","0x10"]`, "")
+	require.NoError(t, err)
+	chainMessage, err := chainParser.ParseMsg("", []byte(request), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.Equal(t, "eth_getBalance", chainMessage.GetApi().Name)
+	require.Equal(t, []interface{}{"0xaa", "0x10"}, chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage).Params)
+	requestedBlock, _ := chainMessage.RequestedBlock()
+	require.Equal(t, int64(0x10), requestedBlock)
+	require.True(t, IsReadOnly(chainMessage))
+
+	request, err = decodeJsonrpcGetRequest("eth_sendRawTransaction", `["0xaa"]`, "")
+	require.NoError(t, err)
+	chainMessage, err = chainParser.ParseMsg("", []byte(request), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.False(t, IsReadOnly(chainMessage))
+}
+
+func TestJsonrpcGetCacheControl(t *testing.T) {
+	require.Equal(t, "no-store", jsonrpcGetCacheControl(nil))
+	require.Equal(t, "no-store", jsonrpcGetCacheControl(&common.RelayResult{}))
+	require.Equal(t, "public, max-age=3600", jsonrpcGetCacheControl(&common.RelayResult{Finalized: true}))
+	// the finalized tag moves with the chain
+	require.Equal(t, "no-store", jsonrpcGetCacheControl(&common.RelayResult{Finalized: true, BlockTag: true}))
+	defer func(maxAge time.Duration) { JsonRPCGetCacheMaxAge = maxAge }(JsonRPCGetCacheMaxAge)
+	JsonRPCGetCacheMaxAge = 0
+	require.Equal(t, "no-store", jsonrpcGetCacheControl(&common.RelayResult{Finalized: true}))
+}
@@ -0,0 +1,77 @@
+package chainlib
+
+import (
+	"path"
+	"strings"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+var MethodDisabledError = sdkerrors.New("MethodDisabled Error", 1102, "method is disabled on this endpoint")
+
+// MethodFilter rejects methods the portal operator disabled regardless of the spec,
+// patterns are method names, a prefix ending with * (debug_*) or a path.Match glob
+type MethodFilter struct {
+	allowed []string
+	denied  []string
+}
+
+// NewMethodFilter returns nil when no patterns are configured, a nil filter allows every method
+func NewMethodFilter(allowed []string, denied []string) (*MethodFilter, error) {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil, nil
+	}
+	for _, pattern := range append(append([]string{}, allowed...), denied...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, sdkerrors.Wrapf(err, "invalid method pattern %s", pattern)
+		}
+	}
+	return &MethodFilter{allowed: allowed, denied: denied}, nil
+}
+
+// Check returns MethodDisabledError if the method is denied or not allowed, the deny list takes precedence
+func (mf *MethodFilter) Check(method string) error {
+	if mf == nil {
+		return nil
+	}
+	if pattern, ok := matchMethodPattern(mf.denied, method); ok {
+		return sdkerrors.Wrapf(MethodDisabledError, "method %s matches the denied pattern %s", method, pattern)
+	}
+	if len(mf.allowed) == 0 {
+		return nil
+	}
+	if _, ok := matchMethodPattern(mf.allowed, method); !ok {
+		return sdkerrors.Wrapf(MethodDisabledError, "method %s is not in the allowed methods", method)
+	}
+	return nil
+}
+
+// CheckMessage checks every api a message calls, a batch is checked element by element so an allowed method can't
+// carry a disabled one past the filter
+func (mf *MethodFilter) CheckMessage(chainMessage ChainMessageForSend) error {
+	if mf == nil {
+		return nil
+	}
+	for _, apiName := range ApiNames(chainMessage) {
+		if err := mf.Check(apiName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchMethodPattern(patterns []string, method string) (string, bool) {
+	for _, pattern := range patterns {
+		if prefix, isPrefix := strings.CutSuffix(pattern, "*"); isPrefix && !strings.ContainsAny(prefix, "*?[\\") {
+			// plain prefixes also match across path separators, so rest namespaces can be disabled as a whole
+			if strings.HasPrefix(method, prefix) {
+				return pattern, true
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, method); matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
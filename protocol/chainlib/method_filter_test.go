@@ -0,0 +1,90 @@
+package chainlib
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodFilter(t *testing.T) {
+	playbook := []struct {
+		name     string
+		allowed  []string
+		denied   []string
+		enabled  []string
+		disabled []string
+	}{
+		{
+			name:    "no configuration",
+			enabled: []string{"eth_blockNumber", "debug_traceTransaction"},
+		},
+		{
+			name:     "deny list with namespace prefixes",
+			denied:   []string{"debug_*", "admin_*", "eth_sendRawTransaction"},
+			enabled:  []string{"eth_blockNumber", "eth_call", "debugger"},
+			disabled: []string{"debug_traceTransaction", "admin_peers", "eth_sendRawTransaction"},
+		},
+		{
+			name:     "allow only",
+			allowed:  []string{"eth_*", "net_version"},
+			enabled:  []string{"eth_blockNumber", "net_version"},
+			disabled: []string{"net_peerCount", "debug_traceTransaction", "web3_clientVersion"},
+		},
+		{
+			name:     "deny takes precedence over allow",
+			allowed:  []string{"eth_*"},
+			denied:   []string{"eth_send*"},
+			enabled:  []string{"eth_getBalance"},
+			disabled: []string{"eth_sendRawTransaction", "eth_sendTransaction"},
+		},
+		{
+			name:     "globs and rest prefixes",
+			denied:   []string{"eth_get?lock*", "/cosmos/tx/*"},
+			enabled:  []string{"eth_getBalance", "/cosmos/base/tendermint/v1beta1/blocks/latest"},
+			disabled: []string{"eth_getBlockByNumber", "/cosmos/tx/v1beta1/txs"},
+		},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			filter, err := NewMethodFilter(play.allowed, play.denied)
+			require.NoError(t, err)
+			for _, method := range play.enabled {
+				require.NoError(t, filter.Check(method), method)
+			}
+			for _, method := range play.disabled {
+				err := filter.Check(method)
+				require.True(t, MethodDisabledError.Is(err), method)
+			}
+		})
+	}
+
+	_, err := NewMethodFilter(nil, []string{"debug_[*"})
+	require.Error(t, err)
+}
+
+func TestMethodFilterBatch(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	batch, err := chainParser.ParseMsg("", []byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]},{"jsonrpc":"2.0","id":2,"method":"net_version","params":[]}]`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.Equal(t, []string{"eth_blockNumber", "net_version"}, ApiNames(batch))
+
+	// the batch's combined name starts with an allowed prefix, its elements are checked on their own
+	allowEth, err := NewMethodFilter([]string{"eth_*"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, allowEth.Check(batch.GetApi().Name))
+	require.True(t, MethodDisabledError.Is(allowEth.CheckMessage(batch)))
+	denyNet, err := NewMethodFilter(nil, []string{"net_version"})
+	require.NoError(t, err)
+	require.True(t, MethodDisabledError.Is(denyNet.CheckMessage(batch)))
+	allowBoth, err := NewMethodFilter([]string{"eth_*", "net_version"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, allowBoth.CheckMessage(batch))
+}
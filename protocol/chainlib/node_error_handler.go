@@ -10,6 +10,7 @@ import (
 	"strings"
 	"syscall"
 
+	sdkerrors "cosmossdk.io/errors"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
 	"github.com/lavanet/lava/protocol/common"
@@ -21,6 +22,22 @@ import (
 
 type genericErrorHandler struct{}
 
+// withClientRequest adds the id and method of the client's request to an error of the node call, the node was sent an
+// internal id that means nothing to the client
+func withClientRequest(err error, id json.RawMessage, method string) error {
+	return sdkerrors.Wrapf(err, "request id %s, method %s", id, method)
+}
+
+func withClientBatchRequest(err error, batch []rpcclient.BatchElemWithId) error {
+	ids := make([]string, len(batch))
+	methods := make([]string, len(batch))
+	for idx, elem := range batch {
+		ids[idx] = string(elem.ID)
+		methods[idx] = elem.Method
+	}
+	return sdkerrors.Wrapf(err, "batch request ids [%s], methods [%s]", strings.Join(ids, ","), strings.Join(methods, ","))
+}
+
 func (geh *genericErrorHandler) handleConnectionError(err error) error {
 	if err == net.ErrWriteToConnected {
 		return utils.LavaFormatProduction("Provider Side Failed Sending Message, Reason: Write to connected connection", nil)
@@ -0,0 +1,111 @@
+package chainlib
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+var InvalidParamsError = sdkerrors.New("InvalidParams Error", 1105, "request params don't match the method's declared signature")
+
+// ParamsSignature declares the positional params of a method. each param is a type or a | separated union of types
+// (string, number, integer, boolean, object, array, null or any), a trailing ? marks it optional, optional params
+// can only be followed by optional params
+type ParamsSignature struct {
+	params   [][]string
+	required int
+}
+
+// CompileParamsSignatures parses the configured signatures per method name, so malformed ones fail on startup
+func CompileParamsSignatures(signatures map[string][]string) (map[string]*ParamsSignature, error) {
+	compiled := make(map[string]*ParamsSignature, len(signatures))
+	for method, params := range signatures {
+		signature := &ParamsSignature{params: make([][]string, 0, len(params))}
+		optional := false
+		for idx, param := range params {
+			if strings.HasSuffix(param, "?") {
+				optional = true
+				param = strings.TrimSuffix(param, "?")
+			} else if optional {
+				return nil, fmt.Errorf("invalid params signature for method %s, required param %d follows an optional one", method, idx)
+			} else {
+				signature.required++
+			}
+			types := strings.Split(param, "|")
+			for _, paramType := range types {
+				switch paramType {
+				case "string", "number", "integer", "boolean", "object", "array", "null", "any":
+				default:
+					return nil, fmt.Errorf("invalid params signature for method %s, unsupported type %s", method, paramType)
+				}
+			}
+			signature.params = append(signature.params, types)
+		}
+		compiled[method] = signature
+	}
+	return compiled, nil
+}
+
+// Validate checks the params decoded from a json request match the signature, named params can't be matched to
+// positions so only the positional form is validated
+func (ps *ParamsSignature) Validate(params interface{}) error {
+	var positional []interface{}
+	switch typedParams := params.(type) {
+	case nil:
+	case []interface{}:
+		positional = typedParams
+	case map[string]interface{}:
+		return nil
+	default:
+		return sdkerrors.Wrapf(InvalidParamsError, "params must be an array or an object, got %T", params)
+	}
+	if len(positional) < ps.required {
+		return sdkerrors.Wrapf(InvalidParamsError, "expected at least %d params, got %d", ps.required, len(positional))
+	}
+	if len(positional) > len(ps.params) {
+		return sdkerrors.Wrapf(InvalidParamsError, "expected at most %d params, got %d", len(ps.params), len(positional))
+	}
+	for idx, value := range positional {
+		if !paramMatchesTypes(value, ps.params[idx]) {
+			return sdkerrors.Wrapf(InvalidParamsError, "param %d: expected type %s", idx, strings.Join(ps.params[idx], "|"))
+		}
+	}
+	return nil
+}
+
+func paramMatchesTypes(value interface{}, types []string) bool {
+	for _, paramType := range types {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			if paramType == "object" {
+				return true
+			}
+		case []interface{}:
+			if paramType == "array" {
+				return true
+			}
+		case string:
+			if paramType == "string" {
+				return true
+			}
+		case bool:
+			if paramType == "boolean" {
+				return true
+			}
+		case float64:
+			if paramType == "number" || (paramType == "integer" && typedValue == math.Trunc(typedValue)) {
+				return true
+			}
+		case nil:
+			if paramType == "null" {
+				return true
+			}
+		}
+		if paramType == "any" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package chainlib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamsSignatureValidate(t *testing.T) {
+	signatures, err := CompileParamsSignatures(map[string][]string{
+		"eth_getBalance":       {"string", "string|integer?"},
+		"eth_getBlockByNumber": {"string|integer", "boolean"},
+		"eth_blockNumber":      {},
+		"eth_call":             {"object", "any?", "object?"},
+	})
+	require.NoError(t, err)
+
+	playbook := []struct {
+		name   string
+		method string
+		params string
+		valid  bool
+	}{
+		{name: "all params", method: "eth_getBalance", params: `["0xaa","latest"]`, valid: true},
+		{name: "optional omitted", method: "eth_getBalance", params: `["0xaa"]`, valid: true},
+		{name: "union type", method: "eth_getBalance", params: `["0xaa",100]`, valid: true},
+		{name: "missing required", method: "eth_getBalance", params: `[]`, valid: false},
+		{name: "too many", method: "eth_getBalance", params: `["0xaa","latest","extra"]`, valid: false},
+		{name: "wrong type", method: "eth_getBlockByNumber", params: `["latest","true"]`, valid: false},
+		{name: "fractional integer", method: "eth_getBlockByNumber", params: `[1.5,true]`, valid: false},
+		{name: "no params", method: "eth_blockNumber", params: `null`, valid: true},
+		{name: "unexpected params", method: "eth_blockNumber", params: `[1]`, valid: false},
+		{name: "any", method: "eth_call", params: `[{"to":"0xaa"},null,{}]`, valid: true},
+		{name: "named params aren't positional", method: "eth_call", params: `{"tx":{}}`, valid: true},
+		{name: "scalar params", method: "eth_call", params: `"0xaa"`, valid: false},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			var params interface{}
+			require.NoError(t, json.Unmarshal([]byte(play.params), &params))
+			err := signatures[play.method].Validate(params)
+			if play.valid {
+				require.NoError(t, err)
+			} else {
+				require.True(t, InvalidParamsError.Is(err))
+			}
+		})
+	}
+}
+
+func TestCompileParamsSignaturesInvalid(t *testing.T) {
+	_, err := CompileParamsSignatures(map[string][]string{"eth_getBalance": {"string?", "string"}})
+	require.Error(t, err)
+	_, err = CompileParamsSignatures(map[string][]string{"eth_getBalance": {"address"}})
+	require.Error(t, err)
+}
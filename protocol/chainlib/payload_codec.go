@@ -0,0 +1,105 @@
+package chainlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// PayloadCodec converts request payloads to and from the form carried in RelayPrivateData.Data,
+// that form is what gets signed, hashed and cached so it must be canonical for the interface
+type PayloadCodec interface {
+	Name() string
+	// Encode returns the relay data for a raw request payload
+	Encode(payload []byte) ([]byte, error)
+	// Decode returns the raw request payload from relay data
+	Decode(data []byte) ([]byte, error)
+}
+
+var (
+	payloadCodecsLock sync.RWMutex
+	payloadCodecs     = map[string]PayloadCodec{
+		spectypes.APIInterfaceJsonRPC:       JSONPayloadCodec{},
+		spectypes.APIInterfaceTendermintRPC: JSONPayloadCodec{},
+		spectypes.APIInterfaceRest:          JSONPayloadCodec{},
+		spectypes.APIInterfaceGrpc:          ProtobufPayloadCodec{},
+	}
+)
+
+// RegisterPayloadCodec sets the codec used for an api interface, nil restores the default json codec
+func RegisterPayloadCodec(apiInterface string, codec PayloadCodec) {
+	payloadCodecsLock.Lock()
+	defer payloadCodecsLock.Unlock()
+	if codec == nil {
+		codec = JSONPayloadCodec{}
+	}
+	payloadCodecs[apiInterface] = codec
+}
+
+// GetPayloadCodec returns the codec for an api interface, interfaces without a registered codec use json
+func GetPayloadCodec(apiInterface string) PayloadCodec {
+	payloadCodecsLock.RLock()
+	defer payloadCodecsLock.RUnlock()
+	if codec, ok := payloadCodecs[apiInterface]; ok {
+		return codec
+	}
+	return JSONPayloadCodec{}
+}
+
+// JSONPayloadCodec compacts json payloads, so requests differing only in whitespace are signed and cached the same.
+// payloads that aren't json, like rest bodies of other content types, are kept as is
+type JSONPayloadCodec struct{}
+
+func (JSONPayloadCodec) Name() string {
+	return "json"
+}
+
+func (JSONPayloadCodec) Encode(payload []byte) ([]byte, error) {
+	if !json.Valid(payload) {
+		return payload, nil
+	}
+	compacted := bytes.NewBuffer(make([]byte, 0, len(payload)))
+	if err := json.Compact(compacted, payload); err != nil {
+		return payload, nil
+	}
+	return compacted.Bytes(), nil
+}
+
+func (JSONPayloadCodec) Decode(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// ProtobufPayloadCodec carries protobuf wire format payloads, it rejects payloads that are not valid wire format
+// so malformed binary requests fail before being signed and sent
+type ProtobufPayloadCodec struct{}
+
+func (ProtobufPayloadCodec) Name() string {
+	return "protobuf"
+}
+
+func (ProtobufPayloadCodec) Encode(payload []byte) ([]byte, error) {
+	return payload, validateProtobufWireFormat(payload)
+}
+
+func (ProtobufPayloadCodec) Decode(data []byte) ([]byte, error) {
+	return data, validateProtobufWireFormat(data)
+}
+
+func validateProtobufWireFormat(data []byte) error {
+	for len(data) > 0 {
+		fieldNum, wireType, tagLen := protowire.ConsumeTag(data)
+		if tagLen < 0 {
+			return utils.LavaFormatWarning("invalid protobuf payload tag", protowire.ParseError(tagLen), utils.LogAttr("remaining", len(data)))
+		}
+		valueLen := protowire.ConsumeFieldValue(fieldNum, wireType, data[tagLen:])
+		if valueLen < 0 {
+			return utils.LavaFormatWarning("invalid protobuf payload field", protowire.ParseError(valueLen), utils.LogAttr("field", fieldNum), utils.LogAttr("remaining", len(data)))
+		}
+		data = data[tagLen+valueLen:]
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package chainlib
+
+import (
+	"testing"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestPayloadCodecs(t *testing.T) {
+	// json interfaces keep the payload untouched
+	jsonPayload := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	for _, apiInterface := range []string{spectypes.APIInterfaceJsonRPC, spectypes.APIInterfaceTendermintRPC, spectypes.APIInterfaceRest, "unknown"} {
+		codec := GetPayloadCodec(apiInterface)
+		require.Equal(t, "json", codec.Name())
+		data, err := codec.Encode(jsonPayload)
+		require.NoError(t, err)
+		require.Equal(t, jsonPayload, data)
+		payload, err := codec.Decode(data)
+		require.NoError(t, err)
+		require.Equal(t, jsonPayload, payload)
+	}
+	// whitespace is dropped so equal requests have equal relay data
+	jsonCodec := GetPayloadCodec(spectypes.APIInterfaceJsonRPC)
+	data, err := jsonCodec.Encode([]byte("{\"jsonrpc\": \"2.0\", \"id\": 1,\n \"method\": \"eth_blockNumber\", \"params\": [ ]}\n"))
+	require.NoError(t, err)
+	require.Equal(t, jsonPayload, data)
+	// strings keep their spaces
+	data, err = jsonCodec.Encode([]byte(`{"name": "a b"}`))
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"a b"}`, string(data))
+	// payloads that aren't json are kept as is
+	for _, payload := range [][]byte{nil, []byte("a=1&b=2"), []byte("{\"truncated\": ")} {
+		data, err = jsonCodec.Encode(payload)
+		require.NoError(t, err)
+		require.Equal(t, payload, data)
+	}
+
+	codec := GetPayloadCodec(spectypes.APIInterfaceGrpc)
+	require.Equal(t, "protobuf", codec.Name())
+	protoPayload := protowire.AppendTag(nil, 1, protowire.BytesType)
+	protoPayload = protowire.AppendString(protoPayload, "lava")
+	protoPayload = protowire.AppendTag(protoPayload, 2, protowire.VarintType)
+	protoPayload = protowire.AppendVarint(protoPayload, 42)
+	data, err = codec.Encode(protoPayload)
+	require.NoError(t, err)
+	payload, err := codec.Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, protoPayload, payload)
+	// empty requests are valid protobuf
+	_, err = codec.Encode(nil)
+	require.NoError(t, err)
+	// truncated payloads are rejected
+	_, err = codec.Encode(protoPayload[:3])
+	require.Error(t, err)
+	_, err = codec.Decode(jsonPayload)
+	require.Error(t, err)
+
+	// overriding a codec and restoring the default
+	RegisterPayloadCodec(spectypes.APIInterfaceGrpc, JSONPayloadCodec{})
+	require.Equal(t, "json", GetPayloadCodec(spectypes.APIInterfaceGrpc).Name())
+	RegisterPayloadCodec(spectypes.APIInterfaceGrpc, ProtobufPayloadCodec{})
+	require.Equal(t, "protobuf", GetPayloadCodec(spectypes.APIInterfaceGrpc).Name())
+}
@@ -0,0 +1,188 @@
+package chainlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	sdkerrors "cosmossdk.io/errors"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+var ReplySchemaMismatchError = sdkerrors.New("ReplySchemaMismatch Error", 1101, "reply does not conform to the api's json schema")
+
+// ReplySchema is the subset of json schema used to validate replies: type, properties, required, items and enum
+type ReplySchema struct {
+	Types      []string                `json:"-"`
+	Properties map[string]*ReplySchema `json:"properties,omitempty"`
+	Required   []string                `json:"required,omitempty"`
+	Items      *ReplySchema            `json:"items,omitempty"`
+	Enum       []interface{}           `json:"enum,omitempty"`
+}
+
+func (rs *ReplySchema) UnmarshalJSON(data []byte) error {
+	type replySchemaAlias ReplySchema
+	schema := struct {
+		*replySchemaAlias
+		Type json.RawMessage `json:"type,omitempty"`
+	}{replySchemaAlias: (*replySchemaAlias)(rs)}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+	if len(schema.Type) == 0 {
+		return nil
+	}
+	// type is either a single type or a list of types
+	var singleType string
+	if err := json.Unmarshal(schema.Type, &singleType); err == nil {
+		rs.Types = []string{singleType}
+	} else if err := json.Unmarshal(schema.Type, &rs.Types); err != nil {
+		return fmt.Errorf("invalid schema type %s", string(schema.Type))
+	}
+	for _, schemaType := range rs.Types {
+		switch schemaType {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return fmt.Errorf("unsupported schema type %s", schemaType)
+		}
+	}
+	return nil
+}
+
+// CompileReplySchemas parses the configured schemas per api name, so malformed schemas fail on startup
+func CompileReplySchemas(schemas map[string]string) (map[string]*ReplySchema, error) {
+	compiled := make(map[string]*ReplySchema, len(schemas))
+	for apiName, schemaText := range schemas {
+		schema, err := CompileReplySchema(schemaText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply schema for api %s: %w", apiName, err)
+		}
+		compiled[apiName] = schema
+	}
+	return compiled, nil
+}
+
+// CompileReplySchema parses a single schema, like the one an api declares in the spec
+func CompileReplySchema(schemaText string) (*ReplySchema, error) {
+	schema := &ReplySchema{}
+	if err := json.Unmarshal([]byte(schemaText), schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// ValidateReply checks a reply against the schema, json-rpc style interfaces validate the result and skip node errors
+func (rs *ReplySchema) ValidateReply(apiInterface string, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var reply interface{}
+	if err := decoder.Decode(&reply); err != nil {
+		return sdkerrors.Wrapf(ReplySchemaMismatchError, "reply is not valid json: %s", err.Error())
+	}
+	if apiInterface == spectypes.APIInterfaceJsonRPC || apiInterface == spectypes.APIInterfaceTendermintRPC {
+		envelope, ok := reply.(map[string]interface{})
+		if !ok {
+			return sdkerrors.Wrap(ReplySchemaMismatchError, "reply is not a json-rpc object")
+		}
+		if replyError, ok := envelope["error"]; ok && replyError != nil {
+			// node errors are handled by the error checks, they don't have the result's shape
+			return nil
+		}
+		reply = envelope["result"]
+	}
+	if err := rs.validate(reply, "$"); err != nil {
+		return sdkerrors.Wrap(ReplySchemaMismatchError, err.Error())
+	}
+	return nil
+}
+
+func (rs *ReplySchema) validate(value interface{}, path string) error {
+	if len(rs.Types) > 0 && !rs.matchesType(value) {
+		return fmt.Errorf("%s: expected type %v", path, rs.Types)
+	}
+	if len(rs.Enum) > 0 {
+		found := false
+		for _, allowed := range rs.Enum {
+			if enumEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed values", path)
+		}
+	}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for _, required := range rs.Required {
+			if _, ok := typedValue[required]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, required)
+			}
+		}
+		for name, propertySchema := range rs.Properties {
+			if propertyValue, ok := typedValue[name]; ok {
+				if err := propertySchema.validate(propertyValue, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if rs.Items != nil {
+			for idx, item := range typedValue {
+				if err := rs.Items.validate(item, fmt.Sprintf("%s[%d]", path, idx)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (rs *ReplySchema) matchesType(value interface{}) bool {
+	for _, schemaType := range rs.Types {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			if schemaType == "object" {
+				return true
+			}
+		case []interface{}:
+			if schemaType == "array" {
+				return true
+			}
+		case string:
+			if schemaType == "string" {
+				return true
+			}
+		case bool:
+			if schemaType == "boolean" {
+				return true
+			}
+		case json.Number:
+			if schemaType == "number" {
+				return true
+			}
+			if _, err := typedValue.Int64(); err == nil && schemaType == "integer" {
+				return true
+			}
+		case nil:
+			if schemaType == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func enumEqual(allowed interface{}, value interface{}) bool {
+	if number, ok := value.(json.Number); ok {
+		// enum values are decoded without UseNumber
+		allowedNumber, ok := allowed.(float64)
+		if !ok {
+			return false
+		}
+		floatValue, err := number.Float64()
+		return err == nil && floatValue == allowedNumber
+	}
+	return reflect.DeepEqual(allowed, value)
+}
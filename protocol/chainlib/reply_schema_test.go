@@ -0,0 +1,53 @@
+package chainlib
+
+import (
+	"testing"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplySchemaValidation(t *testing.T) {
+	schemas, err := CompileReplySchemas(map[string]string{
+		"eth_getBlockByNumber": `{"type":["object","null"],"required":["number","hash"],"properties":{"number":{"type":"string"},"transactions":{"type":"array","items":{"type":"string"}}}}`,
+		"/status":              `{"type":"object","required":["height"],"properties":{"height":{"type":"integer"},"status":{"enum":["ok","syncing"]}}}`,
+	})
+	require.NoError(t, err)
+
+	blockSchema := schemas["eth_getBlockByNumber"]
+	playbook := []struct {
+		name         string
+		schema       *ReplySchema
+		apiInterface string
+		reply        string
+		valid        bool
+	}{
+		{name: "conforming jsonrpc result", schema: blockSchema, apiInterface: spectypes.APIInterfaceJsonRPC, reply: `{"jsonrpc":"2.0","id":1,"result":{"number":"0x1","hash":"0xab","transactions":["0x01"]}}`, valid: true},
+		{name: "null jsonrpc result", schema: blockSchema, apiInterface: spectypes.APIInterfaceJsonRPC, reply: `{"jsonrpc":"2.0","id":1,"result":null}`, valid: true},
+		{name: "missing required field", schema: blockSchema, apiInterface: spectypes.APIInterfaceJsonRPC, reply: `{"jsonrpc":"2.0","id":1,"result":{"number":"0x1"}}`, valid: false},
+		{name: "wrong item type", schema: blockSchema, apiInterface: spectypes.APIInterfaceJsonRPC, reply: `{"jsonrpc":"2.0","id":1,"result":{"number":"0x1","hash":"0xab","transactions":[1]}}`, valid: false},
+		{name: "node error is skipped", schema: blockSchema, apiInterface: spectypes.APIInterfaceJsonRPC, reply: `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bad"}}`, valid: true},
+		{name: "not json", schema: blockSchema, apiInterface: spectypes.APIInterfaceJsonRPC, reply: `<html>`, valid: false},
+		{name: "conforming rest body", schema: schemas["/status"], apiInterface: spectypes.APIInterfaceRest, reply: `{"height":10,"status":"ok"}`, valid: true},
+		{name: "rest non integer", schema: schemas["/status"], apiInterface: spectypes.APIInterfaceRest, reply: `{"height":1.5}`, valid: false},
+		{name: "rest value not in enum", schema: schemas["/status"], apiInterface: spectypes.APIInterfaceRest, reply: `{"height":10,"status":"down"}`, valid: false},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			err := play.schema.ValidateReply(play.apiInterface, []byte(play.reply))
+			if play.valid {
+				require.NoError(t, err)
+			} else {
+				require.True(t, ReplySchemaMismatchError.Is(err), err)
+			}
+		})
+	}
+
+	_, err = CompileReplySchemas(map[string]string{"bad": `{"type":"decimal"}`})
+	require.Error(t, err)
+	_, err = CompileReplySchemas(map[string]string{"bad": `not a schema`})
+	require.Error(t, err)
+	none, err := CompileReplySchemas(nil)
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
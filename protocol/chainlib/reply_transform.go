@@ -0,0 +1,130 @@
+package chainlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+)
+
+var ReplyTransformError = sdkerrors.New("ReplyTransform Error", 1110, "reply transform failed")
+
+// ReplyTransform rewrites a verified reply before it's returned to the user and cached, returning data as is skips the
+// reply. replies were already checked against the providers' signatures, transforms must keep their meaning the same
+// so clients see the data the provider signed, only in another shape
+type ReplyTransform func(chainMessage ChainMessageForSend, data []byte) ([]byte, error)
+
+// ReplyNormalizationRule canonicalizes the result of a jsonrpc method. paths are dot separated fields of the result, *
+// matches every array element or object field, an empty path is the result itself
+type ReplyNormalizationRule struct {
+	QuantityFields []string // hex quantities, rewritten without leading zeros in lower case (0x00AB -> 0xab)
+	DropNullFields bool     // object fields set to null are removed, so a present null and a missing field look the same
+}
+
+// NewReplyNormalizer canonicalizes the results of single jsonrpc replies by the rules of their method, so replies
+// from different nodes are the same bytes. normalized replies are encoded again with sorted keys and without html
+// escaping, error replies and methods without a rule are left as is
+func NewReplyNormalizer(rules map[string]ReplyNormalizationRule) ReplyTransform {
+	return func(chainMessage ChainMessageForSend, data []byte) ([]byte, error) {
+		jsonrpcMessage, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+		if !ok {
+			return data, nil
+		}
+		rule, ok := rules[jsonrpcMessage.Method]
+		if !ok {
+			return data, nil
+		}
+		var reply map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber() // numbers keep their precision
+		if err := decoder.Decode(&reply); err != nil {
+			return nil, sdkerrors.Wrap(ReplyTransformError, err.Error())
+		}
+		result, ok := reply["result"]
+		if !ok || reply["error"] != nil {
+			return data, nil
+		}
+		for _, path := range rule.QuantityFields {
+			result = normalizeAtPath(result, splitFieldPath(path), normalizeQuantity)
+		}
+		if rule.DropNullFields {
+			result = dropNullFields(result)
+		}
+		reply["result"] = result
+		buffer := &bytes.Buffer{}
+		encoder := json.NewEncoder(buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(reply); err != nil {
+			return nil, sdkerrors.Wrap(ReplyTransformError, err.Error())
+		}
+		return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+	}
+}
+
+func splitFieldPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// normalizeAtPath applies normalize to the values at path, values missing on the way are left as is
+func normalizeAtPath(value interface{}, path []string, normalize func(interface{}) interface{}) interface{} {
+	if len(path) == 0 {
+		return normalize(value)
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			if path[0] == "*" || path[0] == key {
+				typed[key] = normalizeAtPath(field, path[1:], normalize)
+			}
+		}
+	case []interface{}:
+		if path[0] != "*" {
+			return value
+		}
+		for idx, element := range typed {
+			typed[idx] = normalizeAtPath(element, path[1:], normalize)
+		}
+	}
+	return value
+}
+
+func normalizeQuantity(value interface{}) interface{} {
+	quantity, ok := value.(string)
+	if !ok {
+		return value
+	}
+	digits, ok := strings.CutPrefix(strings.ToLower(quantity), "0x")
+	if !ok || digits == "" {
+		return value
+	}
+	number, ok := new(big.Int).SetString(digits, 16)
+	if !ok || number.Sign() < 0 {
+		return value
+	}
+	return "0x" + number.Text(16)
+}
+
+func dropNullFields(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			if field == nil {
+				delete(typed, key)
+				continue
+			}
+			typed[key] = dropNullFields(field)
+		}
+	case []interface{}:
+		// array elements are positional, a null element stays
+		for idx, element := range typed {
+			typed[idx] = dropNullFields(element)
+		}
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package chainlib
+
+import (
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplyNormalizer(t *testing.T) {
+	apip := &JsonRPCChainParser{
+		BaseChainParser: BaseChainParser{
+			serverApis: map[ApiKey]ApiContainer{
+				{Name: "eth_getBlockByNumber", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:         "eth_getBlockByNumber",
+					Enabled:      true,
+					ComputeUnits: 10,
+					BlockParsing: spectypes.BlockParser{ParserFunc: spectypes.PARSER_FUNC_EMPTY},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+				{Name: "eth_chainId", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:         "eth_chainId",
+					Enabled:      true,
+					ComputeUnits: 10,
+					BlockParsing: spectypes.BlockParser{ParserFunc: spectypes.PARSER_FUNC_EMPTY},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+			},
+			apiCollections: map[CollectionKey]*spectypes.ApiCollection{{ConnectionType: connectionType_test}: {Enabled: true, CollectionData: spectypes.CollectionData{ApiInterface: spectypes.APIInterfaceJsonRPC}}},
+		},
+	}
+	normalizer := NewReplyNormalizer(map[string]ReplyNormalizationRule{
+		"eth_getBlockByNumber": {QuantityFields: []string{"number", "gasUsed", "baseFeePerGas", "transactions.*.nonce", "transactions.*.value"}, DropNullFields: true},
+		"eth_chainId":          {QuantityFields: []string{""}},
+	})
+	normalize := func(method string, reply string) string {
+		chainMessage, err := apip.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":[]}`), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+		require.NoError(t, err)
+		normalized, err := normalizer(chainMessage, []byte(reply))
+		require.NoError(t, err)
+		// normalizing twice changes nothing
+		again, err := normalizer(chainMessage, normalized)
+		require.NoError(t, err)
+		require.Equal(t, string(normalized), string(again))
+		return string(normalized)
+	}
+
+	// the same block from two nodes, one pads and upper cases quantities and sets unused fields to null, the other
+	// leaves them out and orders fields differently
+	paddedReply := `{"jsonrpc":"2.0","id":1,"result":{"number":"0x0010","hash":"0x00AB","gasUsed":"0x5208","baseFeePerGas":null,"logsBloom":"0x0000","transactions":[{"nonce":"0x01","value":"0x0DE0B6B3A7640000","input":"0x00","to":null}],"extraData":"<data>"}}`
+	minimalReply := `{"result":{"extraData":"<data>","transactions":[{"input":"0x00","value":"0xde0b6b3a7640000","nonce":"0x1"}],"gasUsed":"0x5208","hash":"0x00AB","logsBloom":"0x0000","number":"0x10"},"id":1,"jsonrpc":"2.0"}`
+	normalized := normalize("eth_getBlockByNumber", paddedReply)
+	require.Equal(t, normalized, normalize("eth_getBlockByNumber", minimalReply))
+	// only the configured fields are rewritten, hashes and data keep their bytes
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":{"extraData":"<data>","gasUsed":"0x5208","hash":"0x00AB","logsBloom":"0x0000","number":"0x10","transactions":[{"input":"0x00","nonce":"0x1","value":"0xde0b6b3a7640000"}]}}`, normalized)
+
+	// the result itself can be a quantity
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"0x1"}`, normalize("eth_chainId", `{"jsonrpc":"2.0","id":1,"result":"0x01"}`))
+	// numbers keep their precision
+	require.Equal(t, `{"id":12345678901234567890,"jsonrpc":"2.0","result":"0x1"}`, normalize("eth_chainId", `{"jsonrpc":"2.0","id":12345678901234567890,"result":"0x0001"}`))
+
+	// errors, non quantities and methods without a rule are left as is
+	errorReply := `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`
+	require.Equal(t, errorReply, normalize("eth_getBlockByNumber", errorReply))
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"latest"}`, normalize("eth_chainId", `{"jsonrpc":"2.0","id":1,"result":"latest"}`))
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"0x"}`, normalize("eth_chainId", `{"jsonrpc":"2.0","id":1,"result":"0x"}`))
+
+	chainMessage, err := apip.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	_, err = normalizer(chainMessage, []byte(`bad gateway`))
+	require.True(t, ReplyTransformError.Is(err))
+}
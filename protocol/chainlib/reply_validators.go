@@ -0,0 +1,123 @@
+package chainlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	sdkerrors "cosmossdk.io/errors"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+var RestPaginationViolationError = sdkerrors.New("RestPaginationViolation Error", 1112, "paginated rest reply is inconsistent with its pagination")
+
+// cosmos sdk's page size when the request doesn't set pagination.limit
+const restPaginationDefaultLimit = 100
+
+// ReplyValidator checks invariants of an api's reply that a schema can't express, providers failing it are penalized
+type ReplyValidator interface {
+	ValidateReply(relayData *pairingtypes.RelayPrivateData, data []byte) error
+}
+
+// NewReplyValidators builds the validators configured per api name, pagination validation is only available for rest
+func NewReplyValidators(apiInterface string, paginatedApis []string) (map[string][]ReplyValidator, error) {
+	validators := map[string][]ReplyValidator{}
+	if len(paginatedApis) > 0 && apiInterface != spectypes.APIInterfaceRest {
+		return nil, fmt.Errorf("pagination validation is only supported for %s, got %s", spectypes.APIInterfaceRest, apiInterface)
+	}
+	for _, apiName := range paginatedApis {
+		validators[apiName] = append(validators[apiName], RestPaginationValidator{})
+	}
+	return validators, nil
+}
+
+// RestPaginationValidator checks a cosmos rest list reply against its pagination: a page can't exceed the requested
+// limit, a page pointing to a next key must be full, and a first page of a counted query must have a next key when the
+// total has more items. replies without a pagination object or a single items list aren't checked
+type RestPaginationValidator struct{}
+
+type restPagination struct {
+	NextKey *string `json:"next_key"`
+	Total   string  `json:"total"`
+}
+
+func (RestPaginationValidator) ValidateReply(relayData *pairingtypes.RelayPrivateData, data []byte) error {
+	var reply map[string]json.RawMessage
+	if err := json.Unmarshal(data, &reply); err != nil {
+		// not a list reply, other checks handle malformed json
+		return nil
+	}
+	paginationData, ok := reply["pagination"]
+	if !ok || bytes.Equal(paginationData, []byte("null")) {
+		return nil
+	}
+	pagination := restPagination{}
+	if err := json.Unmarshal(paginationData, &pagination); err != nil {
+		return sdkerrors.Wrapf(RestPaginationViolationError, "malformed pagination: %s", err.Error())
+	}
+	items, ok := restPaginationItems(reply)
+	if !ok {
+		return nil
+	}
+	query := url.Values{}
+	if relayData != nil {
+		if parsedUrl, err := url.Parse(relayData.ApiUrl); err == nil {
+			query = parsedUrl.Query()
+		}
+	}
+	limit := uint64(restPaginationDefaultLimit)
+	if limitParam := query.Get("pagination.limit"); limitParam != "" {
+		parsedLimit, err := strconv.ParseUint(limitParam, 10, 64)
+		if err != nil || parsedLimit == 0 {
+			// the node applies its own default, nothing to compare to
+			return nil
+		}
+		limit = parsedLimit
+	}
+	hasNextKey := pagination.NextKey != nil && *pagination.NextKey != ""
+	if items > limit {
+		return sdkerrors.Wrapf(RestPaginationViolationError, "page has %d items, more than the requested limit %d", items, limit)
+	}
+	if hasNextKey && items < limit {
+		return sdkerrors.Wrapf(RestPaginationViolationError, "page has a next key but only %d items of the requested limit %d", items, limit)
+	}
+	firstPage := query.Get("pagination.key") == "" && (query.Get("pagination.offset") == "" || query.Get("pagination.offset") == "0")
+	if pagination.Total != "" && pagination.Total != "0" && firstPage {
+		total, err := strconv.ParseUint(pagination.Total, 10, 64)
+		if err != nil {
+			return sdkerrors.Wrapf(RestPaginationViolationError, "malformed pagination total %s", pagination.Total)
+		}
+		if total < items {
+			return sdkerrors.Wrapf(RestPaginationViolationError, "page has %d items, more than the total %d", items, total)
+		}
+		if total > items && !hasNextKey {
+			return sdkerrors.Wrapf(RestPaginationViolationError, "page has %d items of total %d but no next key", items, total)
+		}
+	}
+	return nil
+}
+
+// returns the length of the reply's only list field
+func restPaginationItems(reply map[string]json.RawMessage) (uint64, bool) {
+	found := false
+	var items uint64
+	for name, value := range reply {
+		if name == "pagination" || bytes.Equal(value, []byte("null")) {
+			continue
+		}
+		var list []json.RawMessage
+		if err := json.Unmarshal(value, &list); err != nil {
+			continue
+		}
+		if found {
+			// more than one list, can't tell which one is paginated
+			return 0, false
+		}
+		found = true
+		items = uint64(len(list))
+	}
+	return items, found
+}
@@ -0,0 +1,98 @@
+package chainlib
+
+import (
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestPaginationValidator(t *testing.T) {
+	const balancesUrl = "/cosmos/bank/v1beta1/balances/lava@addr"
+	playbook := []struct {
+		name   string
+		apiUrl string
+		reply  string
+		valid  bool
+	}{
+		{
+			name:   "full page with next key",
+			apiUrl: balancesUrl + "?pagination.limit=2",
+			reply:  `{"balances":[{"denom":"a"},{"denom":"b"}],"pagination":{"next_key":"Yw==","total":"0"}}`,
+			valid:  true,
+		},
+		{
+			name:   "counted first page with next key",
+			apiUrl: balancesUrl + "?pagination.limit=2&pagination.count_total=true",
+			reply:  `{"balances":[{"denom":"a"},{"denom":"b"}],"pagination":{"next_key":"Yw==","total":"3"}}`,
+			valid:  true,
+		},
+		{
+			name:   "final page",
+			apiUrl: balancesUrl + "?pagination.limit=2&pagination.key=Yw==",
+			reply:  `{"balances":[{"denom":"c"}],"pagination":{"next_key":null,"total":"0"}}`,
+			valid:  true,
+		},
+		{
+			name:   "empty final page",
+			apiUrl: balancesUrl,
+			reply:  `{"balances":[],"pagination":{"next_key":"","total":"0"}}`,
+			valid:  true,
+		},
+		{
+			name:   "not paginated",
+			apiUrl: "/cosmos/base/tendermint/v1beta1/blocks/latest",
+			reply:  `{"block_id":{"hash":"abc"},"block":{}}`,
+			valid:  true,
+		},
+		{
+			name:   "more items than the limit",
+			apiUrl: balancesUrl + "?pagination.limit=1",
+			reply:  `{"balances":[{"denom":"a"},{"denom":"b"}],"pagination":{"next_key":null,"total":"0"}}`,
+			valid:  false,
+		},
+		{
+			name:   "truncated page with next key",
+			apiUrl: balancesUrl + "?pagination.limit=3",
+			reply:  `{"balances":[{"denom":"a"}],"pagination":{"next_key":"Yw==","total":"0"}}`,
+			valid:  false,
+		},
+		{
+			name:   "missing next key while more items exist",
+			apiUrl: balancesUrl + "?pagination.limit=2&pagination.count_total=true",
+			reply:  `{"balances":[{"denom":"a"},{"denom":"b"}],"pagination":{"next_key":null,"total":"5"}}`,
+			valid:  false,
+		},
+		{
+			name:   "malformed pagination",
+			apiUrl: balancesUrl,
+			reply:  `{"balances":[],"pagination":"broken"}`,
+			valid:  false,
+		},
+	}
+	validator := RestPaginationValidator{}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			err := validator.ValidateReply(&pairingtypes.RelayPrivateData{ApiUrl: play.apiUrl}, []byte(play.reply))
+			if play.valid {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, RestPaginationViolationError.Is(err))
+		})
+	}
+}
+
+func TestNewReplyValidators(t *testing.T) {
+	validators, err := NewReplyValidators(spectypes.APIInterfaceRest, []string{"/cosmos/bank/v1beta1/balances/{address}"})
+	require.NoError(t, err)
+	require.Len(t, validators["/cosmos/bank/v1beta1/balances/{address}"], 1)
+
+	_, err = NewReplyValidators(spectypes.APIInterfaceJsonRPC, []string{"eth_getLogs"})
+	require.Error(t, err)
+	validators, err = NewReplyValidators(spectypes.APIInterfaceJsonRPC, nil)
+	require.NoError(t, err)
+	require.Empty(t, validators)
+}
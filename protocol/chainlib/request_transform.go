@@ -0,0 +1,160 @@
+package chainlib
+
+import (
+	"bytes"
+	"encoding/json"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+)
+
+var (
+	RequestTransformError   = sdkerrors.New("RequestTransform Error", 1106, "request transform failed")
+	BlockRangeTooLargeError = sdkerrors.New("BlockRangeTooLarge Error", 1113, "requested block range is larger than the portal allows, split it into smaller ranges")
+)
+
+// RequestTransform rewrites a parsed request before it's signed and sent, data is the request the message was parsed
+// from and the returned data replaces it, returning data as is skips the request. transforms must be deterministic,
+// the same request always transforming to the same data, so signatures and cache keys stay consistent
+type RequestTransform func(chainMessage ChainMessageForSend, data []byte) ([]byte, error)
+
+// rewrites a single jsonrpc request's positional params, batches, named params and other interfaces are skipped
+func transformJsonrpcParams(chainMessage ChainMessageForSend, data []byte, transform func(method string, params []interface{}) ([]interface{}, bool)) ([]byte, error) {
+	jsonrpcMessage, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+	if !ok {
+		return data, nil
+	}
+	if _, ok := jsonrpcMessage.Params.([]interface{}); !ok && jsonrpcMessage.Params != nil {
+		return data, nil
+	}
+	// the parsed message is shared with the caller and its params lost the precision of big numbers, the rewrite
+	// decodes its own copy
+	var request rpcInterfaceMessages.JsonrpcMessage
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&request); err != nil {
+		return nil, sdkerrors.Wrap(RequestTransformError, err.Error())
+	}
+	params, _ := request.Params.([]interface{})
+	transformedParams, modified := transform(jsonrpcMessage.Method, params)
+	if !modified {
+		return data, nil
+	}
+	request.Params = transformedParams
+	return json.Marshal(request)
+}
+
+// NewBlockRangeLimitTransform rejects jsonrpc filter requests, like eth_getLogs, spanning more blocks than their
+// method's limit, truncating the range would return partial results the client takes for complete ones. only numeric
+// ranges are checked, tags like latest depend on the chain's state
+func NewBlockRangeLimitTransform(maxRanges map[string]uint64) RequestTransform {
+	return func(chainMessage ChainMessageForSend, data []byte) ([]byte, error) {
+		var rangeErr error
+		transformed, err := transformJsonrpcParams(chainMessage, data, func(method string, params []interface{}) ([]interface{}, bool) {
+			maxRange := maxRanges[method]
+			if maxRange == 0 || len(params) == 0 {
+				return params, false
+			}
+			filter, ok := params[0].(map[string]interface{})
+			if !ok {
+				return params, false
+			}
+			fromBlock, fromOk := filter["fromBlock"].(string)
+			toBlock, toOk := filter["toBlock"].(string)
+			if !fromOk || !toOk {
+				return params, false
+			}
+			from, err := hexutil.DecodeUint64(fromBlock)
+			if err != nil {
+				return params, false
+			}
+			to, err := hexutil.DecodeUint64(toBlock)
+			if err != nil || to < from || to-from < maxRange {
+				return params, false
+			}
+			rangeErr = sdkerrors.Wrapf(BlockRangeTooLargeError, "%s spans %d blocks, the limit is %d", method, to-from+1, maxRange)
+			return params, false
+		})
+		if rangeErr != nil {
+			return nil, rangeErr
+		}
+		return transformed, err
+	}
+}
+
+// NewDefaultParamsTransform appends the trailing positional params a jsonrpc request omitted, defaults are per method
+// and positioned from the first param, e.g. eth_getBalance: [nil, "latest"] adds the block tag when only the address is set
+func NewDefaultParamsTransform(defaults map[string][]interface{}) RequestTransform {
+	return func(chainMessage ChainMessageForSend, data []byte) ([]byte, error) {
+		return transformJsonrpcParams(chainMessage, data, func(method string, params []interface{}) ([]interface{}, bool) {
+			methodDefaults, ok := defaults[method]
+			if !ok || len(params) >= len(methodDefaults) {
+				return params, false
+			}
+			for idx := len(params); idx < len(methodDefaults); idx++ {
+				if methodDefaults[idx] == nil {
+					// a required param without a default, the request is sent as is for the node to reject
+					return params, false
+				}
+			}
+			return append(append([]interface{}{}, params...), methodDefaults[len(params):]...), true
+		})
+	}
+}
+
+// NewMethodAliasTransform replaces jsonrpc method aliases with their canonical spec method, in single and batch
+// requests, so the canonical name is what's sent to the providers. other fields are kept as they were sent
+func NewMethodAliasTransform(aliases map[string]string) RequestTransform {
+	rewrite := func(request map[string]json.RawMessage) (bool, error) {
+		var method string
+		if err := json.Unmarshal(request["method"], &method); err != nil {
+			return false, nil
+		}
+		canonical, ok := aliases[method]
+		if !ok {
+			return false, nil
+		}
+		canonicalData, err := json.Marshal(canonical)
+		if err != nil {
+			return false, err
+		}
+		request["method"] = canonicalData
+		return true, nil
+	}
+	return func(chainMessage ChainMessageForSend, data []byte) ([]byte, error) {
+		if _, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage); !ok {
+			if _, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcBatchMessage); !ok {
+				return data, nil
+			}
+		}
+		var requests []map[string]json.RawMessage
+		batch := len(bytes.TrimSpace(data)) > 0 && bytes.TrimSpace(data)[0] == '['
+		if batch {
+			if err := json.Unmarshal(data, &requests); err != nil {
+				return nil, sdkerrors.Wrap(RequestTransformError, err.Error())
+			}
+		} else {
+			var request map[string]json.RawMessage
+			if err := json.Unmarshal(data, &request); err != nil {
+				return nil, sdkerrors.Wrap(RequestTransformError, err.Error())
+			}
+			requests = append(requests, request)
+		}
+		modified := false
+		for _, request := range requests {
+			rewritten, err := rewrite(request)
+			if err != nil {
+				return nil, sdkerrors.Wrap(RequestTransformError, err.Error())
+			}
+			modified = modified || rewritten
+		}
+		if !modified {
+			return data, nil
+		}
+		if batch {
+			return json.Marshal(requests)
+		}
+		return json.Marshal(requests[0])
+	}
+}
@@ -0,0 +1,81 @@
+package chainlib
+
+import (
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTransforms(t *testing.T) {
+	apip := &JsonRPCChainParser{
+		BaseChainParser: BaseChainParser{
+			serverApis: map[ApiKey]ApiContainer{
+				{Name: "eth_getLogs", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:         "eth_getLogs",
+					Enabled:      true,
+					ComputeUnits: 10,
+					BlockParsing: spectypes.BlockParser{ParserFunc: spectypes.PARSER_FUNC_EMPTY},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+				{Name: "eth_getBalance", ConnectionType: connectionType_test}: {api: &spectypes.Api{
+					Name:         "eth_getBalance",
+					Enabled:      true,
+					ComputeUnits: 10,
+					BlockParsing: spectypes.BlockParser{ParserArg: []string{"1"}, ParserFunc: spectypes.PARSER_FUNC_PARSE_BY_ARG, DefaultValue: "latest"},
+				}, collectionKey: CollectionKey{ConnectionType: connectionType_test}},
+			},
+			apiCollections: map[CollectionKey]*spectypes.ApiCollection{{ConnectionType: connectionType_test}: {Enabled: true, CollectionData: spectypes.CollectionData{ApiInterface: spectypes.APIInterfaceJsonRPC}}},
+		},
+	}
+	transform := func(transform RequestTransform, req string) string {
+		chainMessage, err := apip.ParseMsg("", []byte(req), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+		require.NoError(t, err)
+		transformed, err := transform(chainMessage, []byte(req))
+		require.NoError(t, err)
+		// deterministic
+		again, err := transform(chainMessage, []byte(req))
+		require.NoError(t, err)
+		require.Equal(t, transformed, again)
+		return string(transformed)
+	}
+
+	t.Run("block range limit", func(t *testing.T) {
+		limit := NewBlockRangeLimitTransform(map[string]uint64{"eth_getLogs": 100})
+		req := `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"address":"0xaa","fromBlock":"0x100","toBlock":"0x1000"}]}`
+		chainMessage, err := apip.ParseMsg("", []byte(req), connectionType_test, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+		require.NoError(t, err)
+		_, err = limit(chainMessage, []byte(req))
+		require.True(t, BlockRangeTooLargeError.Is(err))
+
+		// within the range, tags and other methods are sent as is
+		for _, req := range []string{
+			`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x100","toBlock":"0x163"}]}`,
+			`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x100","toBlock":"latest"}]}`,
+			`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"blockHash":"0xbb"}]}`,
+			`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x100"]}`,
+		} {
+			require.Equal(t, req, transform(limit, req))
+		}
+	})
+
+	t.Run("default params", func(t *testing.T) {
+		defaults := NewDefaultParamsTransform(map[string][]interface{}{"eth_getBalance": {nil, "latest"}})
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":"a","method":"eth_getBalance","params":["0xaa","latest"]}`, transform(defaults, `{"jsonrpc":"2.0","id":"a","method":"eth_getBalance","params":["0xaa"]}`))
+		// set params aren't overridden and required params aren't made up
+		for _, req := range []string{
+			`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`,
+			`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":[]}`,
+		} {
+			require.Equal(t, req, transform(defaults, req))
+		}
+	})
+
+	t.Run("numbers keep their precision", func(t *testing.T) {
+		defaults := NewDefaultParamsTransform(map[string][]interface{}{"eth_getBalance": {nil, "latest"}})
+		req := `{"jsonrpc":"2.0","id":18446744073709551615,"method":"eth_getBalance","params":[9007199254740993]}`
+		transformed := transform(defaults, req)
+		require.Contains(t, transformed, `"params":[9007199254740993,"latest"]`)
+		require.Contains(t, transformed, `"id":18446744073709551615`)
+	})
+}
@@ -5,18 +5,19 @@ import (
 	"context"
 	"errors"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	sdkerrors "cosmossdk.io/errors"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
 	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
 	"github.com/lavanet/lava/protocol/lavasession"
-	"github.com/lavanet/lava/protocol/parser"
 	"github.com/lavanet/lava/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -29,6 +30,43 @@ import (
 	spectypes "github.com/lavanet/lava/x/spec/types"
 )
 
+var RestResponseTooLargeError = sdkerrors.New("RestResponseTooLarge Error", 1103, "rest response exceeds the maximum response size")
+
+const MaxRestResponseSizeFlagName = "max-rest-response-size"
+
+// rest responses larger than this are rejected instead of buffered, a bigger reply can't be relayed to the consumer anyway
+var MaxRestResponseSize int64 = chainproxy.MaxCallRecvMsgSize
+
+const RestGzipResponsesFlagName = "rest-gzip-responses"
+
+// when set, rest nodes are asked for gzip compressed responses, they are decompressed before the reply is signed
+var RestGzipResponses = true
+
+// readRestResponseBody reads the body into a buffer sized from the content length when it's known, so large responses
+// are not copied over and over while growing, and stops reading once the size cap is crossed.
+// the body isn't streamed to the consumer: a relay reply is one signed grpc message, the whole body is needed for
+// the signature and the reply. streaming it needs a streaming relay and a signature over chunks, a protocol change
+func readRestResponseBody(res *http.Response, maxSize int64) ([]byte, error) {
+	if res.Body == nil {
+		return []byte{}, nil
+	}
+	if res.ContentLength > maxSize {
+		return nil, sdkerrors.Wrapf(RestResponseTooLargeError, "content length %d, limit %d", res.ContentLength, maxSize)
+	}
+	buffer := bytes.Buffer{}
+	if res.ContentLength > 0 {
+		buffer.Grow(int(res.ContentLength))
+	}
+	read, err := buffer.ReadFrom(io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if read > maxSize {
+		return nil, sdkerrors.Wrapf(RestResponseTooLargeError, "limit %d", maxSize)
+	}
+	return buffer.Bytes(), nil
+}
+
 type RestChainParser struct {
 	BaseChainParser
 }
@@ -116,9 +154,9 @@ func (apip *RestChainParser) ParseMsg(urlPath string, data []byte, connectionTyp
 	var requestedBlock int64
 	if overwriteReqBlock == "" {
 		// Fetch requested block, it is used for data reliability
-		requestedBlock, err = parser.ParseBlockFromParams(restMessage, blockParser)
+		requestedBlock, err = apip.blockParsers.ParseRequestedBlock(apiCont.api.Name, restMessage, blockParser)
 		if err != nil {
-			utils.LavaFormatError("ParseBlockFromParams failed parsing block", err,
+			utils.LavaFormatError("ParseRequestedBlock failed parsing block", err,
 				utils.LogAttr("chain", apip.spec.Name),
 				utils.LogAttr("blockParsing", apiCont.api.BlockParsing),
 				utils.LogAttr("apiName", apiCont.api.Name),
@@ -421,6 +459,18 @@ func (apil *RestChainListener) Serve(ctx context.Context, cmdFlags common.Consum
 	ListenWithRetry(app, apil.endpoint.NetworkAddress)
 }
 
+// replies without a content type are treated as json, like cosmos rest endpoints reply
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == fiber.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json")
+}
+
 func addHeadersAndSendString(c *fiber.Ctx, metaData []pairingtypes.Metadata, data string) error {
 	for _, value := range metaData {
 		c.Set(value.Name, value.Value)
@@ -432,9 +482,10 @@ func addHeadersAndSendString(c *fiber.Ctx, metaData []pairingtypes.Metadata, dat
 type RestChainProxy struct {
 	BaseChainProxy
 	httpClient *http.Client
+	keepalive  chainproxy.KeepaliveOptions
 }
 
-func NewRestChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainProxy, error) {
+func NewRestChainProxy(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainProxy, error) {
 	if len(rpcProviderEndpoint.NodeUrls) == 0 {
 		return nil, utils.LavaFormatError("rpcProviderEndpoint.NodeUrl list is empty missing node url", nil, utils.Attribute{Key: "chainID", Value: rpcProviderEndpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: rpcProviderEndpoint.ApiInterface})
 	}
@@ -443,6 +494,7 @@ func NewRestChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint lav
 	nodeUrl.Url = strings.TrimSuffix(rpcProviderEndpoint.NodeUrls[0].Url, "/")
 	rcp := &RestChainProxy{
 		BaseChainProxy: BaseChainProxy{averageBlockTime: averageBlockTime, NodeUrl: rpcProviderEndpoint.NodeUrls[0], ErrorHandler: &RestErrorHandler{}, ChainID: rpcProviderEndpoint.ChainID},
+		keepalive:      keepalive,
 	}
 	return rcp, nil
 }
@@ -452,8 +504,13 @@ func (rcp *RestChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 		return nil, "", nil, utils.LavaFormatError("Subscribe is not allowed on rest", nil)
 	}
 	if rcp.httpClient == nil {
+		// compression is handled explicitly so it can be turned off and the decompressed size can be capped
+		transport := rcp.keepalive.HttpTransport(common.OutboundProxyTransport(rcp.NodeUrl.OutboundProxy())).Clone()
+		transport.DisableCompression = true
 		rcp.httpClient = &http.Client{
-			Timeout: 5 * time.Minute, // we are doing a timeout by request
+			Timeout:       5 * time.Minute, // we are doing a timeout by request
+			Transport:     transport,
+			CheckRedirect: rcp.NodeUrl.CheckRedirect,
 		}
 	}
 	httpClient := rcp.httpClient
@@ -493,6 +550,12 @@ func (rcp *RestChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 	}
 	rcp.NodeUrl.SetAuthHeaders(ctx, req.Header.Set)
 	rcp.NodeUrl.SetIpForwardingIfNecessary(ctx, req.Header.Set)
+	// an encoding requested in the message headers is passed through to the consumer as is
+	requestedGzip := false
+	if RestGzipResponses && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+		requestedGzip = true
+	}
 
 	if debug {
 		utils.LavaFormatDebug("provider sending node message",
@@ -526,9 +589,18 @@ func (rcp *RestChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 		return nil, "", nil, utils.LavaFormatWarning("Received invalid status code", nil, utils.Attribute{Key: "Status Code", Value: res.StatusCode}, utils.Attribute{Key: "chainID", Value: rcp.BaseChainProxy.ChainID}, utils.Attribute{Key: "apiName", Value: chainMessage.GetApi().Name})
 	}
 
-	body, err := io.ReadAll(res.Body)
+	body, err := readRestResponseBody(res, MaxRestResponseSize)
 	if err != nil {
-		return nil, "", nil, err
+		return nil, "", nil, utils.LavaFormatWarning("failed reading rest response", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "apiName", Value: chainMessage.GetApi().Name})
+	}
+	if requestedGzip && strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		body, err = common.DecompressReplyData(common.ReplyEncodingGzip, body, MaxRestResponseSize)
+		if err != nil {
+			return nil, "", nil, utils.LavaFormatWarning("failed decompressing rest response", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "apiName", Value: chainMessage.GetApi().Name})
+		}
+		// the reply carries the decompressed body, the node's encoding headers no longer describe it
+		res.Header.Del("Content-Encoding")
+		res.Header.Del("Content-Length")
 	}
 
 	reply := &pairingtypes.RelayReply{
@@ -536,10 +608,13 @@ func (rcp *RestChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 		Metadata: convertToMetadataMapOfSlices(res.Header),
 	}
 
-	// checking if rest reply data is in json format
-	err = rcp.HandleJSONFormatError(reply.Data)
-	if err != nil {
-		return nil, "", nil, utils.LavaFormatError("Rest reply is neither a JSON object nor a JSON array of objects", nil, utils.Attribute{Key: "reply.Data", Value: string(reply.Data)})
+	// checking if rest reply data is in json format, other content types are passed through as is
+	contentType := res.Header.Get(fiber.HeaderContentType)
+	if isJSONContentType(contentType) {
+		err = rcp.HandleJSONFormatError(reply.Data)
+		if err != nil {
+			return nil, "", nil, utils.LavaFormatError("Rest reply is neither a JSON object nor a JSON array of objects", nil, utils.Attribute{Key: "reply.Data", Value: string(reply.Data)}, utils.LogAttr("contentType", contentType))
+		}
 	}
 
 	return reply, "", nil, nil
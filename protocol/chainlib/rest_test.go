@@ -1,16 +1,20 @@
 package chainlib
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
 	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/parser"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
 	spectypes "github.com/lavanet/lava/x/spec/types"
@@ -153,6 +157,195 @@ func TestRestChainProxy(t *testing.T) {
 	}
 }
 
+func TestRestChainProxyContentTypes(t *testing.T) {
+	ctx := context.Background()
+	playbook := []struct {
+		name        string
+		contentType string
+		body        string
+		shouldFail  bool
+	}{
+		{name: "json", contentType: "application/json", body: `{"block": { "header": {"height": "244591"}}}`},
+		{name: "json-with-charset", contentType: "application/json; charset=utf-8", body: `{"block": { "header": {"height": "244591"}}}`},
+		{name: "invalid-json", contentType: "application/json", body: `not json`, shouldFail: true},
+		{name: "plain-text", contentType: "text/plain; charset=utf-8", body: `not json`},
+		{name: "protobuf", contentType: "application/x-protobuf", body: "\x08\x96\x01"},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			serverHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "blocks/17") {
+					w.Header().Set("Content-Type", play.contentType)
+					w.WriteHeader(http.StatusOK)
+					fmt.Fprint(w, play.body)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"block": { "header": {"height": "244591"}}}`)
+			})
+			chainParser, chainRouter, _, closeServer, err := CreateChainLibMocks(ctx, "LAV1", spectypes.APIInterfaceRest, serverHandler, "../../", nil)
+			require.NoError(t, err)
+			defer func() {
+				if closeServer != nil {
+					closeServer()
+				}
+			}()
+
+			chainMsg, err := chainParser.ParseMsg("/cosmos/base/tendermint/v1beta1/blocks/17", nil, http.MethodGet, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			// requested block and compute units don't depend on the reply content type
+			reqBlock, _ := chainMsg.RequestedBlock()
+			require.Equal(t, int64(17), reqBlock)
+			require.Greater(t, chainMsg.GetApi().ComputeUnits, uint64(0))
+
+			reply, _, _, _, _, err := chainRouter.SendNodeMsg(ctx, nil, chainMsg, nil)
+			if play.shouldFail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, play.body, string(reply.Data))
+
+			// the content type isn't signed but is passed through to the client
+			_, _, ignoredMetadata := chainParser.HandleHeaders(reply.Metadata, chainMsg.GetApiCollection(), spectypes.Header_pass_reply)
+			found := false
+			for _, header := range ignoredMetadata {
+				if strings.EqualFold(header.Name, "content-type") {
+					require.Equal(t, play.contentType, header.Value)
+					found = true
+				}
+			}
+			require.True(t, found)
+		})
+	}
+}
+
+func TestRestChainProxyResponseSizeCap(t *testing.T) {
+	ctx := context.Background()
+	defer func(maxSize int64) { MaxRestResponseSize = maxSize }(MaxRestResponseSize)
+	MaxRestResponseSize = 1024
+	playbook := []struct {
+		name       string
+		size       int
+		chunked    bool
+		shouldFail bool
+	}{
+		{name: "under the cap", size: 1000},
+		{name: "at the cap", size: 1024},
+		{name: "over the cap with content length", size: 1025, shouldFail: true},
+		{name: "under the cap chunked", size: 1000, chunked: true},
+		{name: "over the cap chunked", size: 4096, chunked: true, shouldFail: true},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			body := `{"a":"` + strings.Repeat("a", play.size-8) + `"}`
+			serverHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if !play.chunked {
+					w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				}
+				w.WriteHeader(http.StatusOK)
+				if play.chunked {
+					// flushing before the body is complete forces a chunked response without a content length
+					for chunk := body; len(chunk) > 0; {
+						size := 512
+						if len(chunk) < size {
+							size = len(chunk)
+						}
+						fmt.Fprint(w, chunk[:size])
+						w.(http.Flusher).Flush()
+						chunk = chunk[size:]
+					}
+					return
+				}
+				fmt.Fprint(w, body)
+			})
+			chainParser, chainRouter, _, closeServer, err := CreateChainLibMocks(ctx, "LAV1", spectypes.APIInterfaceRest, serverHandler, "../../", nil)
+			require.NoError(t, err)
+			defer func() {
+				if closeServer != nil {
+					closeServer()
+				}
+			}()
+			chainMsg, err := chainParser.ParseMsg("/cosmos/base/tendermint/v1beta1/blocks/17", nil, http.MethodGet, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			reply, _, _, _, _, err := chainRouter.SendNodeMsg(ctx, nil, chainMsg, nil)
+			if play.shouldFail {
+				require.True(t, RestResponseTooLargeError.Is(err), err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, body, string(reply.Data))
+		})
+	}
+}
+
+func TestRestChainProxyGzip(t *testing.T) {
+	ctx := context.Background()
+	defer func(maxSize int64, gzipResponses bool) {
+		MaxRestResponseSize = maxSize
+		RestGzipResponses = gzipResponses
+	}(MaxRestResponseSize, RestGzipResponses)
+	MaxRestResponseSize = 1024
+	playbook := []struct {
+		name           string
+		gzipResponses  bool
+		acceptEncoding string // the encoding the node receives
+		compress       bool
+		size           int
+		shouldFail     bool
+	}{
+		{name: "gzip response", gzipResponses: true, acceptEncoding: "gzip", compress: true, size: 1000},
+		{name: "node ignores gzip", gzipResponses: true, acceptEncoding: "gzip", size: 1000},
+		{name: "gzip disabled", gzipResponses: false, acceptEncoding: "", size: 1000},
+		{name: "decompressed over the cap", gzipResponses: true, acceptEncoding: "gzip", compress: true, size: 4096, shouldFail: true},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			RestGzipResponses = play.gzipResponses
+			body := `{"a":"` + strings.Repeat("a", play.size-8) + `"}`
+			serverHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, play.acceptEncoding, r.Header.Get("Accept-Encoding"))
+				w.Header().Set("Content-Type", "application/json")
+				if play.compress {
+					compressed := bytes.Buffer{}
+					gzipWriter := gzip.NewWriter(&compressed)
+					_, err := gzipWriter.Write([]byte(body))
+					require.NoError(t, err)
+					require.NoError(t, gzipWriter.Close())
+					w.Header().Set("Content-Encoding", "gzip")
+					w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+					w.WriteHeader(http.StatusOK)
+					w.Write(compressed.Bytes())
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, body)
+			})
+			chainParser, chainRouter, _, closeServer, err := CreateChainLibMocks(ctx, "LAV1", spectypes.APIInterfaceRest, serverHandler, "../../", nil)
+			require.NoError(t, err)
+			defer func() {
+				if closeServer != nil {
+					closeServer()
+				}
+			}()
+			chainMsg, err := chainParser.ParseMsg("/cosmos/base/tendermint/v1beta1/blocks/17", nil, http.MethodGet, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			reply, _, _, _, _, err := chainRouter.SendNodeMsg(ctx, nil, chainMsg, nil)
+			if play.shouldFail {
+				require.True(t, common.ReplyDecompressionError.Is(err), err)
+				return
+			}
+			require.NoError(t, err)
+			// the signed data is the decompressed body
+			require.Equal(t, body, string(reply.Data))
+			for _, header := range reply.Metadata {
+				require.False(t, strings.EqualFold(header.Name, "content-encoding"), header)
+			}
+		})
+	}
+}
+
 func TestParsingRequestedBlocksHeadersRest(t *testing.T) {
 	ctx := context.Background()
 	callbackHeaderNameToCheck := ""
@@ -0,0 +1,129 @@
+package chainlib
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+const (
+	SubscriptionBufferSizeFlagName   = "subscription-buffer-size"
+	SubscriptionBackpressureFlagName = "subscription-backpressure"
+	// method of the notification replacing events dropped from a subscription
+	SubscriptionGapMethod = "lava_subscriptionGap"
+)
+
+// SubscriptionBackpressurePolicy selects what happens to a subscription whose subscriber reads slower than the node
+// pushes events
+type SubscriptionBackpressurePolicy string
+
+const (
+	SubscriptionDropOldest SubscriptionBackpressurePolicy = "drop-oldest" // the oldest events are dropped, the subscriber gets a gap notification instead
+	SubscriptionDisconnect SubscriptionBackpressurePolicy = "disconnect"  // the subscription is ended
+)
+
+var (
+	// events buffered per subscription before the backpressure policy applies
+	SubscriptionBufferSize   = 1000
+	SubscriptionBackpressure = SubscriptionDropOldest
+)
+
+var SubscriptionBufferOverflowError = sdkerrors.New("SubscriptionBufferOverflow Error", 1111, "subscriber is too slow, subscription events buffer is full")
+
+func (sp *SubscriptionBackpressurePolicy) String() string {
+	return string(*sp)
+}
+
+func (sp *SubscriptionBackpressurePolicy) Set(str string) error {
+	switch SubscriptionBackpressurePolicy(str) {
+	case SubscriptionDropOldest, SubscriptionDisconnect:
+		*sp = SubscriptionBackpressurePolicy(str)
+		return nil
+	}
+	return fmt.Errorf("invalid subscription backpressure policy: %s, expected %s or %s", str, SubscriptionDropOldest, SubscriptionDisconnect)
+}
+
+func (sp *SubscriptionBackpressurePolicy) Type() string {
+	return "string"
+}
+
+// SubscriptionGap takes the place of events dropped from a subscription, it's sent as a jsonrpc notification
+type SubscriptionGap struct {
+	Dropped uint64
+}
+
+func (sg *SubscriptionGap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  SubscriptionGapMethod,
+		"params":  map[string]uint64{"dropped": sg.Dropped},
+	})
+}
+
+// BufferSubscription reads the events pushed to in as they arrive and hands them to out in order, so a slow reader of
+// out doesn't block the node connection. at most size events are held, past that policy applies: drop-oldest replaces
+// the dropped events with a SubscriptionGap, disconnect sends SubscriptionBufferOverflowError on overflow and stops.
+// out is closed when in is closed and drained, or when ctx is done
+func BufferSubscription(ctx context.Context, in <-chan interface{}, size int, policy SubscriptionBackpressurePolicy) (out <-chan interface{}, overflow <-chan error) {
+	if size <= 0 {
+		size = 1
+	}
+	outChan := make(chan interface{})
+	overflowChan := make(chan error, 1)
+	go func() {
+		defer close(outChan)
+		queue := list.New()
+		events := 0 // queued events, gaps not included
+		for {
+			var sendChan chan interface{}
+			var next interface{}
+			if front := queue.Front(); front != nil {
+				sendChan = outChan
+				next = front.Value
+			} else if in == nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-in:
+				if !ok {
+					in = nil // drain what's queued
+					continue
+				}
+				queue.PushBack(event)
+				events++
+				if events <= size {
+					continue
+				}
+				if policy == SubscriptionDisconnect {
+					overflowChan <- sdkerrors.Wrapf(SubscriptionBufferOverflowError, "buffered events: %d", size)
+					return
+				}
+				dropOldestEvent(queue)
+				events--
+			case sendChan <- next:
+				if _, ok := next.(*SubscriptionGap); !ok {
+					events--
+				}
+				queue.Remove(queue.Front())
+			}
+		}
+	}()
+	return outChan, overflowChan
+}
+
+// dropOldestEvent removes the oldest queued event, a gap at the front counts it
+func dropOldestEvent(queue *list.List) {
+	front := queue.Front()
+	gap, ok := front.Value.(*SubscriptionGap)
+	if !ok {
+		gap = &SubscriptionGap{}
+		front = queue.PushFront(gap)
+	}
+	queue.Remove(front.Next())
+	gap.Dropped++
+}
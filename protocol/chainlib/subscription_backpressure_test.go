@@ -0,0 +1,84 @@
+package chainlib
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferSubscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("drop oldest", func(t *testing.T) {
+		in := make(chan interface{})
+		out, overflow := BufferSubscription(ctx, in, 3, SubscriptionDropOldest)
+		// a slow subscriber doesn't block the node, the events are received as they are pushed
+		for i := 0; i < 10; i++ {
+			in <- i
+		}
+		gap, ok := (<-out).(*SubscriptionGap)
+		require.True(t, ok)
+		require.Equal(t, uint64(7), gap.Dropped)
+		data, err := json.Marshal(gap)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"jsonrpc":"2.0","method":"lava_subscriptionGap","params":{"dropped":7}}`, string(data))
+		for i := 7; i < 10; i++ {
+			require.Equal(t, i, <-out)
+		}
+
+		// a subscriber keeping up gets every event
+		for i := 10; i < 13; i++ {
+			in <- i
+			require.Equal(t, i, <-out)
+		}
+		require.Empty(t, overflow)
+
+		// events queued when the node closes the channel are still delivered
+		in <- 13
+		close(in)
+		require.Equal(t, 13, <-out)
+		_, ok = <-out
+		require.False(t, ok)
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		in := make(chan interface{})
+		out, overflow := BufferSubscription(ctx, in, 3, SubscriptionDisconnect)
+		for i := 0; i < 3; i++ {
+			in <- i
+		}
+		require.Empty(t, overflow)
+		in <- 3
+		require.True(t, SubscriptionBufferOverflowError.Is(<-overflow))
+		_, ok := <-out
+		require.False(t, ok)
+	})
+
+	t.Run("overflow is ready when the replies close", func(t *testing.T) {
+		in := make(chan interface{})
+		out, overflow := BufferSubscription(ctx, in, 1, SubscriptionDisconnect)
+		in <- 0
+		in <- 1
+		_, ok := <-out
+		require.False(t, ok)
+		// a reader that sees the replies close first must still find the overflow
+		select {
+		case err := <-overflow:
+			require.True(t, SubscriptionBufferOverflowError.Is(err))
+		default:
+			t.Fatal("overflow wasn't reported before the replies closed")
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		bufferCtx, cancelBuffer := context.WithCancel(ctx)
+		out, _ := BufferSubscription(bufferCtx, make(chan interface{}), 3, SubscriptionDropOldest)
+		cancelBuffer()
+		_, ok := <-out
+		require.False(t, ok)
+	})
+}
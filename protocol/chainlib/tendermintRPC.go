@@ -21,7 +21,6 @@ import (
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/protocol/metrics"
-	"github.com/lavanet/lava/protocol/parser"
 	"github.com/lavanet/lava/utils"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
 	spectypes "github.com/lavanet/lava/x/spec/types"
@@ -151,9 +150,9 @@ func (apip *TendermintChainParser) ParseMsg(urlPath string, data []byte, connect
 
 		if overwriteReqBlock == "" {
 			// Fetch requested block, it is used for data reliability
-			requestedBlockForMessage, err = parser.ParseBlockFromParams(msg, apiCont.api.BlockParsing)
+			requestedBlockForMessage, err = apip.blockParsers.ParseRequestedBlock(apiCont.api.Name, msg, apiCont.api.BlockParsing)
 			if err != nil {
-				utils.LavaFormatError("ParseBlockFromParams failed parsing block", err,
+				utils.LavaFormatError("ParseRequestedBlock failed parsing block", err,
 					utils.LogAttr("chain", apip.spec.Name),
 					utils.LogAttr("blockParsing", apiCont.api.BlockParsing),
 					utils.LogAttr("apiName", apiCont.api.Name),
@@ -608,7 +607,7 @@ type tendermintRpcChainProxy struct {
 	httpClient    *http.Client
 }
 
-func NewtendermintRpcChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainProxy, error) {
+func NewtendermintRpcChainProxy(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, rpcProviderEndpoint lavasession.RPCProviderEndpoint, chainParser ChainParser) (ChainProxy, error) {
 	if len(rpcProviderEndpoint.NodeUrls) == 0 {
 		return nil, utils.LavaFormatError("rpcProviderEndpoint.NodeUrl list is empty missing node url", nil, utils.Attribute{Key: "chainID", Value: rpcProviderEndpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: rpcProviderEndpoint.ApiInterface})
 	}
@@ -619,12 +618,12 @@ func NewtendermintRpcChainProxy(ctx context.Context, nConns uint, rpcProviderEnd
 		httpNodeUrl:    httpUrl,
 		httpConnector:  nil,
 	}
-	cp.addHttpConnector(ctx, nConns, httpUrl)
-	return cp, cp.start(ctx, nConns, websocketUrl, nil)
+	cp.addHttpConnector(ctx, nConns, keepalive, httpUrl)
+	return cp, cp.start(ctx, nConns, keepalive, websocketUrl, nil)
 }
 
-func (cp *tendermintRpcChainProxy) addHttpConnector(ctx context.Context, nConns uint, nodeUrl common.NodeUrl) error {
-	conn, err := chainproxy.NewConnector(ctx, nConns, nodeUrl)
+func (cp *tendermintRpcChainProxy) addHttpConnector(ctx context.Context, nConns uint, keepalive chainproxy.KeepaliveOptions, nodeUrl common.NodeUrl) error {
+	conn, err := chainproxy.NewConnector(ctx, nConns, keepalive, nodeUrl)
 	if err != nil {
 		return err
 	}
@@ -665,7 +664,9 @@ func (cp *tendermintRpcChainProxy) SendURI(ctx context.Context, nodeMessage *rpc
 	}
 	if cp.httpClient == nil {
 		cp.httpClient = &http.Client{
-			Timeout: 5 * time.Minute, // we are doing a timeout by request
+			Timeout:       5 * time.Minute, // we are doing a timeout by request
+			Transport:     common.OutboundProxyTransport(cp.httpNodeUrl.OutboundProxy()),
+			CheckRedirect: cp.httpNodeUrl.CheckRedirect,
 		}
 	}
 	httpClient := cp.httpClient
@@ -742,7 +743,15 @@ func (cp *tendermintRpcChainProxy) SendURI(ctx context.Context, nodeMessage *rpc
 func (cp *tendermintRpcChainProxy) SendRPC(ctx context.Context, nodeMessage *rpcInterfaceMessages.TendermintrpcMessage, ch chan interface{}, chainMessage ChainMessageForSend) (relayReply *pairingtypes.RelayReply, subscriptionID string, relayReplyServer *rpcclient.ClientSubscription, err error) {
 	// Get rpc connection from the connection pool
 	var rpc *rpcclient.Client
+	var subscriptionQuery string
 	if ch != nil {
+		if !cp.supportsSubscriptions() {
+			return nil, "", nil, utils.LavaFormatError("tendermint subscriptions require a websocket node url, provider is configured with http only", nil, utils.Attribute{Key: "chainID", Value: cp.BaseChainProxy.ChainID}, utils.Attribute{Key: "method", Value: nodeMessage.Method})
+		}
+		subscriptionQuery, err = getTendermintSubscriptionQuery(nodeMessage.Params)
+		if err != nil {
+			return nil, "", nil, err
+		}
 		internalPath := chainMessage.GetApiCollection().CollectionData.InternalPath
 		rpc, err = cp.conn[internalPath].GetRpc(ctx, true)
 		if err != nil {
@@ -773,7 +782,8 @@ func (cp *tendermintRpcChainProxy) SendRPC(ctx context.Context, nodeMessage *rpc
 	// If ch is not nil do subscription
 	if ch != nil {
 		// subscribe to the rpc call if the channel is not nil
-		sub, rpcMessage, err = rpc.Subscribe(context.Background(), nodeMessage.ID, nodeMessage.Method, ch, nodeMessage.Params)
+		// params are always sent named so the node's events can be matched to the subscription by their query
+		sub, rpcMessage, err = rpc.Subscribe(context.Background(), nodeMessage.ID, nodeMessage.Method, ch, map[string]interface{}{"query": subscriptionQuery})
 	} else {
 		// set context with timeout
 		connectCtx, cancel := cp.NodeUrl.LowerContextTimeout(ctx, chainMessage, cp.averageBlockTime)
@@ -781,16 +791,19 @@ func (cp *tendermintRpcChainProxy) SendRPC(ctx context.Context, nodeMessage *rpc
 
 		cp.NodeUrl.SetIpForwardingIfNecessary(ctx, rpc.SetHeader)
 		// perform the rpc call
+		callStart := time.Now()
 		rpcMessage, err = rpc.CallContext(connectCtx, nodeMessage.ID, nodeMessage.Method, nodeMessage.Params, false, nodeMessage.GetDisableErrorHandling())
+		cp.httpConnector.ObserveLatency(rpc, time.Since(callStart), err)
 		if err != nil {
 			if common.StatusCodeError504.Is(err) || common.StatusCodeError429.Is(err) || common.StatusCodeErrorStrict.Is(err) {
-				return nil, "", nil, utils.LavaFormatWarning("Received invalid status code", err, utils.Attribute{Key: "chainID", Value: cp.BaseChainProxy.ChainID}, utils.Attribute{Key: "apiName", Value: chainMessage.GetApi().Name})
+				return nil, "", nil, utils.LavaFormatWarning("Received invalid status code", withClientRequest(err, nodeMessage.ID, nodeMessage.Method), utils.Attribute{Key: "chainID", Value: cp.BaseChainProxy.ChainID}, utils.Attribute{Key: "apiName", Value: chainMessage.GetApi().Name})
 			}
 			// Validate if the error is related to the provider connection to the node or it is a valid error
 			// in case the error is valid (e.g. bad input parameters) the error will return in the form of a valid error reply
 			if parsedError := cp.HandleNodeError(ctx, err); parsedError != nil {
-				return nil, "", nil, parsedError
+				return nil, "", nil, withClientRequest(parsedError, nodeMessage.ID, nodeMessage.Method)
 			}
+			err = withClientRequest(err, nodeMessage.ID, nodeMessage.Method)
 		}
 	}
 
@@ -836,18 +849,34 @@ func (cp *tendermintRpcChainProxy) SendRPC(ctx context.Context, nodeMessage *rpc
 	}
 
 	if ch != nil {
-		// get the params for the rpc call
-		params := nodeMessage.Params
-
-		paramsMap, ok := params.(map[string]interface{})
-		if !ok {
-			return nil, "", nil, utils.LavaFormatError("unknown params type on tendermint subscribe", nil)
-		}
-		subscriptionID, ok = paramsMap["query"].(string)
-		if !ok {
-			return nil, "", nil, utils.LavaFormatError("unknown subscriptionID type on tendermint subscribe", nil)
-		}
+		// tendermint subscriptions are identified by their query
+		subscriptionID = subscriptionQuery
 	}
 
 	return reply, subscriptionID, sub, err
 }
+
+// subscriptions are only possible over websocket, when no websocket url was configured the http url is used instead
+func (cp *tendermintRpcChainProxy) supportsSubscriptions() bool {
+	return strings.HasPrefix(cp.NodeUrl.Url, "ws://") || strings.HasPrefix(cp.NodeUrl.Url, "wss://")
+}
+
+// returns the event query of a tendermint subscribe request, params can be named {"query": "tm.event='NewBlock'"} or positional ["tm.event='NewBlock'"]
+func getTendermintSubscriptionQuery(params interface{}) (string, error) {
+	var query interface{}
+	switch paramsCasted := params.(type) {
+	case map[string]interface{}:
+		query = paramsCasted["query"]
+	case []interface{}:
+		if len(paramsCasted) > 0 {
+			query = paramsCasted[0]
+		}
+	default:
+		return "", utils.LavaFormatError("unknown params type on tendermint subscribe", nil, utils.Attribute{Key: "params", Value: params})
+	}
+	queryString, ok := query.(string)
+	if !ok || strings.TrimSpace(queryString) == "" {
+		return "", utils.LavaFormatError("missing event query on tendermint subscribe", nil, utils.Attribute{Key: "params", Value: params})
+	}
+	return queryString, nil
+}
@@ -275,3 +275,22 @@ func TestTendermintURIRPC(t *testing.T) {
 		closeServer()
 	}
 }
+
+func TestGetTendermintSubscriptionQuery(t *testing.T) {
+	query, err := getTendermintSubscriptionQuery(map[string]interface{}{"query": "tm.event='NewBlock'"})
+	require.NoError(t, err)
+	require.Equal(t, "tm.event='NewBlock'", query)
+
+	query, err = getTendermintSubscriptionQuery([]interface{}{"tm.event='Tx' AND tx.height=5"})
+	require.NoError(t, err)
+	require.Equal(t, "tm.event='Tx' AND tx.height=5", query)
+
+	_, err = getTendermintSubscriptionQuery(map[string]interface{}{})
+	require.Error(t, err)
+	_, err = getTendermintSubscriptionQuery([]interface{}{})
+	require.Error(t, err)
+	_, err = getTendermintSubscriptionQuery([]interface{}{" "})
+	require.Error(t, err)
+	_, err = getTendermintSubscriptionQuery(nil)
+	require.Error(t, err)
+}
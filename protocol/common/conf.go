@@ -18,6 +18,15 @@ const (
 	MaximumConcurrentProvidersFlagName = "concurrent-providers"
 	StatusCodeMetadataKey              = "status-code"
 	VersionMetadataKey                 = "lavap-version"
+	SpecVersionMetadataKey             = "lava-spec-version"
+	ReplyEncodingMetadataKey           = "lava-reply-encoding"
+	ReplyCommitmentMetadataKey         = "lava-reply-commitment"
+	SessionCuSumMetadataKey            = "lava-session-cu-sum"
+	SessionRelayNumMetadataKey         = "lava-session-relay-num"
+	SimulatedRelayMetadataKey          = "lava-simulated-relay"
+	SupportedApisMetadataKey           = "lava-supported-apis"
+	MaxRequestSizeMetadataKey          = "lava-max-request-size"
+	MaxResponseSizeMetadataKey         = "lava-max-response-size"
 	TimeOutForFetchingLavaBlocksFlag   = "timeout-for-fetching-lava-blocks"
 )
 
@@ -23,11 +23,23 @@ const (
 	PROVIDER_ADDRESS_HEADER_NAME                    = "Lava-Provider-Address"
 	RETRY_COUNT_HEADER_NAME                         = "Lava-Retries"
 	GUID_HEADER_NAME                                = "Lava-Guid"
+	RELAY_LATENCY_HEADER_NAME                       = "Lava-Relay-Latency-Ms"
+	REPLY_VERIFIED_HEADER_NAME                      = "Lava-Reply-Verified"
+	REPLY_VERIFIED_SIGNATURE                        = "signature"
+	REPLY_VERIFIED_COMMITMENT                       = "commitment"
+	REPLY_NOT_VERIFIED                              = "none"
+	OBSERVED_BLOCK_HEADER_NAME                      = "Lava-Observed-Block"
+	PROVIDER_LATEST_BLOCK_HEADER_NAME               = "Lava-Provider-Latest-Block"
+	TRUSTED_FALLBACK_HEADER_NAME                    = "Lava-Trusted-Fallback"
 	// these headers need to be lowercase
 	BLOCK_PROVIDERS_ADDRESSES_HEADER_NAME = "lava-providers-block"
 	RELAY_TIMEOUT_HEADER_NAME             = "lava-relay-timeout"
 	EXTENSION_OVERRIDE_HEADER_NAME        = "lava-extension"
 	FORCE_CACHE_REFRESH_HEADER_NAME       = "lava-force-cache-refresh"
+	AFFINITY_KEY_HEADER_NAME              = "lava-affinity-key"
+	CHAIN_ID_HEADER_NAME                  = "lava-chain-id"
+	SIMULATE_RELAY_HEADER_NAME            = "lava-simulate-relay"
+	RELAY_PRIORITY_HEADER_NAME            = "lava-relay-priority"
 	// send http request to /lava/health to see if the process is up - (ret code 200)
 	DEFAULT_HEALTH_PATH                                       = "/lava/health"
 	MAXIMUM_ALLOWED_TIMEOUT_EXTEND_MULTIPLIER_BY_THE_CONSUMER = 4
@@ -41,6 +53,9 @@ type NodeUrl struct {
 	Timeout           time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty" mapstructure:"timeout"`
 	Addons            []string      `yaml:"addons,omitempty" json:"addons,omitempty" mapstructure:"addons"`
 	SkipVerifications []string      `yaml:"skip-verifications,omitempty" json:"skip-verifications,omitempty" mapstructure:"skip-verifications"`
+	Proxy             string        `yaml:"proxy,omitempty" json:"proxy,omitempty" mapstructure:"proxy"`
+	RedirectHosts     []string      `yaml:"redirect-hosts,omitempty" json:"redirect-hosts,omitempty" mapstructure:"redirect-hosts"`
+	ConnectionWeights []float64     `yaml:"connection-weights,omitempty" json:"connection-weights,omitempty" mapstructure:"connection-weights"` // static weights of the pool's connections by creation order, missing ones weigh 1
 }
 
 type ChainMessageGetApiInterface interface {
@@ -56,6 +71,14 @@ func (nurl NodeUrl) String() string {
 	return urlStr
 }
 
+// the outbound proxy connections to the node go through, see OutboundProxy
+func (nurl *NodeUrl) OutboundProxy() string {
+	if nurl.Proxy != "" {
+		return nurl.Proxy
+	}
+	return OutboundProxy
+}
+
 func (nurl *NodeUrl) UrlStr() string {
 	parsedURL, err := url.Parse(nurl.Url)
 	if err != nil {
@@ -219,8 +242,10 @@ type RelayResult struct {
 	ProviderInfo    ProviderInfo
 	ReplyServer     *pairingtypes.Relayer_RelaySubscribeClient
 	Finalized       bool
+	BlockTag        bool // the request selected its block with a tag, like latest or finalized, rather than a number
 	ConflictHandler ConflictHandlerInterface
 	StatusCode      int
+	Verification    string // how the provider's reply was verified, REPLY_VERIFIED_SIGNATURE or REPLY_VERIFIED_COMMITMENT, empty when it wasn't
 }
 
 func (rr *RelayResult) GetReplyServer() *pairingtypes.Relayer_RelaySubscribeClient {
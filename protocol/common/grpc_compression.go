@@ -0,0 +1,20 @@
+package common
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcCompressor is the grpc compressor used for compressed calls, importing its package registers it so calls
+// compressed with it can be decompressed and replied to compressed
+const GrpcCompressor = gzip.Name
+
+// IsGrpcCompressionUnsupported returns true when the server rejected a call because it can't decompress it, the call
+// wasn't handled and can be sent again uncompressed
+func IsGrpcCompressionUnsupported(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.Unimplemented && strings.Contains(st.Message(), "Decompressor is not installed")
+}
@@ -0,0 +1,18 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsGrpcCompressionUnsupported(t *testing.T) {
+	require.True(t, IsGrpcCompressionUnsupported(status.Errorf(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding %q", GrpcCompressor)))
+	require.False(t, IsGrpcCompressionUnsupported(status.Error(codes.Unimplemented, "unknown method Relay")))
+	require.False(t, IsGrpcCompressionUnsupported(status.Error(codes.Internal, "Decompressor is not installed")))
+	require.False(t, IsGrpcCompressionUnsupported(errors.New("Decompressor is not installed")))
+	require.False(t, IsGrpcCompressionUnsupported(nil))
+}
@@ -0,0 +1,144 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+const OutboundProxyFlag = "outbound-proxy"
+
+// connections to providers and nodes go through this socks5:// or http:// (CONNECT) proxy, empty uses the standard
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables. a node url's own proxy takes precedence
+var OutboundProxy = ""
+
+// OutboundProxyFunc returns the proxy for a request, in the form http.Transport and websocket.Dialer take, using
+// proxyUrl when set and the environment otherwise. an invalid proxyUrl fails every connection instead of bypassing it
+func OutboundProxyFunc(proxyUrl string) func(*http.Request) (*url.URL, error) {
+	if proxyUrl == "" {
+		return http.ProxyFromEnvironment
+	}
+	parsedUrl, err := url.Parse(proxyUrl)
+	if err == nil {
+		switch parsedUrl.Scheme {
+		case "http", "socks5", "socks5h":
+		default:
+			err = fmt.Errorf("unsupported outbound proxy scheme %q, expected http, socks5 or socks5h", parsedUrl.Scheme)
+		}
+	}
+	return func(*http.Request) (*url.URL, error) {
+		return parsedUrl, err
+	}
+}
+
+var outboundProxyTransports sync.Map
+
+// OutboundProxyTransport returns an http transport shared by the connections going through proxyUrl, the default
+// transport, which uses the environment's proxy, when it's empty
+func OutboundProxyTransport(proxyUrl string) *http.Transport {
+	if proxyUrl == "" {
+		return http.DefaultTransport.(*http.Transport)
+	}
+	if transport, ok := outboundProxyTransports.Load(proxyUrl); ok {
+		return transport.(*http.Transport)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = OutboundProxyFunc(proxyUrl)
+	actual, _ := outboundProxyTransports.LoadOrStore(proxyUrl, transport)
+	return actual.(*http.Transport)
+}
+
+// tcpProxyRequest is the request raw tcp connections to address pick their proxy with, grpc connections are tls or
+// h2c and the environment's https proxy applies to both
+func tcpProxyRequest(address string) *http.Request {
+	return &http.Request{URL: &url.URL{Scheme: "https", Host: address}}
+}
+
+// OutboundProxied returns whether raw tcp connections to address go through a proxy, the remote address of such a
+// connection is the proxy's and not the address'
+func OutboundProxied(proxyUrl string, address string) bool {
+	proxyAddress, err := OutboundProxyFunc(proxyUrl)(tcpProxyRequest(address))
+	return err != nil || proxyAddress != nil
+}
+
+// OutboundProxyDialer returns a dialer for raw tcp connections, like grpc's, going through the proxy
+// OutboundProxyFunc picks for the address
+func OutboundProxyDialer(proxyUrl string) func(ctx context.Context, address string) (net.Conn, error) {
+	proxyFunc := OutboundProxyFunc(proxyUrl)
+	return func(ctx context.Context, address string) (net.Conn, error) {
+		proxyAddress, err := proxyFunc(tcpProxyRequest(address))
+		if err != nil {
+			return nil, err
+		}
+		if proxyAddress == nil {
+			return (&net.Dialer{}).DialContext(ctx, "tcp", address)
+		}
+		if proxyAddress.Scheme == "http" {
+			return dialHTTPConnect(ctx, proxyAddress, address)
+		}
+		dialer, err := proxy.FromURL(proxyAddress, &net.Dialer{})
+		if err != nil {
+			return nil, err
+		}
+		contextDialer, ok := dialer.(proxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("outbound proxy %s doesn't support dialing with a context", proxyAddress.Redacted())
+		}
+		return contextDialer.DialContext(ctx, "tcp", address)
+	}
+}
+
+// bufferedConn keeps what the proxy sent after its CONNECT reply
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (bc *bufferedConn) Read(b []byte) (int, error) {
+	return bc.reader.Read(b)
+}
+
+func dialHTTPConnect(ctx context.Context, proxyAddress *url.URL, address string) (net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", proxyAddress.Host)
+	if err != nil {
+		return nil, err
+	}
+	// the deadline only covers the handshake with the proxy, the connection itself is handed over without one
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	request := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: address}, Host: address, Header: http.Header{}}
+	if proxyAddress.User != nil {
+		password, _ := proxyAddress.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyAddress.User.Username() + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("outbound proxy %s refused connecting to %s: %s", proxyAddress.Redacted(), address, response.Status)
+	}
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
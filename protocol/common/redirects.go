@@ -0,0 +1,68 @@
+package common
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+const NodeMaxRedirectsFlag = "node-max-redirects"
+
+// redirects followed on a single http request to a node, 0 fails every redirect
+var NodeMaxRedirects = 10
+
+var RedirectNotAllowedError = sdkerrors.New("RedirectNotAllowed Error", 801, "node redirect not allowed")
+
+// CheckRedirect is an http.Client CheckRedirect for requests to the node. redirects to the node's origin (scheme, host
+// and port) are followed up to NodeMaxRedirects, other origins only when their host is listed in redirect-hosts ("*"
+// allows any host). the configured auth headers are only sent to the node's origin
+func (nurl *NodeUrl) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > NodeMaxRedirects {
+		return sdkerrors.Wrapf(RedirectNotAllowedError, "stopped after %d redirects, to %s", NodeMaxRedirects, req.URL.Redacted())
+	}
+	for _, previous := range via {
+		if previous.URL.String() == req.URL.String() {
+			return sdkerrors.Wrapf(RedirectNotAllowedError, "redirect loop to %s", req.URL.Redacted())
+		}
+	}
+	if sameOrigin(req.URL, via[0].URL) {
+		return nil
+	}
+	host := req.URL.Hostname()
+	if !nurl.redirectHostAllowed(host) {
+		return sdkerrors.Wrapf(RedirectNotAllowedError, "redirect from %s to %s", via[0].URL.Redacted(), req.URL.Redacted())
+	}
+	for header := range nurl.AuthConfig.AuthHeaders {
+		req.Header.Del(header)
+	}
+	return nil
+}
+
+// a scheme or port change on the same host is another origin, a downgrade to http or another service on the host
+// mustn't get the auth headers
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Hostname(), b.Hostname()) && urlPort(a) == urlPort(b)
+}
+
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https", "wss":
+		return "443"
+	default:
+		return "80"
+	}
+}
+
+func (nurl *NodeUrl) redirectHostAllowed(host string) bool {
+	for _, allowed := range nurl.RedirectHosts {
+		if allowed == "*" || strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
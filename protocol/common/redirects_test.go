@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeRedirects(t *testing.T) {
+	const authHeader = "X-Api-Key"
+	// the other host receives requests on localhost while the node is on 127.0.0.1
+	received := make(chan string, 1)
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(authHeader)
+		w.Write([]byte("other"))
+	}))
+	defer otherHost.Close()
+	otherHostUrl, err := url.Parse(otherHost.URL)
+	require.NoError(t, err)
+	otherHostUrl.Host = "localhost:" + otherHostUrl.Port()
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/node", http.StatusTemporaryRedirect)
+		case "/node":
+			received <- r.Header.Get(authHeader)
+			w.Write([]byte("node"))
+		case "/other":
+			http.Redirect(w, r, otherHostUrl.String(), http.StatusTemporaryRedirect)
+		case "/other-port":
+			// the node's host on another port
+			http.Redirect(w, r, otherHost.URL, http.StatusTemporaryRedirect)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer node.Close()
+
+	nodeUrl := &NodeUrl{Url: node.URL, AuthConfig: AuthConfig{AuthHeaders: map[string]string{authHeader: "secret"}}}
+	get := func(path string) (string, error) {
+		client := &http.Client{CheckRedirect: nodeUrl.CheckRedirect}
+		req, err := http.NewRequest(http.MethodGet, nodeUrl.Url+path, nil)
+		require.NoError(t, err)
+		nodeUrl.SetAuthHeaders(context.Background(), req.Header.Set)
+		res, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		return <-received, nil
+	}
+
+	// redirects within the node keep the auth headers
+	authHeaderValue, err := get("/moved")
+	require.NoError(t, err)
+	require.Equal(t, "secret", authHeaderValue)
+
+	// other hosts aren't followed unless allowed
+	_, err = get("/other")
+	require.True(t, errors.Is(err, RedirectNotAllowedError), err)
+	nodeUrl.RedirectHosts = []string{"localhost"}
+	authHeaderValue, err = get("/other")
+	require.NoError(t, err)
+	require.Empty(t, authHeaderValue) // the auth headers aren't sent to another host
+
+	// another port on the node's host is another origin
+	_, err = get("/other-port")
+	require.True(t, errors.Is(err, RedirectNotAllowedError), err)
+	nodeUrl.RedirectHosts = []string{"localhost", "127.0.0.1"}
+	authHeaderValue, err = get("/other-port")
+	require.NoError(t, err)
+	require.Empty(t, authHeaderValue)
+	require.False(t, sameOrigin(&url.URL{Scheme: "https", Host: "node"}, &url.URL{Scheme: "http", Host: "node"}))
+	require.True(t, sameOrigin(&url.URL{Scheme: "https", Host: "node"}, &url.URL{Scheme: "https", Host: "NODE:443"}))
+
+	_, err = get("/loop")
+	require.True(t, errors.Is(err, RedirectNotAllowedError), err)
+
+	// too many redirects
+	defer func(maxRedirects int) { NodeMaxRedirects = maxRedirects }(NodeMaxRedirects)
+	NodeMaxRedirects = 0
+	_, err = get("/moved")
+	require.True(t, errors.Is(err, RedirectNotAllowedError), err)
+}
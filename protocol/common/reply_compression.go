@@ -0,0 +1,41 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+const (
+	ReplyEncodingIdentity = "identity"
+	ReplyEncodingGzip     = "gzip"
+)
+
+var ReplyDecompressionError = sdkerrors.New("ReplyDecompression Error", 3373, "failed decompressing the relay reply, it is malformed or exceeds the maximum decompressed size")
+
+// DecompressReplyData decodes reply data sent with a content encoding, reading at most maxSize bytes so a
+// small compressed payload can't inflate past the limit before it's checked
+func DecompressReplyData(encoding string, data []byte, maxSize int64) ([]byte, error) {
+	switch encoding {
+	case "", ReplyEncodingIdentity:
+		return data, nil
+	case ReplyEncodingGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, sdkerrors.Wrapf(ReplyDecompressionError, "invalid gzip reply: %s", err.Error())
+		}
+		defer reader.Close()
+		decompressed, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+		if err != nil {
+			return nil, sdkerrors.Wrapf(ReplyDecompressionError, "invalid gzip reply: %s", err.Error())
+		}
+		if int64(len(decompressed)) > maxSize {
+			return nil, sdkerrors.Wrapf(ReplyDecompressionError, "reply decompresses beyond the limit of %d bytes, compressed size %d", maxSize, len(data))
+		}
+		return decompressed, nil
+	default:
+		return nil, sdkerrors.Wrapf(ReplyDecompressionError, "unsupported reply encoding %s", encoding)
+	}
+}
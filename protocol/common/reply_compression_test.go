@@ -0,0 +1,52 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	require.NoError(t, err)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestDecompressReplyData(t *testing.T) {
+	reply := []byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+	const maxSize = 1024 * 1024
+
+	data, err := DecompressReplyData("", reply, maxSize)
+	require.NoError(t, err)
+	require.Equal(t, reply, data)
+	data, err = DecompressReplyData(ReplyEncodingIdentity, reply, maxSize)
+	require.NoError(t, err)
+	require.Equal(t, reply, data)
+
+	data, err = DecompressReplyData(ReplyEncodingGzip, gzipData(t, reply), maxSize)
+	require.NoError(t, err)
+	require.Equal(t, reply, data)
+
+	// exactly at the limit is allowed
+	atLimit := bytes.Repeat([]byte{'a'}, maxSize)
+	data, err = DecompressReplyData(ReplyEncodingGzip, gzipData(t, atLimit), maxSize)
+	require.NoError(t, err)
+	require.Len(t, data, maxSize)
+
+	// a tiny payload inflating to 64 times the limit
+	bomb := gzipData(t, make([]byte, 64*maxSize))
+	require.Less(t, len(bomb), maxSize/10)
+	_, err = DecompressReplyData(ReplyEncodingGzip, bomb, maxSize)
+	require.True(t, ReplyDecompressionError.Is(err), err)
+
+	_, err = DecompressReplyData(ReplyEncodingGzip, reply, maxSize)
+	require.True(t, ReplyDecompressionError.Is(err), err)
+	_, err = DecompressReplyData("br", reply, maxSize)
+	require.True(t, ReplyDecompressionError.Is(err), err)
+}
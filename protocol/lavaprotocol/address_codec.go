@@ -0,0 +1,76 @@
+package lavaprotocol
+
+import (
+	"bytes"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// AddressCodec converts the addresses recovered from relay signatures, a process serving several chains with their own
+// bech32 prefix can't rely on the sdk's global config for all of them
+type AddressCodec interface {
+	StringToBytes(text string) ([]byte, error)
+	BytesToString(bz []byte) (string, error)
+}
+
+// DefaultAddressCodec uses the sdk's globally configured account prefix
+var DefaultAddressCodec AddressCodec = sdkAddressCodec{}
+
+type sdkAddressCodec struct{}
+
+func (sdkAddressCodec) StringToBytes(text string) ([]byte, error) {
+	addr, err := sdk.AccAddressFromBech32(text)
+	if err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+func (sdkAddressCodec) BytesToString(bz []byte) (string, error) {
+	return sdk.AccAddress(bz).String(), nil
+}
+
+// Bech32AddressCodec converts addresses with a fixed bech32 prefix regardless of the sdk's global config
+type Bech32AddressCodec struct {
+	Prefix string
+}
+
+func NewBech32AddressCodec(prefix string) Bech32AddressCodec {
+	return Bech32AddressCodec{Prefix: prefix}
+}
+
+func (bc Bech32AddressCodec) StringToBytes(text string) ([]byte, error) {
+	return sdk.GetFromBech32(text, bc.Prefix)
+}
+
+func (bc Bech32AddressCodec) BytesToString(bz []byte) (string, error) {
+	return bech32.ConvertAndEncode(bc.Prefix, bz)
+}
+
+// displayAddressCodec parses addresses with the sdk's global prefix and formats them with a display prefix. pairing
+// addresses always carry the lava prefix, the chain's prefix is only used to show the recovered signer
+type displayAddressCodec struct {
+	display Bech32AddressCodec
+}
+
+// NewDisplayAddressCodec verifies lava addresses and shows signers with prefix
+func NewDisplayAddressCodec(prefix string) AddressCodec {
+	return displayAddressCodec{display: NewBech32AddressCodec(prefix)}
+}
+
+func (dc displayAddressCodec) StringToBytes(text string) ([]byte, error) {
+	return DefaultAddressCodec.StringToBytes(text)
+}
+
+func (dc displayAddressCodec) BytesToString(bz []byte) (string, error) {
+	return dc.display.BytesToString(bz)
+}
+
+func addressMatches(codec AddressCodec, signer []byte, addr string) bool {
+	expected, err := codec.StringToBytes(addr)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(expected, signer)
+}
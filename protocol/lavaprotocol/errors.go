@@ -11,4 +11,9 @@ var (
 	ConsistencyError                             = sdkerrors.New("Consistency Error", 3368, "does not meet consistency requirements")
 	UnhandledRelayReceiverError                  = sdkerrors.New("UnhandledRelayReceiver Error", 3369, "provider does not handle requested api interface and spec")
 	DisabledRelayReceiverError                   = sdkerrors.New("DisabledRelayReceiverError Error", 3370, "provider does not pass verification and disabled this interface and spec")
+	RelayReplySignatureRecoveryError             = sdkerrors.New("RelayReplySignatureRecovery Error", 3371, "failed recovering the relay reply signer, the reply is likely malformed or truncated")
+	SpecVersionMismatchError                     = sdkerrors.New("SpecVersionMismatch Error", 3372, "provider is running a different spec version than the consumer")
+	ReplyCommitmentError                         = sdkerrors.New("ReplyCommitment Error", 3374, "relay reply signed with an unsupported commitment")
+	MinorityForkDetectedError                    = sdkerrors.New("MinorityForkDetected Error", 3375, "provider's finalized hashes persistently disagree with the majority of providers, it is likely on a minority fork")
+	ProviderSpecOutdatedError                    = sdkerrors.New("ProviderSpecOutdated Error", 3376, "provider is running an older spec version than the consumer")
 )
@@ -1,8 +1,10 @@
 package lavaprotocol
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,6 +23,7 @@ type FinalizationConsensus struct {
 	currentEpoch                     uint64
 	latestBlockByMedian              uint64 // for caching
 	specId                           string
+	minorityForks                    *minorityForkTracker
 }
 
 type ProviderHashesConsensus struct {
@@ -37,7 +40,47 @@ type providerDataContainer struct {
 	BlockHeight           int64
 	RelayNum              uint64
 	LatestBlock           int64
-	// TODO:: keep relay request for conflict reporting
+	RelayReply            *pairingtypes.RelayReply // kept as evidence for conflict reporting
+}
+
+// FinalizationConflictError is returned when two providers signed different hashes for the same finalized block.
+// both signed replies are kept so a conflict can be filed on chain
+type FinalizationConflictError struct {
+	BlockNum  int64
+	Provider0 string
+	Hash0     string
+	Provider1 string
+	Hash1     string
+	Conflict  *conflicttypes.FinalizationConflict
+}
+
+func (e *FinalizationConflictError) Error() string {
+	return fmt.Sprintf("%s: block %d provider %s hash %s, provider %s hash %s", HashesConsunsusError.Error(), e.BlockNum, e.Provider0, e.Hash0, e.Provider1, e.Hash1)
+}
+
+func (e *FinalizationConflictError) Cause() error {
+	return HashesConsunsusError
+}
+
+func (e *FinalizationConflictError) Unwrap() error {
+	return HashesConsunsusError
+}
+
+// returns both conflicting signed replies
+func (e *FinalizationConflictError) Evidence() (reply0, reply1 *pairingtypes.RelayReply) {
+	if e.Conflict == nil {
+		return nil, nil
+	}
+	return e.Conflict.RelayReply0, e.Conflict.RelayReply1
+}
+
+// extracts the finalization conflict evidence from an error returned by UpdateFinalizedHashes
+func GetFinalizationConflictError(err error) (*FinalizationConflictError, bool) {
+	var conflictErr *FinalizationConflictError
+	if errors.As(err, &conflictErr) {
+		return conflictErr, true
+	}
+	return nil, false
 }
 
 func NewFinalizationConsensus(specId string) *FinalizationConsensus {
@@ -67,6 +110,7 @@ func (fc *FinalizationConsensus) newProviderHashesConsensus(blockDistanceForFina
 		RelayNum:              req.RelayNum,
 		BlockHeight:           req.Epoch,
 		LatestBlock:           latestBlock,
+		RelayReply:            reply,
 	}
 	providerDataContainers := map[string]providerDataContainer{}
 	providerDataContainers[providerAcc] = newProviderDataContainer
@@ -86,6 +130,7 @@ func (fc *FinalizationConsensus) insertProviderToConsensus(blockDistanceForFinal
 		RelayNum:              req.RelayNum,
 		BlockHeight:           req.Epoch,
 		LatestBlock:           latestBlock,
+		RelayReply:            reply,
 	}
 	consensus.agreeingProviders[providerAcc] = newProviderDataContainer
 
@@ -112,11 +157,14 @@ func (fc *FinalizationConsensus) UpdateFinalizedHashes(blockDistanceForFinalized
 		inserted := false
 		// Looks for discrepancy with current epoch providers
 		// go over all consensus groups, if there is a mismatch add it as a consensus group and send a conflict
+		var conflictErr *FinalizationConflictError
 		for _, consensus := range fc.currentProviderHashesConsensus {
-			err := fc.discrepancyChecker(finalizedBlocks, consensus)
+			blockNum, err := fc.discrepancyChecker(finalizedBlocks, consensus)
 			if err != nil {
-				// TODO: bring the other data as proof
-				finalizationConflict = &conflicttypes.FinalizationConflict{RelayReply0: reply}
+				if conflictErr == nil {
+					conflictErr = newFinalizationConflictError(blockNum, providerAddress, finalizedBlocks[blockNum], reply, consensus)
+					finalizationConflict = conflictErr.Conflict
+				}
 				// we need to insert into a new consensus group before returning
 				// or create new consensus group if no consensus matched
 				continue
@@ -134,18 +182,18 @@ func (fc *FinalizationConsensus) UpdateFinalizedHashes(blockDistanceForFinalized
 			newHashConsensus := fc.newProviderHashesConsensus(blockDistanceForFinalizedData, providerAddress, latestBlock, finalizedBlocks, reply, req)
 			fc.currentProviderHashesConsensus = append(fc.currentProviderHashesConsensus, newHashConsensus)
 		}
-		if finalizationConflict != nil {
+		if conflictErr != nil {
 			// means there was a conflict and we need to report
-			return finalizationConflict, utils.LavaFormatError("Simulation: Conflict found in discrepancyChecker", err)
+			return finalizationConflict, utils.LavaFormatError("Simulation: Conflict found in discrepancyChecker", conflictErr, conflictErr.logAttributes()...)
 		}
 
 		// check for discrepancy with old epoch
 		for idx, consensus := range fc.prevEpochProviderHashesConsensus {
-			err := fc.discrepancyChecker(finalizedBlocks, consensus)
+			blockNum, err := fc.discrepancyChecker(finalizedBlocks, consensus)
 			if err != nil {
-				// TODO: bring the other data as proof
-				finalizationConflict = &conflicttypes.FinalizationConflict{RelayReply0: reply}
-				return finalizationConflict, utils.LavaFormatError("Simulation: prev epoch Conflict found in discrepancyChecker", err, utils.Attribute{Key: "Consensus idx", Value: strconv.Itoa(idx)}, utils.Attribute{Key: "provider", Value: providerAddress})
+				conflictErr = newFinalizationConflictError(blockNum, providerAddress, finalizedBlocks[blockNum], reply, consensus)
+				finalizationConflict = conflictErr.Conflict
+				return finalizationConflict, utils.LavaFormatError("Simulation: prev epoch Conflict found in discrepancyChecker", conflictErr, append(conflictErr.logAttributes(), utils.Attribute{Key: "Consensus idx", Value: strconv.Itoa(idx)})...)
 			}
 		}
 	}
@@ -155,7 +203,43 @@ func (fc *FinalizationConsensus) UpdateFinalizedHashes(blockDistanceForFinalized
 	return finalizationConflict, nil
 }
 
-func (fc *FinalizationConsensus) discrepancyChecker(finalizedBlocksA map[int64]string, consensus ProviderHashesConsensus) (errRet error) {
+// builds the conflict evidence from the new reply and a provider in the consensus group that signed the other hash
+func newFinalizationConflictError(blockNum int64, providerAddress string, hash string, reply *pairingtypes.RelayReply, consensus ProviderHashesConsensus) *FinalizationConflictError {
+	conflictErr := &FinalizationConflictError{
+		BlockNum:  blockNum,
+		Provider0: providerAddress,
+		Hash0:     hash,
+		Hash1:     consensus.FinalizedBlocksHashes[blockNum],
+		Conflict:  &conflicttypes.FinalizationConflict{RelayReply0: reply},
+	}
+	// prefer a provider that signed the conflicting block itself, any agreeing provider is valid evidence otherwise
+	for providerAcc, container := range consensus.agreeingProviders {
+		if container.RelayReply == nil {
+			continue
+		}
+		otherHash, ok := container.FinalizedBlocksHashes[blockNum]
+		if !ok {
+			if conflictErr.Conflict.RelayReply1 == nil {
+				conflictErr.Provider1 = providerAcc
+				conflictErr.Conflict.RelayReply1 = container.RelayReply
+			}
+			continue
+		}
+		if otherHash != hash {
+			conflictErr.Provider1 = providerAcc
+			conflictErr.Conflict.RelayReply1 = container.RelayReply
+			break
+		}
+	}
+	return conflictErr
+}
+
+func (e *FinalizationConflictError) logAttributes() []utils.Attribute {
+	return []utils.Attribute{{Key: "blockNum", Value: e.BlockNum}, {Key: "provider0", Value: e.Provider0}, {Key: "hash0", Value: e.Hash0}, {Key: "provider1", Value: e.Provider1}, {Key: "hash1", Value: e.Hash1}}
+}
+
+// returns the first block number with mismatching hashes
+func (fc *FinalizationConsensus) discrepancyChecker(finalizedBlocksA map[int64]string, consensus ProviderHashesConsensus) (conflictBlock int64, errRet error) {
 	var toIterate map[int64]string   // the smaller map between the two to compare
 	var otherBlocks map[int64]string // the other map
 
@@ -170,13 +254,12 @@ func (fc *FinalizationConsensus) discrepancyChecker(finalizedBlocksA map[int64]s
 	for blockNum, blockHash := range toIterate {
 		if otherHash, ok := otherBlocks[blockNum]; ok {
 			if blockHash != otherHash {
-				// TODO: gather discrepancy data
-				return utils.LavaFormatError("Simulation: reliability discrepancy, different hashes detected for block", HashesConsunsusError, utils.Attribute{Key: "blockNum", Value: blockNum}, utils.Attribute{Key: "Hashes", Value: fmt.Sprintf("%s vs %s", blockHash, otherHash)}, utils.Attribute{Key: "toIterate", Value: toIterate}, utils.Attribute{Key: "otherBlocks", Value: otherBlocks})
+				return blockNum, utils.LavaFormatError("Simulation: reliability discrepancy, different hashes detected for block", HashesConsunsusError, utils.Attribute{Key: "blockNum", Value: blockNum}, utils.Attribute{Key: "Hashes", Value: fmt.Sprintf("%s vs %s", blockHash, otherHash)}, utils.Attribute{Key: "toIterate", Value: toIterate}, utils.Attribute{Key: "otherBlocks", Value: otherBlocks})
 			}
 		}
 	}
 
-	return nil
+	return 0, nil
 }
 
 func (fc *FinalizationConsensus) NewEpoch(epoch uint64) {
@@ -191,15 +274,77 @@ func (fc *FinalizationConsensus) NewEpoch(epoch uint64) {
 		fc.prevEpochProviderHashesConsensus = fc.currentProviderHashesConsensus
 		fc.currentProviderHashesConsensus = []ProviderHashesConsensus{}
 		fc.currentEpoch = epoch
+		if fc.minorityForks != nil {
+			fc.minorityForks.newEpoch()
+		}
 	}
 }
 
+// DetectMinorityFork adds the provider's finalized hashes to the majority view, it returns a MinorityForkError the first
+// time in an epoch the provider persistently disagrees with the majority. unlike UpdateFinalizedHashes a conflict
+// between two groups doesn't matter here, only which side most providers are on. the error carries the provider's
+// reply and a majority provider's signed reply as conflict evidence when one is known
+func (fc *FinalizationConsensus) DetectMinorityFork(providerAddress string, finalizedBlocks map[int64]string, reply *pairingtypes.RelayReply) error {
+	fc.providerDataContainersMu.Lock()
+	defer fc.providerDataContainersMu.Unlock()
+	if fc.minorityForks == nil {
+		fc.minorityForks = newMinorityForkTracker()
+	}
+	forkErr := fc.minorityForks.record(providerAddress, finalizedBlocks)
+	if forkErr == nil {
+		return nil
+	}
+	for _, consensus := range append(fc.currentProviderHashesConsensus, fc.prevEpochProviderHashesConsensus...) {
+		if consensus.FinalizedBlocksHashes[forkErr.BlockNum] != forkErr.MajorityHash {
+			continue
+		}
+		if conflictErr := newFinalizationConflictError(forkErr.BlockNum, providerAddress, forkErr.Hash, reply, consensus); conflictErr.Conflict.RelayReply1 != nil {
+			forkErr.Conflict = conflictErr.Conflict
+			break
+		}
+	}
+	return forkErr
+}
+
+// IsOnMinorityFork returns true if the provider was flagged by DetectMinorityFork this epoch
+func (fc *FinalizationConsensus) IsOnMinorityFork(providerAddress string) bool {
+	fc.providerDataContainersMu.RLock()
+	defer fc.providerDataContainersMu.RUnlock()
+	return fc.minorityForks != nil && fc.minorityForks.isFlagged(providerAddress)
+}
+
+// extracts the fork details from an error returned by DetectMinorityFork
+func GetMinorityForkError(err error) (*MinorityForkError, bool) {
+	var forkErr *MinorityForkError
+	if errors.As(err, &forkErr) {
+		return forkErr, true
+	}
+	return nil, false
+}
+
 func (fc *FinalizationConsensus) LatestBlock() uint64 {
 	fc.providerDataContainersMu.RLock()
 	defer fc.providerDataContainersMu.RUnlock()
 	return fc.latestBlockByMedian
 }
 
+// BlockNumByHash looks up a finalized block the providers agree on by its hash, only blocks within the finalized
+// hashes of the current and previous epoch consensus are known
+func (fc *FinalizationConsensus) BlockNumByHash(hash string) (int64, bool) {
+	fc.providerDataContainersMu.RLock()
+	defer fc.providerDataContainersMu.RUnlock()
+	for _, consensuses := range [][]ProviderHashesConsensus{fc.currentProviderHashesConsensus, fc.prevEpochProviderHashesConsensus} {
+		for _, consensus := range consensuses {
+			for blockNum, blockHash := range consensus.FinalizedBlocksHashes {
+				if strings.EqualFold(blockHash, hash) {
+					return blockNum, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
 func (fc *FinalizationConsensus) GetExpectedBlockHeights(averageBlockTime_ms time.Duration) map[string]int64 {
 	var highestBlockNumber int64 = 0
 	FindAndUpdateHighestBlockNumber := func(listProviderHashesConsensus []ProviderHashesConsensus) {
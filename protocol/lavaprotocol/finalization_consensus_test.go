@@ -155,6 +155,38 @@ func TestConsensusHashesInsertion(t *testing.T) {
 	}
 }
 
+func TestFinalizationConflictEvidence(t *testing.T) {
+	epoch := uint64(200)
+	blockDistanceForFinalizedData := uint32(7)
+	blocksInFinalizationProof := uint32(3)
+	honest := finalizationInsertionForProviders("LAV1", epoch, 100, 0, 2, true, "", blocksInFinalizationProof, blockDistanceForFinalizedData)
+	byzantine := finalizationInsertionForProviders("LAV1", epoch, 100, 2, 1, false, "A", blocksInFinalizationProof, blockDistanceForFinalizedData)[0]
+
+	finalizationConsensus := &FinalizationConsensus{}
+	finalizationConsensus.NewEpoch(epoch)
+	for _, insertion := range honest {
+		_, err := finalizationConsensus.UpdateFinalizedHashes(int64(blockDistanceForFinalizedData), insertion.providerAddr, insertion.finalizedBlocks, insertion.relaySession, insertion.relayReply)
+		require.NoError(t, err)
+	}
+	finalizationConflict, err := finalizationConsensus.UpdateFinalizedHashes(int64(blockDistanceForFinalizedData), byzantine.providerAddr, byzantine.finalizedBlocks, byzantine.relaySession, byzantine.relayReply)
+	require.Error(t, err)
+	require.True(t, HashesConsunsusError.Is(err))
+
+	conflictErr, ok := GetFinalizationConflictError(err)
+	require.True(t, ok)
+	require.Equal(t, byzantine.providerAddr, conflictErr.Provider0)
+	require.Contains(t, []string{honest[0].providerAddr, honest[1].providerAddr}, conflictErr.Provider1)
+	require.NotEqual(t, conflictErr.Hash0, conflictErr.Hash1)
+	reply0, reply1 := conflictErr.Evidence()
+	require.Same(t, byzantine.relayReply, reply0)
+	require.NotNil(t, reply1)
+	require.Same(t, finalizationConflict, conflictErr.Conflict)
+
+	// errors that are not conflicts don't carry evidence
+	_, ok = GetFinalizationConflictError(ConsistencyError)
+	require.False(t, ok)
+}
+
 func TestQoS(t *testing.T) {
 	decToSet, _ := sdk.NewDecFromStr("0.05") // test values fit 0.05 Availability requirements
 	lavasession.AvailabilityPercentage = decToSet
@@ -184,7 +216,7 @@ func TestQoS(t *testing.T) {
 				Enabled:            true,
 				Client:             nil,
 				ConnectionRefusals: 0,
-			}, 1)
+			}, 1, 0)
 			require.NoError(t, err)
 			require.NotNil(t, singleConsumerSession)
 
@@ -230,7 +262,7 @@ func TestQoS(t *testing.T) {
 			singleConsumerSession.CalculateQoS(currentLatency, expectedLatency, expectedBH-latestServicedBlock, numOfProviders, 1)
 			require.Equal(t, uint64(1), singleConsumerSession.QoSInfo.AnsweredRelays)
 			require.Equal(t, uint64(1), singleConsumerSession.QoSInfo.TotalRelays)
-			require.Equal(t, int64(1), singleConsumerSession.QoSInfo.SyncScoreSum)
+			require.Equal(t, sdk.NewDec(1), singleConsumerSession.QoSInfo.SyncScoreSum)
 			require.Equal(t, int64(1), singleConsumerSession.QoSInfo.TotalSyncScore)
 			require.Equal(t, sdk.OneDec(), singleConsumerSession.QoSInfo.LastQoSReport.Availability)
 			require.Equal(t, sdk.OneDec(), singleConsumerSession.QoSInfo.LastQoSReport.Sync)
@@ -240,7 +272,7 @@ func TestQoS(t *testing.T) {
 			singleConsumerSession.CalculateQoS(currentLatency, expectedLatency, expectedBH-latestServicedBlock, numOfProviders, 1)
 			require.Equal(t, uint64(2), singleConsumerSession.QoSInfo.AnsweredRelays)
 			require.Equal(t, uint64(2), singleConsumerSession.QoSInfo.TotalRelays)
-			require.Equal(t, int64(2), singleConsumerSession.QoSInfo.SyncScoreSum)
+			require.Equal(t, sdk.NewDec(2), singleConsumerSession.QoSInfo.SyncScoreSum)
 			require.Equal(t, int64(2), singleConsumerSession.QoSInfo.TotalSyncScore)
 			require.Equal(t, sdk.OneDec(), singleConsumerSession.QoSInfo.LastQoSReport.Availability)
 			require.Equal(t, sdk.OneDec(), singleConsumerSession.QoSInfo.LastQoSReport.Sync)
@@ -250,7 +282,7 @@ func TestQoS(t *testing.T) {
 			singleConsumerSession.CalculateQoS(currentLatency, expectedLatency, expectedBH-latestServicedBlock, numOfProviders, 1)
 			require.Equal(t, uint64(3), singleConsumerSession.QoSInfo.AnsweredRelays)
 			require.Equal(t, uint64(4), singleConsumerSession.QoSInfo.TotalRelays)
-			require.Equal(t, int64(3), singleConsumerSession.QoSInfo.SyncScoreSum)
+			require.Equal(t, sdk.NewDec(3), singleConsumerSession.QoSInfo.SyncScoreSum)
 			require.Equal(t, int64(3), singleConsumerSession.QoSInfo.TotalSyncScore)
 
 			require.Equal(t, sdk.ZeroDec(), singleConsumerSession.QoSInfo.LastQoSReport.Availability) // because availability below 95% is 0
@@ -261,11 +293,11 @@ func TestQoS(t *testing.T) {
 			singleConsumerSession.CalculateQoS(currentLatency, expectedLatency*2, expectedBH-latestServicedBlock, numOfProviders, 1)
 			require.Equal(t, uint64(4), singleConsumerSession.QoSInfo.AnsweredRelays)
 			require.Equal(t, uint64(5), singleConsumerSession.QoSInfo.TotalRelays)
-			require.Equal(t, int64(3), singleConsumerSession.QoSInfo.SyncScoreSum)
+			require.Equal(t, sdk.MustNewDecFromStr("3.8"), singleConsumerSession.QoSInfo.SyncScoreSum) // one block behind loses a proportional part of the score
 			require.Equal(t, int64(4), singleConsumerSession.QoSInfo.TotalSyncScore)
 
 			require.Equal(t, sdk.ZeroDec(), singleConsumerSession.QoSInfo.LastQoSReport.Availability) // because availability below 95% is 0
-			require.Equal(t, sdk.MustNewDecFromStr("0.75"), singleConsumerSession.QoSInfo.LastQoSReport.Sync)
+			require.Equal(t, sdk.MustNewDecFromStr("0.95"), singleConsumerSession.QoSInfo.LastQoSReport.Sync)
 			require.Equal(t, sdk.OneDec(), singleConsumerSession.QoSInfo.LastQoSReport.Latency)
 			latestServicedBlock = expectedBH + 1
 			// add in a loop so availability goes above 95%
@@ -273,7 +305,7 @@ func TestQoS(t *testing.T) {
 				singleConsumerSession.CalculateQoS(currentLatency, expectedLatency*2, expectedBH-latestServicedBlock, numOfProviders, 1)
 			}
 			require.Equal(t, sdk.MustNewDecFromStr("0.8"), singleConsumerSession.QoSInfo.LastQoSReport.Availability) // because availability below 95% is 0
-			require.Equal(t, sdk.MustNewDecFromStr("0.989898989898989898"), singleConsumerSession.QoSInfo.LastQoSReport.Sync)
+			require.Equal(t, sdk.MustNewDecFromStr("0.997979797979797979"), singleConsumerSession.QoSInfo.LastQoSReport.Sync)
 			require.Equal(t, sdk.OneDec(), singleConsumerSession.QoSInfo.LastQoSReport.Latency)
 
 			finalizationInsertionsSpreadBlocks := []finalizationTestInsertion{
@@ -307,3 +339,29 @@ func TestQoS(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockNumByHash(t *testing.T) {
+	epoch := uint64(200)
+	blockDistanceForFinalizedData := uint32(7)
+	finalizationConsensus := NewFinalizationConsensus("ETH1")
+	finalizationConsensus.NewEpoch(epoch)
+	for _, insertion := range finalizationInsertionForProviders("ETH1", epoch, 100, 0, 2, true, "A", 3, blockDistanceForFinalizedData) {
+		_, err := finalizationConsensus.UpdateFinalizedHashes(int64(blockDistanceForFinalizedData), insertion.providerAddr, insertion.finalizedBlocks, insertion.relaySession, insertion.relayReply)
+		require.NoError(t, err)
+	}
+	blockNum, ok := finalizationConsensus.BlockNumByHash("94A")
+	require.True(t, ok)
+	require.Equal(t, int64(94), blockNum)
+	// hex hashes differ in case between clients
+	blockNum, ok = finalizationConsensus.BlockNumByHash("94a")
+	require.True(t, ok)
+	require.Equal(t, int64(94), blockNum)
+	_, ok = finalizationConsensus.BlockNumByHash("100A")
+	require.False(t, ok)
+
+	// the previous epoch consensus is still searched
+	finalizationConsensus.NewEpoch(epoch + 20)
+	blockNum, ok = finalizationConsensus.BlockNumByHash("93A")
+	require.True(t, ok)
+	require.Equal(t, int64(93), blockNum)
+}
@@ -0,0 +1,141 @@
+package lavaprotocol
+
+import (
+	"fmt"
+
+	"github.com/lavanet/lava/utils"
+	conflicttypes "github.com/lavanet/lava/x/conflict/types"
+)
+
+var (
+	// number of consecutive finalization updates disagreeing with the majority before a provider is considered on a fork
+	MinorityForkDisagreementThreshold = 3
+	// a majority is only trusted once this many providers reported a hash for the block
+	MinorityForkMinVoters = 3
+	// finalized blocks older than this distance from the newest tracked block are forgotten
+	MinorityForkBlocksWindow int64 = 100
+)
+
+// MinorityForkError is returned by DetectMinorityFork the first time a provider is flagged as serving from a minority fork
+type MinorityForkError struct {
+	Provider      string
+	BlockNum      int64
+	Hash          string
+	MajorityHash  string
+	Disagreements int
+	// the provider's reply against a majority provider's, nil when no majority reply is kept
+	Conflict *conflicttypes.FinalizationConflict
+}
+
+func (e *MinorityForkError) Error() string {
+	return fmt.Sprintf("%s: provider %s block %d hash %s, majority hash %s, disagreements %d", MinorityForkDetectedError.Error(), e.Provider, e.BlockNum, e.Hash, e.MajorityHash, e.Disagreements)
+}
+
+func (e *MinorityForkError) Cause() error {
+	return MinorityForkDetectedError
+}
+
+func (e *MinorityForkError) Unwrap() error {
+	return MinorityForkDetectedError
+}
+
+func (e *MinorityForkError) LogAttributes() []utils.Attribute {
+	return []utils.Attribute{utils.LogAttr("provider", e.Provider), utils.LogAttr("blockNum", e.BlockNum), utils.LogAttr("hash", e.Hash), utils.LogAttr("majorityHash", e.MajorityHash), utils.LogAttr("disagreements", e.Disagreements)}
+}
+
+// keeps the majority view of finalized block hashes across the providers' recent replies
+type minorityForkTracker struct {
+	votes         map[int64]map[string]string // block -> provider -> hash
+	disagreements map[string]int              // consecutive updates disagreeing with the majority
+	flagged       map[string]struct{}
+	newestBlock   int64
+}
+
+func newMinorityForkTracker() *minorityForkTracker {
+	return &minorityForkTracker{
+		votes:         map[int64]map[string]string{},
+		disagreements: map[string]int{},
+		flagged:       map[string]struct{}{},
+	}
+}
+
+// records the provider's finalized hashes and compares them with the majority, returns an error when the provider
+// crosses the disagreement threshold
+func (mft *minorityForkTracker) record(providerAddress string, finalizedBlocks map[int64]string) *MinorityForkError {
+	for blockNum, hash := range finalizedBlocks {
+		if _, ok := mft.votes[blockNum]; !ok {
+			mft.votes[blockNum] = map[string]string{}
+		}
+		mft.votes[blockNum][providerAddress] = hash
+		if blockNum > mft.newestBlock {
+			mft.newestBlock = blockNum
+		}
+	}
+	mft.prune()
+
+	var forkErr *MinorityForkError
+	compared := false
+	for blockNum, hash := range finalizedBlocks {
+		majorityHash, ok := mft.majority(blockNum)
+		if !ok {
+			continue
+		}
+		compared = true
+		if majorityHash != hash && (forkErr == nil || blockNum > forkErr.BlockNum) {
+			forkErr = &MinorityForkError{Provider: providerAddress, BlockNum: blockNum, Hash: hash, MajorityHash: majorityHash}
+		}
+	}
+	if !compared {
+		return nil
+	}
+	if forkErr == nil {
+		// back with the majority
+		delete(mft.disagreements, providerAddress)
+		delete(mft.flagged, providerAddress)
+		return nil
+	}
+	mft.disagreements[providerAddress]++
+	forkErr.Disagreements = mft.disagreements[providerAddress]
+	if forkErr.Disagreements < MinorityForkDisagreementThreshold {
+		return nil
+	}
+	if _, ok := mft.flagged[providerAddress]; ok {
+		return nil
+	}
+	mft.flagged[providerAddress] = struct{}{}
+	return forkErr
+}
+
+// returns the hash more than half of the block's voters agree on
+func (mft *minorityForkTracker) majority(blockNum int64) (string, bool) {
+	voters := mft.votes[blockNum]
+	if len(voters) < MinorityForkMinVoters {
+		return "", false
+	}
+	counts := map[string]int{}
+	for _, hash := range voters {
+		counts[hash]++
+		if counts[hash]*2 > len(voters) {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+func (mft *minorityForkTracker) prune() {
+	for blockNum := range mft.votes {
+		if blockNum < mft.newestBlock-MinorityForkBlocksWindow {
+			delete(mft.votes, blockNum)
+		}
+	}
+}
+
+func (mft *minorityForkTracker) isFlagged(providerAddress string) bool {
+	_, ok := mft.flagged[providerAddress]
+	return ok
+}
+
+// flags only last for the epoch they were raised in, providers still disagreeing are flagged again on their next update
+func (mft *minorityForkTracker) newEpoch() {
+	mft.flagged = map[string]struct{}{}
+}
@@ -0,0 +1,105 @@
+package lavaprotocol
+
+import (
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinorityForkDetection(t *testing.T) {
+	honest := []string{"lava@honest0", "lava@honest1", "lava@honest2", "lava@honest3"}
+	forked := "lava@forked"
+	epoch := uint64(200)
+
+	finalizationConsensus := &FinalizationConsensus{}
+	finalizationConsensus.NewEpoch(epoch)
+	updateRound := func(round uint64) error {
+		for _, provider := range honest {
+			require.NoError(t, finalizationConsensus.DetectMinorityFork(provider, createStubHashes(90+round, 93+round, ""), nil))
+		}
+		return finalizationConsensus.DetectMinorityFork(forked, createStubHashes(90+round, 93+round, "fork"), nil)
+	}
+
+	// disagreeing below the threshold isn't enough to be flagged
+	for round := uint64(1); round < uint64(MinorityForkDisagreementThreshold); round++ {
+		require.NoError(t, updateRound(round))
+		require.False(t, finalizationConsensus.IsOnMinorityFork(forked))
+	}
+	err := updateRound(uint64(MinorityForkDisagreementThreshold))
+	require.Error(t, err)
+	require.True(t, MinorityForkDetectedError.Is(err))
+	forkErr, ok := GetMinorityForkError(err)
+	require.True(t, ok)
+	require.Equal(t, forked, forkErr.Provider)
+	require.Equal(t, int64(93+MinorityForkDisagreementThreshold), forkErr.BlockNum)
+	require.Equal(t, forkErr.Hash, forkErr.MajorityHash+"fork")
+	require.Equal(t, MinorityForkDisagreementThreshold, forkErr.Disagreements)
+	// the majority's replies weren't kept, there's no evidence to file
+	require.Nil(t, forkErr.Conflict)
+	require.True(t, finalizationConsensus.IsOnMinorityFork(forked))
+	for _, provider := range honest {
+		require.False(t, finalizationConsensus.IsOnMinorityFork(provider))
+	}
+
+	// the signal is raised once per epoch
+	require.NoError(t, updateRound(uint64(MinorityForkDisagreementThreshold)+1))
+	require.True(t, finalizationConsensus.IsOnMinorityFork(forked))
+
+	// a new epoch clears the flag, the provider is flagged again as soon as it keeps disagreeing
+	finalizationConsensus.NewEpoch(epoch + 1)
+	require.False(t, finalizationConsensus.IsOnMinorityFork(forked))
+	err = updateRound(uint64(MinorityForkDisagreementThreshold) + 2)
+	require.Error(t, err)
+	require.True(t, finalizationConsensus.IsOnMinorityFork(forked))
+
+	// rejoining the majority resets the provider
+	require.NoError(t, finalizationConsensus.DetectMinorityFork(forked, createStubHashes(95, 98, ""), nil))
+	require.False(t, finalizationConsensus.IsOnMinorityFork(forked))
+}
+
+func TestMinorityForkNeedsMajority(t *testing.T) {
+	finalizationConsensus := &FinalizationConsensus{}
+	// with an even split there is no majority to disagree with
+	for round := 0; round < MinorityForkDisagreementThreshold+1; round++ {
+		require.NoError(t, finalizationConsensus.DetectMinorityFork("lava@provider0", createStubHashes(90, 93, ""), nil))
+		require.NoError(t, finalizationConsensus.DetectMinorityFork("lava@provider1", createStubHashes(90, 93, ""), nil))
+		require.NoError(t, finalizationConsensus.DetectMinorityFork("lava@provider2", createStubHashes(90, 93, "A"), nil))
+		require.NoError(t, finalizationConsensus.DetectMinorityFork("lava@provider3", createStubHashes(90, 93, "A"), nil))
+	}
+	require.False(t, finalizationConsensus.IsOnMinorityFork("lava@provider2"))
+}
+
+func TestMinorityForkConflictEvidence(t *testing.T) {
+	honest := []string{"lava@honest0", "lava@honest1", "lava@honest2"}
+	forked := "lava@forked"
+	const blockDistanceForFinalizedData = 4
+	finalizationConsensus := &FinalizationConsensus{}
+	finalizationConsensus.NewEpoch(200)
+
+	var forkErr *MinorityForkError
+	for round := uint64(1); round <= uint64(MinorityForkDisagreementThreshold); round++ {
+		for _, provider := range honest {
+			hashes := createStubHashes(90+round, 93+round, "")
+			reply := &pairingtypes.RelayReply{LatestBlock: int64(97 + round)}
+			require.NoError(t, finalizationConsensus.DetectMinorityFork(provider, hashes, reply))
+			_, err := finalizationConsensus.UpdateFinalizedHashes(blockDistanceForFinalizedData, provider, hashes, &pairingtypes.RelaySession{}, reply)
+			require.NoError(t, err)
+		}
+		forkedReply := &pairingtypes.RelayReply{LatestBlock: int64(97 + round), Data: []byte("fork")}
+		err := finalizationConsensus.DetectMinorityFork(forked, createStubHashes(90+round, 93+round, "fork"), forkedReply)
+		if round < uint64(MinorityForkDisagreementThreshold) {
+			require.NoError(t, err)
+			continue
+		}
+		var ok bool
+		forkErr, ok = GetMinorityForkError(err)
+		require.True(t, ok)
+		// the fork is raised with the forked reply against a majority provider's signed reply
+		require.NotNil(t, forkErr.Conflict)
+		require.Equal(t, forkedReply, forkErr.Conflict.RelayReply0)
+		require.NotNil(t, forkErr.Conflict.RelayReply1)
+		require.NotEqual(t, forkedReply, forkErr.Conflict.RelayReply1)
+	}
+	require.NotNil(t, forkErr)
+}
@@ -0,0 +1,66 @@
+package lavaprotocol
+
+import (
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+const (
+	SignReplyCommitmentsFlag    = "sign-reply-commitments"
+	RequestReplyCommitmentsFlag = "request-reply-commitments"
+	ReplyCommitmentMethodsFlag  = "reply-commitment-methods"
+	ReplyCommitmentSha256       = "sha256"
+)
+
+// providers sign a commitment to the reply data instead of the data itself when the consumer asks for it
+var SignReplyCommitments = true
+
+// consumers ask providers to sign a commitment to the reply data, which saves copying big replies into the signed
+// message. replies that may be filed as conflict evidence are always signed in full
+var RequestReplyCommitments = false
+
+// methods whose replies are always signed with a commitment, for big replies like full transaction blocks. their
+// replies can't be filed as conflict evidence so data reliability skips them
+var ReplyCommitmentMethods = []string{}
+
+// IsReplyCommitmentMethod returns whether the method's replies are always signed with a commitment
+func IsReplyCommitmentMethod(method string) bool {
+	for _, commitmentMethod := range ReplyCommitmentMethods {
+		if commitmentMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixed to the committed hash so a commitment signature can't pass as a full signature over a reply holding the hash
+var replyCommitmentPrefix = []byte("lava-reply-commitment/sha256/")
+
+// relayReplySignable returns what the reply signature covers, the relay exchange or, for replies signed with a
+// commitment, the relay exchange with the data replaced by its hash
+func relayReplySignable(request pairingtypes.RelayRequest, reply pairingtypes.RelayReply, commitment string) (sigs.Signable, error) {
+	switch commitment {
+	case "":
+		return pairingtypes.NewRelayExchange(request, reply), nil
+	case ReplyCommitmentSha256:
+		reply.Data = append(append([]byte{}, replyCommitmentPrefix...), sigs.HashMsg(reply.Data)...)
+		return pairingtypes.NewRelayExchange(request, reply), nil
+	default:
+		return nil, sdkerrors.Wrapf(ReplyCommitmentError, "unsupported reply commitment %s", commitment)
+	}
+}
+
+// NegotiateReplyCommitment picks the commitment the reply is signed with out of the ones the consumer accepts, empty
+// when the reply is signed in full
+func NegotiateReplyCommitment(accepted []string) string {
+	if !SignReplyCommitments {
+		return ""
+	}
+	for _, commitment := range accepted {
+		if commitment == ReplyCommitmentSha256 {
+			return commitment
+		}
+	}
+	return ""
+}
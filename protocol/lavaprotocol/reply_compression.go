@@ -0,0 +1,10 @@
+package lavaprotocol
+
+import (
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
+)
+
+const MaxDecompressedReplySizeFlag = "max-decompressed-reply-size"
+
+// replies decompressing beyond this are aborted, defaults to the reply size cap
+var MaxDecompressedReplySize int64 = chainproxy.MaxCallRecvMsgSize
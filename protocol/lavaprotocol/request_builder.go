@@ -66,7 +66,17 @@ func NewRelayData(ctx context.Context, connectionType, apiUrl string, data []byt
 	return relayData
 }
 
+// RelayContentHash is the hash of the relay data a relay session commits to, it doesn't depend on the session so relays
+// sending the same data to several providers can compute it once
+func RelayContentHash(relayRequestData *pairingtypes.RelayPrivateData) []byte {
+	return sigs.HashMsg(relayRequestData.GetContentHashData())
+}
+
 func ConstructRelaySession(lavaChainID string, relayRequestData *pairingtypes.RelayPrivateData, chainID, providerPublicAddress string, singleConsumerSession *lavasession.SingleConsumerSession, epoch int64, reportedProviders []*pairingtypes.ReportedProvider) *pairingtypes.RelaySession {
+	return constructRelaySession(lavaChainID, RelayContentHash(relayRequestData), chainID, providerPublicAddress, singleConsumerSession, epoch, reportedProviders)
+}
+
+func constructRelaySession(lavaChainID string, contentHash []byte, chainID, providerPublicAddress string, singleConsumerSession *lavasession.SingleConsumerSession, epoch int64, reportedProviders []*pairingtypes.ReportedProvider) *pairingtypes.RelaySession {
 	copyQoSServiceReport := func(reportToCopy *pairingtypes.QualityOfServiceReport) *pairingtypes.QualityOfServiceReport {
 		if reportToCopy != nil {
 			QOS := *reportToCopy
@@ -80,7 +90,7 @@ func ConstructRelaySession(lavaChainID string, relayRequestData *pairingtypes.Re
 
 	return &pairingtypes.RelaySession{
 		SpecId:                chainID,
-		ContentHash:           sigs.HashMsg(relayRequestData.GetContentHashData()),
+		ContentHash:           contentHash,
 		SessionId:             uint64(singleConsumerSession.SessionId),
 		CuSum:                 singleConsumerSession.CuSum + singleConsumerSession.LatestRelayCu, // add the latestRelayCu which will be applied when session is returned properly,
 		Provider:              providerPublicAddress,
@@ -96,9 +106,17 @@ func ConstructRelaySession(lavaChainID string, relayRequestData *pairingtypes.Re
 }
 
 func ConstructRelayRequest(ctx context.Context, privKey *btcec.PrivateKey, lavaChainID, chainID string, relayRequestData *pairingtypes.RelayPrivateData, providerPublicAddress string, consumerSession *lavasession.SingleConsumerSession, epoch int64, reportedProviders []*pairingtypes.ReportedProvider) (*pairingtypes.RelayRequest, error) {
+	return ConstructRelayRequestWithContentHash(ctx, privKey, lavaChainID, chainID, relayRequestData, RelayContentHash(relayRequestData), providerPublicAddress, consumerSession, epoch, reportedProviders)
+}
+
+// ConstructRelayRequestWithContentHash is ConstructRelayRequest with the relay data's RelayContentHash computed by the
+// caller, so parallel relays of the same data only hash it once. the session is still signed per relay: the signature
+// covers the whole relay session, session id, relay num and cu sum included, and a compact secp256k1 signature can't
+// be split into a shared part and a per session delta
+func ConstructRelayRequestWithContentHash(ctx context.Context, privKey *btcec.PrivateKey, lavaChainID, chainID string, relayRequestData *pairingtypes.RelayPrivateData, contentHash []byte, providerPublicAddress string, consumerSession *lavasession.SingleConsumerSession, epoch int64, reportedProviders []*pairingtypes.ReportedProvider) (*pairingtypes.RelayRequest, error) {
 	relayRequest := &pairingtypes.RelayRequest{
 		RelayData:    relayRequestData,
-		RelaySession: ConstructRelaySession(lavaChainID, relayRequestData, chainID, providerPublicAddress, consumerSession, epoch, reportedProviders),
+		RelaySession: constructRelaySession(lavaChainID, contentHash, chainID, providerPublicAddress, consumerSession, epoch, reportedProviders),
 	}
 	sig, err := sigs.Sign(privKey, *relayRequest.RelaySession)
 	if err != nil {
@@ -134,6 +152,28 @@ func ReplaceRequestedBlock(requestedBlock, latestBlock int64) int64 {
 	return requestedBlock
 }
 
+// ResolveBlockTag resolves the finalized tag to the latest block the finalization distance considers final and the safe
+// tag to halfway there, the safe head is past the latest block's reorgs but not final yet. other blocks are returned as
+// is. replies are signed over ReplaceRequestedBlock's resolution so this is only applied once they are verified
+func ResolveBlockTag(requestedBlock, latestBlock int64, blockDistanceToFinalization uint32) int64 {
+	if latestBlock <= 0 {
+		return requestedBlock
+	}
+	var resolvedBlock int64
+	switch requestedBlock {
+	case spectypes.FINALIZED_BLOCK:
+		resolvedBlock = latestBlock - int64(blockDistanceToFinalization)
+	case spectypes.SAFE_BLOCK:
+		resolvedBlock = latestBlock - int64(blockDistanceToFinalization/2)
+	default:
+		return requestedBlock
+	}
+	if resolvedBlock < 0 {
+		return 0
+	}
+	return resolvedBlock
+}
+
 func VerifyReliabilityResults(ctx context.Context, originalResult, dataReliabilityResult *common.RelayResult, apiCollection *spectypes.ApiCollection, headerFilterer HeaderFilterer) (conflicts *conflicttypes.ResponseConflict) {
 	conflict_now, detectionMessage := compareRelaysFindConflict(ctx, *originalResult.Reply, *originalResult.Request, *dataReliabilityResult.Reply, *dataReliabilityResult.Request, apiCollection, headerFilterer)
 	if conflict_now {
@@ -16,11 +16,20 @@ import (
 )
 
 func SignRelayResponse(consumerAddress sdk.AccAddress, request pairingtypes.RelayRequest, pkey *btcSecp256k1.PrivateKey, reply *pairingtypes.RelayReply, signDataReliability bool) (*pairingtypes.RelayReply, error) {
+	return SignRelayResponseWithCommitment(consumerAddress, request, pkey, reply, signDataReliability, "")
+}
+
+// SignRelayResponseWithCommitment signs the reply like SignRelayResponse, over a commitment to the reply data when one
+// is set. the consumer must be told the commitment to verify the signature
+func SignRelayResponseWithCommitment(consumerAddress sdk.AccAddress, request pairingtypes.RelayRequest, pkey *btcSecp256k1.PrivateKey, reply *pairingtypes.RelayReply, signDataReliability bool, commitment string) (*pairingtypes.RelayReply, error) {
 	// request is a copy of the original request, but won't modify it
 	// update relay request requestedBlock to the provided one in case it was arbitrary
 	UpdateRequestedBlock(request.RelayData, reply)
 	// Update signature,
-	relayExchange := pairingtypes.NewRelayExchange(request, *reply)
+	relayExchange, err := relayReplySignable(request, *reply, commitment)
+	if err != nil {
+		return nil, err
+	}
 	sig, err := sigs.Sign(pkey, relayExchange)
 	if err != nil {
 		return nil, utils.LavaFormatError("failed signing relay response", err,
@@ -42,20 +51,49 @@ func SignRelayResponse(consumerAddress sdk.AccAddress, request pairingtypes.Rela
 }
 
 func VerifyRelayReply(ctx context.Context, reply *pairingtypes.RelayReply, relayRequest *pairingtypes.RelayRequest, addr string) error {
-	relayExchange := pairingtypes.NewRelayExchange(*relayRequest, *reply)
-	serverKey, err := sigs.RecoverPubKey(relayExchange)
+	return VerifyRelayReplyWithCodec(ctx, reply, relayRequest, addr, DefaultAddressCodec)
+}
+
+// VerifyRelayReplyWithCodec verifies the reply was signed by addr, parsing and formatting addresses with the chain's codec
+func VerifyRelayReplyWithCodec(ctx context.Context, reply *pairingtypes.RelayReply, relayRequest *pairingtypes.RelayRequest, addr string, addressCodec AddressCodec) error {
+	return VerifyRelayReplyCommitment(ctx, reply, relayRequest, addr, addressCodec, "")
+}
+
+// VerifyRelayReplyCommitment verifies a reply signed with commitment by addr, the reply data is checked against the
+// commitment as part of the signature so a tampered payload fails like a wrong signer. only the staked address is
+// accepted, a reply signed by another key can't be held against the provider in a conflict on chain
+func VerifyRelayReplyCommitment(ctx context.Context, reply *pairingtypes.RelayReply, relayRequest *pairingtypes.RelayRequest, addr string, addressCodec AddressCodec, commitment string) error {
+	relayExchange, err := relayReplySignable(*relayRequest, *reply, commitment)
 	if err != nil {
-		return err
+		return utils.LavaFormatWarning("failed verifying relay reply", err, utils.LogAttr("GUID", ctx), utils.LogAttr("expected address", addr))
 	}
-	serverAddr, err := sdk.AccAddressFromHexUnsafe(serverKey.Address().String())
+	serverKey, err := sigs.RecoverPubKey(relayExchange)
 	if err != nil {
-		return err
+		// a signature we can't recover from is usually a transport issue, unlike a valid signature of the wrong signer
+		return utils.LavaFormatWarning("failed recovering relay reply signer", RelayReplySignatureRecoveryError, utils.LogAttr("GUID", ctx), utils.LogAttr("expected address", addr), utils.LogAttr("sigLen", len(reply.Sig)), utils.LogAttr("error", err))
+	}
+	serverAddr := serverKey.Address().Bytes()
+	if addressMatches(addressCodec, serverAddr, addr) {
+		return nil
 	}
-	if serverAddr.String() != addr {
-		return utils.LavaFormatError("reply server address mismatch ", ProviderFinzalizationDataError, utils.LogAttr("GUID", ctx), utils.Attribute{Key: "parsed Address", Value: serverAddr.String()}, utils.Attribute{Key: "expected address", Value: addr}, utils.Attribute{Key: "requestedBlock", Value: relayRequest.RelayData.RequestBlock}, utils.Attribute{Key: "latestBlock", Value: reply.GetLatestBlock()})
+	parsedAddr, err := addressCodec.BytesToString(serverAddr)
+	if err != nil {
+		return utils.LavaFormatWarning("failed formatting relay reply signer address", RelayReplySignatureRecoveryError, utils.LogAttr("GUID", ctx), utils.LogAttr("expected address", addr), utils.LogAttr("error", err))
 	}
+	return utils.LavaFormatError("reply server address mismatch ", ProviderFinzalizationDataError, utils.LogAttr("GUID", ctx), utils.Attribute{Key: "parsed Address", Value: parsedAddr}, utils.Attribute{Key: "expected address", Value: addr}, utils.Attribute{Key: "requestedBlock", Value: relayRequest.RelayData.RequestBlock}, utils.Attribute{Key: "latestBlock", Value: reply.GetLatestBlock()})
+}
 
-	return nil
+// VerifySpecVersion checks the spec version a provider signed into its reply, providers only set it when it differs from the one the consumer sent.
+// a newer provider spec returns SpecVersionMismatchError, an older one ProviderSpecOutdatedError
+func VerifySpecVersion(ctx context.Context, consumerSpecVersion uint64, providerSpecVersion uint64, providerAddr string) error {
+	if providerSpecVersion == 0 || providerSpecVersion == consumerSpecVersion {
+		return nil
+	}
+	attributes := []utils.Attribute{utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerAddr), utils.LogAttr("consumerSpecVersion", consumerSpecVersion), utils.LogAttr("providerSpecVersion", providerSpecVersion)}
+	if providerSpecVersion > consumerSpecVersion {
+		return utils.LavaFormatWarning("provider is running a newer spec, the consumer spec is outdated and should be updated, compute units and requested blocks might be miscalculated", SpecVersionMismatchError, attributes...)
+	}
+	return utils.LavaFormatLog("provider is running an older spec than the consumer", ProviderSpecOutdatedError, attributes, utils.LAVA_LOG_DEBUG)
 }
 
 func VerifyFinalizationData(reply *pairingtypes.RelayReply, relayRequest *pairingtypes.RelayRequest, providerAddr string, consumerAcc sdk.AccAddress, latestSessionBlock int64, blockDistanceForfinalization uint32) (finalizedBlocks map[int64]string, finalizationConflict *conflicttypes.FinalizationConflict, errRet error) {
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"strconv"
 	"testing"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/utils/sigs"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
@@ -114,3 +116,216 @@ func TestSignAndExtractResponseLatest(t *testing.T) {
 	_, _, err = VerifyFinalizationData(reply, relay, provider_address.String(), consumer_address, int64(0), 0)
 	require.NoError(t, err)
 }
+
+func TestVerifyRelayReplyFailureClasses(t *testing.T) {
+	ctx := context.Background()
+	consumer_sk, _ := sigs.GenerateFloatingKey()
+	provider_sk, provider_address := sigs.GenerateFloatingKey()
+	other_sk, _ := sigs.GenerateFloatingKey()
+	epoch := int64(100)
+	singleConsumerSession := &lavasession.SingleConsumerSession{
+		CuSum:         20,
+		LatestRelayCu: 10,
+		QoSInfo:       lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}},
+		SessionId:     123,
+		RelayNum:      1,
+		LatestBlock:   epoch,
+	}
+	relayRequestData := NewRelayData(ctx, "GET", "stub_url", []byte("stub_data"), 0, 55, "tendermintrpc", nil, "test", nil)
+	relay, err := ConstructRelayRequest(ctx, consumer_sk, "lava", "LAV1", relayRequestData, provider_address.String(), singleConsumerSession, epoch, unresponsiveProviderStub())
+	require.NoError(t, err)
+	consumerAddress, err := sigs.ExtractSignerAddress(relay.RelaySession)
+	require.NoError(t, err)
+
+	// truncated signature, can't recover the signer
+	reply, err := SignRelayResponse(consumerAddress, *relay, provider_sk, &pairingtypes.RelayReply{Data: []byte("stub")}, false)
+	require.NoError(t, err)
+	reply.Sig = reply.Sig[:len(reply.Sig)/2]
+	err = VerifyRelayReply(ctx, reply, relay, provider_address.String())
+	require.Error(t, err)
+	require.True(t, RelayReplySignatureRecoveryError.Is(err))
+
+	// valid signature of the wrong signer
+	reply, err = SignRelayResponse(consumerAddress, *relay, other_sk, &pairingtypes.RelayReply{Data: []byte("stub")}, false)
+	require.NoError(t, err)
+	err = VerifyRelayReply(ctx, reply, relay, provider_address.String())
+	require.Error(t, err)
+	require.False(t, RelayReplySignatureRecoveryError.Is(err))
+	require.True(t, ProviderFinzalizationDataError.Is(err))
+}
+
+func TestVerifyRelayReplyAddressCodec(t *testing.T) {
+	ctx := context.Background()
+	consumer_sk, _ := sigs.GenerateFloatingKey()
+	provider_sk, provider_address := sigs.GenerateFloatingKey()
+	singleConsumerSession := &lavasession.SingleConsumerSession{
+		CuSum:         20,
+		LatestRelayCu: 10,
+		QoSInfo:       lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}},
+		SessionId:     123,
+		RelayNum:      1,
+	}
+	relayRequestData := NewRelayData(ctx, "GET", "stub_url", []byte("stub_data"), 0, 55, "tendermintrpc", nil, "test", nil)
+	relay, err := ConstructRelayRequest(ctx, consumer_sk, "lava", "LAV1", relayRequestData, provider_address.String(), singleConsumerSession, 100, unresponsiveProviderStub())
+	require.NoError(t, err)
+	consumerAddress, err := sigs.ExtractSignerAddress(relay.RelaySession)
+	require.NoError(t, err)
+	reply, err := SignRelayResponse(consumerAddress, *relay, provider_sk, &pairingtypes.RelayReply{Data: []byte("stub")}, false)
+	require.NoError(t, err)
+
+	// the same provider key under two chains' prefixes, each verified with its own codec
+	for _, prefix := range []string{"osmo", "juno"} {
+		codec := NewBech32AddressCodec(prefix)
+		addr, err := codec.BytesToString(provider_address)
+		require.NoError(t, err)
+		require.NoError(t, VerifyRelayReplyWithCodec(ctx, reply, relay, addr, codec))
+		// the default codec only knows the global prefix
+		require.Error(t, VerifyRelayReply(ctx, reply, relay, addr))
+	}
+	osmoAddr, err := NewBech32AddressCodec("osmo").BytesToString(provider_address)
+	require.NoError(t, err)
+	err = VerifyRelayReplyWithCodec(ctx, reply, relay, osmoAddr, NewBech32AddressCodec("juno"))
+	require.True(t, ProviderFinzalizationDataError.Is(err))
+	require.NoError(t, VerifyRelayReplyWithCodec(ctx, reply, relay, provider_address.String(), DefaultAddressCodec))
+
+	// pairing addresses keep the lava prefix, the display prefix doesn't change what's verified
+	displayCodec := NewDisplayAddressCodec("osmo")
+	require.NoError(t, VerifyRelayReplyWithCodec(ctx, reply, relay, provider_address.String(), displayCodec))
+	require.Error(t, VerifyRelayReplyWithCodec(ctx, reply, relay, osmoAddr, displayCodec))
+	shown, err := displayCodec.BytesToString(provider_address)
+	require.NoError(t, err)
+	require.Equal(t, osmoAddr, shown)
+}
+
+func commitmentTestRelay(t require.TestingT, ctx context.Context) (*pairingtypes.RelayRequest, sdk.AccAddress) {
+	consumer_sk, _ := sigs.GenerateFloatingKey()
+	singleConsumerSession := &lavasession.SingleConsumerSession{
+		CuSum:         20,
+		LatestRelayCu: 10,
+		QoSInfo:       lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}},
+		SessionId:     123,
+		RelayNum:      1,
+	}
+	relayRequestData := NewRelayData(ctx, "GET", "stub_url", []byte("stub_data"), 0, 55, "tendermintrpc", nil, "test", nil)
+	relay, err := ConstructRelayRequest(ctx, consumer_sk, "lava", "LAV1", relayRequestData, "provider", singleConsumerSession, 100, unresponsiveProviderStub())
+	require.NoError(t, err)
+	consumerAddress, err := sigs.ExtractSignerAddress(relay.RelaySession)
+	require.NoError(t, err)
+	return relay, consumerAddress
+}
+
+func TestVerifyRelayReplyCommitment(t *testing.T) {
+	ctx := context.Background()
+	provider_sk, provider_address := sigs.GenerateFloatingKey()
+	relay, consumerAddress := commitmentTestRelay(t, ctx)
+	sign := func(commitment string) *pairingtypes.RelayReply {
+		reply := &pairingtypes.RelayReply{Data: []byte(`{"result":"big reply"}`), FinalizedBlocksHashes: []byte("{}"), Metadata: []pairingtypes.Metadata{{Name: "a", Value: "b"}}}
+		reply, err := SignRelayResponseWithCommitment(consumerAddress, *relay, provider_sk, reply, true, commitment)
+		require.NoError(t, err)
+		return reply
+	}
+
+	reply := sign(ReplyCommitmentSha256)
+	err := VerifyRelayReplyCommitment(ctx, reply, relay, provider_address.String(), DefaultAddressCodec, ReplyCommitmentSha256)
+	require.NoError(t, err)
+	// the finalization signature doesn't cover the data and is unaffected
+	_, _, err = VerifyFinalizationData(reply, relay, provider_address.String(), consumerAddress, 0, 0)
+	require.NoError(t, err)
+	// a commitment signature doesn't verify as a full one
+	err = VerifyRelayReply(ctx, reply, relay, provider_address.String())
+	require.True(t, ProviderFinzalizationDataError.Is(err))
+
+	// the payload must match the committed hash
+	tampered := *reply
+	tampered.Data = []byte(`{"result":"tampered"}`)
+	err = VerifyRelayReplyCommitment(ctx, &tampered, relay, provider_address.String(), DefaultAddressCodec, ReplyCommitmentSha256)
+	require.True(t, ProviderFinzalizationDataError.Is(err))
+
+	// providers without commitment support sign in full and are verified in full
+	reply = sign("")
+	err = VerifyRelayReplyCommitment(ctx, reply, relay, provider_address.String(), DefaultAddressCodec, "")
+	require.NoError(t, err)
+
+	err = VerifyRelayReplyCommitment(ctx, reply, relay, provider_address.String(), DefaultAddressCodec, "md5")
+	require.True(t, ReplyCommitmentError.Is(err))
+	_, err = SignRelayResponseWithCommitment(consumerAddress, *relay, provider_sk, &pairingtypes.RelayReply{}, false, "md5")
+	require.True(t, ReplyCommitmentError.Is(err))
+}
+
+func TestNegotiateReplyCommitment(t *testing.T) {
+	defer func(sign bool) { SignReplyCommitments = sign }(SignReplyCommitments)
+	SignReplyCommitments = true
+	require.Equal(t, ReplyCommitmentSha256, NegotiateReplyCommitment([]string{"md5", ReplyCommitmentSha256}))
+	require.Equal(t, "", NegotiateReplyCommitment([]string{"md5"}))
+	require.Equal(t, "", NegotiateReplyCommitment(nil))
+	SignReplyCommitments = false
+	require.Equal(t, "", NegotiateReplyCommitment([]string{ReplyCommitmentSha256}))
+}
+
+func BenchmarkVerifyRelayReply(b *testing.B) {
+	ctx := context.Background()
+	provider_sk, provider_address := sigs.GenerateFloatingKey()
+	relay, consumerAddress := commitmentTestRelay(b, ctx)
+	for _, size := range []int{1 << 10, 1 << 20, 16 << 20} {
+		for _, commitment := range []string{"", ReplyCommitmentSha256} {
+			name := "full"
+			if commitment != "" {
+				name = "commitment"
+			}
+			b.Run(name+"/"+strconv.Itoa(size), func(b *testing.B) {
+				reply, err := SignRelayResponseWithCommitment(consumerAddress, *relay, provider_sk, &pairingtypes.RelayReply{Data: bytes.Repeat([]byte("a"), size)}, false, commitment)
+				require.NoError(b, err)
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := VerifyRelayReplyCommitment(ctx, reply, relay, provider_address.String(), DefaultAddressCodec, commitment); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestVerifySpecVersion(t *testing.T) {
+	ctx := context.Background()
+	// providers running the same spec don't signal anything
+	require.NoError(t, VerifySpecVersion(ctx, 100, 0, "provider"))
+	require.NoError(t, VerifySpecVersion(ctx, 100, 100, "provider"))
+	// newer provider spec, the consumer is outdated
+	err := VerifySpecVersion(ctx, 100, 120, "provider")
+	require.True(t, SpecVersionMismatchError.Is(err))
+	// older provider spec
+	err = VerifySpecVersion(ctx, 120, 100, "provider")
+	require.True(t, ProviderSpecOutdatedError.Is(err))
+}
+
+func TestSpecVersionIsSigned(t *testing.T) {
+	ctx := context.Background()
+	consumer_sk, _ := sigs.GenerateFloatingKey()
+	provider_sk, provider_address := sigs.GenerateFloatingKey()
+	singleConsumerSession := &lavasession.SingleConsumerSession{
+		CuSum:         20,
+		LatestRelayCu: 10,
+		QoSInfo:       lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}},
+		SessionId:     123,
+		RelayNum:      1,
+		LatestBlock:   100,
+	}
+	relayRequestData := NewRelayData(ctx, "GET", "stub_url", []byte("stub_data"), 0, 55, "tendermintrpc", nil, "test", nil)
+	relay, err := ConstructRelayRequest(ctx, consumer_sk, "lava", "LAV1", relayRequestData, provider_address.String(), singleConsumerSession, 100, unresponsiveProviderStub())
+	require.NoError(t, err)
+	consumerAddress, err := sigs.ExtractSignerAddress(relay.RelaySession)
+	require.NoError(t, err)
+
+	reply, err := SignRelayResponse(consumerAddress, *relay, provider_sk, &pairingtypes.RelayReply{Data: []byte("stub"), SpecVersion: 120}, false)
+	require.NoError(t, err)
+	require.NoError(t, VerifyRelayReply(ctx, reply, relay, provider_address.String()))
+
+	// a spec version changed after signing, or dropped, fails the verification
+	reply.SpecVersion = 100
+	require.Error(t, VerifyRelayReply(ctx, reply, relay, provider_address.String()))
+	reply.SpecVersion = 0
+	require.Error(t, VerifyRelayReply(ctx, reply, relay, provider_address.String()))
+}
@@ -1,12 +1,15 @@
 package lavaprotocol
 
 import (
+	"bytes"
 	"context"
+	"strconv"
 	"testing"
 
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/utils/sigs"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -41,3 +44,74 @@ func TestSignAndExtract(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, extractedConsumerAddress, address)
 }
+
+func TestConstructRelayRequestWithContentHash(t *testing.T) {
+	ctx := context.Background()
+	sk, address := sigs.GenerateFloatingKey()
+	relayRequestData := NewRelayData(ctx, "POST", "", []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","id":1}`), 0, spectypes.LATEST_BLOCK, "jsonrpc", nil, "", nil)
+	contentHash := RelayContentHash(relayRequestData)
+
+	// parallel relays share the content hash, each session is signed on its own
+	for sessionId := int64(1); sessionId <= 3; sessionId++ {
+		singleConsumerSession := &lavasession.SingleConsumerSession{SessionId: sessionId, RelayNum: 1, LatestRelayCu: 10}
+		shared, err := ConstructRelayRequestWithContentHash(ctx, sk, "lava", "ETH1", relayRequestData, contentHash, "lava@provider"+strconv.Itoa(int(sessionId)), singleConsumerSession, 100, nil)
+		require.NoError(t, err)
+		perRelay, err := ConstructRelayRequest(ctx, sk, "lava", "ETH1", relayRequestData, "lava@provider"+strconv.Itoa(int(sessionId)), singleConsumerSession, 100, nil)
+		require.NoError(t, err)
+		require.Equal(t, perRelay.RelaySession.ContentHash, shared.RelaySession.ContentHash)
+		extractedConsumerAddress, err := sigs.ExtractSignerAddress(shared.RelaySession)
+		require.NoError(t, err)
+		require.Equal(t, address, extractedConsumerAddress)
+	}
+}
+
+func BenchmarkConstructRelayRequests(b *testing.B) {
+	ctx := context.Background()
+	sk, _ := sigs.GenerateFloatingKey()
+	const parallelRelays = 3
+	for _, size := range []int{1 << 10, 1 << 20} {
+		relayRequestData := NewRelayData(ctx, "POST", "", bytes.Repeat([]byte("a"), size), 0, spectypes.LATEST_BLOCK, "jsonrpc", nil, "", nil)
+		sessions := make([]*lavasession.SingleConsumerSession, parallelRelays)
+		for idx := range sessions {
+			sessions[idx] = &lavasession.SingleConsumerSession{SessionId: int64(idx + 1), RelayNum: 1, LatestRelayCu: 10}
+		}
+		b.Run("per-relay/"+strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, session := range sessions {
+					if _, err := ConstructRelayRequest(ctx, sk, "lava", "ETH1", relayRequestData, "lava@provider", session, 100, nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run("shared-content-hash/"+strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				contentHash := RelayContentHash(relayRequestData)
+				for _, session := range sessions {
+					if _, err := ConstructRelayRequestWithContentHash(ctx, sk, "lava", "ETH1", relayRequestData, contentHash, "lava@provider", session, 100, nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestResolveBlockTag(t *testing.T) {
+	// replies are signed over the latest block for every tag
+	require.Equal(t, int64(100), ReplaceRequestedBlock(spectypes.SAFE_BLOCK, 100))
+	require.Equal(t, int64(100), ReplaceRequestedBlock(spectypes.FINALIZED_BLOCK, 100))
+
+	require.Equal(t, int64(93), ResolveBlockTag(spectypes.FINALIZED_BLOCK, 100, 7))
+	require.True(t, spectypes.IsFinalizedBlock(ResolveBlockTag(spectypes.FINALIZED_BLOCK, 100, 7), 100, 7))
+	require.Equal(t, int64(0), ResolveBlockTag(spectypes.FINALIZED_BLOCK, 5, 7))
+	// unknown latest block keeps the tag
+	require.Equal(t, spectypes.FINALIZED_BLOCK, ResolveBlockTag(spectypes.FINALIZED_BLOCK, 0, 7))
+	// safe is halfway to the finalized block and isn't final
+	require.Equal(t, int64(97), ResolveBlockTag(spectypes.SAFE_BLOCK, 100, 7))
+	require.False(t, spectypes.IsFinalizedBlock(ResolveBlockTag(spectypes.SAFE_BLOCK, 100, 7), 100, 7))
+	require.Equal(t, spectypes.SAFE_BLOCK, ResolveBlockTag(spectypes.SAFE_BLOCK, 0, 7))
+	require.Equal(t, int64(50), ResolveBlockTag(50, 100, 7))
+}
@@ -0,0 +1,34 @@
+package lavaprotocol
+
+import (
+	"github.com/lavanet/lava/protocol/common"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// SimulatedRelayMetadata marks the relay data of a simulated relay, and the reply of a provider that served it without
+// settling it. both are signed, so neither side can claim the other agreed to a relay that isn't settled
+var SimulatedRelayMetadata = pairingtypes.Metadata{Name: common.SimulatedRelayMetadataKey, Value: "true"}
+
+// IsSimulatedRelay returns whether the metadata carries the simulated relay mark
+func IsSimulatedRelay(metadata []pairingtypes.Metadata) bool {
+	for _, entry := range metadata {
+		if entry == SimulatedRelayMetadata {
+			return true
+		}
+	}
+	return false
+}
+
+// TakeSimulatedRelayAck separates the provider's acknowledgement of a simulated relay from the reply metadata, the
+// provider appends it after filtering its headers so it's signed last
+func TakeSimulatedRelayAck(metadata []pairingtypes.Metadata) (acknowledged bool, rest []pairingtypes.Metadata) {
+	rest = make([]pairingtypes.Metadata, 0, len(metadata))
+	for _, entry := range metadata {
+		if entry == SimulatedRelayMetadata {
+			acknowledged = true
+			continue
+		}
+		rest = append(rest, entry)
+	}
+	return acknowledged, rest
+}
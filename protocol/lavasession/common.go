@@ -15,6 +15,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/gogo/status"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
+	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/utils"
 	"github.com/lavanet/lava/x/pairing/keeper/scores"
 	planstypes "github.com/lavanet/lava/x/plans/types"
@@ -49,7 +50,8 @@ const (
 	OptimizerPerturbation        = 0.10
 	LatencyThresholdStatic       = 1 * time.Second
 	LatencyThresholdSlope        = 1 * time.Millisecond
-	StaleEpochDistance           = 3 // relays done 3 epochs back are ready to be rewarded
+	StaleEpochDistance           = 3   // relays done 3 epochs back are ready to be rewarded
+	MaxLatestBlockRegression     = 100 // a provider's latest block can go back this much from the previous known height (node switching, reorgs) before it's considered invalid
 
 )
 
@@ -58,13 +60,30 @@ func IsSessionSyncLoss(err error) bool {
 	return code == codes.Code(SessionOutOfSyncError.ABCICode())
 }
 
+// the provider rejected the relay because we used all of our compute units with it this epoch
+func IsProviderCuExhausted(err error) bool {
+	code := status.Code(err)
+	return code == codes.Code(MaximumCULimitReachedByConsumer.ABCICode())
+}
+
+// a latest block is invalid if it's not positive, or if it regressed too far from the previously known height
+func IsValidLatestBlock(latestBlock int64, previousLatestBlock int64) bool {
+	if latestBlock <= 0 {
+		return false
+	}
+	return previousLatestBlock <= 0 || latestBlock >= previousLatestBlock-MaxLatestBlockRegression
+}
+
 func ConnectgRPCClient(ctx context.Context, address string, allowInsecure bool) (*grpc.ClientConn, error) {
 	var tlsConf tls.Config
 	if allowInsecure {
 		tlsConf.InsecureSkipVerify = true // this will allow us to use self signed certificates in development.
 	}
 	credentials := credentials.NewTLS(&tlsConf)
-	conn, err := grpc.DialContext(ctx, address, grpc.WithBlock(), grpc.WithTransportCredentials(credentials), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(chainproxy.MaxCallRecvMsgSize)))
+	opts := []grpc.DialOption{grpc.WithBlock(), grpc.WithContextDialer(common.OutboundProxyDialer(common.OutboundProxy)), grpc.WithTransportCredentials(credentials), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(chainproxy.MaxCallRecvMsgSize))}
+	opts = append(opts, relayClientKeepaliveOptions()...)
+	opts = append(opts, FirstByteDialOption())
+	conn, err := grpc.DialContext(ctx, address, opts...)
 	return conn, err
 }
 
@@ -1,9 +1,14 @@
 package lavasession
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/lavanet/lava/protocol/common"
 	planstypes "github.com/lavanet/lava/x/plans/types"
 	"github.com/stretchr/testify/require"
 )
@@ -111,3 +116,26 @@ func TestGeoOrdering(t *testing.T) {
 		})
 	}
 }
+
+func TestRelayClientOutboundProxy(t *testing.T) {
+	defer func(outboundProxy string) { common.OutboundProxy = outboundProxy }(common.OutboundProxy)
+	// a proxy refusing every connection, a relay client going through it can't connect
+	proxied := make(chan string, 10)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.Method + " " + r.Host
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+
+	common.OutboundProxy = proxy.URL
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err := ConnectgRPCClient(ctx, grpcListener, true)
+	require.Error(t, err)
+	require.Equal(t, http.MethodConnect+" "+grpcListener, <-proxied)
+
+	common.OutboundProxy = ""
+	conn, err := ConnectgRPCClient(context.Background(), grpcListener, true)
+	require.NoError(t, err)
+	conn.Close()
+}
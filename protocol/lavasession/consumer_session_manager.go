@@ -23,7 +23,22 @@ const (
 	debug = false
 )
 
-var DebugProbes = false
+const (
+	PrewarmProvidersFlag       = "prewarm-providers"
+	PrewarmConcurrencyFlag     = "prewarm-concurrency"
+	PrewarmHealthRelayFlag     = "prewarm-health-relay"
+	DefaultPrewarmConcurrency  = 4
+	prewarmProvidersSelectCu   = 10
+	prewarmProvidersMaxRetries = 3
+)
+
+var (
+	DebugProbes = false
+	// number of best providers to open sessions with right after a pairing update, 0 disables prewarming
+	PrewarmProviders   uint64 = 0
+	PrewarmConcurrency uint64 = DefaultPrewarmConcurrency
+	PrewarmHealthRelay        = false
+)
 
 // created with NewConsumerSessionManager
 type ConsumerSessionManager struct {
@@ -44,6 +59,14 @@ type ConsumerSessionManager struct {
 	pairingPurge           map[string]*ConsumerSessionsWithProvider
 	providerOptimizer      ProviderOptimizer
 	consumerMetricsManager *metrics.ConsumerMetricsManager
+	// purged pairings that still had relays in flight when their connections were due to close
+	purgedInFlightPairings []*ConsumerSessionsWithProvider
+	latencySLO             *latencySLOTracker
+	latencyAnomaly         *latencyAnomalyDetector
+	staleProviders         *staleProviderTracker
+	relayScheduler         *relayScheduler
+	sessionEventSink       atomic.Pointer[sessionEventSinkHolder]
+	standby                *standbyPairing // the next epoch's pairing prepared by PrepareNextEpoch
 }
 
 // this is being read in multiple locations and but never changes so no need to lock.
@@ -55,11 +78,15 @@ func (csm *ConsumerSessionManager) UpdateAllProviders(epoch uint64, pairingList
 	pairingListLength := len(pairingList)
 	// TODO: we can block updating until some of the probing is done, this can prevent failed attempts on epoch change when we have no information on the providers,
 	// and all of them are new (less effective on big pairing lists or a process that runs for a few epochs)
+	swappedStandby := false
 	defer func() {
 		// run this after done updating pairing
 		time.Sleep(time.Duration(rand.Intn(500)) * time.Millisecond) // sleep up to 500ms in order to scatter different chains probe triggers
 		ctx := context.Background()
 		go csm.probeProviders(ctx, pairingList, epoch) // probe providers to eliminate offline ones from affecting relays, pairingList is thread safe it's members are not (accessed through csm.pairing)
+		if PrewarmProviders > 0 && !swappedStandby {
+			go csm.prewarmSessions(ctx, pairingList, epoch)
+		}
 	}()
 	csm.lock.Lock()         // start by locking the class lock.
 	defer csm.lock.Unlock() // we defer here so in case we return an error it will unlock automatically.
@@ -67,6 +94,8 @@ func (csm *ConsumerSessionManager) UpdateAllProviders(epoch uint64, pairingList
 	if epoch <= csm.atomicReadCurrentEpoch() { // sentry shouldn't update an old epoch or current epoch
 		return utils.LavaFormatError("trying to update provider list for older epoch", nil, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "currentEpoch", Value: csm.atomicReadCurrentEpoch()})
 	}
+	// providers warmed by PrepareNextEpoch replace the fresh entries, relays in flight keep their sessions in the purged pairing
+	swappedStandby = csm.takeStandby(epoch, pairingList)
 	// Update Epoch.
 	csm.atomicWriteCurrentEpoch(epoch)
 
@@ -87,12 +116,128 @@ func (csm *ConsumerSessionManager) UpdateAllProviders(epoch uint64, pairingList
 		csm.pairingAddresses[idx] = provider.PublicLavaAddress
 		csm.pairing[provider.PublicLavaAddress] = provider
 	}
+	csm.latencySLO.prune(csm.pairing)
 	csm.setValidAddressesToDefaultValue("", nil) // the starting point is that valid addresses are equal to pairing addresses.
 	csm.resetMetricsManager()
+	csm.reportLatencyAnomalies()
 	utils.LavaFormatDebug("updated providers", utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "spec", Value: csm.rpcEndpoint.Key()})
 	return nil
 }
 
+// RefreshProviders replaces the pairing of the current epoch without waiting for the next one.
+// providers that remain keep their sessions and used compute units so the epoch accounting stays intact,
+// a newer epoch is handled as a regular update
+func (csm *ConsumerSessionManager) RefreshProviders(epoch uint64, pairingList map[uint64]*ConsumerSessionsWithProvider) error {
+	csm.lock.Lock()
+	currentEpoch := csm.atomicReadCurrentEpoch()
+	if epoch > currentEpoch {
+		csm.lock.Unlock()
+		return csm.UpdateAllProviders(epoch, pairingList)
+	}
+	defer csm.lock.Unlock()
+	if epoch < currentEpoch {
+		return utils.LavaFormatError("trying to refresh provider list with an older epoch", nil, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "currentEpoch", Value: currentEpoch})
+	}
+	validAddresses := make(map[string]struct{}, len(csm.validAddresses))
+	for _, address := range csm.validAddresses {
+		validAddresses[address] = struct{}{}
+	}
+	blockedProviders := map[string]struct{}{}
+	addedProviders := map[uint64]*ConsumerSessionsWithProvider{}
+	pairing := make(map[string]*ConsumerSessionsWithProvider, len(pairingList))
+	csm.pairingAddresses = make(map[uint64]string, len(pairingList))
+	for idx, provider := range pairingList {
+		if existingProvider, ok := csm.pairing[provider.PublicLavaAddress]; ok {
+			provider = existingProvider
+			if _, valid := validAddresses[provider.PublicLavaAddress]; !valid {
+				// providers blocked this epoch stay blocked
+				blockedProviders[provider.PublicLavaAddress] = struct{}{}
+			}
+		} else {
+			addedProviders[idx] = provider
+		}
+		csm.pairingAddresses[idx] = provider.PublicLavaAddress
+		pairing[provider.PublicLavaAddress] = provider
+	}
+	for address, provider := range csm.pairing {
+		if _, ok := pairing[address]; !ok {
+			// in flight relays keep their session, the connections are closed on the next epoch update once idle
+			csm.purgedInFlightPairings = append(csm.purgedInFlightPairings, provider)
+		}
+	}
+	csm.pairing = pairing
+	csm.latencySLO.prune(csm.pairing)
+	csm.pairingAddressesLength = uint64(len(pairingList))
+	csm.RemoveAddonAddresses("", nil)
+	csm.setValidAddressesToDefaultValue("", nil)
+	if len(blockedProviders) > 0 {
+		validAddressesList := make([]string, 0, len(csm.validAddresses))
+		for _, address := range csm.validAddresses {
+			if _, blocked := blockedProviders[address]; !blocked {
+				validAddressesList = append(validAddressesList, address)
+			}
+		}
+		csm.validAddresses = validAddressesList
+	}
+	if len(addedProviders) > 0 {
+		go csm.probeProviders(context.Background(), addedProviders, epoch)
+	}
+	utils.LavaFormatInfo("refreshed providers", utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "spec", Value: csm.rpcEndpoint.Key()}, utils.Attribute{Key: "added", Value: len(addedProviders)}, utils.Attribute{Key: "providers", Value: len(pairing)})
+	return nil
+}
+
+// GetProviderTier returns the latency slo tier of a provider
+func (csm *ConsumerSessionManager) GetProviderTier(providerAddress string) ProviderTier {
+	return csm.latencySLO.GetTier(providerAddress)
+}
+
+// GetProviderTiers returns the latency slo tier of every provider in the current pairing
+func (csm *ConsumerSessionManager) GetProviderTiers() map[string]ProviderTier {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	tiers := make(map[string]ProviderTier, len(csm.pairing))
+	for providerAddress := range csm.pairing {
+		tiers[providerAddress] = csm.latencySLO.GetTier(providerAddress)
+	}
+	return tiers
+}
+
+// AdmitRelay waits until the relay can be sent under the concurrent relays limit, higher priority relays from the context
+// are admitted first. release must be called once the relay and its retries are done
+func (csm *ConsumerSessionManager) AdmitRelay(ctx context.Context) (release func(), err error) {
+	return csm.relayScheduler.Acquire(ctx, RelayPriorityFromContext(ctx))
+}
+
+// UsableProvidersCount returns the number of providers in the current pairing that weren't blocked this epoch
+func (csm *ConsumerSessionManager) UsableProvidersCount() int {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	return len(csm.validAddresses)
+}
+
+// GetInFlightRelays returns the number of relays in flight to every provider in the current pairing
+func (csm *ConsumerSessionManager) GetInFlightRelays() map[string]int64 {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	inFlight := make(map[string]int64, len(csm.pairing))
+	for providerAddress, consumerSessionsWithProvider := range csm.pairing {
+		inFlight[providerAddress] = consumerSessionsWithProvider.atomicReadInFlightRelays()
+	}
+	return inFlight
+}
+
+// OnReplyValidationFailure penalizes the provider's qos for a reply that was delivered but didn't pass validation
+func (csm *ConsumerSessionManager) OnReplyValidationFailure(providerAddress string) {
+	go csm.providerOptimizer.AppendRelayFailure(providerAddress)
+}
+
+// OnMinorityFork demotes a provider found serving finalized blocks from a minority fork, it is blocked for the rest of
+// the epoch and its qos is penalized. it isn't reported as unresponsive, the fork is a local majority view
+func (csm *ConsumerSessionManager) OnMinorityFork(providerAddress string) error {
+	go csm.providerOptimizer.AppendRelayFailure(providerAddress)
+	return csm.blockProvider(providerAddress, false, csm.atomicReadCurrentEpoch(), 0, 1, nil)
+}
+
 func (csm *ConsumerSessionManager) Initialized() bool {
 	csm.lock.RLock()         // start by locking the class lock.
 	defer csm.lock.RUnlock() // we defer here so in case we return an error it will unlock automatically.
@@ -123,6 +268,20 @@ func (csm *ConsumerSessionManager) CalculateAddonValidAddresses(addon string, ex
 	return supportingProviderAddresses
 }
 
+// HasCapableProvider reports whether any provider in the pairing advertises support for all the apis with the addon
+// and extensions, so a relay no provider can serve fails before consuming a session. the elements of a batch are sent
+// to the same provider, so it has to support every one of them
+func (csm *ConsumerSessionManager) HasCapableProvider(apis []string, addon string, extensions []string) bool {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	for _, providerEntry := range csm.pairing {
+		if providerEntry.IsSupportingApis(apis) && providerEntry.IsSupportingAddon(addon) && providerEntry.IsSupportingExtensions(extensions) {
+			return true
+		}
+	}
+	return false
+}
+
 // assuming csm is Rlocked
 func (csm *ConsumerSessionManager) getValidAddresses(addon string, extensions []string) (addresses []string) {
 	routerKey := NewRouterKey(append(extensions, addon))
@@ -135,14 +294,27 @@ func (csm *ConsumerSessionManager) getValidAddresses(addon string, extensions []
 // After 2 epochs we need to close all open connections.
 // otherwise golang garbage collector is not closing network connections and they
 // will remain open forever.
+// closes the connections of purged pairings, relays that started in an older epoch keep their connection until they are done
 func (csm *ConsumerSessionManager) closePurgedUnusedPairingsConnections() {
-	for _, purgedPairing := range csm.pairingPurge {
+	stillInFlight := []*ConsumerSessionsWithProvider{}
+	closeIfUnused := func(purgedPairing *ConsumerSessionsWithProvider) {
+		if purgedPairing.hasInFlightSessions() {
+			stillInFlight = append(stillInFlight, purgedPairing)
+			return
+		}
 		for _, endpoint := range purgedPairing.Endpoints {
 			if endpoint.connection != nil {
 				endpoint.connection.Close()
 			}
 		}
 	}
+	for _, purgedPairing := range csm.purgedInFlightPairings {
+		closeIfUnused(purgedPairing)
+	}
+	for _, purgedPairing := range csm.pairingPurge {
+		closeIfUnused(purgedPairing)
+	}
+	csm.purgedInFlightPairings = stillInFlight
 }
 
 func (csm *ConsumerSessionManager) probeProviders(ctx context.Context, pairingList map[uint64]*ConsumerSessionsWithProvider, epoch uint64) error {
@@ -184,6 +356,108 @@ func (csm *ConsumerSessionManager) probeProviders(ctx context.Context, pairingLi
 	}
 }
 
+// opens sessions with the best providers of a new pairing in the background so the first relays of the epoch don't pay for it
+func (csm *ConsumerSessionManager) prewarmSessions(ctx context.Context, pairingList map[uint64]*ConsumerSessionsWithProvider, epoch uint64) {
+	csm.prewarmProviders(ctx, csm.providersToPrewarm(pairingList, PrewarmProviders), epoch, nil)
+}
+
+// carriedQoS optionally returns the qos a provider's prewarmed session starts with
+func (csm *ConsumerSessionManager) prewarmProviders(ctx context.Context, providers []*ConsumerSessionsWithProvider, epoch uint64, carriedQoS func(providerAddress string) *QoSReport) {
+	concurrency := PrewarmConcurrency
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for _, consumerSessionsWithProvider := range providers {
+		if csm.atomicReadCurrentEpoch() > epoch {
+			// a newer pairing arrived, it will be prewarmed on its own
+			break
+		}
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(consumerSessionsWithProvider *ConsumerSessionsWithProvider) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			var qos *QoSReport
+			if carriedQoS != nil {
+				qos = carriedQoS(consumerSessionsWithProvider.PublicLavaAddress)
+			}
+			err := csm.prewarmProvider(ctx, consumerSessionsWithProvider, epoch, qos)
+			if err != nil {
+				utils.LavaFormatDebug("failed prewarming provider", utils.LogAttr("provider", consumerSessionsWithProvider.PublicLavaAddress), utils.LogAttr("epoch", epoch), utils.LogAttr("error", err))
+			}
+		}(consumerSessionsWithProvider)
+	}
+	wg.Wait()
+	if DebugProbes {
+		utils.LavaFormatDebug("providers prewarm done", utils.LogAttr("endpoint", csm.rpcEndpoint), utils.LogAttr("epoch", epoch), utils.LogAttr("providers", len(providers)))
+	}
+}
+
+// picks up to count providers from the pairing list, in the order the optimizer would choose them
+func (csm *ConsumerSessionManager) providersToPrewarm(pairingList map[uint64]*ConsumerSessionsWithProvider, count uint64) []*ConsumerSessionsWithProvider {
+	byAddress := make(map[string]*ConsumerSessionsWithProvider, len(pairingList))
+	allAddresses := make([]string, 0, len(pairingList))
+	for _, consumerSessionsWithProvider := range pairingList {
+		byAddress[consumerSessionsWithProvider.PublicLavaAddress] = consumerSessionsWithProvider
+		allAddresses = append(allAddresses, consumerSessionsWithProvider.PublicLavaAddress)
+	}
+	chosen := []*ConsumerSessionsWithProvider{}
+	ignored := map[string]struct{}{}
+	emptyRounds := 0
+	for uint64(len(chosen)) < count && len(ignored) < len(allAddresses) && emptyRounds < prewarmProvidersMaxRetries {
+		addresses := csm.providerOptimizer.ChooseProvider(allAddresses, ignored, prewarmProvidersSelectCu, spectypes.LATEST_BLOCK, 0)
+		added := false
+		for _, address := range addresses {
+			if _, ok := ignored[address]; ok || uint64(len(chosen)) >= count {
+				continue
+			}
+			if consumerSessionsWithProvider, ok := byAddress[address]; ok {
+				ignored[address] = struct{}{}
+				chosen = append(chosen, consumerSessionsWithProvider)
+				added = true
+			}
+		}
+		if !added {
+			emptyRounds++
+		}
+	}
+	return chosen
+}
+
+// connects to the provider and leaves an idle session ready for the first relay, optionally sending a probe to warm its qos.
+// a new session starts with carriedQoS when it's set
+func (csm *ConsumerSessionManager) prewarmProvider(ctx context.Context, consumerSessionsWithProvider *ConsumerSessionsWithProvider, epoch uint64, carriedQoS *QoSReport) error {
+	connected, endpoint, providerAddress, err := consumerSessionsWithProvider.fetchEndpointConnectionFromConsumerSessionWithProvider(ctx)
+	if err != nil {
+		return err
+	}
+	if !connected {
+		return utils.LavaFormatDebug("no active endpoint to prewarm", utils.LogAttr("provider", providerAddress))
+	}
+	singleConsumerSession, pairingEpoch, created, rotatedSessions, err := consumerSessionsWithProvider.getConsumerSessionInstanceFromEndpoint(endpoint, 0, 0, 0)
+	csm.appendSessionRotatedEvents(consumerSessionsWithProvider, rotatedSessions)
+	if err != nil {
+		return err
+	}
+	csm.appendSessionAcquiredEvent(singleConsumerSession, created, pairingEpoch)
+	if created && carriedQoS != nil {
+		singleConsumerSession.carryOverQoS(*carriedQoS)
+	}
+	// release it so relays can pick it up
+	singleConsumerSession.lock.Unlock()
+	if PrewarmHealthRelay {
+		probeCtx := utils.AppendUniqueIdentifier(ctx, utils.GenerateUniqueIdentifier())
+		latency, _, err := csm.probeProvider(probeCtx, consumerSessionsWithProvider, epoch)
+		csm.providerOptimizer.AppendProbeRelayData(providerAddress, latency, err == nil)
+		return err
+	}
+	return nil
+}
+
 // this code needs to be thread safe
 func (csm *ConsumerSessionManager) probeProvider(ctx context.Context, consumerSessionsWithProvider *ConsumerSessionsWithProvider, epoch uint64) (latency time.Duration, providerAddress string, err error) {
 	// TODO: fetch all endpoints not just one
@@ -227,6 +501,9 @@ func (csm *ConsumerSessionManager) probeProvider(ctx context.Context, consumerSe
 	if probeResp.LatestBlock == 0 {
 		return 0, providerAddress, utils.LavaFormatWarning("provider returned 0 latest block", nil, utils.Attribute{Key: "provider", Value: providerAddress}, utils.Attribute{Key: "sent guid", Value: guid})
 	}
+	// providers not advertising a subset serve the whole spec
+	consumerSessionsWithProvider.SetSupportedApis(trailer.Get(common.SupportedApisMetadataKey))
+	consumerSessionsWithProvider.SetMaxSizes(maxSizeFromTrailer(trailer.Get(common.MaxRequestSizeMetadataKey)), maxSizeFromTrailer(trailer.Get(common.MaxResponseSizeMetadataKey)))
 	// public lava address is a value that is not changing, so it's thread safe
 	if DebugProbes {
 		utils.LavaFormatDebug("Probed provider successfully", utils.Attribute{Key: "latency", Value: relayLatency}, utils.Attribute{Key: "provider", Value: consumerSessionsWithProvider.PublicLavaAddress}, utils.LogAttr("version", strings.Join(versions, ",")))
@@ -325,6 +602,23 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 	if initUnwantedProviders == nil {
 		initUnwantedProviders = make(map[string]struct{})
 	}
+	if excludedProviders := ExcludedProvidersFromContext(ctx); len(excludedProviders) > 0 {
+		if err := csm.validateExcludedProviders(excludedProviders, addon, extensionNames); err != nil {
+			return nil, err
+		}
+		for provider := range excludedProviders {
+			initUnwantedProviders[provider] = struct{}{}
+		}
+	}
+	if requestSize, responseSize := RelaySizesFromContext(ctx); requestSize > 0 || responseSize > 0 {
+		rejectingProviders, err := csm.providersRejectingSizes(requestSize, responseSize, addon, extensionNames)
+		if err != nil {
+			return nil, err
+		}
+		for provider := range rejectingProviders {
+			initUnwantedProviders[provider] = struct{}{}
+		}
+	}
 
 	// providers that we don't try to connect this iteration.
 	tempIgnoredProviders := &ignoredProviders{
@@ -333,13 +627,18 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 	}
 
 	// Get a valid consumerSessionsWithProvider
-	sessionWithProviderMap, err := csm.getValidConsumerSessionsWithProvider(tempIgnoredProviders, cuNeededForSession, requestedBlock, addon, extensionNames, stateful, virtualEpoch)
+	affinityKey := AffinityKeyFromContext(ctx)
+	relayMethod := RelayMethodFromContext(ctx)
+	simulated := IsSimulatedRelay(ctx)
+	sessionWithProviderMap, err := csm.getValidConsumerSessionsWithProvider(tempIgnoredProviders, cuNeededForSession, requestedBlock, addon, extensionNames, stateful, virtualEpoch, affinityKey, relayMethod)
 	if err != nil {
 		return nil, err
 	}
 
 	// Save how many sessions we are aiming to have
 	wantedSession := len(sessionWithProviderMap)
+	// set when a provider was skipped because no session could fit the relay, so we can surface it
+	budgetExhausted := false
 	// Save sessions to return
 	sessions := make(ConsumerSessionsMap, wantedSession)
 	for {
@@ -375,12 +674,17 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 			reportedProviders := csm.GetReportedProviders(sessionEpoch)
 
 			// Get session from endpoint or create new or continue. if more than 10 connections are open.
-			consumerSession, pairingEpoch, err := consumerSessionsWithProvider.GetConsumerSessionInstanceFromEndpoint(endpoint, numberOfResets)
+			consumerSession, pairingEpoch, created, rotatedSessions, err := consumerSessionsWithProvider.getConsumerSessionInstanceFromEndpoint(endpoint, numberOfResets, cuNeededForSession, virtualEpoch)
+			// sessions can rotate out even when none is returned
+			csm.appendSessionRotatedEvents(consumerSessionsWithProvider, rotatedSessions)
 			if err != nil {
 				utils.LavaFormatDebug("Error on consumerSessionWithProvider.getConsumerSessionInstanceFromEndpoint", utils.Attribute{Key: "Error", Value: err.Error()})
 				if MaximumNumberOfSessionsExceededError.Is(err) {
 					// we can get a different provider, adding this provider to the list of providers to skip on.
 					tempIgnoredProviders.providers[providerAddress] = struct{}{}
+				} else if SessionBudgetExhaustedError.Is(err) {
+					tempIgnoredProviders.providers[providerAddress] = struct{}{}
+					budgetExhausted = true
 				} else if MaximumNumberOfBlockListedSessionsError.Is(err) {
 					// provider has too many block listed sessions. we block it until the next epoch.
 					err = csm.blockProvider(providerAddress, false, sessionEpoch, 0, 0, nil)
@@ -414,8 +718,9 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 				}
 			} else {
 				// consumer session is locked and valid, we need to set the relayNumber and the relay cu. before returning.
-				consumerSession.LatestRelayCu = cuNeededForSession // set latestRelayCu
-				consumerSession.RelayNum += RelayNumberIncrement   // increase relayNum
+				consumerSession.startRelay(cuNeededForSession, relayMethod, simulated)
+				csm.updateInFlightRelays(consumerSessionsWithProvider, 1)
+				csm.appendSessionAcquiredEvent(consumerSession, created, sessionEpoch)
 				// Successfully created/got a consumerSession.
 				if debug {
 					utils.LavaFormatDebug("Consumer get session",
@@ -431,6 +736,7 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 				sessionInfo := &SessionInfo{
 					StakeSize:         consumerSessionsWithProvider.getProviderStakeSize(),
 					Session:           consumerSession,
+					Generation:        consumerSession.RelayGeneration(),
 					Epoch:             sessionEpoch,
 					ReportedProviders: reportedProviders,
 				}
@@ -442,7 +748,7 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 
 				if consumerSession.RelayNum > 1 {
 					// we only set excellence for sessions with more than one successful relays, this guarantees data within the epoch exists
-					consumerSession.QoSInfo.LastExcellenceQoSReport = csm.providerOptimizer.GetExcellenceQoSReportForProvider(providerAddress)
+					consumerSession.setExcellenceQoSReport(csm.providerOptimizer.GetExcellenceQoSReportForProvider(providerAddress))
 				}
 				// We successfully added provider, we should ignore it if we need to fetch new
 				tempIgnoredProviders.providers[providerAddress] = struct{}{}
@@ -455,7 +761,7 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 		}
 
 		// If we do not have enough fetch more
-		sessionWithProviderMap, err = csm.getValidConsumerSessionsWithProvider(tempIgnoredProviders, cuNeededForSession, requestedBlock, addon, extensionNames, stateful, virtualEpoch)
+		sessionWithProviderMap, err = csm.getValidConsumerSessionsWithProvider(tempIgnoredProviders, cuNeededForSession, requestedBlock, addon, extensionNames, stateful, virtualEpoch, affinityKey, relayMethod)
 
 		// If error exists but we have sessions, return them
 		if err != nil && len(sessions) != 0 {
@@ -464,13 +770,16 @@ func (csm *ConsumerSessionManager) GetSessions(ctx context.Context, cuNeededForS
 
 		// If error happens, and we do not have any sessions return error
 		if err != nil {
+			if budgetExhausted {
+				return nil, utils.LavaFormatWarning("failed getting sessions, session budget exhausted", SessionBudgetExhaustedError, utils.LogAttr("cu", cuNeededForSession), utils.LogAttr("maxCuSumPerSession", MaxCuSumPerSession), utils.LogAttr("maxRelayNumPerSession", MaxRelayNumPerSession), utils.LogAttr("error", err))
+			}
 			return nil, err
 		}
 	}
 }
 
 // Get a valid provider address.
-func (csm *ConsumerSessionManager) getValidProviderAddresses(ignoredProvidersList map[string]struct{}, cu uint64, requestedBlock int64, addon string, extensions []string, stateful uint32) (addresses []string, err error) {
+func (csm *ConsumerSessionManager) getValidProviderAddresses(ignoredProvidersList map[string]struct{}, cu uint64, requestedBlock int64, addon string, extensions []string, stateful uint32, affinityKey string, relayMethod string) (addresses []string, err error) {
 	// cs.Lock must be Rlocked here.
 	ignoredProvidersListLength := len(ignoredProvidersList)
 	validAddresses := csm.getValidAddresses(addon, extensions)
@@ -494,7 +803,16 @@ func (csm *ConsumerSessionManager) getValidProviderAddresses(ignoredProvidersLis
 	if stateful == common.CONSISTENCY_SELECT_ALLPROVIDERS && csm.providerOptimizer.Strategy() != provideroptimizer.STRATEGY_COST {
 		providers = GetAllProviders(validAddresses, ignoredProvidersList)
 	} else {
-		providers = csm.providerOptimizer.ChooseProvider(validAddresses, ignoredProvidersList, cu, requestedBlock, OptimizerPerturbation)
+		// providers demoted for breaching the latency slo are only chosen when the primary tier is exhausted
+		candidates := csm.latencySLO.filterPrimaryTier(validAddresses, ignoredProvidersList)
+		// providers stuck behind the chain are demoted the same way
+		candidates = csm.staleProviders.filterFresh(candidates, ignoredProvidersList)
+		candidates = csm.filterRegionalProviders(candidates, ignoredProvidersList)
+		if affinityProvider := csm.chooseAffinityProvider(affinityKey, candidates, ignoredProvidersList, requestedBlock, relayMethod); affinityProvider != "" {
+			providers = []string{affinityProvider}
+		} else {
+			providers = csm.providerOptimizer.ChooseProviderForMethod(candidates, ignoredProvidersList, cu, requestedBlock, OptimizerPerturbation, relayMethod)
+		}
 	}
 	if debug {
 		utils.LavaFormatDebug("choosing providers",
@@ -517,7 +835,7 @@ func (csm *ConsumerSessionManager) getValidProviderAddresses(ignoredProvidersLis
 	return providers, nil
 }
 
-func (csm *ConsumerSessionManager) getValidConsumerSessionsWithProvider(ignoredProviders *ignoredProviders, cuNeededForSession uint64, requestedBlock int64, addon string, extensions []string, stateful uint32, virtualEpoch uint64) (sessionWithProviderMap SessionWithProviderMap, err error) {
+func (csm *ConsumerSessionManager) getValidConsumerSessionsWithProvider(ignoredProviders *ignoredProviders, cuNeededForSession uint64, requestedBlock int64, addon string, extensions []string, stateful uint32, virtualEpoch uint64, affinityKey string, relayMethod string) (sessionWithProviderMap SessionWithProviderMap, err error) {
 	csm.lock.RLock()
 	defer csm.lock.RUnlock()
 	if debug {
@@ -531,7 +849,7 @@ func (csm *ConsumerSessionManager) getValidConsumerSessionsWithProvider(ignoredP
 	}
 
 	// Fetch provider addresses
-	providerAddresses, err := csm.getValidProviderAddresses(ignoredProviders.providers, cuNeededForSession, requestedBlock, addon, extensions, stateful)
+	providerAddresses, err := csm.getValidProviderAddresses(ignoredProviders.providers, cuNeededForSession, requestedBlock, addon, extensions, stateful, affinityKey, relayMethod)
 	if err != nil {
 		utils.LavaFormatError(csm.rpcEndpoint.ChainID+" could not get a provider addresses", err)
 		return nil, err
@@ -564,6 +882,11 @@ func (csm *ConsumerSessionManager) getValidConsumerSessionsWithProvider(ignoredP
 				ignoredProviders.providers[providerAddress] = struct{}{}
 				continue
 			}
+			if consumerSessionsWithProvider.inFlightRelaysCapReached() {
+				// the provider is busy, let the next best provider take this relay
+				ignoredProviders.providers[providerAddress] = struct{}{}
+				continue
+			}
 
 			// If no error, add provider session map
 			sessionWithProviderMap[providerAddress] = &SessionWithProvider{
@@ -580,7 +903,7 @@ func (csm *ConsumerSessionManager) getValidConsumerSessionsWithProvider(ignoredP
 		}
 
 		// If we do not have enough fetch more
-		providerAddresses, err = csm.getValidProviderAddresses(ignoredProviders.providers, cuNeededForSession, requestedBlock, addon, extensions, stateful)
+		providerAddresses, err = csm.getValidProviderAddresses(ignoredProviders.providers, cuNeededForSession, requestedBlock, addon, extensions, stateful, affinityKey, relayMethod)
 
 		// If error exists but we have providers, return them
 		if err != nil && len(sessionWithProviderMap) != 0 {
@@ -640,6 +963,40 @@ func (csm *ConsumerSessionManager) blockProvider(address string, reportProvider
 	return nil
 }
 
+// a relay's completion must be applied once, completing it again would double count the cu and corrupt the session state.
+// completions carry the generation the session was handed out with, so a late completion of a previous relay can't
+// complete the relay the session was handed out for since. a completed session was already unlocked, so this is
+// checked before verifyLock to not flag it as a lock misuse
+func (csm *ConsumerSessionManager) isRepeatedCompletion(consumerSession *SingleConsumerSession, generation uint64, completion string) bool {
+	if !consumerSession.isCompleted(generation) {
+		return false
+	}
+	csm.warnRepeatedCompletion(consumerSession, generation, completion)
+	return true
+}
+
+func (csm *ConsumerSessionManager) warnRepeatedCompletion(consumerSession *SingleConsumerSession, generation uint64, completion string) {
+	utils.LavaFormatWarning("session was already completed, ignoring repeated completion", nil,
+		utils.LogAttr("completion", completion),
+		utils.LogAttr("provider", consumerSession.Parent.PublicLavaAddress),
+		utils.LogAttr("sessionId", consumerSession.SessionId),
+		utils.LogAttr("generation", generation),
+		utils.LogAttr("sessionGeneration", consumerSession.RelayGeneration()),
+	)
+}
+
+// relayCompleted marks the session's relay completed and releases the provider's in-flight slot, the session's lock must
+// be verified first so a misused session is neither completed nor frees a slot it never held. returns false if a
+// concurrent completion got there first
+func (csm *ConsumerSessionManager) relayCompleted(consumerSession *SingleConsumerSession, generation uint64, completion string) bool {
+	if !consumerSession.markCompleted(generation) {
+		csm.warnRepeatedCompletion(consumerSession, generation, completion)
+		return false
+	}
+	csm.updateInFlightRelays(consumerSession.Parent, -1)
+	return true
+}
+
 // Verify the consumerSession is locked when getting to this function, if its not locked throw an error
 func (csm *ConsumerSessionManager) verifyLock(consumerSession *SingleConsumerSession) error {
 	if consumerSession.lock.TryLock() { // verify.
@@ -655,12 +1012,17 @@ func (csm *ConsumerSessionManager) verifyLock(consumerSession *SingleConsumerSes
 
 // A Session can be created but unused if consumer found the response in the cache.
 // So we need to unlock the session and decrease the cu that were applied
-func (csm *ConsumerSessionManager) OnSessionUnUsed(consumerSession *SingleConsumerSession) error {
+func (csm *ConsumerSessionManager) OnSessionUnUsed(consumerSession *SingleConsumerSession, generation uint64) error {
+	if csm.isRepeatedCompletion(consumerSession, generation, "OnSessionUnUsed") {
+		return nil
+	}
 	if err := csm.verifyLock(consumerSession); err != nil {
 		return sdkerrors.Wrapf(err, "OnSessionUnUsed, consumerSession.lock must be locked before accessing this method, additional info:")
 	}
-	cuToDecrease := consumerSession.LatestRelayCu
-	consumerSession.LatestRelayCu = 0                            // making sure no one uses it in a wrong way
+	if !csm.relayCompleted(consumerSession, generation, "OnSessionUnUsed") {
+		return nil
+	}
+	cuToDecrease := consumerSession.releaseRelayCu()
 	parentConsumerSessionsWithProvider := consumerSession.Parent // must read this pointer before unlocking
 	// finished with consumerSession here can unlock.
 	consumerSession.lock.Unlock()                                                    // we unlock before we change anything in the parent ConsumerSessionsWithProvider
@@ -672,11 +1034,17 @@ func (csm *ConsumerSessionManager) OnSessionUnUsed(consumerSession *SingleConsum
 }
 
 // Report session failure, mark it as blocked from future usages, report if timeout happened.
-func (csm *ConsumerSessionManager) OnSessionFailure(consumerSession *SingleConsumerSession, errorReceived error) error {
+func (csm *ConsumerSessionManager) OnSessionFailure(consumerSession *SingleConsumerSession, generation uint64, errorReceived error) error {
 	// consumerSession must be locked when getting here.
+	if csm.isRepeatedCompletion(consumerSession, generation, "OnSessionFailure") {
+		return nil
+	}
 	if err := csm.verifyLock(consumerSession); err != nil {
 		return sdkerrors.Wrapf(err, "OnSessionFailure, consumerSession.lock must be locked before accessing this method, additional info:")
 	}
+	if !csm.relayCompleted(consumerSession, generation, "OnSessionFailure") {
+		return nil
+	}
 
 	// consumer Session should be locked here. so we can just apply the session failure here.
 	if consumerSession.BlockListed {
@@ -689,19 +1057,19 @@ func (csm *ConsumerSessionManager) OnSessionFailure(consumerSession *SingleConsu
 	if ReportAndBlockProviderError.Is(errorReceived) {
 		blockProvider = true
 		reportProvider = true
-	} else if BlockProviderError.Is(errorReceived) {
+	} else if BlockProviderError.Is(errorReceived) || ErrProviderCuExhausted.Is(errorReceived) {
+		// a provider that ran out of our compute units will keep rejecting us until the next epoch
 		blockProvider = true
 	}
 
-	consumerSession.QoSInfo.TotalRelays++
-	consumerSession.ConsecutiveErrors = append(consumerSession.ConsecutiveErrors, errorReceived)
-	consumerSession.errorsCount += 1
+	consecutiveErrors := consumerSession.recordFailure(errorReceived)
+	csm.appendSessionEvent(metrics.SessionFailed, consumerSession.Parent.PublicLavaAddress, consumerSession.PairingEpoch(), consumerSession.SessionId)
 	// if this session failed more than MaximumNumberOfFailuresAllowedPerConsumerSession times or session went out of sync we block it.
-	if len(consumerSession.ConsecutiveErrors) > MaximumNumberOfFailuresAllowedPerConsumerSession || IsSessionSyncLoss(errorReceived) {
+	if consecutiveErrors > MaximumNumberOfFailuresAllowedPerConsumerSession || IsSessionSyncLoss(errorReceived) {
 		utils.LavaFormatDebug("Blocking consumer session", utils.LogAttr("ConsecutiveErrors", consumerSession.ConsecutiveErrors), utils.LogAttr("errorsCount", consumerSession.errorsCount), utils.Attribute{Key: "id", Value: consumerSession.SessionId})
 		consumerSession.BlockListed = true // block this session from future usages
 		// we will check the total number of cu for this provider and decide if we need to report it.
-		if consumerSession.Parent.atomicReadUsedComputeUnits() <= consumerSession.LatestRelayCu { // if we had 0 successful relays and we reached block session we need to report this provider
+		if consumerSession.Parent.atomicReadUsedComputeUnits() <= consumerSession.Parent.budgetCu(consumerSession.LatestRelayCu) { // if we had 0 successful relays and we reached block session we need to report this provider
 			blockProvider = true
 			reportProvider = true
 		}
@@ -710,11 +1078,9 @@ func (csm *ConsumerSessionManager) OnSessionFailure(consumerSession *SingleConsu
 			go csm.reportedProviders.AppendReport(metrics.NewReportsRequest(providerAddr, consumerSession.ConsecutiveErrors, csm.rpcEndpoint.ChainID))
 		}
 	}
-	cuToDecrease := consumerSession.LatestRelayCu
 	// latency, isHangingApi, syncScore arent updated when there is a failure
-	go csm.providerOptimizer.AppendRelayFailure(consumerSession.Parent.PublicLavaAddress)
-	consumerSession.LatestRelayCu = 0 // making sure no one uses it in a wrong way
-	consecutiveErrors := uint64(len(consumerSession.ConsecutiveErrors))
+	go csm.providerOptimizer.AppendMethodRelayFailure(consumerSession.Parent.PublicLavaAddress, consumerSession.relayMethod)
+	cuToDecrease := consumerSession.releaseRelayCu()
 	parentConsumerSessionsWithProvider := consumerSession.Parent // must read this pointer before unlocking
 	csm.updateMetricsManager(consumerSession)
 	// finished with consumerSession here can unlock.
@@ -730,7 +1096,9 @@ func (csm *ConsumerSessionManager) OnSessionFailure(consumerSession *SingleConsu
 		err = csm.blockProvider(publicProviderAddress, reportProvider, pairingEpoch, 0, consecutiveErrors, nil)
 		if err != nil {
 			if EpochMismatchError.Is(err) {
-				return nil // no effects this epoch has been changed
+				// the relay started before the pairing rotated, blocking applies to the originating epoch only
+				utils.LavaFormatDebug("skipping provider block for a session from a previous epoch", utils.LogAttr("provider", publicProviderAddress), utils.LogAttr("sessionEpoch", pairingEpoch), utils.LogAttr("currentEpoch", csm.atomicReadCurrentEpoch()))
+				return nil
 			}
 			return err
 		}
@@ -740,6 +1108,7 @@ func (csm *ConsumerSessionManager) OnSessionFailure(consumerSession *SingleConsu
 
 // On a successful DataReliability session we don't need to increase and update any field, we just need to unlock the session.
 func (csm *ConsumerSessionManager) OnDataReliabilitySessionDone(consumerSession *SingleConsumerSession,
+	generation uint64,
 	latestServicedBlock int64,
 	specComputeUnits uint64,
 	currentLatency time.Duration,
@@ -748,14 +1117,22 @@ func (csm *ConsumerSessionManager) OnDataReliabilitySessionDone(consumerSession
 	numOfProviders int,
 	providersCount uint64,
 ) error {
+	if csm.isRepeatedCompletion(consumerSession, generation, "OnDataReliabilitySessionDone") {
+		return nil
+	}
 	if err := csm.verifyLock(consumerSession); err != nil {
 		return sdkerrors.Wrapf(err, "OnDataReliabilitySessionDone, consumerSession.lock must be locked before accessing this method")
 	}
+	if !csm.relayCompleted(consumerSession, generation, "OnDataReliabilitySessionDone") {
+		return nil
+	}
 
 	defer consumerSession.lock.Unlock() // we need to be locked here, if we didn't get it locked we try lock anyway
-	consumerSession.ConsecutiveErrors = []error{}
-	consumerSession.LatestBlock = latestServicedBlock // update latest serviced block
-	if expectedBH-latestServicedBlock > 1000 {
+	latestBlock := latestServicedBlock
+	syncKnown := latestServicedBlock != 0 // a reply without the provider's height isn't scored for sync
+	if !syncKnown {
+		latestBlock = consumerSession.LatestBlock
+	} else if expectedBH-latestServicedBlock > 1000 {
 		utils.LavaFormatWarning("identified block gap", nil,
 			utils.Attribute{Key: "expectedBH", Value: expectedBH},
 			utils.Attribute{Key: "latestServicedBlock", Value: latestServicedBlock},
@@ -763,13 +1140,14 @@ func (csm *ConsumerSessionManager) OnDataReliabilitySessionDone(consumerSession
 			utils.Attribute{Key: "provider_address", Value: consumerSession.Parent.PublicLavaAddress},
 		)
 	}
-	consumerSession.CalculateQoS(currentLatency, expectedLatency, expectedBH-latestServicedBlock, numOfProviders, int64(providersCount))
+	consumerSession.recordReply(latestBlock, currentLatency, expectedLatency, expectedBH-latestServicedBlock, syncKnown, numOfProviders, int64(providersCount))
 	return nil
 }
 
 // On a successful session this function will update all necessary fields in the consumerSession. and unlock it when it finishes
 func (csm *ConsumerSessionManager) OnSessionDone(
 	consumerSession *SingleConsumerSession,
+	generation uint64,
 	latestServicedBlock int64,
 	specComputeUnits uint64,
 	currentLatency time.Duration,
@@ -780,19 +1158,59 @@ func (csm *ConsumerSessionManager) OnSessionDone(
 	isHangingApi bool,
 ) error {
 	// release locks, update CU, relaynum etc..
+	if csm.isRepeatedCompletion(consumerSession, generation, "OnSessionDone") {
+		return nil
+	}
 	if err := csm.verifyLock(consumerSession); err != nil {
 		return sdkerrors.Wrapf(err, "OnSessionDone, consumerSession.lock must be locked before accessing this method")
 	}
+	if !csm.relayCompleted(consumerSession, generation, "OnSessionDone") {
+		return nil
+	}
 
-	defer consumerSession.lock.Unlock()                    // we need to be locked here, if we didn't get it locked we try lock anyway
-	consumerSession.CuSum += consumerSession.LatestRelayCu // add CuSum to current cu usage.
-	consumerSession.LatestRelayCu = 0                      // reset cu just in case
-	consumerSession.ConsecutiveErrors = []error{}
-	consumerSession.LatestBlock = latestServicedBlock // update latest serviced block
-	// calculate QoS
-	consumerSession.CalculateQoS(currentLatency, expectedLatency, expectedBH-latestServicedBlock, numOfProviders, int64(providersCount))
-	go csm.providerOptimizer.AppendRelayData(consumerSession.Parent.PublicLavaAddress, currentLatency, isHangingApi, specComputeUnits, uint64(latestServicedBlock))
+	defer consumerSession.lock.Unlock() // we need to be locked here, if we didn't get it locked we try lock anyway
+	var cuToDecrease uint64
+	if consumerSession.simulated {
+		// simulated relays aren't settled, the session stays where it was before the relay
+		cuToDecrease = consumerSession.revertRelay()
+	} else {
+		consumerSession.settleRelay()
+	}
+	csm.appendSessionEvent(metrics.SessionDone, consumerSession.Parent.PublicLavaAddress, consumerSession.PairingEpoch(), consumerSession.SessionId)
+	blockHeightDiff := expectedBH - latestServicedBlock
+	latestBlock := latestServicedBlock
+	syncKnown := true
+	if latestServicedBlock == 0 {
+		// the reply didn't carry the provider's height, that's not being behind so it's left out of the sync score
+		syncKnown = false
+		latestBlock = consumerSession.LatestBlock
+		if fallbackLatestBlock := consumerSession.fallbackLatestBlock; IsValidLatestBlock(fallbackLatestBlock, consumerSession.LatestBlock) {
+			latestBlock = fallbackLatestBlock
+		}
+	} else if !IsValidLatestBlock(latestServicedBlock, consumerSession.LatestBlock) {
+		// don't let a bogus value corrupt the height tracking, keep the previous one and fail the sync score for this relay
+		utils.LavaFormatWarning("provider returned an invalid latest block, ignoring it for height tracking", nil,
+			utils.LogAttr("provider", consumerSession.Parent.PublicLavaAddress),
+			utils.LogAttr("latestBlock", latestServicedBlock),
+			utils.LogAttr("previousLatestBlock", consumerSession.LatestBlock),
+		)
+		blockHeightDiff = SyncScoreMaxBlockLag // scored as out of sync
+		latestBlock = consumerSession.LatestBlock
+	}
+	consumerSession.recordReply(latestBlock, currentLatency, expectedLatency, blockHeightDiff, syncKnown, numOfProviders, int64(providersCount))
+	if syncKnown {
+		csm.staleProviders.AppendBlockLag(consumerSession.Parent.PublicLavaAddress, blockHeightDiff)
+	}
+	if !isHangingApi {
+		csm.latencySLO.AppendLatency(consumerSession.Parent.PublicLavaAddress, currentLatency)
+		csm.appendLatencyAnomalySample(consumerSession.Parent.PublicLavaAddress, currentLatency)
+	}
+	go csm.providerOptimizer.AppendMethodRelayData(consumerSession.Parent.PublicLavaAddress, consumerSession.relayMethod, currentLatency, isHangingApi, specComputeUnits, uint64(consumerSession.LatestBlock))
+	go csm.appendTimeToFirstByte(consumerSession.Parent.PublicLavaAddress, consumerSession.timeToFirstByte)
 	csm.updateMetricsManager(consumerSession)
+	if cuToDecrease > 0 {
+		return consumerSession.Parent.decreaseUsedComputeUnits(cuToDecrease)
+	}
 	return nil
 }
 
@@ -819,6 +1237,15 @@ func (csm *ConsumerSessionManager) updateMetricsManager(consumerSession *SingleC
 	go csm.consumerMetricsManager.SetQOSMetrics(chainId, apiInterface, consumerSession.Parent.PublicLavaAddress, lastQos, lastQosExcellence, consumerSession.LatestBlock, consumerSession.RelayNum)
 }
 
+func (csm *ConsumerSessionManager) updateInFlightRelays(consumerSessionsWithProvider *ConsumerSessionsWithProvider, delta int64) {
+	inFlight := consumerSessionsWithProvider.atomicAddInFlightRelays(delta)
+	if csm.consumerMetricsManager == nil {
+		return
+	}
+	info := csm.RPCEndpoint()
+	go csm.consumerMetricsManager.SetInFlightRelays(info.ChainID, info.ApiInterface, consumerSessionsWithProvider.PublicLavaAddress, inFlight)
+}
+
 // consumerSession should still be locked when accessing this method as it fetches information from the session it self
 func (csm *ConsumerSessionManager) resetMetricsManager() {
 	if csm.consumerMetricsManager == nil {
@@ -827,6 +1254,39 @@ func (csm *ConsumerSessionManager) resetMetricsManager() {
 	csm.consumerMetricsManager.ResetQOSMetrics()
 }
 
+// reports providers whose latency regressed sharply from their baseline, and removes the report once they recover
+func (csm *ConsumerSessionManager) appendLatencyAnomalySample(providerAddress string, latency time.Duration) {
+	changed, anomalous := csm.latencyAnomaly.AppendLatency(providerAddress, latency)
+	if !changed {
+		return
+	}
+	attributes := csm.latencyAnomaly.describe(providerAddress)
+	if anomalous {
+		utils.LavaFormatWarning("provider latency regressed from its baseline, reporting it", nil, attributes...)
+		if !csm.reportedProviders.IsReported(providerAddress) {
+			csm.reportedProviders.ReportProvider(providerAddress, 0, 0, nil)
+			csm.latencyAnomaly.markReported(providerAddress, true)
+		}
+		return
+	}
+	utils.LavaFormatInfo("provider latency recovered to its baseline", attributes...)
+	if csm.latencyAnomaly.markReported(providerAddress, false) {
+		csm.reportedProviders.RemoveReport(providerAddress)
+	}
+}
+
+// reports are reset on a new epoch, providers that are still anomalous are reported again
+// csm.lock must be held
+func (csm *ConsumerSessionManager) reportLatencyAnomalies() {
+	for _, providerAddress := range csm.latencyAnomaly.anomalousProviders() {
+		_, paired := csm.pairing[providerAddress]
+		if paired {
+			csm.reportedProviders.ReportProvider(providerAddress, 0, 0, nil)
+		}
+		csm.latencyAnomaly.markReported(providerAddress, paired)
+	}
+}
+
 // Get the reported providers currently stored in the session manager.
 func (csm *ConsumerSessionManager) GetReportedProviders(epoch uint64) []*pairingtypes.ReportedProvider {
 	if epoch != csm.atomicReadCurrentEpoch() {
@@ -835,6 +1295,11 @@ func (csm *ConsumerSessionManager) GetReportedProviders(epoch uint64) []*pairing
 	return csm.reportedProviders.GetReportedProviders()
 }
 
+// sets a custom aggregation for the reported providers, passing nil restores the default behavior
+func (csm *ConsumerSessionManager) SetReportedProvidersAggregator(aggregator ReportedProvidersAggregator) {
+	csm.reportedProviders.SetAggregator(aggregator)
+}
+
 // Data Reliability Section:
 
 // Atomically read csm.pairingAddressesLength for data reliability.
@@ -927,21 +1392,26 @@ func (csm *ConsumerSessionManager) GetDataReliabilitySession(ctx context.Context
 	}
 
 	// DR consumer session is locked, we can increment data reliability relay number.
-	consumerSession.RelayNum += 1
+	consumerSession.startDataReliabilityRelay()
+	csm.updateInFlightRelays(consumerSession.Parent, 1)
 
 	return consumerSession, providerAddress, currentEpoch, nil
 }
 
 // On a successful Subscribe relay
-func (csm *ConsumerSessionManager) OnSessionDoneIncreaseCUOnly(consumerSession *SingleConsumerSession) error {
+func (csm *ConsumerSessionManager) OnSessionDoneIncreaseCUOnly(consumerSession *SingleConsumerSession, generation uint64) error {
+	if csm.isRepeatedCompletion(consumerSession, generation, "OnSessionDoneIncreaseCUOnly") {
+		return nil
+	}
 	if err := csm.verifyLock(consumerSession); err != nil {
 		return sdkerrors.Wrapf(err, "OnSessionDoneIncreaseRelayAndCu consumerSession.lock must be locked before accessing this method")
 	}
+	if !csm.relayCompleted(consumerSession, generation, "OnSessionDoneIncreaseCUOnly") {
+		return nil
+	}
 
-	defer consumerSession.lock.Unlock()                    // we need to be locked here, if we didn't get it locked we try lock anyway
-	consumerSession.CuSum += consumerSession.LatestRelayCu // add CuSum to current cu usage.
-	consumerSession.LatestRelayCu = 0                      // reset cu just in case
-	consumerSession.ConsecutiveErrors = []error{}
+	defer consumerSession.lock.Unlock() // we need to be locked here, if we didn't get it locked we try lock anyway
+	consumerSession.settleRelay()
 	return nil
 }
 
@@ -956,6 +1426,10 @@ func NewConsumerSessionManager(rpcEndpoint *RPCEndpoint, providerOptimizer Provi
 	csm := &ConsumerSessionManager{
 		reportedProviders:      *NewReportedProviders(reporter),
 		consumerMetricsManager: consumerMetricsManager,
+		latencySLO:             newLatencySLOTracker(LatencySLO, LatencySLOWindow),
+		latencyAnomaly:         newLatencyAnomalyDetector(LatencyAnomalyMultiplier, LatencyAnomalyRecoveryMultiplier, LatencyAnomalyWindow),
+		staleProviders:         newStaleProviderTracker(StaleProviderLag, StaleProviderWindow),
+		relayScheduler:         newRelayScheduler(MaxConcurrentRelays),
 	}
 	csm.rpcEndpoint = rpcEndpoint
 	csm.providerOptimizer = providerOptimizer
@@ -10,6 +10,8 @@ import (
 	"testing"
 	"time"
 
+	sdkerrors "cosmossdk.io/errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/provideroptimizer"
 	"github.com/lavanet/lava/utils"
@@ -18,7 +20,9 @@ import (
 	spectypes "github.com/lavanet/lava/x/spec/types"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -45,7 +49,7 @@ func CreateConsumerSessionManager() *ConsumerSessionManager {
 	AllowInsecureConnectionToProviders = true // set to allow insecure for tests purposes
 	rand.InitRandomSeed()
 	baseLatency := common.AverageWorldLatency / 2 // we want performance to be half our timeout or better
-	return NewConsumerSessionManager(&RPCEndpoint{"stub", "stub", "stub", false, "/", 0}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, baseLatency, 1), nil, nil)
+	return NewConsumerSessionManager(&RPCEndpoint{NetworkAddress: "stub", ChainID: "stub", ApiInterface: "stub", HealthCheckPath: "/"}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, baseLatency, 1), nil, nil)
 }
 
 var grpcServer *grpc.Server
@@ -146,7 +150,7 @@ func TestHappyFlow(t *testing.T) {
 		require.NotNil(t, cs)
 		require.Equal(t, cs.Epoch, csm.currentEpoch)
 		require.Equal(t, cs.Session.LatestRelayCu, cuForFirstRequest)
-		err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 		require.NoError(t, err)
 		require.Equal(t, cs.Session.CuSum, cuForFirstRequest)
 		require.Equal(t, cs.Session.LatestRelayCu, latestRelayCuAfterDone)
@@ -155,6 +159,59 @@ func TestHappyFlow(t *testing.T) {
 	}
 }
 
+func TestSimulatedRelayNotSettled(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	css, err := csm.GetSessions(ContextWithSimulatedRelay(ctx), cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+	require.NoError(t, err)
+
+	for _, cs := range css {
+		require.True(t, cs.Session.IsSimulated())
+		require.Equal(t, cuForFirstRequest, cs.Session.Parent.atomicReadUsedComputeUnits())
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+		require.Zero(t, cs.Session.CuSum)
+		require.Zero(t, cs.Session.RelayNum)
+		require.Zero(t, cs.Session.Parent.atomicReadUsedComputeUnits())
+		require.Equal(t, servicedBlockNumber, cs.Session.LatestBlock)
+	}
+
+	// a regular relay on the same session is settled
+	css, err = csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	for _, cs := range css {
+		require.False(t, cs.Session.IsSimulated())
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+		require.Equal(t, cuForFirstRequest, cs.Session.CuSum)
+		require.Equal(t, relayNumberAfterFirstCall, cs.Session.RelayNum)
+	}
+}
+
+func TestSimulatedRelaySettledWithoutAcknowledgement(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	css, err := csm.GetSessions(ContextWithSimulatedRelay(ctx), cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+	require.NoError(t, err)
+
+	for _, cs := range css {
+		// the provider settled it, so does the session
+		cs.Session.SettleSimulatedRelay()
+		require.False(t, cs.Session.IsSimulated())
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+		require.Equal(t, cuForFirstRequest, cs.Session.CuSum)
+		require.Equal(t, relayNumberAfterFirstCall, cs.Session.RelayNum)
+		require.Equal(t, cuForFirstRequest, cs.Session.Parent.atomicReadUsedComputeUnits())
+	}
+}
+
 func TestHappyFlowVirtualEpoch(t *testing.T) {
 	ctx := context.Background()
 	csm := CreateConsumerSessionManager()
@@ -168,7 +225,7 @@ func TestHappyFlowVirtualEpoch(t *testing.T) {
 		require.NotNil(t, cs)
 		require.Equal(t, cs.Epoch, csm.currentEpoch)
 		require.Equal(t, cs.Session.LatestRelayCu, maxCuForVirtualEpoch*(virtualEpoch+1))
-		err = csm.OnSessionDone(cs.Session, servicedBlockNumber, maxCuForVirtualEpoch*(virtualEpoch+1), time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, maxCuForVirtualEpoch*(virtualEpoch+1), time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 		require.NoError(t, err)
 		require.Equal(t, cs.Session.CuSum, maxCuForVirtualEpoch*(virtualEpoch+1))
 		require.Equal(t, cs.Session.LatestRelayCu, latestRelayCuAfterDone)
@@ -204,7 +261,7 @@ func TestPairingReset(t *testing.T) {
 		require.NotNil(t, cs)
 		require.Equal(t, cs.Epoch, csm.currentEpoch)
 		require.Equal(t, cs.Session.LatestRelayCu, cuForFirstRequest)
-		err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 		require.NoError(t, err)
 		require.Equal(t, cs.Session.CuSum, cuForFirstRequest)
 		require.Equal(t, cs.Session.LatestRelayCu, latestRelayCuAfterDone)
@@ -229,7 +286,7 @@ func TestPairingResetWithFailures(t *testing.T) {
 		require.NoError(t, err)
 
 		for _, cs := range css {
-			err = csm.OnSessionFailure(cs.Session, nil)
+			err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), nil)
 			require.NoError(t, err) // fail test.
 		}
 	}
@@ -262,7 +319,7 @@ func TestPairingResetWithMultipleFailures(t *testing.T) {
 			css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
 
 			for _, cs := range css {
-				err = csm.OnSessionFailure(cs.Session, nil)
+				err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), nil)
 				require.NoError(t, err)
 			}
 
@@ -290,7 +347,7 @@ func TestPairingResetWithMultipleFailures(t *testing.T) {
 		require.NotNil(t, cs)
 		require.Equal(t, cs.Epoch, csm.currentEpoch)
 		require.Equal(t, cs.Session.LatestRelayCu, cuForFirstRequest)
-		err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 		require.NoError(t, err)
 		require.Equal(t, cs.Session.CuSum, cuForFirstRequest)
 		require.Equal(t, cs.Session.LatestRelayCu, latestRelayCuAfterDone)
@@ -336,7 +393,7 @@ func TestSuccessAndFailureOfSessionWithUpdatePairingsInTheMiddle(t *testing.T) {
 		require.Equal(t, epoch, csm.currentEpoch)
 
 		if rand.Intn(2) > 0 {
-			err = csm.OnSessionDone(cs, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+			err = csm.OnSessionDone(cs, cs.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 			require.NoError(t, err)
 			require.Equal(t, cs.CuSum, cuForFirstRequest)
 			require.Equal(t, cs.LatestRelayCu, latestRelayCuAfterDone)
@@ -344,7 +401,7 @@ func TestSuccessAndFailureOfSessionWithUpdatePairingsInTheMiddle(t *testing.T) {
 			require.Equal(t, cs.LatestBlock, servicedBlockNumber)
 			sessionListData[j] = SessTestData{cuSum: cuForFirstRequest, relayNum: 1}
 		} else {
-			err = csm.OnSessionFailure(cs, nil)
+			err = csm.OnSessionFailure(cs, cs.RelayGeneration(), nil)
 			require.NoError(t, err)
 			require.Equal(t, cs.CuSum, uint64(0))
 			require.Equal(t, cs.RelayNum, relayNumberAfterFirstFail)
@@ -370,14 +427,14 @@ func TestSuccessAndFailureOfSessionWithUpdatePairingsInTheMiddle(t *testing.T) {
 	for j := numberOfAllowedSessionsPerConsumer / 2; j < numberOfAllowedSessionsPerConsumer; j++ {
 		cs := sessionList[j].cs
 		if rand.Intn(2) > 0 {
-			err = csm.OnSessionDone(cs, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+			err = csm.OnSessionDone(cs, cs.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 			require.NoError(t, err)
 			require.Equal(t, sessionListData[j].cuSum+cuForFirstRequest, cs.CuSum)
 			require.Equal(t, cs.LatestRelayCu, latestRelayCuAfterDone)
 			require.Equal(t, cs.RelayNum, sessionListData[j].relayNum+1)
 			require.Equal(t, cs.LatestBlock, servicedBlockNumber)
 		} else {
-			err = csm.OnSessionFailure(cs, nil)
+			err = csm.OnSessionFailure(cs, cs.RelayGeneration(), nil)
 			require.NoError(t, err)
 			require.Equal(t, sessionListData[j].cuSum, cs.CuSum)
 			require.Equal(t, cs.RelayNum, sessionListData[j].relayNum+1)
@@ -393,7 +450,7 @@ func successfulSession(ctx context.Context, csm *ConsumerSessionManager, t *test
 	for _, cs := range css {
 		require.NotNil(t, cs)
 		time.Sleep(time.Duration((rand.Intn(500) + 1)) * time.Millisecond)
-		err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 		require.NoError(t, err)
 		ch <- p
 	}
@@ -406,7 +463,7 @@ func failedSession(ctx context.Context, csm *ConsumerSessionManager, t *testing.
 	for _, cs := range css {
 		require.NotNil(t, cs)
 		time.Sleep(time.Duration((rand.Intn(500) + 1)) * time.Millisecond)
-		err = csm.OnSessionFailure(cs.Session, fmt.Errorf("nothing special"))
+		err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), fmt.Errorf("nothing special"))
 		require.NoError(t, err)
 		ch <- p
 	}
@@ -525,7 +582,7 @@ func TestSessionFailureAndGetReportedProviders(t *testing.T) {
 		require.NotNil(t, cs)
 		require.Equal(t, cs.Epoch, csm.currentEpoch)
 		require.Equal(t, cs.Session.LatestRelayCu, cuForFirstRequest)
-		err = csm.OnSessionFailure(cs.Session, ReportAndBlockProviderError)
+		err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), ReportAndBlockProviderError)
 		require.NoError(t, err)
 		require.Equal(t, cs.Session.Parent.UsedComputeUnits, cuSumOnFailure)
 		require.Equal(t, cs.Session.CuSum, cuSumOnFailure)
@@ -562,7 +619,7 @@ func TestSessionFailureEpochMisMatch(t *testing.T) {
 
 		err = csm.UpdateAllProviders(secondEpochHeight, pairingList) // update the providers again.
 		require.NoError(t, err)
-		err = csm.OnSessionFailure(cs.Session, ReportAndBlockProviderError)
+		err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), ReportAndBlockProviderError)
 		require.NoError(t, err)
 	}
 }
@@ -662,7 +719,7 @@ func TestPairingWithAddons(t *testing.T) {
 				css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, addon, nil, common.NOSTATE, 0) // get a session
 				require.NoError(t, err, i)
 				for _, cs := range css {
-					err = csm.OnSessionFailure(cs.Session, ReportAndBlockProviderError)
+					err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), ReportAndBlockProviderError)
 					require.NoError(t, err)
 				}
 				utils.LavaFormatDebug("length!", utils.Attribute{Key: "length", Value: len(csm.getValidAddresses(addon, nil))}, utils.Attribute{Key: "valid addresses", Value: csm.getValidAddresses(addon, nil)})
@@ -674,7 +731,7 @@ func TestPairingWithAddons(t *testing.T) {
 			css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, addon, nil, common.NOSTATE, 0) // get a session
 			require.NoError(t, err)
 			for _, cs := range css {
-				err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+				err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 				require.NoError(t, err)
 			}
 		})
@@ -737,7 +794,7 @@ func TestPairingWithExtensions(t *testing.T) {
 				css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, extensionOpt.addon, extensionsList, common.NOSTATE, 0) // get a session
 				require.NoError(t, err, i)
 				for _, cs := range css {
-					err = csm.OnSessionFailure(cs.Session, ReportAndBlockProviderError)
+					err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), ReportAndBlockProviderError)
 					require.NoError(t, err)
 				}
 				utils.LavaFormatDebug("length!", utils.Attribute{Key: "length", Value: len(csm.getValidAddresses(extensionOpt.addon, extensionOpt.extensions))}, utils.Attribute{Key: "valid addresses", Value: csm.getValidAddresses(extensionOpt.addon, extensionOpt.extensions)})
@@ -749,7 +806,7 @@ func TestPairingWithExtensions(t *testing.T) {
 			css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, extensionOpt.addon, extensionsList, common.NOSTATE, 0) // get a session
 			require.NoError(t, err)
 			for _, cs := range css {
-				err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+				err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 				require.NoError(t, err)
 			}
 		})
@@ -762,7 +819,7 @@ func TestNoPairingsError(t *testing.T) {
 	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
 	require.NoError(t, err)
 	time.Sleep(5 * time.Millisecond) // let probes finish
-	_, err = csm.getValidProviderAddresses(map[string]struct{}{}, 10, 100, "invalid", nil, common.NOSTATE)
+	_, err = csm.getValidProviderAddresses(map[string]struct{}{}, 10, 100, "invalid", nil, common.NOSTATE, "", "")
 	require.Error(t, err)
 	require.True(t, PairingListEmptyError.Is(err))
 }
@@ -785,7 +842,7 @@ func TestPairingWithStateful(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, allProviders, len(css))
 		for _, cs := range css {
-			err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+			err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
 			require.NoError(t, err)
 		}
 		unwantedProvider := map[string]struct{}{providerAddresses[0]: {}}
@@ -794,3 +851,436 @@ func TestPairingWithStateful(t *testing.T) {
 		require.Equal(t, allProviders-1, len(css))
 	})
 }
+
+func TestOnSessionDoneInvalidLatestBlock(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+
+	getSession := func() *SingleConsumerSession {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+		require.NoError(t, err)
+		for _, cs := range css {
+			return cs.Session
+		}
+		return nil
+	}
+	session := getSession()
+	err = csm.OnSessionDone(session, session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber, numberOfProviders, numberOfProviders, false)
+	require.NoError(t, err)
+	require.Equal(t, servicedBlockNumber, session.LatestBlock)
+	require.True(t, session.QoSInfo.LastQoSReport.Sync.Equal(sdk.OneDec()))
+
+	invalidBlocks := []int64{-5, servicedBlockNumber - MaxLatestBlockRegression - 1}
+	for _, invalidBlock := range invalidBlocks {
+		session.lock.Lock()
+		session.markInUse() // simulate handing the session out again
+		session.LatestRelayCu = cuForFirstRequest
+		err = csm.OnSessionDone(session, session.RelayGeneration(), invalidBlock, cuForFirstRequest, time.Millisecond, session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber+1, numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+		// height tracking keeps the previously known value
+		require.Equal(t, servicedBlockNumber, session.LatestBlock)
+	}
+	// all invalid blocks fail the sync score
+	require.True(t, session.QoSInfo.SyncScoreSum.Equal(sdk.OneDec()))
+	require.Equal(t, int64(len(invalidBlocks)+1), session.QoSInfo.TotalSyncScore)
+
+	// a small regression is allowed
+	session.lock.Lock()
+	session.markInUse()
+	err = csm.OnSessionDone(session, session.RelayGeneration(), servicedBlockNumber-1, cuForFirstRequest, time.Millisecond, session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber-1, numberOfProviders, numberOfProviders, false)
+	require.NoError(t, err)
+	require.Equal(t, servicedBlockNumber-1, session.LatestBlock)
+}
+
+func TestOnSessionDoneAbsentLatestBlock(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	err := csm.UpdateAllProviders(firstEpochHeight, createPairingList("", true)) // update the providers.
+	require.NoError(t, err)
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+	require.NoError(t, err)
+	var session *SingleConsumerSession
+	for _, cs := range css {
+		session = cs.Session
+	}
+	relayDone := func(latestBlock int64, expectedBH int64) {
+		err := csm.OnSessionDone(session, session.RelayGeneration(), latestBlock, cuForFirstRequest, time.Millisecond, session.CalculateExpectedLatency(2*time.Millisecond), expectedBH, numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+	}
+	reuseSession := func() {
+		session.lock.Lock()
+		session.startRelay(cuForFirstRequest, "", false) // simulate handing the session out again
+	}
+
+	// the first reply doesn't report a height, it's not scored as out of sync
+	relayDone(0, servicedBlockNumber)
+	require.Equal(t, int64(0), session.LatestBlock)
+	require.Equal(t, int64(0), session.QoSInfo.TotalSyncScore)
+	require.True(t, session.QoSInfo.LastQoSReport.Sync.Equal(sdk.OneDec()))
+
+	// a present height is scored
+	reuseSession()
+	relayDone(servicedBlockNumber, servicedBlockNumber)
+	require.Equal(t, servicedBlockNumber, session.LatestBlock)
+	require.Equal(t, int64(1), session.QoSInfo.TotalSyncScore)
+
+	// absent again, the sync score and the height stay as they were
+	reuseSession()
+	relayDone(0, servicedBlockNumber+100)
+	require.Equal(t, servicedBlockNumber, session.LatestBlock)
+	require.Equal(t, int64(1), session.QoSInfo.TotalSyncScore)
+	require.True(t, session.QoSInfo.LastQoSReport.Sync.Equal(sdk.OneDec()))
+
+	// a fallback height updates the height tracking, still without scoring sync
+	reuseSession()
+	session.SetFallbackLatestBlock(servicedBlockNumber + 10)
+	relayDone(0, servicedBlockNumber+100)
+	require.Equal(t, servicedBlockNumber+10, session.LatestBlock)
+	require.Equal(t, int64(1), session.QoSInfo.TotalSyncScore)
+
+	// the fallback only applies to the relay it was set for
+	reuseSession()
+	relayDone(0, servicedBlockNumber+100)
+	require.Equal(t, servicedBlockNumber+10, session.LatestBlock)
+}
+
+func TestRepeatedSessionCompletion(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	// a single provider so the sessions are reused
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	getSession := func() (*SingleConsumerSession, uint64) {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+		require.NoError(t, err)
+		for _, cs := range css {
+			return cs.Session, cs.Generation
+		}
+		return nil, 0
+	}
+
+	session, generation := getSession()
+	for i := 0; i < 2; i++ {
+		err = csm.OnSessionDone(session, generation, servicedBlockNumber, cuForFirstRequest, time.Millisecond, session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber, numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+	}
+	require.Equal(t, cuForFirstRequest, session.CuSum)
+	require.Equal(t, uint64(1), session.RelayNum)
+	require.Equal(t, uint64(1), session.QoSInfo.TotalRelays)
+	require.False(t, session.BlockListed)
+	require.Equal(t, cuForFirstRequest, session.Parent.atomicReadUsedComputeUnits())
+
+	// a failure after the relay completed doesn't refund the cu or count an error
+	err = csm.OnSessionFailure(session, generation, fmt.Errorf("late failure"))
+	require.NoError(t, err)
+	require.Empty(t, session.ConsecutiveErrors)
+	require.Equal(t, cuForFirstRequest, session.Parent.atomicReadUsedComputeUnits())
+
+	// a late completion of the previous relay doesn't complete the relay the session was handed out for since
+	reused, reusedGeneration := getSession()
+	require.Same(t, session, reused)
+	require.Equal(t, generation+1, reusedGeneration)
+	err = csm.OnSessionFailure(session, generation, fmt.Errorf("late failure"))
+	require.NoError(t, err)
+	require.Empty(t, session.ConsecutiveErrors)
+	require.False(t, session.isCompleted(reusedGeneration))
+	require.False(t, session.lock.TryLock())
+	err = csm.OnSessionDone(session, reusedGeneration, servicedBlockNumber, cuForFirstRequest, time.Millisecond, session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber, numberOfProviders, numberOfProviders, false)
+	require.NoError(t, err)
+	require.Equal(t, 2*cuForFirstRequest, session.CuSum)
+	require.Equal(t, uint64(2), session.QoSInfo.TotalRelays)
+
+	// a failed relay can't be completed twice either
+	session, generation = getSession()
+	usedCu := session.Parent.atomicReadUsedComputeUnits()
+	for i := 0; i < 2; i++ {
+		err = csm.OnSessionFailure(session, generation, fmt.Errorf("relay failed"))
+		require.NoError(t, err)
+	}
+	require.Len(t, session.ConsecutiveErrors, 1)
+	require.Equal(t, usedCu-cuForFirstRequest, session.Parent.atomicReadUsedComputeUnits())
+
+	// a lock misuse isn't a completion
+	session, generation = getSession()
+	session.lock.Unlock()
+	require.Error(t, csm.OnSessionFailure(session, generation, fmt.Errorf("relay failed")))
+	require.False(t, session.isCompleted(generation))
+}
+
+func TestSessionBudgetGuardrails(t *testing.T) {
+	MaxCuSumPerSession = 2 * cuForFirstRequest
+	defer func() { MaxCuSumPerSession = 0 }()
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	// a single provider so all relays go to it
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+
+	sessionIds := []int64{}
+	for i := 0; i < 3; i++ {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for _, cs := range css {
+			sessionIds = append(sessionIds, cs.Session.SessionId)
+			err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber-1, numberOfProviders, numberOfProviders, false)
+			require.NoError(t, err)
+		}
+	}
+	require.Len(t, sessionIds, 3)
+	// the first two relays fit the session, the third one rotates to a fresh session
+	require.Equal(t, sessionIds[0], sessionIds[1])
+	require.NotEqual(t, sessionIds[1], sessionIds[2])
+
+	// a relay that can't fit any session
+	_, err = csm.GetSessions(ctx, MaxCuSumPerSession+1, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.Error(t, err)
+	require.True(t, SessionBudgetExhaustedError.Is(err))
+	// the ceiling is bounded by the provider's allocation, virtual epochs included
+	provider := pairingList[0]
+	MaxCuSumPerSession = 10 * provider.MaxComputeUnits
+	require.Equal(t, provider.MaxComputeUnits, provider.sessionCuLimit(0))
+	require.Equal(t, 2*provider.MaxComputeUnits, provider.sessionCuLimit(1))
+	MaxCuSumPerSession = provider.MaxComputeUnits / 2
+	require.Equal(t, provider.MaxComputeUnits/2, provider.sessionCuLimit(0))
+
+	// without a ceiling a session that reached the allocation rotates
+	MaxCuSumPerSession = 0
+	require.Equal(t, provider.MaxComputeUnits, provider.sessionCuLimit(0))
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	for _, cs := range css {
+		require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+		cs.Session.CuSum = provider.MaxComputeUnits - cuForFirstRequest + 1
+		css, err = csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for _, rotated := range css {
+			require.NotEqual(t, cs.Session.SessionId, rotated.Session.SessionId)
+		}
+	}
+}
+
+func TestPrewarmSessions(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+
+	PrewarmProviders = 3
+	PrewarmConcurrency = 2
+	defer func() {
+		PrewarmProviders = 0
+		PrewarmConcurrency = DefaultPrewarmConcurrency
+	}()
+	csm.prewarmSessions(ctx, pairingList, firstEpochHeight)
+
+	prewarmed := map[string]struct{}{}
+	for _, cswp := range pairingList {
+		cswp.Lock.RLock()
+		sessions := len(cswp.Sessions)
+		cswp.Lock.RUnlock()
+		if sessions > 0 {
+			require.Equal(t, 1, sessions)
+			prewarmed[cswp.PublicLavaAddress] = struct{}{}
+		}
+	}
+	require.Len(t, prewarmed, int(PrewarmProviders))
+
+	// prewarmed sessions are free and picked up by relays
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	for provider, cs := range css {
+		if _, ok := prewarmed[provider]; ok {
+			require.Len(t, cs.Session.Parent.Sessions, 1)
+		}
+		cs.Session.lock.Unlock()
+	}
+}
+
+func TestEpochTransitionWithInFlightRelays(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+
+	// two relays acquire sessions in the first epoch
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	cssFailure, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	oldParent := pairingList[0]
+	require.Equal(t, uint64(cuForFirstRequest*2), oldParent.atomicReadUsedComputeUnits())
+
+	// the pairing rotates while both are in flight, the same provider is paired again
+	newPairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]}
+	newPairingList[0].PairingEpoch = firstEpochHeight + 1
+	err = csm.UpdateAllProviders(firstEpochHeight+1, newPairingList)
+	require.NoError(t, err)
+
+	for _, cs := range css {
+		require.Equal(t, uint64(firstEpochHeight), cs.Epoch)
+		require.Equal(t, uint64(firstEpochHeight), cs.Session.PairingEpoch())
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+		require.Equal(t, uint64(cuForFirstRequest), cs.Session.CuSum)
+	}
+	for _, cs := range cssFailure {
+		require.Equal(t, uint64(firstEpochHeight), cs.Session.PairingEpoch())
+		err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), ReportAndBlockProviderError)
+		require.NoError(t, err)
+	}
+
+	// accounting stays on the originating pairing, the new epoch starts clean and the provider isn't blocked in it
+	require.Equal(t, uint64(cuForFirstRequest), oldParent.atomicReadUsedComputeUnits())
+	require.Equal(t, uint64(0), newPairingList[0].atomicReadUsedComputeUnits())
+	require.Contains(t, csm.validAddresses, newPairingList[0].PublicLavaAddress)
+	require.Empty(t, csm.GetReportedProviders(firstEpochHeight+1))
+
+	// connections of purged pairings with relays in flight are kept until the relays are done
+	css, err = csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	err = csm.UpdateAllProviders(firstEpochHeight+2, map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]})
+	require.NoError(t, err)
+	err = csm.UpdateAllProviders(firstEpochHeight+3, map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]})
+	require.NoError(t, err)
+	require.Len(t, csm.purgedInFlightPairings, 1)
+	require.Same(t, newPairingList[0], csm.purgedInFlightPairings[0])
+	for _, cs := range css {
+		cs.Session.lock.Unlock()
+	}
+	err = csm.UpdateAllProviders(firstEpochHeight+4, map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]})
+	require.NoError(t, err)
+	require.Empty(t, csm.purgedInFlightPairings)
+}
+
+func TestRefreshProviders(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0], 1: createPairingList("", true)[1]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+
+	// a relay is in flight on the provider that gets removed
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, map[string]struct{}{pairingList[1].PublicLavaAddress: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	require.Contains(t, css, pairingList[0].PublicLavaAddress)
+	// the other provider is blocked this epoch
+	csm.validAddresses = []string{pairingList[0].PublicLavaAddress}
+
+	// provider 0 is removed, a new provider joins and provider 1 remains
+	refreshedList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[1], 1: createPairingList("new", true)[2]}
+	err = csm.RefreshProviders(firstEpochHeight, refreshedList)
+	require.NoError(t, err)
+	require.Equal(t, uint64(firstEpochHeight), csm.atomicReadCurrentEpoch())
+	require.Same(t, pairingList[1], csm.pairing[pairingList[1].PublicLavaAddress]) // remaining providers keep their accounting
+	require.NotContains(t, csm.pairing, pairingList[0].PublicLavaAddress)
+	require.ElementsMatch(t, []string{refreshedList[1].PublicLavaAddress}, csm.validAddresses)
+	require.Len(t, csm.purgedInFlightPairings, 1)
+
+	// the in flight relay finishes on its original session
+	for _, cs := range css {
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(cuForFirstRequest), pairingList[0].atomicReadUsedComputeUnits())
+
+	// older epochs are rejected and newer ones are a regular update
+	require.Error(t, csm.RefreshProviders(firstEpochHeight-1, refreshedList))
+	err = csm.RefreshProviders(firstEpochHeight+1, map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]})
+	require.NoError(t, err)
+	require.Equal(t, uint64(firstEpochHeight+1), csm.atomicReadCurrentEpoch())
+}
+
+func TestHasCapableProvider(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	// only the providers serving the extensions advertise a subset of the apis
+	pairingList[2].SetSupportedApis([]string{"eth_call"})
+	pairingList[3].SetSupportedApis([]string{"eth_call", "eth_getLogs"})
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+
+	require.True(t, csm.HasCapableProvider([]string{"eth_blockNumber"}, "", nil))
+	require.True(t, csm.HasCapableProvider([]string{"eth_blockNumber"}, "addon", nil))
+	require.True(t, csm.HasCapableProvider([]string{"eth_call"}, "addon", []string{"ext1"}))
+	require.True(t, csm.HasCapableProvider([]string{"eth_getLogs"}, "addon", []string{"ext1", "ext2"}))
+	// supported apis, but not with the requested extensions
+	require.False(t, csm.HasCapableProvider([]string{"eth_blockNumber"}, "addon", []string{"ext1"}))
+	require.False(t, csm.HasCapableProvider([]string{"eth_call"}, "addon", []string{"ext3"}))
+	require.False(t, csm.HasCapableProvider([]string{"eth_call"}, "other-addon", nil))
+
+	// a batch needs a provider supporting all of its apis
+	require.True(t, csm.HasCapableProvider([]string{"eth_call", "eth_getLogs"}, "addon", []string{"ext1"}))
+	require.False(t, csm.HasCapableProvider([]string{"eth_call", "eth_blockNumber"}, "addon", []string{"ext1"}))
+
+	pairingList[3].SetSupportedApis(nil)
+	require.True(t, csm.HasCapableProvider([]string{"eth_blockNumber"}, "addon", []string{"ext1"}))
+}
+
+func TestProviderCuExhausted(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+
+	// the provider's rejection as it arrives over grpc
+	rejection := status.Error(codes.Code(MaximumCULimitReachedByConsumer.ABCICode()), "consumer reached maximum cu limit")
+	require.True(t, IsProviderCuExhausted(rejection))
+	require.False(t, IsProviderCuExhausted(status.Error(codes.Code(SessionOutOfSyncError.ABCICode()), "out of sync")))
+
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+	require.NoError(t, err)
+	var exhaustedProvider string
+	for providerAddress, cs := range css {
+		exhaustedProvider = providerAddress
+		err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), sdkerrors.Wrapf(ErrProviderCuExhausted, "provider %s", providerAddress))
+		require.NoError(t, err)
+	}
+	// skipped for the rest of the epoch without being reported
+	require.NotContains(t, csm.validAddresses, exhaustedProvider)
+	require.False(t, csm.reportedProviders.IsReported(exhaustedProvider))
+	for i := 0; i < numberOfProviders*3; i++ {
+		css, err = csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for providerAddress, cs := range css {
+			require.NotEqual(t, exhaustedProvider, providerAddress)
+			require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+		}
+	}
+
+	// usable again on the next epoch
+	err = csm.UpdateAllProviders(secondEpochHeight, createPairingList("", true))
+	require.NoError(t, err)
+	require.Contains(t, csm.validAddresses, exhaustedProvider)
+}
+
+func TestUsableProvidersCount(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	require.Equal(t, 0, csm.UsableProvidersCount())
+	err := csm.UpdateAllProviders(firstEpochHeight, createPairingList("", true)) // update the providers.
+	require.NoError(t, err)
+	require.Equal(t, numberOfProviders, csm.UsableProvidersCount())
+
+	err = csm.blockProvider(csm.validAddresses[0], false, firstEpochHeight, 0, 0, nil)
+	require.NoError(t, err)
+	require.Equal(t, numberOfProviders-1, csm.UsableProvidersCount())
+}
+
+func TestOnMinorityFork(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	err := csm.UpdateAllProviders(firstEpochHeight, createPairingList("", true)) // update the providers.
+	require.NoError(t, err)
+	forkedProvider := csm.validAddresses[0]
+
+	require.NoError(t, csm.OnMinorityFork(forkedProvider))
+	require.NotContains(t, csm.validAddresses, forkedProvider)
+	require.False(t, csm.reportedProviders.IsReported(forkedProvider))
+	require.Equal(t, numberOfProviders-1, csm.UsableProvidersCount())
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/provideroptimizer"
 	"github.com/lavanet/lava/utils"
 	"github.com/lavanet/lava/utils/rand"
@@ -23,8 +24,45 @@ const AllowInsecureConnectionToProvidersFlag = "allow-insecure-provider-dialing"
 
 var AllowInsecureConnectionToProviders = false
 
+const (
+	MaxCuSumPerSessionFlag    = "max-cu-per-session"
+	MaxRelayNumPerSessionFlag = "max-relays-per-session"
+)
+
+// per session guardrails, when a session reaches them we rotate to a fresh one. 0 means no limit, the cu sum is bounded
+// by the provider's allocation either way
+var (
+	MaxCuSumPerSession    uint64 = 0
+	MaxRelayNumPerSession uint64 = 0
+)
+
+const MaxInFlightRelaysPerProviderFlag = "max-in-flight-relays-per-provider"
+
+// providers with this many relays in flight are skipped for the next best provider until one completes. 0 means no limit
+var MaxInFlightRelaysPerProvider uint64 = 0
+
+const ValidateProviderCapabilitiesFlag = "validate-provider-capabilities"
+
+// fail relays no paired provider advertises support for before consuming a session
+var ValidateProviderCapabilities = false
+
+const MinUsableProvidersFlag = "min-usable-providers"
+
+// relays are refused while fewer providers than this are usable, protecting the qos and reliability guarantees during
+// ramp up or mass provider outages. 0 disables the check
+var MinUsableProviders uint64 = 0
+
+const (
+	SyncScoreMaxBlockLagFlag    = "sync-score-max-block-lag"
+	DefaultSyncScoreMaxBlockLag = 5
+)
+
+// a relay behind the expected block height loses sync score proportionally to the lag, this many blocks behind scores 0
+var SyncScoreMaxBlockLag int64 = DefaultSyncScoreMaxBlockLag
+
 type SessionInfo struct {
 	Session           *SingleConsumerSession
+	Generation        uint64 // the session's relay generation for this hand-out, its completion must pass it
 	StakeSize         sdk.Coin
 	QoSSummeryResult  sdk.Dec // using ComputeQoS to get the total QOS
 	Epoch             uint64
@@ -38,6 +76,10 @@ type ProviderOptimizer interface {
 	AppendRelayFailure(providerAddress string)
 	AppendRelayData(providerAddress string, latency time.Duration, isHangingApi bool, cu, syncBlock uint64)
 	ChooseProvider(allAddresses []string, ignoredProviders map[string]struct{}, cu uint64, requestedBlock int64, perturbationPercentage float64) (addresses []string)
+	AppendMethodRelayFailure(providerAddress string, method string)
+	AppendMethodRelayData(providerAddress string, method string, latency time.Duration, isHangingApi bool, cu, syncBlock uint64)
+	ChooseProviderForMethod(allAddresses []string, ignoredProviders map[string]struct{}, cu uint64, requestedBlock int64, perturbationPercentage float64, method string) (addresses []string)
+	IsProviderQualified(providerAddress string, requestedBlock int64, method string) bool
 	GetExcellenceQoSReportForProvider(string) *pairingtypes.QualityOfServiceReport
 	Strategy() provideroptimizer.Strategy
 }
@@ -51,7 +93,7 @@ type QoSReport struct {
 	LastQoSReport           *pairingtypes.QualityOfServiceReport
 	LastExcellenceQoSReport *pairingtypes.QualityOfServiceReport
 	LatencyScoreList        []sdk.Dec
-	SyncScoreSum            int64
+	SyncScoreSum            sdk.Dec
 	TotalSyncScore          int64
 	TotalRelays             uint64
 	AnsweredRelays          uint64
@@ -70,6 +112,12 @@ type SingleConsumerSession struct {
 	BlockListed       bool // if session lost sync we blacklist it.
 	ConsecutiveErrors []error
 	errorsCount       uint64
+	completion        uint64        // the relay generation stamped on the last hand-out shifted left, the low bit is set once that relay completed
+	relayMethod       string        // the method of the current relay, for method level qos
+	simulated         bool          // the current relay is simulated and isn't settled
+	timeToFirstByte   time.Duration // of the current relay, 0 when it wasn't measured
+	// height of the provider's node from another source for a current relay whose reply didn't carry it, 0 when unknown
+	fallbackLatestBlock int64
 }
 
 type DataReliabilitySession struct {
@@ -88,6 +136,8 @@ type Endpoint struct {
 	Addons             map[string]struct{}
 	Extensions         map[string]struct{}
 	Geolocation        planstypes.Geolocation
+	// set once the provider rejected a compressed relay
+	compressionUnsupported uint32
 }
 
 type SessionWithProvider struct {
@@ -104,6 +154,50 @@ type RPCEndpoint struct {
 	TLSEnabled      bool   `yaml:"tls-enabled,omitempty" json:"tls-enabled,omitempty" mapstructure:"tls-enabled"`
 	HealthCheckPath string `yaml:"health-check-path,omitempty" json:"health-check-path,omitempty" mapstructure:"health-check-path"` // health check status code 200 path, default is "/"
 	Geolocation     uint64 `yaml:"geolocation,omitempty" json:"geolocation,omitempty" mapstructure:"geolocation"`
+	// client method name -> canonical spec method name, used by jsonrpc parsing
+	MethodAliases        map[string]string `yaml:"method-aliases,omitempty" json:"method-aliases,omitempty" mapstructure:"method-aliases"`
+	RewriteAliasesOnSend bool              `yaml:"rewrite-aliases-on-send,omitempty" json:"rewrite-aliases-on-send,omitempty" mapstructure:"rewrite-aliases-on-send"` // relay the canonical name instead of the alias, for providers without the aliases
+	// jsonrpc method name -> trailing positional params added to requests omitting them, null marks a required param
+	DefaultParams map[string][]interface{} `yaml:"default-params,omitempty" json:"default-params,omitempty" mapstructure:"default-params"`
+	// jsonrpc filter method name (like eth_getLogs) -> the most blocks a numeric fromBlock-toBlock range may span,
+	// larger ranges are rejected before relaying
+	MaxBlockRanges map[string]uint64 `yaml:"max-block-ranges,omitempty" json:"max-block-ranges,omitempty" mapstructure:"max-block-ranges"`
+	// jsonrpc method name -> how its replies are canonicalized before they're returned and cached, so replies of
+	// different providers' nodes are the same bytes
+	ReplyNormalization map[string]ReplyNormalizationConfig `yaml:"reply-normalization,omitempty" json:"reply-normalization,omitempty" mapstructure:"reply-normalization"`
+	// method name -> declared positional param types, jsonrpc requests not matching them are rejected before relaying
+	ParamsSignatures map[string][]string `yaml:"params-signatures,omitempty" json:"params-signatures,omitempty" mapstructure:"params-signatures"`
+	// api name -> json schema the reply must conform to, overrides the spec api's reply schema
+	ReplySchemas       map[string]string `yaml:"reply-schemas,omitempty" json:"reply-schemas,omitempty" mapstructure:"reply-schemas"`
+	StrictReplySchemas bool              `yaml:"strict-reply-schemas,omitempty" json:"strict-reply-schemas,omitempty" mapstructure:"strict-reply-schemas"` // fail the relay on a non conforming reply instead of only penalizing the provider
+	// rest api names whose list replies are checked against their pagination, on top of the spec's paginated apis.
+	// providers returning inconsistent pages are penalized
+	PaginatedApis []string `yaml:"paginated-apis,omitempty" json:"paginated-apis,omitempty" mapstructure:"paginated-apis"`
+	// method patterns (names, prefix* or globs) the portal serves, empty allows every spec method, denied takes precedence
+	AllowedMethods []string `yaml:"allowed-methods,omitempty" json:"allowed-methods,omitempty" mapstructure:"allowed-methods"`
+	DeniedMethods  []string `yaml:"denied-methods,omitempty" json:"denied-methods,omitempty" mapstructure:"denied-methods"`
+	// operator run nodes relays are sent to when every provider failed, their replies bypass the protocol's verification
+	TrustedFallback []common.NodeUrl `yaml:"trusted-fallback,omitempty" json:"trusted-fallback,omitempty" mapstructure:"trusted-fallback"`
+	// tendermint abci_query paths (like the method patterns, /store/bank/*) whose proofs are verified against the app hash
+	// another provider reports, proven replies that don't match fail the relay and penalize the provider. the commit's
+	// validator signatures aren't checked, so the app hash is only as trusted as that provider. it costs an extra relay
+	// per query so it's off unless configured
+	ABCIProofPaths []string `yaml:"abci-proof-paths,omitempty" json:"abci-proof-paths,omitempty" mapstructure:"abci-proof-paths"`
+	// api name -> data reliability level (never, probabilistic or always), overrides the spec api's level. only
+	// deterministic relays of a finalized block can be cross checked, whatever the level
+	ReliabilityMethods map[string]string `yaml:"reliability-methods,omitempty" json:"reliability-methods,omitempty" mapstructure:"reliability-methods"`
+	// bech32 prefix the signers of provider replies are shown with, empty uses the sdk's globally configured prefix.
+	// pairing addresses are lava addresses, they're always verified with the lava prefix
+	AddressPrefix string `yaml:"address-prefix,omitempty" json:"address-prefix,omitempty" mapstructure:"address-prefix"`
+	// dapp id -> priority (low, normal or high) its relays are admitted with under max-concurrent-relays, relays of
+	// other dapps are normal
+	RelayPriorities map[string]string `yaml:"relay-priorities,omitempty" json:"relay-priorities,omitempty" mapstructure:"relay-priorities"`
+}
+
+// ReplyNormalizationConfig is a method's reply normalization rule, see chainlib.ReplyNormalizationRule
+type ReplyNormalizationConfig struct {
+	QuantityFields []string `yaml:"quantity-fields,omitempty" json:"quantity-fields,omitempty" mapstructure:"quantity-fields"` // result paths of hex quantities, "" is the result itself
+	DropNullFields bool     `yaml:"drop-null-fields,omitempty" json:"drop-null-fields,omitempty" mapstructure:"drop-null-fields"`
 }
 
 func (endpoint *RPCEndpoint) String() (retStr string) {
@@ -135,6 +229,15 @@ type ConsumerSessionsWithProvider struct {
 	// whether we already reported this provider this epoch, we can only report one conflict per provider per epoch
 	conflictFoundAndReported uint32   // 0 == not reported, 1 == reported
 	stakeSize                sdk.Coin // the stake size the provider staked
+	// the apis the provider advertised it serves, nil when it serves the whole spec
+	supportedApis map[string]struct{}
+	// number of sessions handed out for a relay and not yet completed
+	inFlightRelays int64
+	// hosts relays to the provider reached, see RecordRemoteHost
+	remoteHosts map[string]struct{}
+	// request and response sizes the provider advertised it accepts, in bytes, 0 is unlimited
+	maxRequestSize  uint64
+	maxResponseSize uint64
 }
 
 func NewConsumerSessionWithProvider(publicLavaAddress string, pairingEndpoints []*Endpoint, maxCu uint64, epoch uint64, stakeSize sdk.Coin) *ConsumerSessionsWithProvider {
@@ -181,6 +284,34 @@ func (cswp *ConsumerSessionsWithProvider) IsSupportingAddon(addon string) bool {
 	return false
 }
 
+// SetSupportedApis records the subset of the spec's apis the provider advertised, nil means all of them
+func (cswp *ConsumerSessionsWithProvider) SetSupportedApis(apis []string) {
+	cswp.Lock.Lock()
+	defer cswp.Lock.Unlock()
+	if apis == nil {
+		cswp.supportedApis = nil
+		return
+	}
+	cswp.supportedApis = make(map[string]struct{}, len(apis))
+	for _, api := range apis {
+		cswp.supportedApis[api] = struct{}{}
+	}
+}
+
+func (cswp *ConsumerSessionsWithProvider) IsSupportingApis(apis []string) bool {
+	cswp.Lock.RLock()
+	defer cswp.Lock.RUnlock()
+	if cswp.supportedApis == nil {
+		return true
+	}
+	for _, api := range apis {
+		if _, ok := cswp.supportedApis[api]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func (cswp *ConsumerSessionsWithProvider) IsSupportingExtensions(extensions []string) bool {
 	cswp.Lock.RLock()
 	defer cswp.Lock.RUnlock()
@@ -207,27 +338,33 @@ func (cswp *ConsumerSessionsWithProvider) verifyDataReliabilitySessionWasNotAlre
 	cswp.Lock.RLock()
 	defer cswp.Lock.RUnlock()
 	if dataReliabilitySession, ok := cswp.Sessions[DataReliabilitySessionId]; ok { // check if we already have a data reliability session.
-		// validate our relay number reached the data reliability relay number limit
-		if dataReliabilitySession.RelayNum >= DataReliabilityRelayNumber {
-			return nil, cswp.PairingEpoch, DataReliabilityAlreadySentThisEpochError
-		}
-		dataReliabilitySession.lock.Lock() // lock before returning.
-		return dataReliabilitySession, cswp.PairingEpoch, nil
+		return lockDataReliabilitySession(dataReliabilitySession, cswp.PairingEpoch)
 	}
 	return nil, cswp.PairingEpoch, NoDataReliabilitySessionWasCreatedError
 }
 
+// locks the data reliability session if it has relays left this epoch, cswp must be locked. a session held by another
+// relay is sending the epoch's data reliability relay already
+func lockDataReliabilitySession(dataReliabilitySession *SingleConsumerSession, pairingEpoch uint64) (*SingleConsumerSession, uint64, error) {
+	if !dataReliabilitySession.lock.TryLock() {
+		return nil, pairingEpoch, DataReliabilityAlreadySentThisEpochError
+	}
+	// validate our relay number reached the data reliability relay number limit
+	if dataReliabilitySession.RelayNum >= DataReliabilityRelayNumber {
+		dataReliabilitySession.lock.Unlock()
+		return nil, pairingEpoch, DataReliabilityAlreadySentThisEpochError
+	}
+	return dataReliabilitySession, pairingEpoch, nil
+}
+
 // get a data reliability session from an endpoint
 func (cswp *ConsumerSessionsWithProvider) getDataReliabilitySingleConsumerSession(endpoint *Endpoint) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, err error) {
 	cswp.Lock.Lock()
 	defer cswp.Lock.Unlock()
 	// we re validate the data reliability session now that we are locked.
 	if dataReliabilitySession, ok := cswp.Sessions[DataReliabilitySessionId]; ok { // check if we already have a data reliability session.
-		if dataReliabilitySession.RelayNum >= DataReliabilityRelayNumber {
-			return nil, cswp.PairingEpoch, DataReliabilityAlreadySentThisEpochError
-		}
-		// we already have the dr session. so return it.
-		return dataReliabilitySession, cswp.PairingEpoch, nil
+		// another relay created it in the meantime
+		return lockDataReliabilitySession(dataReliabilitySession, cswp.PairingEpoch)
 	}
 
 	singleDataReliabilitySession := &SingleConsumerSession{
@@ -252,10 +389,37 @@ func (cswp *ConsumerSessionsWithProvider) getPublicLavaAddressAndPairingEpoch()
 	return cswp.PublicLavaAddress, cswp.PairingEpoch
 }
 
+// returns true if any of the sessions is currently used by a relay
+func (cswp *ConsumerSessionsWithProvider) hasInFlightSessions() bool {
+	cswp.Lock.RLock()
+	defer cswp.Lock.RUnlock()
+	for _, session := range cswp.Sessions {
+		if !session.lock.TryLock() {
+			return true
+		}
+		session.lock.Unlock()
+	}
+	return false
+}
+
+func (cswp *ConsumerSessionsWithProvider) atomicAddInFlightRelays(delta int64) int64 {
+	return atomic.AddInt64(&cswp.inFlightRelays, delta)
+}
+
+func (cswp *ConsumerSessionsWithProvider) atomicReadInFlightRelays() int64 {
+	return atomic.LoadInt64(&cswp.inFlightRelays)
+}
+
+// returns true if the provider reached the in flight relays cap
+func (cswp *ConsumerSessionsWithProvider) inFlightRelaysCapReached() bool {
+	return MaxInFlightRelaysPerProvider > 0 && cswp.atomicReadInFlightRelays() >= int64(MaxInFlightRelaysPerProvider)
+}
+
 // Validate the compute units for this provider
 func (cswp *ConsumerSessionsWithProvider) validateComputeUnits(cu uint64, virtualEpoch uint64) error {
 	cswp.Lock.RLock()
 	defer cswp.Lock.RUnlock()
+	cu = cswp.budgetCu(cu)
 	// add additional CU for virtual epochs
 	if (cswp.UsedComputeUnits + cu) > cswp.MaxComputeUnits*(virtualEpoch+1) {
 		return utils.LavaFormatWarning("validateComputeUnits", MaxComputeUnitsExceededError,
@@ -271,6 +435,7 @@ func (cswp *ConsumerSessionsWithProvider) validateComputeUnits(cu uint64, virtua
 func (cswp *ConsumerSessionsWithProvider) addUsedComputeUnits(cu, virtualEpoch uint64) error {
 	cswp.Lock.Lock()
 	defer cswp.Lock.Unlock()
+	cu = cswp.budgetCu(cu)
 	// add additional CU for virtual epochs
 	if (cswp.UsedComputeUnits + cu) > cswp.MaxComputeUnits*(virtualEpoch+1) {
 		return MaxComputeUnitsExceededError
@@ -279,6 +444,17 @@ func (cswp *ConsumerSessionsWithProvider) addUsedComputeUnits(cu, virtualEpoch u
 	return nil
 }
 
+// sessionCuLimit is the cu sum a session with this provider can reach, the configured ceiling bounded by the
+// provider's allocation with the virtual epochs, the provider rejects a session beyond it at settlement. 0 means no
+// limit, cswp must be locked
+func (cswp *ConsumerSessionsWithProvider) sessionCuLimit(virtualEpoch uint64) uint64 {
+	allocation := cswp.MaxComputeUnits * (virtualEpoch + 1)
+	if MaxCuSumPerSession == 0 || (allocation > 0 && allocation < MaxCuSumPerSession) {
+		return allocation
+	}
+	return MaxCuSumPerSession
+}
+
 // Validate and add the compute units for this provider
 func (cswp *ConsumerSessionsWithProvider) getProviderStakeSize() sdk.Coin {
 	cswp.Lock.RLock()
@@ -290,6 +466,7 @@ func (cswp *ConsumerSessionsWithProvider) getProviderStakeSize() sdk.Coin {
 func (cswp *ConsumerSessionsWithProvider) decreaseUsedComputeUnits(cu uint64) error {
 	cswp.Lock.Lock()
 	defer cswp.Lock.Unlock()
+	cu = cswp.budgetCu(cu)
 	if cswp.UsedComputeUnits < cu {
 		return NegativeComputeUnitsAmountError
 	}
@@ -297,10 +474,17 @@ func (cswp *ConsumerSessionsWithProvider) decreaseUsedComputeUnits(cu uint64) er
 	return nil
 }
 
+// chargeUsedComputeUnits adds compute units the provider already charged us for, they're added past the budget too
+func (cswp *ConsumerSessionsWithProvider) chargeUsedComputeUnits(cu uint64) {
+	cswp.Lock.Lock()
+	defer cswp.Lock.Unlock()
+	cswp.UsedComputeUnits += cswp.budgetCu(cu)
+}
+
 func (cswp *ConsumerSessionsWithProvider) ConnectRawClientWithTimeout(ctx context.Context, addr string) (*pairingtypes.RelayerClient, *grpc.ClientConn, error) {
 	connectCtx, cancel := context.WithTimeout(ctx, TimeoutForEstablishingAConnection)
 	defer cancel()
-	conn, err := ConnectgRPCClient(connectCtx, addr, AllowInsecureConnectionToProviders)
+	conn, err := getRelayDialer()(connectCtx, addr, AllowInsecureConnectionToProviders)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -324,13 +508,24 @@ func (cswp *ConsumerSessionsWithProvider) ConnectRawClientWithTimeout(ctx contex
 	return &c, conn, nil
 }
 
-func (cswp *ConsumerSessionsWithProvider) GetConsumerSessionInstanceFromEndpoint(endpoint *Endpoint, numberOfResets uint64) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, err error) {
-	// TODO: validate that the endpoint even belongs to the ConsumerSessionsWithProvider and is enabled.
+func (cswp *ConsumerSessionsWithProvider) GetConsumerSessionInstanceFromEndpoint(endpoint *Endpoint, numberOfResets uint64, cuNeededForSession uint64) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, err error) {
+	singleConsumerSession, pairingEpoch, _, _, err = cswp.getConsumerSessionInstanceFromEndpoint(endpoint, numberOfResets, cuNeededForSession, 0)
+	return singleConsumerSession, pairingEpoch, err
+}
 
+// getConsumerSessionInstanceFromEndpoint also returns whether the session was created and the ids of the sessions
+// rotated out on the way
+func (cswp *ConsumerSessionsWithProvider) getConsumerSessionInstanceFromEndpoint(endpoint *Endpoint, numberOfResets uint64, cuNeededForSession uint64, virtualEpoch uint64) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, created bool, rotatedSessions []int64, err error) {
+	// TODO: validate that the endpoint even belongs to the ConsumerSessionsWithProvider and is enabled.
 	// Multiply numberOfReset +1 by MaxAllowedBlockListedSessionPerProvider as every reset needs to allow more blocked sessions allowed.
 	maximumBlockedSessionsAllowed := MaxAllowedBlockListedSessionPerProvider * (numberOfResets + 1) // +1 as we start from 0
 	cswp.Lock.Lock()
 	defer cswp.Lock.Unlock()
+	sessionCuLimit := cswp.sessionCuLimit(virtualEpoch)
+	if sessionCuLimit > 0 && cuNeededForSession > sessionCuLimit {
+		// even a fresh session can't fit this relay
+		return nil, 0, false, nil, SessionBudgetExhaustedError
+	}
 
 	// try to lock an existing session, if can't create a new one
 	var numberOfBlockedSessions uint64 = 0
@@ -343,7 +538,7 @@ func (cswp *ConsumerSessionsWithProvider) GetConsumerSessionInstanceFromEndpoint
 			continue
 		}
 		if numberOfBlockedSessions >= maximumBlockedSessionsAllowed {
-			return nil, 0, MaximumNumberOfBlockListedSessionsError
+			return nil, 0, false, rotatedSessions, MaximumNumberOfBlockListedSessionsError
 		}
 
 		if session.lock.TryLock() {
@@ -352,13 +547,20 @@ func (cswp *ConsumerSessionsWithProvider) GetConsumerSessionInstanceFromEndpoint
 				session.lock.Unlock()
 				continue
 			}
+			if !session.hasBudgetFor(cuNeededForSession, sessionCuLimit) {
+				// this session would build a cu sum the provider rejects, rotate to a fresh one instead
+				delete(cswp.Sessions, sessionID)
+				rotatedSessions = append(rotatedSessions, sessionID)
+				session.lock.Unlock()
+				continue
+			}
 			// if we locked the session its available to use, otherwise someone else is already using it
-			return session, cswp.PairingEpoch, nil
+			return session, cswp.PairingEpoch, false, rotatedSessions, nil
 		}
 	}
 	// No Sessions available, create a new session or return an error upon maximum sessions allowed
 	if len(cswp.Sessions) > MaxSessionsAllowedPerProvider {
-		return nil, 0, MaximumNumberOfSessionsExceededError
+		return nil, 0, false, rotatedSessions, MaximumNumberOfSessionsExceededError
 	}
 
 	randomSessionId := int64(0)
@@ -374,7 +576,7 @@ func (cswp *ConsumerSessionsWithProvider) GetConsumerSessionInstanceFromEndpoint
 	consumerSession.lock.Lock() // we must lock the session so other requests wont get it.
 
 	cswp.Sessions[consumerSession.SessionId] = consumerSession // applying the session to the pool of sessions.
-	return consumerSession, cswp.PairingEpoch, nil
+	return consumerSession, cswp.PairingEpoch, true, rotatedSessions, nil
 }
 
 // fetching an endpoint from a ConsumerSessionWithProvider and establishing a connection,
@@ -458,6 +660,26 @@ func (cswp *ConsumerSessionsWithProvider) fetchEndpointConnectionFromConsumerSes
 	return connected, endpointPtr, cswp.PublicLavaAddress, nil
 }
 
+// markInUse stamps the next relay generation on the session when it's handed out for a relay, the session must be locked
+func (cs *SingleConsumerSession) markInUse() {
+	atomic.StoreUint64(&cs.completion, (cs.RelayGeneration()+1)<<1)
+}
+
+// RelayGeneration returns the generation of the relay the session was last handed out for
+func (cs *SingleConsumerSession) RelayGeneration() uint64 {
+	return atomic.LoadUint64(&cs.completion) >> 1
+}
+
+// isCompleted returns true if the generation's relay completed or the session was handed out again since
+func (cs *SingleConsumerSession) isCompleted(generation uint64) bool {
+	return atomic.LoadUint64(&cs.completion) != generation<<1
+}
+
+// markCompleted returns false if the generation's relay was already completed or isn't the session's current relay
+func (cs *SingleConsumerSession) markCompleted(generation uint64) bool {
+	return atomic.CompareAndSwapUint64(&cs.completion, generation<<1, generation<<1|1)
+}
+
 // returns the expected latency to a threshold.
 func (cs *SingleConsumerSession) CalculateExpectedLatency(timeoutGivenToRelay time.Duration) time.Duration {
 	expectedLatency := (timeoutGivenToRelay / 2)
@@ -477,6 +699,11 @@ func (cs *SingleConsumerSession) getQosComputedResultOrZero() sdk.Dec {
 }
 
 func (cs *SingleConsumerSession) CalculateQoS(latency, expectedLatency time.Duration, blockHeightDiff int64, numOfProviders int, servicersToCount int64) {
+	cs.calculateQoS(latency, expectedLatency, blockHeightDiff, true, numOfProviders, servicersToCount)
+}
+
+// syncKnown is false when the reply didn't tell the provider's height, the relay then doesn't contribute to the sync score
+func (cs *SingleConsumerSession) calculateQoS(latency, expectedLatency time.Duration, blockHeightDiff int64, syncKnown bool, numOfProviders int, servicersToCount int64) {
 	// Add current Session QoS
 	cs.QoSInfo.TotalRelays++    // increase total relays
 	cs.QoSInfo.AnsweredRelays++ // increase answered relays
@@ -510,17 +737,23 @@ func (cs *SingleConsumerSession) CalculateQoS(latency, expectedLatency time.Dura
 	// checking if we have enough information to calculate the sync score for the providers, if we haven't talked
 	// with enough providers we don't have enough information and we will wait to have more information before setting the sync score
 	shouldCalculateSyncScore := int64(numOfProviders) > int64(math.Ceil(float64(servicersToCount)*MinProvidersForSync))
-	if shouldCalculateSyncScore { //
-		if blockHeightDiff <= 0 { // if the diff is bigger than 0 than the block is too old (blockHeightDiff = expected - allowedLag - blockHeight) and we don't give him the score
-			cs.QoSInfo.SyncScoreSum++
+	if !syncKnown {
+		if cs.QoSInfo.TotalSyncScore == 0 {
+			// same as having no information yet
+			cs.QoSInfo.LastQoSReport.Sync = sdk.NewDec(1)
+		}
+	} else if shouldCalculateSyncScore { //
+		if cs.QoSInfo.SyncScoreSum.IsNil() {
+			cs.QoSInfo.SyncScoreSum = sdk.ZeroDec()
 		}
+		cs.QoSInfo.SyncScoreSum = cs.QoSInfo.SyncScoreSum.Add(RelaySyncScore(blockHeightDiff))
 		cs.QoSInfo.TotalSyncScore++
-		cs.QoSInfo.LastQoSReport.Sync = sdk.NewDec(cs.QoSInfo.SyncScoreSum).QuoInt64(cs.QoSInfo.TotalSyncScore)
+		cs.QoSInfo.LastQoSReport.Sync = cs.QoSInfo.SyncScoreSum.QuoInt64(cs.QoSInfo.TotalSyncScore)
 		if sdk.OneDec().GT(cs.QoSInfo.LastQoSReport.Sync) {
 			utils.LavaFormatDebug("QoS Sync report",
 				utils.Attribute{Key: "Sync", Value: cs.QoSInfo.LastQoSReport.Sync},
 				utils.Attribute{Key: "block diff", Value: blockHeightDiff},
-				utils.Attribute{Key: "sync score", Value: strconv.FormatInt(cs.QoSInfo.SyncScoreSum, 10) + "/" + strconv.FormatInt(cs.QoSInfo.TotalSyncScore, 10)},
+				utils.Attribute{Key: "sync score", Value: cs.QoSInfo.SyncScoreSum.String() + "/" + strconv.FormatInt(cs.QoSInfo.TotalSyncScore, 10)},
 				utils.Attribute{Key: "session_id", Value: cs.SessionId},
 				utils.Attribute{Key: "provider", Value: cs.Parent.PublicLavaAddress},
 			)
@@ -531,12 +764,48 @@ func (cs *SingleConsumerSession) CalculateQoS(latency, expectedLatency time.Dura
 	}
 }
 
+// RelaySyncScore scores a single relay's sync, blockHeightDiff = expected - allowedLag - blockHeight. a provider ahead of
+// the expected height (clock skew, a faster node) is capped at perfectly synced, one behind it loses score proportionally
+// to the lag down to 0 at SyncScoreMaxBlockLag
+func RelaySyncScore(blockHeightDiff int64) sdk.Dec {
+	if blockHeightDiff <= 0 {
+		return sdk.OneDec()
+	}
+	if SyncScoreMaxBlockLag <= 0 || blockHeightDiff >= SyncScoreMaxBlockLag {
+		return sdk.ZeroDec()
+	}
+	return sdk.NewDec(SyncScoreMaxBlockLag - blockHeightDiff).QuoInt64(SyncScoreMaxBlockLag)
+}
+
 func CalculateAvailabilityScore(qosReport *QoSReport) (downtimePercentageRet, scaledAvailabilityScoreRet sdk.Dec) {
 	downtimePercentage := sdk.NewDecWithPrec(int64(qosReport.TotalRelays-qosReport.AnsweredRelays), 0).Quo(sdk.NewDecWithPrec(int64(qosReport.TotalRelays), 0))
 	scaledAvailabilityScore := sdk.MaxDec(sdk.ZeroDec(), AvailabilityPercentage.Sub(downtimePercentage).Quo(AvailabilityPercentage))
 	return downtimePercentage, scaledAvailabilityScore
 }
 
+// the epoch of the pairing this session belongs to, in-flight relays keep it after the pairing rotates
+func (scs *SingleConsumerSession) PairingEpoch() uint64 {
+	_, epoch := scs.Parent.getPublicLavaAddressAndPairingEpoch()
+	return epoch
+}
+
+// checks the session guardrails allow another relay with the given cu under the session's cu limit, session should be
+// locked
+func (scs *SingleConsumerSession) hasBudgetFor(cu uint64, cuLimit uint64) bool {
+	if MaxRelayNumPerSession > 0 && scs.RelayNum+RelayNumberIncrement > MaxRelayNumPerSession {
+		return false
+	}
+	if cuLimit > 0 && scs.CuSum+cu > cuLimit {
+		return false
+	}
+	return true
+}
+
+// IsSimulated returns whether the current relay on the session is simulated, session should be locked
+func (scs *SingleConsumerSession) IsSimulated() bool {
+	return scs.simulated
+}
+
 // validate if this is a data reliability session
 func (scs *SingleConsumerSession) IsDataReliabilitySession() bool {
 	return scs.SessionId <= DataReliabilitySessionId
@@ -1,7 +1,9 @@
 package lavasession
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/stretchr/testify/require"
@@ -33,3 +35,32 @@ func TestCalculateAvailabilityScore(t *testing.T) {
 	require.Equal(t, downTimeFloat*2, avialabilityAsFloat)
 	require.Equal(t, halfDec, availabilityScore)
 }
+
+func TestCalculateQoSSyncScore(t *testing.T) {
+	playbook := []struct {
+		name            string
+		blockHeightDiff int64
+		expectedSync    sdk.Dec
+	}{
+		{name: "exactly synced", blockHeightDiff: 0, expectedSync: sdk.OneDec()},
+		{name: "provider ahead", blockHeightDiff: -3, expectedSync: sdk.OneDec()},
+		{name: "provider far ahead", blockHeightDiff: math.MinInt64, expectedSync: sdk.OneDec()},
+		{name: "provider behind", blockHeightDiff: 2, expectedSync: sdk.NewDecWithPrec(6, 1)},
+		{name: "provider at max lag", blockHeightDiff: DefaultSyncScoreMaxBlockLag, expectedSync: sdk.ZeroDec()},
+		{name: "provider far behind", blockHeightDiff: math.MaxInt64, expectedSync: sdk.ZeroDec()},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			session := &SingleConsumerSession{Parent: &ConsumerSessionsWithProvider{}}
+			session.CalculateQoS(time.Millisecond, time.Millisecond, play.blockHeightDiff, 2, 1)
+			require.True(t, play.expectedSync.Equal(session.QoSInfo.LastQoSReport.Sync), "sync %s", session.QoSInfo.LastQoSReport.Sync)
+		})
+	}
+
+	// the score averages over the session's relays
+	session := &SingleConsumerSession{Parent: &ConsumerSessionsWithProvider{}}
+	for _, blockHeightDiff := range []int64{-1, 0, 1, DefaultSyncScoreMaxBlockLag + 1} {
+		session.CalculateQoS(time.Millisecond, time.Millisecond, blockHeightDiff, 2, 1)
+	}
+	require.True(t, sdk.MustNewDecFromStr("0.7").Equal(session.QoSInfo.LastQoSReport.Sync))
+}
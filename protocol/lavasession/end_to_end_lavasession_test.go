@@ -57,7 +57,7 @@ func TestHappyFlowE2EEmergency(t *testing.T) {
 
 				skippedRelays++
 
-				err := csm.OnSessionFailure(cs.Session, nil)
+				err := csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), nil)
 				require.NoError(t, err)
 
 				err = psm.OnSessionFailure(sps, cs.Session.RelayNum-skippedRelays)
@@ -72,7 +72,7 @@ func TestHappyFlowE2EEmergency(t *testing.T) {
 				err = psm.OnSessionDone(sps, cs.Session.RelayNum-skippedRelays)
 				require.NoError(t, err)
 
-				err = csm.OnSessionDone(cs.Session, servicedBlockNumber, maxCuForVirtualEpoch, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), 1, 1, false)
+				err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, maxCuForVirtualEpoch, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), 1, 1, false)
 				require.NoError(t, err)
 			}
 
@@ -124,7 +124,7 @@ func TestHappyFlowEmergencyInConsumer(t *testing.T) {
 		require.NoError(t, err)
 
 		// Consumer Side:
-		err = csm.OnSessionFailure(cs.Session, nil)
+		err = csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), nil)
 		require.NoError(t, err)
 		require.Equal(t, cs.Session.CuSum, maxCuForVirtualEpoch)
 		require.Equal(t, cs.Session.LatestRelayCu, latestRelayCuAfterDone)
@@ -195,7 +195,7 @@ func prepareSessionsWithFirstRelay(t *testing.T, cuForFirstRequest uint64) (*Con
 		require.NoError(t, err)
 
 		// Consumer Side:
-		err = csm.OnSessionDone(cs.Session, servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), 1, 1, false)
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), 1, 1, false)
 		require.NoError(t, err)
 		require.Equal(t, cs.Session.CuSum, cuForFirstRequest)
 		require.Equal(t, cs.Session.LatestRelayCu, latestRelayCuAfterDone)
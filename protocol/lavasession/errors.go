@@ -26,6 +26,13 @@ var ( // Consumer Side Errors
 	FailedToConnectToEndPointForDataReliabilityError     = sdkerrors.New("FailedToConnectToEndPointForDataReliability Error", 683, "Failed to connect to a providers endpoints")
 	DataReliabilityEpochMismatchError                    = sdkerrors.New("DataReliabilityEpochMismatch Error", 684, "Data reliability epoch mismatch original session epoch.")
 	NoDataReliabilitySessionWasCreatedError              = sdkerrors.New("NoDataReliabilitySessionWasCreated Error", 685, "No Data reliability session was created")
+	SessionBudgetExhaustedError                          = sdkerrors.New("SessionBudgetExhausted Error", 686, "No session has remaining compute units or relays budget.")
+	SessionResyncRejectedError                           = sdkerrors.New("SessionResyncRejected Error", 687, "Provider's session state can't be resynced to")
+	NoCapableProviderError                               = sdkerrors.New("NoCapableProvider Error", 688, "No provider in the pairing supports the requested api")
+	ErrProviderCuExhausted                               = sdkerrors.New("ProviderCuExhausted Error", 689, "Provider rejected the relay, the consumer's compute units for this epoch are exhausted")
+	ErrInsufficientProviders                             = sdkerrors.New("InsufficientProviders Error", 690, "Not enough usable providers in the pairing to serve relays")
+	RelayAdmissionTimeoutError                           = sdkerrors.New("RelayAdmissionTimeout Error", 691, "Relay wasn't admitted before its deadline, too many concurrent relays")
+	RelayExceedsProviderLimitsError                      = sdkerrors.New("RelayExceedsProviderLimits Error", 692, "No provider in the pairing accepts a request or response of this size")
 )
 
 var ( // Provider Side Errors
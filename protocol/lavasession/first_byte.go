@@ -0,0 +1,71 @@
+package lavasession
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+const MeasureTimeToFirstByteFlag = "measure-time-to-first-byte"
+
+// captures when a provider's reply starts arriving on top of the total relay latency, and tracks it per provider
+var MeasureTimeToFirstByte = false
+
+type firstByteHookContextKey struct{}
+
+// ContextWithFirstByteHook calls hook with the time the reply headers of a relay sent with the returned context arrive,
+// the relay connection must be dialed with FirstByteDialOption
+func ContextWithFirstByteHook(ctx context.Context, hook func(arrival time.Time)) context.Context {
+	return context.WithValue(ctx, firstByteHookContextKey{}, hook)
+}
+
+// FirstByteDialOption makes first byte hooks work on a relay connection, dialers set with SetRelayDialer should add
+// it too
+func FirstByteDialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(firstByteStatsHandler{})
+}
+
+// firstByteStatsHandler calls the context's first byte hook when the reply headers arrive, grpc sends them with the
+// first bytes of the reply so the rest of the transfer isn't included
+type firstByteStatsHandler struct{}
+
+func (firstByteStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (firstByteStatsHandler) HandleRPC(ctx context.Context, rpcStats stats.RPCStats) {
+	inHeader, ok := rpcStats.(*stats.InHeader)
+	if !ok || !inHeader.IsClient() {
+		return
+	}
+	if hook, ok := ctx.Value(firstByteHookContextKey{}).(func(time.Time)); ok {
+		hook(time.Now())
+	}
+}
+
+func (firstByteStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (firstByteStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+// optimizers tracking the time to first byte as a qos dimension of its own
+type firstByteOptimizer interface {
+	AppendTimeToFirstByte(providerAddress string, timeToFirstByte time.Duration)
+}
+
+// reports a measured time to first byte to the optimizer and the metrics, it doesn't affect the relay latency qos
+func (csm *ConsumerSessionManager) appendTimeToFirstByte(providerAddress string, timeToFirstByte time.Duration) {
+	if timeToFirstByte <= 0 {
+		return
+	}
+	if optimizer, ok := csm.providerOptimizer.(firstByteOptimizer); ok {
+		optimizer.AppendTimeToFirstByte(providerAddress, timeToFirstByte)
+	}
+	if csm.consumerMetricsManager != nil {
+		info := csm.RPCEndpoint()
+		csm.consumerMetricsManager.SetTimeToFirstByte(info.ChainID, info.ApiInterface, providerAddress, timeToFirstByte)
+	}
+}
@@ -0,0 +1,84 @@
+package lavasession
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// slowTransferRelayer starts its reply right away and takes transferTime to finish it
+type slowTransferRelayer struct {
+	pairingtypes.UnimplementedRelayerServer
+	transferTime time.Duration
+}
+
+func (str *slowTransferRelayer) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	if err := grpc.SendHeader(ctx, metadata.MD{}); err != nil {
+		return nil, err
+	}
+	time.Sleep(str.transferTime)
+	return &pairingtypes.RelayReply{Data: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`)}, nil
+}
+
+func TestFirstByteHook(t *testing.T) {
+	transferTime := 200 * time.Millisecond
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pairingtypes.RegisterRelayerServer(server, &slowTransferRelayer{transferTime: transferTime})
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.DialContext(context.Background(), "bufconn", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()), FirstByteDialOption())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pairingtypes.NewRelayerClient(conn)
+
+	var calls atomic.Int32
+	var timeToFirstByte time.Duration
+	sentTime := time.Now()
+	ctx := ContextWithFirstByteHook(context.Background(), func(arrival time.Time) {
+		calls.Add(1)
+		timeToFirstByte = arrival.Sub(sentTime)
+	})
+	_, err = client.Relay(ctx, &pairingtypes.RelayRequest{})
+	require.NoError(t, err)
+	latency := time.Since(sentTime)
+	require.Equal(t, int32(1), calls.Load())
+	require.GreaterOrEqual(t, latency, transferTime)
+	require.Less(t, timeToFirstByte, transferTime/2)
+
+	// relays without a hook are left alone
+	_, err = client.Relay(context.Background(), &pairingtypes.RelayRequest{})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestSessionDoneReportsTimeToFirstByte(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	require.NoError(t, csm.UpdateAllProviders(firstEpochHeight, createPairingList("", true)))
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	for providerAddress, cs := range css {
+		cs.Session.SetTimeToFirstByte(15 * time.Millisecond)
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, 200*time.Millisecond, cs.Session.CalculateExpectedLatency(time.Second), servicedBlockNumber-1, numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+		optimizer := csm.providerOptimizer.(*provideroptimizer.ProviderOptimizer)
+		require.Eventually(t, func() bool {
+			timeToFirstByte, ok := optimizer.GetTimeToFirstByte(providerAddress)
+			return ok && timeToFirstByte > 14*time.Millisecond && timeToFirstByte < 16*time.Millisecond
+		}, time.Second, 10*time.Millisecond)
+	}
+}
@@ -0,0 +1,69 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxInFlightRelaysPerProvider(t *testing.T) {
+	defer func(maxInFlight uint64) { MaxInFlightRelaysPerProvider = maxInFlight }(MaxInFlightRelaysPerProvider)
+	MaxInFlightRelaysPerProvider = 2
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0], 1: createPairingList("", true)[1]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+	busyProvider := pairingList[0].PublicLavaAddress
+	otherProvider := pairingList[1].PublicLavaAddress
+
+	// saturate one provider
+	saturating := []*SingleConsumerSession{}
+	for i := 0; i < 2; i++ {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, map[string]struct{}{otherProvider: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		require.Contains(t, css, busyProvider)
+		saturating = append(saturating, css[busyProvider].Session)
+	}
+	require.Equal(t, int64(2), csm.GetInFlightRelays()[busyProvider])
+
+	// the overflow goes to the other provider
+	for i := 0; i < 5; i++ {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		require.Contains(t, css, otherProvider)
+		require.NoError(t, csm.OnSessionDone(css[otherProvider].Session, css[otherProvider].Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, css[otherProvider].Session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber, numberOfProviders, numberOfProviders, false))
+	}
+	require.Equal(t, map[string]int64{busyProvider: 2, otherProvider: 0}, csm.GetInFlightRelays())
+	// with no other provider there is nothing to choose from
+	_, err = csm.GetSessions(ctx, cuForFirstRequest, map[string]struct{}{otherProvider: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.Error(t, err)
+
+	// completing a relay frees the provider
+	require.NoError(t, csm.OnSessionFailure(saturating[0], saturating[0].RelayGeneration(), nil))
+	require.Equal(t, int64(1), csm.GetInFlightRelays()[busyProvider])
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, map[string]struct{}{otherProvider: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	require.Contains(t, css, busyProvider)
+}
+
+func TestInFlightRelaysKeptOnLockMisuse(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+	provider := pairingList[0].PublicLavaAddress
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), csm.GetInFlightRelays()[provider])
+
+	// completing a session that isn't locked is a misuse, it doesn't free the provider's slot
+	session := css[provider].Session
+	session.lock.Unlock()
+	require.Error(t, csm.OnSessionFailure(session, session.RelayGeneration(), nil))
+	require.Equal(t, int64(1), csm.GetInFlightRelays()[provider])
+}
@@ -0,0 +1,164 @@
+package lavasession
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	LatencyAnomalyMultiplierFlag         = "latency-anomaly-multiplier"
+	LatencyAnomalyRecoveryMultiplierFlag = "latency-anomaly-recovery-multiplier"
+	LatencyAnomalyWindowFlag             = "latency-anomaly-window"
+	DefaultLatencyAnomalyWindow          = 5
+	latencyAnomalyBaselineDecay          = 0.05 // weight of a new sample in the baseline, the baseline moves slowly so a regression stands out
+	latencyAnomalyBaselineMinSamples     = 20   // samples needed before the baseline is trusted
+)
+
+var (
+	// a provider whose recent latency is this many times its baseline is reported, 0 disables the detection
+	LatencyAnomalyMultiplier float64 = 0
+	// a reported provider recovers when its recent latency is back under this many times its baseline, keeping it
+	// between 1 and the trigger multiplier avoids flapping, at 1 or under a provider could never recover
+	LatencyAnomalyRecoveryMultiplier float64 = 0
+	LatencyAnomalyWindow             uint64  = DefaultLatencyAnomalyWindow
+)
+
+type providerLatencyBaseline struct {
+	baseline         float64 // ewma of the latency while the provider behaves normally
+	baselineSamples  int
+	recent           []time.Duration
+	next             int
+	anomalous        bool
+	reportedAnomaly  bool // the report was added by the detector, so recovering removes it
+	lastRecentMean   time.Duration
+	lastBaselineMean time.Duration
+}
+
+func (plb *providerLatencyBaseline) appendRecent(latency time.Duration, windowSize int) {
+	if len(plb.recent) < windowSize {
+		plb.recent = append(plb.recent, latency)
+		return
+	}
+	plb.recent[plb.next] = latency
+	plb.next = (plb.next + 1) % windowSize
+}
+
+func (plb *providerLatencyBaseline) recentMean() float64 {
+	var sum time.Duration
+	for _, latency := range plb.recent {
+		sum += latency
+	}
+	return float64(sum) / float64(len(plb.recent))
+}
+
+// latencyAnomalyDetector detects sudden latency regressions per provider, comparing a short rolling window to a
+// slow moving baseline, it catches degraded providers that still answer within the timeouts
+type latencyAnomalyDetector struct {
+	lock               sync.Mutex
+	multiplier         float64
+	recoveryMultiplier float64
+	windowSize         int
+	providers          map[string]*providerLatencyBaseline
+}
+
+func newLatencyAnomalyDetector(multiplier float64, recoveryMultiplier float64, windowSize uint64) *latencyAnomalyDetector {
+	if windowSize == 0 {
+		windowSize = DefaultLatencyAnomalyWindow
+	}
+	if recoveryMultiplier <= 1 || recoveryMultiplier > multiplier {
+		// halfway between the baseline and the trigger
+		recoveryMultiplier = (1 + multiplier) / 2
+	}
+	return &latencyAnomalyDetector{multiplier: multiplier, recoveryMultiplier: recoveryMultiplier, windowSize: int(windowSize), providers: map[string]*providerLatencyBaseline{}}
+}
+
+func (lad *latencyAnomalyDetector) enabled() bool {
+	return lad != nil && lad.multiplier > 0
+}
+
+// AppendLatency returns whether the provider's anomaly state changed and the new state
+func (lad *latencyAnomalyDetector) AppendLatency(providerAddress string, latency time.Duration) (changed bool, anomalous bool) {
+	if !lad.enabled() {
+		return false, false
+	}
+	lad.lock.Lock()
+	defer lad.lock.Unlock()
+	provider, ok := lad.providers[providerAddress]
+	if !ok {
+		provider = &providerLatencyBaseline{}
+		lad.providers[providerAddress] = provider
+	}
+	provider.appendRecent(latency, lad.windowSize)
+	if provider.baselineSamples >= latencyAnomalyBaselineMinSamples && len(provider.recent) >= lad.windowSize {
+		recentMean := provider.recentMean()
+		provider.lastRecentMean = time.Duration(recentMean)
+		provider.lastBaselineMean = time.Duration(provider.baseline)
+		if !provider.anomalous && recentMean > lad.multiplier*provider.baseline {
+			provider.anomalous = true
+			return true, true
+		}
+		if provider.anomalous && recentMean < lad.recoveryMultiplier*provider.baseline {
+			provider.anomalous = false
+			return true, false
+		}
+	}
+	if provider.anomalous {
+		// the regression must not become the new normal
+		return false, true
+	}
+	if provider.baselineSamples >= latencyAnomalyBaselineMinSamples && float64(latency) > lad.recoveryMultiplier*provider.baseline {
+		// outliers are kept out of the baseline, otherwise it absorbs a regression before the window detects it
+		return false, false
+	}
+	if provider.baselineSamples == 0 {
+		provider.baseline = float64(latency)
+	} else {
+		provider.baseline = (1-latencyAnomalyBaselineDecay)*provider.baseline + latencyAnomalyBaselineDecay*float64(latency)
+	}
+	provider.baselineSamples++
+	return false, false
+}
+
+// markReported records whether the detector added the provider's report, returns whether it did before
+func (lad *latencyAnomalyDetector) markReported(providerAddress string, reported bool) bool {
+	lad.lock.Lock()
+	defer lad.lock.Unlock()
+	provider, ok := lad.providers[providerAddress]
+	if !ok {
+		return false
+	}
+	wasReported := provider.reportedAnomaly
+	provider.reportedAnomaly = reported
+	return wasReported
+}
+
+func (lad *latencyAnomalyDetector) anomalousProviders() []string {
+	if !lad.enabled() {
+		return nil
+	}
+	lad.lock.Lock()
+	defer lad.lock.Unlock()
+	addresses := []string{}
+	for address, provider := range lad.providers {
+		if provider.anomalous {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+func (lad *latencyAnomalyDetector) describe(providerAddress string) []utils.Attribute {
+	lad.lock.Lock()
+	defer lad.lock.Unlock()
+	provider, ok := lad.providers[providerAddress]
+	if !ok {
+		return nil
+	}
+	return []utils.Attribute{
+		utils.LogAttr("provider", providerAddress),
+		utils.LogAttr("recentLatency", provider.lastRecentMean),
+		utils.LogAttr("baselineLatency", provider.lastBaselineMean),
+	}
+}
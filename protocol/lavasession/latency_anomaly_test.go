@@ -0,0 +1,122 @@
+package lavasession
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyAnomalyDetector(t *testing.T) {
+	const provider = "provider"
+	detector := newLatencyAnomalyDetector(10, 0, 5)
+	require.Equal(t, 5.5, detector.recoveryMultiplier)
+	appendSamples := func(latency time.Duration, count int) (transitions []bool, anomalous bool) {
+		for i := 0; i < count; i++ {
+			var changed bool
+			changed, anomalous = detector.AppendLatency(provider, latency)
+			if changed {
+				transitions = append(transitions, anomalous)
+			}
+		}
+		return transitions, anomalous
+	}
+
+	// a slow but stable provider isn't anomalous
+	transitions, anomalous := appendSamples(20*time.Millisecond, latencyAnomalyBaselineMinSamples)
+	require.Empty(t, transitions)
+	require.False(t, anomalous)
+	// a single spike isn't enough
+	transitions, anomalous = appendSamples(300*time.Millisecond, 1)
+	require.Empty(t, transitions)
+	require.False(t, anomalous)
+	_, _ = appendSamples(20*time.Millisecond, 5)
+
+	// a sustained 15x regression is detected once
+	transitions, anomalous = appendSamples(300*time.Millisecond, 20)
+	require.Equal(t, []bool{true}, transitions)
+	require.True(t, anomalous)
+	require.Equal(t, []string{provider}, detector.anomalousProviders())
+
+	// hysteresis, 7x the baseline is under the trigger but over the recovery threshold
+	transitions, anomalous = appendSamples(140*time.Millisecond, 20)
+	require.Empty(t, transitions)
+	require.True(t, anomalous)
+
+	// the regression didn't move the baseline, returning to normal recovers
+	transitions, anomalous = appendSamples(20*time.Millisecond, 5)
+	require.Equal(t, []bool{false}, transitions)
+	require.False(t, anomalous)
+	require.Empty(t, detector.anomalousProviders())
+
+	disabled := newLatencyAnomalyDetector(0, 0, 5)
+	for i := 0; i < 100; i++ {
+		changed, anomalous := disabled.AppendLatency(provider, time.Duration(i)*time.Second)
+		require.False(t, changed)
+		require.False(t, anomalous)
+	}
+}
+
+func TestLatencyAnomalyLowMultiplierRecovers(t *testing.T) {
+	const provider = "provider"
+	// a recovery threshold at 1 or under would need the provider to beat its own baseline
+	for _, recoveryMultiplier := range []float64{0, 0.5, 1} {
+		require.Equal(t, 1.5, newLatencyAnomalyDetector(2, recoveryMultiplier, 5).recoveryMultiplier)
+	}
+	detector := newLatencyAnomalyDetector(2, 0, 5)
+	for i := 0; i < latencyAnomalyBaselineMinSamples; i++ {
+		detector.AppendLatency(provider, 20*time.Millisecond)
+	}
+	anomalous := false
+	for i := 0; i < 5; i++ {
+		_, anomalous = detector.AppendLatency(provider, 50*time.Millisecond)
+	}
+	require.True(t, anomalous)
+	for i := 0; i < 5; i++ {
+		_, anomalous = detector.AppendLatency(provider, 20*time.Millisecond)
+	}
+	require.False(t, anomalous)
+}
+
+func TestLatencyAnomalyReporting(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	csm.latencyAnomaly = newLatencyAnomalyDetector(10, 0, 5)
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	anomalousProvider := pairingList[0].PublicLavaAddress
+	erroredProvider := pairingList[1].PublicLavaAddress
+	csm.reportedProviders.ReportProvider(erroredProvider, 1, 0, nil)
+
+	appendSamples := func(providerAddress string, latency time.Duration, count int) {
+		for i := 0; i < count; i++ {
+			csm.appendLatencyAnomalySample(providerAddress, latency)
+		}
+	}
+	reported := func(epoch uint64) []string {
+		addresses := []string{}
+		for _, reportedProvider := range csm.GetReportedProviders(epoch) {
+			addresses = append(addresses, reportedProvider.Address)
+		}
+		return addresses
+	}
+	for _, providerAddress := range []string{anomalousProvider, erroredProvider} {
+		appendSamples(providerAddress, 10*time.Millisecond, latencyAnomalyBaselineMinSamples)
+		appendSamples(providerAddress, 200*time.Millisecond, 5)
+	}
+	require.ElementsMatch(t, []string{anomalousProvider, erroredProvider}, reported(firstEpochHeight))
+
+	// recovering removes only the reports the detector added
+	for _, providerAddress := range []string{anomalousProvider, erroredProvider} {
+		appendSamples(providerAddress, 10*time.Millisecond, 5)
+	}
+	require.Equal(t, []string{erroredProvider}, reported(firstEpochHeight))
+
+	// providers that are still anomalous are reported again in the next epoch
+	appendSamples(anomalousProvider, 200*time.Millisecond, 5)
+	err = csm.UpdateAllProviders(secondEpochHeight, pairingList)
+	require.NoError(t, err)
+	require.Equal(t, []string{anomalousProvider}, reported(secondEpochHeight))
+	appendSamples(anomalousProvider, 10*time.Millisecond, 5)
+	require.Empty(t, reported(secondEpochHeight))
+}
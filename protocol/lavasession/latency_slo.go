@@ -0,0 +1,165 @@
+package lavasession
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	LatencySLOFlag              = "latency-slo"
+	LatencySLOWindowFlag        = "latency-slo-window"
+	DefaultLatencySLOWindow     = 100
+	latencySLOPercentile        = 0.95
+	latencySLOMinSamplesDivisor = 2 // a provider needs at least half a window of samples before it can be demoted
+)
+
+var (
+	// providers with a rolling p95 latency above this are demoted to the fallback tier, 0 disables the slo
+	LatencySLO       time.Duration = 0
+	LatencySLOWindow uint64        = DefaultLatencySLOWindow
+)
+
+type ProviderTier string
+
+const (
+	ProviderTierPrimary  ProviderTier = "primary"
+	ProviderTierFallback ProviderTier = "fallback"
+)
+
+type providerLatencyWindow struct {
+	samples []time.Duration
+	next    int
+	demoted bool
+	lastP95 time.Duration
+}
+
+func (plw *providerLatencyWindow) append(latency time.Duration, windowSize int) {
+	if len(plw.samples) < windowSize {
+		plw.samples = append(plw.samples, latency)
+		return
+	}
+	plw.samples[plw.next] = latency
+	plw.next = (plw.next + 1) % windowSize
+}
+
+func (plw *providerLatencyWindow) percentile(percentile float64) time.Duration {
+	if len(plw.samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(plw.samples))
+	copy(sorted, plw.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(percentile*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// latencySLOTracker keeps a rolling latency window per provider and demotes providers breaching the slo,
+// it is keyed by address so demotions survive pairing updates for providers that stay paired
+type latencySLOTracker struct {
+	lock       sync.RWMutex
+	slo        time.Duration
+	windowSize int
+	providers  map[string]*providerLatencyWindow
+}
+
+func newLatencySLOTracker(slo time.Duration, windowSize uint64) *latencySLOTracker {
+	if windowSize == 0 {
+		windowSize = DefaultLatencySLOWindow
+	}
+	return &latencySLOTracker{slo: slo, windowSize: int(windowSize), providers: map[string]*providerLatencyWindow{}}
+}
+
+func (lst *latencySLOTracker) enabled() bool {
+	return lst != nil && lst.slo > 0
+}
+
+func (lst *latencySLOTracker) AppendLatency(providerAddress string, latency time.Duration) {
+	if !lst.enabled() {
+		return
+	}
+	lst.lock.Lock()
+	defer lst.lock.Unlock()
+	window, ok := lst.providers[providerAddress]
+	if !ok {
+		window = &providerLatencyWindow{}
+		lst.providers[providerAddress] = window
+	}
+	window.append(latency, lst.windowSize)
+	if len(window.samples) < lst.windowSize/latencySLOMinSamplesDivisor {
+		return
+	}
+	window.lastP95 = window.percentile(latencySLOPercentile)
+	demoted := window.lastP95 > lst.slo
+	if demoted != window.demoted {
+		// recovering requires the rolling window to be back within the slo, so only sustained good latency promotes
+		utils.LavaFormatInfo("provider latency tier changed",
+			utils.LogAttr("provider", providerAddress),
+			utils.LogAttr("tier", tierOf(demoted)),
+			utils.LogAttr("p95", window.lastP95),
+			utils.LogAttr("slo", lst.slo),
+		)
+		window.demoted = demoted
+	}
+}
+
+// prune drops the windows of providers that are no longer paired, so the tracker doesn't grow across epochs
+func (lst *latencySLOTracker) prune(pairing map[string]*ConsumerSessionsWithProvider) {
+	if !lst.enabled() {
+		return
+	}
+	lst.lock.Lock()
+	defer lst.lock.Unlock()
+	for address := range lst.providers {
+		if _, ok := pairing[address]; !ok {
+			delete(lst.providers, address)
+		}
+	}
+}
+
+func tierOf(demoted bool) ProviderTier {
+	if demoted {
+		return ProviderTierFallback
+	}
+	return ProviderTierPrimary
+}
+
+func (lst *latencySLOTracker) GetTier(providerAddress string) ProviderTier {
+	if !lst.enabled() {
+		return ProviderTierPrimary
+	}
+	lst.lock.RLock()
+	defer lst.lock.RUnlock()
+	window, ok := lst.providers[providerAddress]
+	return tierOf(ok && window.demoted)
+}
+
+// filterPrimaryTier returns the primary tier providers if any of them can still be chosen, otherwise all of them so the fallback tier is used
+func (lst *latencySLOTracker) filterPrimaryTier(addresses []string, ignoredProviders map[string]struct{}) []string {
+	if !lst.enabled() {
+		return addresses
+	}
+	lst.lock.RLock()
+	defer lst.lock.RUnlock()
+	primary := make([]string, 0, len(addresses))
+	availablePrimary := false
+	for _, address := range addresses {
+		if window, ok := lst.providers[address]; ok && window.demoted {
+			continue
+		}
+		primary = append(primary, address)
+		if _, ignored := ignoredProviders[address]; !ignored {
+			availablePrimary = true
+		}
+	}
+	if !availablePrimary {
+		return addresses
+	}
+	return primary
+}
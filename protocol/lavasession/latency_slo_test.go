@@ -0,0 +1,83 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencySLOTracker(t *testing.T) {
+	tracker := newLatencySLOTracker(100*time.Millisecond, 20)
+	// not enough samples to judge the provider yet
+	for i := 0; i < 9; i++ {
+		tracker.AppendLatency("slow", time.Second)
+	}
+	require.Equal(t, ProviderTierPrimary, tracker.GetTier("slow"))
+	tracker.AppendLatency("slow", time.Second)
+	require.Equal(t, ProviderTierFallback, tracker.GetTier("slow"))
+
+	// a few outliers under the p95 don't demote
+	for i := 0; i < 19; i++ {
+		tracker.AppendLatency("fast", 10*time.Millisecond)
+	}
+	tracker.AppendLatency("fast", time.Second)
+	require.Equal(t, ProviderTierPrimary, tracker.GetTier("fast"))
+
+	// recovery needs the whole window to be good again
+	for i := 0; i < 18; i++ {
+		tracker.AppendLatency("slow", 10*time.Millisecond)
+	}
+	require.Equal(t, ProviderTierFallback, tracker.GetTier("slow"))
+	tracker.AppendLatency("slow", 10*time.Millisecond)
+	require.Equal(t, ProviderTierPrimary, tracker.GetTier("slow"))
+
+	// disabled tracker keeps everyone primary
+	disabled := newLatencySLOTracker(0, 10)
+	for i := 0; i < 10; i++ {
+		disabled.AppendLatency("slow", time.Second)
+	}
+	require.Equal(t, ProviderTierPrimary, disabled.GetTier("slow"))
+}
+
+func TestLatencySLOTieredSelection(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	csm.latencySLO = newLatencySLOTracker(100*time.Millisecond, 2)
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0], 1: createPairingList("", true)[1]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+	slowProvider := pairingList[0].PublicLavaAddress
+	fastProvider := pairingList[1].PublicLavaAddress
+	csm.latencySLO.AppendLatency(slowProvider, time.Second)
+	require.Equal(t, map[string]ProviderTier{slowProvider: ProviderTierFallback, fastProvider: ProviderTierPrimary}, csm.GetProviderTiers())
+
+	// the demoted provider is never picked while the primary tier has providers
+	for i := 0; i < 5; i++ {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		require.Contains(t, css, fastProvider)
+		for _, cs := range css {
+			require.NoError(t, csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber-1), numberOfProviders, numberOfProviders, false))
+		}
+	}
+
+	// once the primary tier is exhausted the fallback tier is used
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, map[string]struct{}{fastProvider: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	require.Contains(t, css, slowProvider)
+	for _, cs := range css {
+		require.NoError(t, csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber-1), numberOfProviders, numberOfProviders, false))
+	}
+
+	// a provider that stays paired keeps its demotion, the windows of unpaired providers are dropped
+	err = csm.UpdateAllProviders(firstEpochHeight+1, map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]})
+	require.NoError(t, err)
+	require.Equal(t, ProviderTierFallback, csm.GetProviderTier(slowProvider))
+	csm.latencySLO.lock.RLock()
+	require.Len(t, csm.latencySLO.providers, 1)
+	require.Contains(t, csm.latencySLO.providers, slowProvider)
+	csm.latencySLO.lock.RUnlock()
+}
@@ -0,0 +1,63 @@
+package lavasession
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+type affinityKeyContextKey struct{}
+
+// ContextWithAffinityKey makes GetSessions prefer the same provider for every request with this key,
+// used for workloads that benefit from warm provider side caches
+func ContextWithAffinityKey(ctx context.Context, affinityKey string) context.Context {
+	if affinityKey == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, affinityKeyContextKey{}, affinityKey)
+}
+
+func AffinityKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	affinityKey, _ := ctx.Value(affinityKeyContextKey{}).(string)
+	return affinityKey
+}
+
+// chooseAffinityProvider maps the key to one of the providers the optimizer qualifies for the relay, so a key pinned to
+// a provider with a poor qos moves to the key's next preferred provider. returns an empty address without a key or a
+// qualified provider, the optimizer chooses then
+func (csm *ConsumerSessionManager) chooseAffinityProvider(affinityKey string, candidates []string, ignoredProviders map[string]struct{}, requestedBlock int64, relayMethod string) string {
+	if affinityKey == "" {
+		return ""
+	}
+	qualified := make([]string, 0, len(candidates))
+	for _, address := range candidates {
+		if csm.providerOptimizer.IsProviderQualified(address, requestedBlock, relayMethod) {
+			qualified = append(qualified, address)
+		}
+	}
+	return chooseAffinityProvider(affinityKey, qualified, ignoredProviders)
+}
+
+// chooseAffinityProvider maps the key to a provider with rendezvous hashing, so pairing changes only remap the keys
+// of providers that left. ignored providers are skipped, which makes failover land on the key's next preferred provider
+func chooseAffinityProvider(affinityKey string, addresses []string, ignoredProviders map[string]struct{}) string {
+	chosen := ""
+	var chosenScore uint64
+	for _, address := range addresses {
+		if _, ignored := ignoredProviders[address]; ignored {
+			continue
+		}
+		hasher := fnv.New64a()
+		hasher.Write([]byte(affinityKey))
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(address))
+		score := hasher.Sum64()
+		if chosen == "" || score > chosenScore {
+			chosen = address
+			chosenScore = score
+		}
+	}
+	return chosen
+}
@@ -0,0 +1,120 @@
+package lavasession
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/stretchr/testify/require"
+)
+
+// ignoreProbesOptimizer drops probe results, the test providers don't answer probes and would all be disqualified
+type ignoreProbesOptimizer struct {
+	*provideroptimizer.ProviderOptimizer
+}
+
+func (ipo ignoreProbesOptimizer) AppendProbeRelayData(providerAddress string, latency time.Duration, success bool) {
+}
+
+func TestAffinityProviderSelection(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	csm.providerOptimizer = ignoreProbesOptimizer{provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, common.AverageWorldLatency/2, 1)}
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond) // let probes finish
+
+	getProvider := func(ctx context.Context) string {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+		require.NoError(t, err)
+		require.Len(t, css, 1)
+		for providerAddress, cs := range css {
+			// release the cu so providers don't run out of it over the repeated requests
+			require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+			return providerAddress
+		}
+		return ""
+	}
+
+	// the providers the optimizer qualifies for the relay
+	qualifiedAddresses := func() []string {
+		qualified := []string{}
+		for _, address := range csm.getValidAddresses("", nil) {
+			if csm.providerOptimizer.IsProviderQualified(address, servicedBlockNumber, "") {
+				qualified = append(qualified, address)
+			}
+		}
+		return qualified
+	}
+
+	t.Run("stability", func(t *testing.T) {
+		chosenProviders := map[string]struct{}{}
+		for key := 0; key < 20; key++ {
+			ctx := ContextWithAffinityKey(context.Background(), "dapp"+strconv.Itoa(key))
+			provider := getProvider(ctx)
+			for i := 0; i < 5; i++ {
+				require.Equal(t, provider, getProvider(ctx))
+			}
+			chosenProviders[provider] = struct{}{}
+		}
+		// keys spread across the pairing
+		require.Greater(t, len(chosenProviders), 1)
+	})
+
+	t.Run("failover", func(t *testing.T) {
+		ctx := ContextWithAffinityKey(context.Background(), "failover-key")
+		provider := getProvider(ctx)
+		// an unwanted provider fails over to the key's next preferred provider, deterministically
+		unwanted := map[string]struct{}{provider: {}}
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, map[string]struct{}{provider: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		var failoverProvider string
+		for providerAddress, cs := range css {
+			failoverProvider = providerAddress
+			require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+		}
+		require.NotEqual(t, provider, failoverProvider)
+		require.Equal(t, chooseAffinityProvider("failover-key", qualifiedAddresses(), unwanted), failoverProvider)
+
+		// a blocked provider isn't chosen until the pairing is updated
+		err = csm.blockProvider(provider, false, firstEpochHeight, 0, 0, nil)
+		require.NoError(t, err)
+		require.Equal(t, failoverProvider, getProvider(ctx))
+	})
+
+	t.Run("no key uses the optimizer", func(t *testing.T) {
+		require.Equal(t, "", AffinityKeyFromContext(context.Background()))
+		require.Equal(t, "", AffinityKeyFromContext(ContextWithAffinityKey(context.Background(), "")))
+		require.NotEmpty(t, getProvider(context.Background()))
+	})
+
+	t.Run("a provider the optimizer disqualifies fails over", func(t *testing.T) {
+		ctx := ContextWithAffinityKey(context.Background(), "qos-key")
+		provider := getProvider(ctx)
+		for i := 0; i < 3; i++ {
+			csm.providerOptimizer.AppendRelayFailure(provider)
+			time.Sleep(5 * time.Millisecond) // let the optimizer's cache apply the failure
+		}
+		failoverProvider := getProvider(ctx)
+		require.NotEqual(t, provider, failoverProvider)
+		require.NotContains(t, qualifiedAddresses(), provider)
+		require.Equal(t, chooseAffinityProvider("qos-key", qualifiedAddresses(), nil), failoverProvider)
+	})
+}
+
+func TestChooseAffinityProvider(t *testing.T) {
+	addresses := []string{"provider0", "provider1", "provider2", "provider3"}
+	chosen := chooseAffinityProvider("key", addresses, nil)
+	require.Contains(t, addresses, chosen)
+	// removing another provider doesn't remap the key
+	for _, address := range addresses {
+		if address == chosen {
+			continue
+		}
+		require.Equal(t, chosen, chooseAffinityProvider("key", addresses, map[string]struct{}{address: {}}))
+	}
+	require.Equal(t, "", chooseAffinityProvider("key", addresses, map[string]struct{}{"provider0": {}, "provider1": {}, "provider2": {}, "provider3": {}}))
+}
@@ -0,0 +1,64 @@
+package lavasession
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const ProviderCuMultipliersFlag = "provider-cu-multipliers"
+
+// CuMultipliers maps provider addresses to the multiplier their relays' compute units are charged with
+type CuMultipliers map[string]float64
+
+// relays to these providers are charged against their local compute units budget with the multiplier, so providers
+// that cost more run out of it sooner and the rest are picked instead. sessions' cu sum and settlement keep the spec's
+// compute units, providers without a multiplier are charged 1.0. multipliers under 1 aren't allowed, they would let
+// the consumer send the provider more than its on chain compute units limit, which the provider rejects
+var ProviderCuMultipliers = CuMultipliers{}
+
+func (cm *CuMultipliers) String() string {
+	entries := make([]string, 0, len(*cm))
+	for provider, multiplier := range *cm {
+		entries = append(entries, provider+"="+strconv.FormatFloat(multiplier, 'f', -1, 64))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// Set parses comma separated provider=multiplier pairs, adding them to the ones already set
+func (cm *CuMultipliers) Set(str string) error {
+	if *cm == nil {
+		*cm = CuMultipliers{}
+	}
+	for _, entry := range strings.Split(str, ",") {
+		if entry == "" {
+			continue
+		}
+		provider, multiplierStr, found := strings.Cut(entry, "=")
+		if !found || provider == "" {
+			return fmt.Errorf("invalid provider cu multiplier: %s, expected provider=multiplier", entry)
+		}
+		multiplier, err := strconv.ParseFloat(multiplierStr, 64)
+		if err != nil || multiplier < 1 || math.IsInf(multiplier, 0) {
+			return fmt.Errorf("invalid provider cu multiplier: %s, expected a number of at least 1", entry)
+		}
+		(*cm)[provider] = multiplier
+	}
+	return nil
+}
+
+func (cm *CuMultipliers) Type() string {
+	return "stringToFloat"
+}
+
+// the compute units a relay of cu is charged against the provider's local budget, rounded up
+func (cswp *ConsumerSessionsWithProvider) budgetCu(cu uint64) uint64 {
+	multiplier, ok := ProviderCuMultipliers[cswp.PublicLavaAddress]
+	if !ok || multiplier == 1 {
+		return cu
+	}
+	return uint64(math.Ceil(float64(cu) * multiplier))
+}
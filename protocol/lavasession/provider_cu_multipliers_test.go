@@ -0,0 +1,66 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCuMultipliersFlag(t *testing.T) {
+	multipliers := CuMultipliers{}
+	require.NoError(t, multipliers.Set("lava@a=1.5,lava@b=1"))
+	require.NoError(t, multipliers.Set("lava@c=2"))
+	require.Equal(t, CuMultipliers{"lava@a": 1.5, "lava@b": 1, "lava@c": 2}, multipliers)
+	require.Equal(t, "lava@a=1.5,lava@b=1,lava@c=2", multipliers.String())
+	// under 1 the consumer could send more than the provider's on chain limit
+	for _, invalid := range []string{"lava@a", "=2", "lava@a=0", "lava@a=0.5", "lava@a=-1", "lava@a=x"} {
+		require.Error(t, multipliers.Set(invalid), invalid)
+	}
+}
+
+func TestProviderCuMultipliers(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0], 1: createPairingList("", true)[1]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+	expensiveProvider := pairingList[0].PublicLavaAddress
+	cheapProvider := pairingList[1].PublicLavaAddress
+	defer func(multipliers CuMultipliers) { ProviderCuMultipliers = multipliers }(ProviderCuMultipliers)
+	ProviderCuMultipliers = CuMultipliers{expensiveProvider: 4}
+
+	// a failed relay gives back what it was charged
+	const cu = uint64(10)
+	css, err := csm.GetSessions(ctx, cu, map[string]struct{}{cheapProvider: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4*cu, pairingList[0].atomicReadUsedComputeUnits())
+	require.NoError(t, csm.OnSessionFailure(css[expensiveProvider].Session, css[expensiveProvider].Session.RelayGeneration(), nil))
+	require.Zero(t, pairingList[0].atomicReadUsedComputeUnits())
+
+	// both have a 200 cu budget, the expensive provider runs out of it after 5 relays
+	picks := map[string]int{}
+	for i := 0; i < 20; i++ {
+		css, err := csm.GetSessions(ctx, cu, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for provider, cs := range css {
+			picks[provider]++
+			require.NoError(t, csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cu, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber, numberOfProviders, numberOfProviders, false))
+		}
+	}
+	require.LessOrEqual(t, picks[expensiveProvider], 5)
+	require.GreaterOrEqual(t, picks[cheapProvider], 15)
+	require.Equal(t, uint64(picks[expensiveProvider])*4*cu, pairingList[0].atomicReadUsedComputeUnits())
+	require.Equal(t, uint64(picks[cheapProvider])*cu, pairingList[1].atomicReadUsedComputeUnits())
+
+	// the sessions' cu sum, which is settled, keeps the spec's compute units
+	pairingList[0].Lock.RLock()
+	defer pairingList[0].Lock.RUnlock()
+	cuSum := uint64(0)
+	for _, session := range pairingList[0].Sessions {
+		cuSum += session.CuSum
+	}
+	require.Equal(t, uint64(picks[expensiveProvider])*cu, cuSum)
+}
@@ -0,0 +1,45 @@
+package lavasession
+
+import (
+	"context"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+type excludedProvidersContextKey struct{}
+
+// ContextWithExcludedProviders makes GetSessions skip the providers for this relay only, e.g. in the context given to
+// SendRelay by a caller that saw a provider fail an earlier relay of the same operation. other relays still use them
+func ContextWithExcludedProviders(ctx context.Context, providers ...string) context.Context {
+	if len(providers) == 0 {
+		return ctx
+	}
+	excluded := make(map[string]struct{}, len(providers))
+	for provider := range ExcludedProvidersFromContext(ctx) {
+		excluded[provider] = struct{}{}
+	}
+	for _, provider := range providers {
+		excluded[provider] = struct{}{}
+	}
+	return context.WithValue(ctx, excludedProvidersContextKey{}, excluded)
+}
+
+func ExcludedProvidersFromContext(ctx context.Context) map[string]struct{} {
+	if ctx == nil {
+		return nil
+	}
+	excluded, _ := ctx.Value(excludedProvidersContextKey{}).(map[string]struct{})
+	return excluded
+}
+
+// validateExcludedProviders fails a relay whose caller excluded every provider that can serve it
+func (csm *ConsumerSessionManager) validateExcludedProviders(excluded map[string]struct{}, addon string, extensions []string) error {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	for _, address := range csm.getValidAddresses(addon, extensions) {
+		if _, ok := excluded[address]; !ok {
+			return nil
+		}
+	}
+	return sdkerrors.Wrapf(NoCapableProviderError, "every provider was excluded by the caller, excluded: %d, addon: %s, extensions: %v", len(excluded), addon, extensions)
+}
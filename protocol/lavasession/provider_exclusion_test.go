@@ -0,0 +1,52 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcludedProviders(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond) // let probes finish
+	allProviders := make([]string, 0, len(pairingList))
+	for _, cswp := range pairingList {
+		allProviders = append(allProviders, cswp.PublicLavaAddress)
+	}
+
+	// every provider but the last two is excluded, selection is narrowed to those two
+	excludedCtx := ContextWithExcludedProviders(ctx, allProviders[:len(allProviders)-2]...)
+	remaining := map[string]struct{}{allProviders[len(allProviders)-1]: {}, allProviders[len(allProviders)-2]: {}}
+	for i := 0; i < 20; i++ {
+		css, err := csm.GetSessions(excludedCtx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for providerAddress, cs := range css {
+			require.Contains(t, remaining, providerAddress)
+			require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+		}
+	}
+
+	// exclusions accumulate, and only apply to the relay using the context
+	excludedCtx = ContextWithExcludedProviders(excludedCtx, allProviders[len(allProviders)-2])
+	for i := 0; i < 5; i++ {
+		css, err := csm.GetSessions(excludedCtx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for providerAddress, cs := range css {
+			require.Equal(t, allProviders[len(allProviders)-1], providerAddress)
+			require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+		}
+	}
+	require.Len(t, ExcludedProvidersFromContext(ctx), 0)
+	require.Equal(t, len(allProviders), csm.UsableProvidersCount())
+
+	// excluding every provider fails the relay instead of ignoring the exclusion
+	_, err = csm.GetSessions(ContextWithExcludedProviders(ctx, allProviders...), cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.True(t, NoCapableProviderError.Is(err), err)
+}
@@ -0,0 +1,104 @@
+package lavasession
+
+import (
+	"net"
+	"strings"
+)
+
+// hostOf returns the host of a host:port address, or the address itself when it has no port
+func hostOf(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	return strings.ToLower(host)
+}
+
+// RecordRemoteHost records the address a relay to the provider actually reached, endpoints registered under different
+// names that resolve to the same machine are identified by it
+func (cswp *ConsumerSessionsWithProvider) RecordRemoteHost(remoteAddress string) {
+	host := hostOf(remoteAddress)
+	if host == "" {
+		return
+	}
+	cswp.Lock.Lock()
+	defer cswp.Lock.Unlock()
+	if cswp.remoteHosts == nil {
+		cswp.remoteHosts = map[string]struct{}{}
+	}
+	cswp.remoteHosts[host] = struct{}{}
+}
+
+// hosts returns the hosts of the provider's endpoints and the ones relays reached
+func (cswp *ConsumerSessionsWithProvider) hosts() map[string]struct{} {
+	cswp.Lock.RLock()
+	defer cswp.Lock.RUnlock()
+	hosts := make(map[string]struct{}, len(cswp.Endpoints)+len(cswp.remoteHosts))
+	for _, endpoint := range cswp.Endpoints {
+		if host := hostOf(endpoint.NetworkAddress); host != "" {
+			hosts[host] = struct{}{}
+		}
+	}
+	for host := range cswp.remoteHosts {
+		hosts[host] = struct{}{}
+	}
+	return hosts
+}
+
+// ColocatedProviders returns the other paired providers sharing a host with providerAddress, they are likely the
+// same operator serving several provider addresses from one node
+func (csm *ConsumerSessionManager) ColocatedProviders(providerAddress string) map[string]struct{} {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	colocated := map[string]struct{}{}
+	provider, ok := csm.pairing[providerAddress]
+	if !ok {
+		return colocated
+	}
+	providerHosts := provider.hosts()
+	for address, other := range csm.pairing {
+		if address == providerAddress {
+			continue
+		}
+		for host := range other.hosts() {
+			if _, ok := providerHosts[host]; ok {
+				colocated[address] = struct{}{}
+				break
+			}
+		}
+	}
+	return colocated
+}
+
+// ReliabilityCandidates returns the usable providers supporting the addon and extensions that aren't unwanted, with
+// the number of other paired providers each one shares a host with
+func (csm *ConsumerSessionManager) ReliabilityCandidates(unwanted map[string]struct{}, addon string, extensions []string) map[string]int {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	providersByHost := map[string][]string{}
+	for address, provider := range csm.pairing {
+		for host := range provider.hosts() {
+			providersByHost[host] = append(providersByHost[host], address)
+		}
+	}
+	candidates := map[string]int{}
+	for _, address := range csm.getValidAddresses(addon, extensions) {
+		if _, ok := unwanted[address]; ok {
+			continue
+		}
+		provider, ok := csm.pairing[address]
+		if !ok {
+			continue
+		}
+		colocated := map[string]struct{}{}
+		for host := range provider.hosts() {
+			for _, other := range providersByHost[host] {
+				if other != address {
+					colocated[other] = struct{}{}
+				}
+			}
+		}
+		candidates[address] = len(colocated)
+	}
+	return candidates
+}
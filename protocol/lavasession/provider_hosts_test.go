@@ -0,0 +1,37 @@
+package lavasession
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColocatedProviders(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	provider := func(address string, networkAddresses ...string) *ConsumerSessionsWithProvider {
+		endpoints := make([]*Endpoint, len(networkAddresses))
+		for idx, networkAddress := range networkAddresses {
+			endpoints[idx] = &Endpoint{NetworkAddress: networkAddress, Enabled: true}
+		}
+		return NewConsumerSessionWithProvider(address, endpoints, 200, firstEpochHeight, sdk.NewInt64Coin("ulava", 100))
+	}
+	csm.pairing = map[string]*ConsumerSessionsWithProvider{
+		"primary":     provider("primary", "node.operator.com:2221"),
+		"samePort":    provider("samePort", "NODE.operator.com:2222"),
+		"aliased":     provider("aliased", "other-name.operator.com:2221"),
+		"independent": provider("independent", "elsewhere.com:2221", "backup.elsewhere.com:443"),
+	}
+
+	// the same host on another port is the same node
+	require.Equal(t, map[string]struct{}{"samePort": {}}, csm.ColocatedProviders("primary"))
+
+	// another name is only identified once relays reached the same address
+	csm.pairing["primary"].RecordRemoteHost("10.0.0.1:2221")
+	csm.pairing["aliased"].RecordRemoteHost("10.0.0.1:2221")
+	csm.pairing["independent"].RecordRemoteHost("10.0.0.2:2221")
+	require.Equal(t, map[string]struct{}{"samePort": {}, "aliased": {}}, csm.ColocatedProviders("primary"))
+	require.Equal(t, map[string]struct{}{"primary": {}}, csm.ColocatedProviders("aliased"))
+	require.Empty(t, csm.ColocatedProviders("independent"))
+	require.Empty(t, csm.ColocatedProviders("unknown"))
+}
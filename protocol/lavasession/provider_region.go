@@ -0,0 +1,97 @@
+package lavasession
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lavanet/lava/x/pairing/keeper/scores"
+	planstypes "github.com/lavanet/lava/x/plans/types"
+)
+
+const ProviderRegionPreferenceFlag = "provider-region-preference"
+
+// RegionPreference selects which providers are preferred by their endpoints' geolocation relative to the consumer's
+type RegionPreference string
+
+const (
+	RegionPreferenceDisabled RegionPreference = ""
+	RegionPreferenceSame     RegionPreference = "same"    // providers with an endpoint in the consumer's geolocation
+	RegionPreferenceNearest  RegionPreference = "nearest" // providers with the lowest geo latency to the consumer's geolocation
+)
+
+// providers in the preferred region are chosen by the optimizer first, the rest are only used when none of the
+// regional ones can be. gives a good first guess before the optimizer has latency data
+var ProviderRegionPreference = RegionPreferenceDisabled
+
+func (rp *RegionPreference) String() string {
+	return string(*rp)
+}
+
+func (rp *RegionPreference) Set(str string) error {
+	switch RegionPreference(str) {
+	case RegionPreferenceDisabled, RegionPreferenceSame, RegionPreferenceNearest:
+		*rp = RegionPreference(str)
+		return nil
+	}
+	return fmt.Errorf("invalid region preference: %s, expected %s or %s", str, RegionPreferenceSame, RegionPreferenceNearest)
+}
+
+func (rp *RegionPreference) Type() string {
+	return "string"
+}
+
+// geoLatency returns the lowest geo latency from the consumer's geolocation to the provider's enabled endpoints
+func (cswp *ConsumerSessionsWithProvider) geoLatency(consumerGeo planstypes.Geolocation) uint64 {
+	cswp.Lock.RLock()
+	defer cswp.Lock.RUnlock()
+	lowest := uint64(math.MaxUint64)
+	for _, endpoint := range cswp.Endpoints {
+		if !endpoint.Enabled {
+			continue
+		}
+		if _, latency := scores.CalcGeoLatency(consumerGeo, []planstypes.Geolocation{endpoint.Geolocation}); latency < lowest {
+			lowest = latency
+		}
+	}
+	return lowest
+}
+
+// filterRegionalProviders returns the providers in the preferred region if any of them can still be chosen, otherwise
+// all of them so selection falls back to qos alone. csm.lock must be rlocked
+func (csm *ConsumerSessionManager) filterRegionalProviders(addresses []string, ignoredProviders map[string]struct{}) []string {
+	if ProviderRegionPreference == RegionPreferenceDisabled || csm.rpcEndpoint == nil {
+		return addresses
+	}
+	consumerGeo := planstypes.Geolocation(csm.rpcEndpoint.Geolocation)
+	latencies := make(map[string]uint64, len(addresses))
+	lowest := uint64(math.MaxUint64)
+	for _, address := range addresses {
+		if _, ignored := ignoredProviders[address]; ignored {
+			continue
+		}
+		provider, ok := csm.pairing[address]
+		if !ok {
+			continue
+		}
+		latency := provider.geoLatency(consumerGeo)
+		latencies[address] = latency
+		if latency < lowest {
+			lowest = latency
+		}
+	}
+	threshold := lowest
+	if ProviderRegionPreference == RegionPreferenceSame {
+		// the geo latency within the same geolocation
+		_, threshold = scores.CalcGeoLatency(consumerGeo, []planstypes.Geolocation{consumerGeo})
+	}
+	regional := make([]string, 0, len(latencies))
+	for _, address := range addresses {
+		if latency, ok := latencies[address]; ok && latency <= threshold {
+			regional = append(regional, address)
+		}
+	}
+	if len(regional) == 0 {
+		return addresses
+	}
+	return regional
+}
@@ -0,0 +1,81 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/common"
+	planstypes "github.com/lavanet/lava/x/plans/types"
+	"github.com/stretchr/testify/require"
+)
+
+// createRegionalPairingList returns a pairing where the first regionalProviders providers are located in EU and the rest in AS
+func createRegionalPairingList(regionalProviders int) map[uint64]*ConsumerSessionsWithProvider {
+	pairingList := createPairingList("", true)
+	for idx, cswp := range pairingList {
+		geolocation := planstypes.Geolocation_AS
+		if idx < uint64(regionalProviders) {
+			geolocation = planstypes.Geolocation_EU
+		}
+		cswp.Endpoints = []*Endpoint{{NetworkAddress: grpcListener, Enabled: true, Geolocation: geolocation}}
+	}
+	return pairingList
+}
+
+func TestRegionalProviderSelection(t *testing.T) {
+	defer func(preference RegionPreference) { ProviderRegionPreference = preference }(ProviderRegionPreference)
+	for _, preference := range []RegionPreference{RegionPreferenceSame, RegionPreferenceNearest} {
+		t.Run(string(preference), func(t *testing.T) {
+			ProviderRegionPreference = preference
+			csm := CreateConsumerSessionManager()
+			csm.rpcEndpoint.Geolocation = uint64(planstypes.Geolocation_EU)
+			pairingList := createRegionalPairingList(2)
+			err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+			require.NoError(t, err)
+			regional := map[string]struct{}{pairingList[0].PublicLavaAddress: {}, pairingList[1].PublicLavaAddress: {}}
+			for i := 0; i < 10; i++ {
+				css, err := csm.GetSessions(context.Background(), cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+				require.NoError(t, err)
+				for providerAddress, cs := range css {
+					require.Contains(t, regional, providerAddress)
+					require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+				}
+			}
+		})
+	}
+}
+
+func TestRegionalProviderSelectionFallback(t *testing.T) {
+	defer func(preference RegionPreference) { ProviderRegionPreference = preference }(ProviderRegionPreference)
+	ProviderRegionPreference = RegionPreferenceSame
+	csm := CreateConsumerSessionManager()
+	csm.rpcEndpoint.Geolocation = uint64(planstypes.Geolocation_EU)
+
+	t.Run("no regional providers", func(t *testing.T) {
+		err := csm.UpdateAllProviders(firstEpochHeight, createRegionalPairingList(0))
+		require.NoError(t, err)
+		csm.lock.RLock()
+		defer csm.lock.RUnlock()
+		require.Len(t, csm.filterRegionalProviders(csm.validAddresses, nil), len(csm.validAddresses))
+	})
+
+	t.Run("regional providers ignored", func(t *testing.T) {
+		pairingList := createRegionalPairingList(1)
+		err := csm.UpdateAllProviders(firstEpochHeight+1, pairingList)
+		require.NoError(t, err)
+		csm.lock.RLock()
+		defer csm.lock.RUnlock()
+		require.Equal(t, []string{pairingList[0].PublicLavaAddress}, csm.filterRegionalProviders(csm.validAddresses, nil))
+		ignored := map[string]struct{}{pairingList[0].PublicLavaAddress: {}}
+		require.Len(t, csm.filterRegionalProviders(csm.validAddresses, ignored), len(csm.validAddresses))
+	})
+}
+
+func TestRegionPreferenceSet(t *testing.T) {
+	var preference RegionPreference
+	require.NoError(t, preference.Set("nearest"))
+	require.Equal(t, RegionPreferenceNearest, preference)
+	require.NoError(t, preference.Set(""))
+	require.Equal(t, RegionPreferenceDisabled, preference)
+	require.Error(t, preference.Set("closest"))
+}
@@ -58,6 +58,7 @@ func (psm *ProviderSessionManager) getSingleSessionFromProviderSessionWithConsum
 	if singleProviderSession.RelayNum+1 > relayNumber { // validate relay number here, but add only in PrepareSessionForUsage
 		// unlock the session since we are returning an error
 		defer singleProviderSession.lock.Unlock()
+		SetSessionSyncTrailer(ctx, singleProviderSession.CuSum, singleProviderSession.RelayNum)
 		return nil, utils.LavaFormatError("singleProviderSession.RelayNum mismatch, session out of sync", SessionOutOfSyncError, utils.LogAttr("GUID", ctx), utils.LogAttr("errCount", singleProviderSession.errorsCount), utils.LogAttr("sessionID", singleProviderSession.SessionID), utils.Attribute{Key: "singleProviderSession.RelayNum", Value: singleProviderSession.RelayNum + 1}, utils.Attribute{Key: "request.relayNumber", Value: relayNumber})
 	}
 	// singleProviderSession is locked at this point.
@@ -242,6 +243,11 @@ func (psm *ProviderSessionManager) OnSessionDone(singleProviderSession *SinglePr
 	return singleProviderSession.onSessionDone(relayNumber)
 }
 
+// OnSimulatedSessionDone unlocks the session after a simulated relay, the relay isn't settled so its cu is reverted
+func (psm *ProviderSessionManager) OnSimulatedSessionDone(singleProviderSession *SingleProviderSession) (err error) {
+	return singleProviderSession.onSimulatedSessionDone()
+}
+
 func (psm *ProviderSessionManager) RPCProviderEndpoint() *RPCProviderEndpoint {
 	return psm.rpcProviderEndpoint
 }
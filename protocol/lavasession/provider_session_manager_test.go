@@ -436,6 +436,23 @@ func TestPSMOnSessionFailure(t *testing.T) {
 	require.Equal(t, sps.PairingEpoch, epoch1)
 }
 
+func TestPSMOnSimulatedSessionDone(t *testing.T) {
+	psm, sps := prepareSession(t, context.Background())
+
+	err := psm.OnSimulatedSessionDone(sps)
+	require.NoError(t, err)
+	require.Zero(t, sps.LatestRelayCu)
+	require.Zero(t, sps.CuSum)
+	require.Equal(t, relayNumberBeforeUse, sps.RelayNum)
+	require.Zero(t, sps.errorsCount) // not a failure
+
+	// the next relay starts from the same cu sum
+	_, err = psm.GetSession(context.Background(), consumerOneAddress, epoch1, sessionId, relayNumber, nil)
+	require.NoError(t, err)
+	err = sps.PrepareSessionForUsage(context.Background(), relayCu, relayCu, 0, 0)
+	require.NoError(t, err)
+}
+
 func TestPSMUpdateCu(t *testing.T) {
 	// init test
 	psm, sps := prepareSession(t, context.Background())
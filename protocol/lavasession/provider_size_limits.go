@@ -0,0 +1,78 @@
+package lavasession
+
+import (
+	"context"
+	"strconv"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+type relaySizesContextKey struct{}
+
+type relaySizes struct {
+	request  uint64
+	response uint64
+}
+
+// ContextWithRelaySizes makes GetSessions skip providers whose advertised limits can't accept the relay, sizes are in
+// bytes and 0 is unknown. the response size is a hint from callers expecting a large reply
+func ContextWithRelaySizes(ctx context.Context, requestSize uint64, responseSize uint64) context.Context {
+	if requestSize == 0 && responseSize == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, relaySizesContextKey{}, relaySizes{request: requestSize, response: responseSize})
+}
+
+func RelaySizesFromContext(ctx context.Context) (requestSize uint64, responseSize uint64) {
+	if ctx == nil {
+		return 0, 0
+	}
+	sizes, _ := ctx.Value(relaySizesContextKey{}).(relaySizes)
+	return sizes.request, sizes.response
+}
+
+// maxSizeFromTrailer parses a size limit a provider advertised in its probe trailer, a missing or invalid one is unlimited
+func maxSizeFromTrailer(values []string) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+	size, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// SetMaxSizes records the largest request and response the provider advertised it accepts, 0 is unlimited
+func (cswp *ConsumerSessionsWithProvider) SetMaxSizes(maxRequestSize uint64, maxResponseSize uint64) {
+	cswp.Lock.Lock()
+	defer cswp.Lock.Unlock()
+	cswp.maxRequestSize = maxRequestSize
+	cswp.maxResponseSize = maxResponseSize
+}
+
+func (cswp *ConsumerSessionsWithProvider) IsAcceptingSizes(requestSize uint64, responseSize uint64) bool {
+	cswp.Lock.RLock()
+	defer cswp.Lock.RUnlock()
+	if cswp.maxRequestSize != 0 && requestSize > cswp.maxRequestSize {
+		return false
+	}
+	return cswp.maxResponseSize == 0 || responseSize <= cswp.maxResponseSize
+}
+
+// providersRejectingSizes returns the providers whose limits can't accept the relay, and fails it when no provider can
+func (csm *ConsumerSessionManager) providersRejectingSizes(requestSize uint64, responseSize uint64, addon string, extensions []string) (map[string]struct{}, error) {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	validAddresses := csm.getValidAddresses(addon, extensions)
+	rejecting := map[string]struct{}{}
+	for _, address := range validAddresses {
+		if providerEntry, ok := csm.pairing[address]; ok && !providerEntry.IsAcceptingSizes(requestSize, responseSize) {
+			rejecting[address] = struct{}{}
+		}
+	}
+	if len(validAddresses) > 0 && len(rejecting) == len(validAddresses) {
+		return nil, sdkerrors.Wrapf(RelayExceedsProviderLimitsError, "request size: %d, response size: %d, providers: %d", requestSize, responseSize, len(validAddresses))
+	}
+	return rejecting, nil
+}
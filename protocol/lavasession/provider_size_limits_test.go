@@ -0,0 +1,72 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderSizeLimits(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	// providers 0 and 1 accept large requests, 2 accepts large responses, the rest accept small relays only
+	largeRequestProviders := map[string]struct{}{}
+	for idx, cswp := range pairingList {
+		switch idx {
+		case 0, 1:
+			cswp.SetMaxSizes(0, 1000)
+			largeRequestProviders[cswp.PublicLavaAddress] = struct{}{}
+		case 2:
+			cswp.SetMaxSizes(1000, 0)
+		default:
+			cswp.SetMaxSizes(1000, 1000)
+		}
+	}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond) // let probes finish
+
+	// a large request is only routed to providers accepting it
+	largeRequestCtx := ContextWithRelaySizes(ctx, 5000, 0)
+	for i := 0; i < 20; i++ {
+		css, err := csm.GetSessions(largeRequestCtx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for providerAddress, cs := range css {
+			require.Contains(t, largeRequestProviders, providerAddress)
+			require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+		}
+	}
+
+	// an expected large response narrows selection the same way
+	for i := 0; i < 5; i++ {
+		css, err := csm.GetSessions(ContextWithRelaySizes(ctx, 100, 5000), cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		for providerAddress, cs := range css {
+			require.Equal(t, pairingList[2].PublicLavaAddress, providerAddress)
+			require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+		}
+	}
+
+	// providers with limits still serve relays within them
+	limitedProvider := pairingList[3].PublicLavaAddress
+	excluded := []string{}
+	for _, cswp := range pairingList {
+		if cswp.PublicLavaAddress != limitedProvider {
+			excluded = append(excluded, cswp.PublicLavaAddress)
+		}
+	}
+	css, err := csm.GetSessions(ContextWithExcludedProviders(ContextWithRelaySizes(ctx, 100, 100), excluded...), cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	for providerAddress, cs := range css {
+		require.Equal(t, limitedProvider, providerAddress)
+		require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+	}
+
+	// no provider accepts both, the relay fails before taking a session
+	_, err = csm.GetSessions(ContextWithRelaySizes(ctx, 5000, 5000), cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.True(t, RelayExceedsProviderLimitsError.Is(err), err)
+}
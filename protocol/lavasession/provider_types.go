@@ -31,6 +31,13 @@ type RPCProviderEndpoint struct {
 	ApiInterface   string             `yaml:"api-interface,omitempty" json:"api-interface,omitempty" mapstructure:"api-interface"`
 	Geolocation    uint64             `yaml:"geolocation,omitempty" json:"geolocation,omitempty" mapstructure:"geolocation"`
 	NodeUrls       []common.NodeUrl   `yaml:"node-urls,omitempty" json:"node-urls,omitempty" mapstructure:"node-urls"`
+	// client method name -> canonical spec method name, must match the aliases consumers relay so their requests resolve
+	MethodAliases map[string]string `yaml:"method-aliases,omitempty" json:"method-aliases,omitempty" mapstructure:"method-aliases"`
+	// the spec apis the node serves, advertised to consumers on probes, empty serves the whole spec
+	SupportedApis []string `yaml:"supported-apis,omitempty" json:"supported-apis,omitempty" mapstructure:"supported-apis"`
+	// the largest request and response in bytes the node accepts, advertised to consumers on probes, 0 is unlimited
+	MaxRequestSize  uint64 `yaml:"max-request-size,omitempty" json:"max-request-size,omitempty" mapstructure:"max-request-size"`
+	MaxResponseSize uint64 `yaml:"max-response-size,omitempty" json:"max-response-size,omitempty" mapstructure:"max-response-size"`
 }
 
 func (endpoint *RPCProviderEndpoint) UrlsString() string {
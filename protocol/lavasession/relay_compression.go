@@ -0,0 +1,38 @@
+package lavasession
+
+import (
+	"sync/atomic"
+
+	"github.com/lavanet/lava/protocol/common"
+	"google.golang.org/grpc"
+)
+
+const RelayCompressionFlag = "relay-grpc-compression"
+
+// relays are sent compressed with grpc's per call compression and providers reply compressed, signatures are over the
+// uncompressed bytes. providers that can't decompress get uncompressed relays
+var RelayCompression = false
+
+// RelayCallOptions returns the call options compressing relays to the endpoint, none when compression is disabled or
+// the provider doesn't support it
+func (e *Endpoint) RelayCallOptions() []grpc.CallOption {
+	if !e.compressesRelays() {
+		return nil
+	}
+	return []grpc.CallOption{grpc.UseCompressor(common.GrpcCompressor)}
+}
+
+func (e *Endpoint) compressesRelays() bool {
+	return RelayCompression && atomic.LoadUint32(&e.compressionUnsupported) == 0
+}
+
+// FallbackFromCompression stops compressing relays to the endpoint if err shows the provider can't decompress them,
+// returns true when the relay should be sent again uncompressed
+func (e *Endpoint) FallbackFromCompression(err error) bool {
+	if !e.compressesRelays() || !common.IsGrpcCompressionUnsupported(err) {
+		return false
+	}
+	// the endpoint is dropped with its pairing, so support is checked again on the next epoch
+	atomic.StoreUint32(&e.compressionUnsupported, 1)
+	return true
+}
@@ -0,0 +1,33 @@
+package lavasession
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// RelayDialer establishes the grpc connection used for relays to a provider endpoint
+type RelayDialer func(ctx context.Context, address string, allowInsecure bool) (*grpc.ClientConn, error)
+
+var (
+	relayDialerLock sync.RWMutex
+	relayDialer     RelayDialer = ConnectgRPCClient
+)
+
+// SetRelayDialer replaces how connections to providers are dialed, e.g. to reach providers served in process. dialers
+// should add FirstByteDialOption to their options. nil restores ConnectgRPCClient
+func SetRelayDialer(dialer RelayDialer) {
+	relayDialerLock.Lock()
+	defer relayDialerLock.Unlock()
+	if dialer == nil {
+		dialer = ConnectgRPCClient
+	}
+	relayDialer = dialer
+}
+
+func getRelayDialer() RelayDialer {
+	relayDialerLock.RLock()
+	defer relayDialerLock.RUnlock()
+	return relayDialer
+}
@@ -0,0 +1,33 @@
+package lavasession
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestSetRelayDialer(t *testing.T) {
+	AllowInsecureConnectionToProviders = true
+	defer SetRelayDialer(nil)
+	ctx := context.Background()
+	cswp := &ConsumerSessionsWithProvider{PublicLavaAddress: providerStr, Sessions: map[int64]*SingleConsumerSession{}}
+
+	dials := 0
+	SetRelayDialer(func(ctx context.Context, address string, allowInsecure bool) (*grpc.ClientConn, error) {
+		dials++
+		return nil, fmt.Errorf("unreachable")
+	})
+	_, _, err := cswp.ConnectRawClientWithTimeout(ctx, grpcListener)
+	require.Error(t, err)
+	require.Equal(t, 1, dials)
+
+	// nil restores the default dialer
+	SetRelayDialer(nil)
+	_, conn, err := cswp.ConnectRawClientWithTimeout(ctx, grpcListener)
+	require.NoError(t, err)
+	conn.Close()
+	require.Equal(t, 1, dials)
+}
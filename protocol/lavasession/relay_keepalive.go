@@ -0,0 +1,50 @@
+package lavasession
+
+import (
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	RelayKeepaliveIntervalFlag    = "relay-keepalive-interval"
+	RelayKeepaliveTimeoutFlag     = "relay-keepalive-timeout"
+	RelayMaxIdleFlag              = "relay-max-idle"
+	RelayKeepaliveMinIntervalFlag = "relay-keepalive-min-interval"
+
+	// off by default, providers that don't accept the pings yet close the connection with too_many_pings
+	DefaultRelayKeepaliveInterval time.Duration = 0
+	DefaultRelayKeepaliveTimeout                = 20 * time.Second
+	// providers accept pings this often, it must stay under the consumers' interval or the connection is closed with too_many_pings
+	DefaultRelayKeepaliveMinInterval = 30 * time.Second
+)
+
+// the consumer keeps a single connection per provider endpoint, so these apply to each endpoint connection. the
+// provider's nConns pool of node connections is configured by chainproxy.NodeKeepalive, through the proxy constructors
+var (
+	// ping idle connections to providers this often so intermediaries don't silently drop them, 0 disables the pings
+	RelayKeepaliveInterval = DefaultRelayKeepaliveInterval
+	// a connection not answering a ping within this duration is closed and redialed on the next relay
+	RelayKeepaliveTimeout = DefaultRelayKeepaliveTimeout
+	// connections without relays for this long go idle and reconnect lazily, 0 keeps grpc's default
+	RelayMaxIdle time.Duration = 0
+	// provider side, the minimal ping interval accepted from consumers
+	RelayKeepaliveMinInterval = DefaultRelayKeepaliveMinInterval
+)
+
+func relayClientKeepaliveOptions() []grpc.DialOption {
+	return chainproxy.KeepaliveOptions{Interval: RelayKeepaliveInterval, Timeout: RelayKeepaliveTimeout, MaxIdle: RelayMaxIdle}.GrpcDialOptions()
+}
+
+// RelayServerKeepaliveOptions lets consumers keep idle relay connections warm, grpc's default policy closes connections
+// pinged more than once per 5 minutes or while no relay is in flight
+func RelayServerKeepaliveOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             RelayKeepaliveMinInterval,
+			PermitWithoutStream: true,
+		}),
+	}
+}
@@ -0,0 +1,30 @@
+package lavasession
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayClientKeepaliveOptions(t *testing.T) {
+	defer func(interval, timeout, maxIdle time.Duration) {
+		RelayKeepaliveInterval = interval
+		RelayKeepaliveTimeout = timeout
+		RelayMaxIdle = maxIdle
+	}(RelayKeepaliveInterval, RelayKeepaliveTimeout, RelayMaxIdle)
+
+	// pings are off by default so providers that don't accept them yet don't close the connections
+	RelayKeepaliveInterval = DefaultRelayKeepaliveInterval
+	require.Empty(t, relayClientKeepaliveOptions())
+
+	RelayKeepaliveInterval = time.Minute
+	require.Len(t, relayClientKeepaliveOptions(), 1)
+
+	RelayMaxIdle = 10 * time.Minute
+	require.Len(t, relayClientKeepaliveOptions(), 2)
+
+	RelayKeepaliveInterval = 0
+	RelayMaxIdle = 0
+	require.Empty(t, relayClientKeepaliveOptions())
+}
@@ -0,0 +1,24 @@
+package lavasession
+
+import (
+	"context"
+)
+
+type relayMethodContextKey struct{}
+
+// ContextWithRelayMethod lets GetSessions choose providers by their qos for the method, when method level qos tracking
+// is enabled in the optimizer
+func ContextWithRelayMethod(ctx context.Context, method string) context.Context {
+	if method == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, relayMethodContextKey{}, method)
+}
+
+func RelayMethodFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	method, _ := ctx.Value(relayMethodContextKey{}).(string)
+	return method
+}
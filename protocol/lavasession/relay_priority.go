@@ -0,0 +1,162 @@
+package lavasession
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	sdkerrors "cosmossdk.io/errors"
+)
+
+type RelayPriority int
+
+const (
+	RelayPriorityLow RelayPriority = iota
+	RelayPriorityNormal
+	RelayPriorityHigh
+	numberOfRelayPriorities
+)
+
+const DefaultRelayPriority = RelayPriorityNormal
+
+func (rp RelayPriority) String() string {
+	switch rp {
+	case RelayPriorityLow:
+		return "low"
+	case RelayPriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+func ParseRelayPriority(priority string) (RelayPriority, error) {
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case "low":
+		return RelayPriorityLow, nil
+	case "normal":
+		return RelayPriorityNormal, nil
+	case "high":
+		return RelayPriorityHigh, nil
+	}
+	return DefaultRelayPriority, fmt.Errorf("invalid relay priority: %s, expected low, normal or high", priority)
+}
+
+type relayPriorityContextKey struct{}
+
+// ContextWithRelayPriority sets the priority the relay is admitted with when the consumer is at its concurrent relays limit
+func ContextWithRelayPriority(ctx context.Context, priority RelayPriority) context.Context {
+	return context.WithValue(ctx, relayPriorityContextKey{}, priority)
+}
+
+func RelayPriorityFromContext(ctx context.Context) RelayPriority {
+	if ctx == nil {
+		return DefaultRelayPriority
+	}
+	priority, ok := ctx.Value(relayPriorityContextKey{}).(RelayPriority)
+	if !ok || priority < RelayPriorityLow || priority >= numberOfRelayPriorities {
+		return DefaultRelayPriority
+	}
+	return priority
+}
+
+const MaxConcurrentRelaysFlag = "max-concurrent-relays"
+
+// relays above this many in flight wait for a slot, higher priority relays are admitted first. 0 means no limit
+var MaxConcurrentRelays uint64 = 0
+
+type relayWaiter struct {
+	admitted chan struct{}
+}
+
+// relayScheduler admits up to capacity concurrent relays, a freed slot is handed to the highest priority waiter,
+// in arrival order within a priority
+type relayScheduler struct {
+	lock     sync.Mutex
+	capacity int
+	inFlight int
+	waiting  [numberOfRelayPriorities][]*relayWaiter
+}
+
+func newRelayScheduler(capacity uint64) *relayScheduler {
+	return &relayScheduler{capacity: int(capacity)}
+}
+
+func (rs *relayScheduler) enabled() bool {
+	return rs != nil && rs.capacity > 0
+}
+
+// Acquire blocks until the relay is admitted or the context is done, release must be called when the relay is done
+func (rs *relayScheduler) Acquire(ctx context.Context, priority RelayPriority) (release func(), err error) {
+	if !rs.enabled() {
+		return func() {}, nil
+	}
+	rs.lock.Lock()
+	if rs.inFlight < rs.capacity && !rs.hasWaitersFrom(priority) {
+		rs.inFlight++
+		rs.lock.Unlock()
+		return rs.releaseFunc(), nil
+	}
+	waiter := &relayWaiter{admitted: make(chan struct{})}
+	rs.waiting[priority] = append(rs.waiting[priority], waiter)
+	rs.lock.Unlock()
+
+	select {
+	case <-waiter.admitted:
+		return rs.releaseFunc(), nil
+	case <-ctx.Done():
+	}
+	rs.lock.Lock()
+	select {
+	case <-waiter.admitted:
+		// admitted while giving up, pass the slot on
+		rs.lock.Unlock()
+		rs.releaseFunc()()
+	default:
+		rs.removeWaiter(priority, waiter)
+		rs.lock.Unlock()
+	}
+	return nil, sdkerrors.Wrapf(RelayAdmissionTimeoutError, "priority: %s, max concurrent relays: %d, %s", priority, rs.capacity, ctx.Err())
+}
+
+// rs.lock must be locked
+func (rs *relayScheduler) hasWaitersFrom(priority RelayPriority) bool {
+	for waitingPriority := priority; waitingPriority < numberOfRelayPriorities; waitingPriority++ {
+		if len(rs.waiting[waitingPriority]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rs.lock must be locked
+func (rs *relayScheduler) removeWaiter(priority RelayPriority, waiter *relayWaiter) {
+	for idx, queued := range rs.waiting[priority] {
+		if queued == waiter {
+			rs.waiting[priority] = append(rs.waiting[priority][:idx], rs.waiting[priority][idx+1:]...)
+			return
+		}
+	}
+}
+
+func (rs *relayScheduler) releaseFunc() func() {
+	once := sync.Once{}
+	return func() {
+		once.Do(rs.release)
+	}
+}
+
+func (rs *relayScheduler) release() {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	for priority := numberOfRelayPriorities - 1; priority >= RelayPriorityLow; priority-- {
+		if len(rs.waiting[priority]) > 0 {
+			// the slot moves to the waiter as is
+			waiter := rs.waiting[priority][0]
+			rs.waiting[priority] = rs.waiting[priority][1:]
+			close(waiter.admitted)
+			return
+		}
+	}
+	rs.inFlight--
+}
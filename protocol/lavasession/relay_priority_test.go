@@ -0,0 +1,81 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelaySchedulerPriority(t *testing.T) {
+	scheduler := newRelayScheduler(1)
+	release, err := scheduler.Acquire(context.Background(), RelayPriorityNormal)
+	require.NoError(t, err)
+
+	admitted := make(chan RelayPriority, 2)
+	acquire := func(priority RelayPriority) {
+		release, err := scheduler.Acquire(context.Background(), priority)
+		require.NoError(t, err)
+		admitted <- priority
+		release()
+	}
+	go acquire(RelayPriorityLow)
+	require.Eventually(t, func() bool {
+		scheduler.lock.Lock()
+		defer scheduler.lock.Unlock()
+		return len(scheduler.waiting[RelayPriorityLow]) == 1
+	}, time.Second, time.Millisecond)
+	go acquire(RelayPriorityHigh)
+	require.Eventually(t, func() bool {
+		scheduler.lock.Lock()
+		defer scheduler.lock.Unlock()
+		return len(scheduler.waiting[RelayPriorityHigh]) == 1
+	}, time.Second, time.Millisecond)
+
+	// the high priority relay arrived last but jumps ahead
+	release()
+	release() // releasing twice has no effect
+	require.Equal(t, RelayPriorityHigh, <-admitted)
+	require.Equal(t, RelayPriorityLow, <-admitted)
+	require.Eventually(t, func() bool {
+		scheduler.lock.Lock()
+		defer scheduler.lock.Unlock()
+		return scheduler.inFlight == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestRelaySchedulerDeadline(t *testing.T) {
+	scheduler := newRelayScheduler(1)
+	release, err := scheduler.Acquire(context.Background(), RelayPriorityHigh)
+	require.NoError(t, err)
+
+	// a waiting relay gives up at its deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = scheduler.Acquire(ctx, RelayPriorityLow)
+	require.True(t, RelayAdmissionTimeoutError.Is(err))
+	require.Empty(t, scheduler.waiting[RelayPriorityLow])
+
+	release()
+	release, err = scheduler.Acquire(context.Background(), RelayPriorityLow)
+	require.NoError(t, err)
+	release()
+
+	// no limit admits everything
+	unlimited := newRelayScheduler(0)
+	for i := 0; i < 10; i++ {
+		_, err := unlimited.Acquire(context.Background(), RelayPriorityLow)
+		require.NoError(t, err)
+	}
+}
+
+func TestRelayPriorityFromContext(t *testing.T) {
+	require.Equal(t, DefaultRelayPriority, RelayPriorityFromContext(context.Background()))
+	require.Equal(t, RelayPriorityHigh, RelayPriorityFromContext(ContextWithRelayPriority(context.Background(), RelayPriorityHigh)))
+	priority, err := ParseRelayPriority("LOW")
+	require.NoError(t, err)
+	require.Equal(t, RelayPriorityLow, priority)
+	_, err = ParseRelayPriority("urgent")
+	require.Error(t, err)
+}
@@ -13,10 +13,17 @@ const (
 	ReconnectCandidateTime = 2 * time.Minute
 )
 
+// ReportedProvidersAggregator allows customizing the list of reported providers sent with relays,
+// it receives the default list built from the collected reports and returns the list to send
+type ReportedProvidersAggregator interface {
+	AggregateReportedProviders(reportedProviders []*pairingtypes.ReportedProvider) []*pairingtypes.ReportedProvider
+}
+
 type ReportedProviders struct {
 	addedToPurgeAndReport map[string]*ReportedProviderEntry // list of purged providers to report for QoS unavailability. (easier to search maps.)
 	lock                  sync.RWMutex
 	reporter              metrics.Reporter
+	aggregator            ReportedProvidersAggregator // optional, when nil all reports are sent as is
 }
 
 type ReportedProviderEntry struct {
@@ -45,9 +52,18 @@ func (rp *ReportedProviders) GetReportedProviders() []*pairingtypes.ReportedProv
 		}
 		reportedProviders = append(reportedProviders, &reportedProvider)
 	}
+	if rp.aggregator != nil {
+		return rp.aggregator.AggregateReportedProviders(reportedProviders)
+	}
 	return reportedProviders
 }
 
+func (rp *ReportedProviders) SetAggregator(aggregator ReportedProvidersAggregator) {
+	rp.lock.Lock()
+	defer rp.lock.Unlock()
+	rp.aggregator = aggregator
+}
+
 func (rp *ReportedProviders) ReportProvider(address string, errors uint64, disconnections uint64, reconnectCB func() error) {
 	rp.lock.Lock()
 	defer rp.lock.Unlock()
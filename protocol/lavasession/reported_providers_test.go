@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -91,3 +92,32 @@ func TestReportedReconnect(t *testing.T) {
 	require.True(t, reportedProviders.IsReported(providers[3]))
 	require.False(t, reportedProviders.IsReported(providers[1]))
 }
+
+type disconnectionsOnlyAggregator struct{}
+
+func (disconnectionsOnlyAggregator) AggregateReportedProviders(reportedProviders []*pairingtypes.ReportedProvider) []*pairingtypes.ReportedProvider {
+	ret := []*pairingtypes.ReportedProvider{}
+	for _, reported := range reportedProviders {
+		if reported.Disconnections > 0 {
+			ret = append(ret, reported)
+		}
+	}
+	return ret
+}
+
+func TestReportedProvidersAggregator(t *testing.T) {
+	reportedProviders := NewReportedProviders(nil)
+	reportedProviders.ReportProvider("p1", 5, 0, nil)
+	reportedProviders.ReportProvider("p2", 0, 1, nil)
+	require.Len(t, reportedProviders.GetReportedProviders(), 2)
+
+	reportedProviders.SetAggregator(disconnectionsOnlyAggregator{})
+	reported := reportedProviders.GetReportedProviders()
+	require.Len(t, reported, 1)
+	require.Equal(t, "p2", reported[0].Address)
+	// reports are still kept, only the sent list is affected
+	require.True(t, reportedProviders.IsReported("p1"))
+
+	reportedProviders.SetAggregator(nil)
+	require.Len(t, reportedProviders.GetReportedProviders(), 2)
+}
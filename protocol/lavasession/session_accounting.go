@@ -0,0 +1,134 @@
+package lavasession
+
+import (
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// session locking model:
+//
+// a SingleConsumerSession belongs to the relay holding its lock. GetSessions and GetDataReliabilitySession hand it out
+// locked and one of the OnSession* callbacks unlocks it, everything between them may read the session freely.
+// CuSum, RelayNum, LatestRelayCu, QoSInfo, LatestBlock and the error counters are only changed through the accounting
+// methods below, which assert the lock is held.
+//
+// locks are taken in the order csm.lock, cswp.Lock, session lock. a session lock is only ever try-locked while its
+// parent's lock is held, so a relay holding a session may still take its parent's lock, e.g. to update the used cu.
+
+// fails accounting on a session whose lock isn't held instead of logging it, set by tests
+var strictSessionLocking = false
+
+// assertLocked reports accounting done on a session no relay holds, the lock only tells it's held, not by whom
+func (cs *SingleConsumerSession) assertLocked(operation string) {
+	if !cs.lock.TryLock() {
+		return
+	}
+	cs.lock.Unlock()
+	if strictSessionLocking {
+		panic("session accounting without holding the session lock: " + operation)
+	}
+	utils.LavaFormatError("session accounting without holding the session lock", LockMisUseDetectedError, utils.LogAttr("operation", operation), utils.LogAttr("sessionId", cs.SessionId))
+}
+
+// startRelay charges the session for a relay it's handed out for
+func (cs *SingleConsumerSession) startRelay(cu uint64, relayMethod string, simulated bool) {
+	cs.assertLocked("startRelay")
+	cs.LatestRelayCu = cu
+	cs.RelayNum += RelayNumberIncrement
+	cs.relayMethod = relayMethod
+	cs.simulated = simulated
+	cs.timeToFirstByte = 0
+	cs.fallbackLatestBlock = 0
+	cs.markInUse()
+}
+
+// SetTimeToFirstByte records how long the current relay's reply took to start arriving, it's reported when the
+// session is done
+func (cs *SingleConsumerSession) SetTimeToFirstByte(timeToFirstByte time.Duration) {
+	cs.assertLocked("SetTimeToFirstByte")
+	cs.timeToFirstByte = timeToFirstByte
+}
+
+// carryOverQoS starts a new session with the qos a provider built up in a previous epoch
+func (cs *SingleConsumerSession) carryOverQoS(qos QoSReport) {
+	cs.assertLocked("carryOverQoS")
+	cs.QoSInfo = qos
+}
+
+// SetFallbackLatestBlock records the provider's height learned elsewhere for a relay whose reply doesn't carry its latest
+// block, it's used for height tracking when the session is done but doesn't count for the sync score
+func (cs *SingleConsumerSession) SetFallbackLatestBlock(latestBlock int64) {
+	cs.assertLocked("SetFallbackLatestBlock")
+	cs.fallbackLatestBlock = latestBlock
+}
+
+// startDataReliabilityRelay charges the data reliability session for its relay, which doesn't pay cu
+func (cs *SingleConsumerSession) startDataReliabilityRelay() {
+	cs.assertLocked("startDataReliabilityRelay")
+	cs.RelayNum += 1
+	cs.markInUse()
+}
+
+// setExcellenceQoSReport sets the excellence report the next relay carries
+func (cs *SingleConsumerSession) setExcellenceQoSReport(report *pairingtypes.QualityOfServiceReport) {
+	cs.assertLocked("setExcellenceQoSReport")
+	cs.QoSInfo.LastExcellenceQoSReport = report
+}
+
+// releaseRelayCu returns the cu charged for the current relay, which the parent must give back, and clears it
+func (cs *SingleConsumerSession) releaseRelayCu() uint64 {
+	cs.assertLocked("releaseRelayCu")
+	cu := cs.LatestRelayCu
+	cs.LatestRelayCu = 0 // making sure no one uses it in a wrong way
+	return cu
+}
+
+// settleRelay adds the cu of a successful relay to the session's cu sum
+func (cs *SingleConsumerSession) settleRelay() {
+	cs.assertLocked("settleRelay")
+	cs.CuSum += cs.LatestRelayCu
+	cs.LatestRelayCu = 0 // reset cu just in case
+	cs.ConsecutiveErrors = []error{}
+}
+
+// revertRelay returns the session to where it was before a relay that isn't settled, like a simulated one, and
+// returns the cu the parent must give back
+func (cs *SingleConsumerSession) revertRelay() uint64 {
+	cs.assertLocked("revertRelay")
+	cs.RelayNum -= RelayNumberIncrement
+	cs.ConsecutiveErrors = []error{}
+	return cs.releaseRelayCu()
+}
+
+// SettleSimulatedRelay settles the current simulated relay like any other, for a provider that didn't acknowledge serving
+// it without charge
+func (cs *SingleConsumerSession) SettleSimulatedRelay() {
+	cs.assertLocked("SettleSimulatedRelay")
+	cs.simulated = false
+}
+
+// recordFailure counts a failed relay, returns the number of consecutive errors
+func (cs *SingleConsumerSession) recordFailure(err error) uint64 {
+	cs.assertLocked("recordFailure")
+	cs.QoSInfo.TotalRelays++
+	cs.ConsecutiveErrors = append(cs.ConsecutiveErrors, err)
+	cs.errorsCount += 1
+	return uint64(len(cs.ConsecutiveErrors))
+}
+
+// recordReply updates the latest block and the qos of the session with a relay's reply
+func (cs *SingleConsumerSession) recordReply(latestBlock int64, latency, expectedLatency time.Duration, blockHeightDiff int64, syncKnown bool, numOfProviders int, servicersToCount int64) {
+	cs.assertLocked("recordReply")
+	cs.ConsecutiveErrors = []error{}
+	cs.LatestBlock = latestBlock
+	cs.calculateQoS(latency, expectedLatency, blockHeightDiff, syncKnown, numOfProviders, servicersToCount)
+}
+
+// resync adopts the cu sum and relay number the provider holds for the session
+func (cs *SingleConsumerSession) resync(cuSum uint64, relayNum uint64) {
+	cs.assertLocked("resync")
+	cs.CuSum = cuSum
+	cs.RelayNum = relayNum
+}
@@ -0,0 +1,142 @@
+package lavasession
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionAccountingRequiresLock(t *testing.T) {
+	strictSessionLocking = true
+	defer func() { strictSessionLocking = false }()
+	session := &SingleConsumerSession{SessionId: 1}
+	require.Panics(t, func() { session.startRelay(cuForFirstRequest, "", false) })
+	require.Panics(t, func() { session.recordFailure(fmt.Errorf("failure")) })
+	require.Zero(t, session.RelayNum)
+
+	session.lock.Lock()
+	require.NotPanics(t, func() {
+		session.startRelay(cuForFirstRequest, "", false)
+		session.settleRelay()
+	})
+	session.lock.Unlock()
+	require.Equal(t, cuForFirstRequest, session.CuSum)
+	require.Equal(t, uint64(RelayNumberIncrement), session.RelayNum)
+}
+
+// the endpoints are connected up front, connecting isn't part of the accounting under test
+func accountingPairingList(t *testing.T, providers int) map[uint64]*ConsumerSessionsWithProvider {
+	pairingList := make(map[uint64]*ConsumerSessionsWithProvider, providers)
+	for p := 0; p < providers; p++ {
+		cswp := &ConsumerSessionsWithProvider{
+			PublicLavaAddress: providerStr + "accounting" + strconv.Itoa(p),
+			Endpoints:         []*Endpoint{{NetworkAddress: grpcListener, Enabled: true}},
+			Sessions:          map[int64]*SingleConsumerSession{},
+			MaxComputeUnits:   1_000_000,
+			PairingEpoch:      firstEpochHeight,
+		}
+		connected, _, _, err := cswp.fetchEndpointConnectionFromConsumerSessionWithProvider(context.Background())
+		require.NoError(t, err)
+		require.True(t, connected)
+		pairingList[uint64(p)] = cswp
+	}
+	return pairingList
+}
+
+// hammers a small provider set with concurrent relays, run with -race
+func TestConcurrentSessionAccounting(t *testing.T) {
+	strictSessionLocking = true
+	defer func() { strictSessionLocking = false }()
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := accountingPairingList(t, 3)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+
+	const relaysPerRoutine = 25
+	var handedOut, succeeded, failed uint64
+	wg := sync.WaitGroup{}
+	for routine := 0; routine < parallelGoRoutines; routine++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for relay := 0; relay < relaysPerRoutine; relay++ {
+				css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+				if err != nil {
+					continue
+				}
+				for _, cs := range css {
+					atomic.AddUint64(&handedOut, 1)
+					switch rand.Intn(5) {
+					case 0:
+						atomic.AddUint64(&failed, 1)
+						require.NoError(t, csm.OnSessionFailure(cs.Session, cs.Session.RelayGeneration(), fmt.Errorf("nothing special")))
+					case 1:
+						require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+					default:
+						atomic.AddUint64(&succeeded, 1)
+						require.NoError(t, csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, time.Millisecond, servicedBlockNumber-1, numberOfProviders, numberOfProviders, false))
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.NotZero(t, succeeded)
+
+	// every provider was charged exactly the cu its sessions settled
+	var usedCu, relayNums, totalRelays uint64
+	for _, cswp := range pairingList {
+		var cuSum uint64
+		cswp.Lock.RLock()
+		for _, session := range cswp.Sessions {
+			cuSum += session.CuSum
+			relayNums += session.RelayNum
+			totalRelays += session.QoSInfo.TotalRelays
+			require.Zero(t, session.LatestRelayCu)
+		}
+		cswp.Lock.RUnlock()
+		require.Equal(t, cuSum, cswp.atomicReadUsedComputeUnits(), cswp.PublicLavaAddress)
+		usedCu += cuSum
+	}
+	require.Equal(t, succeeded*cuForFirstRequest, usedCu)
+	require.Equal(t, handedOut, relayNums)
+	require.Equal(t, succeeded+failed, totalRelays)
+}
+
+func TestConcurrentDataReliabilitySessions(t *testing.T) {
+	strictSessionLocking = true
+	defer func() { strictSessionLocking = false }()
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	err := csm.UpdateAllProviders(firstEpochHeight, accountingPairingList(t, 2))
+	require.NoError(t, err)
+
+	// a provider's data reliability session is handed out once an epoch, to a single relay
+	var acquired uint64
+	wg := sync.WaitGroup{}
+	for routine := 0; routine < parallelGoRoutines; routine++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, _, _, err := csm.GetDataReliabilitySession(ctx, "", 0, firstEpochHeight)
+			if err != nil {
+				require.True(t, DataReliabilityAlreadySentThisEpochError.Is(err), err)
+				return
+			}
+			atomic.AddUint64(&acquired, 1)
+			time.Sleep(time.Millisecond)
+			require.NoError(t, csm.OnDataReliabilitySessionDone(session, session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, time.Millisecond, servicedBlockNumber-1, numberOfProviders, numberOfProviders))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, uint64(1), acquired)
+}
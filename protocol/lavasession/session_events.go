@@ -0,0 +1,59 @@
+package lavasession
+
+import (
+	"github.com/lavanet/lava/protocol/metrics"
+)
+
+type sessionEventSinkHolder struct {
+	sink metrics.SessionEventSink
+}
+
+// SetSessionEventSink sets where session lifecycle events are sent, the consumer's prometheus metrics by default,
+// passing nil stops sending them
+func (csm *ConsumerSessionManager) SetSessionEventSink(sink metrics.SessionEventSink) {
+	csm.sessionEventSink.Store(&sessionEventSinkHolder{sink: sink})
+}
+
+func (csm *ConsumerSessionManager) getSessionEventSink() metrics.SessionEventSink {
+	if holder := csm.sessionEventSink.Load(); holder != nil {
+		return holder.sink
+	}
+	if csm.consumerMetricsManager == nil {
+		// a nil manager in the interface wouldn't compare to nil
+		return nil
+	}
+	return csm.consumerMetricsManager
+}
+
+func (csm *ConsumerSessionManager) appendSessionEvent(eventType metrics.SessionEventType, providerAddress string, epoch uint64, sessionId int64) {
+	sink := csm.getSessionEventSink()
+	if sink == nil {
+		return
+	}
+	sink.AppendSessionEvent(metrics.SessionEvent{
+		ChainID:         csm.rpcEndpoint.ChainID,
+		ApiInterface:    csm.rpcEndpoint.ApiInterface,
+		ProviderAddress: providerAddress,
+		Epoch:           epoch,
+		SessionID:       sessionId,
+		Type:            eventType,
+	})
+}
+
+func (csm *ConsumerSessionManager) appendSessionRotatedEvents(consumerSessionsWithProvider *ConsumerSessionsWithProvider, rotatedSessions []int64) {
+	if len(rotatedSessions) == 0 {
+		return
+	}
+	providerAddress, epoch := consumerSessionsWithProvider.getPublicLavaAddressAndPairingEpoch()
+	for _, sessionId := range rotatedSessions {
+		csm.appendSessionEvent(metrics.SessionRotated, providerAddress, epoch, sessionId)
+	}
+}
+
+func (csm *ConsumerSessionManager) appendSessionAcquiredEvent(consumerSession *SingleConsumerSession, created bool, epoch uint64) {
+	eventType := metrics.SessionReused
+	if created {
+		eventType = metrics.SessionCreated
+	}
+	csm.appendSessionEvent(eventType, consumerSession.Parent.PublicLavaAddress, epoch, consumerSession.SessionId)
+}
@@ -0,0 +1,87 @@
+package lavasession
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSessionEventSink struct {
+	lock   sync.Mutex
+	events []metrics.SessionEvent
+}
+
+func (rses *recordingSessionEventSink) AppendSessionEvent(event metrics.SessionEvent) {
+	rses.lock.Lock()
+	defer rses.lock.Unlock()
+	rses.events = append(rses.events, event)
+}
+
+func (rses *recordingSessionEventSink) eventTypes() []metrics.SessionEventType {
+	rses.lock.Lock()
+	defer rses.lock.Unlock()
+	eventTypes := make([]metrics.SessionEventType, 0, len(rses.events))
+	for _, event := range rses.events {
+		eventTypes = append(eventTypes, event.Type)
+	}
+	return eventTypes
+}
+
+func TestSessionEvents(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	sink := &recordingSessionEventSink{}
+	csm.SetSessionEventSink(sink)
+	// a single provider so all relays go to it
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+
+	relay := func() *SingleConsumerSession {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		require.Len(t, css, 1)
+		for _, cs := range css {
+			return cs.Session
+		}
+		return nil
+	}
+	done := func(session *SingleConsumerSession) {
+		err := csm.OnSessionDone(session, session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber-1, numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+	}
+
+	session := relay()
+	done(session)
+	require.Equal(t, session.SessionId, relay().SessionId)
+	require.NoError(t, csm.OnSessionFailure(session, session.RelayGeneration(), fmt.Errorf("relay failed")))
+	require.Equal(t, []metrics.SessionEventType{metrics.SessionCreated, metrics.SessionDone, metrics.SessionReused, metrics.SessionFailed}, sink.eventTypes())
+	for _, event := range sink.events {
+		require.Equal(t, pairingList[0].PublicLavaAddress, event.ProviderAddress)
+		require.Equal(t, uint64(firstEpochHeight), event.Epoch)
+		require.Equal(t, session.SessionId, event.SessionID)
+		require.Equal(t, csm.rpcEndpoint.ChainID, event.ChainID)
+	}
+
+	// a session at its cu sum cap is rotated out for a fresh one
+	MaxCuSumPerSession = 2 * cuForFirstRequest
+	defer func() { MaxCuSumPerSession = 0 }()
+	sink.events = nil
+	done(relay())
+	rotatedTo := relay()
+	require.NotEqual(t, session.SessionId, rotatedTo.SessionId)
+	require.Equal(t, []metrics.SessionEventType{metrics.SessionReused, metrics.SessionDone, metrics.SessionRotated, metrics.SessionCreated}, sink.eventTypes())
+	require.Equal(t, session.SessionId, sink.events[2].SessionID)
+	require.Equal(t, rotatedTo.SessionId, sink.events[3].SessionID)
+
+	// no sink, no events
+	csm.SetSessionEventSink(nil)
+	done(rotatedTo)
+	require.Len(t, sink.eventTypes(), 4)
+}
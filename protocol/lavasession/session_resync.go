@@ -0,0 +1,93 @@
+package lavasession
+
+import (
+	"context"
+	"strconv"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	SessionResyncCuToleranceFlag          = "session-resync-cu-tolerance"
+	SessionResyncRelayNumToleranceFlag    = "session-resync-relay-num-tolerance"
+	DefaultSessionResyncRelayNumTolerance = 2
+)
+
+var (
+	// a provider whose cu sum for a session is at most this much above ours is resynced to instead of failing the session,
+	// a larger drift might be an attempt to overcharge so it still fails hard. 0 disables the resync
+	SessionResyncCuTolerance       uint64 = 0
+	SessionResyncRelayNumTolerance uint64 = DefaultSessionResyncRelayNumTolerance
+)
+
+// SetSessionSyncTrailer lets the consumer know the provider's view of a session that went out of sync
+func SetSessionSyncTrailer(ctx context.Context, cuSum uint64, relayNum uint64) {
+	trailer := metadata.Pairs(
+		common.SessionCuSumMetadataKey, strconv.FormatUint(cuSum, 10),
+		common.SessionRelayNumMetadataKey, strconv.FormatUint(relayNum, 10),
+	)
+	grpc.SetTrailer(ctx, trailer) // we ignore this error here since this code can be triggered not from grpc
+}
+
+// ParseSessionSyncTrailer reads the provider's view of the session set by SetSessionSyncTrailer
+func ParseSessionSyncTrailer(trailer metadata.MD) (cuSum uint64, relayNum uint64, ok bool) {
+	cuSums := trailer.Get(common.SessionCuSumMetadataKey)
+	relayNums := trailer.Get(common.SessionRelayNumMetadataKey)
+	if len(cuSums) == 0 || len(relayNums) == 0 {
+		return 0, 0, false
+	}
+	cuSum, err := strconv.ParseUint(cuSums[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	relayNum, err = strconv.ParseUint(relayNums[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return cuSum, relayNum, true
+}
+
+// ResyncSession aligns a session that lost sync with the provider's view of it, so the relay can be retried instead of
+// blocking the session. the provider can only be ahead of us, an in flight relay crossing an epoch or a dropped reply, and
+// only by the configured tolerance. the session must be locked, the relay request must be re-signed after a resync
+func (csm *ConsumerSessionManager) ResyncSession(consumerSession *SingleConsumerSession, providerCuSum uint64, providerRelayNum uint64) error {
+	if err := csm.verifyLock(consumerSession); err != nil {
+		return sdkerrors.Wrapf(err, "ResyncSession, consumerSession.lock must be locked before accessing this method")
+	}
+	if SessionResyncCuTolerance == 0 {
+		return sdkerrors.Wrapf(SessionResyncRejectedError, "session resync is disabled")
+	}
+	cuSum := consumerSession.CuSum
+	if providerCuSum > cuSum {
+		cuSum = providerCuSum
+	}
+	// the provider expects a relay number above the last one it handled
+	relayNum := consumerSession.RelayNum
+	if providerRelayNum+1 > relayNum {
+		relayNum = providerRelayNum + 1
+	}
+	attributes := []utils.Attribute{
+		utils.LogAttr("provider", consumerSession.Parent.PublicLavaAddress),
+		utils.LogAttr("sessionID", consumerSession.SessionId),
+		utils.LogAttr("cuSum", consumerSession.CuSum),
+		utils.LogAttr("providerCuSum", providerCuSum),
+		utils.LogAttr("relayNum", consumerSession.RelayNum),
+		utils.LogAttr("providerRelayNum", providerRelayNum),
+	}
+	if cuSum == consumerSession.CuSum && relayNum == consumerSession.RelayNum {
+		return sdkerrors.Wrapf(SessionResyncRejectedError, "provider's session state doesn't explain the sync loss")
+	}
+	if cuSum-consumerSession.CuSum > SessionResyncCuTolerance || relayNum-consumerSession.RelayNum > SessionResyncRelayNumTolerance {
+		return utils.LavaFormatWarning("provider's session state drifted beyond the resync tolerance", SessionResyncRejectedError,
+			append(attributes, utils.LogAttr("cuTolerance", SessionResyncCuTolerance), utils.LogAttr("relayNumTolerance", SessionResyncRelayNumTolerance))...)
+	}
+	utils.LavaFormatInfo("resyncing session with the provider", attributes...)
+	// the provider's extra cu were spent on this provider, the pairing's budget has to account for them too
+	consumerSession.Parent.chargeUsedComputeUnits(cuSum - consumerSession.CuSum)
+	consumerSession.resync(cuSum, relayNum)
+	return nil
+}
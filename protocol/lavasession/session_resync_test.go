@@ -0,0 +1,92 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseSessionSyncTrailer(t *testing.T) {
+	trailer := metadata.Pairs(common.SessionCuSumMetadataKey, "120", common.SessionRelayNumMetadataKey, "7")
+	cuSum, relayNum, ok := ParseSessionSyncTrailer(trailer)
+	require.True(t, ok)
+	require.Equal(t, uint64(120), cuSum)
+	require.Equal(t, uint64(7), relayNum)
+
+	_, _, ok = ParseSessionSyncTrailer(metadata.Pairs(common.SessionCuSumMetadataKey, "120"))
+	require.False(t, ok)
+	_, _, ok = ParseSessionSyncTrailer(metadata.Pairs(common.SessionCuSumMetadataKey, "-1", common.SessionRelayNumMetadataKey, "7"))
+	require.False(t, ok)
+	_, _, ok = ParseSessionSyncTrailer(nil)
+	require.False(t, ok)
+}
+
+func TestResyncSession(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList) // update the providers.
+	require.NoError(t, err)
+	getSession := func() *SingleConsumerSession {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0) // get a session
+		require.NoError(t, err)
+		for _, cs := range css {
+			return cs.Session
+		}
+		return nil
+	}
+	defer func(cuTolerance, relayNumTolerance uint64) {
+		SessionResyncCuTolerance = cuTolerance
+		SessionResyncRelayNumTolerance = relayNumTolerance
+	}(SessionResyncCuTolerance, SessionResyncRelayNumTolerance)
+
+	// disabled by default
+	session := getSession()
+	session.CuSum = 100
+	err = csm.ResyncSession(session, 110, session.RelayNum)
+	require.True(t, SessionResyncRejectedError.Is(err))
+	require.Equal(t, uint64(100), session.CuSum)
+
+	SessionResyncCuTolerance = 20
+	SessionResyncRelayNumTolerance = 2
+
+	// a dropped reply, the provider counted one more relay
+	relayNum := session.RelayNum
+	usedComputeUnits := session.Parent.atomicReadUsedComputeUnits()
+	err = csm.ResyncSession(session, 100+cuForFirstRequest, relayNum)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100+cuForFirstRequest), session.CuSum)
+	require.Equal(t, relayNum+1, session.RelayNum)
+	// the provider's extra cu count against its budget
+	require.Equal(t, usedComputeUnits+cuForFirstRequest, session.Parent.atomicReadUsedComputeUnits())
+
+	// nothing to resync to
+	err = csm.ResyncSession(session, session.CuSum-5, session.RelayNum-1)
+	require.True(t, SessionResyncRejectedError.Is(err))
+	require.NoError(t, csm.OnSessionUnUsed(session, session.RelayGeneration()))
+
+	// beyond the cu tolerance fails hard and leaves the session untouched
+	session = getSession()
+	session.CuSum = 100
+	relayNum = session.RelayNum
+	usedComputeUnits = session.Parent.atomicReadUsedComputeUnits()
+	err = csm.ResyncSession(session, 121, relayNum)
+	require.True(t, SessionResyncRejectedError.Is(err))
+	require.Equal(t, uint64(100), session.CuSum)
+	require.Equal(t, usedComputeUnits, session.Parent.atomicReadUsedComputeUnits())
+	require.Equal(t, relayNum, session.RelayNum)
+
+	// beyond the relay number tolerance
+	err = csm.ResyncSession(session, 100, relayNum+2)
+	require.True(t, SessionResyncRejectedError.Is(err))
+	require.Equal(t, uint64(100), session.CuSum)
+	require.Equal(t, relayNum, session.RelayNum)
+	require.NoError(t, csm.OnSessionUnUsed(session, session.RelayGeneration()))
+
+	// the session must be locked
+	err = csm.ResyncSession(session, 110, relayNum)
+	require.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package lavasession
+
+import (
+	"context"
+)
+
+type simulatedRelayContextKey struct{}
+
+// ContextWithSimulatedRelay marks the relay as simulated, sessions taken for it don't advance their cu sum and relay
+// number when done. a provider that doesn't acknowledge serving it without charge settles it, so the relay's session is
+// settled with SettleSimulatedRelay to stay in sync
+func ContextWithSimulatedRelay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, simulatedRelayContextKey{}, true)
+}
+
+func IsSimulatedRelay(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	simulated, _ := ctx.Value(simulatedRelayContextKey{}).(bool)
+	return simulated
+}
@@ -289,14 +289,33 @@ func (sps *SingleProviderSession) onSessionFailure() error {
 		return sps.onDataReliabilitySessionFailure()
 	}
 
+	sps.revertLatestRelayCu()
+	sps.errorsCount += 1
+	return nil
+}
+
+// onSimulatedSessionDone unlocks the session without keeping the relay's cu and relay number, the consumer doesn't
+// advance its session for simulated relays either
+func (sps *SingleProviderSession) onSimulatedSessionDone() error {
+	err := sps.VerifyLock() // sps is locked
+	if err != nil {
+		return utils.LavaFormatError("sps.verifyLock() failed in onSimulatedSessionDone", err, utils.Attribute{Key: "sessionID", Value: sps.SessionID})
+	}
+	defer sps.lock.Unlock()
+	if sps.userSessionsParent.atomicReadIsDataReliability() == isDataReliabilityPSWC {
+		return nil
+	}
+	sps.revertLatestRelayCu()
+	return nil
+}
+
+func (sps *SingleProviderSession) revertLatestRelayCu() {
 	sps.CuSum -= sps.LatestRelayCu
 	sps.validateAndSubUsedCU(sps.LatestRelayCu)
 	if sps.IsBadgeSession() {
 		sps.validateAndSubBadgeUsedCU(sps.LatestRelayCu, sps.BadgeUserData)
 	}
 	sps.LatestRelayCu = 0
-	sps.errorsCount += 1
-	return nil
 }
 
 func (sps *SingleProviderSession) onSessionDone(relayNumber uint64) error {
@@ -0,0 +1,128 @@
+package lavasession
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	StaleProviderLagFlag          = "stale-provider-lag"
+	StaleProviderWindowFlag       = "stale-provider-window"
+	DefaultStaleProviderWindow    = 5
+	DefaultStaleProviderRetryTime = 30 * time.Second // a stale provider is given another relay after this long to check if it caught up
+)
+
+var (
+	// providers serving this many blocks or more behind the expected block height for a whole window of relays are only
+	// chosen when no other provider is available, 0 disables the detection
+	StaleProviderLag    int64  = 0
+	StaleProviderWindow uint64 = DefaultStaleProviderWindow
+)
+
+type providerStaleness struct {
+	consecutiveStale uint64
+	staleSince       time.Time // zero when the provider is fresh
+}
+
+func (ps *providerStaleness) stale() bool {
+	return !ps.staleSince.IsZero()
+}
+
+// staleProviderTracker demotes providers that keep serving data behind the rest of the pairing, even when they are fast
+// and their replies verify. it is keyed by address so the state survives pairing updates
+type staleProviderTracker struct {
+	lock       sync.RWMutex
+	lag        int64
+	window     uint64
+	retryTime  time.Duration
+	providers  map[string]*providerStaleness
+	timeSource func() time.Time
+}
+
+func newStaleProviderTracker(lag int64, window uint64) *staleProviderTracker {
+	if window == 0 {
+		window = DefaultStaleProviderWindow
+	}
+	return &staleProviderTracker{lag: lag, window: window, retryTime: DefaultStaleProviderRetryTime, providers: map[string]*providerStaleness{}, timeSource: time.Now}
+}
+
+func (spt *staleProviderTracker) enabled() bool {
+	return spt != nil && spt.lag > 0
+}
+
+// AppendBlockLag records how far behind the expected block height a provider's relay was served
+func (spt *staleProviderTracker) AppendBlockLag(providerAddress string, blockLag int64) {
+	if !spt.enabled() {
+		return
+	}
+	spt.lock.Lock()
+	defer spt.lock.Unlock()
+	staleness, ok := spt.providers[providerAddress]
+	if !ok {
+		staleness = &providerStaleness{}
+		spt.providers[providerAddress] = staleness
+	}
+	if blockLag < spt.lag {
+		// a single relay at the chain's head means the provider caught up
+		if staleness.stale() {
+			utils.LavaFormatInfo("stale provider caught up", utils.LogAttr("provider", providerAddress), utils.LogAttr("blockLag", blockLag))
+		}
+		*staleness = providerStaleness{}
+		return
+	}
+	staleness.consecutiveStale++
+	if staleness.consecutiveStale < spt.window {
+		return
+	}
+	if !staleness.stale() {
+		utils.LavaFormatWarning("provider is consistently behind the expected block height, demoting it", nil,
+			utils.LogAttr("provider", providerAddress),
+			utils.LogAttr("blockLag", blockLag),
+			utils.LogAttr("consecutiveStale", staleness.consecutiveStale),
+		)
+	}
+	// still behind, restart the retry period
+	staleness.staleSince = spt.timeSource()
+}
+
+// IsStale returns true if the provider is demoted and isn't due for a retry
+func (spt *staleProviderTracker) IsStale(providerAddress string) bool {
+	if !spt.enabled() {
+		return false
+	}
+	spt.lock.RLock()
+	defer spt.lock.RUnlock()
+	return spt.isStale(providerAddress)
+}
+
+// spt.lock must be rlocked
+func (spt *staleProviderTracker) isStale(providerAddress string) bool {
+	staleness, ok := spt.providers[providerAddress]
+	return ok && staleness.stale() && spt.timeSource().Sub(staleness.staleSince) < spt.retryTime
+}
+
+// filterFresh returns the providers that aren't stale if any of them can still be chosen, otherwise all of them
+func (spt *staleProviderTracker) filterFresh(addresses []string, ignoredProviders map[string]struct{}) []string {
+	if !spt.enabled() {
+		return addresses
+	}
+	spt.lock.RLock()
+	defer spt.lock.RUnlock()
+	fresh := make([]string, 0, len(addresses))
+	availableFresh := false
+	for _, address := range addresses {
+		if spt.isStale(address) {
+			continue
+		}
+		fresh = append(fresh, address)
+		if _, ignored := ignoredProviders[address]; !ignored {
+			availableFresh = true
+		}
+	}
+	if !availableFresh {
+		return addresses
+	}
+	return fresh
+}
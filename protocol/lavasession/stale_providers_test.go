@@ -0,0 +1,75 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleProviderTracker(t *testing.T) {
+	now := time.Now()
+	tracker := newStaleProviderTracker(10, 3)
+	tracker.timeSource = func() time.Time { return now }
+
+	// an occasional lagging relay isn't enough
+	tracker.AppendBlockLag("stale", 50)
+	tracker.AppendBlockLag("stale", 50)
+	tracker.AppendBlockLag("stale", 0)
+	tracker.AppendBlockLag("stale", 50)
+	require.False(t, tracker.IsStale("stale"))
+
+	// consistently behind over the window
+	tracker.AppendBlockLag("stale", 50)
+	tracker.AppendBlockLag("stale", 50)
+	require.True(t, tracker.IsStale("stale"))
+	require.Equal(t, []string{"fresh"}, tracker.filterFresh([]string{"stale", "fresh"}, nil))
+	// stale providers are still used when nothing else is available
+	require.Equal(t, []string{"stale", "fresh"}, tracker.filterFresh([]string{"stale", "fresh"}, map[string]struct{}{"fresh": {}}))
+
+	// it's retried after a while and demoted again if it's still behind
+	now = now.Add(DefaultStaleProviderRetryTime)
+	require.False(t, tracker.IsStale("stale"))
+	tracker.AppendBlockLag("stale", 50)
+	require.True(t, tracker.IsStale("stale"))
+
+	// recovers once it catches up
+	tracker.AppendBlockLag("stale", 1)
+	require.False(t, tracker.IsStale("stale"))
+
+	// disabled tracker never demotes
+	disabled := newStaleProviderTracker(0, 1)
+	disabled.AppendBlockLag("stale", 1000)
+	require.False(t, disabled.IsStale("stale"))
+}
+
+func TestStaleProviderSelection(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	csm.staleProviders = newStaleProviderTracker(10, 3)
+	pairingList := map[uint64]*ConsumerSessionsWithProvider{0: createPairingList("", true)[0], 1: createPairingList("", true)[1]}
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+	staleProvider := pairingList[0].PublicLavaAddress
+	freshProvider := pairingList[1].PublicLavaAddress
+
+	// the stale provider is fast and its relays succeed but it keeps serving old blocks
+	for i := 0; i < 3; i++ {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, map[string]struct{}{freshProvider: {}}, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		require.Contains(t, css, staleProvider)
+		require.NoError(t, csm.OnSessionDone(css[staleProvider].Session, css[staleProvider].Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, css[staleProvider].Session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber+50, numberOfProviders, numberOfProviders, false))
+	}
+	require.True(t, csm.staleProviders.IsStale(staleProvider))
+
+	for i := 0; i < 5; i++ {
+		css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+		require.NoError(t, err)
+		require.Contains(t, css, freshProvider)
+		for _, cs := range css {
+			require.NoError(t, csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber, numberOfProviders, numberOfProviders, false))
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package lavasession
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+)
+
+// the next epoch's pairing, warmed in the background and swapped in by UpdateAllProviders when that epoch arrives
+type standbyPairing struct {
+	epoch     uint64
+	providers map[string]*ConsumerSessionsWithProvider // key == provider address
+	ready     chan struct{}
+}
+
+// PrepareNextEpoch warms the pairing of an upcoming epoch while the current one keeps serving relays. connections and
+// sessions are opened with the PrewarmProviders best providers, or all of them when prewarming is disabled, and
+// providers that stay paired carry over their sessions' qos. the returned channel is closed once warming is done,
+// UpdateAllProviders swaps in whatever was prepared for its epoch either way. the pairing updater calls it when its
+// pairing source knows the next epoch's pairing
+func (csm *ConsumerSessionManager) PrepareNextEpoch(ctx context.Context, epoch uint64, pairingList map[uint64]*ConsumerSessionsWithProvider) (<-chan struct{}, error) {
+	csm.lock.Lock()
+	if epoch <= csm.atomicReadCurrentEpoch() {
+		csm.lock.Unlock()
+		return nil, utils.LavaFormatError("trying to prepare a standby pairing for a current or older epoch", nil, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "currentEpoch", Value: csm.atomicReadCurrentEpoch()})
+	}
+	standby := &standbyPairing{
+		epoch:     epoch,
+		providers: make(map[string]*ConsumerSessionsWithProvider, len(pairingList)),
+		ready:     make(chan struct{}),
+	}
+	persistent := map[string]*ConsumerSessionsWithProvider{}
+	for _, provider := range pairingList {
+		standby.providers[provider.PublicLavaAddress] = provider
+		if current, ok := csm.pairing[provider.PublicLavaAddress]; ok {
+			persistent[provider.PublicLavaAddress] = current
+		}
+	}
+	csm.standby = standby
+	csm.lock.Unlock()
+
+	go func() {
+		defer close(standby.ready)
+		count := PrewarmProviders
+		if count == 0 {
+			count = uint64(len(pairingList))
+		}
+		carriedQoS := func(providerAddress string) *QoSReport {
+			if current, ok := persistent[providerAddress]; ok {
+				return current.latestQoS()
+			}
+			return nil
+		}
+		csm.prewarmProviders(ctx, csm.providersToPrewarm(pairingList, count), epoch, carriedQoS)
+		utils.LavaFormatDebug("standby pairing ready", utils.LogAttr("spec", csm.rpcEndpoint.Key()), utils.LogAttr("epoch", epoch), utils.LogAttr("persistentProviders", len(persistent)))
+	}()
+	return standby.ready, nil
+}
+
+// StandbyEpoch returns the epoch of the pairing prepared by PrepareNextEpoch, 0 when none is prepared
+func (csm *ConsumerSessionManager) StandbyEpoch() uint64 {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	if csm.standby == nil {
+		return 0
+	}
+	return csm.standby.epoch
+}
+
+// replaces the pairing list's entries with the ones prepared for the epoch and drops the standby. prepared providers
+// the new pairing doesn't have, or whose endpoints or compute units changed since they were prepared, are left out and
+// their connections are closed with the purged pairings. csm.lock must be held
+func (csm *ConsumerSessionManager) takeStandby(epoch uint64, pairingList map[uint64]*ConsumerSessionsWithProvider) (swapped bool) {
+	standby := csm.standby
+	if standby == nil || standby.epoch > epoch {
+		return false
+	}
+	csm.standby = nil
+	if standby.epoch < epoch {
+		csm.dropStandbyProviders(standby.providers)
+		return false
+	}
+	unused := make(map[string]*ConsumerSessionsWithProvider, len(standby.providers))
+	for providerAddress, prepared := range standby.providers {
+		unused[providerAddress] = prepared
+	}
+	for idx, provider := range pairingList {
+		prepared, ok := standby.providers[provider.PublicLavaAddress]
+		if !ok {
+			continue
+		}
+		if !prepared.samePairing(provider) {
+			utils.LavaFormatDebug("standby provider changed since it was prepared, using the fresh entry", utils.LogAttr("provider", provider.PublicLavaAddress), utils.LogAttr("epoch", epoch))
+			continue
+		}
+		pairingList[idx] = prepared
+		delete(unused, provider.PublicLavaAddress)
+	}
+	csm.dropStandbyProviders(unused)
+	return true
+}
+
+// the warming may still be connecting, so the connections of unused standby providers are closed with the next purge
+// instead of right away. csm.lock must be held
+func (csm *ConsumerSessionManager) dropStandbyProviders(providers map[string]*ConsumerSessionsWithProvider) {
+	for _, provider := range providers {
+		csm.purgedInFlightPairings = append(csm.purgedInFlightPairings, provider)
+	}
+}
+
+// whether the prepared entry has the endpoints and compute units of the fresh one
+func (cswp *ConsumerSessionsWithProvider) samePairing(fresh *ConsumerSessionsWithProvider) bool {
+	cswp.Lock.RLock()
+	defer cswp.Lock.RUnlock()
+	if cswp.MaxComputeUnits != fresh.MaxComputeUnits || len(cswp.Endpoints) != len(fresh.Endpoints) {
+		return false
+	}
+	sameSet := func(first, second map[string]struct{}) bool {
+		if len(first) != len(second) {
+			return false
+		}
+		for key := range first {
+			if _, ok := second[key]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	for idx, endpoint := range cswp.Endpoints {
+		freshEndpoint := fresh.Endpoints[idx]
+		if endpoint.NetworkAddress != freshEndpoint.NetworkAddress || endpoint.Geolocation != freshEndpoint.Geolocation ||
+			!sameSet(endpoint.Addons, freshEndpoint.Addons) || !sameSet(endpoint.Extensions, freshEndpoint.Extensions) {
+			return false
+		}
+	}
+	return true
+}
+
+// the qos of the provider's busiest session, sessions in use by a relay are skipped
+func (cswp *ConsumerSessionsWithProvider) latestQoS() *QoSReport {
+	cswp.Lock.Lock()
+	defer cswp.Lock.Unlock()
+	var latest *QoSReport
+	for _, session := range cswp.Sessions {
+		if !session.lock.TryLock() {
+			continue
+		}
+		if latest == nil || session.QoSInfo.TotalRelays > latest.TotalRelays {
+			qos := session.QoSInfo.clone()
+			latest = &qos
+		}
+		session.lock.Unlock()
+	}
+	return latest
+}
+
+func (qos QoSReport) clone() QoSReport {
+	cloned := qos
+	if qos.LastQoSReport != nil {
+		report := *qos.LastQoSReport
+		cloned.LastQoSReport = &report
+	}
+	if qos.LastExcellenceQoSReport != nil {
+		report := *qos.LastExcellenceQoSReport
+		cloned.LastExcellenceQoSReport = &report
+	}
+	cloned.LatencyScoreList = append([]sdk.Dec{}, qos.LatencyScoreList...)
+	return cloned
+}
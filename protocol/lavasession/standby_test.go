@@ -0,0 +1,93 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandbyEpochSwap(t *testing.T) {
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("", true)
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.NoError(t, err)
+
+	// relays in flight across the boundary, started while the first ones build up qos. the in flight relays hold other
+	// sessions than the ones with qos, sessions in use aren't read
+	css, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	inFlight, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, inFlight)
+	for _, cs := range css {
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+	}
+
+	// a standby for an epoch that already started is rejected
+	_, err = csm.PrepareNextEpoch(ctx, firstEpochHeight, createPairingList("", true))
+	require.Error(t, err)
+
+	// the next pairing keeps the providers, they are warmed before the boundary
+	standbyList := createPairingList("", true)
+	for _, cswp := range standbyList {
+		cswp.PairingEpoch = secondEpochHeight
+	}
+	ready, err := csm.PrepareNextEpoch(ctx, secondEpochHeight, standbyList)
+	require.NoError(t, err)
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("standby pairing wasn't warmed in time")
+	}
+	require.Equal(t, uint64(firstEpochHeight), csm.atomicReadCurrentEpoch())
+	require.Equal(t, uint64(secondEpochHeight), csm.StandbyEpoch())
+	for _, cswp := range standbyList {
+		cswp.Lock.RLock()
+		require.Len(t, cswp.Sessions, 1)
+		for _, session := range cswp.Sessions {
+			if _, used := css[cswp.PublicLavaAddress]; used {
+				// qos carried over from the current epoch
+				require.NotZero(t, session.QoSInfo.TotalRelays)
+			}
+		}
+		cswp.Lock.RUnlock()
+	}
+
+	// the updater hands fresh entries at the boundary, the warmed ones are swapped in unless the provider changed
+	freshList := createPairingList("", true)
+	for _, cswp := range freshList {
+		cswp.PairingEpoch = secondEpochHeight
+	}
+	changed := freshList[0]
+	changed.MaxComputeUnits++
+	err = csm.UpdateAllProviders(secondEpochHeight, freshList)
+	require.NoError(t, err)
+	require.Zero(t, csm.StandbyEpoch())
+	require.Same(t, changed, csm.pairing[changed.PublicLavaAddress])
+	for _, cswp := range standbyList {
+		if cswp.PublicLavaAddress != changed.PublicLavaAddress {
+			require.Same(t, cswp, csm.pairing[cswp.PublicLavaAddress])
+		}
+	}
+	newCss, err := csm.GetSessions(ctx, cuForFirstRequest, nil, servicedBlockNumber, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	for providerAddress, cs := range newCss {
+		require.Equal(t, uint64(secondEpochHeight), cs.Epoch)
+		require.Same(t, csm.pairing[providerAddress], cs.Session.Parent)
+		require.NoError(t, csm.OnSessionUnUsed(cs.Session, cs.Session.RelayGeneration()))
+	}
+
+	// relays started before the swap complete against the old pairing, none of them is dropped
+	for _, cs := range inFlight {
+		require.Equal(t, uint64(firstEpochHeight), cs.Session.PairingEpoch())
+		err = csm.OnSessionDone(cs.Session, cs.Session.RelayGeneration(), servicedBlockNumber, cuForFirstRequest, time.Millisecond, cs.Session.CalculateExpectedLatency(2*time.Millisecond), (servicedBlockNumber - 1), numberOfProviders, numberOfProviders, false)
+		require.NoError(t, err)
+		require.Equal(t, pairingList[0].PairingEpoch, cs.Session.Parent.PairingEpoch)
+	}
+	require.Empty(t, csm.GetReportedProviders(secondEpochHeight))
+}
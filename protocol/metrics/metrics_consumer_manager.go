@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/lavanet/lava/utils"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
@@ -12,16 +13,30 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+type DataReliabilityOutcome string
+
+const (
+	DataReliabilityTriggered    DataReliabilityOutcome = "triggered"
+	DataReliabilityAgreed       DataReliabilityOutcome = "agreed"
+	DataReliabilityConflict     DataReliabilityOutcome = "conflicts"
+	DataReliabilityErrored      DataReliabilityOutcome = "errored"
+	DataReliabilityMinorityFork DataReliabilityOutcome = "minority_forks"
+)
+
 type ConsumerMetricsManager struct {
 	totalCURequestedMetric        *prometheus.CounterVec
 	totalRelaysRequestedMetric    *prometheus.CounterVec
 	totalErroredMetric            *prometheus.CounterVec
 	blockMetric                   *prometheus.GaugeVec
 	latencyMetric                 *prometheus.GaugeVec
+	timeToFirstByteMetric         *prometheus.GaugeVec
 	qosMetric                     *prometheus.GaugeVec
 	qosExcellenceMetric           *prometheus.GaugeVec
 	LatestBlockMetric             *prometheus.GaugeVec
 	LatestProviderRelay           *prometheus.GaugeVec
+	inFlightRelaysMetric          *prometheus.GaugeVec
+	sessionEventsMetric           *prometheus.CounterVec
+	dataReliabilityMetrics        map[DataReliabilityOutcome]*prometheus.CounterVec
 	virtualEpochMetric            *prometheus.GaugeVec
 	endpointsHealthChecksOkMetric prometheus.Gauge
 	endpointsHealthChecksOk       uint64
@@ -62,6 +77,11 @@ func NewConsumerMetricsManager(networkAddress string) *ConsumerMetricsManager {
 		Help: "The latency of requests requested by the consumer over time.",
 	}, []string{"spec", "apiInterface"})
 
+	timeToFirstByteMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_provider_time_to_first_byte",
+		Help: "The time in milliseconds until the latest reply of a provider started arriving, measured when time to first byte measuring is enabled.",
+	}, []string{"spec", "apiInterface", "provider_address"})
+
 	qosMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "lava_consumer_qos_metrics",
 		Help: "The QOS metrics per provider for current epoch for the session with the most relays.",
@@ -80,6 +100,27 @@ func NewConsumerMetricsManager(networkAddress string) *ConsumerMetricsManager {
 		Name: "lava_consumer_latest_provider_relay_time",
 		Help: "The latest time we sent a relay to provider",
 	}, []string{"spec", "provider_address", "apiInterface"})
+	inFlightRelaysMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_provider_in_flight_relays",
+		Help: "The number of relays currently in flight to a provider",
+	}, []string{"spec", "provider_address", "apiInterface"})
+	sessionEventsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_consumer_session_events",
+		Help: "The number of consumer sessions created, reused, rotated, failed and done per provider",
+	}, []string{"spec", "apiInterface", "provider_address", "event"})
+	dataReliabilityMetrics := map[DataReliabilityOutcome]*prometheus.CounterVec{}
+	for outcome, help := range map[DataReliabilityOutcome]string{
+		DataReliabilityTriggered:    "The number of data reliability relays sent to a second provider",
+		DataReliabilityAgreed:       "The number of data reliability relays that matched the original reply",
+		DataReliabilityConflict:     "The number of data reliability relays that conflicted with the original reply",
+		DataReliabilityErrored:      "The number of data reliability relays that failed",
+		DataReliabilityMinorityFork: "The number of providers flagged for serving finalized blocks from a minority fork",
+	} {
+		dataReliabilityMetrics[outcome] = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lava_consumer_data_reliability_" + string(outcome),
+			Help: help,
+		}, []string{"spec"})
+	}
 	virtualEpochMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "virtual_epoch",
 		Help: "The current virtual epoch measured",
@@ -99,10 +140,16 @@ func NewConsumerMetricsManager(networkAddress string) *ConsumerMetricsManager {
 	prometheus.MustRegister(totalErroredMetric)
 	prometheus.MustRegister(blockMetric)
 	prometheus.MustRegister(latencyMetric)
+	prometheus.MustRegister(timeToFirstByteMetric)
 	prometheus.MustRegister(qosMetric)
 	prometheus.MustRegister(qosExcellenceMetric)
 	prometheus.MustRegister(latestBlockMetric)
 	prometheus.MustRegister(latestProviderRelay)
+	prometheus.MustRegister(inFlightRelaysMetric)
+	prometheus.MustRegister(sessionEventsMetric)
+	for _, dataReliabilityMetric := range dataReliabilityMetrics {
+		prometheus.MustRegister(dataReliabilityMetric)
+	}
 	prometheus.MustRegister(virtualEpochMetric)
 	prometheus.MustRegister(endpointsHealthChecksOkMetric)
 	prometheus.MustRegister(protocolVersionMetric)
@@ -113,10 +160,14 @@ func NewConsumerMetricsManager(networkAddress string) *ConsumerMetricsManager {
 		totalErroredMetric:            totalErroredMetric,
 		blockMetric:                   blockMetric,
 		latencyMetric:                 latencyMetric,
+		timeToFirstByteMetric:         timeToFirstByteMetric,
 		qosMetric:                     qosMetric,
 		qosExcellenceMetric:           qosExcellenceMetric,
 		LatestBlockMetric:             latestBlockMetric,
 		LatestProviderRelay:           latestProviderRelay,
+		inFlightRelaysMetric:          inFlightRelaysMetric,
+		sessionEventsMetric:           sessionEventsMetric,
+		dataReliabilityMetrics:        dataReliabilityMetrics,
 		providerRelays:                map[string]uint64{},
 		virtualEpochMetric:            virtualEpochMetric,
 		endpointsHealthChecksOkMetric: endpointsHealthChecksOkMetric,
@@ -220,6 +271,29 @@ func (pme *ConsumerMetricsManager) SetQOSMetrics(chainId string, apiInterface st
 	pme.LatestBlockMetric.WithLabelValues(chainId, providerAddress, apiInterface).Set(float64(latestBlock))
 }
 
+func (pme *ConsumerMetricsManager) SetInFlightRelays(chainId string, apiInterface string, providerAddress string, inFlight int64) {
+	if pme == nil {
+		return
+	}
+	pme.inFlightRelaysMetric.WithLabelValues(chainId, providerAddress, apiInterface).Set(float64(inFlight))
+}
+
+func (pme *ConsumerMetricsManager) SetTimeToFirstByte(chainId string, apiInterface string, providerAddress string, timeToFirstByte time.Duration) {
+	if pme == nil {
+		return
+	}
+	pme.timeToFirstByteMetric.WithLabelValues(chainId, apiInterface, providerAddress).Set(float64(timeToFirstByte.Milliseconds()))
+}
+
+func (pme *ConsumerMetricsManager) AddDataReliabilityOutcome(chainId string, outcome DataReliabilityOutcome) {
+	if pme == nil {
+		return
+	}
+	if dataReliabilityMetric, ok := pme.dataReliabilityMetrics[outcome]; ok {
+		dataReliabilityMetric.WithLabelValues(chainId).Inc()
+	}
+}
+
 func (pme *ConsumerMetricsManager) SetVirtualEpoch(virtualEpoch uint64) {
 	if pme == nil {
 		return
@@ -194,6 +194,13 @@ func (rpccl *RPCConsumerLogs) AddMetricForGrpc(data *RelayMetrics, err error, me
 	}
 }
 
+func (rpccl *RPCConsumerLogs) AddDataReliabilityOutcome(chainId string, outcome DataReliabilityOutcome) {
+	if rpccl == nil {
+		return
+	}
+	rpccl.consumerMetricsManager.AddDataReliabilityOutcome(chainId, outcome)
+}
+
 func (rpccl *RPCConsumerLogs) shouldCountMetrics(refererHeaderValue string, userAgentHeaderValue string) bool {
 	if len(rpccl.excludeMetricsReferrers) > 0 && len(refererHeaderValue) > 0 {
 		if strings.Contains(refererHeaderValue, rpccl.excludeMetricsReferrers) {
@@ -0,0 +1,34 @@
+package metrics
+
+type SessionEventType string
+
+const (
+	SessionCreated SessionEventType = "created"
+	SessionReused  SessionEventType = "reused"
+	SessionRotated SessionEventType = "rotated" // dropped before reaching the cu sum the provider accepts
+	SessionFailed  SessionEventType = "failed"
+	SessionDone    SessionEventType = "done"
+)
+
+// SessionEvent is a step in the lifecycle of a consumer session with a provider
+type SessionEvent struct {
+	ChainID         string
+	ApiInterface    string
+	ProviderAddress string
+	Epoch           uint64
+	SessionID       int64
+	Type            SessionEventType
+}
+
+// SessionEventSink receives the consumer's session lifecycle events, it's called on the relay path and must not block
+type SessionEventSink interface {
+	AppendSessionEvent(event SessionEvent)
+}
+
+// AppendSessionEvent counts the event, the epoch isn't a label as it would make a new series every epoch
+func (pme *ConsumerMetricsManager) AppendSessionEvent(event SessionEvent) {
+	if pme == nil {
+		return
+	}
+	pme.sessionEventsMetric.WithLabelValues(event.ChainID, event.ApiInterface, event.ProviderAddress, string(event.Type)).Inc()
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gogo/status"
 	lvutil "github.com/lavanet/lava/ecosystem/lavavisor/pkg/util"
 	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavaprotocol"
 	"github.com/lavanet/lava/protocol/lavasession"
@@ -387,7 +388,7 @@ func CheckConsumersAndReferences(ctx context.Context,
 		var chainRouter chainlib.ChainRouter
 		for i := uint64(0); i <= QueryRetries; i++ {
 			sendCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-			chainRouter, err = chainlib.GetChainRouter(sendCtx, 1, compatibleEndpoint, chainParser)
+			chainRouter, err = chainlib.GetChainRouter(sendCtx, 1, chainproxy.KeepaliveOptions{}, compatibleEndpoint, chainParser)
 			cancel()
 			if err == nil {
 				break
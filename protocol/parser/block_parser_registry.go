@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"sync"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+// BlockNumberParser extracts the requested block from a message, tags are resolved to the spectypes sentinel values
+type BlockNumberParser func(rpcInput RPCInput) (int64, error)
+
+// special block tags and the sentinel values they resolve to
+var blockTags = map[string]int64{
+	"latest":    spectypes.LATEST_BLOCK,
+	"earliest":  spectypes.EARLIEST_BLOCK,
+	"pending":   spectypes.PENDING_BLOCK,
+	"safe":      spectypes.SAFE_BLOCK,
+	"finalized": spectypes.FINALIZED_BLOCK,
+}
+
+// BlockParserRegistry holds the custom requested block parsers of a chain parser by api name, the zero value is empty
+type BlockParserRegistry struct {
+	lock    sync.RWMutex
+	parsers map[string]BlockNumberParser
+}
+
+// Register sets a custom requested block parser for an api, overriding the spec's block parsing for it. nil removes it
+func (bpr *BlockParserRegistry) Register(apiName string, blockParser BlockNumberParser) {
+	bpr.lock.Lock()
+	defer bpr.lock.Unlock()
+	if blockParser == nil {
+		delete(bpr.parsers, apiName)
+		return
+	}
+	if bpr.parsers == nil {
+		bpr.parsers = map[string]BlockNumberParser{}
+	}
+	bpr.parsers[apiName] = blockParser
+}
+
+func (bpr *BlockParserRegistry) Get(apiName string) (BlockNumberParser, bool) {
+	bpr.lock.RLock()
+	defer bpr.lock.RUnlock()
+	blockParser, ok := bpr.parsers[apiName]
+	return blockParser, ok
+}
+
+// ParseRequestedBlock uses the parser registered for the api if there is one, and the spec's block parsing otherwise
+func (bpr *BlockParserRegistry) ParseRequestedBlock(apiName string, rpcInput RPCInput, blockParser spectypes.BlockParser) (int64, error) {
+	if registeredParser, ok := bpr.Get(apiName); ok {
+		return registeredParser(rpcInput)
+	}
+	return ParseBlockFromParams(rpcInput, blockParser)
+}
+
+// ResolveBlockTag returns the sentinel value of a special block tag such as latest or finalized
+func ResolveBlockTag(tag string) (int64, bool) {
+	block, ok := blockTags[tag]
+	return block, ok
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"strconv"
+	"testing"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBlockTag(t *testing.T) {
+	testCases := []struct {
+		tag      string
+		expected int64
+		found    bool
+	}{
+		{tag: "latest", expected: spectypes.LATEST_BLOCK, found: true},
+		{tag: "earliest", expected: spectypes.EARLIEST_BLOCK, found: true},
+		{tag: "pending", expected: spectypes.PENDING_BLOCK, found: true},
+		{tag: "safe", expected: spectypes.SAFE_BLOCK, found: true},
+		{tag: "finalized", expected: spectypes.FINALIZED_BLOCK, found: true},
+		{tag: "Latest", found: false},
+		{tag: " finalized ", found: false},
+		{tag: "0x10", found: false},
+		{tag: "", found: false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.tag, func(t *testing.T) {
+			block, found := ResolveBlockTag(testCase.tag)
+			require.Equal(t, testCase.found, found)
+			if testCase.found {
+				require.Equal(t, testCase.expected, block)
+				// the default parsing resolves tags the same way
+				parsed, err := ParseDefaultBlockParameter(testCase.tag)
+				require.NoError(t, err)
+				require.Equal(t, testCase.expected, parsed)
+			}
+		})
+	}
+}
+
+func TestBlockParserRegistry(t *testing.T) {
+	const apiName = "custom_height"
+	message := &RPCInputTest{Params: map[string]interface{}{"height": "42"}}
+	specParser := spectypes.BlockParser{ParserArg: []string{"latest"}, ParserFunc: spectypes.PARSER_FUNC_DEFAULT}
+	registry := &BlockParserRegistry{}
+
+	// no registration falls back to the spec parsing
+	block, err := registry.ParseRequestedBlock(apiName, message, specParser)
+	require.NoError(t, err)
+	require.Equal(t, spectypes.LATEST_BLOCK, block)
+
+	registry.Register(apiName, func(rpcInput RPCInput) (int64, error) {
+		height := rpcInput.GetParams().(map[string]interface{})["height"].(string)
+		if tagBlock, ok := ResolveBlockTag(height); ok {
+			return tagBlock, nil
+		}
+		return strconv.ParseInt(height, 10, 64)
+	})
+
+	block, err = registry.ParseRequestedBlock(apiName, message, specParser)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), block)
+
+	message.Params = map[string]interface{}{"height": "finalized"}
+	block, err = registry.ParseRequestedBlock(apiName, message, specParser)
+	require.NoError(t, err)
+	require.Equal(t, spectypes.FINALIZED_BLOCK, block)
+
+	// api names are matched like the spec's, case sensitive
+	block, err = registry.ParseRequestedBlock("Custom_Height", message, specParser)
+	require.NoError(t, err)
+	require.Equal(t, spectypes.LATEST_BLOCK, block)
+
+	// registrations belong to their registry
+	block, err = (&BlockParserRegistry{}).ParseRequestedBlock(apiName, message, specParser)
+	require.NoError(t, err)
+	require.Equal(t, spectypes.LATEST_BLOCK, block)
+
+	// unregistering restores the spec parsing
+	registry.Register(apiName, nil)
+	_, found := registry.Get(apiName)
+	require.False(t, found)
+}
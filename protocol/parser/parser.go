@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -29,19 +30,8 @@ type RPCInput interface {
 }
 
 func ParseDefaultBlockParameter(block string) (int64, error) {
-	switch block {
-	case "latest":
-		return spectypes.LATEST_BLOCK, nil
-	case "earliest":
-		return spectypes.EARLIEST_BLOCK, nil
-	case "pending":
-		return spectypes.PENDING_BLOCK, nil
-	case "safe":
-		return spectypes.SAFE_BLOCK, nil
-	case "finalized":
-		return spectypes.FINALIZED_BLOCK, nil
-	default:
-		// try to parse a number
+	if blockNum, ok := ResolveBlockTag(block); ok {
+		return blockNum, nil
 	}
 
 	hashNoPrefix, found := strings.CutPrefix(block, "0x")
@@ -208,6 +198,47 @@ func getDataToParse(rpcInput RPCInput, dataSource int) (interface{}, error) {
 	}
 }
 
+// parseResultField decodes the value at path in a json object result, reading the result only up to it. values
+// before it are skipped without being decoded and the rest isn't read, so big replies like full transaction blocks
+// aren't unmarshaled. ok is false when the result isn't a well formed json object, for the caller to fully parse it
+func parseResultField(result json.RawMessage, path []string) (value interface{}, ok bool, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	for depth, key := range path {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false, nil
+		}
+		if delim, isDelim := token.(json.Delim); !isDelim || delim != '{' {
+			if depth == 0 {
+				return nil, false, nil
+			}
+			return nil, true, utils.LavaFormatWarning("invalid parser input format, blockContainer is not map[string]interface{}", ValueNotSetError, utils.LogAttr("key", key))
+		}
+		found := false
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, false, nil
+			}
+			if keyToken == key {
+				found = true
+				break
+			}
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return nil, false, nil
+			}
+		}
+		if !found {
+			return nil, true, ValueNotSetError
+		}
+	}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false, nil
+	}
+	return value, true, nil
+}
+
 func blockInterfaceToString(block interface{}) string {
 	switch castedBlock := block.(type) {
 	case string:
@@ -224,6 +255,22 @@ func blockInterfaceToString(block interface{}) string {
 	}
 }
 
+// eip-1898 lets jsonrpc block params be an object selecting the block by number or by hash, the selected value is parsed
+// like a plain block param, so a block number far behind latest still needs an archive provider
+func resolveBlockParameterObject(block interface{}) interface{} {
+	blockObject, ok := block.(map[string]interface{})
+	if !ok {
+		return block
+	}
+	if blockNumber, ok := blockObject["blockNumber"]; ok {
+		return blockNumber
+	}
+	if blockHash, ok := blockObject["blockHash"]; ok {
+		return blockHash
+	}
+	return block
+}
+
 func parseByArg(rpcInput RPCInput, input []string, dataSource int) ([]interface{}, error) {
 	// specified block is one of the direct parameters, input should be one string defining the location of the block
 	if len(input) != 1 {
@@ -245,6 +292,9 @@ func parseByArg(rpcInput RPCInput, input []string, dataSource int) ([]interface{
 			return nil, ValueNotSetError
 		}
 		block := unmarshaledDataTyped[param_index]
+		if dataSource == PARSE_PARAMS {
+			block = resolveBlockParameterObject(block)
+		}
 		// TODO: turn this into type assertion instead
 
 		retArr := make([]interface{}, 0)
@@ -268,6 +318,15 @@ func parseByArg(rpcInput RPCInput, input []string, dataSource int) ([]interface{
 //
 // should output an interface array with "wanted result" in first index 0
 func parseCanonical(rpcInput RPCInput, input []string, dataSource int) ([]interface{}, error) {
+	if dataSource == PARSE_RESULT && len(input) > 1 && input[0] == "0" {
+		// results are parsed as a single element list, only the fields on the path are decoded
+		if value, ok, err := parseResultField(rpcInput.GetResult(), input[1:]); ok {
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{blockInterfaceToString(value)}, nil
+		}
+	}
 	unmarshalledData, err := getDataToParse(rpcInput, dataSource)
 	if err != nil {
 		return nil, fmt.Errorf("invalid input format, data is not json: %s, error: %s", unmarshalledData, err)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
@@ -229,6 +230,28 @@ func TestParseBlockFromParamsHappyFlow(t *testing.T) {
 			},
 			expectedBlock: 1,
 		},
+		{
+			name: "ParseByArgBlockNumberObject",
+			message: RPCInputTest{
+				Params: []interface{}{"0xaa", map[string]interface{}{"blockNumber": "0x10"}},
+			},
+			blockParser: spectypes.BlockParser{
+				ParserArg:  []string{"1"},
+				ParserFunc: spectypes.PARSER_FUNC_PARSE_BY_ARG,
+			},
+			expectedBlock: 16,
+		},
+		{
+			name: "ParseByArgBlockHashObject",
+			message: RPCInputTest{
+				Params: []interface{}{"0xaa", map[string]interface{}{"blockHash": "0x" + strings.Repeat("ab", 32), "requireCanonical": true}},
+			},
+			blockParser: spectypes.BlockParser{
+				ParserArg:  []string{"1"},
+				ParserFunc: spectypes.PARSER_FUNC_PARSE_BY_ARG,
+			},
+			expectedBlock: spectypes.LATEST_BLOCK,
+		},
 		{
 			name: "ParseCanonical__[]interface{}__Case",
 			message: RPCInputTest{
@@ -404,3 +427,84 @@ func TestParseBlockFromReplyHappyFlow(t *testing.T) {
 		})
 	}
 }
+
+func TestParseResultField(t *testing.T) {
+	playbook := []struct {
+		name     string
+		result   string
+		path     []string
+		expected string
+		valueErr bool
+	}{
+		{name: "top level field", result: `{"hash":"0xab","number":"0x10","transactions":[{"nonce":"0x1"}]}`, path: []string{"number"}, expected: "0x10"},
+		{name: "field after a big list", result: `{"transactions":[{"nonce":"0x1"},{"nonce":"0x2"}],"number":"0x10"}`, path: []string{"number"}, expected: "0x10"},
+		{name: "nested field", result: `{"block_id":{"hash":"ab"},"block":{"header":{"height":"25"}}}`, path: []string{"block", "header", "height"}, expected: "25"},
+		{name: "number value", result: `{"block":25}`, path: []string{"block"}, expected: "25"},
+		{name: "missing field", result: `{"hash":"0xab"}`, path: []string{"number"}, valueErr: true},
+		{name: "not an object on the path", result: `{"block":"25"}`, path: []string{"block", "height"}, valueErr: true},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			parsed, err := parseCanonical(&RPCInputTest{Result: []byte(play.result)}, append([]string{"0"}, play.path...), PARSE_RESULT)
+			if play.valueErr {
+				require.True(t, ValueNotSetError.Is(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []interface{}{play.expected}, parsed)
+		})
+	}
+
+	// results that aren't json objects are left to the full parsing
+	for _, result := range []string{`"0x10"`, `null`, `{"number":`, ``} {
+		_, ok, _ := parseResultField([]byte(result), []string{"number"})
+		require.False(t, ok, result)
+	}
+}
+
+func largeBlockResult(txs int) []byte {
+	transactions := make([]map[string]string, txs)
+	for i := range transactions {
+		transactions[i] = map[string]string{
+			"blockNumber": "0x10",
+			"from":        "0x" + strings.Repeat("a", 40),
+			"gas":         "0x5208",
+			"hash":        fmt.Sprintf("0x%064x", i),
+			"input":       "0x" + strings.Repeat("b", 512),
+			"nonce":       fmt.Sprintf("0x%x", i),
+			"to":          "0x" + strings.Repeat("c", 40),
+			"value":       "0x0",
+		}
+	}
+	// field order as served by geth, the number comes before the transactions
+	result, _ := json.Marshal(map[string]interface{}{
+		"hash":         "0x" + strings.Repeat("d", 64),
+		"number":       "0x10",
+		"parentHash":   "0x" + strings.Repeat("e", 64),
+		"transactions": transactions,
+	})
+	return result
+}
+
+func BenchmarkParseBlockFromLargeReply(b *testing.B) {
+	input := &RPCInputTest{Result: largeBlockResult(2000)}
+	b.Run("full unmarshal", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			data, err := getDataToParse(input, PARSE_RESULT)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if data.([]interface{})[0].(map[string]interface{})["number"] != "0x10" {
+				b.Fatal("wrong block")
+			}
+		}
+	})
+	b.Run("streamed field", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			parsed, err := parseCanonical(input, []string{"0", "number"}, PARSE_RESULT)
+			if err != nil || parsed[0] != "0x10" {
+				b.Fatal("wrong block", err)
+			}
+		}
+	})
+}
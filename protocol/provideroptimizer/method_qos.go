@@ -0,0 +1,66 @@
+package provideroptimizer
+
+import (
+	"time"
+)
+
+const (
+	MethodQoSTrackingFlag  = "method-qos-tracking"
+	MethodCacheMaxCost     = 20000  // provider and method pairs, each costs 1
+	MethodCacheNumCounters = 200000 // expect 20000 items
+)
+
+// track qos per provider and method next to the per provider aggregate, so a method is routed to the providers serving
+// it best. the methods are kept in a storage of their own so they don't evict the aggregates, disabled only the
+// aggregates are kept
+var MethodQoSTracking = false
+
+func methodStorageKey(providerAddress string, method string) string {
+	return providerAddress + "|" + method
+}
+
+func (po *ProviderOptimizer) tracksMethod(method string) bool {
+	return MethodQoSTracking && method != ""
+}
+
+// AppendMethodRelayData is AppendRelayData that also updates the provider's qos for the relay's method
+func (po *ProviderOptimizer) AppendMethodRelayData(providerAddress string, method string, latency time.Duration, isHangingApi bool, cu, syncBlock uint64) {
+	sampleTime := time.Now()
+	po.appendRelayData(providerAddress, latency, isHangingApi, true, cu, syncBlock, sampleTime)
+	if po.tracksMethod(method) {
+		po.appendMethodRelayData(providerAddress, method, latency, isHangingApi, true, cu, syncBlock, sampleTime)
+	}
+}
+
+// AppendMethodRelayFailure is AppendRelayFailure that also updates the provider's qos for the relay's method
+func (po *ProviderOptimizer) AppendMethodRelayFailure(providerAddress string, method string) {
+	sampleTime := time.Now()
+	po.appendRelayData(providerAddress, 0, false, false, 0, 0, sampleTime)
+	if po.tracksMethod(method) {
+		po.appendMethodRelayData(providerAddress, method, 0, false, false, 0, 0, sampleTime)
+	}
+}
+
+func (po *ProviderOptimizer) appendMethodRelayData(providerAddress string, method string, latency time.Duration, isHangingApi, success bool, cu, syncBlock uint64, sampleTime time.Time) {
+	key := methodStorageKey(providerAddress, method)
+	providerData, _ := getStoredProviderData(po.methodsStorage, key)
+	// the provider's relay rate sets the decay of its methods too
+	halfTime := po.calculateHalfTime(providerAddress, sampleTime)
+	providerData = po.updateRelayData(providerData, latency, isHangingApi, success, cu, syncBlock, halfTime, sampleTime)
+	po.methodsStorage.Set(key, providerData, 1)
+}
+
+// ChooseProviderForMethod is ChooseProvider scoring providers by their qos for the method, providers without data for
+// it are scored by their aggregate
+func (po *ProviderOptimizer) ChooseProviderForMethod(allAddresses []string, ignoredProviders map[string]struct{}, cu uint64, requestedBlock int64, perturbationPercentage float64, method string) (addresses []string) {
+	return po.chooseProvider(allAddresses, ignoredProviders, cu, requestedBlock, perturbationPercentage, method)
+}
+
+func (po *ProviderOptimizer) getProviderDataForMethod(providerAddress string, method string) (providerData ProviderData, found bool) {
+	if po.tracksMethod(method) {
+		if providerData, found = getStoredProviderData(po.methodsStorage, methodStorageKey(providerAddress, method)); found {
+			return providerData, found
+		}
+	}
+	return po.getProviderData(providerAddress)
+}
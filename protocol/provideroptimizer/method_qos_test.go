@@ -0,0 +1,60 @@
+package provideroptimizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodQoSRouting(t *testing.T) {
+	providerOptimizer := setupProviderOptimizer(1)
+	providersGen := (&providersGenerator{}).setupProvidersForTest(2)
+	rand.InitRandomSeed()
+	requestCU := uint64(10)
+	requestBlock := int64(1000)
+	syncBlock := uint64(requestBlock)
+	// provider 0 serves light calls well and heavy ones badly, provider 1 is the opposite
+	appendSamples := func() {
+		for i := 0; i < 5; i++ {
+			providerOptimizer.AppendMethodRelayData(providersGen.providersAddresses[0], "eth_blockNumber", TEST_BASE_WORLD_LATENCY/4, false, requestCU, syncBlock)
+			providerOptimizer.AppendMethodRelayData(providersGen.providersAddresses[0], "eth_getLogs", TEST_BASE_WORLD_LATENCY*8, false, requestCU, syncBlock)
+			providerOptimizer.AppendMethodRelayData(providersGen.providersAddresses[1], "eth_blockNumber", TEST_BASE_WORLD_LATENCY*2, false, requestCU, syncBlock)
+			providerOptimizer.AppendMethodRelayData(providersGen.providersAddresses[1], "eth_getLogs", TEST_BASE_WORLD_LATENCY, false, requestCU, syncBlock)
+		}
+		time.Sleep(4 * time.Millisecond)
+	}
+	choose := func(method string) string {
+		return providerOptimizer.ChooseProviderForMethod(providersGen.providersAddresses, nil, requestCU, requestBlock, 0, method)[0]
+	}
+
+	// disabled, every method is routed by the aggregate
+	appendSamples()
+	require.Equal(t, choose("eth_blockNumber"), choose("eth_getLogs"))
+	_, found := getStoredProviderData(providerOptimizer.methodsStorage, methodStorageKey(providersGen.providersAddresses[0], "eth_getLogs"))
+	require.False(t, found)
+
+	defer func(enabled bool) { MethodQoSTracking = enabled }(MethodQoSTracking)
+	MethodQoSTracking = true
+	appendSamples()
+	require.Equal(t, providersGen.providersAddresses[0], choose("eth_blockNumber"))
+	require.Equal(t, providersGen.providersAddresses[1], choose("eth_getLogs"))
+	// methods without data fall back to the aggregate
+	require.Equal(t, choose(""), choose("eth_chainId"))
+
+	// failures count against the method too
+	for i := 0; i < 20; i++ {
+		providerOptimizer.AppendMethodRelayFailure(providersGen.providersAddresses[1], "eth_getLogs")
+	}
+	time.Sleep(4 * time.Millisecond)
+	methodData, found := providerOptimizer.getProviderDataForMethod(providersGen.providersAddresses[1], "eth_getLogs")
+	require.True(t, found)
+	aggregateData, _ := providerOptimizer.getProviderData(providersGen.providersAddresses[1])
+	require.Less(t, methodData.Availability.Num/methodData.Availability.Denom, 0.5)
+	require.Less(t, aggregateData.Availability.Num/aggregateData.Availability.Denom, 0.5)
+
+	// methods live apart from the aggregates
+	_, found = providerOptimizer.getProviderData(methodStorageKey(providersGen.providersAddresses[1], "eth_getLogs"))
+	require.False(t, found)
+}
@@ -29,6 +29,7 @@ const (
 	DEFAULT_EXPLORATION_CHANCE = 0.1
 	COST_EXPLORATION_CHANCE    = 0.01
 	WANTED_PRECISION           = int64(8)
+	unqualifiedProbability     = 0.5 // failure probability above which a provider isn't qualified for a relay
 )
 
 type ConcurrentBlockStore struct {
@@ -45,7 +46,9 @@ type cacheInf interface {
 type ProviderOptimizer struct {
 	strategy                        Strategy
 	providersStorage                cacheInf
+	methodsStorage                  cacheInf         // per provider and method, bounded apart so methods don't evict providers
 	providerRelayStats              *ristretto.Cache // used to decide on the half time of the decay
+	providersTimeToFirstByte        *ristretto.Cache // a qos dimension of its own, not used for selection yet
 	averageBlockTime                time.Duration
 	baseWorldLatency                time.Duration
 	wantedNumProvidersInConcurrency uint
@@ -80,9 +83,19 @@ func (po *ProviderOptimizer) AppendRelayData(providerAddress string, latency tim
 }
 
 func (po *ProviderOptimizer) appendRelayData(providerAddress string, latency time.Duration, isHangingApi, success bool, cu, syncBlock uint64, sampleTime time.Time) {
-	latestSync, timeSync := po.updateLatestSyncData(syncBlock, sampleTime)
 	providerData, _ := po.getProviderData(providerAddress)
 	halfTime := po.calculateHalfTime(providerAddress, sampleTime)
+	providerData = po.updateRelayData(providerData, latency, isHangingApi, success, cu, syncBlock, halfTime, sampleTime)
+	po.providersStorage.Set(providerAddress, providerData, 1)
+	po.updateRelayTime(providerAddress, sampleTime)
+	if debug {
+		utils.LavaFormatDebug("relay update", utils.Attribute{Key: "providerData", Value: providerData}, utils.Attribute{Key: "syncBlock", Value: syncBlock}, utils.Attribute{Key: "cu", Value: cu}, utils.Attribute{Key: "providerAddress", Value: providerAddress}, utils.Attribute{Key: "latency", Value: latency}, utils.Attribute{Key: "success", Value: success})
+	}
+}
+
+// updateRelayData returns providerData updated with a relay's result
+func (po *ProviderOptimizer) updateRelayData(providerData ProviderData, latency time.Duration, isHangingApi, success bool, cu, syncBlock uint64, halfTime time.Duration, sampleTime time.Time) ProviderData {
+	latestSync, timeSync := po.updateLatestSyncData(syncBlock, sampleTime)
 	providerData = po.updateProbeEntryAvailability(providerData, success, RELAY_UPDATE_WEIGHT, halfTime, sampleTime)
 	if success {
 		if latency > 0 {
@@ -99,11 +112,7 @@ func (po *ProviderOptimizer) appendRelayData(providerAddress string, latency tim
 		syncLag := po.calculateSyncLag(latestSync, timeSync, providerData.SyncBlock, sampleTime)
 		providerData = po.updateProbeEntrySync(providerData, syncLag, po.averageBlockTime, halfTime, sampleTime)
 	}
-	po.providersStorage.Set(providerAddress, providerData, 1)
-	po.updateRelayTime(providerAddress, sampleTime)
-	if debug {
-		utils.LavaFormatDebug("relay update", utils.Attribute{Key: "providerData", Value: providerData}, utils.Attribute{Key: "syncBlock", Value: syncBlock}, utils.Attribute{Key: "cu", Value: cu}, utils.Attribute{Key: "providerAddress", Value: providerAddress}, utils.Attribute{Key: "latency", Value: latency}, utils.Attribute{Key: "success", Value: success})
-	}
+	return providerData
 }
 
 func (po *ProviderOptimizer) AppendProbeRelayData(providerAddress string, latency time.Duration, success bool) {
@@ -123,6 +132,17 @@ func (po *ProviderOptimizer) AppendProbeRelayData(providerAddress string, latenc
 
 // returns a sub set of selected providers according to their scores, perturbation factor will be added to each score in order to randomly select providers that are not always on top
 func (po *ProviderOptimizer) ChooseProvider(allAddresses []string, ignoredProviders map[string]struct{}, cu uint64, requestedBlock int64, perturbationPercentage float64) (addresses []string) {
+	return po.chooseProvider(allAddresses, ignoredProviders, cu, requestedBlock, perturbationPercentage, "")
+}
+
+// IsProviderQualified reports whether the provider's qos lets it serve a relay it was chosen for without comparing it to
+// the others, it isn't more likely to time out or to miss the requested block than to succeed
+func (po *ProviderOptimizer) IsProviderQualified(providerAddress string, requestedBlock int64, method string) bool {
+	providerData, _ := po.getProviderDataForMethod(providerAddress, method)
+	return po.CalculateProbabilityOfTimeout(providerData.Availability) <= unqualifiedProbability && po.CalculateProbabilityOfBlockError(requestedBlock, providerData) <= unqualifiedProbability
+}
+
+func (po *ProviderOptimizer) chooseProvider(allAddresses []string, ignoredProviders map[string]struct{}, cu uint64, requestedBlock int64, perturbationPercentage float64, method string) (addresses []string) {
 	returnedProviders := make([]string, 1) // location 0 is always the best score
 	latencyScore := math.MaxFloat64        // smaller = better i.e less latency
 	syncScore := math.MaxFloat64           // smaller = better i.e less sync lag
@@ -136,7 +156,7 @@ func (po *ProviderOptimizer) ChooseProvider(allAddresses []string, ignoredProvid
 			// ignored provider, skip it
 			continue
 		}
-		providerData, found := po.getProviderData(providerAddress)
+		providerData, found := po.getProviderDataForMethod(providerAddress, method)
 		if debug && !found {
 			utils.LavaFormatDebug("provider data was not found for address", utils.Attribute{Key: "providerAddress", Value: providerAddress})
 		}
@@ -334,7 +354,11 @@ func (po *ProviderOptimizer) CalculateProbabilityOfBlockError(requestedBlock int
 }
 
 func (po *ProviderOptimizer) getProviderData(providerAddress string) (providerData ProviderData, found bool) {
-	storedVal, found := po.providersStorage.Get(providerAddress)
+	return getStoredProviderData(po.providersStorage, providerAddress)
+}
+
+func getStoredProviderData(storage cacheInf, key string) (providerData ProviderData, found bool) {
+	storedVal, found := storage.Get(key)
 	if found {
 		var ok bool
 
@@ -391,13 +415,19 @@ func (po *ProviderOptimizer) updateRelayTime(providerAddress string, sampleTime
 }
 
 func (po *ProviderOptimizer) calculateHalfTime(providerAddress string, sampleTime time.Time) time.Duration {
-	halfTime := HALF_LIFE_TIME
+	halfTime, maxHalfTime := QoSHalfLife, QoSMaxHalfLife
+	if halfTime <= 0 {
+		halfTime = HALF_LIFE_TIME
+	}
+	if maxHalfTime <= 0 {
+		maxHalfTime = MAX_HALF_TIME
+	}
 	relaysHalfTime := po.getRelayStatsTimeDiff(providerAddress, sampleTime)
 	if relaysHalfTime > halfTime {
 		halfTime = relaysHalfTime
 	}
-	if halfTime > MAX_HALF_TIME {
-		halfTime = MAX_HALF_TIME
+	if halfTime > maxHalfTime {
+		halfTime = maxHalfTime
 	}
 	return halfTime
 }
@@ -436,11 +466,19 @@ func NewProviderOptimizer(strategy Strategy, averageBlockTIme, baseWorldLatency
 	if err != nil {
 		utils.LavaFormatFatal("failed setting up cache for queries", err)
 	}
+	timeToFirstByteCache, err := ristretto.NewCache(&ristretto.Config{NumCounters: CacheNumCounters, MaxCost: CacheMaxCost, BufferItems: 64, IgnoreInternalCost: true})
+	if err != nil {
+		utils.LavaFormatFatal("failed setting up cache for queries", err)
+	}
+	methodsCache, err := ristretto.NewCache(&ristretto.Config{NumCounters: MethodCacheNumCounters, MaxCost: MethodCacheMaxCost, BufferItems: 64, IgnoreInternalCost: true})
+	if err != nil {
+		utils.LavaFormatFatal("failed setting up cache for queries", err)
+	}
 	if strategy == STRATEGY_PRIVACY {
 		// overwrite
 		wantedNumProvidersInConcurrency = 1
 	}
-	return &ProviderOptimizer{strategy: strategy, providersStorage: cache, averageBlockTime: averageBlockTIme, baseWorldLatency: baseWorldLatency, providerRelayStats: relayCache, wantedNumProvidersInConcurrency: wantedNumProvidersInConcurrency}
+	return &ProviderOptimizer{strategy: strategy, providersStorage: cache, methodsStorage: methodsCache, averageBlockTime: averageBlockTIme, baseWorldLatency: baseWorldLatency, providerRelayStats: relayCache, providersTimeToFirstByte: timeToFirstByteCache, wantedNumProvidersInConcurrency: wantedNumProvidersInConcurrency}
 }
 
 // calculate the probability a random variable with a poisson distribution
@@ -572,3 +572,20 @@ func TestPerturbationWithNormalGaussianOnConcurrentComputation(t *testing.T) {
 	wg.Wait()
 	fmt.Println("Test completed successfully")
 }
+
+func TestProviderOptimizerIsProviderQualified(t *testing.T) {
+	providerOptimizer := setupProviderOptimizer(1)
+	providersGen := (&providersGenerator{}).setupProvidersForTest(2)
+	healthy, failing := providersGen.providersAddresses[0], providersGen.providersAddresses[1]
+	requestBlock := int64(1000)
+	// providers without data are qualified
+	require.True(t, providerOptimizer.IsProviderQualified(failing, requestBlock, ""))
+
+	providerOptimizer.AppendRelayData(healthy, TEST_BASE_WORLD_LATENCY, false, 10, uint64(requestBlock))
+	for i := 0; i < 3; i++ {
+		providerOptimizer.AppendRelayFailure(failing)
+	}
+	time.Sleep(4 * time.Millisecond)
+	require.True(t, providerOptimizer.IsProviderQualified(healthy, requestBlock, ""))
+	require.False(t, providerOptimizer.IsProviderQualified(failing, requestBlock, ""))
+}
@@ -0,0 +1,14 @@
+package provideroptimizer
+
+const (
+	QoSHalfLifeFlag    = "optimizer-qos-half-life"
+	QoSMaxHalfLifeFlag = "optimizer-qos-max-half-life"
+)
+
+var (
+	// half life of the decay applied to latency, availability and sync samples, recent samples weigh more than old ones
+	QoSHalfLife = HALF_LIFE_TIME
+	// the half life grows with the age of a provider's relays up to this, so a provider relayed to rarely keeps its
+	// scores longer. lower it to let a provider recover from an old penalty faster
+	QoSMaxHalfLife = MAX_HALF_TIME
+)
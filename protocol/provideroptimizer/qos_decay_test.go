@@ -0,0 +1,52 @@
+package provideroptimizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQoSDecayRecovery(t *testing.T) {
+	rand.InitRandomSeed()
+	const (
+		penalized = "lava@penalized"
+		steady    = "lava@steady"
+	)
+	requestCU := uint64(10)
+	requestBlock := int64(1000)
+	// the penalized provider fails a burst of relays, then recovers and serves faster than the steady provider
+	playRecovery := func(recoveryPeriod time.Duration) string {
+		providerOptimizer := setupProviderOptimizer(1)
+		start := time.Now().Add(-recoveryPeriod)
+		for i := 0; i < 10; i++ {
+			sampleTime := start.Add(time.Duration(i) * time.Millisecond)
+			providerOptimizer.appendRelayData(penalized, 0, false, false, 0, 0, sampleTime)
+			providerOptimizer.appendRelayData(steady, TEST_BASE_WORLD_LATENCY*2, false, true, requestCU, 0, sampleTime)
+			time.Sleep(4 * time.Millisecond)
+		}
+		recovered := start.Add(recoveryPeriod)
+		for i := 0; i < 10; i++ {
+			sampleTime := recovered.Add(time.Duration(i) * time.Millisecond)
+			providerOptimizer.appendRelayData(penalized, TEST_BASE_WORLD_LATENCY, false, true, requestCU, 0, sampleTime)
+			providerOptimizer.appendRelayData(steady, TEST_BASE_WORLD_LATENCY*2, false, true, requestCU, 0, sampleTime)
+			time.Sleep(4 * time.Millisecond)
+		}
+		returnedProviders := providerOptimizer.ChooseProvider([]string{penalized, steady}, nil, requestCU, requestBlock, 0)
+		require.Len(t, returnedProviders, 1)
+		return returnedProviders[0]
+	}
+
+	// with the default decay a short recovery doesn't outweigh the failures
+	require.Equal(t, steady, playRecovery(10*time.Minute))
+	// old failures fade once enough half lives passed
+	require.Equal(t, penalized, playRecovery(48*time.Hour))
+
+	// a shorter half life lets the provider regain preference sooner
+	defer func(halfLife, maxHalfLife time.Duration) {
+		QoSHalfLife, QoSMaxHalfLife = halfLife, maxHalfLife
+	}(QoSHalfLife, QoSMaxHalfLife)
+	QoSHalfLife, QoSMaxHalfLife = time.Minute, time.Minute
+	require.Equal(t, penalized, playRecovery(10*time.Minute))
+}
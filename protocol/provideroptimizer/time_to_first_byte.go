@@ -0,0 +1,40 @@
+package provideroptimizer
+
+import (
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/lavanet/lava/utils/score"
+)
+
+// AppendTimeToFirstByte updates the provider's time to first byte, the relay itself is reported separately with its
+// total latency so availability, latency and sync aren't affected
+func (po *ProviderOptimizer) AppendTimeToFirstByte(providerAddress string, timeToFirstByte time.Duration) {
+	sampleTime := time.Now()
+	oldScore, _ := po.getTimeToFirstByteScore(providerAddress)
+	halfTime := po.calculateHalfTime(providerAddress, sampleTime)
+	// a denominator of 1 keeps the score a decayed average in seconds
+	newScore := score.NewScoreStore(timeToFirstByte.Seconds(), 1, sampleTime)
+	po.providersTimeToFirstByte.Set(providerAddress, score.CalculateTimeDecayFunctionUpdate(oldScore, newScore, halfTime, RELAY_UPDATE_WEIGHT, sampleTime), 1)
+}
+
+// GetTimeToFirstByte returns the provider's decayed average time to first byte, ok is false before it's measured
+func (po *ProviderOptimizer) GetTimeToFirstByte(providerAddress string) (timeToFirstByte time.Duration, ok bool) {
+	timeToFirstByteScore, found := po.getTimeToFirstByteScore(providerAddress)
+	if !found || timeToFirstByteScore.Denom == 0 {
+		return 0, false
+	}
+	return time.Duration(timeToFirstByteScore.Num / timeToFirstByteScore.Denom * float64(time.Second)), true
+}
+
+func (po *ProviderOptimizer) getTimeToFirstByteScore(providerAddress string) (timeToFirstByteScore score.ScoreStore, found bool) {
+	storedVal, found := po.providersTimeToFirstByte.Get(providerAddress)
+	if !found {
+		return score.ScoreStore{}, false
+	}
+	timeToFirstByteScore, ok := storedVal.(score.ScoreStore)
+	if !ok {
+		utils.LavaFormatFatal("invalid usage of optimizer time to first byte storage", nil, utils.Attribute{Key: "storedVal", Value: storedVal})
+	}
+	return timeToFirstByteScore, true
+}
@@ -0,0 +1,34 @@
+package provideroptimizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeToFirstByte(t *testing.T) {
+	providerOptimizer := setupProviderOptimizer(1)
+	providerAddress := "lava@provider"
+	_, ok := providerOptimizer.GetTimeToFirstByte(providerAddress)
+	require.False(t, ok)
+
+	providerOptimizer.AppendRelayData(providerAddress, 500*time.Millisecond, false, 10, 100)
+	time.Sleep(4 * time.Millisecond)
+	before, found := providerOptimizer.getProviderData(providerAddress)
+	require.True(t, found)
+	providerOptimizer.AppendTimeToFirstByte(providerAddress, 20*time.Millisecond)
+	time.Sleep(4 * time.Millisecond)
+	providerOptimizer.AppendTimeToFirstByte(providerAddress, 40*time.Millisecond)
+	time.Sleep(4 * time.Millisecond)
+
+	timeToFirstByte, ok := providerOptimizer.GetTimeToFirstByte(providerAddress)
+	require.True(t, ok)
+	require.InDelta(t, 30*time.Millisecond, timeToFirstByte, float64(time.Millisecond))
+	// the other qos dimensions are kept as they were
+	after, found := providerOptimizer.getProviderData(providerAddress)
+	require.True(t, found)
+	require.Equal(t, before.Latency, after.Latency)
+	require.Equal(t, before.Availability, after.Availability)
+	require.Equal(t, before.Sync, after.Sync)
+}
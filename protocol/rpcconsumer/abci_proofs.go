@@ -0,0 +1,96 @@
+package rpcconsumer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+const minAppHashRetryInterval = 100 * time.Millisecond
+
+var ABCIProofUnverifiedError = sdkerrors.New("ABCIProofUnverified Error", 696, "couldn't get the app hash to verify the abci query proof")
+
+// validateABCIQueryProof verifies the proof of an abci_query reply to a path the endpoint opted in to, against the app
+// hash another provider reports. queries the client didn't ask a proof for pass as is, a missing proof fails the relay
+// and penalizes the provider, and a proof that can't be checked fails the relay rather than returning it unverified,
+// without penalizing the provider.
+// the app hash is taken from the other provider's signed relay reply, the commit's validator signatures aren't
+// checked. this is weaker than a light client: it catches a single provider forging state, not two colluding ones
+func (rpccs *RPCConsumerServer) validateABCIQueryProof(ctx context.Context, chainMessage chainlib.ChainMessage, relayRequest *pairingtypes.RelayRequest, reply *pairingtypes.RelayReply, providerPublicAddress string, relayTimeout time.Duration) error {
+	if len(rpccs.listenEndpoint.ABCIProofPaths) == 0 || rpccs.listenEndpoint.ApiInterface != spectypes.APIInterfaceTendermintRPC || chainMessage.GetApi().Name != chainlib.ABCIQueryMethod {
+		return nil
+	}
+	path, prove := chainlib.ABCIQueryParams(relayRequest.RelayData.Data, relayRequest.RelayData.ApiUrl)
+	if !prove || !chainlib.ABCIProofRequired(rpccs.listenEndpoint.ABCIProofPaths, path) {
+		return nil
+	}
+	response, err := chainlib.DecodeABCIQueryReply(reply.Data)
+	if err != nil || response.IsErr() {
+		// node errors carry no proof, they're returned to the client as they are
+		return nil
+	}
+	if response.ProofOps == nil || len(response.ProofOps.Ops) == 0 {
+		err := sdkerrors.Wrapf(chainlib.InvalidABCIQueryProofError, "response to %s has no proof", path)
+		utils.LavaFormatWarning("provider returned an abci query without the requested proof", err, utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerPublicAddress))
+		rpccs.consumerSessionManager.OnReplyValidationFailure(providerPublicAddress)
+		return err
+	}
+	// the app hash committing to the state at a height is in the next block's header, for queries at the tip it's
+	// waited for within the relay timeout
+	fetchCtx, cancel := context.WithTimeout(ctx, relayTimeout)
+	defer cancel()
+	appHash, err := rpccs.fetchAppHash(fetchCtx, response.Height+1, providerPublicAddress)
+	if err != nil {
+		return utils.LavaFormatWarning("couldn't get the app hash to verify an abci query proof, failing the relay", ABCIProofUnverifiedError, utils.LogAttr("GUID", ctx), utils.LogAttr("path", path), utils.LogAttr("height", response.Height), utils.LogAttr("error", err))
+	}
+	if err := chainlib.VerifyABCIQueryProof(path, response, appHash); err != nil {
+		utils.LavaFormatWarning("provider returned an abci query with an invalid proof", err, utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerPublicAddress))
+		rpccs.consumerSessionManager.OnReplyValidationFailure(providerPublicAddress)
+		return err
+	}
+	return nil
+}
+
+// fetchAppHash gets the app hash of the header at height from a provider other than the one being verified, retrying
+// until ctx is done while the header doesn't exist yet
+func (rpccs *RPCConsumerServer) fetchAppHash(ctx context.Context, height int64, verifiedProvider string) ([]byte, error) {
+	_, averageBlockTime, _, _ := rpccs.chainParser.ChainBlockStats()
+	retryInterval := averageBlockTime / 4
+	if retryInterval < minAppHashRetryInterval {
+		retryInterval = minAppHashRetryInterval
+	}
+	for {
+		appHash, err := rpccs.fetchAppHashOnce(ctx, height, verifiedProvider)
+		if err == nil {
+			return appHash, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (rpccs *RPCConsumerServer) fetchAppHashOnce(ctx context.Context, height int64, verifiedProvider string) ([]byte, error) {
+	data := []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"commit","params":{"height":"%d"}}`, height))
+	chainMessage, err := rpccs.chainParser.ParseMsg("", data, "", nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	if err != nil {
+		return nil, err
+	}
+	relayRequestData := lavaprotocol.NewRelayData(ctx, "", "", data, 0, height, rpccs.listenEndpoint.ApiInterface, chainMessage.GetRPCMessage().GetHeaders(), chainlib.GetAddon(chainMessage), nil)
+	unwantedProviders := map[string]struct{}{verifiedProvider: {}}
+	relayResult, err := rpccs.sendRelayToProvider(ctx, chainMessage, relayRequestData, "-abci-proof-", "", &unwantedProviders, 0)
+	if err != nil {
+		return nil, err
+	}
+	return chainlib.AppHashFromCommitReply(relayResult.Reply.Data)
+}
@@ -0,0 +1,65 @@
+package rpcconsumer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+// tipCommitRelayer answers commit relays with an error until the header exists, after pendingRelays relays
+type tipCommitRelayer struct {
+	mockRelayer
+	pendingRelays int32
+	relays        atomic.Int32
+}
+
+func (tcr *tipCommitRelayer) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	data := []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"height 11 must be less than or equal to the current blockchain height 10"}}`)
+	if tcr.relays.Add(1) > tcr.pendingRelays {
+		data = []byte(`{"jsonrpc":"2.0","id":1,"result":{"signed_header":{"header":{"app_hash":"AABB"}}}}`)
+	}
+	return lavaprotocol.SignRelayResponse(tcr.consumerAddress, *request, tcr.privKey, &pairingtypes.RelayReply{Data: data, LatestBlock: 100}, false)
+}
+
+func TestFetchAppHashWaitsForTheNextHeader(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("LAV1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	spec.AverageBlockTime = 400         // retries every 100ms
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	relayer := &tipCommitRelayer{mockRelayer: mockRelayer{consumerAddress: consumerAddress, privKey: providerKey}, pendingRelays: 2}
+	rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceTendermintRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	rpccs.chainParser = chainParser
+	rpccs.listenEndpoint.ApiInterface = spectypes.APIInterfaceTendermintRPC
+
+	// the header isn't there for the first relays
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	appHash, err := rpccs.fetchAppHash(ctx, 11, "")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xaa, 0xbb}, appHash)
+	require.Equal(t, int32(3), relayer.relays.Load())
+
+	// gives up when the header doesn't show up in time
+	relayer.relays.Store(0)
+	relayer.pendingRelays = 1000
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = rpccs.fetchAppHash(ctx, 11, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package rpcconsumer
+
+import (
+	"sync/atomic"
+
+	"github.com/lavanet/lava/utils"
+)
+
+// bufferedSink hands items to consume from a single goroutine off the relay path, items pushed while the buffer is
+// full are dropped instead of holding replies back
+type bufferedSink[T any] struct {
+	name    string // what the items are, for logs
+	items   chan T
+	stop    chan struct{}
+	done    chan struct{}
+	dropped uint64
+}
+
+func newBufferedSink[T any](name string, bufferSize int, consume func(T)) *bufferedSink[T] {
+	sink := &bufferedSink[T]{name: name, items: make(chan T, bufferSize), stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(sink.done)
+		for {
+			select {
+			case item := <-sink.items:
+				consume(item)
+			case <-sink.stop:
+				// hand over what was buffered before stopping
+				for {
+					select {
+					case item := <-sink.items:
+						consume(item)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return sink
+}
+
+// push never blocks, attributes describe the item when it's dropped
+func (bs *bufferedSink[T]) push(item T, attributes ...utils.Attribute) {
+	select {
+	case bs.items <- item:
+	default:
+		dropped := atomic.AddUint64(&bs.dropped, 1)
+		attributes = append(attributes, utils.LogAttr("dropped", dropped))
+		utils.LavaFormatDebug(bs.name+" buffer is full, dropping", attributes...)
+	}
+}
+
+// the number of items dropped because the consumer fell behind
+func (bs *bufferedSink[T]) droppedItems() uint64 {
+	return atomic.LoadUint64(&bs.dropped)
+}
+
+// close stops consuming once the buffered items are consumed, it returns after they are
+func (bs *bufferedSink[T]) close() {
+	close(bs.stop)
+	<-bs.done
+}
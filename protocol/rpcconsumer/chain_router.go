@@ -0,0 +1,33 @@
+package rpcconsumer
+
+import (
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+const (
+	ChainRouterListenFlag       = "chain-router-listen"
+	ChainRouterApiInterfaceFlag = "chain-router-api-interface"
+)
+
+var (
+	// serves every chain of the consumer on this address, requests select their chain with the lava-chain-id header
+	// or a /<chainID> path prefix. disabled when empty
+	ChainRouterListen = ""
+	// the api interface served by the chain router, chains are routed to their endpoint of this api interface
+	ChainRouterApiInterface = spectypes.APIInterfaceJsonRPC
+)
+
+// addToChainRouter routes the chain's requests to the endpoint's consumer server when the chain router serves its api
+// interface
+func addToChainRouter(chainRouter *chainlib.ChainProxyRouter, rpcEndpoint *lavasession.RPCEndpoint, relaySender chainlib.RelaySender) {
+	if chainRouter == nil || rpcEndpoint.ApiInterface != ChainRouterApiInterface {
+		return
+	}
+	err := chainRouter.AddChain(rpcEndpoint.ChainID, relaySender)
+	if err != nil {
+		utils.LavaFormatWarning("failed adding chain to the chain router", err, utils.LogAttr("endpoint", rpcEndpoint.String()))
+	}
+}
@@ -0,0 +1,24 @@
+package rpcconsumer
+
+const AvoidColocatedReliabilityProvidersFlag = "avoid-colocated-reliability-providers"
+
+// data reliability relays skip providers sharing a host with the original provider, an operator serving several
+// provider addresses from one node would otherwise cross check itself
+var AvoidColocatedReliabilityProviders = false
+
+// reliabilityUnwantedProviders returns the providers the data reliability relay of originalProvider must not be sent
+// to, and the ones among them excluded for being colocated with it
+func (rpccs *RPCConsumerServer) reliabilityUnwantedProviders(originalProvider string, unwantedProviders map[string]struct{}) (unwanted map[string]struct{}, colocated map[string]struct{}) {
+	unwanted = make(map[string]struct{}, len(unwantedProviders))
+	for provider := range unwantedProviders {
+		unwanted[provider] = struct{}{}
+	}
+	if !AvoidColocatedReliabilityProviders {
+		return unwanted, nil
+	}
+	colocated = rpccs.consumerSessionManager.ColocatedProviders(originalProvider)
+	for provider := range colocated {
+		unwanted[provider] = struct{}{}
+	}
+	return unwanted, colocated
+}
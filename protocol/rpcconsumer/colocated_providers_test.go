@@ -0,0 +1,47 @@
+package rpcconsumer
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReliabilityUnwantedProviders(t *testing.T) {
+	rand.InitRandomSeed()
+	defer func(avoid bool) { AvoidColocatedReliabilityProviders = avoid }(AvoidColocatedReliabilityProviders)
+	csm := lavasession.NewConsumerSessionManager(&lavasession.RPCEndpoint{ChainID: "stub", ApiInterface: "stub"}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	pairing := map[uint64]*lavasession.ConsumerSessionsWithProvider{}
+	for idx, provider := range []struct{ address, networkAddress string }{
+		{"primary", "node.operator.com:2221"},
+		{"colocated", "node.operator.com:2222"},
+		{"independent", "elsewhere.com:2221"},
+		{"unused", "unused.com:2221"},
+	} {
+		endpoints := []*lavasession.Endpoint{{NetworkAddress: provider.networkAddress, Enabled: true, Addons: map[string]struct{}{}, Extensions: map[string]struct{}{}}}
+		pairing[uint64(idx)] = lavasession.NewConsumerSessionWithProvider(provider.address, endpoints, 200, 20, sdk.NewInt64Coin("ulava", 100))
+	}
+	require.NoError(t, csm.UpdateAllProviders(20, pairing))
+	rpccs := &RPCConsumerServer{consumerSessionManager: csm}
+	unwantedProviders := map[string]struct{}{"primary": {}, "unused": {}}
+
+	// disabled by default
+	unwanted, colocated := rpccs.reliabilityUnwantedProviders("primary", unwantedProviders)
+	require.Equal(t, unwantedProviders, unwanted)
+	require.Empty(t, colocated)
+
+	AvoidColocatedReliabilityProviders = true
+	unwanted, colocated = rpccs.reliabilityUnwantedProviders("primary", unwantedProviders)
+	require.Equal(t, map[string]struct{}{"primary": {}, "unused": {}, "colocated": {}}, unwanted)
+	require.Equal(t, map[string]struct{}{"colocated": {}}, colocated)
+	// the relay's own unwanted providers are left as is
+	require.Len(t, unwantedProviders, 2)
+
+	unwanted, colocated = rpccs.reliabilityUnwantedProviders("independent", map[string]struct{}{"independent": {}})
+	require.Equal(t, map[string]struct{}{"independent": {}}, unwanted)
+	require.Empty(t, colocated)
+}
@@ -0,0 +1,32 @@
+package rpcconsumer
+
+import (
+	"context"
+	"fmt"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/protocol/chainlib"
+)
+
+var InvalidRequestForEstimationError = sdkerrors.New("InvalidRequestForEstimation Error", 693, "request would be rejected by SendRelay, its compute units can't be estimated")
+
+// EstimateComputeUnits returns the compute units SendRelay would charge for the request, dynamic costs included,
+// without taking a session or relaying. the request is parsed, transformed and filtered like SendRelay does, a request
+// it would reject fails with InvalidRequestForEstimationError wrapping the rejection
+func (rpccs *RPCConsumerServer) EstimateComputeUnits(apiUrl string, data []byte, connectionType string) (uint64, error) {
+	chainMessage, err := rpccs.chainParser.ParseMsg(apiUrl, data, connectionType, nil, rpccs.getExtensionsFromDirectiveHeaders(nil))
+	if err != nil {
+		return 0, fmt.Errorf("%w: parsing failed: %w", InvalidRequestForEstimationError, err)
+	}
+	chainMessage, _, err = rpccs.transformRequest(context.Background(), chainMessage, apiUrl, data, connectionType, nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", InvalidRequestForEstimationError, err)
+	}
+	if err := chainlib.ValidateChainMessage(chainMessage); err != nil {
+		return 0, fmt.Errorf("%w: %w", InvalidRequestForEstimationError, err)
+	}
+	if err := rpccs.methodFilter.CheckMessage(chainMessage); err != nil {
+		return 0, fmt.Errorf("%w: %w", InvalidRequestForEstimationError, err)
+	}
+	return chainlib.GetComputeUnits(chainMessage), nil
+}
@@ -0,0 +1,72 @@
+package rpcconsumer
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateComputeUnits(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	// state overrides are only charged for apis the spec opts in
+	for _, apiCollection := range spec.ApiCollections {
+		for idx := range apiCollection.Apis {
+			if apiCollection.Apis[idx].Name == "eth_call" {
+				apiCollection.Apis[idx].ExtraComputeUnits = 1
+			}
+		}
+	}
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	rpccs := &RPCConsumerServer{chainParser: chainParser, finalizationConsensus: lavaprotocol.NewFinalizationConsensus("ETH1")}
+	specComputeUnits := map[string]uint64{}
+	for _, apiCollection := range spec.ApiCollections {
+		if apiCollection.CollectionData.ApiInterface != spectypes.APIInterfaceJsonRPC {
+			continue
+		}
+		for _, api := range apiCollection.Apis {
+			specComputeUnits[api.Name] = api.ComputeUnits
+		}
+	}
+
+	// static compute units come from the spec
+	computeUnits, err := rpccs.EstimateComputeUnits("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`), http.MethodPost)
+	require.NoError(t, err)
+	require.Equal(t, specComputeUnits["eth_blockNumber"], computeUnits)
+
+	// a state override adds to eth_call's cost
+	computeUnits, err = rpccs.EstimateComputeUnits("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x00000000000000000000000000000000000000aa","data":"0x"},"latest"]}`), http.MethodPost)
+	require.NoError(t, err)
+	require.Equal(t, specComputeUnits["eth_call"], computeUnits)
+	computeUnits, err = rpccs.EstimateComputeUnits("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x00000000000000000000000000000000000000aa","data":"0x"},"latest",{"0x00000000000000000000000000000000000000aa":{"stateDiff":{"0x1":"0x1","0x2":"0x1"}}}]}`), http.MethodPost)
+	require.NoError(t, err)
+	require.Equal(t, specComputeUnits["eth_call"]+rpcInterfaceMessages.StateOverrideAccountComputeUnits+2*rpcInterfaceMessages.StateOverrideSlotComputeUnits, computeUnits)
+
+	// requests SendRelay rejects can't be estimated
+	for _, request := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_unknownMethod","params":[]}`,
+		`{"jsonrpc":"2.0","id":1,"method":`,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x00000000000000000000000000000000000000aa"},"latest",{"0xaa":"0x1"}]}`,
+	} {
+		_, err = rpccs.EstimateComputeUnits("", []byte(request), http.MethodPost)
+		require.True(t, errors.Is(err, InvalidRequestForEstimationError), request)
+	}
+
+	// the rejection is kept as the cause, a method disabled on the endpoint is rejected like SendRelay does
+	rpccs.methodFilter, err = chainlib.NewMethodFilter(nil, []string{"eth_blockNumber"})
+	require.NoError(t, err)
+	_, err = rpccs.EstimateComputeUnits("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`), http.MethodPost)
+	require.True(t, errors.Is(err, InvalidRequestForEstimationError))
+	require.True(t, errors.Is(err, chainlib.MethodDisabledError), err)
+	_, err = rpccs.EstimateComputeUnits("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`), http.MethodPost)
+	require.NoError(t, err)
+}
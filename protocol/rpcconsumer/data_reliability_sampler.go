@@ -0,0 +1,45 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils/rand"
+)
+
+// DataReliabilitySampler draws the value compared to the spec's reliability threshold, a relay is sent to a second
+// provider for data reliability when the value isn't above the threshold. swapping it allows a different selection
+// scheme, or deterministic tests of the selection probability
+type DataReliabilitySampler interface {
+	Sample(ctx context.Context, relayResult *common.RelayResult) uint32
+}
+
+// randomDataReliabilitySampler is the default, a uniform draw per relay
+type randomDataReliabilitySampler struct{}
+
+func (randomDataReliabilitySampler) Sample(ctx context.Context, relayResult *common.RelayResult) uint32 {
+	return rand.Uint32()
+}
+
+// SetDataReliabilitySampler replaces the data reliability selection, nil restores the default random draw
+func (rpccs *RPCConsumerServer) SetDataReliabilitySampler(sampler DataReliabilitySampler) {
+	rpccs.dataReliabilitySampler = sampler
+}
+
+// shouldSendDataReliability decides on a relay that can be cross checked, by the api's reliability level and for
+// probabilistic apis by the sampler. the caller checks eligibility and the remaining budget, always only means
+// the sampler is skipped
+func (rpccs *RPCConsumerServer) shouldSendDataReliability(ctx context.Context, relayResult *common.RelayResult, chainMessage chainlib.ChainMessage, dataReliabilityThreshold uint32) bool {
+	switch rpccs.reliabilityLevel(chainMessage) {
+	case ReliabilityAlways:
+		return true
+	case ReliabilityNever:
+		return false
+	}
+	var sampler DataReliabilitySampler = randomDataReliabilitySampler{}
+	if rpccs.dataReliabilitySampler != nil {
+		sampler = rpccs.dataReliabilitySampler
+	}
+	return sampler.Sample(ctx, relayResult) <= dataReliabilityThreshold
+}
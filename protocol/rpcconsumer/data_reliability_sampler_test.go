@@ -0,0 +1,51 @@
+package rpcconsumer
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/stretchr/testify/require"
+)
+
+// gridSampler walks a uniform grid over the uint32 range, so the selected fraction is exact
+type gridSampler struct {
+	next  uint64
+	steps uint64
+}
+
+func (gs *gridSampler) Sample(ctx context.Context, relayResult *common.RelayResult) uint32 {
+	value := gs.next * (math.MaxUint32 + 1) / gs.steps
+	gs.next = (gs.next + 1) % gs.steps
+	return uint32(value)
+}
+
+func TestDataReliabilitySampler(t *testing.T) {
+	ctx := context.Background()
+	rpccs := &RPCConsumerServer{}
+	const steps = 1000
+	selected := func(threshold uint32) int {
+		rpccs.SetDataReliabilitySampler(&gridSampler{steps: steps})
+		count := 0
+		for i := 0; i < steps; i++ {
+			if rpccs.shouldSendDataReliability(ctx, nil, nil, threshold) {
+				count++
+			}
+		}
+		return count
+	}
+	require.Equal(t, steps, selected(math.MaxUint32))
+	require.Equal(t, 1, selected(0))
+	// a quarter of the range
+	require.Equal(t, steps/4, selected(math.MaxUint32/4))
+	require.Equal(t, steps/10+1, selected(math.MaxUint32/10))
+
+	// nil restores the random draw
+	rand.InitRandomSeed()
+	rpccs.SetDataReliabilitySampler(nil)
+	for i := 0; i < 100; i++ {
+		require.True(t, rpccs.shouldSendDataReliability(ctx, nil, nil, math.MaxUint32))
+	}
+}
@@ -0,0 +1,74 @@
+package rpcconsumer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+)
+
+const LatestBlockFallbackFlag = "latest-block-fallback"
+
+// fetch the latest block from the trusted fallback node for replies that don't carry one, it's only used for height
+// tracking, such replies never count for the provider's sync score
+var LatestBlockFallback = false
+
+// implemented by chainlib.ChainFetcher
+type latestBlockFetcher interface {
+	FetchLatestBlockNum(ctx context.Context) (int64, error)
+}
+
+// keeps the fetched latest block for an average block time so relays don't each query the node. the block is
+// refreshed in the background, relays never wait on the node
+type latestBlockFallback struct {
+	fetcher     latestBlockFetcher
+	ttl         time.Duration
+	lock        sync.Mutex
+	latestBlock int64
+	fetchedAt   time.Time
+	refreshing  bool
+}
+
+func newLatestBlockFallback(ctx context.Context, listenEndpoint *lavasession.RPCEndpoint, chainParser chainlib.ChainParser, trustedFallback chainlib.ChainRouter) *latestBlockFallback {
+	_, averageBlockTime, _, _ := chainParser.ChainBlockStats()
+	fetcher := chainlib.NewChainFetcher(ctx, &chainlib.ChainFetcherOptions{
+		ChainRouter: trustedFallback,
+		ChainParser: chainParser,
+		Endpoint:    trustedFallbackEndpoint(listenEndpoint),
+	})
+	return &latestBlockFallback{fetcher: fetcher, ttl: averageBlockTime}
+}
+
+// returns the last fetched latest block of the node, 0 until one was fetched. an expired block is returned as is
+// while a single refresh runs in the background
+func (lbf *latestBlockFallback) LatestBlock(ctx context.Context) int64 {
+	lbf.lock.Lock()
+	defer lbf.lock.Unlock()
+	if !lbf.refreshing && (lbf.fetchedAt.IsZero() || time.Since(lbf.fetchedAt) >= lbf.ttl) {
+		lbf.refreshing = true
+		guid, _ := utils.GetUniqueIdentifier(ctx)
+		go lbf.refresh(guid)
+	}
+	return lbf.latestBlock
+}
+
+func (lbf *latestBlockFallback) refresh(guid uint64) {
+	// detached from the relay, it returns before the node answers
+	fetchCtx, cancel := context.WithTimeout(context.Background(), common.AverageWorldLatency)
+	defer cancel()
+	latestBlock, err := lbf.fetcher.FetchLatestBlockNum(fetchCtx)
+	lbf.lock.Lock()
+	defer lbf.lock.Unlock()
+	lbf.refreshing = false
+	// failures are cached too so a down node isn't queried on every relay
+	lbf.fetchedAt = time.Now()
+	if err != nil {
+		utils.LavaFormatDebug("failed fetching the latest block from the trusted fallback", utils.LogAttr("GUID", guid), utils.LogAttr("error", err))
+		return
+	}
+	lbf.latestBlock = latestBlock
+}
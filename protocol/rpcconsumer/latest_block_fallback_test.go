@@ -0,0 +1,101 @@
+package rpcconsumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockLatestBlockFetcher struct {
+	lock        sync.Mutex
+	latestBlock int64
+	err         error
+	fetches     int
+	block       chan struct{} // fetches wait on it when set
+}
+
+func (mlbf *mockLatestBlockFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
+	mlbf.lock.Lock()
+	block := mlbf.block
+	mlbf.lock.Unlock()
+	if block != nil {
+		<-block
+	}
+	mlbf.lock.Lock()
+	defer mlbf.lock.Unlock()
+	mlbf.fetches++
+	return mlbf.latestBlock, mlbf.err
+}
+
+func (mlbf *mockLatestBlockFetcher) set(latestBlock int64, err error) {
+	mlbf.lock.Lock()
+	defer mlbf.lock.Unlock()
+	mlbf.latestBlock, mlbf.err = latestBlock, err
+}
+
+func (mlbf *mockLatestBlockFetcher) fetchCount() int {
+	mlbf.lock.Lock()
+	defer mlbf.lock.Unlock()
+	return mlbf.fetches
+}
+
+// waitRefreshed waits for the background refresh started by the last LatestBlock call
+func waitRefreshed(t *testing.T, fallback *latestBlockFallback) {
+	require.Eventually(t, func() bool {
+		fallback.lock.Lock()
+		defer fallback.lock.Unlock()
+		return !fallback.refreshing
+	}, time.Second, time.Millisecond)
+}
+
+func expire(fallback *latestBlockFallback) {
+	fallback.lock.Lock()
+	defer fallback.lock.Unlock()
+	fallback.fetchedAt = time.Now().Add(-2 * time.Hour)
+}
+
+func TestLatestBlockFallbackCache(t *testing.T) {
+	ctx := context.Background()
+	fetcher := &mockLatestBlockFetcher{latestBlock: 100}
+	fallback := &latestBlockFallback{fetcher: fetcher, ttl: time.Hour}
+	// nothing fetched yet, the first relay triggers the fetch
+	require.Zero(t, fallback.LatestBlock(ctx))
+	waitRefreshed(t, fallback)
+	require.Equal(t, int64(100), fallback.LatestBlock(ctx))
+	fetcher.set(101, nil)
+	// cached for the block time
+	require.Equal(t, int64(100), fallback.LatestBlock(ctx))
+	require.Equal(t, 1, fetcher.fetchCount())
+
+	// an expired block is returned while it's refreshed
+	expire(fallback)
+	require.Equal(t, int64(100), fallback.LatestBlock(ctx))
+	waitRefreshed(t, fallback)
+	require.Equal(t, int64(101), fallback.LatestBlock(ctx))
+
+	// a failed fetch keeps the last block and isn't retried until the cache expires
+	fetcher.set(102, fmt.Errorf("node down"))
+	expire(fallback)
+	fallback.LatestBlock(ctx)
+	waitRefreshed(t, fallback)
+	require.Equal(t, int64(101), fallback.LatestBlock(ctx))
+	require.Equal(t, 3, fetcher.fetchCount())
+}
+
+func TestLatestBlockFallbackDoesntWaitOnTheNode(t *testing.T) {
+	ctx := context.Background()
+	fetcher := &mockLatestBlockFetcher{latestBlock: 100, block: make(chan struct{})}
+	fallback := &latestBlockFallback{fetcher: fetcher, ttl: time.Hour}
+	// relays return while the node is slow and only one fetch is in flight
+	for i := 0; i < 10; i++ {
+		require.Zero(t, fallback.LatestBlock(ctx))
+	}
+	close(fetcher.block)
+	waitRefreshed(t, fallback)
+	require.Equal(t, int64(100), fallback.LatestBlock(ctx))
+	require.Equal(t, 1, fetcher.fetchCount())
+}
@@ -0,0 +1,46 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/metrics"
+	"github.com/lavanet/lava/utils"
+	conflicttypes "github.com/lavanet/lava/x/conflict/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// a provider whose finalized hashes keep disagreeing with the majority is likely serving from a fork, it's demoted for
+// the rest of the epoch and its reply is filed against a majority provider's through the finalization conflict flow.
+// the majority is only a local view so the provider isn't reported as unresponsive, the conflict is what settles it
+func (rpccs *RPCConsumerServer) onMinorityFork(ctx context.Context, forkErr *lavaprotocol.MinorityForkError) {
+	utils.LavaFormatWarning("provider is serving finalized blocks from a minority fork, demoting it", forkErr, append(forkErr.LogAttributes(), utils.LogAttr("GUID", ctx))...)
+	rpccs.rpcConsumerLogs.AddDataReliabilityOutcome(rpccs.listenEndpoint.ChainID, metrics.DataReliabilityMinorityFork)
+	if demoteErr := rpccs.consumerSessionManager.OnMinorityFork(forkErr.Provider); demoteErr != nil {
+		utils.LavaFormatDebug("failed demoting minority fork provider", utils.LogAttr("provider", forkErr.Provider), utils.LogAttr("error", demoteErr))
+	}
+}
+
+// updateFinalizationConsensus checks the provider's finalized hashes for a minority fork and adds them to the
+// consensus. a fork and a hashes conflict of the same reply are the same evidence, so a single conflict is filed,
+// the hashes conflict unless it has no reply of the other side
+func (rpccs *RPCConsumerServer) updateFinalizationConsensus(ctx context.Context, providerAddress string, finalizedBlocks map[int64]string, relaySession *pairingtypes.RelaySession, reply *pairingtypes.RelayReply, blockDistanceForFinalizedData int64, conflictHandler common.ConflictHandlerInterface) error {
+	detectErr := rpccs.finalizationConsensus.DetectMinorityFork(providerAddress, finalizedBlocks, reply)
+	_, err := rpccs.finalizationConsensus.UpdateFinalizedHashes(blockDistanceForFinalizedData, providerAddress, finalizedBlocks, relaySession, reply)
+	var conflict *conflicttypes.FinalizationConflict
+	if conflictErr, ok := lavaprotocol.GetFinalizationConflictError(err); ok {
+		conflict = conflictErr.Conflict
+	}
+	if forkErr, ok := lavaprotocol.GetMinorityForkError(detectErr); ok {
+		rpccs.onMinorityFork(ctx, forkErr)
+		if forkErr.Conflict != nil && (conflict == nil || conflict.RelayReply1 == nil) {
+			conflict = forkErr.Conflict
+		}
+	}
+	if conflict != nil {
+		// both signed replies are available, file the conflict on chain
+		go rpccs.consumerTxSender.TxConflictDetection(ctx, conflict, nil, nil, conflictHandler)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package rpcconsumer
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/metrics"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	conflicttypes "github.com/lavanet/lava/x/conflict/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTxSender counts the finalization conflicts filed
+type countingTxSender struct {
+	mockConsumerTxSender
+	filed *int64
+}
+
+func (cts countingTxSender) TxConflictDetection(ctx context.Context, finalizationConflict *conflicttypes.FinalizationConflict, responseConflict *conflicttypes.ResponseConflict, sameProviderConflict *conflicttypes.FinalizationConflict, conflictHandler common.ConflictHandlerInterface) error {
+	atomic.AddInt64(cts.filed, 1)
+	return nil
+}
+
+func TestMinorityForkConflictFiledOnce(t *testing.T) {
+	ctx := context.Background()
+	const blockDistanceForFinalizedData = 4
+	hashes := func(from, to uint64, identifier string) map[int64]string {
+		ret := map[int64]string{}
+		for block := from; block <= to; block++ {
+			ret[int64(block)] = strconv.FormatUint(block, 10) + identifier
+		}
+		return ret
+	}
+	rpcEndpoint := &lavasession.RPCEndpoint{ChainID: "LAV1", ApiInterface: "tendermintrpc"}
+	logs, err := metrics.NewRPCConsumerLogs(nil, nil)
+	require.NoError(t, err)
+	filed := int64(0)
+	finalizationConsensus := &lavaprotocol.FinalizationConsensus{}
+	finalizationConsensus.NewEpoch(200)
+	rpccs := &RPCConsumerServer{
+		listenEndpoint:         rpcEndpoint,
+		rpcConsumerLogs:        logs,
+		finalizationConsensus:  finalizationConsensus,
+		consumerSessionManager: lavasession.NewConsumerSessionManager(rpcEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil),
+		consumerTxSender:       countingTxSender{filed: &filed},
+	}
+
+	// waits for the filings of the previous calls and returns their count
+	settledFilings := func() int64 {
+		time.Sleep(50 * time.Millisecond)
+		return atomic.LoadInt64(&filed)
+	}
+	for round := uint64(1); round <= uint64(lavaprotocol.MinorityForkDisagreementThreshold); round++ {
+		for _, provider := range []string{"lava@honest0", "lava@honest1", "lava@honest2"} {
+			reply := &pairingtypes.RelayReply{LatestBlock: int64(97 + round)}
+			// honest providers conflict with the forked provider's consensus group once it has one
+			rpccs.updateFinalizationConsensus(ctx, provider, hashes(90+round, 93+round, ""), &pairingtypes.RelaySession{}, reply, blockDistanceForFinalizedData, nil)
+		}
+		filedBefore := settledFilings()
+		reply := &pairingtypes.RelayReply{LatestBlock: int64(97 + round), Data: []byte("fork")}
+		err := rpccs.updateFinalizationConsensus(ctx, "lava@forked", hashes(90+round, 93+round, "fork"), &pairingtypes.RelaySession{}, reply, blockDistanceForFinalizedData, nil)
+		_, conflictFound := lavaprotocol.GetFinalizationConflictError(err)
+		require.True(t, conflictFound)
+		// the round that flags the fork conflicts with the consensus as well, the reply is filed once either way
+		require.Equal(t, filedBefore+1, settledFilings())
+	}
+	require.True(t, finalizationConsensus.IsOnMinorityFork("lava@forked"))
+}
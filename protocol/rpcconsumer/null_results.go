@@ -0,0 +1,103 @@
+package rpcconsumer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	NullResultStrategyFlag = "null-result-strategy"
+	NullResultMethodsFlag  = "null-result-methods"
+)
+
+// NullResultStrategyType selects how replies with a null result are handled
+type NullResultStrategyType string
+
+const (
+	NullResultAccept     NullResultStrategyType = "accept"      // null is a valid result
+	NullResultCrossCheck NullResultStrategyType = "cross-check" // a suspicious null is only returned if another provider confirms it
+)
+
+var NullResultStrategy = NullResultAccept
+
+// methods that return data for every block the provider already has, a null from them for such a block suggests a
+// pruned or misconfigured node. other methods, like a lookup of an unknown transaction, can legitimately return null
+var NullResultMethods = []string{"eth_getBlockByNumber"}
+
+func (ns *NullResultStrategyType) String() string {
+	return string(*ns)
+}
+
+func (ns *NullResultStrategyType) Set(str string) error {
+	switch NullResultStrategyType(str) {
+	case NullResultAccept, NullResultCrossCheck:
+		*ns = NullResultStrategyType(str)
+		return nil
+	}
+	return fmt.Errorf("invalid null result strategy: %s, expected %s or %s", str, NullResultAccept, NullResultCrossCheck)
+}
+
+func (ns *NullResultStrategyType) Type() string {
+	return "string"
+}
+
+// isNullResult returns true for a jsonrpc reply without an error whose result is empty
+func isNullResult(data []byte) bool {
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return false
+	}
+	if len(reply.Error) > 0 && string(reply.Error) != "null" {
+		return false
+	}
+	return slices.Contains(chainlib.InvalidResponses, string(bytes.TrimSpace(reply.Result)))
+}
+
+// isSuspiciousNullResult returns true for a null reply to a method that should have data at the requested block,
+// when the provider claims to already have that block
+func (rpccs *RPCConsumerServer) isSuspiciousNullResult(chainMessage chainlib.ChainMessage, relayResult *common.RelayResult) bool {
+	if NullResultStrategy != NullResultCrossCheck || relayResult.Reply == nil || relayResult.Request == nil {
+		return false
+	}
+	switch rpccs.listenEndpoint.ApiInterface {
+	case spectypes.APIInterfaceJsonRPC, spectypes.APIInterfaceTendermintRPC:
+	default:
+		return false
+	}
+	if !slices.Contains(NullResultMethods, chainMessage.GetApi().Name) {
+		return false
+	}
+	requestedBlock := relayResult.Request.RelayData.RequestBlock
+	if requestedBlock < 0 || requestedBlock > relayResult.Reply.LatestBlock {
+		// a block the provider doesn't have yet can be null
+		return false
+	}
+	return isNullResult(relayResult.Reply.Data)
+}
+
+// crossCheckNullResult compares a held back null reply with another provider's reply to the same request, a
+// provider returning data shows the null was wrong and the provider that returned it is penalized
+func (rpccs *RPCConsumerServer) crossCheckNullResult(chainMessage chainlib.ChainMessage, nullResult *common.RelayResult, relayResult *common.RelayResult) {
+	if isNullResult(relayResult.Reply.Data) {
+		utils.LavaFormatDebug("null result confirmed by another provider", utils.LogAttr("method", chainMessage.GetApi().Name), utils.LogAttr("provider", nullResult.ProviderInfo.ProviderAddress), utils.LogAttr("confirmedBy", relayResult.ProviderInfo.ProviderAddress))
+		return
+	}
+	utils.LavaFormatWarning("provider returned a null result another provider had data for", nil,
+		utils.LogAttr("method", chainMessage.GetApi().Name),
+		utils.LogAttr("provider", nullResult.ProviderInfo.ProviderAddress),
+		utils.LogAttr("requestedBlock", nullResult.Request.RelayData.RequestBlock),
+		utils.LogAttr("providerLatestBlock", nullResult.Reply.LatestBlock),
+		utils.LogAttr("crossCheckProvider", relayResult.ProviderInfo.ProviderAddress),
+	)
+	rpccs.consumerSessionManager.OnReplyValidationFailure(nullResult.ProviderInfo.ProviderAddress)
+}
@@ -0,0 +1,105 @@
+package rpcconsumer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+// records the providers penalized for a relay failure
+type failureRecordingOptimizer struct {
+	*provideroptimizer.ProviderOptimizer
+	failures chan string
+}
+
+func (fro *failureRecordingOptimizer) AppendRelayFailure(providerAddress string) {
+	fro.failures <- providerAddress
+}
+
+func nullTestRelayResult(provider string, data string, requestBlock int64, latestBlock int64) *common.RelayResult {
+	return &common.RelayResult{
+		Reply:        &pairingtypes.RelayReply{Data: []byte(data), LatestBlock: latestBlock},
+		Request:      &pairingtypes.RelayRequest{RelayData: &pairingtypes.RelayPrivateData{RequestBlock: requestBlock}},
+		ProviderInfo: common.ProviderInfo{ProviderAddress: provider},
+	}
+}
+
+func TestIsNullResult(t *testing.T) {
+	for _, play := range []struct {
+		name string
+		data string
+		null bool
+	}{
+		{name: "null result", data: `{"jsonrpc":"2.0","id":1,"result":null}`, null: true},
+		{name: "missing result", data: `{"jsonrpc":"2.0","id":1}`, null: true},
+		{name: "null error", data: `{"jsonrpc":"2.0","id":1,"result":null,"error":null}`, null: true},
+		{name: "result", data: `{"jsonrpc":"2.0","id":1,"result":{"number":"0x10"}}`, null: false},
+		{name: "error", data: `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"failed"}}`, null: false},
+		{name: "not json", data: `bad gateway`, null: false},
+	} {
+		t.Run(play.name, func(t *testing.T) {
+			require.Equal(t, play.null, isNullResult([]byte(play.data)))
+		})
+	}
+}
+
+func TestNullResultStrategy(t *testing.T) {
+	defer func(strategy NullResultStrategyType) { NullResultStrategy = strategy }(NullResultStrategy)
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	optimizer := &failureRecordingOptimizer{ProviderOptimizer: provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), failures: make(chan string, 1)}
+	rpccs := &RPCConsumerServer{
+		chainParser:            chainParser,
+		listenEndpoint:         &lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC},
+		consumerSessionManager: lavasession.NewConsumerSessionManager(&lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC}, optimizer, nil, nil),
+	}
+	parse := func(req string) chainlib.ChainMessage {
+		chainMessage, err := chainParser.ParseMsg("", []byte(req), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+		require.NoError(t, err)
+		return chainMessage
+	}
+	getBlock := parse(`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x10",false]}`)
+	getTransaction := parse(`{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionByHash","params":["0xaa"]}`)
+	const null = `{"jsonrpc":"2.0","id":1,"result":null}`
+	const block = `{"jsonrpc":"2.0","id":1,"result":{"number":"0x10"}}`
+
+	// nulls are accepted by default
+	require.False(t, rpccs.isSuspiciousNullResult(getBlock, nullTestRelayResult("provider", null, 0x10, 0x20)))
+
+	NullResultStrategy = NullResultCrossCheck
+	require.True(t, rpccs.isSuspiciousNullResult(getBlock, nullTestRelayResult("provider", null, 0x10, 0x20)))
+	// legitimate nulls: a block the provider doesn't have yet, and a lookup that may not find anything
+	require.False(t, rpccs.isSuspiciousNullResult(getBlock, nullTestRelayResult("provider", null, 0x30, 0x20)))
+	require.False(t, rpccs.isSuspiciousNullResult(getTransaction, nullTestRelayResult("provider", null, 0x10, 0x20)))
+	require.False(t, rpccs.isSuspiciousNullResult(getBlock, nullTestRelayResult("provider", block, 0x10, 0x20)))
+
+	// a null confirmed by another provider isn't penalized
+	rpccs.crossCheckNullResult(getBlock, nullTestRelayResult("provider", null, 0x10, 0x20), nullTestRelayResult("other", null, 0x10, 0x20))
+	select {
+	case provider := <-optimizer.failures:
+		require.Fail(t, "confirmed null penalized", provider)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// a null contradicted by another provider is
+	rpccs.crossCheckNullResult(getBlock, nullTestRelayResult("provider", null, 0x10, 0x20), nullTestRelayResult("other", block, 0x10, 0x20))
+	select {
+	case provider := <-optimizer.failures:
+		require.Equal(t, "provider", provider)
+	case <-time.After(time.Second):
+		require.Fail(t, "contradicted null wasn't penalized")
+	}
+}
@@ -0,0 +1,111 @@
+package rpcconsumer
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/utils"
+)
+
+const RequireReadinessFlag = "require-readiness"
+
+// refuse relays and report unhealthy until the pairing is loaded and a provider answered a relay, so orchestrators
+// can hold traffic back behind the health check while the consumer starts
+var RequireReadiness = false
+
+// how often a starting consumer checks whether it's ready
+var ReadinessCheckInterval = time.Second
+
+var ConsumerNotReadyError = sdkerrors.New("ConsumerNotReady Error", 692, "consumer is starting and has no confirmed provider yet, retry shortly")
+
+// readinessGate flips ready once, after the pairing is loaded and a provider answered a relay. the initial relays mark
+// it ready, the gate only probes on its own while they didn't
+type readinessGate struct {
+	ready         atomic.Bool
+	pairingLoaded func() bool
+	probe         func(ctx context.Context) bool // true when a provider answered
+}
+
+func newReadinessGate(pairingLoaded func() bool, probe func(ctx context.Context) bool) *readinessGate {
+	return &readinessGate{pairingLoaded: pairingLoaded, probe: probe}
+}
+
+func (rg *readinessGate) isReady() bool {
+	return rg != nil && rg.ready.Load()
+}
+
+// markReady is called when a provider answered a relay
+func (rg *readinessGate) markReady() {
+	if rg != nil {
+		rg.ready.Store(true)
+	}
+}
+
+// check probes the providers once the pairing is loaded, returns whether the consumer is ready
+func (rg *readinessGate) check(ctx context.Context) bool {
+	if rg.isReady() {
+		return true
+	}
+	if !rg.pairingLoaded() || !rg.probe(ctx) {
+		return false
+	}
+	rg.markReady()
+	return true
+}
+
+// run checks readiness every interval until the consumer is ready, the first check waits an interval so the initial
+// relays get to mark it ready first
+func (rg *readinessGate) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if rg.check(ctx) {
+			return
+		}
+	}
+}
+
+// IsReady returns true once the pairing was loaded and a provider answered a relay
+func (rpccs *RPCConsumerServer) IsReady() bool {
+	return rpccs.readiness.isReady()
+}
+
+// startReadinessGate gates relays until the consumer is ready, it must start before the chain listener serves
+func (rpccs *RPCConsumerServer) startReadinessGate(ctx context.Context) {
+	if !RequireReadiness {
+		return
+	}
+	rpccs.readiness = newReadinessGate(rpccs.consumerSessionManager.Initialized, rpccs.probeReadiness)
+	go func() {
+		rpccs.readiness.run(ctx, ReadinessCheckInterval)
+		if rpccs.IsReady() {
+			utils.LavaFormatInfo("consumer is ready", utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID), utils.LogAttr("APIInterface", rpccs.listenEndpoint.ApiInterface))
+		}
+	}()
+}
+
+// probeReadiness sends a latest block relay, a spec without one can't be probed and the pairing is all readiness
+// waits for
+func (rpccs *RPCConsumerServer) probeReadiness(ctx context.Context) bool {
+	ctx = utils.WithUniqueIdentifier(ctx, utils.GenerateUniqueIdentifier())
+	ok, relay, chainMessage, _ := rpccs.craftRelay(ctx)
+	if !ok {
+		return true
+	}
+	success, _ := rpccs.sendRelayWithRetries(ctx, 1, false, relay, chainMessage)
+	return success
+}
+
+func (rpccs *RPCConsumerServer) validateReadiness() error {
+	if !RequireReadiness || rpccs.IsReady() {
+		return nil
+	}
+	return sdkerrors.Wrapf(ConsumerNotReadyError, "pairing loaded: %t", rpccs.consumerSessionManager != nil && rpccs.consumerSessionManager.Initialized())
+}
@@ -0,0 +1,79 @@
+package rpcconsumer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessGate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var pairingLoaded, providerAnswers atomic.Bool
+	probes := atomic.Int64{}
+	gate := newReadinessGate(pairingLoaded.Load, func(ctx context.Context) bool {
+		probes.Add(1)
+		return providerAnswers.Load()
+	})
+	done := make(chan struct{})
+	go func() {
+		gate.run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	// providers aren't probed before there is a pairing
+	time.Sleep(20 * time.Millisecond)
+	require.False(t, gate.isReady())
+	require.Zero(t, probes.Load())
+
+	// a pairing without a provider answering isn't ready
+	pairingLoaded.Store(true)
+	require.Eventually(t, func() bool { return probes.Load() > 0 }, time.Second, time.Millisecond)
+	require.False(t, gate.isReady())
+
+	providerAnswers.Store(true)
+	<-done
+	require.True(t, gate.isReady())
+	// ready stays ready, the gate is for startup only
+	providerAnswers.Store(false)
+	require.True(t, gate.check(ctx))
+
+	// a successful initial relay marks the gate ready without probing
+	probes.Store(0)
+	gate = newReadinessGate(pairingLoaded.Load, func(ctx context.Context) bool {
+		probes.Add(1)
+		return false
+	})
+	gate.markReady()
+	require.True(t, gate.isReady())
+	require.True(t, gate.check(ctx))
+	require.Zero(t, probes.Load())
+}
+
+func TestRequireReadiness(t *testing.T) {
+	defer func(require bool) { RequireReadiness = require }(RequireReadiness)
+	csm := lavasession.NewConsumerSessionManager(&lavasession.RPCEndpoint{ChainID: "stub", ApiInterface: "stub"}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	rpccs := &RPCConsumerServer{consumerSessionManager: csm}
+	// relays aren't gated by default and nothing probes the providers
+	rpccs.startReadinessGate(context.Background())
+	require.Nil(t, rpccs.readiness)
+	require.False(t, rpccs.IsReady())
+	require.NoError(t, rpccs.validateReadiness())
+	rpccs.readiness.markReady()
+
+	rpccs.readiness = newReadinessGate(func() bool { return true }, func(ctx context.Context) bool { return true })
+
+	RequireReadiness = true
+	err := rpccs.validateReadiness()
+	require.True(t, ConsumerNotReadyError.Is(err))
+	require.False(t, rpccs.IsHealthy())
+
+	require.True(t, rpccs.readiness.check(context.Background()))
+	require.True(t, rpccs.IsReady())
+	require.NoError(t, rpccs.validateReadiness())
+}
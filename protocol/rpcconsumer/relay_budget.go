@@ -0,0 +1,58 @@
+package rpcconsumer
+
+import (
+	"context"
+	"time"
+)
+
+const DataReliabilityBudgetShareFlag = "data-reliability-budget-share"
+
+// the share of a relay's remaining deadline reserved for its data reliability relay, the primary relay gets the rest.
+// reliability runs after the reply is returned, so by default nothing is reserved and it only gets what the primary
+// relay left unused
+var DataReliabilityBudgetShare = 0.0
+
+// data reliability relays left with less than this much of the caller's deadline are skipped
+var MinDataReliabilityBudget = 200 * time.Millisecond
+
+// relayBudget partitions the caller's deadline between the primary relay and its data reliability relay
+type relayBudget struct {
+	deadline        time.Time // the caller's deadline, zero when it has none
+	primaryDeadline time.Time
+}
+
+func newRelayBudget(ctx context.Context, now time.Time, reserveReliability bool) relayBudget {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return relayBudget{}
+	}
+	budget := relayBudget{deadline: deadline, primaryDeadline: deadline}
+	remaining := deadline.Sub(now)
+	if !reserveReliability || remaining <= 0 || DataReliabilityBudgetShare <= 0 || DataReliabilityBudgetShare >= 1 {
+		return budget
+	}
+	reserved := time.Duration(float64(remaining) * DataReliabilityBudgetShare)
+	if reserved < MinDataReliabilityBudget {
+		// too tight for both, the primary relay gets all of it and reliability is skipped
+		return budget
+	}
+	budget.primaryDeadline = deadline.Add(-reserved)
+	return budget
+}
+
+// primaryContext bounds the primary relay and its retries to their share of the deadline
+func (rb relayBudget) primaryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if !rb.primaryDeadline.Before(rb.deadline) {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, rb.primaryDeadline)
+}
+
+// reliabilityContext bounds the data reliability relay by the caller's deadline, it gets the reserved share and
+// whatever the primary relay left unused
+func (rb relayBudget) reliabilityContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if rb.deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, rb.deadline)
+}
@@ -0,0 +1,122 @@
+package rpcconsumer
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayBudgetDefault(t *testing.T) {
+	// nothing is reserved by default, the primary relay gets the whole deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+	budget := newRelayBudget(ctx, time.Now(), true)
+	primaryCtx, cancelPrimary := budget.primaryContext(ctx)
+	defer cancelPrimary()
+	primaryDeadline, ok := primaryCtx.Deadline()
+	require.True(t, ok)
+	callerDeadline, _ := ctx.Deadline()
+	require.Equal(t, callerDeadline, primaryDeadline)
+}
+
+func TestRelayBudget(t *testing.T) {
+	defer func(share float64) { DataReliabilityBudgetShare = share }(DataReliabilityBudgetShare)
+	DataReliabilityBudgetShare = 0.25
+	now := time.Now()
+	playbook := []struct {
+		name               string
+		remaining          time.Duration // 0 for no deadline
+		reserveReliability bool
+		primary            time.Duration // 0 for no primary deadline
+		reliability        time.Duration // 0 for no reliability deadline
+	}{
+		{name: "no deadline", reserveReliability: true},
+		{name: "ample budget is partitioned", remaining: 4 * time.Second, reserveReliability: true, primary: 3 * time.Second, reliability: 4 * time.Second},
+		{name: "reliability not applicable", remaining: 4 * time.Second, reserveReliability: false, primary: 4 * time.Second, reliability: 4 * time.Second},
+		{name: "tight budget goes to the primary", remaining: 500 * time.Millisecond, reserveReliability: true, primary: 500 * time.Millisecond, reliability: 500 * time.Millisecond},
+		{name: "smallest partitioned budget", remaining: 4 * MinDataReliabilityBudget, reserveReliability: true, primary: 3 * MinDataReliabilityBudget, reliability: 4 * MinDataReliabilityBudget},
+		{name: "expired deadline", remaining: -time.Second, reserveReliability: true, primary: -time.Second, reliability: -time.Second},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			ctx := context.Background()
+			if play.remaining != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, now.Add(play.remaining))
+				defer cancel()
+			}
+			budget := newRelayBudget(ctx, now, play.reserveReliability)
+
+			primaryCtx, cancel := budget.primaryContext(ctx)
+			defer cancel()
+			deadline, ok := primaryCtx.Deadline()
+			require.Equal(t, play.primary != 0, ok)
+			if ok {
+				require.Equal(t, play.primary, deadline.Sub(now))
+			}
+
+			// detached from the caller's context like the data reliability relay
+			reliabilityCtx, cancel := budget.reliabilityContext(context.Background())
+			defer cancel()
+			deadline, ok = reliabilityCtx.Deadline()
+			require.Equal(t, play.reliability != 0, ok)
+			if ok {
+				require.Equal(t, play.reliability, deadline.Sub(now))
+			}
+		})
+	}
+}
+
+func TestRelayBudgetReliabilityGetsUnusedPrimaryTime(t *testing.T) {
+	defer func(share float64) { DataReliabilityBudgetShare = share }(DataReliabilityBudgetShare)
+	DataReliabilityBudgetShare = 0.25
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	budget := newRelayBudget(ctx, time.Now(), true)
+	primaryCtx, cancelPrimary := budget.primaryContext(ctx)
+	defer cancelPrimary()
+	primaryDeadline, ok := primaryCtx.Deadline()
+	require.True(t, ok)
+	callerDeadline, _ := ctx.Deadline()
+	require.True(t, primaryDeadline.Before(callerDeadline))
+
+	// the primary relay returned right away, reliability may use everything up to the caller's deadline
+	reliabilityCtx, cancelReliability := budget.reliabilityContext(context.Background())
+	defer cancelReliability()
+	reliabilityDeadline, ok := reliabilityCtx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, callerDeadline, reliabilityDeadline)
+}
+
+func TestDataReliabilitySkippedWithoutBudget(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	// no session manager, reaching the reliability relay would panic
+	rpccs := &RPCConsumerServer{chainParser: chainParser}
+	rpccs.SetDataReliabilitySampler(&gridSampler{steps: 1})
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.True(t, chainMessage.GetApi().Category.Deterministic)
+	relayResult := &common.RelayResult{
+		Request:   &pairingtypes.RelayRequest{RelayData: &pairingtypes.RelayPrivateData{RequestBlock: 0x10}},
+		Reply:     &pairingtypes.RelayReply{},
+		Finalized: true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), MinDataReliabilityBudget/2)
+	defer cancel()
+	require.NoError(t, rpccs.sendDataReliabilityRelayIfApplicable(ctx, "dapp", "127.0.0.1", relayResult, chainMessage, math.MaxUint32, map[string]struct{}{}))
+}
@@ -0,0 +1,110 @@
+package rpcconsumer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+type relayCompressionKey struct{}
+
+// relayCompressionStats puts the compression of incoming relays in their context
+type relayCompressionStats struct{}
+
+func (relayCompressionStats) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, relayCompressionKey{}, new(string))
+}
+
+func (relayCompressionStats) HandleRPC(ctx context.Context, rpcStats stats.RPCStats) {
+	if inHeader, ok := rpcStats.(*stats.InHeader); ok {
+		if compression, ok := ctx.Value(relayCompressionKey{}).(*string); ok {
+			*compression = inHeader.Compression
+		}
+	}
+}
+
+func (relayCompressionStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (relayCompressionStats) HandleConn(context.Context, stats.ConnStats) {}
+
+// compressionRelayer records the compression of the relays it gets, and rejects compressed ones like a provider
+// without the compressor when rejectCompressed is set
+type compressionRelayer struct {
+	*mockRelayer
+	rejectCompressed bool
+	lock             sync.Mutex
+	compressions     []string
+}
+
+func (cr *compressionRelayer) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	compression := *ctx.Value(relayCompressionKey{}).(*string)
+	cr.lock.Lock()
+	cr.compressions = append(cr.compressions, compression)
+	cr.lock.Unlock()
+	if cr.rejectCompressed && compression != "" {
+		return nil, status.Errorf(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding %q", compression)
+	}
+	return cr.mockRelayer.Relay(ctx, request)
+}
+
+func (cr *compressionRelayer) seenCompressions() []string {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	return append([]string{}, cr.compressions...)
+}
+
+func TestSendRelayCompression(t *testing.T) {
+	// a block sized reply, so compression has something to do
+	largeReply := `{"jsonrpc":"2.0","id":1,"result":"0x` + strings.Repeat("ab", 64*1024) + `"}`
+	for _, play := range []struct {
+		name                 string
+		compression          bool
+		rejectCompressed     bool
+		expectedCompressions []string
+	}{
+		{name: "uncompressed", compression: false, expectedCompressions: []string{"", ""}},
+		{name: "compressed", compression: true, expectedCompressions: []string{common.GrpcCompressor, common.GrpcCompressor}},
+		// the rejected relay is sent again uncompressed, and the following ones are sent uncompressed
+		{name: "provider without compression", compression: true, rejectCompressed: true, expectedCompressions: []string{common.GrpcCompressor, "", ""}},
+	} {
+		t.Run(play.name, func(t *testing.T) {
+			rand.InitRandomSeed()
+			relayCompression := lavasession.RelayCompression
+			lavasession.RelayCompression = play.compression
+			defer func() { lavasession.RelayCompression = relayCompression }()
+			spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+			require.NoError(t, err)
+			spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+			consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+			providerKey, providerAddress := sigs.GenerateFloatingKey()
+			relayer := &compressionRelayer{
+				mockRelayer:      &mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(largeReply)},
+				rejectCompressed: play.rejectCompressed,
+			}
+			rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress, grpc.StatsHandler(relayCompressionStats{}))
+
+			for i := 0; i < 2; i++ {
+				relayResult, err := rpccs.SendRelay(context.Background(), "", `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+				require.NoError(t, err)
+				require.Equal(t, largeReply, string(relayResult.GetReply().Data))
+			}
+			require.Equal(t, play.expectedCompressions, relayer.seenCompressions())
+		})
+	}
+}
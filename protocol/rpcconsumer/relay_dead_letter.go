@@ -0,0 +1,115 @@
+package rpcconsumer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/utils"
+)
+
+const DeadLettersPathFlag = "dead-letters-path"
+
+// relays failing on every provider are appended to this file when set
+var DeadLettersPath = ""
+
+// dead letters waiting for a slow sink beyond this are dropped
+const DefaultDeadLetterBufferSize = 100
+
+// DeadLetter is a relay that failed every retry, kept to look into provider coverage gaps or to replay it manually
+type DeadLetter struct {
+	Time           time.Time `json:"time"`
+	ChainID        string    `json:"chain_id"`
+	ApiInterface   string    `json:"api_interface"`
+	Method         string    `json:"method"`
+	Url            string    `json:"url"`
+	ConnectionType string    `json:"connection_type"`
+	Request        []byte    `json:"request"` // as sent to the providers
+	DappID         string    `json:"dapp_id"`
+	ProvidersTried []string  `json:"providers_tried"` // empty when no provider could be picked
+	Error          string    `json:"error"`
+}
+
+// DeadLetterSink receives the relays that failed every retry, it's called from a single goroutine off the relay path.
+// letters arriving while the buffer is full are dropped instead of holding replies back
+type DeadLetterSink interface {
+	DeadLetter(letter *DeadLetter)
+}
+
+// ChannelDeadLetterSink sends the dead letters to a channel, the channel is expected to be drained
+type ChannelDeadLetterSink chan *DeadLetter
+
+func (cdls ChannelDeadLetterSink) DeadLetter(letter *DeadLetter) {
+	cdls <- letter
+}
+
+// FileDeadLetterSink appends the dead letters to a file as json lines
+type FileDeadLetterSink struct {
+	lock    sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDeadLetterSink{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func (fdls *FileDeadLetterSink) DeadLetter(letter *DeadLetter) {
+	fdls.lock.Lock()
+	defer fdls.lock.Unlock()
+	if err := fdls.encoder.Encode(letter); err != nil {
+		utils.LavaFormatWarning("failed writing dead letter", err, utils.LogAttr("path", fdls.file.Name()), utils.LogAttr("method", letter.Method))
+	}
+}
+
+func (fdls *FileDeadLetterSink) Close() error {
+	fdls.lock.Lock()
+	defer fdls.lock.Unlock()
+	return fdls.file.Close()
+}
+
+// SetDeadLetterSink sends the relays that failed every retry to the sink, buffering up to bufferSize of them for it.
+// nil stops sending them
+func (rpccs *RPCConsumerServer) SetDeadLetterSink(sink DeadLetterSink, bufferSize int) {
+	var deadLetters *bufferedSink[*DeadLetter]
+	if sink != nil {
+		deadLetters = newBufferedSink("dead letter", bufferSize, sink.DeadLetter)
+	}
+	// relays read the sink while it's replaced, the previous one sends its buffered letters before closing
+	if previous := rpccs.deadLetters.Swap(deadLetters); previous != nil {
+		previous.close()
+	}
+}
+
+func (rpccs *RPCConsumerServer) sendDeadLetter(ctx context.Context, chainMessage chainlib.ChainMessage, url string, req []byte, connectionType string, dappID string, relayErrors *RelayErrors, relayErr error) {
+	deadLetters := rpccs.deadLetters.Load()
+	if deadLetters == nil {
+		return
+	}
+	letter := &DeadLetter{
+		Time:           time.Now(),
+		ChainID:        rpccs.listenEndpoint.ChainID,
+		ApiInterface:   rpccs.listenEndpoint.ApiInterface,
+		Method:         chainMessage.GetApi().Name,
+		Url:            url,
+		ConnectionType: connectionType,
+		Request:        req,
+		DappID:         dappID,
+		ProvidersTried: []string{},
+		Error:          relayErr.Error(),
+	}
+	for _, relayError := range relayErrors.relayErrors {
+		if relayError.ProviderInfo.ProviderAddress != "" {
+			letter.ProvidersTried = append(letter.ProvidersTried, relayError.ProviderInfo.ProviderAddress)
+		}
+	}
+	utils.LavaFormatDebug("relay failed every retry, sending a dead letter", utils.LogAttr("GUID", ctx), utils.LogAttr("providersTried", len(letter.ProvidersTried)))
+	deadLetters.push(letter, utils.LogAttr("method", letter.Method))
+}
@@ -0,0 +1,69 @@
+package rpcconsumer
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRelayer is a provider failing every relay
+type failingRelayer struct {
+	pairingtypes.UnimplementedRelayerServer
+}
+
+func TestDeadLetterSink(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	_, providerAddress := sigs.GenerateFloatingKey()
+	rpccs := newTestConsumer(t, spec, &failingRelayer{}, providerAddress, consumerKey, consumerAddress)
+	ctx := context.Background()
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`
+
+	sink := make(ChannelDeadLetterSink, 10)
+	rpccs.SetDeadLetterSink(sink, DefaultDeadLetterBufferSize)
+	defer rpccs.SetDeadLetterSink(nil, 0)
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.Error(t, err)
+	var letter *DeadLetter
+	select {
+	case letter = <-sink:
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed relay didn't land in the dead letter sink")
+	}
+	require.Equal(t, "ETH1", letter.ChainID)
+	require.Equal(t, "eth_getBalance", letter.Method)
+	require.Equal(t, http.MethodPost, letter.ConnectionType)
+	require.Equal(t, "dapp", letter.DappID)
+	require.JSONEq(t, req, string(letter.Request))
+	require.Equal(t, []string{providerAddress.String()}, letter.ProvidersTried)
+	require.Equal(t, err.Error(), letter.Error)
+
+	// relays the trusted fallback serves aren't dead letters
+	rpccs.trustedFallback = &mockTrustedNode{}
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.Never(t, func() bool { return len(sink) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	// the file sink keeps them as json lines
+	path := filepath.Join(t.TempDir(), "dead_letters.jsonl")
+	fileSink, err := NewFileDeadLetterSink(path)
+	require.NoError(t, err)
+	fileSink.DeadLetter(letter)
+	fileSink.DeadLetter(letter)
+	require.NoError(t, fileSink.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, bytes.Count(data, []byte("\n")))
+}
@@ -0,0 +1,73 @@
+package rpcconsumer
+
+import (
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils"
+)
+
+// records waiting for a slow export sink beyond this are dropped
+const DefaultRelayExportBufferSize = 1000
+
+// RelayExportRecord describes a completed relay for analytics pipelines
+type RelayExportRecord struct {
+	Time         time.Time     `json:"time"`
+	ChainID      string        `json:"chain_id"`
+	ApiInterface string        `json:"api_interface"`
+	Method       string        `json:"method"` // empty when the request failed parsing
+	DappID       string        `json:"dapp_id"`
+	Provider     string        `json:"provider"` // empty when no provider replied
+	Latency      time.Duration `json:"latency"`
+	ComputeUnits uint64        `json:"compute_units"`
+	StatusCode   int           `json:"status_code"`
+	Success      bool          `json:"success"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// RelayExportSink receives a record of every relay SendRelay completed, it's called from a single goroutine off the
+// relay path. records arriving while the buffer is full are dropped instead of holding replies back
+type RelayExportSink interface {
+	Export(record *RelayExportRecord)
+}
+
+// SetRelayExportSink exports a record of every completed relay to the sink, buffering up to bufferSize records for it.
+// nil stops exporting
+func (rpccs *RPCConsumerServer) SetRelayExportSink(sink RelayExportSink, bufferSize int) {
+	var exporter *bufferedSink[*RelayExportRecord]
+	if sink != nil {
+		exporter = newBufferedSink("relay export", bufferSize, sink.Export)
+	}
+	// relays read the exporter while it's replaced, the previous one exports its buffered records before closing
+	if previous := rpccs.relayExporter.Swap(exporter); previous != nil {
+		previous.close()
+	}
+}
+
+func (rpccs *RPCConsumerServer) exportRelay(relaySentTime time.Time, chainMessage chainlib.ChainMessage, dappID string, relayResult *common.RelayResult, relayErr error) {
+	exporter := rpccs.relayExporter.Load()
+	if exporter == nil {
+		return
+	}
+	record := &RelayExportRecord{
+		Time:         relaySentTime,
+		ChainID:      rpccs.listenEndpoint.ChainID,
+		ApiInterface: rpccs.listenEndpoint.ApiInterface,
+		DappID:       dappID,
+		Latency:      time.Since(relaySentTime),
+		Success:      relayErr == nil,
+	}
+	if chainMessage != nil {
+		record.Method = chainMessage.GetApi().Name
+		record.ComputeUnits = chainlib.GetComputeUnits(chainMessage)
+	}
+	if relayResult != nil {
+		record.Provider = relayResult.ProviderInfo.ProviderAddress
+		record.StatusCode = relayResult.StatusCode
+	}
+	if relayErr != nil {
+		record.Error = relayErr.Error()
+	}
+	exporter.push(record, utils.LogAttr("method", record.Method))
+}
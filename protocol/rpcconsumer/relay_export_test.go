@@ -0,0 +1,146 @@
+package rpcconsumer
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+type channelExportSink struct {
+	records chan *RelayExportRecord
+	release chan struct{} // when set, Export blocks until it's closed
+}
+
+func (ces *channelExportSink) Export(record *RelayExportRecord) {
+	if ces.release != nil {
+		<-ces.release
+	}
+	ces.records <- record
+}
+
+func (ces *channelExportSink) next(t *testing.T) *RelayExportRecord {
+	select {
+	case record := <-ces.records:
+		return record
+	case <-time.After(time.Second):
+		require.FailNow(t, "relay was not exported")
+		return nil
+	}
+}
+
+func TestRelayExport(t *testing.T) {
+	ctx := context.Background()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	listenEndpoint := &lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC}
+	// no provider is paired, relays fail unless the trusted node serves them
+	csm := lavasession.NewConsumerSessionManager(listenEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	rpccs := &RPCConsumerServer{
+		chainParser:            chainParser,
+		consumerSessionManager: csm,
+		listenEndpoint:         listenEndpoint,
+		finalizationConsensus:  lavaprotocol.NewFinalizationConsensus("ETH1"),
+		consumerConsistency:    NewConsumerConsistency("ETH1"),
+		consumerTxSender:       mockConsumerTxSender{},
+	}
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+
+	// a no-op by default
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.Error(t, err)
+
+	sink := &channelExportSink{records: make(chan *RelayExportRecord, 10)}
+	rpccs.SetRelayExportSink(sink, DefaultRelayExportBufferSize)
+	defer rpccs.SetRelayExportSink(nil, 0)
+
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.Error(t, err)
+	record := sink.next(t)
+	require.False(t, record.Success)
+	require.Equal(t, err.Error(), record.Error)
+	require.Equal(t, "ETH1", record.ChainID)
+	require.Equal(t, spectypes.APIInterfaceJsonRPC, record.ApiInterface)
+	require.Equal(t, "eth_blockNumber", record.Method)
+	require.Equal(t, "dapp", record.DappID)
+	require.NotZero(t, record.ComputeUnits)
+
+	rpccs.trustedFallback = &mockTrustedNode{}
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	record = sink.next(t)
+	require.True(t, record.Success)
+	require.Empty(t, record.Error)
+	require.Equal(t, "eth_blockNumber", record.Method)
+	require.Positive(t, record.Latency)
+
+	// requests that fail parsing are exported without a method
+	_, err = rpccs.SendRelay(ctx, "", `{"jsonrpc":"2.0","id":1,"method":"no_such_method","params":[]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.Error(t, err)
+	record = sink.next(t)
+	require.False(t, record.Success)
+	require.Empty(t, record.Method)
+}
+
+func TestRelayExportDropsWhenSinkIsSlow(t *testing.T) {
+	sink := &channelExportSink{records: make(chan *RelayExportRecord, 10), release: make(chan struct{})}
+	exporter := newBufferedSink("relay export", 1, sink.Export)
+	defer exporter.close()
+	// the first record is held by the blocked sink, the second fills the buffer
+	exporter.push(&RelayExportRecord{Method: "first"})
+	require.Eventually(t, func() bool { return len(exporter.items) == 0 }, time.Second, time.Millisecond)
+	exporter.push(&RelayExportRecord{Method: "second"})
+	done := make(chan struct{})
+	go func() {
+		exporter.push(&RelayExportRecord{Method: "dropped"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "push blocked on a slow sink")
+	}
+	require.Equal(t, uint64(1), exporter.droppedItems())
+
+	close(sink.release)
+	require.Equal(t, "first", sink.next(t).Method)
+	require.Equal(t, "second", sink.next(t).Method)
+}
+
+func TestRelayExportSinkDrainsOnClose(t *testing.T) {
+	rpccs := &RPCConsumerServer{listenEndpoint: &lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC}}
+	sink := &channelExportSink{records: make(chan *RelayExportRecord, 100), release: make(chan struct{})}
+	rpccs.SetRelayExportSink(sink, 100)
+
+	// export concurrently with replacing the sink
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rpccs.exportRelay(time.Now(), nil, "dapp", nil, nil)
+		}()
+	}
+	wg.Wait()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(sink.release)
+	}()
+	// replacing the sink waits for the records buffered for the previous one to be exported
+	rpccs.SetRelayExportSink(nil, 0)
+	require.Len(t, sink.records, 10)
+	require.Nil(t, rpccs.relayExporter.Load())
+	rpccs.exportRelay(time.Now(), nil, "dapp", nil, nil) // a no-op once it's unset
+}
@@ -0,0 +1,42 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+)
+
+const TrustRelayPriorityHeaderFlag = "trust-relay-priority-header"
+
+// honor the lava-relay-priority header, only for portals whose callers are all trusted. otherwise any caller could
+// admit its relays ahead of everyone else's, priorities come from the endpoint's relay-priorities
+var TrustRelayPriorityHeader = false
+
+// parseRelayPriorities validates the endpoint's dapp id -> relay priority configuration
+func parseRelayPriorities(priorities map[string]string) (map[string]lavasession.RelayPriority, error) {
+	parsed := make(map[string]lavasession.RelayPriority, len(priorities))
+	for dappID, priority := range priorities {
+		relayPriority, err := lavasession.ParseRelayPriority(priority)
+		if err != nil {
+			return nil, utils.LavaFormatError("invalid relay priority", err, utils.LogAttr("dappID", dappID))
+		}
+		parsed[dappID] = relayPriority
+	}
+	return parsed, nil
+}
+
+// relayPriority returns the priority the dapp's relays are configured with, a trusted priority header overrides it.
+// returns false when neither sets one
+func (rpccs *RPCConsumerServer) relayPriority(ctx context.Context, dappID string, directiveHeaders map[string]string) (lavasession.RelayPriority, bool) {
+	if priorityHeader, ok := directiveHeaders[common.RELAY_PRIORITY_HEADER_NAME]; ok && TrustRelayPriorityHeader {
+		priority, err := lavasession.ParseRelayPriority(priorityHeader)
+		if err == nil {
+			return priority, true
+		}
+		utils.LavaFormatWarning("ignoring relay priority header", err, utils.LogAttr("GUID", ctx))
+	}
+	priority, ok := rpccs.relayPriorities[dappID]
+	return priority, ok
+}
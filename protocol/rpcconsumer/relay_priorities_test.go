@@ -0,0 +1,43 @@
+package rpcconsumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayPriority(t *testing.T) {
+	defer func(trust bool) { TrustRelayPriorityHeader = trust }(TrustRelayPriorityHeader)
+	ctx := context.Background()
+	priorities, err := parseRelayPriorities(map[string]string{"indexer": "low", "wallet": "high"})
+	require.NoError(t, err)
+	rpccs := &RPCConsumerServer{relayPriorities: priorities}
+	highHeader := map[string]string{common.RELAY_PRIORITY_HEADER_NAME: "high"}
+
+	priority, ok := rpccs.relayPriority(ctx, "wallet", nil)
+	require.True(t, ok)
+	require.Equal(t, lavasession.RelayPriorityHigh, priority)
+	_, ok = rpccs.relayPriority(ctx, "other", nil)
+	require.False(t, ok)
+	// callers can't raise their own priority by default
+	priority, ok = rpccs.relayPriority(ctx, "indexer", highHeader)
+	require.True(t, ok)
+	require.Equal(t, lavasession.RelayPriorityLow, priority)
+	_, ok = rpccs.relayPriority(ctx, "other", highHeader)
+	require.False(t, ok)
+
+	TrustRelayPriorityHeader = true
+	priority, ok = rpccs.relayPriority(ctx, "indexer", highHeader)
+	require.True(t, ok)
+	require.Equal(t, lavasession.RelayPriorityHigh, priority)
+	// an invalid header falls back to the dapp's priority
+	priority, ok = rpccs.relayPriority(ctx, "indexer", map[string]string{common.RELAY_PRIORITY_HEADER_NAME: "urgent"})
+	require.True(t, ok)
+	require.Equal(t, lavasession.RelayPriorityLow, priority)
+
+	_, err = parseRelayPriorities(map[string]string{"indexer": "urgent"})
+	require.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package rpcconsumer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/utils"
+	"github.com/lavanet/lava/utils/sigs"
+	conflicttypes "github.com/lavanet/lava/x/conflict/types"
+	"github.com/lavanet/lava/x/conflict/types/construct"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+const RecordRelaysFlag = "record-relays"
+
+// relays are recorded to this file when set
+var RecordRelaysPath = ""
+
+// request headers carrying credentials, they are stripped from the recorded requests
+var recordStrippedHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+	"x-api-key":           {},
+	"x-auth-token":        {},
+}
+
+// RelayRecord holds what's needed to reproduce a relay's verification offline, the request and reply are kept as their
+// protobuf encoding so a replay sees the exact bytes the provider signed. the relay request only carries the consumer's
+// signature, the private key is never part of a record. credential headers are stripped from the request, the hash the
+// provider signed is kept instead for those relays
+type RelayRecord struct {
+	Time              time.Time     `json:"time"`
+	ProviderAddress   string        `json:"provider_address"`
+	Request           []byte        `json:"request"`
+	Reply             []byte        `json:"reply"`
+	SignedHash        []byte        `json:"signed_hash,omitempty"`
+	Latency           time.Duration `json:"latency"`
+	VerificationError string        `json:"verification_error,omitempty"`
+}
+
+func (rr *RelayRecord) RelayRequest() (*pairingtypes.RelayRequest, error) {
+	request := &pairingtypes.RelayRequest{}
+	return request, request.Unmarshal(rr.Request)
+}
+
+func (rr *RelayRecord) RelayReply() (*pairingtypes.RelayReply, error) {
+	reply := &pairingtypes.RelayReply{}
+	return reply, reply.Unmarshal(rr.Reply)
+}
+
+// ReplayVerification runs the record's relay through the reply verification again
+func (rr *RelayRecord) ReplayVerification(ctx context.Context) error {
+	request, err := rr.RelayRequest()
+	if err != nil {
+		return utils.LavaFormatError("failed decoding recorded relay request", err)
+	}
+	reply, err := rr.RelayReply()
+	if err != nil {
+		return utils.LavaFormatError("failed decoding recorded relay reply", err)
+	}
+	if len(rr.SignedHash) == 0 {
+		return lavaprotocol.VerifyRelayReply(ctx, reply, request, rr.ProviderAddress)
+	}
+	// the stripped request no longer hashes to what the provider signed, the signature is checked against the recorded hash
+	signer, err := sigs.ExtractSignerAddress(conflicttypes.ReplyMetadata{HashAllDataHash: rr.SignedHash, Sig: reply.Sig})
+	if err != nil {
+		return utils.LavaFormatError("failed recovering the recorded reply signer", lavaprotocol.RelayReplySignatureRecoveryError, utils.LogAttr("GUID", ctx), utils.LogAttr("error", err))
+	}
+	if signer.String() != rr.ProviderAddress {
+		return utils.LavaFormatError("reply server address mismatch ", lavaprotocol.ProviderFinzalizationDataError, utils.LogAttr("GUID", ctx), utils.LogAttr("parsed Address", signer.String()), utils.LogAttr("expected address", rr.ProviderAddress))
+	}
+	return nil
+}
+
+// RelayRecordSink receives the recorded relays, it's called from the relay path so it should not block for long
+type RelayRecordSink interface {
+	Record(record *RelayRecord) error
+}
+
+// FileRelayRecorder appends relay records to a file as json lines
+type FileRelayRecorder struct {
+	lock    sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func NewFileRelayRecorder(path string) (*FileRelayRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRelayRecorder{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func (frr *FileRelayRecorder) Record(record *RelayRecord) error {
+	frr.lock.Lock()
+	defer frr.lock.Unlock()
+	return frr.encoder.Encode(record)
+}
+
+func (frr *FileRelayRecorder) Close() error {
+	frr.lock.Lock()
+	defer frr.lock.Unlock()
+	return frr.file.Close()
+}
+
+// LoadRelayRecords reads the records written by a FileRelayRecorder
+func LoadRelayRecords(path string) ([]*RelayRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records := []*RelayRecord{}
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for decoder.More() {
+		record := &RelayRecord{}
+		if err := decoder.Decode(record); err != nil {
+			return nil, utils.LavaFormatError("failed decoding relay record", err, utils.LogAttr("path", path), utils.LogAttr("index", len(records)))
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SetRelayRecorder records every relay's request, reply and verification outcome to the sink, nil stops recording
+func (rpccs *RPCConsumerServer) SetRelayRecorder(sink RelayRecordSink) {
+	rpccs.relayRecorder = sink
+}
+
+func (rpccs *RPCConsumerServer) recordRelay(ctx context.Context, providerAddress string, request *pairingtypes.RelayRequest, reply *pairingtypes.RelayReply, latency time.Duration, verificationErr error) {
+	if rpccs.relayRecorder == nil {
+		return
+	}
+	recordedRequest, stripped := stripCredentialHeaders(request)
+	// the request and reply keep changing after verification, so they are encoded now
+	requestBytes, err := recordedRequest.Marshal()
+	if err != nil {
+		utils.LavaFormatWarning("failed encoding relay request for the recorder", err, utils.LogAttr("GUID", ctx))
+		return
+	}
+	replyBytes, err := reply.Marshal()
+	if err != nil {
+		utils.LavaFormatWarning("failed encoding relay reply for the recorder", err, utils.LogAttr("GUID", ctx))
+		return
+	}
+	record := &RelayRecord{
+		Time:            time.Now(),
+		ProviderAddress: providerAddress,
+		Request:         requestBytes,
+		Reply:           replyBytes,
+		Latency:         latency,
+	}
+	if stripped {
+		record.SignedHash = construct.ConstructReplyMetadata(reply, request).HashAllDataHash
+	}
+	if verificationErr != nil {
+		record.VerificationError = verificationErr.Error()
+	}
+	if err := rpccs.relayRecorder.Record(record); err != nil {
+		utils.LavaFormatWarning("failed recording relay", err, utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerAddress))
+	}
+}
+
+// stripCredentialHeaders returns the request without the headers carrying credentials, the request itself isn't changed
+func stripCredentialHeaders(request *pairingtypes.RelayRequest) (*pairingtypes.RelayRequest, bool) {
+	if request.RelayData == nil {
+		return request, false
+	}
+	metadata := make([]pairingtypes.Metadata, 0, len(request.RelayData.Metadata))
+	for _, header := range request.RelayData.Metadata {
+		if _, ok := recordStrippedHeaders[strings.ToLower(header.Name)]; ok {
+			continue
+		}
+		metadata = append(metadata, header)
+	}
+	if len(metadata) == len(request.RelayData.Metadata) {
+		return request, false
+	}
+	relayData := *request.RelayData
+	relayData.Metadata = metadata
+	return &pairingtypes.RelayRequest{RelaySession: request.RelaySession, RelayData: &relayData}, true
+}
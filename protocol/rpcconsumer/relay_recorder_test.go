@@ -0,0 +1,109 @@
+package rpcconsumer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayRecorder(t *testing.T) {
+	ctx := context.Background()
+	consumerSk, consumerAddress := sigs.GenerateFloatingKey()
+	providerSk, providerAddress := sigs.GenerateFloatingKey()
+	singleConsumerSession := &lavasession.SingleConsumerSession{
+		CuSum:         20,
+		LatestRelayCu: 10,
+		QoSInfo:       lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}},
+		SessionId:     123,
+		RelayNum:      1,
+	}
+	relayRequestData := lavaprotocol.NewRelayData(ctx, "POST", "", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`), 0, 55, "jsonrpc", nil, "", nil)
+	request, err := lavaprotocol.ConstructRelayRequest(ctx, consumerSk, "lava", "ETH1", relayRequestData, providerAddress.String(), singleConsumerSession, 100, nil)
+	require.NoError(t, err)
+	reply, err := lavaprotocol.SignRelayResponse(consumerAddress, *request, providerSk, &pairingtypes.RelayReply{Data: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x37"}`), LatestBlock: 55}, false)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "relays.jsonl")
+	recorder, err := NewFileRelayRecorder(path)
+	require.NoError(t, err)
+	rpccs := &RPCConsumerServer{}
+	// disabled recording is a no-op
+	rpccs.recordRelay(ctx, providerAddress.String(), request, reply, time.Millisecond, nil)
+
+	rpccs.SetRelayRecorder(recorder)
+	rpccs.recordRelay(ctx, providerAddress.String(), request, reply, 5*time.Millisecond, nil)
+	// the recorder snapshots the relay, later changes to the reply don't leak into the record
+	tampered := *reply
+	tampered.Data = []byte(`{"jsonrpc":"2.0","id":1,"result":"0x38"}`)
+	verificationErr := lavaprotocol.VerifyRelayReply(ctx, &tampered, request, providerAddress.String())
+	require.Error(t, verificationErr)
+	rpccs.recordRelay(ctx, providerAddress.String(), request, &tampered, 5*time.Millisecond, verificationErr)
+	reply.Data = nil
+	require.NoError(t, recorder.Close())
+
+	records, err := LoadRelayRecords(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	require.Equal(t, providerAddress.String(), records[0].ProviderAddress)
+	require.Equal(t, 5*time.Millisecond, records[0].Latency)
+	require.Empty(t, records[0].VerificationError)
+	recordedReply, err := records[0].RelayReply()
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x37"}`, string(recordedReply.Data))
+	require.NoError(t, records[0].ReplayVerification(ctx))
+
+	// a failed verification replays the same way
+	require.Equal(t, verificationErr.Error(), records[1].VerificationError)
+	require.Error(t, records[1].ReplayVerification(ctx))
+}
+
+func TestRelayRecorderStripsCredentials(t *testing.T) {
+	ctx := context.Background()
+	consumerSk, consumerAddress := sigs.GenerateFloatingKey()
+	providerSk, providerAddress := sigs.GenerateFloatingKey()
+	singleConsumerSession := &lavasession.SingleConsumerSession{
+		CuSum:         20,
+		LatestRelayCu: 10,
+		QoSInfo:       lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}},
+		SessionId:     123,
+		RelayNum:      1,
+	}
+	headers := []pairingtypes.Metadata{{Name: "Authorization", Value: "Bearer secret"}, {Name: "x-cosmos-block-height", Value: "55"}}
+	relayRequestData := lavaprotocol.NewRelayData(ctx, "POST", "", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`), 0, 55, "jsonrpc", headers, "", nil)
+	request, err := lavaprotocol.ConstructRelayRequest(ctx, consumerSk, "lava", "ETH1", relayRequestData, providerAddress.String(), singleConsumerSession, 100, nil)
+	require.NoError(t, err)
+	reply, err := lavaprotocol.SignRelayResponse(consumerAddress, *request, providerSk, &pairingtypes.RelayReply{Data: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x37"}`), LatestBlock: 55}, false)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "relays.jsonl")
+	recorder, err := NewFileRelayRecorder(path)
+	require.NoError(t, err)
+	rpccs := &RPCConsumerServer{}
+	rpccs.SetRelayRecorder(recorder)
+	rpccs.recordRelay(ctx, providerAddress.String(), request, reply, 5*time.Millisecond, nil)
+	tampered := *reply
+	tampered.Data = []byte(`{"jsonrpc":"2.0","id":1,"result":"0x38"}`)
+	rpccs.recordRelay(ctx, providerAddress.String(), request, &tampered, 5*time.Millisecond, nil)
+	require.NoError(t, recorder.Close())
+	// the relay itself keeps its headers
+	require.Equal(t, headers, request.RelayData.Metadata)
+
+	records, err := LoadRelayRecords(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	recordedRequest, err := records[0].RelayRequest()
+	require.NoError(t, err)
+	require.Equal(t, headers[1:], recordedRequest.RelayData.Metadata)
+	require.NotEmpty(t, records[0].SignedHash)
+	// the signature is replayed against the hash the provider signed
+	require.NoError(t, records[0].ReplayVerification(ctx))
+	require.Error(t, records[1].ReplayVerification(ctx))
+}
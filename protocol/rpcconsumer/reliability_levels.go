@@ -0,0 +1,56 @@
+package rpcconsumer
+
+import (
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/protocol/chainlib"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+// ReliabilityLevel is how often an api's relays are cross checked with a second provider for data reliability. the
+// spec's api declares its level, the endpoint's reliability-methods override it
+type ReliabilityLevel string
+
+const (
+	// never cross checked, for cheap calls whose data isn't worth the extra relay
+	ReliabilityNever ReliabilityLevel = spectypes.ReliabilityLevelNever
+	// cross checked when the spec's reliability threshold selects the relay, the default
+	ReliabilityProbabilistic ReliabilityLevel = spectypes.ReliabilityLevelProbabilistic
+	// the sampler is skipped, for data like balances and nonces. a relay is still only cross checked when it's
+	// eligible (a deterministic api, a finalized reply for a specific block), the reliability budget is left and
+	// another provider is available after the cooldown and colocation rules. the cross check is a regular relay, not
+	// the per epoch data reliability session, so it isn't limited to one per provider per epoch
+	ReliabilityAlways ReliabilityLevel = spectypes.ReliabilityLevelAlways
+)
+
+var InvalidReliabilityLevelError = sdkerrors.New("InvalidReliabilityLevel Error", 695, "unknown reliability level, use never, probabilistic or always")
+
+// parseReliabilityLevels validates the endpoint's api name -> reliability level configuration
+func parseReliabilityLevels(levels map[string]string) (map[string]ReliabilityLevel, error) {
+	parsed := make(map[string]ReliabilityLevel, len(levels))
+	for apiName, level := range levels {
+		switch ReliabilityLevel(level) {
+		case ReliabilityNever, ReliabilityProbabilistic, ReliabilityAlways:
+			parsed[apiName] = ReliabilityLevel(level)
+		default:
+			return nil, sdkerrors.Wrapf(InvalidReliabilityLevelError, "api %s level %q", apiName, level)
+		}
+	}
+	return parsed, nil
+}
+
+// reliabilityLevel returns the configured level of the message's api, else the level its spec api declares. apis
+// without one are probabilistic
+func (rpccs *RPCConsumerServer) reliabilityLevel(chainMessage chainlib.ChainMessage) ReliabilityLevel {
+	if chainMessage == nil {
+		return ReliabilityProbabilistic
+	}
+	api := chainMessage.GetApi()
+	if level, ok := rpccs.reliabilityLevels[api.Name]; ok {
+		return level
+	}
+	switch level := ReliabilityLevel(api.ReliabilityLevel); level {
+	case ReliabilityNever, ReliabilityAlways:
+		return level
+	}
+	return ReliabilityProbabilistic
+}
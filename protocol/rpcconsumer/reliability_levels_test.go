@@ -0,0 +1,71 @@
+package rpcconsumer
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedSampler draws the same value for every relay
+type fixedSampler uint32
+
+func (fs fixedSampler) Sample(ctx context.Context, relayResult *common.RelayResult) uint32 {
+	return uint32(fs)
+}
+
+func TestReliabilityLevels(t *testing.T) {
+	ctx := context.Background()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+
+	threshold := uint32(math.MaxUint32 / 2)
+	selected, notSelected := fixedSampler(0), fixedSampler(math.MaxUint32)
+	for _, play := range []struct {
+		name      string
+		level     string
+		specLevel string
+		sampler   fixedSampler
+		expected  bool
+	}{
+		{name: "never skips a selected relay", level: "never", sampler: selected, expected: false},
+		{name: "never skips an unselected relay", level: "never", sampler: notSelected, expected: false},
+		{name: "always checks a selected relay", level: "always", sampler: selected, expected: true},
+		{name: "always checks an unselected relay", level: "always", sampler: notSelected, expected: true},
+		{name: "probabilistic checks a selected relay", level: "probabilistic", sampler: selected, expected: true},
+		{name: "probabilistic skips an unselected relay", level: "probabilistic", sampler: notSelected, expected: false},
+		{name: "unconfigured checks a selected relay", sampler: selected, expected: true},
+		{name: "unconfigured skips an unselected relay", sampler: notSelected, expected: false},
+		{name: "spec never skips a selected relay", specLevel: "never", sampler: selected, expected: false},
+		{name: "spec always checks an unselected relay", specLevel: "always", sampler: notSelected, expected: true},
+		{name: "configured level overrides the spec", level: "always", specLevel: "never", sampler: notSelected, expected: true},
+	} {
+		t.Run(play.name, func(t *testing.T) {
+			configured := map[string]string{"eth_blockNumber": "never"}
+			if play.level != "" {
+				configured[chainMessage.GetApi().Name] = play.level
+			}
+			levels, err := parseReliabilityLevels(configured)
+			require.NoError(t, err)
+			chainMessage.GetApi().ReliabilityLevel = play.specLevel
+			rpccs := &RPCConsumerServer{reliabilityLevels: levels}
+			rpccs.SetDataReliabilitySampler(play.sampler)
+			require.Equal(t, play.expected, rpccs.shouldSendDataReliability(ctx, nil, chainMessage, threshold))
+		})
+	}
+
+	_, err = parseReliabilityLevels(map[string]string{"eth_getBalance": "sometimes"})
+	require.ErrorIs(t, err, InvalidReliabilityLevelError)
+}
@@ -0,0 +1,161 @@
+package rpcconsumer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils/rand"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ReliabilityProviderPolicyFlag = "reliability-provider-policy"
+	ReliabilityCooldownFlag       = "reliability-cooldown"
+)
+
+// ReliabilityProviderPolicyType selects the provider a data reliability relay is sent to
+type ReliabilityProviderPolicyType string
+
+const (
+	ReliabilityProviderBestQoS     ReliabilityProviderPolicyType = "best-qos"    // the provider optimizer's pick among the other providers
+	ReliabilityProviderRandom      ReliabilityProviderPolicyType = "random"      // a uniformly random other provider, the original provider can't predict it
+	ReliabilityProviderIndependent ReliabilityProviderPolicyType = "independent" // the providers sharing a host with the fewest others, never one colocated with the original
+)
+
+var ReliabilityProviderPolicy = ReliabilityProviderBestQoS
+
+// a provider that was sent a data reliability relay isn't picked for another one for this long, unless every other
+// provider is cooling down too, 0 disables it
+var ReliabilityCooldown time.Duration = 0
+
+func (rp *ReliabilityProviderPolicyType) String() string {
+	return string(*rp)
+}
+
+func (rp *ReliabilityProviderPolicyType) Set(str string) error {
+	switch ReliabilityProviderPolicyType(str) {
+	case ReliabilityProviderBestQoS, ReliabilityProviderRandom, ReliabilityProviderIndependent:
+		*rp = ReliabilityProviderPolicyType(str)
+		return nil
+	}
+	return fmt.Errorf("invalid reliability provider policy: %s, expected %s, %s or %s", str, ReliabilityProviderBestQoS, ReliabilityProviderRandom, ReliabilityProviderIndependent)
+}
+
+func (rp *ReliabilityProviderPolicyType) Type() string {
+	return "string"
+}
+
+// applyReliabilityPolicy narrows the providers the data reliability relay of originalProvider can be sent to by the
+// policy, the optimizer picks among the ones left. when the policy leaves none the providers are kept as they were
+func (rpccs *RPCConsumerServer) applyReliabilityPolicy(originalProvider string, unwanted map[string]struct{}, chainMessage chainlib.ChainMessage) map[string]struct{} {
+	unwanted = rpccs.applyReliabilityCooldown(unwanted, chainMessage)
+	if ReliabilityProviderPolicy == ReliabilityProviderBestQoS {
+		return unwanted
+	}
+	excluded := unwanted
+	if ReliabilityProviderPolicy == ReliabilityProviderIndependent {
+		excluded = make(map[string]struct{}, len(unwanted))
+		for provider := range unwanted {
+			excluded[provider] = struct{}{}
+		}
+		for provider := range rpccs.consumerSessionManager.ColocatedProviders(originalProvider) {
+			excluded[provider] = struct{}{}
+		}
+	}
+	candidates := rpccs.consumerSessionManager.ReliabilityCandidates(excluded, chainlib.GetAddon(chainMessage), common.GetExtensionNames(chainMessage.GetExtensions()))
+	if len(candidates) == 0 {
+		return unwanted
+	}
+	chosen := map[string]struct{}{}
+	switch ReliabilityProviderPolicy {
+	case ReliabilityProviderRandom:
+		addresses := make([]string, 0, len(candidates))
+		for address := range candidates {
+			addresses = append(addresses, address)
+		}
+		slices.Sort(addresses)
+		chosen[addresses[rand.Intn(len(addresses))]] = struct{}{}
+	case ReliabilityProviderIndependent:
+		leastColocated := -1
+		for _, colocated := range candidates {
+			if leastColocated < 0 || colocated < leastColocated {
+				leastColocated = colocated
+			}
+		}
+		for address, colocated := range candidates {
+			if colocated == leastColocated {
+				chosen[address] = struct{}{}
+			}
+		}
+	}
+	narrowed := make(map[string]struct{}, len(unwanted)+len(candidates))
+	for provider := range excluded {
+		narrowed[provider] = struct{}{}
+	}
+	for address := range candidates {
+		if _, ok := chosen[address]; !ok {
+			narrowed[address] = struct{}{}
+		}
+	}
+	return narrowed
+}
+
+// applyReliabilityCooldown adds the providers that were recently sent a data reliability relay to unwanted, as long as
+// a provider is left for this one
+func (rpccs *RPCConsumerServer) applyReliabilityCooldown(unwanted map[string]struct{}, chainMessage chainlib.ChainMessage) map[string]struct{} {
+	coolingDown := rpccs.reliabilityCooldowns.coolingDown(ReliabilityCooldown)
+	if len(coolingDown) == 0 {
+		return unwanted
+	}
+	withCooldown := make(map[string]struct{}, len(unwanted)+len(coolingDown))
+	for provider := range unwanted {
+		withCooldown[provider] = struct{}{}
+	}
+	for provider := range coolingDown {
+		withCooldown[provider] = struct{}{}
+	}
+	if len(rpccs.consumerSessionManager.ReliabilityCandidates(withCooldown, chainlib.GetAddon(chainMessage), common.GetExtensionNames(chainMessage.GetExtensions()))) == 0 {
+		// every other provider served one recently, the relay still goes out
+		return unwanted
+	}
+	return withCooldown
+}
+
+// reliabilityCooldownTracker keeps when each provider was last sent a data reliability relay
+type reliabilityCooldownTracker struct {
+	lock       sync.Mutex
+	lastServed map[string]time.Time
+}
+
+func (rct *reliabilityCooldownTracker) served(provider string) {
+	if ReliabilityCooldown <= 0 || provider == "" {
+		return
+	}
+	rct.lock.Lock()
+	defer rct.lock.Unlock()
+	if rct.lastServed == nil {
+		rct.lastServed = map[string]time.Time{}
+	}
+	rct.lastServed[provider] = time.Now()
+}
+
+// coolingDown returns the providers served within cooldown, forgetting the ones served before it
+func (rct *reliabilityCooldownTracker) coolingDown(cooldown time.Duration) map[string]struct{} {
+	if cooldown <= 0 {
+		return nil
+	}
+	rct.lock.Lock()
+	defer rct.lock.Unlock()
+	coolingDown := map[string]struct{}{}
+	for provider, lastServed := range rct.lastServed {
+		if time.Since(lastServed) >= cooldown {
+			delete(rct.lastServed, provider)
+			continue
+		}
+		coolingDown[provider] = struct{}{}
+	}
+	return coolingDown
+}
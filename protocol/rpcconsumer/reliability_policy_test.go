@@ -0,0 +1,117 @@
+package rpcconsumer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	"github.com/lavanet/lava/utils/rand"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReliabilityProviderPolicy(t *testing.T) {
+	rand.InitRandomSeed()
+	defer func(policy ReliabilityProviderPolicyType) { ReliabilityProviderPolicy = policy }(ReliabilityProviderPolicy)
+	defer func(cooldown time.Duration) { ReliabilityCooldown = cooldown }(ReliabilityCooldown)
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+
+	newServer := func(providers []struct{ address, networkAddress string }) *RPCConsumerServer {
+		csm := lavasession.NewConsumerSessionManager(&lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+		pairing := map[uint64]*lavasession.ConsumerSessionsWithProvider{}
+		for idx, provider := range providers {
+			endpoints := []*lavasession.Endpoint{{NetworkAddress: provider.networkAddress, Enabled: true, Addons: map[string]struct{}{}, Extensions: map[string]struct{}{}}}
+			pairing[uint64(idx)] = lavasession.NewConsumerSessionWithProvider(provider.address, endpoints, 200, 20, sdk.NewInt64Coin("ulava", 100))
+		}
+		require.NoError(t, csm.UpdateAllProviders(20, pairing))
+		return &RPCConsumerServer{consumerSessionManager: csm}
+	}
+	rpccs := newServer([]struct{ address, networkAddress string }{
+		{"primary", "node.operator.com:2221"},
+		{"colocated", "node.operator.com:2222"},
+		{"independent", "elsewhere.com:2221"},
+		{"independent2", "another.com:2221"},
+		{"shared1", "shared.com:2221"},
+		{"shared2", "shared.com:2222"},
+	})
+	unwanted := map[string]struct{}{"primary": {}}
+	wanted := func(narrowed map[string]struct{}) []string {
+		left := []string{}
+		for _, address := range []string{"primary", "colocated", "independent", "independent2", "shared1", "shared2"} {
+			if _, ok := narrowed[address]; !ok {
+				left = append(left, address)
+			}
+		}
+		return left
+	}
+
+	t.Run("best qos", func(t *testing.T) {
+		ReliabilityProviderPolicy = ReliabilityProviderBestQoS
+		// the optimizer picks among every other provider, like before policies existed
+		require.Equal(t, []string{"colocated", "independent", "independent2", "shared1", "shared2"}, wanted(rpccs.applyReliabilityPolicy("primary", unwanted, chainMessage)))
+	})
+
+	t.Run("random", func(t *testing.T) {
+		ReliabilityProviderPolicy = ReliabilityProviderRandom
+		picked := map[string]int{}
+		for i := 0; i < 200; i++ {
+			left := wanted(rpccs.applyReliabilityPolicy("primary", unwanted, chainMessage))
+			require.Len(t, left, 1)
+			picked[left[0]]++
+		}
+		require.Len(t, picked, 5)
+		require.NotContains(t, picked, "primary")
+	})
+
+	t.Run("independent", func(t *testing.T) {
+		ReliabilityProviderPolicy = ReliabilityProviderIndependent
+		// the least colocated providers are left to the optimizer
+		require.Equal(t, []string{"independent", "independent2"}, wanted(rpccs.applyReliabilityPolicy("primary", unwanted, chainMessage)))
+		require.Equal(t, []string{"independent2"}, wanted(rpccs.applyReliabilityPolicy("independent", map[string]struct{}{"independent": {}}, chainMessage)))
+		// the relay's own unwanted providers are left as is
+		require.Len(t, unwanted, 1)
+
+		// only colocated providers are left, the policy can't narrow them
+		colocatedOnly := newServer([]struct{ address, networkAddress string }{
+			{"primary", "node.operator.com:2221"},
+			{"colocated", "node.operator.com:2222"},
+		})
+		require.Equal(t, unwanted, colocatedOnly.applyReliabilityPolicy("primary", unwanted, chainMessage))
+	})
+	t.Run("cooldown", func(t *testing.T) {
+		ReliabilityProviderPolicy = ReliabilityProviderBestQoS
+		ReliabilityCooldown = time.Minute
+		rpccs.reliabilityCooldowns.served("independent")
+		rpccs.reliabilityCooldowns.served("shared1")
+		require.Equal(t, []string{"colocated", "independent2", "shared2"}, wanted(rpccs.applyReliabilityPolicy("primary", unwanted, chainMessage)))
+		// the cooldown narrows before the policy picks
+		ReliabilityProviderPolicy = ReliabilityProviderIndependent
+		require.Equal(t, []string{"independent2"}, wanted(rpccs.applyReliabilityPolicy("primary", unwanted, chainMessage)))
+
+		// with every other provider cooling down the relay is still sent
+		ReliabilityProviderPolicy = ReliabilityProviderBestQoS
+		for _, provider := range []string{"colocated", "independent2", "shared2"} {
+			rpccs.reliabilityCooldowns.served(provider)
+		}
+		require.Equal(t, []string{"colocated", "independent", "independent2", "shared1", "shared2"}, wanted(rpccs.applyReliabilityPolicy("primary", unwanted, chainMessage)))
+
+		// providers can be picked again once it passes
+		for provider := range rpccs.reliabilityCooldowns.lastServed {
+			rpccs.reliabilityCooldowns.lastServed[provider] = time.Now().Add(-ReliabilityCooldown)
+		}
+		require.Empty(t, rpccs.reliabilityCooldowns.coolingDown(ReliabilityCooldown))
+		require.Len(t, wanted(rpccs.applyReliabilityPolicy("primary", unwanted, chainMessage)), 5)
+	})
+}
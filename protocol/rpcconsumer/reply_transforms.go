@@ -0,0 +1,62 @@
+package rpcconsumer
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// AddReplyTransform appends a transform to the chain every verified reply goes through, in the order they were added,
+// before it's returned to the user and cached
+func (rpccs *RPCConsumerServer) AddReplyTransform(transform chainlib.ReplyTransform) {
+	rpccs.replyTransforms = append(rpccs.replyTransforms, transform)
+}
+
+// replyNormalizationRules converts the endpoint's reply normalization config to the normalizer's rules
+func replyNormalizationRules(config map[string]lavasession.ReplyNormalizationConfig) map[string]chainlib.ReplyNormalizationRule {
+	rules := make(map[string]chainlib.ReplyNormalizationRule, len(config))
+	for method, rule := range config {
+		rules[method] = chainlib.ReplyNormalizationRule{QuantityFields: rule.QuantityFields, DropNullFields: rule.DropNullFields}
+	}
+	return rules
+}
+
+// runs the reply transforms on data, a transform that fails is skipped as the reply it got is still valid
+func (rpccs *RPCConsumerServer) transformReplyData(ctx context.Context, chainMessage chainlib.ChainMessage, data []byte) []byte {
+	for idx, transform := range rpccs.replyTransforms {
+		transformed, err := transform(chainMessage, data)
+		if err != nil {
+			utils.LavaFormatWarning("failed transforming reply, skipping the transform", err, utils.LogAttr("GUID", ctx), utils.LogAttr("transform", idx), utils.LogAttr("api", chainMessage.GetApi().Name))
+			continue
+		}
+		data = transformed
+	}
+	return data
+}
+
+// transformReply returns the relay result with the transformed reply, a copy when it changed as the original reply is
+// still read by data reliability and conflict detection, which need the data the provider signed
+func (rpccs *RPCConsumerServer) transformReply(ctx context.Context, chainMessage chainlib.ChainMessage, relayResult *common.RelayResult) *common.RelayResult {
+	if len(rpccs.replyTransforms) == 0 || relayResult == nil || relayResult.Reply == nil {
+		return relayResult
+	}
+	transformed := rpccs.transformReplyData(ctx, chainMessage, relayResult.Reply.Data)
+	if bytes.Equal(transformed, relayResult.Reply.Data) {
+		return relayResult
+	}
+	transformedResult := *relayResult
+	transformedResult.Reply = &pairingtypes.RelayReply{
+		Data:                  transformed,
+		Sig:                   relayResult.Reply.Sig,
+		LatestBlock:           relayResult.Reply.LatestBlock,
+		FinalizedBlocksHashes: relayResult.Reply.FinalizedBlocksHashes,
+		SigBlocks:             relayResult.Reply.SigBlocks,
+		Metadata:              append([]pairingtypes.Metadata{}, relayResult.Reply.Metadata...),
+	}
+	return &transformedResult
+}
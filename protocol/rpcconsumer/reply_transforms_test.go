@@ -0,0 +1,45 @@
+package rpcconsumer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformReply(t *testing.T) {
+	ctx := context.Background()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	rpccs := &RPCConsumerServer{chainParser: chainParser}
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	relayResult := &common.RelayResult{Reply: &pairingtypes.RelayReply{Data: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x0010"}`), Sig: []byte("sig"), LatestBlock: 16}}
+
+	// no transforms
+	require.Same(t, relayResult, rpccs.transformReply(ctx, chainMessage, relayResult))
+
+	rpccs.AddReplyTransform(chainlib.NewReplyNormalizer(replyNormalizationRules(map[string]lavasession.ReplyNormalizationConfig{"eth_blockNumber": {QuantityFields: []string{""}}})))
+	// a failing transform is skipped
+	rpccs.AddReplyTransform(func(chainlib.ChainMessageForSend, []byte) ([]byte, error) {
+		return nil, errors.New("failed")
+	})
+	transformed := rpccs.transformReply(ctx, chainMessage, relayResult)
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"0x10"}`, string(transformed.Reply.Data))
+	require.Equal(t, relayResult.Reply.Sig, transformed.Reply.Sig)
+	require.Equal(t, relayResult.Reply.LatestBlock, transformed.Reply.LatestBlock)
+	// the signed reply is kept for data reliability
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x0010"}`, string(relayResult.Reply.Data))
+}
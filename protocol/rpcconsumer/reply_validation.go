@@ -0,0 +1,43 @@
+package rpcconsumer
+
+import (
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+// replySchema returns the schema the api's replies are validated with, the endpoint's schema overrides the one the
+// spec's api declares. nil when the replies aren't validated
+func (rpccs *RPCConsumerServer) replySchema(api *spectypes.Api) *chainlib.ReplySchema {
+	if replySchema, ok := rpccs.replySchemas[api.Name]; ok {
+		return replySchema
+	}
+	if api.ReplySchema == "" {
+		return nil
+	}
+	if compiled, ok := rpccs.specReplySchemas.Load(api.ReplySchema); ok {
+		return compiled.(*chainlib.ReplySchema)
+	}
+	// the spec only checks the schema is json, an unsupported one is cached as nil so it's reported once
+	replySchema, err := chainlib.CompileReplySchema(api.ReplySchema)
+	if err != nil {
+		utils.LavaFormatWarning("the spec's reply schema is unsupported, the api's replies aren't validated", err, utils.LogAttr("api", api.Name))
+	}
+	rpccs.specReplySchemas.Store(api.ReplySchema, replySchema)
+	return replySchema
+}
+
+// apiReplyValidators returns the validators of the api's replies, the endpoint's paginated apis and the spec's
+// paginated rest apis are validated against their pagination
+func (rpccs *RPCConsumerServer) apiReplyValidators(api *spectypes.Api) []chainlib.ReplyValidator {
+	validators := rpccs.replyValidators[api.Name]
+	if !api.Paginated || rpccs.listenEndpoint.ApiInterface != spectypes.APIInterfaceRest {
+		return validators
+	}
+	for _, validator := range validators {
+		if _, ok := validator.(chainlib.RestPaginationValidator); ok {
+			return validators
+		}
+	}
+	return append(validators[:len(validators):len(validators)], chainlib.RestPaginationValidator{})
+}
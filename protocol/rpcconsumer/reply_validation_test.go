@@ -0,0 +1,40 @@
+package rpcconsumer
+
+import (
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavasession"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecReplyValidation(t *testing.T) {
+	endpointSchemas, err := chainlib.CompileReplySchemas(map[string]string{"configured": `{"type":"array"}`})
+	require.NoError(t, err)
+	endpointValidators, err := chainlib.NewReplyValidators(spectypes.APIInterfaceRest, []string{"configured"})
+	require.NoError(t, err)
+	rpccs := &RPCConsumerServer{
+		listenEndpoint:  &lavasession.RPCEndpoint{ApiInterface: spectypes.APIInterfaceRest},
+		replySchemas:    endpointSchemas,
+		replyValidators: endpointValidators,
+	}
+
+	// the spec's schema is compiled once and the endpoint's schema overrides it
+	specApi := &spectypes.Api{Name: "declared", ReplySchema: `{"type":"object","required":["pagination"]}`}
+	replySchema := rpccs.replySchema(specApi)
+	require.NotNil(t, replySchema)
+	require.Same(t, replySchema, rpccs.replySchema(specApi))
+	require.Error(t, replySchema.ValidateReply(spectypes.APIInterfaceRest, []byte(`{}`)))
+	require.Same(t, endpointSchemas["configured"], rpccs.replySchema(&spectypes.Api{Name: "configured", ReplySchema: specApi.ReplySchema}))
+	require.Nil(t, rpccs.replySchema(&spectypes.Api{Name: "plain"}))
+	require.Nil(t, rpccs.replySchema(&spectypes.Api{Name: "unsupported", ReplySchema: `{"type":"decimal"}`}))
+
+	// the spec's paginated apis are validated once, and only on rest
+	require.Empty(t, rpccs.apiReplyValidators(&spectypes.Api{Name: "plain"}))
+	require.Len(t, rpccs.apiReplyValidators(&spectypes.Api{Name: "declared", Paginated: true}), 1)
+	require.Len(t, rpccs.apiReplyValidators(&spectypes.Api{Name: "configured", Paginated: true}), 1)
+	require.Len(t, rpccs.apiReplyValidators(&spectypes.Api{Name: "configured"}), 1)
+	rpccs.listenEndpoint.ApiInterface = spectypes.APIInterfaceJsonRPC
+	require.Empty(t, rpccs.apiReplyValidators(&spectypes.Api{Name: "declared", Paginated: true}))
+}
@@ -0,0 +1,38 @@
+package rpcconsumer
+
+import (
+	"bytes"
+	"context"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// AddRequestTransform appends a transform to the chain every request parsed by SendRelay goes through, in the order
+// they were added. the transformed request is what's signed, cached and sent to the providers
+func (rpccs *RPCConsumerServer) AddRequestTransform(transform chainlib.RequestTransform) {
+	rpccs.requestTransforms = append(rpccs.requestTransforms, transform)
+}
+
+// runs the request transforms, a request a transform modified is parsed again so the next transforms and the relay see
+// the message the transformed data describes
+func (rpccs *RPCConsumerServer) transformRequest(ctx context.Context, chainMessage chainlib.ChainMessage, url string, data []byte, connectionType string, metadata []pairingtypes.Metadata, directiveHeaders map[string]string) (chainlib.ChainMessage, []byte, error) {
+	for idx, transform := range rpccs.requestTransforms {
+		transformed, err := transform(chainMessage, data)
+		if err != nil {
+			return nil, nil, sdkerrors.Wrapf(chainlib.RequestTransformError, "transform %d: %s", idx, err.Error())
+		}
+		if bytes.Equal(transformed, data) {
+			continue
+		}
+		chainMessage, err = rpccs.chainParser.ParseMsg(url, transformed, connectionType, metadata, rpccs.getExtensionsFromDirectiveHeaders(directiveHeaders))
+		if err != nil {
+			return nil, nil, sdkerrors.Wrapf(chainlib.RequestTransformError, "transform %d produced an invalid request: %s", idx, err.Error())
+		}
+		utils.LavaFormatDebug("request transformed", utils.LogAttr("GUID", ctx), utils.LogAttr("transform", idx), utils.LogAttr("request", string(transformed)))
+		data = transformed
+	}
+	return chainMessage, data, nil
+}
@@ -0,0 +1,54 @@
+package rpcconsumer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformRequest(t *testing.T) {
+	ctx := context.Background()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	rpccs := &RPCConsumerServer{chainParser: chainParser, finalizationConsensus: lavaprotocol.NewFinalizationConsensus("ETH1")}
+
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa"]}`
+	chainMessage, err := chainParser.ParseMsg("", []byte(req), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+
+	// no transforms
+	transformedMessage, transformed, err := rpccs.transformRequest(ctx, chainMessage, "", []byte(req), http.MethodPost, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, req, string(transformed))
+	require.Equal(t, chainMessage, transformedMessage)
+
+	// the transformed request is parsed again, the next transform sees the previous one's result
+	rpccs.AddRequestTransform(chainlib.NewDefaultParamsTransform(map[string][]interface{}{"eth_getBalance": {nil, "0x10"}}))
+	rpccs.AddRequestTransform(func(chainMessage chainlib.ChainMessageForSend, data []byte) ([]byte, error) {
+		requestedBlock, _ := chainMessage.(chainlib.ChainMessage).RequestedBlock()
+		require.Equal(t, int64(0x10), requestedBlock)
+		return data, nil
+	})
+	transformedMessage, transformed, err = rpccs.transformRequest(ctx, chainMessage, "", []byte(req), http.MethodPost, nil, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`, string(transformed))
+	requestedBlock, _ := transformedMessage.RequestedBlock()
+	require.Equal(t, int64(0x10), requestedBlock)
+
+	rpccs.AddRequestTransform(func(chainlib.ChainMessageForSend, []byte) ([]byte, error) {
+		return nil, errors.New("rejected")
+	})
+	_, _, err = rpccs.transformRequest(ctx, chainMessage, "", []byte(req), http.MethodPost, nil, nil)
+	require.True(t, chainlib.RequestTransformError.Is(err))
+}
@@ -0,0 +1,35 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/utils"
+)
+
+// RequestedBlockResolutionHook observes how a requested block was resolved from a provider reply, before is the block
+// requested (possibly a magic like latest) and after the concrete block it resolved to. the returned value is used for
+// the rest of the relay, including reliability, so returning after keeps the default behavior
+type RequestedBlockResolutionHook func(ctx context.Context, chainMessage chainlib.ChainMessage, providerAddress string, before int64, after int64) int64
+
+// SetRequestedBlockResolutionHook sets the hook called on every resolved relay, nil restores the passthrough
+func (rpccs *RPCConsumerServer) SetRequestedBlockResolutionHook(hook RequestedBlockResolutionHook) {
+	rpccs.requestedBlockHook = hook
+}
+
+func (rpccs *RPCConsumerServer) resolveRequestedBlock(ctx context.Context, chainMessage chainlib.ChainMessage, providerAddress string, before int64, after int64) int64 {
+	if rpccs.requestedBlockHook == nil {
+		return after
+	}
+	resolved := rpccs.requestedBlockHook(ctx, chainMessage, providerAddress, before, after)
+	if resolved != after {
+		utils.LavaFormatDebug("requested block resolution overridden",
+			utils.LogAttr("GUID", ctx),
+			utils.LogAttr("provider", providerAddress),
+			utils.LogAttr("requestedBlock", before),
+			utils.LogAttr("resolvedBlock", after),
+			utils.LogAttr("override", resolved),
+		)
+	}
+	return resolved
+}
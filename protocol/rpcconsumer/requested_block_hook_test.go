@@ -0,0 +1,34 @@
+package rpcconsumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestedBlockResolutionHook(t *testing.T) {
+	ctx := context.Background()
+	rpccs := &RPCConsumerServer{}
+	// default is a passthrough
+	require.Equal(t, int64(100), rpccs.resolveRequestedBlock(ctx, nil, "provider", spectypes.LATEST_BLOCK, 100))
+
+	type resolution struct{ before, after int64 }
+	observed := []resolution{}
+	rpccs.SetRequestedBlockResolutionHook(func(ctx context.Context, chainMessage chainlib.ChainMessage, providerAddress string, before int64, after int64) int64 {
+		observed = append(observed, resolution{before: before, after: after})
+		if before == spectypes.LATEST_BLOCK {
+			// pin latest a block back
+			return after - 1
+		}
+		return after
+	})
+	require.Equal(t, int64(99), rpccs.resolveRequestedBlock(ctx, nil, "provider", spectypes.LATEST_BLOCK, 100))
+	require.Equal(t, int64(50), rpccs.resolveRequestedBlock(ctx, nil, "provider", 50, 50))
+	require.Equal(t, []resolution{{before: spectypes.LATEST_BLOCK, after: 100}, {before: 50, after: 50}}, observed)
+
+	rpccs.SetRequestedBlockResolutionHook(nil)
+	require.Equal(t, int64(100), rpccs.resolveRequestedBlock(ctx, nil, "provider", spectypes.LATEST_BLOCK, 100))
+}
@@ -164,6 +164,9 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, options *rpcConsumerStartOpt
 	if err != nil {
 		utils.LavaFormatFatal("failed unmarshaling public address", err, utils.Attribute{Key: "keyName", Value: keyName}, utils.Attribute{Key: "pubkey", Value: pubkey.Address()})
 	}
+	if err := verifySigningKey(privKey, consumerAddr); err != nil {
+		return utils.LavaFormatError("consumer signing key check failed", err, utils.Attribute{Key: "keyName", Value: keyName})
+	}
 	// we want one provider optimizer per chain so we will store them for reuse across rpcEndpoints
 	chainMutexes := map[string]*sync.Mutex{}
 	for _, endpoint := range options.rpcEndpoints {
@@ -188,6 +191,37 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, options *rpcConsumerStartOpt
 	}
 	consumerStateTracker.RegisterForVersionUpdates(ctx, version.Version, &upgrade.ProtocolVersion{})
 	relaysMonitorAggregator := metrics.NewRelaysMonitorAggregator(options.cmdFlags.RelaysHealthIntervalFlag, consumerMetricsManager)
+	var relayRecorder RelayRecordSink
+	if RecordRelaysPath != "" {
+		fileRecorder, err := NewFileRelayRecorder(RecordRelaysPath)
+		if err != nil {
+			return utils.LavaFormatError("failed opening the relay recording file", err, utils.LogAttr("path", RecordRelaysPath))
+		}
+		utils.LavaFormatWarning("recording all relays, this is meant for debugging", nil, utils.LogAttr("path", RecordRelaysPath))
+		relayRecorder = fileRecorder
+		defer func() {
+			if err := fileRecorder.Close(); err != nil {
+				utils.LavaFormatWarning("failed closing the relay recording file", err, utils.LogAttr("path", RecordRelaysPath))
+			}
+		}()
+	}
+	var deadLetterSink DeadLetterSink
+	if DeadLettersPath != "" {
+		fileSink, err := NewFileDeadLetterSink(DeadLettersPath)
+		if err != nil {
+			return utils.LavaFormatError("failed opening the dead letters file", err, utils.LogAttr("path", DeadLettersPath))
+		}
+		deadLetterSink = fileSink
+	}
+	var chainRouter *chainlib.ChainProxyRouter
+	var chainRouterListener *chainlib.ChainRouterListener
+	if ChainRouterListen != "" {
+		chainRouter = chainlib.NewChainProxyRouter()
+		chainRouterListener, err = chainlib.NewChainRouterListener(ChainRouterListen, ChainRouterApiInterface, chainRouter, rpcConsumerMetrics)
+		if err != nil {
+			return err
+		}
+	}
 	policyUpdaters := syncMapPolicyUpdaters{}
 	for _, rpcEndpoint := range options.rpcEndpoints {
 		go func(rpcEndpoint *lavasession.RPCEndpoint) error {
@@ -198,6 +232,18 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, options *rpcConsumerStartOpt
 				errCh <- err
 				return err
 			}
+			if jsonRPCChainParser, ok := chainParser.(*chainlib.JsonRPCChainParser); ok && len(rpcEndpoint.MethodAliases) > 0 {
+				jsonRPCChainParser.SetMethodAliases(rpcEndpoint.MethodAliases)
+			}
+			if jsonRPCChainParser, ok := chainParser.(*chainlib.JsonRPCChainParser); ok && len(rpcEndpoint.ParamsSignatures) > 0 {
+				paramsSignatures, err := chainlib.CompileParamsSignatures(rpcEndpoint.ParamsSignatures)
+				if err != nil {
+					err = utils.LavaFormatError("failed compiling params signatures", err, utils.Attribute{Key: "endpoint", Value: rpcEndpoint})
+					errCh <- err
+					return err
+				}
+				jsonRPCChainParser.SetParamsSignatures(paramsSignatures)
+			}
 			chainID := rpcEndpoint.ChainID
 			// create policyUpdaters per chain
 			if policyUpdater, ok := policyUpdaters.Load(rpcEndpoint.ChainID); ok {
@@ -279,6 +325,10 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, options *rpcConsumerStartOpt
 				errCh <- err
 				return err
 			}
+			if jsonRPCChainParser, ok := chainParser.(*chainlib.JsonRPCChainParser); ok {
+				// requests by block hash are placed using the finalized hashes providers agree on
+				jsonRPCChainParser.SetBlockHashResolver(finalizationConsensus.BlockNumByHash)
+			}
 
 			// Register For Updates
 			consumerSessionManager := lavasession.NewConsumerSessionManager(rpcEndpoint, optimizer, consumerMetricsManager, consumerReportsManager)
@@ -290,6 +340,25 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, options *rpcConsumerStartOpt
 				relaysMonitorAggregator.RegisterRelaysMonitor(rpcEndpoint.String(), relaysMonitor)
 			}
 			rpcConsumerServer := &RPCConsumerServer{}
+			rpcConsumerServer.SetRelayRecorder(relayRecorder)
+			if rpcEndpoint.RewriteAliasesOnSend && len(rpcEndpoint.MethodAliases) > 0 {
+				rpcConsumerServer.AddRequestTransform(chainlib.NewMethodAliasTransform(rpcEndpoint.MethodAliases))
+			}
+			if len(rpcEndpoint.DefaultParams) > 0 {
+				rpcConsumerServer.AddRequestTransform(chainlib.NewDefaultParamsTransform(rpcEndpoint.DefaultParams))
+			}
+			if len(rpcEndpoint.MaxBlockRanges) > 0 {
+				rpcConsumerServer.AddRequestTransform(chainlib.NewBlockRangeLimitTransform(rpcEndpoint.MaxBlockRanges))
+			}
+			if len(rpcEndpoint.ReplyNormalization) > 0 {
+				rpcConsumerServer.AddReplyTransform(chainlib.NewReplyNormalizer(replyNormalizationRules(rpcEndpoint.ReplyNormalization)))
+			}
+			if deadLetterSink != nil {
+				rpcConsumerServer.SetDeadLetterSink(deadLetterSink, DefaultDeadLetterBufferSize)
+			}
+			if rpcEndpoint.AddressPrefix != "" {
+				rpcConsumerServer.SetAddressCodec(lavaprotocol.NewDisplayAddressCodec(rpcEndpoint.AddressPrefix))
+			}
 			utils.LavaFormatInfo("RPCConsumer Listening", utils.Attribute{Key: "endpoints", Value: rpcEndpoint.String()})
 			err = rpcConsumerServer.ServeRPCRequests(ctx, rpcEndpoint, rpcc.consumerStateTracker, chainParser, finalizationConsensus, consumerSessionManager, options.requiredResponses, privKey, lavaChainID, options.cache, rpcConsumerMetrics, consumerAddr, consumerConsistency, relaysMonitor, options.cmdFlags, options.stateShare, options.refererData, consumerReportsManager)
 			if err != nil {
@@ -297,6 +366,7 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, options *rpcConsumerStartOpt
 				errCh <- err
 				return err
 			}
+			addToChainRouter(chainRouter, rpcEndpoint, rpcConsumerServer)
 			return nil
 		}(rpcEndpoint)
 	}
@@ -309,6 +379,10 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, options *rpcConsumerStartOpt
 	}
 
 	relaysMonitorAggregator.StartMonitoring(ctx)
+	if chainRouterListener != nil {
+		utils.LavaFormatInfo("serving the chain router", utils.LogAttr("address", ChainRouterListen), utils.LogAttr("chains", chainRouter.ChainIDs()))
+		go chainRouterListener.Serve(ctx, options.cmdFlags)
+	}
 
 	utils.LavaFormatDebug("Starting Policy Updaters for all chains")
 	for chain := range chainMutexes {
@@ -564,6 +638,59 @@ rpcconsumer consumer_examples/full_consumer_example.yml --cache-be "127.0.0.1:77
 	cmdRPCConsumer.Flags().String(refererMarkerFlagName, "lava-referer-", "the string marker to identify referer")
 	cmdRPCConsumer.Flags().String(reportsSendBEAddress, "", "address to send reports to")
 	cmdRPCConsumer.Flags().BoolVar(&lavasession.DebugProbes, DebugProbesFlagName, false, "adding information to probes")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.MaxConcurrentRelays, lavasession.MaxConcurrentRelaysFlag, 0, "maximum number of concurrent relays, relays above it wait for a slot and higher priority relays (the endpoint's relay-priorities) are admitted first, 0 means no limit")
+	cmdRPCConsumer.Flags().BoolVar(&TrustRelayPriorityHeader, TrustRelayPriorityHeaderFlag, TrustRelayPriorityHeader, "admit relays with the priority their lava-relay-priority header sets, only for portals whose callers are all trusted")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.MaxInFlightRelaysPerProvider, lavasession.MaxInFlightRelaysPerProviderFlag, 0, "maximum number of relays in flight to a single provider, when reached the next best provider is chosen, 0 means no limit")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.MaxCuSumPerSession, lavasession.MaxCuSumPerSessionFlag, 0, "maximum cu sum for a single session before rotating to a new session, 0 means no limit")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.MaxRelayNumPerSession, lavasession.MaxRelayNumPerSessionFlag, 0, "maximum relays for a single session before rotating to a new session, 0 means no limit")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.PrewarmProviders, lavasession.PrewarmProvidersFlag, 0, "number of top providers to open sessions with right after a pairing update, 0 disables prewarming")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.PrewarmConcurrency, lavasession.PrewarmConcurrencyFlag, lavasession.DefaultPrewarmConcurrency, "maximum number of providers prewarmed concurrently")
+	cmdRPCConsumer.Flags().BoolVar(&lavasession.PrewarmHealthRelay, lavasession.PrewarmHealthRelayFlag, false, "send a probe relay to prewarmed providers to warm up their qos")
+	cmdRPCConsumer.Flags().DurationVar(&lavasession.LatencySLO, lavasession.LatencySLOFlag, 0, "providers with a rolling p95 latency above this are only used when no other provider is available, 0 disables the slo")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.LatencySLOWindow, lavasession.LatencySLOWindowFlag, lavasession.DefaultLatencySLOWindow, "number of relay latency samples in the rolling latency slo window of each provider")
+	cmdRPCConsumer.Flags().Int64Var(&lavasession.StaleProviderLag, lavasession.StaleProviderLagFlag, 0, "providers serving this many blocks or more behind the expected block height for a whole window of relays are only used when no other provider is available, 0 disables the detection")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.StaleProviderWindow, lavasession.StaleProviderWindowFlag, lavasession.DefaultStaleProviderWindow, "number of consecutive stale relays before a provider is demoted")
+	cmdRPCConsumer.Flags().Float64Var(&lavasession.LatencyAnomalyMultiplier, lavasession.LatencyAnomalyMultiplierFlag, 0, "report providers whose recent latency jumps to this many times their baseline, 0 disables the detection")
+	cmdRPCConsumer.Flags().Float64Var(&lavasession.LatencyAnomalyRecoveryMultiplier, lavasession.LatencyAnomalyRecoveryMultiplierFlag, 0, "a reported provider recovers when its recent latency is under this many times its baseline, must be over 1, defaults to halfway between 1 and the anomaly multiplier")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.LatencyAnomalyWindow, lavasession.LatencyAnomalyWindowFlag, lavasession.DefaultLatencyAnomalyWindow, "number of recent relay latencies compared to the provider's baseline")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.SessionResyncCuTolerance, lavasession.SessionResyncCuToleranceFlag, 0, "on a session sync loss, resync to the provider's cu sum once if it's at most this much above ours, 0 disables the resync")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.SessionResyncRelayNumTolerance, lavasession.SessionResyncRelayNumToleranceFlag, lavasession.DefaultSessionResyncRelayNumTolerance, "on a session sync loss, resync to the provider's relay number once if it's at most this much ahead of ours")
+	cmdRPCConsumer.Flags().DurationVar(&lavasession.RelayKeepaliveInterval, lavasession.RelayKeepaliveIntervalFlag, lavasession.DefaultRelayKeepaliveInterval, "keepalive ping interval on idle provider connections, off (0) by default, keep it above the providers' minimal interval (30s by default) and only enable it once the providers accept the pings")
+	cmdRPCConsumer.Flags().DurationVar(&lavasession.RelayKeepaliveTimeout, lavasession.RelayKeepaliveTimeoutFlag, lavasession.DefaultRelayKeepaliveTimeout, "provider connections not answering a keepalive ping within this duration are closed")
+	cmdRPCConsumer.Flags().DurationVar(&lavasession.RelayMaxIdle, lavasession.RelayMaxIdleFlag, 0, "provider connections without relays for this long go idle and reconnect on the next relay, 0 keeps the grpc default")
+	cmdRPCConsumer.Flags().DurationVar(&provideroptimizer.QoSHalfLife, provideroptimizer.QoSHalfLifeFlag, provideroptimizer.QoSHalfLife, "half life of the decay applied to provider latency, availability and sync samples, recent behavior dominates provider selection")
+	cmdRPCConsumer.Flags().DurationVar(&provideroptimizer.QoSMaxHalfLife, provideroptimizer.QoSMaxHalfLifeFlag, provideroptimizer.QoSMaxHalfLife, "the qos half life grows with the age of a provider's relays up to this duration, lower it so penalized providers recover faster")
+	cmdRPCConsumer.Flags().BoolVar(&lavasession.RelayCompression, lavasession.RelayCompressionFlag, lavasession.RelayCompression, "compress relays with grpc compression, providers reply compressed and sign the uncompressed reply. providers that don't support it get uncompressed relays")
+	cmdRPCConsumer.Flags().BoolVar(&lavasession.MeasureTimeToFirstByte, lavasession.MeasureTimeToFirstByteFlag, lavasession.MeasureTimeToFirstByte, "measure when provider replies start arriving apart from the total relay latency, tracked per provider in the optimizer and the metrics")
+	cmdRPCConsumer.Flags().BoolVar(&provideroptimizer.MethodQoSTracking, provideroptimizer.MethodQoSTrackingFlag, false, "track provider qos per method and choose providers by their qos for the relayed method, uses more memory")
+	cmdRPCConsumer.Flags().StringVar(&ExpectedConsumerAddress, ExpectedConsumerAddressFlag, ExpectedConsumerAddress, "refuse to start unless the consumer key signs as this account, e.g. the account holding the subscription")
+	cmdRPCConsumer.Flags().BoolVar(&RequireReadiness, RequireReadinessFlag, RequireReadiness, "refuse relays and report unhealthy until the pairing is loaded and a provider answered a relay, so traffic can be held back while the consumer starts")
+	cmdRPCConsumer.Flags().Uint64Var(&lavasession.MinUsableProviders, lavasession.MinUsableProvidersFlag, 0, "refuse relays and report unhealthy while fewer providers than this are usable, 0 disables the check")
+	cmdRPCConsumer.Flags().Int64Var(&lavasession.SyncScoreMaxBlockLag, lavasession.SyncScoreMaxBlockLagFlag, lavasession.DefaultSyncScoreMaxBlockLag, "blocks behind the expected height at which a relay's sync score reaches 0, the score drops proportionally up to it")
+	cmdRPCConsumer.Flags().BoolVar(&LatestBlockFallback, LatestBlockFallbackFlag, false, "when a provider reply is missing its latest block, track the provider's height with the trusted fallback node's latest block, such replies never count for the provider's sync")
+	cmdRPCConsumer.Flags().BoolVar(&lavasession.ValidateProviderCapabilities, lavasession.ValidateProviderCapabilitiesFlag, false, "fail relays no paired provider advertises support for, before consuming a session")
+	cmdRPCConsumer.Flags().DurationVar(&MaxRelayTimeout, MaxRelayTimeoutFlag, DefaultMaxRelayTimeout, "ceiling on a relay's timeout, including timeouts requested with the lava-relay-timeout header, a shorter client deadline is always honored. 0 disables the ceiling")
+	cmdRPCConsumer.Flags().DurationVar(&DataReliabilityTimeout, DataReliabilityTimeoutFlag, 0, "timeout for data reliability relays, they run after the reply is returned so a slow provider only fails its own session, 0 uses the original relay's timeout")
+	cmdRPCConsumer.Flags().Float64Var(&DataReliabilityBudgetShare, DataReliabilityBudgetShareFlag, DataReliabilityBudgetShare, "share of a relay's deadline reserved for its data reliability relay, 0 reserves nothing and reliability gets what the primary relay left, reliability is skipped when a reserve would be under 200ms")
+	cmdRPCConsumer.Flags().BoolVar(&AvoidColocatedReliabilityProviders, AvoidColocatedReliabilityProvidersFlag, AvoidColocatedReliabilityProviders, "don't send data reliability relays to providers sharing a host with the original provider, they are likely the same node")
+	cmdRPCConsumer.Flags().Var(&ReliabilityProviderPolicy, ReliabilityProviderPolicyFlag, fmt.Sprintf("provider data reliability relays are sent to: %s is the optimizer's pick, %s a random other provider, %s the providers sharing a host with the fewest others and never one colocated with the original", ReliabilityProviderBestQoS, ReliabilityProviderRandom, ReliabilityProviderIndependent))
+	cmdRPCConsumer.Flags().DurationVar(&ReliabilityCooldown, ReliabilityCooldownFlag, ReliabilityCooldown, "minimum time before a provider that was sent a data reliability relay is picked for another one, spreading reliability load across the pairing, unless no other provider is left. 0 disables it")
+	cmdRPCConsumer.Flags().Var(&NullResultStrategy, NullResultStrategyFlag, fmt.Sprintf("how null results are handled: %s returns them, %s only returns a null from a provider that has the requested block after another provider confirms it, a provider contradicted by another one is penalized", NullResultAccept, NullResultCrossCheck))
+	cmdRPCConsumer.Flags().StringSliceVar(&NullResultMethods, NullResultMethodsFlag, NullResultMethods, "methods whose null results are cross checked, they should return data for every block the provider has")
+	cmdRPCConsumer.Flags().BoolVar(&AllowSimulatedRelays, AllowSimulatedRelaysFlag, false, "accept the lava-simulate-relay header for canary and monitoring traffic, simulated relays are not settled and only providers running with simulated relays enabled honor them")
+	cmdRPCConsumer.Flags().Var(&lavasession.ProviderCuMultipliers, lavasession.ProviderCuMultipliersFlag, "provider=multiplier pairs, relays to the provider are charged against its local compute units budget multiplied, so costlier providers are used less, multipliers must be at least 1. settlement keeps the spec's compute units")
+	cmdRPCConsumer.Flags().Var(&lavasession.ProviderRegionPreference, lavasession.ProviderRegionPreferenceFlag, "prefer providers by their endpoints' geolocation relative to the consumer's: same (in the consumer's geolocation) or nearest, falls back to all providers when no preferred one is available")
+	cmdRPCConsumer.Flags().DurationVar(&chainlib.JsonRPCGetCacheMaxAge, chainlib.JsonRPCGetCacheMaxAgeFlag, chainlib.JsonRPCGetCacheMaxAge, "max-age of the cache-control header on jsonrpc GET replies for finalized blocks, other GET replies are marked no-store, 0 marks all of them no-store")
+	cmdRPCConsumer.Flags().BoolVar(&RelayFreshnessHeaders, RelayFreshnessHeadersFlag, false, "add the block the reply was observed at and the provider's latest block to the response headers")
+	cmdRPCConsumer.Flags().BoolVar(&RelaySLAHeaders, RelaySLAHeadersFlag, false, "add the relay latency and how the reply was verified (signature, commitment or none) to the response headers, next to the provider address")
+	cmdRPCConsumer.Flags().StringVar(&RecordRelaysPath, RecordRelaysFlag, "", "debugging, append every relay's request, reply and verification outcome to this file")
+	cmdRPCConsumer.Flags().StringVar(&DeadLettersPath, DeadLettersPathFlag, "", "append relays that failed on every provider to this file, with the providers tried and the final error, for analysis or replaying them")
+	cmdRPCConsumer.Flags().Int64Var(&lavaprotocol.MaxDecompressedReplySize, lavaprotocol.MaxDecompressedReplySizeFlag, lavaprotocol.MaxDecompressedReplySize, "compressed provider replies decompressing beyond this many bytes are rejected and the provider is blocked")
+	cmdRPCConsumer.Flags().BoolVar(&lavaprotocol.RequestReplyCommitments, lavaprotocol.RequestReplyCommitmentsFlag, lavaprotocol.RequestReplyCommitments, "ask providers to sign a commitment to the reply data, cheaper to verify for big replies. replies data reliability may compare are still signed in full")
+	cmdRPCConsumer.Flags().StringSliceVar(&lavaprotocol.ReplyCommitmentMethods, lavaprotocol.ReplyCommitmentMethodsFlag, lavaprotocol.ReplyCommitmentMethods, "methods whose replies are always signed with a commitment, e.g. eth_getBlockByNumber for full transaction blocks. cheaper to verify but skipped by data reliability")
+	cmdRPCConsumer.Flags().StringVar(&ChainRouterListen, ChainRouterListenFlag, "", "serve every chain on this address (such as 127.0.0.1:3360) as well, requests select their chain with the lava-chain-id header or a /<chainID> path prefix, disabled when empty")
+	cmdRPCConsumer.Flags().StringVar(&ChainRouterApiInterface, ChainRouterApiInterfaceFlag, ChainRouterApiInterface, "the api interface served by the chain router, jsonrpc, tendermintrpc or rest")
+	cmdRPCConsumer.Flags().StringVar(&common.OutboundProxy, common.OutboundProxyFlag, common.OutboundProxy, "socks5:// or http:// proxy relay connections to the providers go through, when empty HTTPS_PROXY, HTTP_PROXY and NO_PROXY are used")
 	cmdRPCConsumer.Flags().Bool(common.DisableConflictTransactionsFlag, false, "disabling conflict transactions, this flag should not be used as it harms the network's data reliability and therefore the service.")
 	cmdRPCConsumer.Flags().DurationVar(&updaters.TimeOutForFetchingLavaBlocks, common.TimeOutForFetchingLavaBlocksFlag, time.Second*5, "setting the timeout for fetching lava blocks")
 
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	sdkerrors "cosmossdk.io/errors"
@@ -20,21 +23,57 @@ import (
 	"github.com/lavanet/lava/protocol/performance"
 	"github.com/lavanet/lava/utils"
 	"github.com/lavanet/lava/utils/protocopy"
-	"github.com/lavanet/lava/utils/rand"
+	"github.com/lavanet/lava/utils/sigs"
 	conflicttypes "github.com/lavanet/lava/x/conflict/types"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
 	plantypes "github.com/lavanet/lava/x/plans/types"
 	spectypes "github.com/lavanet/lava/x/spec/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 const (
 	MaxRelayRetries = 6
+	// first backoff on a transient reply verification failure, doubled for every consecutive session error
+	verificationFailureBaseBackoff = 250 * time.Millisecond
 )
 
 var NoResponseTimeout = sdkerrors.New("NoResponseTimeout Error", 685, "timeout occurred while waiting for providers responses")
 
+const (
+	RelaySLAHeadersFlag       = "relay-sla-headers"
+	RelayFreshnessHeadersFlag = "relay-freshness-headers"
+)
+
+// add the relay's latency and how the reply was verified to the reply headers, for portals exposing slas
+var RelaySLAHeaders = false
+
+// add the block the reply's data was observed at and the provider's latest block to the reply headers, so clients
+// polling latest data can tell whether re-requesting is worth it
+var RelayFreshnessHeaders = false
+
+const (
+	DataReliabilityTimeoutFlag = "data-reliability-timeout"
+	AllowSimulatedRelaysFlag   = "allow-simulated-relays"
+	MaxRelayTimeoutFlag        = "max-relay-timeout"
+	DefaultMaxRelayTimeout     = 2 * time.Minute
+)
+
+// ceiling on a single relay's timeout, so a client's timeout header can't tie up a session for longer, 0 disables it
+var MaxRelayTimeout = DefaultMaxRelayTimeout
+
+// timeout for the data reliability relay, 0 uses the relay timeout the original relay got
+var DataReliabilityTimeout time.Duration = 0
+
+// accept the simulate relay header. simulated relays are only served without charge by providers that run with
+// simulated relays enabled and acknowledge it in the signed reply, relays other providers serve are settled as usual
+var AllowSimulatedRelays = false
+
+var SimulatedRelaysNotAllowedError = sdkerrors.New("SimulatedRelaysNotAllowed Error", 697, "simulated relays are not enabled on this consumer")
+
 // implements Relay Sender interfaced and uses an ChainListener to get it called
 type RPCConsumerServer struct {
 	chainParser            chainlib.ChainParser
@@ -53,6 +92,32 @@ type RPCConsumerServer struct {
 	relaysMonitor          *metrics.RelaysMonitor
 	reporter               metrics.Reporter
 	debugRelays            bool
+	tracer                 trace.Tracer
+	replySchemas           map[string]*chainlib.ReplySchema     // api name -> schema, overrides the schema of the spec's api
+	specReplySchemas       sync.Map                             // spec schema -> *chainlib.ReplySchema, compiled on first use
+	replyValidators        map[string][]chainlib.ReplyValidator // api name -> validators run after the schema
+	methodFilter           *chainlib.MethodFilter               // nil when every spec method is allowed
+	reliabilityLevels      map[string]ReliabilityLevel          // api name -> level, apis without one are probabilistic
+	relayPriorities        map[string]lavasession.RelayPriority // dapp id -> priority, relays of other dapps keep the context's
+	requestedBlockHook     RequestedBlockResolutionHook
+	dataReliabilitySampler DataReliabilitySampler
+	relayRecorder          RelayRecordSink                                  // nil when relays aren't recorded
+	relayExporter          atomic.Pointer[bufferedSink[*RelayExportRecord]] // nil when relays aren't exported
+	deadLetters            atomic.Pointer[bufferedSink[*DeadLetter]]        // nil when failed relays aren't kept
+	addressCodec           lavaprotocol.AddressCodec                        // nil uses the sdk's global account prefix
+	trustedFallback        chainlib.ChainRouter                             // nil when no trusted fallback node is configured
+	latestBlockFallback    *latestBlockFallback                             // nil unless enabled with a trusted fallback node
+	requestTransforms      []chainlib.RequestTransform
+	replyTransforms        []chainlib.ReplyTransform
+	readiness              *readinessGate // nil until serving starts
+	reliabilityCooldowns   reliabilityCooldownTracker
+	finalizedTag           atomic.Pointer[finalizedTagResolution] // the last finalized tag reply's resolution, nil before one arrives
+}
+
+// finalizedTagResolution is the height a finalized tag reply was resolved to and when
+type finalizedTagResolution struct {
+	block      int64
+	resolvedAt time.Time
 }
 
 type relayResponse struct {
@@ -84,6 +149,9 @@ func (rpccs *RPCConsumerServer) ServeRPCRequests(ctx context.Context, listenEndp
 	refererData *chainlib.RefererData,
 	reporter metrics.Reporter,
 ) (err error) {
+	if err := verifySigningKey(privKey, consumerAddress); err != nil {
+		return utils.LavaFormatError("consumer signing key check failed", err, utils.Attribute{Key: "endpoint", Value: listenEndpoint})
+	}
 	rpccs.consumerSessionManager = consumerSessionManager
 	rpccs.listenEndpoint = listenEndpoint
 	rpccs.cache = cache
@@ -99,15 +167,47 @@ func (rpccs *RPCConsumerServer) ServeRPCRequests(ctx context.Context, listenEndp
 	rpccs.sharedState = sharedState
 	rpccs.reporter = reporter
 	rpccs.debugRelays = cmdFlags.DebugRelays
+	rpccs.replySchemas, err = chainlib.CompileReplySchemas(listenEndpoint.ReplySchemas)
+	if err != nil {
+		return utils.LavaFormatError("failed compiling reply schemas", err, utils.Attribute{Key: "endpoint", Value: listenEndpoint})
+	}
+	rpccs.replyValidators, err = chainlib.NewReplyValidators(listenEndpoint.ApiInterface, listenEndpoint.PaginatedApis)
+	if err != nil {
+		return utils.LavaFormatError("failed creating reply validators", err, utils.Attribute{Key: "endpoint", Value: listenEndpoint})
+	}
+	rpccs.methodFilter, err = chainlib.NewMethodFilter(listenEndpoint.AllowedMethods, listenEndpoint.DeniedMethods)
+	if err != nil {
+		return utils.LavaFormatError("failed creating method filter", err, utils.Attribute{Key: "endpoint", Value: listenEndpoint})
+	}
+	rpccs.reliabilityLevels, err = parseReliabilityLevels(listenEndpoint.ReliabilityMethods)
+	if err != nil {
+		return utils.LavaFormatError("failed parsing reliability levels", err, utils.Attribute{Key: "endpoint", Value: listenEndpoint})
+	}
+	rpccs.relayPriorities, err = parseRelayPriorities(listenEndpoint.RelayPriorities)
+	if err != nil {
+		return utils.LavaFormatError("failed parsing relay priorities", err, utils.Attribute{Key: "endpoint", Value: listenEndpoint})
+	}
+	rpccs.trustedFallback, err = newTrustedFallback(ctx, listenEndpoint, chainParser)
+	if err != nil {
+		return utils.LavaFormatError("failed connecting to the trusted fallback nodes", err, utils.Attribute{Key: "endpoint", Value: listenEndpoint})
+	}
+	if LatestBlockFallback {
+		if rpccs.trustedFallback == nil {
+			utils.LavaFormatWarning("latest block fallback requires a trusted fallback node, replies missing the latest block keep the provider's previous one", nil, utils.Attribute{Key: "endpoint", Value: listenEndpoint.Key()})
+		} else {
+			rpccs.latestBlockFallback = newLatestBlockFallback(ctx, listenEndpoint, chainParser, rpccs.trustedFallback)
+		}
+	}
 	chainListener, err := chainlib.NewChainListener(ctx, listenEndpoint, rpccs, rpccs, rpcConsumerLogs, chainParser, refererData)
 	if err != nil {
 		return err
 	}
 
+	rpccs.relaysMonitor = relaysMonitor
+	rpccs.startReadinessGate(ctx)
 	go chainListener.Serve(ctx, cmdFlags)
 
 	initialRelays := true
-	rpccs.relaysMonitor = relaysMonitor
 
 	// we trigger a latest block call to get some more information on our providers, using the relays monitor
 	if cmdFlags.RelaysHealthEnableFlag {
@@ -209,6 +309,7 @@ func (rpccs *RPCConsumerServer) sendRelayWithRetries(ctx context.Context, retrie
 			utils.LavaFormatInfo("[+] init relay succeeded", []utils.Attribute{{Key: "chainID", Value: rpccs.listenEndpoint.ChainID}, {Key: "APIInterface", Value: rpccs.listenEndpoint.ApiInterface}, {Key: "latestBlock", Value: relayResult.Reply.LatestBlock}, {Key: "provider address", Value: relayResult.ProviderInfo.ProviderAddress}}...)
 
 			rpccs.relaysMonitor.LogRelay()
+			rpccs.readiness.markReady()
 			success = true
 
 			// If this is the first time we send relays, we want to send all of them, instead of break on first successful relay
@@ -266,14 +367,94 @@ func (rpccs *RPCConsumerServer) SendRelay(
 	// compares the response with other consumer wallets if defined so
 	// asynchronously sends data reliability if necessary
 
+	ctx, span := rpccs.startSpan(ctx, "SendRelay", attribute.String("chainID", rpccs.listenEndpoint.ChainID), attribute.String("apiInterface", rpccs.listenEndpoint.ApiInterface))
+	defer func() { endSpan(span, errRet) }()
 	// remove lava directive headers
 	metadata, directiveHeaders := rpccs.LavaDirectiveHeaders(metadata)
 	relaySentTime := time.Now()
-	chainMessage, err := rpccs.chainParser.ParseMsg(url, []byte(req), connectionType, metadata, rpccs.getExtensionsFromDirectiveHeaders(directiveHeaders))
+	var chainMessage chainlib.ChainMessage
+	defer func() { rpccs.exportRelay(relaySentTime, chainMessage, dappID, relayResult, errRet) }()
+	// converted once, the parser, the transforms and the relay data all share these bytes
+	reqData := []byte(req)
+	chainMessage, err := rpccs.chainParser.ParseMsg(url, reqData, connectionType, metadata, rpccs.getExtensionsFromDirectiveHeaders(directiveHeaders))
 	if err != nil {
 		return nil, err
 	}
-	// temporarily disable subscriptions
+	chainMessage, reqData, err = rpccs.transformRequest(ctx, chainMessage, url, reqData, connectionType, metadata, directiveHeaders)
+	if err != nil {
+		return nil, utils.LavaFormatWarning("failed transforming request", err, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	return rpccs.sendParsedRelay(ctx, relaySentTime, chainMessage, url, reqData, connectionType, dappID, consumerIp, analytics, directiveHeaders)
+}
+
+// SendParsedRelay relays a message already parsed by the chain parser, for callers relaying the same request many
+// times. req is the raw request the message was parsed from, it's the data sent to the provider, and directiveHeaders
+// are the lava directives removed from its metadata before parsing. request transforms aren't applied, the caller
+// parses the request it wants sent. every send relays its own copy of the message, so a latest block request is
+// resolved again each time instead of reusing the block the previous send was pinned to
+func (rpccs *RPCConsumerServer) SendParsedRelay(
+	ctx context.Context,
+	chainMessage chainlib.ChainMessage,
+	url string,
+	req string,
+	connectionType string,
+	dappID string,
+	consumerIp string,
+	analytics *metrics.RelayMetrics,
+	directiveHeaders map[string]string,
+) (relayResult *common.RelayResult, errRet error) {
+	ctx, span := rpccs.startSpan(ctx, "SendParsedRelay", attribute.String("chainID", rpccs.listenEndpoint.ChainID), attribute.String("apiInterface", rpccs.listenEndpoint.ApiInterface))
+	defer func() { endSpan(span, errRet) }()
+	chainMessage = chainMessage.Clone()
+	relaySentTime := time.Now()
+	defer func() { rpccs.exportRelay(relaySentTime, chainMessage, dappID, relayResult, errRet) }()
+	return rpccs.sendParsedRelay(ctx, relaySentTime, chainMessage, url, []byte(req), connectionType, dappID, consumerIp, analytics, directiveHeaders)
+}
+
+func (rpccs *RPCConsumerServer) sendParsedRelay(
+	ctx context.Context,
+	relaySentTime time.Time,
+	chainMessage chainlib.ChainMessage,
+	url string,
+	req []byte,
+	connectionType string,
+	dappID string,
+	consumerIp string,
+	analytics *metrics.RelayMetrics,
+	directiveHeaders map[string]string,
+) (relayResult *common.RelayResult, errRet error) {
+	var err error
+	if err = chainlib.ValidateChainMessage(chainMessage); err != nil {
+		return nil, utils.LavaFormatError("spec configuration is invalid for relay", err, utils.LogAttr("GUID", ctx), utils.LogAttr("url", url), utils.LogAttr("connectionType", connectionType), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	if err = rpccs.methodFilter.CheckMessage(chainMessage); err != nil {
+		return nil, utils.LavaFormatWarning("rejected relay for a disabled method", err, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	if err = rpccs.validateReadiness(); err != nil {
+		return nil, utils.LavaFormatWarning("rejected relay, consumer isn't ready", err, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	// when the providers can't serve the relay it's sent straight to the trusted fallback, it's rejected without one
+	var providersErr error
+	if err = rpccs.validateUsableProviders(); err != nil {
+		if rpccs.trustedFallback == nil {
+			return nil, utils.LavaFormatWarning("rejected relay, not enough usable providers", err, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+		}
+		providersErr = err
+	} else if err = rpccs.validateProviderCapabilities(chainMessage); err != nil {
+		if rpccs.trustedFallback == nil {
+			return nil, utils.LavaFormatWarning("rejected relay no provider can serve", err, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+		}
+		providersErr = err
+	}
+	if chainlib.IsReadOnlyRelay(ctx) && !chainlib.IsReadOnly(chainMessage) {
+		return nil, utils.LavaFormatWarning("rejected read only relay", sdkerrors.Wrapf(chainlib.ReadOnlyRelayError, "api: %s", chainMessage.GetApi().Name), utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	_, simulated := directiveHeaders[common.SIMULATE_RELAY_HEADER_NAME]
+	if simulated && !AllowSimulatedRelays {
+		return nil, utils.LavaFormatWarning("rejected simulated relay", SimulatedRelaysNotAllowedError, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	// temporarily disable subscriptions, resuming them across restarts (persisting the signed relay and the client
+	// channel binding, with a reset marker for the downtime gap) has to come with re-enabling them
 	isSubscription := chainlib.IsSubscription(chainMessage)
 	if isSubscription {
 		return &common.RelayResult{ProviderInfo: common.ProviderInfo{ProviderAddress: ""}}, utils.LavaFormatError("Subscriptions are not supported at the moment", nil)
@@ -282,23 +463,68 @@ func (rpccs *RPCConsumerServer) SendRelay(
 	rpccs.HandleDirectiveHeadersForMessage(chainMessage, directiveHeaders)
 	// do this in a loop with retry attempts, configurable via a flag, limited by the number of providers in CSM
 	reqBlock, _ := chainMessage.RequestedBlock()
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("api", chainMessage.GetApi().Name), attribute.Int64("requestedBlock", reqBlock))
+	defer func() {
+		if relayResult != nil {
+			relayResult.BlockTag = reqBlock < 0 && reqBlock != spectypes.NOT_APPLICABLE
+		}
+	}()
 	seenBlock, _ := rpccs.consumerConsistency.GetSeenBlock(dappID, consumerIp)
 	if seenBlock < 0 {
 		seenBlock = 0
 	}
-	relayRequestData := lavaprotocol.NewRelayData(ctx, connectionType, url, []byte(req), seenBlock, reqBlock, rpccs.listenEndpoint.ApiInterface, chainMessage.GetRPCMessage().GetHeaders(), chainlib.GetAddon(chainMessage), common.GetExtensionNames(chainMessage.GetExtensions()))
+	relayData, err := chainlib.GetPayloadCodec(rpccs.listenEndpoint.ApiInterface).Encode(req)
+	if err != nil {
+		return nil, err
+	}
+	relayRequestData := lavaprotocol.NewRelayData(ctx, connectionType, url, relayData, seenBlock, reqBlock, rpccs.listenEndpoint.ApiInterface, chainMessage.GetRPCMessage().GetHeaders(), chainlib.GetAddon(chainMessage), common.GetExtensionNames(chainMessage.GetExtensions()))
+	if simulated {
+		// in the signed relay data, the spec's header filtering keeps it from the node
+		relayRequestData.Metadata = append(relayRequestData.Metadata, lavaprotocol.SimulatedRelayMetadata)
+	}
 	relayResults := []*common.RelayResult{}
 	relayErrors := &RelayErrors{onFailureMergeAll: true}
 	blockOnSyncLoss := map[string]struct{}{}
 	modifiedOnLatestReq := false
 	errorRelayResult := &common.RelayResult{} // returned on error
+	var heldNullResult *common.RelayResult    // a suspicious null reply waiting for another provider's reply
+	nullCrossChecked := false
 	retries := uint64(0)
 	timeouts := 0
+	if providersErr != nil {
+		relayErrors.relayErrors = append(relayErrors.relayErrors, RelayError{err: providersErr})
+	}
 	unwantedProviders := rpccs.GetInitialUnwantedProviders(directiveHeaders)
+	// requests with the same affinity key prefer the same provider, for warm provider side caches
+	ctx = lavasession.ContextWithAffinityKey(ctx, directiveHeaders[common.AFFINITY_KEY_HEADER_NAME])
+	ctx = lavasession.ContextWithRelayMethod(ctx, chainMessage.GetApi().Name)
+	// providers advertising a smaller max request size aren't chosen, a response size hint set by the caller is kept
+	_, responseSizeHint := lavasession.RelaySizesFromContext(ctx)
+	ctx = lavasession.ContextWithRelaySizes(ctx, uint64(len(relayData)), responseSizeHint)
+	if simulated {
+		ctx = lavasession.ContextWithSimulatedRelay(ctx)
+	}
+	if priority, ok := rpccs.relayPriority(ctx, dappID, directiveHeaders); ok {
+		ctx = lavasession.ContextWithRelayPriority(ctx, priority)
+	}
+	// under the concurrent relays limit lower priority relays wait behind higher priority ones, up to their deadline
+	releaseRelay, err := rpccs.consumerSessionManager.AdmitRelay(ctx)
+	if err != nil {
+		return errorRelayResult, utils.LavaFormatWarning("relay wasn't admitted", err, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	defer releaseRelay()
 
-	for ; retries < MaxRelayRetries; retries++ {
+	enabled, dataReliabilityThreshold := rpccs.chainParser.DataReliabilityParams()
+	// there's no reply to compare on a notification and sending it again repeats its side effects
+	isNotification := chainlib.IsNotification(chainMessage)
+	// part of the deadline is kept for the data reliability relay, so the primary relay's retries can't use all of it
+	budget := newRelayBudget(ctx, time.Now(), enabled && !isNotification && chainMessage.GetApi().Category.Deterministic)
+	primaryCtx, cancelPrimary := budget.primaryContext(ctx)
+	defer cancelPrimary()
+
+	for ; retries < MaxRelayRetries && providersErr == nil; retries++ {
 		// TODO: make this async between different providers
-		relayResult, err := rpccs.sendRelayToProvider(ctx, chainMessage, relayRequestData, dappID, consumerIp, &unwantedProviders, timeouts)
+		relayResult, err := rpccs.sendRelayToProvider(primaryCtx, chainMessage, relayRequestData, dappID, consumerIp, &unwantedProviders, timeouts)
 		if relayResult == nil {
 			utils.LavaFormatError("unexpected behavior relay result returned nil from sendRelayToProvider", nil)
 			continue
@@ -328,7 +554,7 @@ func (rpccs *RPCConsumerServer) SendRelay(
 				errorRelayResult.StatusCode = relayResult.GetStatusCode()
 			}
 			relayErrors.relayErrors = append(relayErrors.relayErrors, RelayError{err: err, ProviderInfo: relayResult.ProviderInfo})
-			if lavasession.PairingListEmptyError.Is(err) {
+			if lavasession.PairingListEmptyError.Is(err) || lavasession.NoCapableProviderError.Is(err) || lavasession.RelayExceedsProviderLimitsError.Is(err) {
 				// if we ran out of pairings because unwantedProviders is too long or validProviders is too short, continue to reply handling code
 				break
 			}
@@ -336,7 +562,6 @@ func (rpccs *RPCConsumerServer) SendRelay(
 			utils.LavaFormatDebug("could not send relay to provider", utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "error", Value: err.Error()}, utils.Attribute{Key: "endpoint", Value: rpccs.listenEndpoint})
 			continue
 		}
-		relayResults = append(relayResults, relayResult)
 		unwantedProviders[relayResult.ProviderInfo.ProviderAddress] = struct{}{}
 		// future relay requests and data reliability requests need to ask for the same specific block height to get consensus on the reply
 		// we do not modify the chain message data on the consumer, only it's requested block, so we let the provider know it can't put any block height it wants by setting a specific block height
@@ -347,34 +572,70 @@ func (rpccs *RPCConsumerServer) SendRelay(
 				relayResult.Finalized = false // shut down data reliability
 			}
 		}
+		if heldNullResult == nil && !nullCrossChecked && rpccs.isSuspiciousNullResult(chainMessage, relayResult) {
+			// ask another provider before returning the null, it's returned if none can answer
+			heldNullResult = relayResult
+			continue
+		}
+		if heldNullResult != nil {
+			rpccs.crossCheckNullResult(chainMessage, heldNullResult, relayResult)
+			heldNullResult = nil
+			nullCrossChecked = true
+		}
+		relayResults = append(relayResults, relayResult)
 		if len(relayResults) >= rpccs.requiredResponses {
 			break
 		}
 	}
 
-	enabled, dataReliabilityThreshold := rpccs.chainParser.DataReliabilityParams()
-	if enabled {
+	if heldNullResult != nil && len(relayResults) == 0 {
+		utils.LavaFormatDebug("no other provider could cross check a null result, returning it", utils.LogAttr("GUID", ctx), utils.LogAttr("provider", heldNullResult.ProviderInfo.ProviderAddress))
+		relayResults = append(relayResults, heldNullResult)
+	}
+
+	if enabled && !isNotification {
 		for _, relayResult := range relayResults {
 			// new context is needed for data reliability as some clients cancel the context they provide when the relay returns
 			// as data reliability happens in a go routine it will continue while the response returns.
 			guid, found := utils.GetUniqueIdentifier(ctx)
-			dataReliabilityContext := context.Background()
+			dataReliabilityContext := contextWithParentSpan(context.Background(), ctx)
 			if found {
 				dataReliabilityContext = utils.WithUniqueIdentifier(dataReliabilityContext, guid)
 			}
-			go rpccs.sendDataReliabilityRelayIfApplicable(dataReliabilityContext, dappID, consumerIp, relayResult, chainMessage, dataReliabilityThreshold, unwantedProviders) // runs asynchronously
+			if simulated {
+				dataReliabilityContext = lavasession.ContextWithSimulatedRelay(dataReliabilityContext)
+			}
+			dataReliabilityContext, cancel := budget.reliabilityContext(dataReliabilityContext)
+			go func(relayResult *common.RelayResult) {
+				defer cancel()
+				rpccs.sendDataReliabilityRelayIfApplicable(dataReliabilityContext, dappID, consumerIp, relayResult, chainMessage, dataReliabilityThreshold, unwantedProviders)
+			}(relayResult) // runs asynchronously
 		}
 	}
 
+	if len(relayResults) == 0 && rpccs.trustedFallback != nil {
+		// every provider failed or none is paired, on a fallback failure the providers' errors are returned
+		fallbackResult, err := rpccs.relayToTrustedFallback(ctx, chainMessage, relayErrors)
+		if err == nil {
+			fallbackResult = rpccs.transformReply(ctx, chainMessage, fallbackResult)
+			rpccs.appendHeadersToRelayResult(ctx, fallbackResult, retries)
+			rpccs.appendSLAHeadersToRelayResult(fallbackResult, time.Since(relaySentTime))
+			return fallbackResult, nil
+		}
+	}
 	if len(relayResults) == 0 {
 		rpccs.appendHeadersToRelayResult(ctx, errorRelayResult, retries)
+		rpccs.appendSLAHeadersToRelayResult(errorRelayResult, time.Since(relaySentTime))
 		// suggest the user to add the timeout flag
 		if uint64(timeouts) == retries && retries > 0 {
 			utils.LavaFormatDebug("all relays timeout", utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "errors", Value: relayErrors.relayErrors})
-			return errorRelayResult, utils.LavaFormatError("Failed all relay retries due to timeout consider adding 'lava-relay-timeout' header to extend the allowed timeout duration", nil, utils.Attribute{Key: "GUID", Value: ctx})
+			err = utils.LavaFormatError("Failed all relay retries due to timeout consider adding 'lava-relay-timeout' header to extend the allowed timeout duration", nil, utils.Attribute{Key: "GUID", Value: ctx})
+		} else {
+			bestRelayError := relayErrors.GetBestErrorMessageForUser()
+			err = utils.LavaFormatError("Failed all retries", nil, utils.Attribute{Key: "GUID", Value: ctx}, utils.LogAttr("error", bestRelayError.err), utils.LogAttr("chain_id", rpccs.listenEndpoint.ChainID))
 		}
-		bestRelayError := relayErrors.GetBestErrorMessageForUser()
-		return errorRelayResult, utils.LavaFormatError("Failed all retries", nil, utils.Attribute{Key: "GUID", Value: ctx}, utils.LogAttr("error", bestRelayError.err), utils.LogAttr("chain_id", rpccs.listenEndpoint.ChainID))
+		rpccs.sendDeadLetter(ctx, chainMessage, url, req, connectionType, dappID, relayErrors, err)
+		return errorRelayResult, err
 	} else if len(relayErrors.relayErrors) > 0 {
 		utils.LavaFormatDebug("relay succeeded but had some errors", utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "errors", Value: relayErrors})
 	}
@@ -392,13 +653,49 @@ func (rpccs *RPCConsumerServer) SendRelay(
 	if retries > 0 {
 		utils.LavaFormatDebug("relay succeeded after retries", utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "retries", Value: retries})
 	}
+	returnedResult = rpccs.transformReply(ctx, chainMessage, returnedResult)
 	rpccs.appendHeadersToRelayResult(ctx, returnedResult, retries)
+	// data reliability runs after the reply is returned, it isn't part of the verification header
+	rpccs.appendSLAHeadersToRelayResult(returnedResult, time.Since(relaySentTime))
+	rpccs.appendFreshnessHeadersToRelayResult(returnedResult)
+	if isNotification {
+		returnedResult.StatusCode = http.StatusNoContent
+	}
 
 	rpccs.relaysMonitor.LogRelay()
 
 	return returnedResult, nil
 }
 
+// UsableProvidersCount returns the number of providers relays can currently be sent to
+func (rpccs *RPCConsumerServer) UsableProvidersCount() int {
+	return rpccs.consumerSessionManager.UsableProvidersCount()
+}
+
+func (rpccs *RPCConsumerServer) validateUsableProviders() error {
+	if lavasession.MinUsableProviders == 0 {
+		return nil
+	}
+	usableProviders := rpccs.UsableProvidersCount()
+	if uint64(usableProviders) >= lavasession.MinUsableProviders {
+		return nil
+	}
+	return sdkerrors.Wrapf(lavasession.ErrInsufficientProviders, "usable providers: %d, required: %d", usableProviders, lavasession.MinUsableProviders)
+}
+
+func (rpccs *RPCConsumerServer) validateProviderCapabilities(chainMessage chainlib.ChainMessage) error {
+	if !lavasession.ValidateProviderCapabilities {
+		return nil
+	}
+	apis := chainlib.ApiNames(chainMessage)
+	addon := chainlib.GetAddon(chainMessage)
+	extensions := common.GetExtensionNames(chainMessage.GetExtensions())
+	if rpccs.consumerSessionManager.HasCapableProvider(apis, addon, extensions) {
+		return nil
+	}
+	return sdkerrors.Wrapf(lavasession.NoCapableProviderError, "apis: %v, addon: %s, extensions: %v", apis, addon, extensions)
+}
+
 func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	ctx context.Context,
 	chainMessage chainlib.ChainMessage,
@@ -441,7 +738,9 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 
 	// try using cache before sending relay
 	var cacheError error
-	if reqBlock != spectypes.NOT_APPLICABLE || !chainMessage.GetForceCacheRefresh() {
+	// notifications have side effects and no reply, the cache key doesn't include the id so they would collide with requests
+	cacheable := !chainlib.IsNotification(chainMessage)
+	if cacheable && (reqBlock != spectypes.NOT_APPLICABLE || !chainMessage.GetForceCacheRefresh()) {
 		var cacheReply *pairingtypes.CacheRelayReply
 		hashKey, outputFormatter, err := chainlib.HashCacheRequest(relayRequestData, chainID)
 		if err != nil {
@@ -450,7 +749,7 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 			cacheCtx, cancel := context.WithTimeout(ctx, common.CacheTimeout)
 			cacheReply, cacheError = rpccs.cache.GetEntry(cacheCtx, &pairingtypes.RelayCacheGet{
 				RequestHash:    hashKey,
-				RequestedBlock: relayRequestData.RequestBlock,
+				RequestedBlock: rpccs.cacheRequestedBlock(relayRequestData.RequestBlock),
 				ChainId:        chainID,
 				BlockHash:      nil,
 				Finalized:      false,
@@ -476,6 +775,10 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 				// Info was fetched from cache, so we don't need to change the state
 				// so we can return here, no need to update anything and calculate as this info was fetched from the cache
 				reply.Data = outputFormatter(reply.Data)
+				if reply.LatestBlock > 0 {
+					// resolve block tags against the cached reply like a relayed reply is, so the freshness headers report its block
+					lavaprotocol.UpdateRequestedBlock(relayRequestData, reply)
+				}
 				relayResult = &common.RelayResult{
 					Reply: reply,
 					Request: &pairingtypes.RelayRequest{
@@ -504,7 +807,9 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	addon := chainlib.GetAddon(chainMessage)
 	extensions := chainMessage.GetExtensions()
 
-	sessions, err := rpccs.consumerSessionManager.GetSessions(ctx, chainlib.GetComputeUnits(chainMessage), *unwantedProviders, reqBlock, addon, extensions, chainlib.GetStateful(chainMessage), virtualEpoch)
+	sessionsCtx, sessionsSpan := rpccs.startSpan(ctx, "GetSessions", attribute.Int64("requestedBlock", reqBlock))
+	sessions, err := rpccs.consumerSessionManager.GetSessions(sessionsCtx, chainlib.GetComputeUnits(chainMessage), *unwantedProviders, reqBlock, addon, extensions, chainlib.GetStateful(chainMessage), virtualEpoch)
+	endSpan(sessionsSpan, err)
 	if err != nil {
 		if lavasession.PairingListEmptyError.Is(err) && (addon != "" || len(extensions) > 0) {
 			// if we have no providers for a specific addon or extension, return an indicative error
@@ -516,7 +821,9 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	// Make a channel for all providers to send responses
 	responses := make(chan *relayResponse, len(sessions))
 
-	relayTimeout := chainlib.GetRelayTimeout(chainMessage, rpccs.chainParser, timeouts)
+	relayTimeout := capRelayTimeout(ctx, chainlib.GetRelayTimeout(chainMessage, rpccs.chainParser, timeouts))
+	// every session relays the same data, only the session part of the request is signed per provider
+	contentHash := lavaprotocol.RelayContentHash(relayRequestData)
 	// Iterate over the sessions map
 	for providerPublicAddress, sessionInfo := range sessions {
 		// Launch a separate goroutine for each session
@@ -531,7 +838,7 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 				ConflictHandler: sessionInfo.Session.Parent,
 			}
 			var errResponse error
-			goroutineCtx, goroutineCtxCancel := context.WithCancel(context.Background())
+			goroutineCtx, goroutineCtxCancel := context.WithCancel(contextWithParentSpan(context.Background(), ctx))
 			guid, found := utils.GetUniqueIdentifier(ctx)
 			if found {
 				goroutineCtx = utils.WithUniqueIdentifier(goroutineCtx, guid)
@@ -550,10 +857,11 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 
 			// Extract fields from the sessionInfo
 			singleConsumerSession := sessionInfo.Session
+			generation := sessionInfo.Generation
 			epoch := sessionInfo.Epoch
 			reportedProviders := sessionInfo.ReportedProviders
 
-			relayRequest, errResponse := lavaprotocol.ConstructRelayRequest(goroutineCtx, privKey, lavaChainID, chainID, &localRelayRequestData, providerPublicAddress, singleConsumerSession, int64(epoch), reportedProviders)
+			relayRequest, errResponse := lavaprotocol.ConstructRelayRequestWithContentHash(goroutineCtx, privKey, lavaChainID, chainID, &localRelayRequestData, contentHash, providerPublicAddress, singleConsumerSession, int64(epoch), reportedProviders)
 			if errResponse != nil {
 				utils.LavaFormatError("Failed ConstructRelayRequest", errResponse, utils.LogAttr("Request data", localRelayRequestData))
 				return
@@ -562,7 +870,7 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 			endpointClient := *singleConsumerSession.Endpoint.Client
 
 			if isSubscription {
-				errResponse = rpccs.relaySubscriptionInner(goroutineCtx, endpointClient, singleConsumerSession, localRelayResult)
+				errResponse = rpccs.relaySubscriptionInner(goroutineCtx, endpointClient, singleConsumerSession, generation, localRelayResult)
 				if errResponse != nil {
 					utils.LavaFormatError("Failed relaySubscriptionInner", errResponse, utils.LogAttr("Request data", localRelayRequestData))
 					return
@@ -572,15 +880,27 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 			// unique per dappId and ip
 			consumerToken := common.GetUniqueToken(dappID, consumerIp)
 			relayLatency, errResponse, backoff := rpccs.relayInner(goroutineCtx, singleConsumerSession, localRelayResult, relayTimeout, chainMessage, consumerToken)
+			if ABCIProofUnverifiedError.Is(errResponse) {
+				// the provider served the relay, only the proof couldn't be checked
+				errReport := rpccs.consumerSessionManager.OnSessionDoneIncreaseCUOnly(singleConsumerSession, generation)
+				if errReport != nil {
+					utils.LavaFormatError("failed relay OnSessionDoneIncreaseCUOnly errored", errReport, utils.Attribute{Key: "GUID", Value: goroutineCtx})
+				}
+				return
+			}
 			if errResponse != nil {
 				failRelaySession := func(origErr error, backoff_ bool) {
 					backOffDuration := 0 * time.Second
 					if backoff_ {
 						backOffDuration = lavasession.BACKOFF_TIME_ON_FAILURE
+						if lavaprotocol.RelayReplySignatureRecoveryError.Is(origErr) {
+							// the session is blocked once it passes the consecutive errors limit, that's where we give up on it
+							backOffDuration = verificationFailureBackoff(len(singleConsumerSession.ConsecutiveErrors))
+						}
 					}
 					time.Sleep(backOffDuration) // sleep before releasing this singleConsumerSession
 					// relay failed need to fail the session advancement
-					errReport := rpccs.consumerSessionManager.OnSessionFailure(singleConsumerSession, origErr)
+					errReport := rpccs.consumerSessionManager.OnSessionFailure(singleConsumerSession, generation, origErr)
 					if errReport != nil {
 						utils.LavaFormatError("failed relay onSessionFailure errored", errReport, utils.Attribute{Key: "GUID", Value: goroutineCtx}, utils.Attribute{Key: "original error", Value: origErr.Error()})
 					}
@@ -593,7 +913,11 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 			expectedBH, numOfProviders := rpccs.finalizationConsensus.ExpectedBlockHeight(rpccs.chainParser)
 			pairingAddressesLen := rpccs.consumerSessionManager.GetAtomicPairingAddressesLength()
 			latestBlock := localRelayResult.Reply.LatestBlock
-			if expectedBH-latestBlock > 1000 {
+			if latestBlock == 0 && rpccs.latestBlockFallback != nil {
+				// the provider didn't report its height, only used for tracking, it doesn't count for the provider's sync
+				singleConsumerSession.SetFallbackLatestBlock(rpccs.latestBlockFallback.LatestBlock(goroutineCtx))
+			}
+			if latestBlock > 0 && expectedBH-latestBlock > 1000 {
 				utils.LavaFormatWarning("identified block gap", nil,
 					utils.Attribute{Key: "expectedBH", Value: expectedBH},
 					utils.Attribute{Key: "latestServicedBlock", Value: latestBlock},
@@ -616,12 +940,16 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 					utils.Attribute{Key: "finalizationConsensus", Value: rpccs.finalizationConsensus.String()},
 				)
 			}
-			errResponse = rpccs.consumerSessionManager.OnSessionDone(singleConsumerSession, latestBlock, chainlib.GetComputeUnits(chainMessage), relayLatency, singleConsumerSession.CalculateExpectedLatency(relayTimeout), expectedBH, numOfProviders, pairingAddressesLen, chainMessage.GetApi().Category.HangingApi) // session done successfully
+			errResponse = rpccs.consumerSessionManager.OnSessionDone(singleConsumerSession, generation, latestBlock, chainlib.GetComputeUnits(chainMessage), relayLatency, singleConsumerSession.CalculateExpectedLatency(relayTimeout), expectedBH, numOfProviders, pairingAddressesLen, chainMessage.GetApi().Category.HangingApi) // session done successfully
 
-			if rpccs.cache.CacheActive() {
+			if rpccs.cache.CacheActive() && cacheable {
 				// copy reply data so if it changes it doesn't panic mid async send
 				copyReply := &pairingtypes.RelayReply{}
 				copyReplyErr := protocopy.DeepCopyProtoObject(localRelayResult.Reply, copyReply)
+				if copyReplyErr == nil {
+					// the cache keeps the shape returned to users
+					copyReply.Data = rpccs.transformReplyData(ctx, chainMessage, copyReply.Data)
+				}
 				// set cache in a non blocking call
 
 				requestedBlock := localRelayResult.Request.RelayData.RequestBlock                             // get requested block before removing it from the data
@@ -758,19 +1086,68 @@ func (rpccs *RPCConsumerServer) getBestResult(timeout time.Duration, responses c
 	}
 }
 
+// resyncSessionWithProvider applies the provider's view of a session that lost sync and re-signs the relay request with it
+func (rpccs *RPCConsumerServer) resyncSessionWithProvider(singleConsumerSession *lavasession.SingleConsumerSession, relayRequest *pairingtypes.RelayRequest, trailer metadata.MD) error {
+	providerCuSum, providerRelayNum, ok := lavasession.ParseSessionSyncTrailer(trailer)
+	if !ok {
+		return sdkerrors.Wrapf(lavasession.SessionResyncRejectedError, "provider did not report its session state")
+	}
+	err := rpccs.consumerSessionManager.ResyncSession(singleConsumerSession, providerCuSum, providerRelayNum)
+	if err != nil {
+		return err
+	}
+	relayRequest.RelaySession.CuSum = singleConsumerSession.CuSum + singleConsumerSession.LatestRelayCu
+	relayRequest.RelaySession.RelayNum = singleConsumerSession.RelayNum
+	sig, err := sigs.Sign(rpccs.privKey, *relayRequest.RelaySession)
+	if err != nil {
+		return err
+	}
+	relayRequest.RelaySession.Sig = sig
+	return nil
+}
+
 func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSession *lavasession.SingleConsumerSession, relayResult *common.RelayResult, relayTimeout time.Duration, chainMessage chainlib.ChainMessage, consumerToken string) (relayLatency time.Duration, err error, needsBackoff bool) {
 	existingSessionLatestBlock := singleConsumerSession.LatestBlock // we read it now because singleConsumerSession is locked, and later it's not
 	endpointClient := *singleConsumerSession.Endpoint.Client
 	providerPublicAddress := relayResult.ProviderInfo.ProviderAddress
 	relayRequest := relayResult.Request
+	requestCommitment := requestReplyCommitment(chainMessage)
+	replyCommitment := ""
+	replyEncoding := ""
+	var errorTrailer metadata.MD
+	var timeToFirstByte time.Duration
 	callRelay := func() (reply *pairingtypes.RelayReply, relayLatency time.Duration, err error, backoff bool) {
+		relayCtx, relaySpan := rpccs.startSpan(ctx, "Relay",
+			attribute.String("provider", providerPublicAddress),
+			attribute.Int64("sessionID", int64(singleConsumerSession.SessionId)),
+			attribute.Int64("requestedBlock", relayRequest.RelayData.RequestBlock),
+		)
+		defer func() { endSpan(relaySpan, err) }()
 		relaySentTime := time.Now()
-		connectCtx, connectCtxCancel := context.WithTimeout(ctx, relayTimeout)
-		metadataAdd := metadata.New(map[string]string{common.IP_FORWARDING_HEADER_NAME: consumerToken})
+		connectCtx, connectCtxCancel := context.WithTimeout(relayCtx, relayTimeout)
+		metadataAdd := metadata.New(map[string]string{
+			common.IP_FORWARDING_HEADER_NAME: consumerToken,
+			common.SpecVersionMetadataKey:    strconv.FormatUint(rpccs.chainParser.SpecVersion(), 10),
+		})
+		if requestCommitment {
+			metadataAdd.Set(common.ReplyCommitmentMetadataKey, lavaprotocol.ReplyCommitmentSha256)
+		}
 		connectCtx = metadata.NewOutgoingContext(connectCtx, metadataAdd)
 		defer connectCtxCancel()
+		var firstByteLatency atomic.Int64
+		if lavasession.MeasureTimeToFirstByte {
+			connectCtx = lavasession.ContextWithFirstByteHook(connectCtx, func(arrival time.Time) {
+				firstByteLatency.CompareAndSwap(0, int64(arrival.Sub(relaySentTime)))
+			})
+		}
 		var trailer metadata.MD
-		reply, err = endpointClient.Relay(connectCtx, relayRequest, grpc.Trailer(&trailer))
+		var remotePeer peer.Peer
+		callOptions := append(singleConsumerSession.Endpoint.RelayCallOptions(), grpc.Trailer(&trailer), grpc.Peer(&remotePeer))
+		reply, err = endpointClient.Relay(connectCtx, relayRequest, callOptions...)
+		// behind a proxy the remote address is the proxy's, every provider would look like it shares its host
+		if remotePeer.Addr != nil && singleConsumerSession.Parent != nil && !common.OutboundProxied(common.OutboundProxy, singleConsumerSession.Endpoint.NetworkAddress) {
+			singleConsumerSession.Parent.RecordRemoteHost(remotePeer.Addr.String())
+		}
 		statuses := trailer.Get(common.StatusCodeMetadataKey)
 		if len(statuses) > 0 {
 			codeNum, errStatus := strconv.Atoi(statuses[0])
@@ -780,6 +1157,16 @@ func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSe
 			relayResult.StatusCode = codeNum
 		}
 		relayLatency = time.Since(relaySentTime)
+		timeToFirstByte = time.Duration(firstByteLatency.Load())
+		if err == nil {
+			if encodings := trailer.Get(common.ReplyEncodingMetadataKey); len(encodings) > 0 {
+				replyEncoding = encodings[0]
+			}
+			// providers that don't support commitments sign in full and don't set it
+			if commitments := trailer.Get(common.ReplyCommitmentMetadataKey); requestCommitment && len(commitments) > 0 {
+				replyCommitment = commitments[0]
+			}
+		}
 		if rpccs.debugRelays {
 			utils.LavaFormatDebug("sending relay to provider",
 				utils.LogAttr("GUID", ctx),
@@ -794,11 +1181,13 @@ func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSe
 				utils.LogAttr("relayNum", relayRequest.RelaySession.RelayNum),
 				utils.LogAttr("sessionId", relayRequest.RelaySession.SessionId),
 				utils.LogAttr("latency", relayLatency),
+				utils.LogAttr("timeToFirstByte", timeToFirstByte),
 				utils.LogAttr("replyErred", err != nil),
 				utils.LogAttr("replyLatestBlock", reply.GetLatestBlock()),
 			)
 		}
 		if err != nil {
+			errorTrailer = trailer
 			backoff := false
 			if errors.Is(connectCtx.Err(), context.DeadlineExceeded) {
 				backoff = true
@@ -808,18 +1197,66 @@ func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSe
 		return reply, relayLatency, nil, false
 	}
 	reply, relayLatency, err, backoff := callRelay()
+	if err != nil && singleConsumerSession.Endpoint.FallbackFromCompression(err) {
+		// the provider rejected the compressed relay before handling it
+		utils.LavaFormatDebug("provider doesn't support compressed relays, sending uncompressed", utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerPublicAddress))
+		reply, relayLatency, err, backoff = callRelay()
+	}
+	if err != nil && lavasession.IsSessionSyncLoss(err) {
+		resyncErr := rpccs.resyncSessionWithProvider(singleConsumerSession, relayRequest, errorTrailer)
+		if resyncErr == nil {
+			// retry once with the provider's view of the session, failing again blocks the session as usual
+			reply, relayLatency, err, backoff = callRelay()
+		} else {
+			utils.LavaFormatDebug("session sync loss was not resynced", utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerPublicAddress), utils.LogAttr("error", resyncErr))
+		}
+	}
 	if err != nil {
+		if lavasession.IsProviderCuExhausted(err) {
+			// retrying won't help, the session manager skips this provider for the rest of the epoch
+			return 0, sdkerrors.Wrapf(lavasession.ErrProviderCuExhausted, "provider %s: %s", providerPublicAddress, err.Error()), false
+		}
 		return 0, err, backoff
 	}
 	relayResult.Reply = reply
+	singleConsumerSession.SetTimeToFirstByte(timeToFirstByte)
+	requestedBlockBeforeResolution := relayRequest.RelayData.RequestBlock
 	lavaprotocol.UpdateRequestedBlock(relayRequest.RelayData, reply) // update relay request requestedBlock to the provided one in case it was arbitrary
 	_, _, blockDistanceForFinalizedData, _ := rpccs.chainParser.ChainBlockStats()
 	finalized := spectypes.IsFinalizedBlock(relayRequest.RelayData.RequestBlock, reply.LatestBlock, blockDistanceForFinalizedData)
-	filteredHeaders, _, ignoredHeaders := rpccs.chainParser.HandleHeaders(reply.Metadata, chainMessage.GetApiCollection(), spectypes.Header_pass_reply)
+	simulatedRelayAcknowledged, replyMetadata := lavaprotocol.TakeSimulatedRelayAck(reply.Metadata)
+	filteredHeaders, _, ignoredHeaders := rpccs.chainParser.HandleHeaders(replyMetadata, chainMessage.GetApiCollection(), spectypes.Header_pass_reply)
+	if simulatedRelayAcknowledged {
+		// signed after the provider's filtered headers
+		filteredHeaders = append(filteredHeaders, lavaprotocol.SimulatedRelayMetadata)
+	}
 	reply.Metadata = filteredHeaders
-	err = lavaprotocol.VerifyRelayReply(ctx, reply, relayRequest, providerPublicAddress)
+	_, verifySpan := rpccs.startSpan(ctx, "VerifyRelayReply", attribute.String("provider", providerPublicAddress))
+	err = lavaprotocol.VerifyRelayReplyCommitment(ctx, reply, relayRequest, providerPublicAddress, rpccs.getAddressCodec(), replyCommitment)
+	endSpan(verifySpan, err)
+	rpccs.recordRelay(ctx, providerPublicAddress, relayRequest, reply, relayLatency, err)
 	if err != nil {
-		return 0, err, false
+		if lavaprotocol.RelayReplySignatureRecoveryError.Is(err) {
+			// likely transient, back off this session and let the relay retry on another provider
+			return 0, err, true
+		}
+		// the reply was validly signed by someone else, there is no point in retrying this provider
+		return 0, sdkerrors.Wrapf(lavasession.BlockProviderError, "relay reply verification failed: %s", err.Error()), false
+	}
+	relayResult.Verification = common.REPLY_VERIFIED_SIGNATURE
+	if replyCommitment != "" {
+		// the signature covers the provider's commitment to the data, checked against the data it sent
+		relayResult.Verification = common.REPLY_VERIFIED_COMMITMENT
+	}
+	// the reply is still valid and the session already accounts for it. a provider on an older spec may compute units
+	// and requested blocks differently, its qos is penalized so up to date providers are preferred
+	if lavaprotocol.ProviderSpecOutdatedError.Is(lavaprotocol.VerifySpecVersion(ctx, rpccs.chainParser.SpecVersion(), reply.SpecVersion, providerPublicAddress)) {
+		rpccs.consumerSessionManager.OnReplyValidationFailure(providerPublicAddress)
+	}
+	if singleConsumerSession.IsSimulated() && !simulatedRelayAcknowledged {
+		// the provider didn't serve it without charge, it settles the relay so the session has to as well
+		utils.LavaFormatDebug("provider settled a simulated relay", utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerPublicAddress))
+		singleConsumerSession.SettleSimulatedRelay()
 	}
 	reply.Metadata = append(reply.Metadata, ignoredHeaders...)
 	// TODO: response data sanity, check its under an expected format add that format to spec
@@ -834,22 +1271,70 @@ func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSe
 			return 0, err, false
 		}
 
-		finalizationConflict, err = rpccs.finalizationConsensus.UpdateFinalizedHashes(int64(blockDistanceForFinalizedData), providerPublicAddress, finalizedBlocks, relayRequest.RelaySession, reply)
+		err = rpccs.updateFinalizationConsensus(ctx, providerPublicAddress, finalizedBlocks, relayRequest.RelaySession, reply, int64(blockDistanceForFinalizedData), singleConsumerSession.Parent)
 		if err != nil {
-			go rpccs.consumerTxSender.TxConflictDetection(ctx, finalizationConflict, nil, nil, singleConsumerSession.Parent)
 			return 0, err, false
 		}
 	}
+	// the signed and finalization checked data stays compressed until here, it's decoded with a limited reader
+	replyData, err := common.DecompressReplyData(replyEncoding, reply.Data, lavaprotocol.MaxDecompressedReplySize)
+	if err != nil {
+		utils.LavaFormatWarning("failed decompressing provider reply", err, utils.LogAttr("GUID", ctx), utils.LogAttr("provider", providerPublicAddress), utils.LogAttr("encoding", replyEncoding))
+		// a reply that inflates past the limit or doesn't decode is the provider's doing, it isn't retried on it
+		return 0, sdkerrors.Wrapf(lavasession.BlockProviderError, "reply decompression failed: %s", err.Error()), false
+	}
+	reply.Data = replyData
+	if replySchema := rpccs.replySchema(chainMessage.GetApi()); replySchema != nil {
+		if err := replySchema.ValidateReply(rpccs.listenEndpoint.ApiInterface, reply.Data); err != nil {
+			if rpccs.listenEndpoint.StrictReplySchemas {
+				return 0, err, false
+			}
+			utils.LavaFormatWarning("provider reply does not conform to the api's schema", err,
+				utils.LogAttr("GUID", ctx),
+				utils.LogAttr("provider", providerPublicAddress),
+				utils.LogAttr("api", chainMessage.GetApi().Name),
+			)
+			rpccs.consumerSessionManager.OnReplyValidationFailure(providerPublicAddress)
+		}
+	}
+	for _, replyValidator := range rpccs.apiReplyValidators(chainMessage.GetApi()) {
+		if err := replyValidator.ValidateReply(relayRequest.RelayData, reply.Data); err != nil {
+			utils.LavaFormatWarning("provider reply failed validation", err,
+				utils.LogAttr("GUID", ctx),
+				utils.LogAttr("provider", providerPublicAddress),
+				utils.LogAttr("api", chainMessage.GetApi().Name),
+			)
+			rpccs.consumerSessionManager.OnReplyValidationFailure(providerPublicAddress)
+		}
+	}
+	if err := rpccs.validateABCIQueryProof(ctx, chainMessage, relayRequest, reply, providerPublicAddress, relayTimeout); err != nil {
+		return 0, err, false
+	}
+	if requestedBlockBeforeResolution == spectypes.FINALIZED_BLOCK || requestedBlockBeforeResolution == spectypes.SAFE_BLOCK {
+		// the finalized tag's content doesn't change anymore, resolving it to the finalized height makes it cacheable.
+		// the safe tag's height isn't final
+		relayRequest.RelayData.RequestBlock = lavaprotocol.ResolveBlockTag(requestedBlockBeforeResolution, reply.LatestBlock, blockDistanceForFinalizedData)
+		finalized = spectypes.IsFinalizedBlock(relayRequest.RelayData.RequestBlock, reply.LatestBlock, blockDistanceForFinalizedData)
+	}
+	// overrides apply after the signature checks, those cover the block the provider resolved
+	if resolvedBlock := rpccs.resolveRequestedBlock(ctx, chainMessage, providerPublicAddress, requestedBlockBeforeResolution, relayRequest.RelayData.RequestBlock); resolvedBlock != relayRequest.RelayData.RequestBlock {
+		relayRequest.RelayData.RequestBlock = resolvedBlock
+		finalized = spectypes.IsFinalizedBlock(resolvedBlock, reply.LatestBlock, blockDistanceForFinalizedData)
+	}
+	if requestedBlockBeforeResolution == spectypes.FINALIZED_BLOCK {
+		// the reply is cached under this height, lookups for the tag use it until it expires
+		rpccs.finalizedTag.Store(&finalizedTagResolution{block: relayRequest.RelayData.RequestBlock, resolvedAt: time.Now()})
+	}
 	relayResult.Finalized = finalized
 	return relayLatency, nil, false
 }
 
-func (rpccs *RPCConsumerServer) relaySubscriptionInner(ctx context.Context, endpointClient pairingtypes.RelayerClient, singleConsumerSession *lavasession.SingleConsumerSession, relayResult *common.RelayResult) (err error) {
+func (rpccs *RPCConsumerServer) relaySubscriptionInner(ctx context.Context, endpointClient pairingtypes.RelayerClient, singleConsumerSession *lavasession.SingleConsumerSession, generation uint64, relayResult *common.RelayResult) (err error) {
 	// relaySentTime := time.Now()
 	replyServer, err := endpointClient.RelaySubscribe(ctx, relayResult.Request)
 	// relayLatency := time.Since(relaySentTime) // TODO: use subscription QoS
 	if err != nil {
-		errReport := rpccs.consumerSessionManager.OnSessionFailure(singleConsumerSession, err)
+		errReport := rpccs.consumerSessionManager.OnSessionFailure(singleConsumerSession, generation, err)
 		if errReport != nil {
 			return utils.LavaFormatError("subscribe relay failed onSessionFailure errored", errReport, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "original error", Value: err.Error()})
 		}
@@ -859,7 +1344,7 @@ func (rpccs *RPCConsumerServer) relaySubscriptionInner(ctx context.Context, endp
 	// my thoughts are that this fails if the grpc fails not if the provider fails, and if the provider returns an error this is reflected by the Recv function on the chainListener calling us here
 	// and this is too late
 	relayResult.ReplyServer = &replyServer
-	err = rpccs.consumerSessionManager.OnSessionDoneIncreaseCUOnly(singleConsumerSession)
+	err = rpccs.consumerSessionManager.OnSessionDoneIncreaseCUOnly(singleConsumerSession, generation)
 	return err
 }
 
@@ -873,9 +1358,16 @@ func (rpccs *RPCConsumerServer) sendDataReliabilityRelayIfApplicable(ctx context
 	if !specCategory.Deterministic || !relayResult.Finalized {
 		return nil // disabled for this spec and requested block so no data reliability messages
 	}
+	if lavaprotocol.IsReplyCommitmentMethod(chainMessage.GetApi().Name) {
+		return nil // the reply is signed with a commitment, a mismatch couldn't be filed as a conflict
+	}
 
 	reqBlock, _ := chainMessage.RequestedBlock()
 	if reqBlock <= spectypes.NOT_APPLICABLE {
+		if reqBlock == spectypes.FINALIZED_BLOCK || reqBlock == spectypes.SAFE_BLOCK {
+			// the request still asks for the tag, another provider can't be asked for the exact block it was resolved to
+			return nil
+		}
 		if reqBlock <= spectypes.LATEST_BLOCK {
 			return utils.LavaFormatError("sendDataReliabilityRelayIfApplicable latest requestBlock", nil, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "RequestBlock", Value: reqBlock})
 		}
@@ -883,20 +1375,48 @@ func (rpccs *RPCConsumerServer) sendDataReliabilityRelayIfApplicable(ctx context
 		return nil
 	}
 
-	if rand.Uint32() > dataReliabilityThreshold {
+	if !rpccs.shouldSendDataReliability(ctx, relayResult, chainMessage, dataReliabilityThreshold) {
 		// decided not to do data reliability
 		return nil
 	}
+	if remaining := common.GetRemainingTimeoutFromContext(ctx); remaining < MinDataReliabilityBudget {
+		utils.LavaFormatDebug("skipping data reliability, not enough of the relay's deadline is left", utils.LogAttr("GUID", ctx), utils.LogAttr("remaining", remaining))
+		return nil
+	}
+	rpccs.rpcConsumerLogs.AddDataReliabilityOutcome(rpccs.listenEndpoint.ChainID, metrics.DataReliabilityTriggered)
+	ctx, span := rpccs.startSpan(ctx, "DataReliabilityRelay", attribute.Int64("requestedBlock", reqBlock), attribute.String("originalProvider", relayResult.ProviderInfo.ProviderAddress))
+	defer span.End()
 	relayRequestData := lavaprotocol.NewRelayData(ctx, relayResult.Request.RelayData.ConnectionType, relayResult.Request.RelayData.ApiUrl, relayResult.Request.RelayData.Data, relayResult.Request.RelayData.SeenBlock, reqBlock, relayResult.Request.RelayData.ApiInterface, chainMessage.GetRPCMessage().GetHeaders(), relayResult.Request.RelayData.Addon, relayResult.Request.RelayData.Extensions)
-	// TODO: give the same timeout the original provider got by setting the same retry
-	relayResultDataReliability, err := rpccs.sendRelayToProvider(ctx, chainMessage, relayRequestData, dappID, consumerIp, &unwantedProviders, 0)
+	if lavasession.IsSimulatedRelay(ctx) {
+		relayRequestData.Metadata = append(relayRequestData.Metadata, lavaprotocol.SimulatedRelayMetadata)
+	}
+	// the original reply was already returned to the user, the deadline only bounds how long the reliability provider
+	// can hold us. a provider that times out fails its session like on any other relay
+	dataReliabilityTimeout := rpccs.dataReliabilityTimeout(ctx, chainMessage)
+	ctx, cancel := context.WithTimeout(ctx, dataReliabilityTimeout)
+	defer cancel()
+	reliabilityUnwanted, colocated := rpccs.reliabilityUnwantedProviders(relayResult.ProviderInfo.ProviderAddress, unwantedProviders)
+	reliabilityUnwanted = rpccs.applyReliabilityPolicy(relayResult.ProviderInfo.ProviderAddress, reliabilityUnwanted, chainMessage)
+	relayResultDataReliability, err := rpccs.sendRelayToProvider(ctx, chainMessage, relayRequestData, dappID, consumerIp, &reliabilityUnwanted, 0)
+	if err != nil && len(colocated) > 0 && lavasession.PairingListEmptyError.Is(err) {
+		utils.LavaFormatWarning("only providers colocated with the original provider are left for data reliability, it won't cross check independent infrastructure", nil, utils.LogAttr("GUID", ctx), utils.LogAttr("originalProvider", relayResult.ProviderInfo.ProviderAddress), utils.LogAttr("colocated", colocated))
+		relayResultDataReliability, err = rpccs.sendRelayToProvider(ctx, chainMessage, relayRequestData, dappID, consumerIp, &unwantedProviders, 0)
+	}
+	// a provider that failed the relay was still loaded with it
+	rpccs.reliabilityCooldowns.served(relayResultDataReliability.ProviderInfo.ProviderAddress)
 	if err != nil {
+		span.RecordError(err)
+		rpccs.rpcConsumerLogs.AddDataReliabilityOutcome(rpccs.listenEndpoint.ChainID, metrics.DataReliabilityErrored)
 		errAttributes := []utils.Attribute{}
 		// failed to send to a provider
 		if relayResultDataReliability.ProviderInfo.ProviderAddress != "" {
 			errAttributes = append(errAttributes, utils.Attribute{Key: "address", Value: relayResultDataReliability.ProviderInfo.ProviderAddress})
 		}
 		errAttributes = append(errAttributes, utils.Attribute{Key: "relayRequestData", Value: relayRequestData})
+		if common.ContextOutOfTime(ctx) {
+			errAttributes = append(errAttributes, utils.Attribute{Key: "timeout", Value: dataReliabilityTimeout})
+			return utils.LavaFormatWarning("data reliability relay timed out", err, errAttributes...)
+		}
 		return utils.LavaFormatWarning("failed data reliability relay to provider", err, errAttributes...)
 	}
 	if !relayResultDataReliability.Finalized {
@@ -905,6 +1425,7 @@ func (rpccs *RPCConsumerServer) sendDataReliabilityRelayIfApplicable(ctx context
 	}
 	conflict := lavaprotocol.VerifyReliabilityResults(ctx, relayResult, relayResultDataReliability, chainMessage.GetApiCollection(), rpccs.chainParser)
 	if conflict != nil {
+		rpccs.rpcConsumerLogs.AddDataReliabilityOutcome(rpccs.listenEndpoint.ChainID, metrics.DataReliabilityConflict)
 		// TODO: remove this check when we fix the missing extensions information on conflict detection transaction
 		if relayRequestData.Extensions == nil || len(relayRequestData.Extensions) == 0 {
 			err := rpccs.consumerTxSender.TxConflictDetection(ctx, nil, conflict, nil, relayResultDataReliability.ConflictHandler)
@@ -917,11 +1438,82 @@ func (rpccs *RPCConsumerServer) sendDataReliabilityRelayIfApplicable(ctx context
 			}
 		}
 	} else {
+		rpccs.rpcConsumerLogs.AddDataReliabilityOutcome(rpccs.listenEndpoint.ChainID, metrics.DataReliabilityAgreed)
 		utils.LavaFormatDebug("[+] verified relay successfully with data reliability", utils.LogAttr("api", chainMessage.GetApi().Name))
 	}
 	return nil
 }
 
+// replies are signed with a commitment for the configured methods, or when requested and the reply can't be evidence
+func requestReplyCommitment(chainMessage chainlib.ChainMessage) bool {
+	if lavaprotocol.IsReplyCommitmentMethod(chainMessage.GetApi().Name) {
+		return true
+	}
+	return lavaprotocol.RequestReplyCommitments && !replyMayBeConflictEvidence(chainMessage)
+}
+
+// data reliability compares replies of deterministic apis for a specific block, latest requests are pinned to one
+// after the first reply, and files mismatches as conflicts. the chain verifies those against the full reply so they
+// are only signed with a commitment for methods data reliability skips
+func replyMayBeConflictEvidence(chainMessage chainlib.ChainMessage) bool {
+	if !chainMessage.GetApi().Category.Deterministic {
+		return false
+	}
+	requestedBlock, _ := chainMessage.RequestedBlock()
+	return requestedBlock > spectypes.NOT_APPLICABLE || requestedBlock == spectypes.LATEST_BLOCK
+}
+
+// SetAddressCodec sets the codec provider addresses are verified and shown with
+func (rpccs *RPCConsumerServer) SetAddressCodec(addressCodec lavaprotocol.AddressCodec) {
+	rpccs.addressCodec = addressCodec
+}
+
+func (rpccs *RPCConsumerServer) getAddressCodec() lavaprotocol.AddressCodec {
+	if rpccs.addressCodec == nil {
+		return lavaprotocol.DefaultAddressCodec
+	}
+	return rpccs.addressCodec
+}
+
+// the reliability timeout is held to the same ceiling and caller deadline as any other relay
+func (rpccs *RPCConsumerServer) dataReliabilityTimeout(ctx context.Context, chainMessage chainlib.ChainMessage) time.Duration {
+	if DataReliabilityTimeout > 0 {
+		return capRelayTimeout(ctx, DataReliabilityTimeout)
+	}
+	return capRelayTimeout(ctx, chainlib.GetRelayTimeout(chainMessage, rpccs.chainParser, 0))
+}
+
+// finalized tag replies are cached under the height their reply resolved to, lookups use the last one so they hit the
+// same key. an estimate from our latest block would drift between replies and miss. the finalized block advances with
+// the chain, so the resolution is only used for an average block time, after that lookups miss and the next reply
+// resolves the tag again
+func (rpccs *RPCConsumerServer) cacheRequestedBlock(requestedBlock int64) int64 {
+	if requestedBlock != spectypes.FINALIZED_BLOCK {
+		return requestedBlock
+	}
+	finalizedTag := rpccs.finalizedTag.Load()
+	if finalizedTag == nil || finalizedTag.block <= 0 {
+		return requestedBlock
+	}
+	_, averageBlockTime, _, _ := rpccs.chainParser.ChainBlockStats()
+	if time.Since(finalizedTag.resolvedAt) >= averageBlockTime {
+		return requestedBlock
+	}
+	return finalizedTag.block
+}
+
+// the relay goroutines run on a detached context so the caller's deadline has to bound the relay timeout itself, the
+// sooner of the two is used and neither can exceed MaxRelayTimeout
+func capRelayTimeout(ctx context.Context, relayTimeout time.Duration) time.Duration {
+	if MaxRelayTimeout > 0 && relayTimeout > MaxRelayTimeout {
+		relayTimeout = MaxRelayTimeout
+	}
+	if remaining := common.GetRemainingTimeoutFromContext(ctx); remaining < relayTimeout {
+		return remaining
+	}
+	return relayTimeout
+}
+
 func (rpccs *RPCConsumerServer) LavaDirectiveHeaders(metadata []pairingtypes.Metadata) ([]pairingtypes.Metadata, map[string]string) {
 	metadataRet := []pairingtypes.Metadata{}
 	headerDirectives := map[string]string{}
@@ -936,6 +1528,12 @@ func (rpccs *RPCConsumerServer) LavaDirectiveHeaders(metadata []pairingtypes.Met
 			headerDirectives[name] = metaElement.Value
 		case common.FORCE_CACHE_REFRESH_HEADER_NAME:
 			headerDirectives[name] = metaElement.Value
+		case common.AFFINITY_KEY_HEADER_NAME:
+			headerDirectives[name] = metaElement.Value
+		case common.SIMULATE_RELAY_HEADER_NAME:
+			headerDirectives[name] = metaElement.Value
+		case common.RELAY_PRIORITY_HEADER_NAME:
+			headerDirectives[name] = metaElement.Value
 		default:
 			metadataRet = append(metadataRet, metaElement)
 		}
@@ -1021,6 +1619,60 @@ func (rpccs *RPCConsumerServer) appendHeadersToRelayResult(ctx context.Context,
 	relayResult.Reply.Metadata = append(relayResult.Reply.Metadata, metadataReply...)
 }
 
+func (rpccs *RPCConsumerServer) appendSLAHeadersToRelayResult(relayResult *common.RelayResult, latency time.Duration) {
+	if !RelaySLAHeaders || relayResult == nil {
+		return
+	}
+	if relayResult.Reply == nil {
+		relayResult.Reply = &pairingtypes.RelayReply{}
+	}
+	// cached, fallback and failed replies weren't verified against a provider's signature
+	verification := relayResult.Verification
+	if verification == "" {
+		verification = common.REPLY_NOT_VERIFIED
+	}
+	relayResult.Reply.Metadata = append(relayResult.Reply.Metadata,
+		pairingtypes.Metadata{
+			Name:  common.RELAY_LATENCY_HEADER_NAME,
+			Value: strconv.FormatInt(latency.Milliseconds(), 10),
+		},
+		pairingtypes.Metadata{
+			Name:  common.REPLY_VERIFIED_HEADER_NAME,
+			Value: verification,
+		})
+}
+
+func (rpccs *RPCConsumerServer) appendFreshnessHeadersToRelayResult(relayResult *common.RelayResult) {
+	if !RelayFreshnessHeaders || relayResult == nil || relayResult.Reply == nil || relayResult.Request == nil || relayResult.Request.RelayData == nil {
+		return
+	}
+	// the requested block was already resolved against the reply's latest block, for latest reads they're the same.
+	// a block that is still a tag wasn't resolved (a cached reply without a latest block) and isn't reported
+	if requestBlock := relayResult.Request.RelayData.RequestBlock; requestBlock >= 0 {
+		relayResult.Reply.Metadata = append(relayResult.Reply.Metadata, pairingtypes.Metadata{
+			Name:  common.OBSERVED_BLOCK_HEADER_NAME,
+			Value: strconv.FormatInt(requestBlock, 10),
+		})
+	}
+	relayResult.Reply.Metadata = append(relayResult.Reply.Metadata,
+		pairingtypes.Metadata{
+			Name:  common.PROVIDER_LATEST_BLOCK_HEADER_NAME,
+			Value: strconv.FormatInt(relayResult.Reply.LatestBlock, 10),
+		})
+}
+
 func (rpccs *RPCConsumerServer) IsHealthy() bool {
-	return rpccs.relaysMonitor.IsHealthy()
+	return rpccs.validateReadiness() == nil && rpccs.relaysMonitor.IsHealthy() && rpccs.validateUsableProviders() == nil
+}
+
+// exponential backoff for transient reply verification failures, capped at the regular failure backoff
+func verificationFailureBackoff(consecutiveErrors int) time.Duration {
+	backoff := verificationFailureBaseBackoff
+	for i := 0; i < consecutiveErrors && backoff < lavasession.BACKOFF_TIME_ON_FAILURE; i++ {
+		backoff *= 2
+	}
+	if backoff > lavasession.BACKOFF_TIME_ON_FAILURE {
+		return lavasession.BACKOFF_TIME_ON_FAILURE
+	}
+	return backoff
 }
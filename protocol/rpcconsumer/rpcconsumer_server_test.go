@@ -0,0 +1,614 @@
+package rpcconsumer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/lavanet/lava/protocol/rpcprovider/devprovider"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcmetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestAppendSLAHeadersToRelayResult(t *testing.T) {
+	rpccs := &RPCConsumerServer{}
+	headers := func(relayResult *common.RelayResult) map[string]string {
+		values := map[string]string{}
+		for _, header := range relayResult.Reply.GetMetadata() {
+			values[header.Name] = header.Value
+		}
+		return values
+	}
+
+	relayResult := &common.RelayResult{ProviderInfo: common.ProviderInfo{ProviderAddress: "lava@provider"}, Verification: common.REPLY_VERIFIED_COMMITMENT}
+	rpccs.appendHeadersToRelayResult(context.Background(), relayResult, 0)
+	rpccs.appendSLAHeadersToRelayResult(relayResult, 150*time.Millisecond)
+	require.NotContains(t, headers(relayResult), common.RELAY_LATENCY_HEADER_NAME) // opt in only
+
+	defer func(enabled bool) { RelaySLAHeaders = enabled }(RelaySLAHeaders)
+	RelaySLAHeaders = true
+	rpccs.appendSLAHeadersToRelayResult(relayResult, 150*time.Millisecond)
+	require.Equal(t, map[string]string{
+		common.PROVIDER_ADDRESS_HEADER_NAME: "lava@provider",
+		common.RELAY_LATENCY_HEADER_NAME:    "150",
+		common.REPLY_VERIFIED_HEADER_NAME:   "commitment",
+	}, headers(relayResult))
+
+	failedResult := &common.RelayResult{}
+	rpccs.appendSLAHeadersToRelayResult(failedResult, 2*time.Second)
+	require.Equal(t, "2000", headers(failedResult)[common.RELAY_LATENCY_HEADER_NAME])
+	require.Equal(t, "none", headers(failedResult)[common.REPLY_VERIFIED_HEADER_NAME])
+
+	signedResult := &common.RelayResult{Verification: common.REPLY_VERIFIED_SIGNATURE}
+	rpccs.appendSLAHeadersToRelayResult(signedResult, time.Millisecond)
+	require.Equal(t, "signature", headers(signedResult)[common.REPLY_VERIFIED_HEADER_NAME])
+}
+
+func TestAppendFreshnessHeadersToRelayResult(t *testing.T) {
+	rpccs := &RPCConsumerServer{}
+	relayResult := &common.RelayResult{
+		Request: &pairingtypes.RelayRequest{RelayData: &pairingtypes.RelayPrivateData{RequestBlock: 100}},
+		Reply:   &pairingtypes.RelayReply{Data: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`), LatestBlock: 102},
+	}
+	rpccs.appendFreshnessHeadersToRelayResult(relayResult)
+	require.Empty(t, relayResult.Reply.Metadata) // opt in only
+
+	defer func(enabled bool) { RelayFreshnessHeaders = enabled }(RelayFreshnessHeaders)
+	RelayFreshnessHeaders = true
+	rpccs.appendFreshnessHeadersToRelayResult(relayResult)
+	require.Equal(t, []pairingtypes.Metadata{
+		{Name: common.OBSERVED_BLOCK_HEADER_NAME, Value: "100"},
+		{Name: common.PROVIDER_LATEST_BLOCK_HEADER_NAME, Value: "102"},
+	}, relayResult.Reply.Metadata)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.Reply.Data))
+
+	// a block tag that wasn't resolved, like a cached reply without a latest block, isn't reported as the observed block
+	unresolved := &common.RelayResult{
+		Request: &pairingtypes.RelayRequest{RelayData: &pairingtypes.RelayPrivateData{RequestBlock: spectypes.LATEST_BLOCK}},
+		Reply:   &pairingtypes.RelayReply{LatestBlock: 102},
+	}
+	rpccs.appendFreshnessHeadersToRelayResult(unresolved)
+	require.Equal(t, []pairingtypes.Metadata{{Name: common.PROVIDER_LATEST_BLOCK_HEADER_NAME, Value: "102"}}, unresolved.Reply.Metadata)
+
+	// nothing to report without a reply
+	rpccs.appendFreshnessHeadersToRelayResult(&common.RelayResult{})
+}
+
+func TestValidateUsableProviders(t *testing.T) {
+	csm := lavasession.NewConsumerSessionManager(&lavasession.RPCEndpoint{ChainID: "stub", ApiInterface: "stub"}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	rpccs := &RPCConsumerServer{consumerSessionManager: csm}
+	require.Equal(t, 0, rpccs.UsableProvidersCount())
+	// disabled by default
+	require.NoError(t, rpccs.validateUsableProviders())
+
+	defer func(minUsableProviders uint64) { lavasession.MinUsableProviders = minUsableProviders }(lavasession.MinUsableProviders)
+	lavasession.MinUsableProviders = 1
+	err := rpccs.validateUsableProviders()
+	require.True(t, lavasession.ErrInsufficientProviders.Is(err))
+	require.False(t, rpccs.IsHealthy())
+}
+
+func TestDataReliabilityTimeout(t *testing.T) {
+	rpccs := &RPCConsumerServer{}
+	defer func(timeout time.Duration) { DataReliabilityTimeout = timeout }(DataReliabilityTimeout)
+	DataReliabilityTimeout = 50 * time.Millisecond
+	require.Equal(t, 50*time.Millisecond, rpccs.dataReliabilityTimeout(context.Background(), nil))
+
+	// the reliability deadline is shorter than the relay timeout so it bounds the relay
+	ctx, cancel := context.WithTimeout(context.Background(), rpccs.dataReliabilityTimeout(context.Background(), nil))
+	defer cancel()
+	relayTimeout := capRelayTimeout(ctx, 10*time.Second)
+	require.LessOrEqual(t, relayTimeout, 50*time.Millisecond)
+	require.Positive(t, relayTimeout)
+	// no deadline keeps the relay timeout
+	require.Equal(t, 10*time.Second, capRelayTimeout(context.Background(), 10*time.Second))
+
+	<-ctx.Done()
+	require.LessOrEqual(t, capRelayTimeout(ctx, 10*time.Second), time.Duration(0))
+
+	// the reliability timeout can't exceed the relay timeout ceiling or the caller's deadline
+	defer func(timeout time.Duration) { MaxRelayTimeout = timeout }(MaxRelayTimeout)
+	MaxRelayTimeout = time.Minute
+	DataReliabilityTimeout = time.Hour
+	require.Equal(t, time.Minute, rpccs.dataReliabilityTimeout(context.Background(), nil))
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer shortCancel()
+	require.LessOrEqual(t, rpccs.dataReliabilityTimeout(shortCtx, nil), 100*time.Millisecond)
+}
+
+func TestCapRelayTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { MaxRelayTimeout = timeout }(MaxRelayTimeout)
+	MaxRelayTimeout = time.Minute
+
+	// a client deadline shorter than the api's timeout wins
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	relayTimeout := capRelayTimeout(ctx, 10*time.Second)
+	require.LessOrEqual(t, relayTimeout, 100*time.Millisecond)
+	require.Positive(t, relayTimeout)
+
+	// a longer client deadline doesn't extend the api's timeout
+	longCtx, longCancel := context.WithTimeout(context.Background(), time.Hour)
+	defer longCancel()
+	require.Equal(t, 10*time.Second, capRelayTimeout(longCtx, 10*time.Second))
+
+	// a timeout requested above the ceiling is held to it, even under a longer client deadline
+	require.Equal(t, time.Minute, capRelayTimeout(longCtx, time.Hour))
+	require.Equal(t, time.Minute, capRelayTimeout(context.Background(), time.Hour))
+
+	MaxRelayTimeout = 0
+	require.Equal(t, time.Hour, capRelayTimeout(context.Background(), time.Hour))
+}
+
+func TestSendParsedRelay(t *testing.T) {
+	ctx := context.Background()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	methodFilter, err := chainlib.NewMethodFilter(nil, []string{"eth_blockNumber"})
+	require.NoError(t, err)
+	csm := lavasession.NewConsumerSessionManager(&lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	rpccs := &RPCConsumerServer{
+		chainParser:            chainParser,
+		consumerSessionManager: csm,
+		listenEndpoint:         &lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC},
+		methodFilter:           methodFilter,
+		finalizationConsensus:  lavaprotocol.NewFinalizationConsensus("ETH1"),
+	}
+
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	metadata, directiveHeaders := rpccs.LavaDirectiveHeaders(nil)
+	chainMessage, err := chainParser.ParseMsg("", []byte(req), http.MethodPost, metadata, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	// the message is parsed once and goes through the same checks as SendRelay on every relay
+	for i := 0; i < 2; i++ {
+		_, err = rpccs.SendParsedRelay(ctx, chainMessage, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, directiveHeaders)
+		require.True(t, chainlib.MethodDisabledError.Is(err))
+	}
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.True(t, chainlib.MethodDisabledError.Is(err))
+
+	rpccs.methodFilter = nil
+	defer func(minUsableProviders uint64) { lavasession.MinUsableProviders = minUsableProviders }(lavasession.MinUsableProviders)
+	lavasession.MinUsableProviders = 1
+	_, err = rpccs.SendParsedRelay(ctx, chainMessage, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, directiveHeaders)
+	require.True(t, lavasession.ErrInsufficientProviders.Is(err))
+}
+
+// requestBlockRelayer records the requested block of every relay it answers
+type requestBlockRelayer struct {
+	mockRelayer
+	lock          sync.Mutex
+	requestBlocks []int64
+}
+
+func (rbr *requestBlockRelayer) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	rbr.lock.Lock()
+	rbr.requestBlocks = append(rbr.requestBlocks, request.RelayData.RequestBlock)
+	rbr.lock.Unlock()
+	return rbr.mockRelayer.Relay(ctx, request)
+}
+
+func TestSendParsedRelayTwice(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	relayer := &requestBlockRelayer{mockRelayer: mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`)}}
+	rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+
+	ctx := context.Background()
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	metadata, directiveHeaders := rpccs.LavaDirectiveHeaders(nil)
+	chainMessage, err := rpccs.chainParser.ParseMsg("", []byte(req), http.MethodPost, metadata, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	latest, earliest := chainMessage.RequestedBlock()
+	require.Equal(t, spectypes.LATEST_BLOCK, latest)
+	for i := 0; i < 2; i++ {
+		relayResult, err := rpccs.SendParsedRelay(ctx, chainMessage, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, directiveHeaders)
+		require.NoError(t, err)
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.GetReply().Data))
+		// sending doesn't pin the parsed message to the block the relay resolved
+		requestedLatest, requestedEarliest := chainMessage.RequestedBlock()
+		require.Equal(t, latest, requestedLatest)
+		require.Equal(t, earliest, requestedEarliest)
+	}
+	relayer.lock.Lock()
+	defer relayer.lock.Unlock()
+	require.Len(t, relayer.requestBlocks, 2)
+	require.Equal(t, relayer.requestBlocks[0], relayer.requestBlocks[1])
+}
+
+func TestSendRelayFinalizedTagCacheKey(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	relayer := &mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)}
+	rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+	// no reply resolved the tag yet, it can't match a cached entry
+	require.Equal(t, spectypes.FINALIZED_BLOCK, rpccs.cacheRequestedBlock(spectypes.FINALIZED_BLOCK))
+
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","finalized"]}`
+	relayResult, err := rpccs.SendRelay(context.Background(), "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	// the mock provider signs the full reply
+	require.Equal(t, common.REPLY_VERIFIED_SIGNATURE, relayResult.Verification)
+	// the tag isn't a block number, the reply changes as the chain advances
+	require.True(t, relayResult.BlockTag)
+	// lookups use the height the reply was cached under
+	require.Equal(t, relayResult.Request.RelayData.RequestBlock, rpccs.cacheRequestedBlock(spectypes.FINALIZED_BLOCK))
+	_, _, blockDistanceForFinalizedData, _ := rpccs.chainParser.ChainBlockStats()
+	require.Equal(t, 100-int64(blockDistanceForFinalizedData), rpccs.cacheRequestedBlock(spectypes.FINALIZED_BLOCK))
+	require.Equal(t, int64(0x10), rpccs.cacheRequestedBlock(0x10))
+	// the finalized block advances, an old resolution isn't used
+	_, averageBlockTime, _, _ := rpccs.chainParser.ChainBlockStats()
+	rpccs.finalizedTag.Store(&finalizedTagResolution{block: 90, resolvedAt: time.Now().Add(-averageBlockTime)})
+	require.Equal(t, spectypes.FINALIZED_BLOCK, rpccs.cacheRequestedBlock(spectypes.FINALIZED_BLOCK))
+
+	// safe resolves short of the finalized block and isn't final
+	req = `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","safe"]}`
+	relayResult, err = rpccs.SendRelay(context.Background(), "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 100-int64(blockDistanceForFinalizedData/2), relayResult.Request.RelayData.RequestBlock)
+	require.False(t, relayResult.Finalized)
+	require.Equal(t, spectypes.SAFE_BLOCK, rpccs.cacheRequestedBlock(spectypes.SAFE_BLOCK))
+}
+
+// gzipRelayer sends its reply gzipped, the signature covers the compressed data
+type gzipRelayer struct {
+	mockRelayer
+}
+
+func (gr *gzipRelayer) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	grpc.SetTrailer(ctx, grpcmetadata.Pairs(common.ReplyEncodingMetadataKey, common.ReplyEncodingGzip))
+	return gr.mockRelayer.Relay(ctx, request)
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	require.NoError(t, err)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestSendRelayDecompressesReply(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	const reply = `{"jsonrpc":"2.0","id":1,"result":"0x64"}`
+	relayer := &gzipRelayer{mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: gzipData(t, []byte(reply))}}
+	rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+	defer func(maxSize int64) { lavaprotocol.MaxDecompressedReplySize = maxSize }(lavaprotocol.MaxDecompressedReplySize)
+	lavaprotocol.MaxDecompressedReplySize = 1024
+
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	relayResult, err := rpccs.SendRelay(context.Background(), "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, reply, string(relayResult.Reply.Data))
+
+	// a tiny reply inflating far past the limit fails the relay and blocks the provider
+	bomb := gzipData(t, make([]byte, 64*lavaprotocol.MaxDecompressedReplySize))
+	require.Less(t, int64(len(bomb)), lavaprotocol.MaxDecompressedReplySize)
+	relayer.reply = bomb
+	_, err = rpccs.SendRelay(context.Background(), "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.Error(t, err)
+	// the retry finds no other provider, either error can be the one reported
+	if !strings.Contains(err.Error(), "decompresses beyond the limit") {
+		require.ErrorContains(t, err, lavasession.PairingListEmptyError.Error())
+		return
+	}
+	require.ErrorContains(t, err, lavasession.BlockProviderError.Error())
+}
+
+// supportedApisRelayer advertises a subset of the spec's apis on probes
+type supportedApisRelayer struct {
+	mockRelayer
+	supportedApis []string
+}
+
+func (sar *supportedApisRelayer) Probe(ctx context.Context, probeReq *pairingtypes.ProbeRequest) (*pairingtypes.ProbeReply, error) {
+	grpc.SetTrailer(ctx, grpcmetadata.MD{common.SupportedApisMetadataKey: sar.supportedApis})
+	return sar.mockRelayer.Probe(ctx, probeReq)
+}
+
+func TestSendRelayProviderCapabilities(t *testing.T) {
+	defer func(validate bool) { lavasession.ValidateProviderCapabilities = validate }(lavasession.ValidateProviderCapabilities)
+	lavasession.ValidateProviderCapabilities = true
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	relayer := &supportedApisRelayer{mockRelayer: mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`)}, supportedApis: []string{"eth_blockNumber", "eth_chainId"}}
+	rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+
+	// the pairing's probe records what the provider advertised
+	require.Eventually(t, func() bool {
+		return !rpccs.consumerSessionManager.HasCapableProvider([]string{"eth_getBalance"}, "", nil)
+	}, 5*time.Second, 10*time.Millisecond)
+	ctx := context.Background()
+	relayResult, err := rpccs.SendRelay(ctx, "", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.GetReply().Data))
+	_, err = rpccs.SendRelay(ctx, "", `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.True(t, lavasession.NoCapableProviderError.Is(err))
+
+	// batches are checked by the apis of their elements
+	_, err = rpccs.SendRelay(ctx, "", `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]},{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]}]`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.False(t, lavasession.NoCapableProviderError.Is(err), err)
+	_, err = rpccs.SendRelay(ctx, "", `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]},{"jsonrpc":"2.0","id":2,"method":"eth_getBalance","params":["0xaa","0x10"]}]`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.True(t, lavasession.NoCapableProviderError.Is(err))
+}
+
+// simulatedRelayer records the relay data it got and acknowledges simulated relays when serving them without charge
+type simulatedRelayer struct {
+	mockRelayer
+	acknowledge bool
+	lock        sync.Mutex
+	simulated   []bool
+}
+
+func (sr *simulatedRelayer) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	simulated := lavaprotocol.IsSimulatedRelay(request.RelayData.Metadata)
+	sr.lock.Lock()
+	sr.simulated = append(sr.simulated, simulated)
+	sr.lock.Unlock()
+	reply := &pairingtypes.RelayReply{Data: sr.reply, LatestBlock: 100}
+	if simulated && sr.acknowledge {
+		reply.Metadata = []pairingtypes.Metadata{lavaprotocol.SimulatedRelayMetadata}
+	}
+	return lavaprotocol.SignRelayResponse(sr.consumerAddress, *request, sr.privKey, reply, false)
+}
+
+func TestSendRelaySimulated(t *testing.T) {
+	defer func(allow bool) { AllowSimulatedRelays = allow }(AllowSimulatedRelays)
+	AllowSimulatedRelays = true
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	for _, acknowledge := range []bool{true, false} {
+		consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+		providerKey, providerAddress := sigs.GenerateFloatingKey()
+		relayer := &simulatedRelayer{mockRelayer: mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`)}, acknowledge: acknowledge}
+		rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+
+		ctx := context.Background()
+		req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+		// the acknowledgement is signed after the provider's filtered headers and verifies
+		relayResult, err := rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, []pairingtypes.Metadata{{Name: common.SIMULATE_RELAY_HEADER_NAME, Value: "true"}})
+		require.NoError(t, err)
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.GetReply().Data))
+		_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+		require.NoError(t, err)
+		relayer.lock.Lock()
+		require.Equal(t, []bool{true, false}, relayer.simulated)
+		relayer.lock.Unlock()
+	}
+}
+
+// sizeLimitsRelayer advertises the largest request it accepts on probes
+type sizeLimitsRelayer struct {
+	mockRelayer
+	maxRequestSize string
+}
+
+func (slr *sizeLimitsRelayer) Probe(ctx context.Context, probeReq *pairingtypes.ProbeRequest) (*pairingtypes.ProbeReply, error) {
+	grpc.SetTrailer(ctx, grpcmetadata.Pairs(common.MaxRequestSizeMetadataKey, slr.maxRequestSize))
+	return slr.mockRelayer.Probe(ctx, probeReq)
+}
+
+func TestSendRelayProviderSizeLimits(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	relayer := &sizeLimitsRelayer{mockRelayer: mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`)}, maxRequestSize: "100"}
+	rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+
+	// the pairing's probe records the provider's limits
+	ctx := context.Background()
+	largeRequest := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x` + strings.Repeat("a", 100) + `","latest"]}`
+	require.Eventually(t, func() bool {
+		_, err := rpccs.SendRelay(ctx, "", largeRequest, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+		// the relay fails with the selection's error flattened into its retries summary
+		return err != nil && strings.Contains(err.Error(), lavasession.RelayExceedsProviderLimitsError.Error())
+	}, 5*time.Second, 10*time.Millisecond)
+	relayResult, err := rpccs.SendRelay(ctx, "", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.GetReply().Data))
+}
+
+func TestSendRelayReadOnly(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	rpccs := &RPCConsumerServer{
+		chainParser:           chainParser,
+		listenEndpoint:        &lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC},
+		finalizationConsensus: lavaprotocol.NewFinalizationConsensus("ETH1"),
+	}
+	ctx := chainlib.ContextWithReadOnlyRelay(context.Background())
+	_, err = rpccs.SendRelay(ctx, "", `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["0xaa"]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.True(t, chainlib.ReadOnlyRelayError.Is(err))
+}
+
+func TestReplyMayBeConflictEvidence(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	playbook := []struct {
+		request  string
+		evidence bool
+	}{
+		{request: `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`, evidence: true},
+		{request: `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","latest"]}`, evidence: true},
+		{request: `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","pending"]}`, evidence: false},
+		{request: `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x10","toBlock":"0x20"}]}`, evidence: false},
+	}
+	for _, play := range playbook {
+		chainMessage, err := chainParser.ParseMsg("", []byte(play.request), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+		require.NoError(t, err)
+		require.Equal(t, play.evidence, replyMayBeConflictEvidence(chainMessage), play.request)
+	}
+
+	// configured methods are signed with a commitment even when they could be evidence
+	defer func(methods []string) { lavaprotocol.ReplyCommitmentMethods = methods }(lavaprotocol.ReplyCommitmentMethods)
+	lavaprotocol.ReplyCommitmentMethods = []string{"eth_getBlockByNumber"}
+	blockMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x10",true]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.True(t, replyMayBeConflictEvidence(blockMessage))
+	require.True(t, requestReplyCommitment(blockMessage))
+	balanceMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	require.False(t, requestReplyCommitment(balanceMessage))
+}
+
+// mockRelayer is a provider answering every relay with the same signed reply
+type mockRelayer struct {
+	pairingtypes.UnimplementedRelayerServer
+	consumerAddress sdk.AccAddress
+	privKey         *btcec.PrivateKey
+	reply           []byte
+}
+
+func (mr *mockRelayer) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	return lavaprotocol.SignRelayResponse(mr.consumerAddress, *request, mr.privKey, &pairingtypes.RelayReply{Data: mr.reply, LatestBlock: 100}, false)
+}
+
+func (mr *mockRelayer) Probe(ctx context.Context, probeReq *pairingtypes.ProbeRequest) (*pairingtypes.ProbeReply, error) {
+	return &pairingtypes.ProbeReply{Guid: probeReq.Guid, LatestBlock: 100}, nil
+}
+
+// newBenchmarkConsumer returns a consumer paired with a single mock provider
+func newBenchmarkConsumer(b *testing.B) *RPCConsumerServer {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(b, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	relayer := &mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`)}
+	return newTestConsumer(b, spec, relayer, providerAddress, consumerKey, consumerAddress)
+}
+
+// newTestConsumer returns a consumer paired with a single provider served by relayer, relays reach it over an in
+// memory connection through the relay dialer hook
+func newTestConsumer(tb testing.TB, spec spectypes.Spec, relayer pairingtypes.RelayerServer, providerAddress sdk.AccAddress, consumerKey *btcec.PrivateKey, consumerAddress sdk.AccAddress, serverOptions ...grpc.ServerOption) *RPCConsumerServer {
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(tb, err)
+	chainParser.SetSpec(spec)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(serverOptions...)
+	pairingtypes.RegisterRelayerServer(server, relayer)
+	go server.Serve(listener)
+	tb.Cleanup(server.Stop)
+	lavasession.SetRelayDialer(func(ctx context.Context, address string, allowInsecure bool) (*grpc.ClientConn, error) {
+		return grpc.DialContext(ctx, address, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()), lavasession.FirstByteDialOption())
+	})
+	tb.Cleanup(func() { lavasession.SetRelayDialer(nil) })
+
+	listenEndpoint := &lavasession.RPCEndpoint{ChainID: spec.Index, ApiInterface: spectypes.APIInterfaceJsonRPC}
+	csm := lavasession.NewConsumerSessionManager(listenEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	endpoints := []*lavasession.Endpoint{{NetworkAddress: "bufconn", Enabled: true, Addons: map[string]struct{}{}, Extensions: map[string]struct{}{}}}
+	pairing := map[uint64]*lavasession.ConsumerSessionsWithProvider{0: lavasession.NewConsumerSessionWithProvider(providerAddress.String(), endpoints, math.MaxUint64/2, 20, sdk.NewInt64Coin("ulava", 100))}
+	require.NoError(tb, csm.UpdateAllProviders(20, pairing))
+	return &RPCConsumerServer{
+		chainParser:            chainParser,
+		consumerSessionManager: csm,
+		listenEndpoint:         listenEndpoint,
+		finalizationConsensus:  lavaprotocol.NewFinalizationConsensus(spec.Index),
+		consumerConsistency:    NewConsumerConsistency(spec.Index),
+		consumerTxSender:       mockConsumerTxSender{},
+		requiredResponses:      1,
+		privKey:                consumerKey,
+		consumerAddress:        consumerAddress,
+		lavaChainID:            "lava",
+	}
+}
+
+func BenchmarkSendRelay(b *testing.B) {
+	rpccs := newBenchmarkConsumer(b)
+	ctx := context.Background()
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xaa","0x10"]}`
+	relayResult, err := rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(b, err)
+	require.Equal(b, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.GetReply().Data))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSendRelayDevProvider(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	require.True(t, spec.DataReliabilityEnabled)
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, _ := sigs.GenerateFloatingKey()
+	provider := devprovider.NewProvider(providerKey, devprovider.Replies{"eth_blockNumber": json.RawMessage(`"0x64"`)}, 100)
+	provider.SetFinalization(spec.BlockDistanceForFinalizedData, spec.BlocksInFinalizationProof)
+	rpccs := newTestConsumer(t, spec, provider, provider.Address(), consumerKey, consumerAddress)
+
+	ctx := context.Background()
+	relayResult, err := rpccs.SendRelay(ctx, "", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.GetReply().Data))
+	require.Equal(t, provider.Address().String(), relayResult.ProviderInfo.ProviderAddress)
+
+	// methods without a canned reply get a node like error
+	relayResult, err = rpccs.SendRelay(ctx, "", `{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]}`, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the method eth_chainId does not exist/is not available"}}`, string(relayResult.GetReply().Data))
+}
@@ -0,0 +1,31 @@
+package rpcconsumer
+
+import (
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const ExpectedConsumerAddressFlag = "expected-consumer-address"
+
+// when set, the consumer refuses to start unless it signs relays as this account, e.g. the one holding the subscription
+var ExpectedConsumerAddress = ""
+
+var SigningKeyMismatchError = sdkerrors.New("SigningKeyMismatch Error", 694, "the signing key doesn't match the consumer account, providers would reject every relay")
+
+// verifySigningKey checks relays are signed as the consumer account, a mismatch only shows up as every provider
+// rejecting the relays so it's checked on startup
+func verifySigningKey(privKey *btcec.PrivateKey, consumerAddress sdk.AccAddress) error {
+	if privKey == nil {
+		return sdkerrors.Wrapf(SigningKeyMismatchError, "no signing key for consumer account %s", consumerAddress)
+	}
+	signingAddress := sdk.AccAddress((&secp256k1.PubKey{Key: privKey.PubKey().SerializeCompressed()}).Address())
+	if !signingAddress.Equals(consumerAddress) {
+		return sdkerrors.Wrapf(SigningKeyMismatchError, "the key signs as %s, the consumer account is %s", signingAddress, consumerAddress)
+	}
+	if ExpectedConsumerAddress != "" && ExpectedConsumerAddress != consumerAddress.String() {
+		return sdkerrors.Wrapf(SigningKeyMismatchError, "the key signs as %s, --%s is %s", consumerAddress, ExpectedConsumerAddressFlag, ExpectedConsumerAddress)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package rpcconsumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils/sigs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySigningKey(t *testing.T) {
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	_, otherAddress := sigs.GenerateFloatingKey()
+	require.NoError(t, verifySigningKey(consumerKey, consumerAddress))
+	err := verifySigningKey(consumerKey, otherAddress)
+	require.ErrorIs(t, err, SigningKeyMismatchError)
+	require.ErrorContains(t, err, consumerAddress.String())
+	require.ErrorContains(t, err, otherAddress.String())
+	require.ErrorIs(t, verifySigningKey(nil, consumerAddress), SigningKeyMismatchError)
+
+	expectedConsumerAddress := ExpectedConsumerAddress
+	defer func() { ExpectedConsumerAddress = expectedConsumerAddress }()
+	ExpectedConsumerAddress = consumerAddress.String()
+	require.NoError(t, verifySigningKey(consumerKey, consumerAddress))
+	ExpectedConsumerAddress = otherAddress.String()
+	err = verifySigningKey(consumerKey, consumerAddress)
+	require.ErrorIs(t, err, SigningKeyMismatchError)
+	require.ErrorContains(t, err, ExpectedConsumerAddressFlag)
+}
+
+func TestServeRPCRequestsSigningKeyMismatch(t *testing.T) {
+	consumerKey, _ := sigs.GenerateFloatingKey()
+	_, otherAddress := sigs.GenerateFloatingKey()
+	rpccs := &RPCConsumerServer{}
+	listenEndpoint := &lavasession.RPCEndpoint{NetworkAddress: "127.0.0.1:0", ChainID: "LAV1", ApiInterface: "tendermintrpc"}
+	// fails before touching any of the missing dependencies
+	err := rpccs.ServeRPCRequests(context.Background(), listenEndpoint, nil, nil, nil, nil, 1, consumerKey, "lava", nil, nil, otherAddress, nil, nil, common.ConsumerCmdFlags{}, false, nil, nil)
+	require.ErrorIs(t, err, SigningKeyMismatchError)
+	require.ErrorContains(t, err, otherAddress.String())
+}
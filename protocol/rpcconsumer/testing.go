@@ -43,7 +43,7 @@ func startTesting(ctx context.Context, clientCtx client.Context, rpcEndpoints []
 				return err
 			}
 			chainParser.SetSpec(*specResponse)
-			chainProxy, err := chainlib.GetChainRouter(ctx, parallelConnections, rpcProviderEndpoint, chainParser)
+			chainProxy, err := chainlib.GetChainRouter(ctx, parallelConnections, chainproxy.NodeKeepalive, rpcProviderEndpoint, chainParser)
 			if err != nil {
 				return utils.LavaFormatError("panic severity critical error, failed creating chain proxy, continuing with others endpoints", err, utils.Attribute{Key: "parallelConnections", Value: uint64(parallelConnections)}, utils.Attribute{Key: "rpcProviderEndpoint", Value: rpcProviderEndpoint})
 			}
@@ -0,0 +1,40 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/lavanet/lava/protocol/rpcconsumer"
+
+// relays are traced with a no-op tracer unless one is set, so there is no cost when tracing is disabled
+var noopTracer = trace.NewNoopTracerProvider().Tracer(tracerName)
+
+// SetTracer sets the tracer used for the relay lifecycle spans, nil disables tracing
+func (rpccs *RPCConsumerServer) SetTracer(tracer trace.Tracer) {
+	rpccs.tracer = tracer
+}
+
+func (rpccs *RPCConsumerServer) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := rpccs.tracer
+	if tracer == nil {
+		tracer = noopTracer
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// relays run on fresh contexts so they outlive the user's request, this keeps them under the relay span
+func contextWithParentSpan(ctx context.Context, parentCtx context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, trace.SpanFromContext(parentCtx))
+}
@@ -0,0 +1,42 @@
+package rpcconsumer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type recordingTracer struct {
+	trace.Tracer
+	spans []string
+}
+
+func (rt *recordingTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	rt.spans = append(rt.spans, spanName)
+	return rt.Tracer.Start(ctx, spanName, opts...)
+}
+
+func TestRelayTracing(t *testing.T) {
+	rpccs := &RPCConsumerServer{}
+	// no tracer set, spans are no-ops
+	_, span := rpccs.startSpan(context.Background(), "SendRelay")
+	require.False(t, span.IsRecording())
+	endSpan(span, fmt.Errorf("failed"))
+
+	tracer := &recordingTracer{Tracer: noopTracer}
+	rpccs.SetTracer(tracer)
+	parentCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	}))
+	ctx, span := rpccs.startSpan(parentCtx, "SendRelay")
+	endSpan(span, nil)
+	require.Equal(t, []string{"SendRelay"}, tracer.spans)
+
+	// relays run on a detached context but stay in the same trace
+	relayCtx := contextWithParentSpan(context.Background(), ctx)
+	require.Equal(t, trace.TraceID{1}, trace.SpanFromContext(relayCtx).SpanContext().TraceID())
+}
@@ -0,0 +1,61 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+// connects to the endpoint's trusted fallback nodes, returns nil when none are configured
+func newTrustedFallback(ctx context.Context, listenEndpoint *lavasession.RPCEndpoint, chainParser chainlib.ChainParser) (chainlib.ChainRouter, error) {
+	if len(listenEndpoint.TrustedFallback) == 0 {
+		return nil, nil
+	}
+	fallbackEndpoint := trustedFallbackEndpoint(listenEndpoint)
+	chainRouter, err := chainlib.GetChainRouter(ctx, chainproxy.NumberOfParallelConnections, chainproxy.KeepaliveOptions{}, fallbackEndpoint, chainParser)
+	if err != nil {
+		return nil, err
+	}
+	utils.LavaFormatWarning("trusted fallback is enabled, relays every provider failed are sent to the trusted nodes without the protocol's verification", nil,
+		utils.LogAttr("chainID", listenEndpoint.ChainID),
+		utils.LogAttr("apiInterface", listenEndpoint.ApiInterface),
+		utils.LogAttr("nodeUrls", fallbackEndpoint.UrlsString()),
+	)
+	return chainRouter, nil
+}
+
+func trustedFallbackEndpoint(listenEndpoint *lavasession.RPCEndpoint) *lavasession.RPCProviderEndpoint {
+	return &lavasession.RPCProviderEndpoint{
+		ChainID:      listenEndpoint.ChainID,
+		ApiInterface: listenEndpoint.ApiInterface,
+		Geolocation:  listenEndpoint.Geolocation,
+		NodeUrls:     listenEndpoint.TrustedFallback,
+	}
+}
+
+// relayToTrustedFallback sends the relay to the operator's trusted node after every provider failed. the reply isn't
+// signed by a provider so there's no verification, no QoS and no CU spent, it's marked with the trusted fallback header
+func (rpccs *RPCConsumerServer) relayToTrustedFallback(ctx context.Context, chainMessage chainlib.ChainMessage, relayErrors *RelayErrors) (*common.RelayResult, error) {
+	utils.LavaFormatWarning("all providers failed, relaying to the trusted fallback node, the reply is not verified", nil,
+		utils.LogAttr("GUID", ctx),
+		utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID),
+		utils.LogAttr("api", chainMessage.GetApi().Name),
+		utils.LogAttr("providerErrors", len(relayErrors.relayErrors)),
+	)
+	reply, _, _, _, _, err := rpccs.trustedFallback.SendNodeMsg(ctx, nil, chainMessage, common.GetExtensionNames(chainMessage.GetExtensions()))
+	if err != nil {
+		return nil, utils.LavaFormatError("trusted fallback relay failed", err, utils.LogAttr("GUID", ctx), utils.LogAttr("chainID", rpccs.listenEndpoint.ChainID))
+	}
+	if reply == nil {
+		reply = &pairingtypes.RelayReply{}
+	}
+	reply.Metadata, _, _ = rpccs.chainParser.HandleHeaders(reply.Metadata, chainMessage.GetApiCollection(), spectypes.Header_pass_reply)
+	reply.Metadata = append(reply.Metadata, pairingtypes.Metadata{Name: common.TRUSTED_FALLBACK_HEADER_NAME, Value: "true"})
+	return &common.RelayResult{Reply: reply, Finalized: false}, nil
+}
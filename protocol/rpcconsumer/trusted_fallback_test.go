@@ -0,0 +1,139 @@
+package rpcconsumer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/lavanet/lava/utils/sigs"
+	conflicttypes "github.com/lavanet/lava/x/conflict/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	plantypes "github.com/lavanet/lava/x/plans/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTrustedNode struct {
+	relays int
+}
+
+func (mtn *mockTrustedNode) SendNodeMsg(ctx context.Context, ch chan interface{}, chainMessage chainlib.ChainMessageForSend, extensions []string) (*pairingtypes.RelayReply, string, *rpcclient.ClientSubscription, common.NodeUrl, string, error) {
+	mtn.relays++
+	return &pairingtypes.RelayReply{Data: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`)}, "", nil, common.NodeUrl{}, "", nil
+}
+
+func (mtn *mockTrustedNode) ExtensionsSupported([]string) bool {
+	return true
+}
+
+type mockConsumerTxSender struct{}
+
+func (mockConsumerTxSender) TxConflictDetection(ctx context.Context, finalizationConflict *conflicttypes.FinalizationConflict, responseConflict *conflicttypes.ResponseConflict, sameProviderConflict *conflicttypes.FinalizationConflict, conflictHandler common.ConflictHandlerInterface) error {
+	return nil
+}
+
+func (mockConsumerTxSender) GetConsumerPolicy(ctx context.Context, consumerAddress, chainID string) (*plantypes.Policy, error) {
+	return &plantypes.Policy{}, nil
+}
+
+func (mockConsumerTxSender) GetLatestVirtualEpoch() uint64 {
+	return 0
+}
+
+func TestTrustedFallback(t *testing.T) {
+	ctx := context.Background()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	listenEndpoint := &lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC}
+	// no provider is paired so every relay exhausts the providers
+	csm := lavasession.NewConsumerSessionManager(listenEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	rpccs := &RPCConsumerServer{
+		chainParser:            chainParser,
+		consumerSessionManager: csm,
+		listenEndpoint:         listenEndpoint,
+		finalizationConsensus:  lavaprotocol.NewFinalizationConsensus("ETH1"),
+		consumerConsistency:    NewConsumerConsistency("ETH1"),
+		consumerTxSender:       mockConsumerTxSender{},
+	}
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+
+	// disabled by default
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.Error(t, err)
+
+	trustedNode := &mockTrustedNode{}
+	rpccs.trustedFallback = trustedNode
+	relayResult, err := rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, trustedNode.relays)
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`, string(relayResult.GetReply().Data))
+	require.Contains(t, relayResult.GetReply().Metadata, pairingtypes.Metadata{Name: common.TRUSTED_FALLBACK_HEADER_NAME, Value: "true"})
+	require.Empty(t, relayResult.GetProvider())
+
+	// too few usable providers falls back instead of rejecting the relay
+	defer func(minUsableProviders uint64) { lavasession.MinUsableProviders = minUsableProviders }(lavasession.MinUsableProviders)
+	lavasession.MinUsableProviders = 1
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, trustedNode.relays)
+	rpccs.trustedFallback = nil
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.ErrorIs(t, err, lavasession.ErrInsufficientProviders)
+	rpccs.trustedFallback = trustedNode
+	lavasession.MinUsableProviders = 0
+
+	// relays rejected before reaching the providers don't fall back
+	rpccs.methodFilter, err = chainlib.NewMethodFilter(nil, []string{"eth_blockNumber"})
+	require.NoError(t, err)
+	_, err = rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+	require.True(t, chainlib.MethodDisabledError.Is(err))
+	require.Equal(t, 2, trustedNode.relays)
+}
+
+func TestTrustedFallbackPairedProviders(t *testing.T) {
+	rand.InitRandomSeed()
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	spec.DataReliabilityEnabled = false // the mock provider doesn't sign finalization data
+	ctx := context.Background()
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+
+	t.Run("every provider exhausted", func(t *testing.T) {
+		consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+		_, providerAddress := sigs.GenerateFloatingKey()
+		rpccs := newTestConsumer(t, spec, &failingRelayer{}, providerAddress, consumerKey, consumerAddress)
+		trustedNode := &mockTrustedNode{}
+		rpccs.trustedFallback = trustedNode
+		relayResult, err := rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, trustedNode.relays)
+		require.Contains(t, relayResult.GetReply().Metadata, pairingtypes.Metadata{Name: common.TRUSTED_FALLBACK_HEADER_NAME, Value: "true"})
+	})
+
+	t.Run("a provider success prevents the fallback", func(t *testing.T) {
+		consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+		providerKey, providerAddress := sigs.GenerateFloatingKey()
+		relayer := &mockRelayer{consumerAddress: consumerAddress, privKey: providerKey, reply: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`)}
+		rpccs := newTestConsumer(t, spec, relayer, providerAddress, consumerKey, consumerAddress)
+		trustedNode := &mockTrustedNode{}
+		rpccs.trustedFallback = trustedNode
+		relayResult, err := rpccs.SendRelay(ctx, "", req, http.MethodPost, "dapp", "127.0.0.1", nil, nil)
+		require.NoError(t, err)
+		require.Zero(t, trustedNode.relays)
+		require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"0x64"}`, string(relayResult.GetReply().Data))
+		require.Equal(t, providerAddress.String(), relayResult.GetProvider())
+		require.NotContains(t, relayResult.GetReply().Metadata, pairingtypes.Metadata{Name: common.TRUSTED_FALLBACK_HEADER_NAME, Value: "true"})
+	})
+}
@@ -0,0 +1,175 @@
+// Package devprovider is for local development only, it serves relays from canned replies without a node, a provider
+// stake or a chain, so dApps and the consumer can be exercised on a laptop. never run it against a real network.
+package devprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+
+	sdkerrors "cosmossdk.io/errors"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcclient"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// jsonrpc error code of methods without a canned reply
+const MethodNotFoundCode = -32601
+
+var UnsupportedDevRequestError = sdkerrors.New("UnsupportedDevRequest Error", 4501, "the dev provider only serves single jsonrpc requests")
+
+// Replies maps jsonrpc methods to the result returned for them
+type Replies map[string]json.RawMessage
+
+// reply returns the jsonrpc reply to a request, methods without a canned result get a method not found error
+func (r Replies) reply(data []byte) ([]byte, error) {
+	var request rpcInterfaceMessages.JsonrpcMessage
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, sdkerrors.Wrap(UnsupportedDevRequestError, err.Error())
+	}
+	return r.replyTo(&request)
+}
+
+func (r Replies) replyTo(request *rpcInterfaceMessages.JsonrpcMessage) ([]byte, error) {
+	reply := rpcInterfaceMessages.JsonrpcMessage{Version: "2.0", ID: request.ID}
+	if result, ok := r[request.Method]; ok {
+		reply.Result = result
+	} else {
+		reply.Error = &rpcclient.JsonError{Code: MethodNotFoundCode, Message: "the method " + request.Method + " does not exist/is not available"}
+	}
+	return json.Marshal(reply)
+}
+
+// ChainProxy answers node messages from canned replies, it stands in for a node behind a provider
+type ChainProxy struct {
+	Replies Replies
+}
+
+var _ chainlib.ChainProxy = (*ChainProxy)(nil)
+
+func (cp *ChainProxy) GetChainProxyInformation() (common.NodeUrl, string) {
+	return common.NodeUrl{Url: "devprovider"}, ""
+}
+
+func (cp *ChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{}, chainMessage chainlib.ChainMessageForSend) (relayReply *pairingtypes.RelayReply, subscriptionID string, relayReplyServer *rpcclient.ClientSubscription, err error) {
+	if ch != nil {
+		return nil, "", nil, sdkerrors.Wrap(UnsupportedDevRequestError, "subscriptions are not supported")
+	}
+	request, ok := chainMessage.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+	if !ok {
+		return nil, "", nil, sdkerrors.Wrapf(UnsupportedDevRequestError, "message type %T", chainMessage.GetRPCMessage())
+	}
+	data, err := cp.Replies.replyTo(request)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return &pairingtypes.RelayReply{Data: data}, "", nil, nil
+}
+
+// Provider is a relayer answering relays from canned replies signed with a fixed key, consumers verify them like
+// replies of a staked provider
+type Provider struct {
+	pairingtypes.UnimplementedRelayerServer
+	privKey                       *btcec.PrivateKey
+	address                       sdk.AccAddress
+	chainProxy                    *ChainProxy
+	latestBlock                   int64
+	blockDistanceForFinalizedData uint32
+	blocksInFinalizationProof     uint32
+}
+
+func NewProvider(privKey *btcec.PrivateKey, replies Replies, latestBlock int64) *Provider {
+	return &Provider{
+		privKey:     privKey,
+		address:     sdk.AccAddress((&secp256k1.PubKey{Key: privKey.PubKey().SerializeCompressed()}).Address()),
+		chainProxy:  &ChainProxy{Replies: replies},
+		latestBlock: latestBlock,
+	}
+}
+
+// SetFinalization makes replies carry signed finalization data for the spec's finalization params, consumers of specs
+// with data reliability enabled reject replies without it. the finalized hashes are made up
+func (p *Provider) SetFinalization(blockDistanceForFinalizedData, blocksInFinalizationProof uint32) {
+	p.blockDistanceForFinalizedData = blockDistanceForFinalizedData
+	p.blocksInFinalizationProof = blocksInFinalizationProof
+}
+
+// returns made up hashes of the finalized blocks closest to the latest block
+func (p *Provider) finalizedBlocksHashes() ([]byte, error) {
+	finalizedBlocks := map[int64]string{}
+	latestFinalizedBlock := p.latestBlock - int64(p.blockDistanceForFinalizedData)
+	for blockNum := latestFinalizedBlock - int64(p.blocksInFinalizationProof) + 1; blockNum <= latestFinalizedBlock; blockNum++ {
+		if blockNum >= 0 {
+			finalizedBlocks[blockNum] = fmt.Sprintf("0x%064x", blockNum)
+		}
+	}
+	return json.Marshal(finalizedBlocks)
+}
+
+// Address is the lava address consumers expect the replies to be signed by
+func (p *Provider) Address() sdk.AccAddress {
+	return p.address
+}
+
+func (p *Provider) Relay(ctx context.Context, request *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	if request.RelayData == nil || request.RelaySession == nil {
+		return nil, sdkerrors.Wrap(UnsupportedDevRequestError, "missing relay data")
+	}
+	consumerAddress, err := sigs.ExtractSignerAddress(request.RelaySession)
+	if err != nil {
+		return nil, err
+	}
+	data, err := p.chainProxy.Replies.reply(request.RelayData.Data)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pairingtypes.RelayReply{Data: data, LatestBlock: p.latestBlock}
+	signFinalization := p.blocksInFinalizationProof > 0
+	if signFinalization {
+		reply.FinalizedBlocksHashes, err = p.finalizedBlocksHashes()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lavaprotocol.SignRelayResponse(consumerAddress, *request, p.privKey, reply, signFinalization)
+}
+
+func (p *Provider) Probe(ctx context.Context, probeReq *pairingtypes.ProbeRequest) (*pairingtypes.ProbeReply, error) {
+	return &pairingtypes.ProbeReply{Guid: probeReq.Guid, LatestBlock: p.latestBlock}, nil
+}
+
+// Serve serves relays on the listener with a self signed certificate until ctx is done, consumers need
+// --allow-insecure-provider-dialing to connect
+func (p *Provider) Serve(ctx context.Context, listener net.Listener) error {
+	tlsConfig, err := lavasession.GetSelfSignedConfig()
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	pairingtypes.RegisterRelayerServer(server, p)
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+	utils.LavaFormatWarning("dev provider serving canned replies, for local development only", nil, utils.LogAttr("address", listener.Addr().String()), utils.LogAttr("provider", p.address.String()))
+	return server.Serve(listener)
+}
+
+// Pairing returns the provider as a consumer's pairing entry, for ConsumerSessionManager.UpdateAllProviders
+func (p *Provider) Pairing(networkAddress string, epoch uint64) *lavasession.ConsumerSessionsWithProvider {
+	endpoints := []*lavasession.Endpoint{{NetworkAddress: networkAddress, Enabled: true, Addons: map[string]struct{}{}, Extensions: map[string]struct{}{}}}
+	return lavasession.NewConsumerSessionWithProvider(p.address.String(), endpoints, math.MaxUint64/2, epoch, sdk.NewInt64Coin("ulava", 1))
+}
@@ -0,0 +1,53 @@
+package devprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainProxy(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../../", nil, nil)
+	require.NoError(t, err)
+	chainParser, err := chainlib.NewChainParser(spectypes.APIInterfaceJsonRPC)
+	require.NoError(t, err)
+	chainParser.SetSpec(spec)
+	chainProxy := &ChainProxy{Replies: Replies{"eth_blockNumber": json.RawMessage(`"0x64"`)}}
+	sendNodeMsg := func(request string) string {
+		chainMessage, err := chainParser.ParseMsg("", []byte(request), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+		require.NoError(t, err)
+		reply, _, _, err := chainProxy.SendNodeMsg(context.Background(), nil, chainMessage)
+		require.NoError(t, err)
+		return string(reply.Data)
+	}
+
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":7,"result":"0x64"}`, sendNodeMsg(`{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber","params":[]}`))
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":"a","error":{"code":-32601,"message":"the method eth_chainId does not exist/is not available"}}`, sendNodeMsg(`{"jsonrpc":"2.0","id":"a","method":"eth_chainId","params":[]}`))
+
+	// subscriptions aren't served
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+	require.NoError(t, err)
+	_, _, _, err = chainProxy.SendNodeMsg(context.Background(), make(chan interface{}), chainMessage)
+	require.True(t, UnsupportedDevRequestError.Is(err))
+}
+
+func TestFinalizedBlocksHashes(t *testing.T) {
+	provider := &Provider{latestBlock: 100}
+	provider.SetFinalization(7, 3)
+	data, err := provider.finalizedBlocksHashes()
+	require.NoError(t, err)
+	finalizedBlocks := map[int64]string{}
+	require.NoError(t, json.Unmarshal(data, &finalizedBlocks))
+	require.Len(t, finalizedBlocks, 3)
+	for blockNum := int64(91); blockNum <= 93; blockNum++ {
+		require.Contains(t, finalizedBlocks, blockNum)
+		require.True(t, chainlib.IsBlockHash(finalizedBlocks[blockNum]))
+	}
+}
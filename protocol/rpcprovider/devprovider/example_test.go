@@ -0,0 +1,69 @@
+package devprovider_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/lavanet/lava/protocol/rpcprovider/devprovider"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/lavanet/lava/utils/sigs"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+// a local relay round trip: the consumer session manager is paired with a dev provider, and its signed reply is
+// verified like a staked provider's
+func ExampleProvider() {
+	rand.InitRandomSeed()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	providerKey, _ := sigs.GenerateFloatingKey()
+	provider := devprovider.NewProvider(providerKey, devprovider.Replies{"eth_blockNumber": json.RawMessage(`"0x64"`)}, 100)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	go provider.Serve(ctx, listener)
+
+	// the dev provider uses a self signed certificate
+	lavasession.AllowInsecureConnectionToProviders = true
+	epoch := uint64(20)
+	rpcEndpoint := &lavasession.RPCEndpoint{ChainID: "ETH1", ApiInterface: spectypes.APIInterfaceJsonRPC}
+	csm := lavasession.NewConsumerSessionManager(rpcEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, time.Second, time.Millisecond, 1), nil, nil)
+	err = csm.UpdateAllProviders(epoch, map[uint64]*lavasession.ConsumerSessionsWithProvider{0: provider.Pairing(listener.Addr().String(), epoch)})
+	if err != nil {
+		panic(err)
+	}
+
+	consumerKey, _ := sigs.GenerateFloatingKey()
+	sessions, err := csm.GetSessions(ctx, 10, nil, spectypes.LATEST_BLOCK, "", nil, common.NOSTATE, 0)
+	if err != nil {
+		panic(err)
+	}
+	for providerAddress, sessionInfo := range sessions {
+		relayData := lavaprotocol.NewRelayData(ctx, http.MethodPost, "", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`), 0, spectypes.LATEST_BLOCK, spectypes.APIInterfaceJsonRPC, nil, "", nil)
+		relayRequest, err := lavaprotocol.ConstructRelayRequest(ctx, consumerKey, "lava", rpcEndpoint.ChainID, relayData, providerAddress, sessionInfo.Session, int64(epoch), nil)
+		if err != nil {
+			panic(err)
+		}
+		reply, err := (*sessionInfo.Session.Endpoint.Client).Relay(ctx, relayRequest)
+		if err != nil {
+			panic(err)
+		}
+		// a latest block request is signed for the block the provider replied at
+		lavaprotocol.UpdateRequestedBlock(relayRequest.RelayData, reply)
+		if err := lavaprotocol.VerifyRelayReply(ctx, reply, relayRequest, providerAddress); err != nil {
+			panic(err)
+		}
+		fmt.Println(string(reply.Data))
+	}
+	// Output: {"jsonrpc":"2.0","id":1,"result":"0x64"}
+}
@@ -62,7 +62,7 @@ func NewProviderListener(ctx context.Context, networkAddress lavasession.Network
 	// GRPC
 	lis := chainlib.GetListenerWithRetryGrpc("tcp", networkAddress.Address)
 	serverReceiveMaxMessageSize := grpc.MaxRecvMsgSize(1024 * 1024 * 32) // setting receive size to 32mb instead of 4mb default
-	grpcServer := grpc.NewServer(serverReceiveMaxMessageSize)
+	grpcServer := grpc.NewServer(append([]grpc.ServerOption{serverReceiveMaxMessageSize}, lavasession.RelayServerKeepaliveOptions()...)...)
 
 	wrappedServer := grpcweb.WrapServer(grpcServer)
 	handler := func(resp http.ResponseWriter, req *http.Request) {
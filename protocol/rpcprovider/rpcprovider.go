@@ -21,6 +21,7 @@ import (
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy"
 	"github.com/lavanet/lava/protocol/chaintracker"
 	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/protocol/metrics"
 	"github.com/lavanet/lava/protocol/performance"
@@ -334,6 +335,9 @@ func (rpcp *RPCProvider) SetupEndpoint(ctx context.Context, rpcProviderEndpoint
 	if err != nil {
 		return utils.LavaFormatError("[PANIC] panic severity critical error, aborting support for chain api due to invalid chain parser, continuing with others", err, utils.Attribute{Key: "endpoint", Value: rpcProviderEndpoint.String()})
 	}
+	if jsonRPCChainParser, ok := chainParser.(*chainlib.JsonRPCChainParser); ok && len(rpcProviderEndpoint.MethodAliases) > 0 {
+		jsonRPCChainParser.SetMethodAliases(rpcProviderEndpoint.MethodAliases)
+	}
 
 	rpcEndpoint := lavasession.RPCEndpoint{ChainID: chainID, ApiInterface: apiInterface}
 	err = rpcp.providerStateTracker.RegisterForSpecUpdates(ctx, chainParser, rpcEndpoint)
@@ -348,7 +352,7 @@ func (rpcp *RPCProvider) SetupEndpoint(ctx context.Context, rpcProviderEndpoint
 		utils.LogAttr("apiInterface", apiInterface),
 		utils.LogAttr("supportedServices", providerPolicy.addons))
 	chainParser.SetPolicy(providerPolicy, rpcProviderEndpoint.ChainID, apiInterface)
-	chainRouter, err := chainlib.GetChainRouter(ctx, rpcp.parallelConnections, rpcProviderEndpoint, chainParser)
+	chainRouter, err := chainlib.GetChainRouter(ctx, rpcp.parallelConnections, chainproxy.NodeKeepalive, rpcProviderEndpoint, chainParser)
 	if err != nil {
 		return utils.LavaFormatError("[PANIC] panic severity critical error, failed creating chain proxy, continuing with others endpoints", err, utils.Attribute{Key: "parallelConnections", Value: uint64(rpcp.parallelConnections)}, utils.Attribute{Key: "rpcProviderEndpoint", Value: rpcProviderEndpoint})
 	}
@@ -721,6 +725,21 @@ rpcprovider 127.0.0.1:3333 COS3 tendermintrpc "wss://www.node-path.com:80,https:
 	cmdRPCProvider.Flags().Bool(common.RelaysHealthEnableFlag, true, "enables relays health check")
 	cmdRPCProvider.Flags().Duration(common.RelayHealthIntervalFlag, RelayHealthIntervalFlagDefault, "interval between relay health checks")
 	cmdRPCProvider.Flags().String(HealthCheckURLPathFlagName, HealthCheckURLPathFlagDefault, "the url path for the provider's grpc health check")
+	cmdRPCProvider.Flags().Int64Var(&chainlib.MaxRestResponseSize, chainlib.MaxRestResponseSizeFlagName, chainlib.MaxRestResponseSize, "rest node responses larger than this many bytes are rejected instead of buffered")
+	cmdRPCProvider.Flags().BoolVar(&chainlib.RestGzipResponses, chainlib.RestGzipResponsesFlagName, chainlib.RestGzipResponses, "ask rest nodes for gzip compressed responses, they are decompressed before being signed")
+	cmdRPCProvider.Flags().BoolVar(&lavaprotocol.SignReplyCommitments, lavaprotocol.SignReplyCommitmentsFlag, lavaprotocol.SignReplyCommitments, "sign a commitment to the reply data instead of the data itself for consumers asking for it")
+	cmdRPCProvider.Flags().DurationVar(&lavasession.RelayKeepaliveMinInterval, lavasession.RelayKeepaliveMinIntervalFlag, lavasession.DefaultRelayKeepaliveMinInterval, "minimal keepalive ping interval accepted from consumers, consumers pinging more often are disconnected")
+	cmdRPCProvider.Flags().DurationVar(&chainproxy.NodeKeepalive.Interval, chainproxy.NodeKeepaliveIntervalFlag, 0, "keepalive ping interval on idle node connections, each of the parallel connections is pinged on its own, 0 disables the pings")
+	cmdRPCProvider.Flags().DurationVar(&chainproxy.NodeKeepalive.Timeout, chainproxy.NodeKeepaliveTimeoutFlag, 20*time.Second, "grpc node connections not answering a keepalive ping within this duration are closed")
+	cmdRPCProvider.Flags().DurationVar(&chainproxy.NodeKeepalive.MaxIdle, chainproxy.NodeMaxIdleFlag, 0, "node connections without requests for this long are closed and reconnect lazily, 0 keeps the default")
+	cmdRPCProvider.Flags().BoolVar(&chainproxy.WeightedNodeConnections, chainproxy.WeightedNodeConnectionsFlag, false, "spread requests over the node connections by their static connection-weights and the node's observed response latency instead of always using the first free one")
+	cmdRPCProvider.Flags().BoolVar(&ServeSimulatedRelays, ServeSimulatedRelaysFlag, false, "serve relays consumers mark as simulated without charging them, the relays are not settled")
+	cmdRPCProvider.Flags().IntVar(&common.NodeMaxRedirects, common.NodeMaxRedirectsFlag, common.NodeMaxRedirects, "redirects followed on a request to a node, redirects to other hosts than the node url's need its redirect-hosts, 0 fails every redirect")
+	cmdRPCProvider.Flags().StringVar(&common.OutboundProxy, common.OutboundProxyFlag, common.OutboundProxy, "socks5:// or http:// proxy connections to the nodes go through, a node url's proxy overrides it, when empty HTTPS_PROXY, HTTP_PROXY and NO_PROXY are used")
+	cmdRPCProvider.Flags().IntVar(&chainlib.SubscriptionBufferSize, chainlib.SubscriptionBufferSizeFlagName, chainlib.SubscriptionBufferSize, "subscription events buffered for a consumer reading slower than the node pushes them, past that the backpressure policy applies")
+	cmdRPCProvider.Flags().Var(&chainlib.SubscriptionBackpressure, chainlib.SubscriptionBackpressureFlagName, fmt.Sprintf("what happens when a subscription's buffer is full: %s drops the oldest events and sends a %s notification in their place, %s ends the subscription", chainlib.SubscriptionDropOldest, chainlib.SubscriptionGapMethod, chainlib.SubscriptionDisconnect))
+	cmdRPCProvider.Flags().BoolVar(&chainlib.GrpcNodeCompression, chainlib.GrpcNodeCompressionFlagName, chainlib.GrpcNodeCompression, "compress calls to grpc nodes and receive compressed replies, nodes that don't support it are called uncompressed")
+	cmdRPCProvider.Flags().BoolVar(&chainlib.GrpcReflectionDiscovery, chainlib.GrpcReflectionDiscoveryFlagName, false, "on startup, list the grpc node's methods using reflection and warn on mismatches with the spec")
 	cmdRPCProvider.Flags().DurationVar(&updaters.TimeOutForFetchingLavaBlocks, common.TimeOutForFetchingLavaBlocksFlag, time.Second*5, "setting the timeout for fetching lava blocks")
 
 	common.AddRollingLogConfig(cmdRPCProvider)
@@ -42,6 +42,11 @@ const (
 
 var RPCProviderStickinessHeaderName = "X-Node-Sticky"
 
+const ServeSimulatedRelaysFlag = "serve-simulated-relays"
+
+// serve relays the consumer marked as simulated without settling them, for canary and monitoring traffic
+var ServeSimulatedRelays = false
+
 type RPCProviderServer struct {
 	cache                     *performance.Cache
 	chainRouter               chainlib.ChainRouter
@@ -206,6 +211,12 @@ func (rpcps *RPCProviderServer) Relay(ctx context.Context, request *pairingtypes
 			utils.Attribute{Key: "timed_out", Value: common.ContextOutOfTime(ctx)},
 		)
 		go rpcps.metrics.AddError()
+	} else if ServeSimulatedRelays && lavaprotocol.IsSimulatedRelay(request.RelayData.Metadata) {
+		// served but not settled, no proof is kept for it
+		relayError := rpcps.providerSessionManager.OnSimulatedSessionDone(relaySession)
+		if relayError != nil {
+			utils.LavaFormatError("OnSimulatedSessionDone failure: ", relayError)
+		}
 	} else {
 		// On successful relay
 		pairingEpoch := relaySession.PairingEpoch
@@ -258,8 +269,12 @@ func (rpcps *RPCProviderServer) initRelay(ctx context.Context, request *pairingt
 	if extensionInfo.ExtensionOverride == nil { // in case consumer did not set an extension, we skip the extension parsing and we are sending it to the regular url
 		extensionInfo.ExtensionOverride = []string{}
 	}
+	payload, err := chainlib.GetPayloadCodec(request.RelayData.ApiInterface).Decode(request.RelayData.Data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	// parse the message to extract the cu and chainMessage for sending it
-	chainMessage, err = rpcps.chainParser.ParseMsg(request.RelayData.ApiUrl, request.RelayData.Data, request.RelayData.ConnectionType, request.RelayData.GetMetadata(), extensionInfo)
+	chainMessage, err = rpcps.chainParser.ParseMsg(request.RelayData.ApiUrl, payload, request.RelayData.ConnectionType, request.RelayData.GetMetadata(), extensionInfo)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -267,9 +282,15 @@ func (rpcps *RPCProviderServer) initRelay(ctx context.Context, request *pairingt
 	virtualEpoch := rpcps.stateTracker.GetVirtualEpoch(uint64(request.RelaySession.Epoch))
 	err = relaySession.PrepareSessionForUsage(ctx, relayCU, request.RelaySession.CuSum, rpcps.allowedMissingCUThreshold, virtualEpoch)
 	if err != nil {
+		if lavasession.MaximumCULimitReachedByConsumer.Is(err) {
+			// not a sync loss, the consumer can't use this provider until the next epoch
+			return nil, nil, nil, utils.LavaFormatWarning("consumer exhausted its compute units for the epoch", err, utils.Attribute{Key: "GUID", Value: ctx})
+		}
 		// If PrepareSessionForUsage, session lose sync.
 		// We then wrap the error with the SessionOutOfSyncError that has a unique error code.
 		// The consumer knows the session lost sync using the code and will create a new session.
+		// Our view of the session lets the consumer resync to it when the drift is small.
+		lavasession.SetSessionSyncTrailer(ctx, relaySession.CuSum, relaySession.RelayNum)
 		return nil, nil, nil, utils.LavaFormatError("Session Out of sync", lavasession.SessionOutOfSyncError, utils.Attribute{Key: "PrepareSessionForUsage_Error", Value: err.Error()}, utils.Attribute{Key: "GUID", Value: ctx})
 	}
 	return relaySession, consumerAddress, chainMessage, nil
@@ -309,6 +330,9 @@ func (rpcps *RPCProviderServer) ValidateRequest(chainMessage chainlib.ChainMessa
 		chainMessage.UpdateLatestBlockInMessage(request.RelayData.RequestBlock, true)
 		// if after UpdateLatestBlockInMessage it's not aligned we have a problem
 		reqBlock, _ = chainMessage.RequestedBlock()
+		if reqBlock != request.RelayData.RequestBlock && consumerResolvedBlockHash(chainMessage, providerRequestedBlockPreUpdate, request.RelayData.RequestBlock) {
+			return nil
+		}
 		if reqBlock != request.RelayData.RequestBlock {
 			utils.LavaFormatDebug("requested block mismatch between consumer and provider",
 				utils.LogAttr("request data", request.RelayData.Data),
@@ -324,6 +348,19 @@ func (rpcps *RPCProviderServer) ValidateRequest(chainMessage chainlib.ChainMessa
 	return nil
 }
 
+// consumers place requests by block hash at the block number of a finalized hash they know, the provider has no such
+// lookup and parses them as latest. the node serves them by the hash, so the consumer's number is accepted as is
+func consumerResolvedBlockHash(chainMessage chainlib.ChainMessage, providerRequestedBlock int64, consumerRequestedBlock int64) bool {
+	if providerRequestedBlock != spectypes.LATEST_BLOCK && providerRequestedBlock != spectypes.NOT_APPLICABLE {
+		return false
+	}
+	if consumerRequestedBlock < 0 {
+		return false
+	}
+	_, ok := chainlib.MessageRequestedBlockHash(chainMessage)
+	return ok
+}
+
 func (rpcps *RPCProviderServer) RelaySubscribe(request *pairingtypes.RelayRequest, srv pairingtypes.Relayer_RelaySubscribeServer) error {
 	if request.RelayData == nil || request.RelaySession == nil {
 		return utils.LavaFormatError("invalid relay subscribe request, internal fields are nil", nil)
@@ -409,6 +446,10 @@ func (rpcps *RPCProviderServer) TryRelaySubscribe(ctx context.Context, requestBl
 	}
 	rpcps.rewardServer.SubscribeStarted(consumerAddress.String(), requestBlockHeight, subscriptionID)
 	processSubscribeMessages := func() (subscribed bool, errRet error) {
+		// the node's events are buffered here and not in the node client, so a slow consumer is handled by the backpressure policy
+		bufferCtx, cancelBuffer := context.WithCancel(ctx)
+		defer cancelBuffer()
+		bufferedReplies, overflow := chainlib.BufferSubscription(bufferCtx, subscribeRepliesChan, chainlib.SubscriptionBufferSize, chainlib.SubscriptionBackpressure)
 		err = srv.Send(reply) // this reply contains the RPC ID
 		if err != nil {
 			utils.LavaFormatError("Error getting RPC ID", err, utils.Attribute{Key: "GUID", Value: ctx})
@@ -423,7 +464,21 @@ func (rpcps *RPCProviderServer) TryRelaySubscribe(ctx context.Context, requestBl
 				// delete this connection from the subs map
 
 				return subscribed, err
-			case subscribeReply := <-subscribeRepliesChan:
+			case err := <-overflow:
+				return subscribed, utils.LavaFormatWarning("disconnecting slow subscriber", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.LogAttr("subscriptionID", subscriptionID))
+			case subscribeReply, ok := <-bufferedReplies:
+				if !ok {
+					// the buffer closes its replies right after reporting an overflow, so both can be ready
+					select {
+					case err := <-overflow:
+						return subscribed, utils.LavaFormatWarning("disconnecting slow subscriber", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.LogAttr("subscriptionID", subscriptionID))
+					default:
+					}
+					return subscribed, utils.LavaFormatWarning("subscription ended", ctx.Err(), utils.Attribute{Key: "GUID", Value: ctx})
+				}
+				if gap, ok := subscribeReply.(*chainlib.SubscriptionGap); ok {
+					utils.LavaFormatDebug("dropped events of a slow subscriber", utils.Attribute{Key: "GUID", Value: ctx}, utils.LogAttr("dropped", gap.Dropped))
+				}
 				data, err := json.Marshal(subscribeReply)
 				if err != nil {
 					return subscribed, utils.LavaFormatError("client sub unmarshal", err, utils.Attribute{Key: "GUID", Value: ctx})
@@ -643,6 +698,8 @@ func (rpcps *RPCProviderServer) handleRelayErrorStatus(err error) error {
 		err = status.Error(codes.Code(lavasession.SessionOutOfSyncError.ABCICode()), err.Error())
 	} else if lavasession.EpochMismatchError.Is(err) {
 		err = status.Error(codes.Code(lavasession.EpochMismatchError.ABCICode()), err.Error())
+	} else if lavasession.MaximumCULimitReachedByConsumer.Is(err) {
+		err = status.Error(codes.Code(lavasession.MaximumCULimitReachedByConsumer.ABCICode()), err.Error())
 	}
 	return err
 }
@@ -720,7 +777,9 @@ func (rpcps *RPCProviderServer) TryRelay(ctx context.Context, request *pairingty
 	var reply *pairingtypes.RelayReply = nil
 	var err error = nil
 	ignoredMetadata := []pairingtypes.Metadata{}
-	if requestedBlockHash != nil || finalized {
+	// notifications have side effects and no reply, the cache key doesn't include the id so they would collide with requests
+	cacheable := !chainlib.IsNotification(chainMsg)
+	if cacheable && (requestedBlockHash != nil || finalized) {
 		var cacheReply *pairingtypes.CacheRelayReply
 
 		hashKey, outPutFormatter, hashErr := chainlib.HashCacheRequest(request.RelayData, rpcps.rpcProviderEndpoint.ChainID)
@@ -768,7 +827,7 @@ func (rpcps *RPCProviderServer) TryRelay(ctx context.Context, request *pairingty
 		}
 		reply.Metadata, _, ignoredMetadata = rpcps.chainParser.HandleHeaders(reply.Metadata, chainMsg.GetApiCollection(), spectypes.Header_pass_reply)
 		// TODO: use overwriteReqBlock on the reply metadata to set the correct latest block
-		if cache.CacheActive() && (requestedBlockHash != nil || finalized) {
+		if cache.CacheActive() && cacheable && (requestedBlockHash != nil || finalized) {
 			// copy request and reply as they change later on and we call SetEntry in a routine.
 			requestedBlock := request.RelayData.RequestBlock                                                       // get requested block before removing it from the data
 			hashKey, _, hashErr := chainlib.HashCacheRequest(request.RelayData, rpcps.rpcProviderEndpoint.ChainID) // get the hash (this changes the data)
@@ -840,10 +899,19 @@ func (rpcps *RPCProviderServer) TryRelay(ctx context.Context, request *pairingty
 		reply.LatestBlock = proofBlock
 	}
 	// utils.LavaFormatDebug("response signing", utils.LogAttr("request block", request.RelayData.RequestBlock), utils.LogAttr("GUID", ctx), utils.LogAttr("latestBlock", reply.LatestBlock))
-	reply, err = lavaprotocol.SignRelayResponse(consumerAddr, *request, rpcps.privKey, reply, dataReliabilityEnabled)
+	if ServeSimulatedRelays && lavaprotocol.IsSimulatedRelay(request.RelayData.Metadata) {
+		// signed, the consumer only skips settling the relay when it's acknowledged
+		reply.Metadata = append(reply.Metadata, lavaprotocol.SimulatedRelayMetadata)
+	}
+	reply.SpecVersion = rpcps.specVersionSignal(ctx)
+	replyCommitment := requestedReplyCommitment(ctx)
+	reply, err = lavaprotocol.SignRelayResponseWithCommitment(consumerAddr, *request, rpcps.privKey, reply, dataReliabilityEnabled, replyCommitment)
 	if err != nil {
 		return nil, err
 	}
+	if replyCommitment != "" {
+		grpc.SetTrailer(ctx, metadata.Pairs(common.ReplyCommitmentMetadataKey, replyCommitment)) // we ignore this error here since this code can be triggered not from grpc
+	}
 	reply.Metadata = append(reply.Metadata, ignoredMetadata...) // appended here only after signing
 	// return reply to user
 	return reply, nil
@@ -1034,6 +1102,16 @@ func (rpcps *RPCProviderServer) Probe(ctx context.Context, probeReq *pairingtype
 		LavaLatestBlock:       uint64(rpcps.stateTracker.LatestBlock()),
 	}
 	trailer := metadata.Pairs(common.VersionMetadataKey, upgrade.GetCurrentVersion().ProviderVersion)
+	if rpcps.rpcProviderEndpoint != nil && len(rpcps.rpcProviderEndpoint.SupportedApis) > 0 {
+		// consumers skip this provider for the apis it doesn't list
+		trailer.Append(common.SupportedApisMetadataKey, rpcps.rpcProviderEndpoint.SupportedApis...)
+	}
+	if rpcps.rpcProviderEndpoint != nil && rpcps.rpcProviderEndpoint.MaxRequestSize > 0 {
+		trailer.Append(common.MaxRequestSizeMetadataKey, strconv.FormatUint(rpcps.rpcProviderEndpoint.MaxRequestSize, 10))
+	}
+	if rpcps.rpcProviderEndpoint != nil && rpcps.rpcProviderEndpoint.MaxResponseSize > 0 {
+		trailer.Append(common.MaxResponseSizeMetadataKey, strconv.FormatUint(rpcps.rpcProviderEndpoint.MaxResponseSize, 10))
+	}
 	grpc.SetTrailer(ctx, trailer) // we ignore this error here since this code can be triggered not from grpc
 	return probeReply, nil
 }
@@ -1062,6 +1140,35 @@ func (rpcps *RPCProviderServer) tryGetTimeoutFromRequest(ctx context.Context) (t
 	return 0, false, nil
 }
 
+// the spec version the reply signals when the consumer runs a different one, so an outdated side can be noticed before
+// it causes settlement mismatches. it's part of the signed reply, 0 when the versions match
+func (rpcps *RPCProviderServer) specVersionSignal(ctx context.Context) uint64 {
+	incomingMetaData, found := metadata.FromIncomingContext(ctx)
+	if !found {
+		return 0
+	}
+	consumerSpecVersions := incomingMetaData.Get(common.SpecVersionMetadataKey)
+	if len(consumerSpecVersions) == 0 {
+		// consumers that don't send their spec version don't check it either
+		return 0
+	}
+	specVersion := rpcps.chainParser.SpecVersion()
+	if consumerSpecVersions[0] == strconv.FormatUint(specVersion, 10) {
+		return 0
+	}
+	utils.LavaFormatDebug("consumer is running a different spec version", utils.LogAttr("GUID", ctx), utils.LogAttr("consumerSpecVersion", consumerSpecVersions[0]), utils.LogAttr("specVersion", specVersion))
+	return specVersion
+}
+
+// the commitment the consumer asked the reply signature to cover, empty when the reply is signed in full
+func requestedReplyCommitment(ctx context.Context) string {
+	incomingMetaData, found := metadata.FromIncomingContext(ctx)
+	if !found {
+		return ""
+	}
+	return lavaprotocol.NegotiateReplyCommitment(incomingMetaData.Get(common.ReplyCommitmentMetadataKey))
+}
+
 func (rpcps *RPCProviderServer) IsHealthy() bool {
 	return rpcps.relaysMonitor.IsHealthy()
 }
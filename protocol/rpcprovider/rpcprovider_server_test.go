@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/extensionslib"
 	"github.com/lavanet/lava/protocol/chaintracker"
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/protocol/rpcprovider/reliabilitymanager"
+	keepertest "github.com/lavanet/lava/testutil/keeper"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
 	spectypes "github.com/lavanet/lava/x/spec/types"
 	"github.com/stretchr/testify/require"
 )
@@ -266,3 +269,44 @@ func TestHandleConsistency(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRequestResolvedBlockHash(t *testing.T) {
+	spec, err := keepertest.GetASpec("ETH1", "../../", nil, nil)
+	require.NoError(t, err)
+	const blockHash = "0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"
+	consumerParser, err := chainlib.NewJrpcChainParser()
+	require.NoError(t, err)
+	consumerParser.SetSpec(spec)
+	consumerParser.SetBlockHashResolver(func(hash string) (int64, bool) { return 42, hash == blockHash })
+	providerParser, err := chainlib.NewJrpcChainParser()
+	require.NoError(t, err)
+	providerParser.SetSpec(spec)
+	rpcps := &RPCProviderServer{}
+
+	playbook := []struct {
+		name    string
+		request string
+		valid   bool
+	}{
+		{name: "block by hash", request: `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByHash","params":["` + blockHash + `",false]}`, valid: true},
+		{name: "EIP-1898 block hash", request: `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x00000000000000000000000000000000000000aa",{"blockHash":"` + blockHash + `"}]}`, valid: true},
+		{name: "latest request with a consumer block", request: `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x00000000000000000000000000000000000000aa","latest"]}`, valid: false},
+	}
+	for _, play := range playbook {
+		t.Run(play.name, func(t *testing.T) {
+			consumerMessage, err := consumerParser.ParseMsg("", []byte(play.request), http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			requestedBlock, _ := consumerMessage.RequestedBlock()
+			if play.valid {
+				require.Equal(t, int64(42), requestedBlock)
+			} else {
+				requestedBlock = 42 // a consumer claiming a block for a latest request
+			}
+			request := &pairingtypes.RelayRequest{RelayData: &pairingtypes.RelayPrivateData{Data: []byte(play.request), RequestBlock: requestedBlock, SeenBlock: 40}}
+			providerMessage, err := providerParser.ParseMsg("", request.RelayData.Data, http.MethodPost, nil, extensionslib.ExtensionInfo{LatestBlock: 0})
+			require.NoError(t, err)
+			err = rpcps.ValidateRequest(providerMessage, request, context.Background())
+			require.Equal(t, play.valid, err == nil, err)
+		})
+	}
+}
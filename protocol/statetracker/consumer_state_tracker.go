@@ -68,6 +68,16 @@ func (cst *ConsumerStateTracker) RegisterConsumerSessionManagerForPairingUpdates
 	}
 }
 
+// ForceRefreshPairing rebuilds the pairing of all registered consumer session managers now, it is rate limited since it queries the chain
+func (cst *ConsumerStateTracker) ForceRefreshPairing(ctx context.Context) error {
+	pairingUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, updaters.NewPairingUpdater(cst.stateQuery))
+	pairingUpdater, ok := pairingUpdaterRaw.(*updaters.PairingUpdater)
+	if !ok {
+		return utils.LavaFormatError("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: pairingUpdaterRaw})
+	}
+	return pairingUpdater.ForceRefreshPairing(ctx)
+}
+
 func (cst *ConsumerStateTracker) RegisterForPairingUpdates(ctx context.Context, pairingUpdatable updaters.PairingUpdatable) {
 	pairingUpdater := updaters.NewPairingUpdater(cst.stateQuery)
 	pairingUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, pairingUpdater)
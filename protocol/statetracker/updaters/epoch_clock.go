@@ -0,0 +1,53 @@
+package updaters
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// EpochClock is the source of the current epoch, in production it's the chain's epoch state. tests drive epoch
+// transitions with a ManualEpochClock instead of a live chain
+type EpochClock interface {
+	CurrentEpochStart(ctx context.Context) (uint64, error)
+}
+
+// ManualEpochClock is an EpochClock that only moves when advanced, epochs are epochBlocks blocks long
+type ManualEpochClock struct {
+	lock        sync.RWMutex
+	epochStart  uint64
+	epochBlocks uint64
+}
+
+var _ EpochClock = (*ManualEpochClock)(nil)
+
+func NewManualEpochClock(epochStart uint64, epochBlocks uint64) *ManualEpochClock {
+	return &ManualEpochClock{epochStart: epochStart, epochBlocks: epochBlocks}
+}
+
+func (mec *ManualEpochClock) CurrentEpochStart(ctx context.Context) (uint64, error) {
+	mec.lock.RLock()
+	defer mec.lock.RUnlock()
+	return mec.epochStart, nil
+}
+
+// NextEpochStart is the block the next epoch starts at
+func (mec *ManualEpochClock) NextEpochStart() uint64 {
+	mec.lock.RLock()
+	defer mec.lock.RUnlock()
+	return mec.epochStart + mec.epochBlocks
+}
+
+func (mec *ManualEpochClock) epochs() (epochStart uint64, nextEpochStart uint64) {
+	mec.lock.RLock()
+	defer mec.lock.RUnlock()
+	return mec.epochStart, mec.epochStart + mec.epochBlocks
+}
+
+// Advance moves the clock to the next epoch and returns its start block, updaters see it on their next update
+func (mec *ManualEpochClock) Advance() uint64 {
+	mec.lock.Lock()
+	defer mec.lock.Unlock()
+	mec.epochStart += mec.epochBlocks
+	return mec.epochStart
+}
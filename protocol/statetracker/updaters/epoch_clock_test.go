@@ -0,0 +1,96 @@
+package updaters
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialInMemory makes every provider reachable over an in memory connection through the relay dialer hook
+func dialInMemory(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	lavasession.SetRelayDialer(func(ctx context.Context, address string, allowInsecure bool) (*grpc.ClientConn, error) {
+		return grpc.DialContext(ctx, address, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	})
+	t.Cleanup(func() { lavasession.SetRelayDialer(nil) })
+}
+
+func TestManualEpochClock(t *testing.T) {
+	ctx := context.Background()
+	clock := NewManualEpochClock(20, 20)
+	epoch, err := clock.CurrentEpochStart(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), epoch)
+	require.Equal(t, uint64(40), clock.NextEpochStart())
+	require.Equal(t, uint64(40), clock.Advance())
+	epoch, err = clock.CurrentEpochStart(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(40), epoch)
+	require.Equal(t, uint64(60), clock.NextEpochStart())
+}
+
+func TestEpochRollover(t *testing.T) {
+	rand.InitRandomSeed()
+	dialInMemory(t)
+	ctx := context.Background()
+	clock := NewManualEpochClock(20, 20)
+	rpcEndpoint := &lavasession.RPCEndpoint{NetworkAddress: "stub", ChainID: "LAV1", ApiInterface: "tendermintrpc", Geolocation: 1}
+	csm := lavasession.NewConsumerSessionManager(rpcEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	source := NewStaticPairingSource()
+	source.SetEpochClock(clock)
+	source.SetPairing("LAV1", "tendermintrpc", staticProviders(20, 2))
+	pairingUpdater := NewPairingUpdaterWithSource(source)
+	require.NoError(t, pairingUpdater.RegisterPairing(ctx, csm))
+	epochUpdater := NewEpochUpdater(clock)
+	updatable := &epochUpdatable{}
+	epochUpdater.RegisterEpochUpdatable(ctx, updatable, 0)
+	require.Equal(t, uint64(20), updatable.updatedBlock)
+
+	sessions, err := csm.GetSessions(ctx, 10, nil, 30, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, sessions)
+	for _, sessionInfo := range sessions {
+		require.Equal(t, uint64(20), sessionInfo.Epoch)
+	}
+
+	// nothing moves before the clock does
+	pairingUpdater.Update(39)
+	epochUpdater.Update(39)
+	require.Equal(t, uint64(2), csm.GetAtomicPairingAddressesLength())
+	require.Equal(t, uint64(20), updatable.updatedBlock)
+
+	// the epoch rolls over while the sessions are in flight
+	source.SetPairing("LAV1", "tendermintrpc", staticProviders(40, 3))
+	require.Equal(t, uint64(40), clock.Advance())
+	pairingUpdater.Update(40)
+	epochUpdater.Update(40)
+	require.Equal(t, uint64(3), csm.GetAtomicPairingAddressesLength())
+	require.Equal(t, uint64(40), updatable.updatedBlock)
+
+	// sessions of the previous epoch still complete, against the pairing they were taken from
+	for _, sessionInfo := range sessions {
+		require.Equal(t, uint64(20), sessionInfo.Session.PairingEpoch())
+		require.NoError(t, csm.OnSessionDone(sessionInfo.Session, sessionInfo.Session.RelayGeneration(), 30, 10, time.Millisecond, time.Second, 30, 1, 2, false))
+	}
+	sessions, err = csm.GetSessions(ctx, 10, nil, 50, "", nil, common.NOSTATE, 0)
+	require.NoError(t, err)
+	for _, sessionInfo := range sessions {
+		require.Equal(t, uint64(40), sessionInfo.Epoch)
+		require.Equal(t, uint64(40), sessionInfo.Session.PairingEpoch())
+	}
+}
@@ -38,26 +38,25 @@ func (euwbd *EpochUpdatableWithBlockDelay) UpdateOnBlock(currentEpoch uint64, la
 	}
 }
 
-type EpochStateQueryInterface interface {
-	CurrentEpochStart(ctx context.Context) (uint64, error)
-}
+// EpochStateQueryInterface is kept for callers of the former name of EpochClock
+type EpochStateQueryInterface = EpochClock
 
 type EpochUpdater struct {
 	lock            sync.RWMutex
 	epochUpdatables []*EpochUpdatableWithBlockDelay
 	currentEpoch    uint64
-	stateQuery      EpochStateQueryInterface
+	epochClock      EpochClock
 }
 
-func NewEpochUpdater(stateQuery EpochStateQueryInterface) *EpochUpdater {
-	return &EpochUpdater{epochUpdatables: []*EpochUpdatableWithBlockDelay{}, stateQuery: stateQuery}
+func NewEpochUpdater(epochClock EpochClock) *EpochUpdater {
+	return &EpochUpdater{epochUpdatables: []*EpochUpdatableWithBlockDelay{}, epochClock: epochClock}
 }
 
 func (eu *EpochUpdater) RegisterEpochUpdatable(ctx context.Context, epochUpdatable EpochUpdatable, blocksUpdateDelay int64) {
 	eu.lock.Lock()
 	defer eu.lock.Unlock()
 	// initialize with the current epoch
-	currentEpoch, err := eu.stateQuery.CurrentEpochStart(ctx)
+	currentEpoch, err := eu.epochClock.CurrentEpochStart(ctx)
 	if err != nil {
 		utils.LavaFormatFatal("epoch updatable failed registering for epoch updates", err)
 	}
@@ -81,7 +80,7 @@ func (eu *EpochUpdater) updateInner(latestBlock int64) {
 	defer eu.lock.Unlock()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
 	defer cancel()
-	currentEpoch, err := eu.stateQuery.CurrentEpochStart(ctx)
+	currentEpoch, err := eu.epochClock.CurrentEpochStart(ctx)
 	if err != nil {
 		return // failed to get the current epoch
 	}
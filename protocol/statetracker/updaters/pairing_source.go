@@ -0,0 +1,208 @@
+package updaters
+
+import (
+	"sync"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	planstypes "github.com/lavanet/lava/x/plans/types"
+	"golang.org/x/net/context"
+)
+
+// PairingSource provides the providers a consumer endpoint is paired with, the pairing updater hands them to the
+// endpoint's consumer session manager on every epoch
+type PairingSource interface {
+	// GetPairing returns the endpoint's providers in the epoch of latestBlock, -1 for the current one, and the block
+	// the next pairing should be fetched at
+	GetPairing(ctx context.Context, rpcEndpoint lavasession.RPCEndpoint, latestBlock int64) (providers []*lavasession.ConsumerSessionsWithProvider, epoch, nextBlockForUpdate uint64, err error)
+	// GetEpoch returns the epoch of latestBlock, -1 for the current one
+	GetEpoch(ctx context.Context, latestBlock int64) (epoch uint64, err error)
+	// InvalidatePairing drops any cached pairing of the chain so the next GetPairing fetches it again
+	InvalidatePairing(chainID string)
+}
+
+// NextPairingSource is a PairingSource that knows the next epoch's pairing before that epoch starts, the pairing
+// updater hands it to the session managers to warm so they swap to open connections at the boundary. the chain's
+// pairing depends on the epoch's block hash, so the chain source can't provide it
+type NextPairingSource interface {
+	PairingSource
+	// GetNextPairing returns the endpoint's providers in the epoch after the current one, none when it isn't known
+	GetNextPairing(ctx context.Context, rpcEndpoint lavasession.RPCEndpoint) (providers []*lavasession.ConsumerSessionsWithProvider, epoch uint64, err error)
+}
+
+// chainPairingSource queries the pairing from the chain
+type chainPairingSource struct {
+	stateQuery *ConsumerStateQuery
+}
+
+func NewChainPairingSource(stateQuery *ConsumerStateQuery) PairingSource {
+	return &chainPairingSource{stateQuery: stateQuery}
+}
+
+func (cps *chainPairingSource) GetPairing(ctx context.Context, rpcEndpoint lavasession.RPCEndpoint, latestBlock int64) (providers []*lavasession.ConsumerSessionsWithProvider, epoch, nextBlockForUpdate uint64, err error) {
+	pairingList, epoch, nextBlockForUpdate, err := cps.stateQuery.GetPairing(ctx, rpcEndpoint.ChainID, latestBlock)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	providers, err = cps.filterPairingListByEndpoint(ctx, planstypes.Geolocation(rpcEndpoint.Geolocation), pairingList, rpcEndpoint, epoch)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return providers, epoch, nextBlockForUpdate, nil
+}
+
+func (cps *chainPairingSource) GetEpoch(ctx context.Context, latestBlock int64) (epoch uint64, err error) {
+	_, epoch, _, err = cps.stateQuery.GetPairing(ctx, "", latestBlock)
+	return epoch, err
+}
+
+func (cps *chainPairingSource) InvalidatePairing(chainID string) {
+	cps.stateQuery.InvalidatePairing(chainID)
+}
+
+func (cps *chainPairingSource) filterPairingListByEndpoint(ctx context.Context, currentGeo planstypes.Geolocation, pairingList []epochstoragetypes.StakeEntry, rpcEndpoint lavasession.RPCEndpoint, epoch uint64) (filteredList []*lavasession.ConsumerSessionsWithProvider, err error) {
+	// go over stake entries, and filter endpoints that match geolocation and api interface
+	pairing := []*lavasession.ConsumerSessionsWithProvider{}
+	for _, provider := range pairingList {
+		//
+		// Sanity
+		providerEndpoints := provider.GetEndpoints()
+		if len(providerEndpoints) == 0 {
+			utils.LavaFormatError("skipping provider with no endoints", nil, utils.Attribute{Key: "Address", Value: provider.Address}, utils.Attribute{Key: "ChainID", Value: provider.Chain})
+			continue
+		}
+
+		relevantEndpoints := []epochstoragetypes.Endpoint{}
+		for _, endpoint := range providerEndpoints {
+			// only take into account endpoints that use the same api interface and the same geolocation
+			for _, endpointApiInterface := range endpoint.ApiInterfaces {
+				if endpointApiInterface == rpcEndpoint.ApiInterface { // we take all geolocations provided by the chain. the provider optimizer will prioritize the relevant ones
+					relevantEndpoints = append(relevantEndpoints, endpoint)
+					break
+				}
+			}
+		}
+		if len(relevantEndpoints) == 0 {
+			utils.LavaFormatError("skipping provider, No relevant endpoints for apiInterface", nil, utils.Attribute{Key: "Address", Value: provider.Address}, utils.Attribute{Key: "ChainID", Value: provider.Chain}, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface}, utils.Attribute{Key: "Endpoints", Value: providerEndpoints})
+			continue
+		}
+
+		maxCu, err := cps.stateQuery.GetMaxCUForUser(ctx, provider.Chain, epoch)
+		if err != nil {
+			return nil, err
+		}
+		//
+		pairingEndpoints := make([]*lavasession.Endpoint, len(relevantEndpoints))
+		for idx, relevantEndpoint := range relevantEndpoints {
+			addons := map[string]struct{}{}
+			extensions := map[string]struct{}{}
+			for _, addon := range relevantEndpoint.Addons {
+				addons[addon] = struct{}{}
+			}
+			for _, extension := range relevantEndpoint.Extensions {
+				extensions[extension] = struct{}{}
+			}
+
+			endp := &lavasession.Endpoint{Geolocation: planstypes.Geolocation(relevantEndpoint.Geolocation), NetworkAddress: relevantEndpoint.IPPORT, Enabled: true, Client: nil, ConnectionRefusals: 0, Addons: addons, Extensions: extensions}
+			pairingEndpoints[idx] = endp
+		}
+		lavasession.SortByGeolocations(pairingEndpoints, currentGeo)
+		pairing = append(pairing, lavasession.NewConsumerSessionWithProvider(
+			provider.Address,
+			pairingEndpoints,
+			maxCu,
+			epoch,
+			provider.Stake,
+		))
+	}
+	return pairing, nil
+}
+
+// StaticPairingSource serves a pairing set in code, for tests and for pairings sourced from a cache or a custom service.
+// the providers are handed to the session managers as is, set new ones for every epoch
+type StaticPairingSource struct {
+	lock               sync.RWMutex
+	epoch              uint64
+	nextBlockForUpdate uint64
+	epochClock         *ManualEpochClock                                      // overrides the epoch set with SetEpoch when set
+	pairing            map[string][]*lavasession.ConsumerSessionsWithProvider // key is chainID and api interface
+	nextEpoch          uint64
+	nextPairing        map[string][]*lavasession.ConsumerSessionsWithProvider // key is chainID and api interface
+}
+
+var _ NextPairingSource = (*StaticPairingSource)(nil)
+
+func NewStaticPairingSource() *StaticPairingSource {
+	return &StaticPairingSource{pairing: map[string][]*lavasession.ConsumerSessionsWithProvider{}, nextPairing: map[string][]*lavasession.ConsumerSessionsWithProvider{}}
+}
+
+func staticPairingKey(chainID string, apiInterface string) string {
+	return chainID + "/" + apiInterface
+}
+
+// SetEpoch sets the epoch the pairing is served for and the block the pairing updater fetches it again at
+func (sps *StaticPairingSource) SetEpoch(epoch uint64, nextBlockForUpdate uint64) {
+	sps.lock.Lock()
+	defer sps.lock.Unlock()
+	sps.epoch = epoch
+	sps.nextBlockForUpdate = nextBlockForUpdate
+}
+
+// SetEpochClock makes the pairing follow the clock's epochs, it's fetched again when the next epoch starts
+func (sps *StaticPairingSource) SetEpochClock(epochClock *ManualEpochClock) {
+	sps.lock.Lock()
+	defer sps.lock.Unlock()
+	sps.epochClock = epochClock
+}
+
+func (sps *StaticPairingSource) currentEpoch() (epoch uint64, nextBlockForUpdate uint64) {
+	if sps.epochClock != nil {
+		return sps.epochClock.epochs()
+	}
+	return sps.epoch, sps.nextBlockForUpdate
+}
+
+// SetPairing sets the providers served to the chain's endpoints of apiInterface
+func (sps *StaticPairingSource) SetPairing(chainID string, apiInterface string, providers []*lavasession.ConsumerSessionsWithProvider) {
+	sps.lock.Lock()
+	defer sps.lock.Unlock()
+	sps.pairing[staticPairingKey(chainID, apiInterface)] = providers
+}
+
+// SetNextPairing sets the providers the chain's endpoints of apiInterface are paired with in the next epoch, it's
+// served until the current epoch reaches it
+func (sps *StaticPairingSource) SetNextPairing(chainID string, apiInterface string, epoch uint64, providers []*lavasession.ConsumerSessionsWithProvider) {
+	sps.lock.Lock()
+	defer sps.lock.Unlock()
+	if epoch != sps.nextEpoch {
+		sps.nextPairing = map[string][]*lavasession.ConsumerSessionsWithProvider{}
+	}
+	sps.nextEpoch = epoch
+	sps.nextPairing[staticPairingKey(chainID, apiInterface)] = providers
+}
+
+func (sps *StaticPairingSource) GetNextPairing(ctx context.Context, rpcEndpoint lavasession.RPCEndpoint) (providers []*lavasession.ConsumerSessionsWithProvider, epoch uint64, err error) {
+	sps.lock.RLock()
+	defer sps.lock.RUnlock()
+	if currentEpoch, _ := sps.currentEpoch(); sps.nextEpoch <= currentEpoch {
+		return nil, 0, nil
+	}
+	return sps.nextPairing[staticPairingKey(rpcEndpoint.ChainID, rpcEndpoint.ApiInterface)], sps.nextEpoch, nil
+}
+
+func (sps *StaticPairingSource) GetPairing(ctx context.Context, rpcEndpoint lavasession.RPCEndpoint, latestBlock int64) (providers []*lavasession.ConsumerSessionsWithProvider, epoch, nextBlockForUpdate uint64, err error) {
+	sps.lock.RLock()
+	defer sps.lock.RUnlock()
+	epoch, nextBlockForUpdate = sps.currentEpoch()
+	return sps.pairing[staticPairingKey(rpcEndpoint.ChainID, rpcEndpoint.ApiInterface)], epoch, nextBlockForUpdate, nil
+}
+
+func (sps *StaticPairingSource) GetEpoch(ctx context.Context, latestBlock int64) (epoch uint64, err error) {
+	sps.lock.RLock()
+	defer sps.lock.RUnlock()
+	epoch, _ = sps.currentEpoch()
+	return epoch, nil
+}
+
+func (sps *StaticPairingSource) InvalidatePairing(chainID string) {}
@@ -6,13 +6,13 @@ import (
 
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/utils"
-	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
-	planstypes "github.com/lavanet/lava/x/plans/types"
 	"golang.org/x/net/context"
 )
 
 const (
 	CallbackKeyForPairingUpdate = "pairing-update"
+	// forced refreshes query the chain, don't let them run more often than this
+	MinTimeBetweenForcedPairingRefresh = time.Minute
 )
 
 type PairingUpdatable interface {
@@ -23,21 +23,28 @@ type PairingUpdater struct {
 	lock                       sync.RWMutex
 	consumerSessionManagersMap map[string][]*lavasession.ConsumerSessionManager // key is chainID so we don;t run getPairing more than once per chain
 	nextBlockForUpdate         uint64
-	stateQuery                 *ConsumerStateQuery
+	pairingSource              PairingSource
 	pairingUpdatables          []*PairingUpdatable
+	lastForcedRefresh          time.Time
 }
 
 func NewPairingUpdater(stateQuery *ConsumerStateQuery) *PairingUpdater {
-	return &PairingUpdater{consumerSessionManagersMap: map[string][]*lavasession.ConsumerSessionManager{}, stateQuery: stateQuery}
+	return NewPairingUpdaterWithSource(NewChainPairingSource(stateQuery))
+}
+
+// NewPairingUpdaterWithSource creates a pairing updater getting the pairing from pairingSource instead of the chain
+func NewPairingUpdaterWithSource(pairingSource PairingSource) *PairingUpdater {
+	return &PairingUpdater{consumerSessionManagersMap: map[string][]*lavasession.ConsumerSessionManager{}, pairingSource: pairingSource}
 }
 
 func (pu *PairingUpdater) RegisterPairing(ctx context.Context, consumerSessionManager *lavasession.ConsumerSessionManager) error {
 	chainID := consumerSessionManager.RPCEndpoint().ChainID
-	pairingList, epoch, nextBlockForUpdate, err := pu.stateQuery.GetPairing(context.Background(), chainID, -1)
+	providers, epoch, nextBlockForUpdate, err := pu.pairingSource.GetPairing(context.Background(), consumerSessionManager.RPCEndpoint(), -1)
 	if err != nil {
 		return err
 	}
-	pu.updateConsummerSessionManager(ctx, pairingList, consumerSessionManager, epoch)
+	pu.updateConsummerSessionManager(providers, consumerSessionManager, epoch)
+	pu.prepareNextPairing(ctx, consumerSessionManager)
 	if nextBlockForUpdate > pu.nextBlockForUpdate {
 		// make sure we don't update twice, this updates pu.nextBlockForUpdate
 		pu.Update(int64(nextBlockForUpdate))
@@ -56,7 +63,7 @@ func (pu *PairingUpdater) RegisterPairing(ctx context.Context, consumerSessionMa
 func (pu *PairingUpdater) RegisterPairingUpdatable(ctx context.Context, pairingUpdatable *PairingUpdatable) error {
 	pu.lock.Lock()
 	defer pu.lock.Unlock()
-	_, epoch, _, err := pu.stateQuery.GetPairing(ctx, "", -1)
+	epoch, err := pu.pairingSource.GetEpoch(ctx, -1)
 	if err != nil {
 		return err
 	}
@@ -80,30 +87,30 @@ func (pu *PairingUpdater) updateInner(latestBlock int64) {
 	}
 	nextBlockForUpdateList := []uint64{}
 	for chainID, consumerSessionManagerList := range pu.consumerSessionManagersMap {
-		timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-		pairingList, epoch, nextBlockForUpdate, err := pu.stateQuery.GetPairing(timeoutCtx, chainID, latestBlock)
-		cancel()
-		if err != nil {
-			utils.LavaFormatError("could not update pairing for chain, trying again next block", err, utils.Attribute{Key: "chain", Value: chainID})
-			nextBlockForUpdateList = append(nextBlockForUpdateList, pu.nextBlockForUpdate+1)
-			continue
-		} else {
-			nextBlockForUpdateList = append(nextBlockForUpdateList, nextBlockForUpdate)
-		}
 		for _, consumerSessionManager := range consumerSessionManagerList {
-			// same pairing for all apiInterfaces, they pick the right endpoints from inside using our filter function
-			err = pu.updateConsummerSessionManager(ctx, pairingList, consumerSessionManager, epoch)
+			// same pairing for all apiInterfaces, the source picks the right endpoints for each of them
+			timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+			providers, epoch, nextBlockForUpdate, err := pu.pairingSource.GetPairing(timeoutCtx, consumerSessionManager.RPCEndpoint(), latestBlock)
+			cancel()
+			if err != nil {
+				utils.LavaFormatError("could not update pairing for chain, trying again next block", err, utils.Attribute{Key: "chain", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: consumerSessionManager.RPCEndpoint().ApiInterface})
+				nextBlockForUpdateList = append(nextBlockForUpdateList, pu.nextBlockForUpdate+1)
+				continue
+			}
+			nextBlockForUpdateList = append(nextBlockForUpdateList, nextBlockForUpdate)
+			err = pu.updateConsummerSessionManager(providers, consumerSessionManager, epoch)
 			if err != nil {
-				utils.LavaFormatError("failed updating consumer session manager", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: consumerSessionManager.RPCEndpoint().ApiInterface}, utils.Attribute{Key: "pairingListLen", Value: len(pairingList)})
+				utils.LavaFormatError("failed updating consumer session manager", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: consumerSessionManager.RPCEndpoint().ApiInterface}, utils.Attribute{Key: "pairingListLen", Value: len(providers)})
 				continue
 			}
+			pu.prepareNextPairing(ctx, consumerSessionManager)
 		}
 	}
 
 	// get latest epoch from cache
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	_, epoch, _, err := pu.stateQuery.GetPairing(timeoutCtx, "", latestBlock)
+	epoch, err := pu.pairingSource.GetEpoch(timeoutCtx, latestBlock)
 	if err != nil {
 		utils.LavaFormatError("could not update pairing for updatables, trying again next block", err)
 		nextBlockForUpdateList = append(nextBlockForUpdateList, pu.nextBlockForUpdate+1)
@@ -131,73 +138,83 @@ func (pu *PairingUpdater) Update(latestBlock int64) {
 	pu.updateInner(latestBlock)
 }
 
-func (pu *PairingUpdater) updateConsummerSessionManager(ctx context.Context, pairingList []epochstoragetypes.StakeEntry, consumerSessionManager *lavasession.ConsumerSessionManager, epoch uint64) (err error) {
-	pairingListForThisCSM, err := pu.filterPairingListByEndpoint(ctx, planstypes.Geolocation(consumerSessionManager.RPCEndpoint().Geolocation), pairingList, consumerSessionManager.RPCEndpoint(), epoch)
+// ForceRefreshPairing fetches the pairing from the chain and updates all consumer session managers without waiting for the next epoch
+func (pu *PairingUpdater) ForceRefreshPairing(ctx context.Context) error {
+	pu.lock.Lock()
+	defer pu.lock.Unlock()
+	if sinceLastRefresh := time.Since(pu.lastForcedRefresh); sinceLastRefresh < MinTimeBetweenForcedPairingRefresh {
+		return utils.LavaFormatWarning("pairing refresh was requested too soon after the previous one", nil, utils.Attribute{Key: "sinceLastRefresh", Value: sinceLastRefresh}, utils.Attribute{Key: "minTimeBetweenRefreshes", Value: MinTimeBetweenForcedPairingRefresh})
+	}
+	pu.lastForcedRefresh = time.Now()
+	var errRet error
+	for chainID, consumerSessionManagerList := range pu.consumerSessionManagersMap {
+		pu.pairingSource.InvalidatePairing(chainID)
+		for _, consumerSessionManager := range consumerSessionManagerList {
+			timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+			providers, epoch, _, err := pu.pairingSource.GetPairing(timeoutCtx, consumerSessionManager.RPCEndpoint(), -1)
+			cancel()
+			if err != nil {
+				errRet = utils.LavaFormatError("could not refresh pairing for chain", err, utils.Attribute{Key: "chain", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: consumerSessionManager.RPCEndpoint().ApiInterface})
+				continue
+			}
+			pairing, err := pu.pairingForConsumerSessionManager(providers, consumerSessionManager)
+			if err == nil {
+				err = consumerSessionManager.RefreshProviders(epoch, pairing)
+			}
+			if err != nil {
+				errRet = utils.LavaFormatError("failed refreshing consumer session manager", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: consumerSessionManager.RPCEndpoint().ApiInterface})
+			}
+		}
+	}
+	return errRet
+}
+
+func (pu *PairingUpdater) updateConsummerSessionManager(providers []*lavasession.ConsumerSessionsWithProvider, consumerSessionManager *lavasession.ConsumerSessionManager, epoch uint64) (err error) {
+	pairing, err := pu.pairingForConsumerSessionManager(providers, consumerSessionManager)
 	if err != nil {
 		return err
 	}
-	err = consumerSessionManager.UpdateAllProviders(epoch, pairingListForThisCSM)
+	err = consumerSessionManager.UpdateAllProviders(epoch, pairing)
 	return
 }
 
-func (pu *PairingUpdater) filterPairingListByEndpoint(ctx context.Context, currentGeo planstypes.Geolocation, pairingList []epochstoragetypes.StakeEntry, rpcEndpoint lavasession.RPCEndpoint, epoch uint64) (filteredList map[uint64]*lavasession.ConsumerSessionsWithProvider, err error) {
-	// go over stake entries, and filter endpoints that match geolocation and api interface
-	pairing := map[uint64]*lavasession.ConsumerSessionsWithProvider{}
-	for providerIdx, provider := range pairingList {
-		//
-		// Sanity
-		providerEndpoints := provider.GetEndpoints()
-		if len(providerEndpoints) == 0 {
-			utils.LavaFormatError("skipping provider with no endoints", nil, utils.Attribute{Key: "Address", Value: provider.Address}, utils.Attribute{Key: "ChainID", Value: provider.Chain})
-			continue
-		}
-
-		relevantEndpoints := []epochstoragetypes.Endpoint{}
-		for _, endpoint := range providerEndpoints {
-			// only take into account endpoints that use the same api interface and the same geolocation
-			for _, endpointApiInterface := range endpoint.ApiInterfaces {
-				if endpointApiInterface == rpcEndpoint.ApiInterface { // we take all geolocations provided by the chain. the provider optimizer will prioritize the relevant ones
-					relevantEndpoints = append(relevantEndpoints, endpoint)
-					break
-				}
-			}
-		}
-		if len(relevantEndpoints) == 0 {
-			utils.LavaFormatError("skipping provider, No relevant endpoints for apiInterface", nil, utils.Attribute{Key: "Address", Value: provider.Address}, utils.Attribute{Key: "ChainID", Value: provider.Chain}, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface}, utils.Attribute{Key: "Endpoints", Value: providerEndpoints})
-			continue
-		}
-
-		maxCu, err := pu.stateQuery.GetMaxCUForUser(ctx, provider.Chain, epoch)
-		if err != nil {
-			return nil, err
-		}
-		//
-		pairingEndpoints := make([]*lavasession.Endpoint, len(relevantEndpoints))
-		for idx, relevantEndpoint := range relevantEndpoints {
-			addons := map[string]struct{}{}
-			extensions := map[string]struct{}{}
-			for _, addon := range relevantEndpoint.Addons {
-				addons[addon] = struct{}{}
-			}
-			for _, extension := range relevantEndpoint.Extensions {
-				extensions[extension] = struct{}{}
-			}
+// prepareNextPairing hands the next epoch's pairing to the session manager to warm before the boundary, when the
+// pairing source knows it
+func (pu *PairingUpdater) prepareNextPairing(ctx context.Context, consumerSessionManager *lavasession.ConsumerSessionManager) {
+	nextPairingSource, ok := pu.pairingSource.(NextPairingSource)
+	if !ok {
+		return
+	}
+	rpcEndpoint := consumerSessionManager.RPCEndpoint()
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	providers, epoch, err := nextPairingSource.GetNextPairing(timeoutCtx, rpcEndpoint)
+	cancel()
+	if err != nil {
+		utils.LavaFormatWarning("could not get the next epoch's pairing, it isn't warmed before the boundary", err, utils.Attribute{Key: "chainID", Value: rpcEndpoint.ChainID}, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface})
+		return
+	}
+	if len(providers) == 0 || consumerSessionManager.StandbyEpoch() == epoch {
+		// unknown or already prepared
+		return
+	}
+	pairing, err := pu.pairingForConsumerSessionManager(providers, consumerSessionManager)
+	if err == nil {
+		_, err = consumerSessionManager.PrepareNextEpoch(ctx, epoch, pairing)
+	}
+	if err != nil {
+		utils.LavaFormatWarning("failed preparing the next epoch's pairing", err, utils.Attribute{Key: "chainID", Value: rpcEndpoint.ChainID}, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface}, utils.Attribute{Key: "epoch", Value: epoch})
+	}
+}
 
-			endp := &lavasession.Endpoint{Geolocation: planstypes.Geolocation(relevantEndpoint.Geolocation), NetworkAddress: relevantEndpoint.IPPORT, Enabled: true, Client: nil, ConnectionRefusals: 0, Addons: addons, Extensions: extensions}
-			pairingEndpoints[idx] = endp
-		}
-		lavasession.SortByGeolocations(pairingEndpoints, currentGeo)
-		pairing[uint64(providerIdx)] = lavasession.NewConsumerSessionWithProvider(
-			provider.Address,
-			pairingEndpoints,
-			maxCu,
-			epoch,
-			provider.Stake,
-		)
-	}
-	if len(pairing) == 0 {
-		return nil, utils.LavaFormatError("Failed getting pairing for consumer, pairing is empty", err, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface}, utils.Attribute{Key: "ChainID", Value: rpcEndpoint.ChainID}, utils.Attribute{Key: "geolocation", Value: rpcEndpoint.Geolocation})
+func (pu *PairingUpdater) pairingForConsumerSessionManager(providers []*lavasession.ConsumerSessionsWithProvider, consumerSessionManager *lavasession.ConsumerSessionManager) (map[uint64]*lavasession.ConsumerSessionsWithProvider, error) {
+	if len(providers) == 0 {
+		rpcEndpoint := consumerSessionManager.RPCEndpoint()
+		return nil, utils.LavaFormatError("Failed getting pairing for consumer, pairing is empty", nil, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface}, utils.Attribute{Key: "ChainID", Value: rpcEndpoint.ChainID}, utils.Attribute{Key: "geolocation", Value: rpcEndpoint.Geolocation})
 	}
 	// replace previous pairing with new providers
+	pairing := make(map[uint64]*lavasession.ConsumerSessionsWithProvider, len(providers))
+	for idx, provider := range providers {
+		pairing[uint64(idx)] = provider
+	}
 	return pairing, nil
 }
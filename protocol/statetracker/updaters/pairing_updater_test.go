@@ -0,0 +1,87 @@
+package updaters
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/lavanet/lava/utils/rand"
+	"github.com/stretchr/testify/require"
+)
+
+func staticProviders(epoch uint64, count int) []*lavasession.ConsumerSessionsWithProvider {
+	providers := make([]*lavasession.ConsumerSessionsWithProvider, count)
+	for idx := range providers {
+		endpoints := []*lavasession.Endpoint{{NetworkAddress: "127.0.0.1:" + strconv.Itoa(2000+idx), Enabled: true, Addons: map[string]struct{}{}, Extensions: map[string]struct{}{}}}
+		providers[idx] = lavasession.NewConsumerSessionWithProvider("provider"+strconv.Itoa(idx), endpoints, 200, epoch, sdk.NewInt64Coin("ulava", 100))
+	}
+	return providers
+}
+
+func TestPairingUpdaterStaticSource(t *testing.T) {
+	rand.InitRandomSeed()
+	ctx := context.Background()
+	rpcEndpoint := &lavasession.RPCEndpoint{NetworkAddress: "stub", ChainID: "LAV1", ApiInterface: "tendermintrpc", Geolocation: 1}
+	csm := lavasession.NewConsumerSessionManager(rpcEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	source := NewStaticPairingSource()
+	source.SetEpoch(20, 40)
+	source.SetPairing("LAV1", "tendermintrpc", staticProviders(20, 2))
+	pairingUpdater := NewPairingUpdaterWithSource(source)
+
+	require.NoError(t, pairingUpdater.RegisterPairing(ctx, csm))
+	require.Equal(t, uint64(2), csm.GetAtomicPairingAddressesLength())
+	updatable := &epochUpdatable{}
+	var pairingUpdatable PairingUpdatable = updatable
+	require.NoError(t, pairingUpdater.RegisterPairingUpdatable(ctx, &pairingUpdatable))
+	require.Equal(t, uint64(20), updatable.updatedBlock)
+
+	// the next epoch's pairing isn't fetched before its block
+	source.SetEpoch(40, 60)
+	source.SetPairing("LAV1", "tendermintrpc", staticProviders(40, 3))
+	pairingUpdater.Update(39)
+	require.Equal(t, uint64(2), csm.GetAtomicPairingAddressesLength())
+	require.Equal(t, uint64(20), updatable.updatedBlock)
+
+	pairingUpdater.Update(40)
+	require.Equal(t, uint64(3), csm.GetAtomicPairingAddressesLength())
+	require.Equal(t, uint64(40), updatable.updatedBlock)
+
+	// an empty pairing keeps the previous one
+	source.SetEpoch(60, 80)
+	source.SetPairing("LAV1", "tendermintrpc", nil)
+	pairingUpdater.Update(60)
+	require.Equal(t, uint64(3), csm.GetAtomicPairingAddressesLength())
+	require.Equal(t, uint64(60), updatable.updatedBlock)
+}
+
+func TestPairingUpdaterPreparesNextPairing(t *testing.T) {
+	rand.InitRandomSeed()
+	ctx := context.Background()
+	rpcEndpoint := &lavasession.RPCEndpoint{NetworkAddress: "stub", ChainID: "LAV1", ApiInterface: "tendermintrpc", Geolocation: 1}
+	csm := lavasession.NewConsumerSessionManager(rpcEndpoint, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_BALANCED, 0, time.Millisecond, 1), nil, nil)
+	source := NewStaticPairingSource()
+	source.SetEpoch(20, 40)
+	source.SetPairing("LAV1", "tendermintrpc", staticProviders(20, 2))
+	pairingUpdater := NewPairingUpdaterWithSource(source)
+
+	// nothing to prepare while the next pairing isn't known
+	require.NoError(t, pairingUpdater.RegisterPairing(ctx, csm))
+	require.Zero(t, csm.StandbyEpoch())
+
+	// the next pairing is prepared once the current one is updated, and swapped in at its epoch
+	source.SetNextPairing("LAV1", "tendermintrpc", 60, staticProviders(60, 3))
+	source.SetEpoch(40, 60)
+	source.SetPairing("LAV1", "tendermintrpc", staticProviders(40, 2))
+	pairingUpdater.Update(40)
+	require.Equal(t, uint64(60), csm.StandbyEpoch())
+
+	source.SetEpoch(60, 80)
+	source.SetPairing("LAV1", "tendermintrpc", staticProviders(60, 3))
+	pairingUpdater.Update(60)
+	require.Zero(t, csm.StandbyEpoch())
+	require.Equal(t, uint64(3), csm.GetAtomicPairingAddressesLength())
+}
@@ -159,6 +159,11 @@ func (csq *ConsumerStateQuery) GetPairing(ctx context.Context, chainID string, l
 	return pairingResp.Providers, pairingResp.CurrentEpoch, pairingResp.BlockOfNextPairing, nil
 }
 
+// InvalidatePairing drops the cached pairing of a chain so the next GetPairing queries the chain
+func (csq *ConsumerStateQuery) InvalidatePairing(chainID string) {
+	csq.ResponsesCache.Del(PairingRespKey + chainID)
+}
+
 func (csq *ConsumerStateQuery) GetMaxCUForUser(ctx context.Context, chainID string, epoch uint64) (maxCu uint64, err error) {
 	address := csq.clientCtx.FromAddress.String()
 	UserEntryRes, err := csq.PairingQueryClient.UserEntry(ctx, &pairingtypes.QueryUserEntryRequest{ChainID: chainID, Address: address, Block: epoch})
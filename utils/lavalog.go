@@ -206,12 +206,17 @@ func StrValue(val interface{}) string {
 	return st_val
 }
 
-func LavaFormatLog(description string, err error, attributes []Attribute, severity uint) error {
+func init() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+}
+
+func LavaFormatLog(description string, err error, attributes []Attribute, severity uint) error {
+	// a logger per call, assigning the global one would race between concurrent log calls
+	var logger zerolog.Logger
 	if JsonFormat {
-		zerologlog.Logger = zerologlog.Output(os.Stderr).Level(globalLogLevel)
+		logger = zerologlog.Output(os.Stderr).Level(globalLogLevel)
 	} else {
-		zerologlog.Logger = zerologlog.Output(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: NoColor, TimeFormat: time.Stamp}).Level(globalLogLevel)
+		logger = zerologlog.Output(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: NoColor, TimeFormat: time.Stamp}).Level(globalLogLevel)
 	}
 
 	var logEvent *zerolog.Event
@@ -219,30 +224,30 @@ func LavaFormatLog(description string, err error, attributes []Attribute, severi
 	switch severity {
 	case LAVA_LOG_PANIC:
 		// prefix = "Panic:"
-		logEvent = zerologlog.Panic()
+		logEvent = logger.Panic()
 		if rollingLogLogger.GetLevel() != zerolog.Disabled {
 			rollingLoggerEvent = rollingLogLogger.Panic()
 		}
 	case LAVA_LOG_FATAL:
 		// prefix = "Fatal:"
-		logEvent = zerologlog.Fatal()
+		logEvent = logger.Fatal()
 		if rollingLogLogger.GetLevel() != zerolog.Disabled {
 			rollingLoggerEvent = rollingLogLogger.Fatal()
 		}
 	case LAVA_LOG_ERROR:
 		// prefix = "Error:"
-		logEvent = zerologlog.Error()
+		logEvent = logger.Error()
 		rollingLoggerEvent = rollingLogLogger.Error()
 	case LAVA_LOG_WARN:
 		// prefix = "Warning:"
-		logEvent = zerologlog.Warn()
+		logEvent = logger.Warn()
 		rollingLoggerEvent = rollingLogLogger.Warn()
 	case LAVA_LOG_INFO:
-		logEvent = zerologlog.Info()
+		logEvent = logger.Info()
 		rollingLoggerEvent = rollingLogLogger.Info()
 		// prefix = "Info:"
 	case LAVA_LOG_DEBUG:
-		logEvent = zerologlog.Debug()
+		logEvent = logger.Debug()
 		rollingLoggerEvent = rollingLogLogger.Debug()
 		// prefix = "Debug:"
 	}
@@ -686,6 +686,7 @@ type RelayReply struct {
 	FinalizedBlocksHashes []byte     `protobuf:"bytes,5,opt,name=finalized_blocks_hashes,json=finalizedBlocksHashes,proto3" json:"finalized_blocks_hashes,omitempty"`
 	SigBlocks             []byte     `protobuf:"bytes,6,opt,name=sig_blocks,json=sigBlocks,proto3" json:"sig_blocks,omitempty"`
 	Metadata              []Metadata `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata"`
+	SpecVersion           uint64     `protobuf:"varint,8,opt,name=spec_version,json=specVersion,proto3" json:"spec_version,omitempty"`
 }
 
 func (m *RelayReply) Reset()         { *m = RelayReply{} }
@@ -763,6 +764,13 @@ func (m *RelayReply) GetMetadata() []Metadata {
 	return nil
 }
 
+func (m *RelayReply) GetSpecVersion() uint64 {
+	if m != nil {
+		return m.SpecVersion
+	}
+	return 0
+}
+
 type QualityOfServiceReport struct {
 	Latency      github_com_cosmos_cosmos_sdk_types.Dec `protobuf:"bytes,1,opt,name=latency,proto3,customtype=github.com/cosmos/cosmos-sdk/types.Dec" json:"latency" yaml:"Latency"`
 	Availability github_com_cosmos_cosmos_sdk_types.Dec `protobuf:"bytes,2,opt,name=availability,proto3,customtype=github.com/cosmos/cosmos-sdk/types.Dec" json:"availability" yaml:"availability"`
@@ -818,83 +826,84 @@ func init() {
 func init() { proto.RegisterFile("lavanet/lava/pairing/relay.proto", fileDescriptor_a61d253b10eeeb9e) }
 
 var fileDescriptor_a61d253b10eeeb9e = []byte{
-	// 1206 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xdd, 0x6e, 0x1b, 0x45,
-	0x14, 0xf6, 0xfa, 0x27, 0xb6, 0x8f, 0xb7, 0x69, 0x99, 0x36, 0xad, 0x95, 0x0a, 0xc7, 0x5d, 0xa4,
-	0x34, 0x42, 0x60, 0x43, 0x40, 0x5c, 0x20, 0x21, 0xb5, 0xa6, 0x11, 0x04, 0x0a, 0x4d, 0x37, 0x70,
-	0x13, 0x09, 0x6d, 0xc7, 0xbb, 0x93, 0xcd, 0xd0, 0xf5, 0xce, 0x66, 0x66, 0xd6, 0xc4, 0xbc, 0x00,
-	0x57, 0x48, 0x3c, 0x04, 0x4f, 0xc0, 0x43, 0x54, 0xbd, 0xec, 0x25, 0x42, 0x22, 0x42, 0xc9, 0x1b,
-	0x20, 0x1e, 0x00, 0xcd, 0x8f, 0xff, 0x12, 0x27, 0x28, 0xc0, 0xd5, 0xce, 0x7c, 0x73, 0xf6, 0x9c,
-	0x33, 0xdf, 0x39, 0xe7, 0xdb, 0x85, 0x76, 0x82, 0x87, 0x38, 0x25, 0xb2, 0xab, 0x9e, 0xdd, 0x0c,
-	0x53, 0x4e, 0xd3, 0xb8, 0xcb, 0x49, 0x82, 0x47, 0x9d, 0x8c, 0x33, 0xc9, 0xd0, 0x2d, 0x6b, 0xd1,
-	0x51, 0xcf, 0x8e, 0xb5, 0x58, 0xbd, 0x15, 0xb3, 0x98, 0x69, 0x83, 0xae, 0x5a, 0x19, 0xdb, 0xd5,
-	0x56, 0xcc, 0x58, 0x9c, 0x90, 0xae, 0xde, 0xf5, 0xf3, 0xfd, 0xee, 0x77, 0x1c, 0x67, 0x19, 0xe1,
-	0xc2, 0x9e, 0xaf, 0x9d, 0x3d, 0x97, 0x74, 0x40, 0x84, 0xc4, 0x83, 0xcc, 0x18, 0x78, 0xcf, 0xc0,
-	0xdd, 0xe1, 0xac, 0x4f, 0x7c, 0x72, 0x98, 0x13, 0x21, 0x11, 0x82, 0x72, 0x9c, 0xd3, 0xa8, 0xe9,
-	0xb4, 0x9d, 0x8d, 0xb2, 0xaf, 0xd7, 0xe8, 0x0e, 0x54, 0x45, 0x46, 0xc2, 0x80, 0x46, 0xcd, 0x62,
-	0xdb, 0xd9, 0xa8, 0xfb, 0x4b, 0x6a, 0xbb, 0x1d, 0xa1, 0x37, 0xe0, 0x1a, 0xce, 0x68, 0x40, 0x53,
-	0x49, 0xf8, 0x3e, 0x0e, 0x49, 0xb3, 0xa4, 0x8f, 0x5d, 0x9c, 0xd1, 0xed, 0x31, 0xe6, 0xbd, 0x70,
-	0x00, 0x6c, 0x88, 0x2c, 0x19, 0x2d, 0x0c, 0x70, 0x0f, 0xdc, 0x04, 0x4b, 0x22, 0x64, 0xd0, 0x4f,
-	0x58, 0xf8, 0x5c, 0x47, 0x29, 0xf9, 0x0d, 0x83, 0xf5, 0x14, 0x84, 0x3e, 0x80, 0x3b, 0xfb, 0x34,
-	0xc5, 0x09, 0xfd, 0x9e, 0x44, 0xc6, 0x4a, 0x04, 0x07, 0x58, 0x1c, 0x10, 0xa1, 0x83, 0xba, 0xfe,
-	0xca, 0xe4, 0x58, 0xbf, 0x20, 0x3e, 0xd5, 0x87, 0xe8, 0x75, 0x00, 0x45, 0x63, 0x40, 0x32, 0x16,
-	0x1e, 0x34, 0xcb, 0x3a, 0x68, 0x5d, 0x21, 0x5b, 0x0a, 0x40, 0x6f, 0xc2, 0x6b, 0xfa, 0x78, 0x2e,
-	0x7c, 0x45, 0x5b, 0x5d, 0x57, 0x07, 0x8f, 0xa7, 0x29, 0x78, 0x2f, 0xca, 0xe0, 0xfa, 0xaa, 0x4e,
-	0xbb, 0x44, 0x08, 0xca, 0xd2, 0x59, 0x5e, 0x9c, 0x39, 0x5e, 0xee, 0x81, 0x1b, 0xb2, 0x54, 0x92,
-	0x54, 0xea, 0x1c, 0xf5, 0x7d, 0x5c, 0xbf, 0x61, 0x31, 0x95, 0x99, 0xca, 0x4b, 0x18, 0x37, 0xea,
-	0xf5, 0x92, 0xc9, 0xcb, 0x22, 0xdb, 0x11, 0x5a, 0x81, 0xa5, 0x30, 0x0f, 0x44, 0x3e, 0xb0, 0x29,
-	0x57, 0xc2, 0x7c, 0x37, 0x1f, 0xa0, 0x55, 0xa8, 0x65, 0x9c, 0x0d, 0x69, 0x44, 0xb8, 0xce, 0xb2,
-	0xee, 0x4f, 0xf6, 0xe8, 0x2e, 0xd4, 0x75, 0x17, 0x05, 0x69, 0x3e, 0x68, 0x2e, 0xe9, 0xb7, 0x6a,
-	0x1a, 0xf8, 0x32, 0x1f, 0xa0, 0xcf, 0x01, 0x0e, 0x99, 0x08, 0x38, 0xc9, 0x18, 0x97, 0xcd, 0x6a,
-	0xdb, 0xd9, 0x68, 0x6c, 0xbe, 0xd5, 0x59, 0xd4, 0x68, 0x9d, 0xa7, 0x39, 0x4e, 0xa8, 0x1c, 0x3d,
-	0xd9, 0xdf, 0x25, 0x7c, 0x48, 0x43, 0x55, 0x36, 0xc6, 0xa5, 0x5f, 0x3f, 0x64, 0xc2, 0x2c, 0xd1,
-	0x2d, 0xa8, 0x18, 0x3a, 0x6b, 0xba, 0x4e, 0x66, 0x83, 0xbe, 0x81, 0xdb, 0x79, 0xca, 0x89, 0xc8,
-	0x58, 0x2a, 0xe8, 0x90, 0x04, 0xe3, 0xc4, 0x44, 0xb3, 0xde, 0x2e, 0x6d, 0x34, 0x36, 0xd7, 0x17,
-	0x87, 0x33, 0x3e, 0x49, 0xb4, 0x63, 0xcd, 0xfd, 0x95, 0x59, 0x2f, 0x63, 0x54, 0x20, 0x0f, 0xae,
-	0xe9, 0x4a, 0x85, 0x07, 0x98, 0x6a, 0xce, 0x40, 0xdf, 0xbf, 0xa1, 0xc0, 0x8f, 0x15, 0xb6, 0x1d,
-	0xa1, 0x1b, 0x50, 0x12, 0x34, 0x6e, 0x36, 0x34, 0xdd, 0x6a, 0x89, 0xde, 0x85, 0x4a, 0x1f, 0x47,
-	0x31, 0x69, 0xba, 0xfa, 0xca, 0x77, 0x17, 0xe7, 0xd0, 0x53, 0x26, 0xbe, 0xb1, 0x44, 0xcf, 0x60,
-	0x45, 0x51, 0x45, 0x8e, 0x42, 0x92, 0x24, 0x24, 0x0d, 0xc9, 0x98, 0xb5, 0x6b, 0xff, 0x82, 0xb5,
-	0x9b, 0x87, 0x4c, 0x6c, 0x4d, 0x3c, 0x19, 0x50, 0x4d, 0x44, 0x45, 0x87, 0x54, 0x03, 0x14, 0xe6,
-	0x01, 0x4e, 0x12, 0x16, 0x62, 0x49, 0x59, 0x6a, 0xa7, 0xc2, 0x0d, 0xf3, 0x87, 0x13, 0x6c, 0x4a,
-	0x77, 0xd1, 0xb4, 0x82, 0xa1, 0xbb, 0x09, 0x55, 0x1c, 0x45, 0x9c, 0x08, 0x61, 0xa7, 0x6e, 0xbc,
-	0x3d, 0xcf, 0x54, 0xf9, 0x3c, 0x53, 0x6b, 0xd0, 0xc8, 0x38, 0xfb, 0x96, 0x84, 0x32, 0x50, 0x8c,
-	0x55, 0x34, 0x63, 0x60, 0xa1, 0x5d, 0x1a, 0xab, 0xcc, 0x86, 0x94, 0xcb, 0x1c, 0x27, 0x76, 0x74,
-	0x4c, 0x47, 0xb9, 0x16, 0xd4, 0xd3, 0xe3, 0xfd, 0x5e, 0x84, 0x1b, 0x7a, 0x22, 0x76, 0x38, 0x1d,
-	0x62, 0x49, 0x1e, 0x61, 0x89, 0xd1, 0x7d, 0xb8, 0x1e, 0xb2, 0x34, 0x25, 0xa1, 0x4a, 0x3e, 0x90,
-	0xa3, 0x8c, 0xd8, 0xe9, 0x58, 0x9e, 0xc2, 0x5f, 0x8d, 0x32, 0xa2, 0xc6, 0x47, 0xa9, 0x47, 0xce,
-	0x93, 0xb1, 0xac, 0xe0, 0x8c, 0x7e, 0xcd, 0x13, 0x25, 0x11, 0x11, 0x96, 0xd8, 0x0e, 0xb6, 0x5e,
-	0xab, 0x7c, 0xb8, 0x91, 0x28, 0x3b, 0xa4, 0x65, 0xdd, 0x7b, 0xae, 0x05, 0x8d, 0x48, 0x9c, 0xd3,
-	0xa3, 0xca, 0x79, 0x3d, 0x52, 0xde, 0x05, 0x4e, 0xa4, 0xbe, 0x90, 0xeb, 0xeb, 0x35, 0x7a, 0x00,
-	0xb5, 0x01, 0x91, 0x58, 0x47, 0xad, 0xea, 0x6e, 0x6d, 0x2d, 0x2e, 0xf3, 0x17, 0xd6, 0xaa, 0x57,
-	0x7e, 0x79, 0xbc, 0x56, 0xf0, 0x27, 0x6f, 0xa9, 0x22, 0xe1, 0x28, 0x62, 0xa9, 0x9e, 0x89, 0xba,
-	0x6f, 0x36, 0xa8, 0x05, 0x40, 0x8e, 0x24, 0x49, 0xd5, 0x54, 0x9b, 0x39, 0xa8, 0xfb, 0x33, 0x88,
-	0x51, 0x01, 0x92, 0xda, 0x2b, 0x81, 0xbe, 0x52, 0x5d, 0x21, 0x46, 0x71, 0x7e, 0x74, 0x14, 0xbf,
-	0xf3, 0xf3, 0x31, 0x5b, 0x78, 0x67, 0xbe, 0xf0, 0xeb, 0xb0, 0x1c, 0x51, 0x31, 0x65, 0x59, 0xd8,
-	0x8e, 0x39, 0x83, 0xa2, 0xdb, 0xb0, 0x44, 0x38, 0x67, 0x5c, 0x58, 0xdd, 0xb1, 0x3b, 0xd5, 0x14,
-	0x93, 0xcf, 0x43, 0x20, 0x2c, 0xc3, 0x30, 0x81, 0x76, 0xbd, 0xf7, 0xa1, 0x36, 0x26, 0x40, 0xd1,
-	0x98, 0xe2, 0xc1, 0xb8, 0xb6, 0x7a, 0xad, 0x48, 0x18, 0xe2, 0x24, 0x27, 0xb6, 0x9e, 0x66, 0xe3,
-	0xfd, 0xec, 0x58, 0xdd, 0x1c, 0x7f, 0x63, 0x3e, 0x51, 0xb5, 0x54, 0x4a, 0x65, 0xf5, 0x4e, 0xfb,
-	0x68, 0x6c, 0x7a, 0x17, 0x09, 0xc4, 0x54, 0x72, 0x55, 0xbd, 0x67, 0x04, 0x78, 0x0b, 0xc0, 0x38,
-	0xd2, 0x85, 0x2b, 0x6a, 0x2f, 0xeb, 0x97, 0x78, 0x99, 0x69, 0x53, 0xdf, 0x88, 0xa5, 0x5a, 0x7e,
-	0x56, 0xae, 0x95, 0x6e, 0x94, 0xbd, 0xbf, 0x1c, 0x00, 0x9b, 0xa6, 0xfd, 0x4e, 0x69, 0xaf, 0xce,
-	0x4c, 0x13, 0x5a, 0x7d, 0x29, 0x4e, 0xf5, 0xe5, 0xec, 0x97, 0xab, 0x7c, 0xa5, 0x2f, 0x57, 0xe5,
-	0x1f, 0xbe, 0x5c, 0x82, 0xc6, 0xf6, 0x0d, 0xdb, 0xad, 0x75, 0x41, 0x63, 0x63, 0xf4, 0xdf, 0x5b,
-	0xd6, 0x5e, 0xfb, 0x97, 0x22, 0xdc, 0x5e, 0x2c, 0x5e, 0x68, 0x0f, 0xaa, 0xea, 0x22, 0x69, 0x38,
-	0x32, 0x55, 0xee, 0x3d, 0x50, 0x1e, 0x7e, 0x3b, 0x5e, 0x5b, 0x8f, 0xa9, 0x3c, 0xc8, 0xfb, 0x9d,
-	0x90, 0x0d, 0xba, 0x21, 0x13, 0x03, 0x26, 0xec, 0xe3, 0x6d, 0x11, 0x3d, 0xef, 0xaa, 0x91, 0x17,
-	0x9d, 0x47, 0x24, 0xfc, 0xf3, 0x78, 0x6d, 0x79, 0x84, 0x07, 0xc9, 0x87, 0xde, 0x63, 0xe3, 0xc6,
-	0xf3, 0xc7, 0x0e, 0x11, 0x05, 0x17, 0x0f, 0x31, 0x4d, 0x70, 0x9f, 0xaa, 0xd0, 0xa6, 0x63, 0x7a,
-	0x5b, 0x57, 0x0e, 0x70, 0xd3, 0x04, 0x98, 0xf5, 0xe5, 0xf9, 0x73, 0xae, 0xd1, 0x53, 0x28, 0x8b,
-	0x51, 0x1a, 0x1a, 0x99, 0xec, 0x7d, 0x74, 0xe5, 0x10, 0x0d, 0x13, 0x42, 0xf9, 0xf0, 0x7c, 0xed,
-	0x6a, 0xf3, 0x87, 0x22, 0x54, 0x75, 0xaf, 0x10, 0x8e, 0x9e, 0x40, 0x45, 0x2f, 0xd1, 0x65, 0xfd,
-	0x6b, 0x5b, 0x7f, 0xb5, 0x7d, 0xa9, 0x4d, 0x96, 0x8c, 0xbc, 0x02, 0xda, 0x83, 0x65, 0xd3, 0xf3,
-	0x79, 0x5f, 0x84, 0x9c, 0xf6, 0xc9, 0xff, 0xe5, 0xf9, 0x1d, 0x47, 0x25, 0xab, 0xff, 0xc5, 0x2e,
-	0x72, 0x39, 0xfb, 0x2f, 0x78, 0x91, 0xcb, 0xe9, 0xcf, 0x9c, 0x57, 0xe8, 0x3d, 0x7c, 0x79, 0xd2,
-	0x72, 0x5e, 0x9d, 0xb4, 0x9c, 0x3f, 0x4e, 0x5a, 0xce, 0x4f, 0xa7, 0xad, 0xc2, 0xab, 0xd3, 0x56,
-	0xe1, 0xd7, 0xd3, 0x56, 0x61, 0xef, 0xfe, 0x0c, 0xc1, 0x73, 0xff, 0xbc, 0x47, 0x93, 0xbf, 0x5e,
-	0xcd, 0x72, 0x7f, 0x49, 0xff, 0x89, 0xbe, 0xf7, 0x77, 0x00, 0x00, 0x00, 0xff, 0xff, 0xda, 0x6e,
-	0xf9, 0x4b, 0x1a, 0x0b, 0x00, 0x00,
+	// 1222 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xdf, 0x6e, 0xdc, 0xc4,
+	0x17, 0x8e, 0x37, 0xde, 0x24, 0x7b, 0xd6, 0x49, 0xf3, 0x9b, 0x36, 0xed, 0x2a, 0xd5, 0x6f, 0xb3,
+	0x35, 0x52, 0x1b, 0x21, 0xd8, 0x85, 0x80, 0xb8, 0x40, 0x42, 0x6a, 0x97, 0x46, 0x10, 0x28, 0xb4,
+	0x75, 0x80, 0x8b, 0x4a, 0xc8, 0x9d, 0xb5, 0x27, 0xce, 0x50, 0xaf, 0xc7, 0x99, 0x19, 0x2f, 0x5d,
+	0x5e, 0x80, 0x2b, 0x24, 0xee, 0x78, 0x01, 0x9e, 0x80, 0x87, 0xa8, 0x7a, 0xd9, 0x4b, 0x84, 0x44,
+	0x85, 0xda, 0x37, 0xe0, 0x09, 0xd0, 0x9c, 0x99, 0xfd, 0x93, 0x26, 0x0d, 0x2a, 0xea, 0x95, 0x67,
+	0x3e, 0x1f, 0x9f, 0x73, 0xe6, 0x3b, 0xe7, 0x7c, 0x63, 0xe8, 0xe4, 0x74, 0x44, 0x0b, 0xa6, 0x7b,
+	0xe6, 0xd9, 0x2b, 0x29, 0x97, 0xbc, 0xc8, 0x7a, 0x92, 0xe5, 0x74, 0xdc, 0x2d, 0xa5, 0xd0, 0x82,
+	0x5c, 0x70, 0x16, 0x5d, 0xf3, 0xec, 0x3a, 0x8b, 0xcd, 0x0b, 0x99, 0xc8, 0x04, 0x1a, 0xf4, 0xcc,
+	0xca, 0xda, 0x6e, 0xb6, 0x33, 0x21, 0xb2, 0x9c, 0xf5, 0x70, 0x37, 0xa8, 0x0e, 0x7a, 0xdf, 0x4b,
+	0x5a, 0x96, 0x4c, 0x2a, 0xf7, 0x7e, 0xeb, 0xc5, 0xf7, 0x9a, 0x0f, 0x99, 0xd2, 0x74, 0x58, 0x5a,
+	0x83, 0xf0, 0x3e, 0x04, 0x77, 0xa4, 0x18, 0xb0, 0x88, 0x1d, 0x55, 0x4c, 0x69, 0x42, 0xc0, 0xcf,
+	0x2a, 0x9e, 0xb6, 0xbc, 0x8e, 0xb7, 0xed, 0x47, 0xb8, 0x26, 0x97, 0x60, 0x59, 0x95, 0x2c, 0x89,
+	0x79, 0xda, 0xaa, 0x75, 0xbc, 0xed, 0x46, 0xb4, 0x64, 0xb6, 0x7b, 0x29, 0x79, 0x03, 0x56, 0x69,
+	0xc9, 0x63, 0x5e, 0x68, 0x26, 0x0f, 0x68, 0xc2, 0x5a, 0x8b, 0xf8, 0x3a, 0xa0, 0x25, 0xdf, 0x9b,
+	0x60, 0xe1, 0x23, 0x0f, 0xc0, 0x85, 0x28, 0xf3, 0xf1, 0xa9, 0x01, 0xae, 0x40, 0x90, 0x53, 0xcd,
+	0x94, 0x8e, 0x07, 0xb9, 0x48, 0x1e, 0x60, 0x94, 0xc5, 0xa8, 0x69, 0xb1, 0xbe, 0x81, 0xc8, 0x07,
+	0x70, 0xe9, 0x80, 0x17, 0x34, 0xe7, 0x3f, 0xb0, 0xd4, 0x5a, 0xa9, 0xf8, 0x90, 0xaa, 0x43, 0xa6,
+	0x30, 0x68, 0x10, 0x6d, 0x4c, 0x5f, 0xe3, 0x07, 0xea, 0x53, 0x7c, 0x49, 0xfe, 0x0f, 0x60, 0x68,
+	0x8c, 0x59, 0x29, 0x92, 0xc3, 0x96, 0x8f, 0x41, 0x1b, 0x06, 0xd9, 0x35, 0x00, 0x79, 0x13, 0xfe,
+	0x87, 0xaf, 0x8f, 0x85, 0xaf, 0xa3, 0xd5, 0x39, 0xf3, 0xe2, 0xd6, 0x2c, 0x85, 0xf0, 0x91, 0x0f,
+	0x41, 0x64, 0xea, 0xb4, 0xcf, 0x94, 0xe2, 0xa2, 0x98, 0xe7, 0xc5, 0x3b, 0xc6, 0xcb, 0x15, 0x08,
+	0x12, 0x51, 0x68, 0x56, 0x68, 0xcc, 0x11, 0xcf, 0x13, 0x44, 0x4d, 0x87, 0x99, 0xcc, 0x4c, 0x5e,
+	0xca, 0xba, 0x31, 0x9f, 0x2f, 0xda, 0xbc, 0x1c, 0xb2, 0x97, 0x92, 0x0d, 0x58, 0x4a, 0xaa, 0x58,
+	0x55, 0x43, 0x97, 0x72, 0x3d, 0xa9, 0xf6, 0xab, 0x21, 0xd9, 0x84, 0x95, 0x52, 0x8a, 0x11, 0x4f,
+	0x99, 0xc4, 0x2c, 0x1b, 0xd1, 0x74, 0x4f, 0x2e, 0x43, 0x03, 0xbb, 0x28, 0x2e, 0xaa, 0x61, 0x6b,
+	0x09, 0xbf, 0x5a, 0x41, 0xe0, 0xcb, 0x6a, 0x48, 0x3e, 0x07, 0x38, 0x12, 0x2a, 0x96, 0xac, 0x14,
+	0x52, 0xb7, 0x96, 0x3b, 0xde, 0x76, 0x73, 0xe7, 0xad, 0xee, 0x69, 0x8d, 0xd6, 0xbd, 0x5b, 0xd1,
+	0x9c, 0xeb, 0xf1, 0xed, 0x83, 0x7d, 0x26, 0x47, 0x3c, 0x31, 0x65, 0x13, 0x52, 0x47, 0x8d, 0x23,
+	0xa1, 0xec, 0x92, 0x5c, 0x80, 0xba, 0xa5, 0x73, 0x05, 0xeb, 0x64, 0x37, 0xe4, 0x5b, 0xb8, 0x58,
+	0x15, 0x92, 0xa9, 0x52, 0x14, 0x8a, 0x8f, 0x58, 0x3c, 0x49, 0x4c, 0xb5, 0x1a, 0x9d, 0xc5, 0xed,
+	0xe6, 0xce, 0xd5, 0xd3, 0xc3, 0x59, 0x9f, 0x2c, 0xbd, 0xe3, 0xcc, 0xa3, 0x8d, 0x79, 0x2f, 0x13,
+	0x54, 0x91, 0x10, 0x56, 0xb1, 0x52, 0xc9, 0x21, 0xe5, 0xc8, 0x19, 0xe0, 0xf9, 0x9b, 0x06, 0xfc,
+	0xd8, 0x60, 0x7b, 0x29, 0x59, 0x87, 0x45, 0xc5, 0xb3, 0x56, 0x13, 0xe9, 0x36, 0x4b, 0xf2, 0x2e,
+	0xd4, 0x07, 0x34, 0xcd, 0x58, 0x2b, 0xc0, 0x23, 0x5f, 0x3e, 0x3d, 0x87, 0xbe, 0x31, 0x89, 0xac,
+	0x25, 0xb9, 0x0f, 0x1b, 0x86, 0x2a, 0xf6, 0x30, 0x61, 0x79, 0xce, 0x8a, 0x84, 0x4d, 0x58, 0x5b,
+	0xfd, 0x0f, 0xac, 0x9d, 0x3f, 0x12, 0x6a, 0x77, 0xea, 0xc9, 0x82, 0x66, 0x22, 0xea, 0x18, 0xd2,
+	0x0c, 0x50, 0x52, 0xc5, 0x34, 0xcf, 0x45, 0x42, 0x35, 0x17, 0x85, 0x9b, 0x8a, 0x20, 0xa9, 0x6e,
+	0x4c, 0xb1, 0x19, 0xdd, 0x35, 0xdb, 0x0a, 0xb8, 0x21, 0x2d, 0x58, 0xa6, 0x69, 0x2a, 0x99, 0x52,
+	0x6e, 0xea, 0x26, 0xdb, 0x93, 0x4c, 0xf9, 0x27, 0x99, 0xda, 0x82, 0x66, 0x29, 0xc5, 0x77, 0x2c,
+	0xd1, 0xb1, 0x61, 0xac, 0x8e, 0x8c, 0x81, 0x83, 0xf6, 0x79, 0x66, 0x32, 0x1b, 0x71, 0xa9, 0x2b,
+	0x9a, 0xbb, 0xd1, 0xb1, 0x1d, 0x15, 0x38, 0x10, 0xa7, 0x27, 0xfc, 0xb3, 0x06, 0xeb, 0x38, 0x11,
+	0x77, 0x24, 0x1f, 0x51, 0xcd, 0x6e, 0x52, 0x4d, 0xc9, 0x35, 0x38, 0x97, 0x88, 0xa2, 0x60, 0x89,
+	0x49, 0x3e, 0xd6, 0xe3, 0x92, 0xb9, 0xe9, 0x58, 0x9b, 0xc1, 0x5f, 0x8d, 0x4b, 0x66, 0xc6, 0xc7,
+	0xa8, 0x47, 0x25, 0xf3, 0x89, 0xac, 0xd0, 0x92, 0x7f, 0x2d, 0x73, 0x23, 0x11, 0x29, 0xd5, 0xd4,
+	0x0d, 0x36, 0xae, 0x4d, 0x3e, 0xd2, 0x4a, 0x94, 0x1b, 0x52, 0x1f, 0x7b, 0x2f, 0x70, 0xa0, 0x15,
+	0x89, 0x13, 0x7a, 0x54, 0x3f, 0xa9, 0x47, 0xc6, 0xbb, 0xa2, 0xb9, 0xc6, 0x03, 0x05, 0x11, 0xae,
+	0xc9, 0x75, 0x58, 0x19, 0x32, 0x4d, 0x31, 0xea, 0x32, 0x76, 0x6b, 0xfb, 0xf4, 0x32, 0x7f, 0xe1,
+	0xac, 0xfa, 0xfe, 0xe3, 0xa7, 0x5b, 0x0b, 0xd1, 0xf4, 0x2b, 0x53, 0x24, 0x9a, 0xa6, 0xa2, 0xc0,
+	0x99, 0x68, 0x44, 0x76, 0x43, 0xda, 0x00, 0xec, 0xa1, 0x66, 0x85, 0x99, 0x6a, 0x3b, 0x07, 0x8d,
+	0x68, 0x0e, 0xb1, 0x2a, 0xc0, 0x0a, 0x77, 0x24, 0xc0, 0x23, 0x35, 0x0c, 0x62, 0x15, 0xe7, 0x27,
+	0x0f, 0xd6, 0x6d, 0xcf, 0xcc, 0xe6, 0x63, 0xbe, 0xf0, 0xde, 0xf1, 0xc2, 0x5f, 0x85, 0xb5, 0x94,
+	0xab, 0x19, 0xcb, 0xca, 0x75, 0xcc, 0x0b, 0x28, 0xb9, 0x08, 0x4b, 0x4c, 0x4a, 0x21, 0x95, 0xd3,
+	0x1d, 0xb7, 0x33, 0x4d, 0x31, 0xbd, 0x1e, 0x62, 0xe5, 0x18, 0x86, 0x29, 0xb4, 0x1f, 0xbe, 0x0f,
+	0x2b, 0x13, 0x02, 0x0c, 0x8d, 0x05, 0x1d, 0x4e, 0x6a, 0x8b, 0x6b, 0x43, 0xc2, 0x88, 0xe6, 0x15,
+	0x73, 0xf5, 0xb4, 0x9b, 0xf0, 0x57, 0xcf, 0xe9, 0xe6, 0xe4, 0x8e, 0xf9, 0x04, 0x56, 0xad, 0x52,
+	0x39, 0xbd, 0x43, 0x1f, 0xcd, 0x9d, 0xf0, 0x65, 0x02, 0x31, 0x93, 0x5c, 0x53, 0xef, 0xd9, 0x8e,
+	0xec, 0x02, 0x58, 0x47, 0x58, 0xb8, 0x5a, 0xc7, 0x3b, 0x4b, 0x66, 0x8e, 0xb7, 0x69, 0x64, 0xc5,
+	0xd2, 0x2c, 0x3f, 0xf3, 0x57, 0x16, 0xd7, 0xfd, 0xf0, 0x97, 0x1a, 0x80, 0x4b, 0xd3, 0xdd, 0x53,
+	0xe8, 0xd5, 0x9b, 0x6b, 0x42, 0xa7, 0x2f, 0xb5, 0x99, 0xbe, 0xbc, 0x78, 0x73, 0xf9, 0xaf, 0x74,
+	0x73, 0xd5, 0xff, 0xe5, 0xe6, 0x52, 0x3c, 0x73, 0x5f, 0xb8, 0x6e, 0x6d, 0x28, 0x9e, 0x59, 0xa3,
+	0xd7, 0xd0, 0xb2, 0x57, 0x20, 0xc0, 0xeb, 0x6b, 0xc4, 0xa4, 0xe2, 0xae, 0x73, 0xfd, 0xa8, 0x69,
+	0xb0, 0x6f, 0x2c, 0xe4, 0x98, 0xf9, 0xad, 0x06, 0x17, 0x4f, 0xd7, 0x37, 0x72, 0x0f, 0x96, 0xcd,
+	0x59, 0x8b, 0x64, 0x6c, 0x1b, 0xa1, 0x7f, 0xdd, 0x04, 0xf9, 0xe3, 0xe9, 0xd6, 0xd5, 0x8c, 0xeb,
+	0xc3, 0x6a, 0xd0, 0x4d, 0xc4, 0xb0, 0x97, 0x08, 0x35, 0x14, 0xca, 0x3d, 0xde, 0x56, 0xe9, 0x83,
+	0x9e, 0x51, 0x05, 0xd5, 0xbd, 0xc9, 0x92, 0xbf, 0x9f, 0x6e, 0xad, 0x8d, 0xe9, 0x30, 0xff, 0x30,
+	0xbc, 0x65, 0xdd, 0x84, 0xd1, 0xc4, 0x21, 0xe1, 0x10, 0xd0, 0x11, 0xe5, 0x39, 0x1d, 0x70, 0x13,
+	0xda, 0x36, 0x55, 0x7f, 0xf7, 0x95, 0x03, 0x9c, 0xb7, 0x01, 0xe6, 0x7d, 0x85, 0xd1, 0x31, 0xd7,
+	0xe4, 0x2e, 0xf8, 0x6a, 0x5c, 0x24, 0x56, 0x49, 0xfb, 0x1f, 0xbd, 0x72, 0x88, 0xa6, 0x0d, 0x61,
+	0x7c, 0x84, 0x11, 0xba, 0xda, 0xf9, 0xb1, 0x06, 0xcb, 0xd8, 0x4e, 0x4c, 0x92, 0xdb, 0x50, 0xc7,
+	0x25, 0x39, 0xab, 0xc5, 0xdd, 0x74, 0x6c, 0x76, 0xce, 0xb4, 0x29, 0xf3, 0x71, 0xb8, 0x40, 0xee,
+	0xc1, 0x9a, 0x1d, 0x8b, 0x6a, 0xa0, 0x12, 0xc9, 0x07, 0xec, 0x75, 0x79, 0x7e, 0xc7, 0x33, 0xc9,
+	0xe2, 0xef, 0xda, 0xcb, 0x5c, 0xce, 0xff, 0x2e, 0x6e, 0x76, 0xce, 0xb4, 0x41, 0x97, 0xfd, 0x1b,
+	0xf7, 0xae, 0xcd, 0x11, 0x79, 0xec, 0xf7, 0xf7, 0xe1, 0xf4, 0x07, 0x18, 0xd9, 0x7c, 0xfc, 0xac,
+	0xed, 0x3d, 0x79, 0xd6, 0xf6, 0xfe, 0x7a, 0xd6, 0xf6, 0x7e, 0x7e, 0xde, 0x5e, 0x78, 0xf2, 0xbc,
+	0xbd, 0xf0, 0xfb, 0xf3, 0xf6, 0xc2, 0x60, 0x09, 0x7f, 0x56, 0xdf, 0xfb, 0x67, 0x00, 0x6c, 0xc7,
+	0x64, 0xf7, 0x3d, 0x0b, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1603,6 +1612,11 @@ func (m *RelayReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.SpecVersion != 0 {
+		i = encodeVarintRelay(dAtA, i, uint64(m.SpecVersion))
+		i--
+		dAtA[i] = 0x40
+	}
 	if len(m.Metadata) > 0 {
 		for iNdEx := len(m.Metadata) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1989,6 +2003,9 @@ func (m *RelayReply) Size() (n int) {
 			n += 1 + l + sovRelay(uint64(l))
 		}
 	}
+	if m.SpecVersion != 0 {
+		n += 1 + sovRelay(uint64(m.SpecVersion))
+	}
 	return n
 }
 
@@ -3886,6 +3903,25 @@ func (m *RelayReply) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpecVersion", wireType)
+			}
+			m.SpecVersion = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRelay
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SpecVersion |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRelay(dAtA[iNdEx:])
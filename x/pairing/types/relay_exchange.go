@@ -1,8 +1,10 @@
 package types
 
 import (
+	"bytes"
 	"strings"
 
+	"github.com/cosmos/gogoproto/proto"
 	"github.com/lavanet/lava/utils"
 	"github.com/lavanet/lava/utils/sigs"
 )
@@ -23,23 +25,24 @@ func (re RelayExchange) GetSignature() []byte {
 
 func (re RelayExchange) DataToSign() []byte {
 	re.Reply.Sig = nil
-	var metadataBytes []byte
+	// we remove the salt from the signature because it can be different
+	re.Request.RelayData.Salt = nil
+	// reply data, the relay data text and the marshaled metadata, joined in one buffer
+	buf := bytes.NewBuffer(make([]byte, 0, len(re.Reply.GetData())+re.Request.RelayData.Size()*2))
+	buf.Write(re.Reply.GetData())
+	proto.CompactText(buf, re.Request.RelayData)
 	for _, metadata := range re.Reply.GetMetadata() {
 		data, err := metadata.Marshal()
 		if err != nil {
 			utils.LavaFormatError("metadata can't be marshaled to bytes", err)
 		}
-		metadataBytes = append(metadataBytes, data...)
+		buf.Write(data)
 	}
-	// we remove the salt from the signature because it can be different
-	re.Request.RelayData.Salt = nil
-	msgParts := [][]byte{
-		re.Reply.GetData(),
-		[]byte(re.Request.RelayData.String()),
-		metadataBytes,
+	if re.Reply.SpecVersion != 0 {
+		// only signed when set, so replies without it keep their signatures
+		buf.Write(sigs.EncodeUint64(re.Reply.SpecVersion))
 	}
-
-	return sigs.Join(msgParts)
+	return buf.Bytes()
 }
 
 func (re RelayExchange) HashRounds() int {
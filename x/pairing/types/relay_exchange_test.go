@@ -0,0 +1,41 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/lavanet/lava/utils/sigs"
+	"github.com/stretchr/testify/require"
+)
+
+// the signed data is consensus relevant, it must stay the text format relays were always signed with
+func TestDataToSign(t *testing.T) {
+	relayData := &RelayPrivateData{
+		ConnectionType: "POST",
+		ApiUrl:         "/url",
+		Data:           []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`),
+		RequestBlock:   -2,
+		ApiInterface:   "jsonrpc",
+		Salt:           []byte("salt"),
+		Metadata:       []Metadata{{Name: "key", Value: "value"}},
+		Extensions:     []string{"archive"},
+		SeenBlock:      10,
+	}
+	session := RelaySession{SpecId: "ETH1", ContentHash: []byte("hash"), SessionId: 1, CuSum: 10, Provider: "provider", RelayNum: 2, Epoch: 20, LavaChainId: "lava", Sig: []byte("sig"), Badge: &Badge{CuAllocation: 1}}
+
+	expectedSession := session
+	expectedSession.Sig = nil
+	expectedSession.Badge = nil
+	require.Equal(t, []byte(expectedSession.String()), session.DataToSign())
+
+	reply := RelayReply{Data: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`), Sig: []byte("sig"), Metadata: []Metadata{{Name: "a", Value: "b"}, {Name: "c", Value: "d"}}}
+	expectedRelayData := *relayData
+	expectedRelayData.Salt = nil
+	var metadataBytes []byte
+	for _, metadata := range reply.Metadata {
+		data, err := metadata.Marshal()
+		require.NoError(t, err)
+		metadataBytes = append(metadataBytes, data...)
+	}
+	expected := sigs.Join([][]byte{reply.Data, []byte(expectedRelayData.String()), metadataBytes})
+	require.Equal(t, expected, NewRelayExchange(RelayRequest{RelaySession: &session, RelayData: relayData}, reply).DataToSign())
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"bytes"
+
+	"github.com/cosmos/gogoproto/proto"
 	"github.com/lavanet/lava/utils/sigs"
 )
 
@@ -12,7 +15,10 @@ func (rs RelaySession) DataToSign() []byte {
 	rs.Badge = nil // its not a part of the signature, its a separate part
 	rs.Sig = nil
 	// utils.LavaFormatError("DEBUG", nil, utils.Attribute{"RelayString", rs.String()})
-	return []byte(rs.String())
+	// same bytes as rs.String(), written straight into the returned buffer
+	var buf bytes.Buffer
+	proto.CompactText(&buf, &rs)
+	return buf.Bytes()
 }
 
 func (rs RelaySession) HashRounds() int {
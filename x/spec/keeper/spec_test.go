@@ -852,6 +852,42 @@ func TestCookbookSpecs(t *testing.T) {
 	}
 }
 
+func TestSpecApiReliabilityFields(t *testing.T) {
+	spec, err := keepertest.GetASpec("LAV1", "../../../", nil, nil)
+	require.NoError(t, err)
+	var restApi, grpcApi *types.Api
+	for _, apiCollection := range spec.ApiCollections {
+		switch apiCollection.CollectionData.ApiInterface {
+		case types.APIInterfaceRest:
+			restApi = apiCollection.Apis[0]
+		case types.APIInterfaceGrpc:
+			grpcApi = apiCollection.Apis[0]
+		}
+	}
+	require.NotNil(t, restApi)
+	require.NotNil(t, grpcApi)
+
+	restApi.ReliabilityLevel = types.ReliabilityLevelAlways
+	restApi.Paginated = true
+	restApi.ReplySchema = `{"type":"object","required":["pagination"]}`
+	_, err = spec.ValidateSpec(10000000)
+	require.NoError(t, err)
+
+	restApi.ReliabilityLevel = "sometimes"
+	_, err = spec.ValidateSpec(10000000)
+	require.Error(t, err)
+	restApi.ReliabilityLevel = ""
+
+	restApi.ReplySchema = `{"type":`
+	_, err = spec.ValidateSpec(10000000)
+	require.Error(t, err)
+	restApi.ReplySchema = ""
+
+	grpcApi.Paginated = true
+	_, err = spec.ValidateSpec(10000000)
+	require.Error(t, err)
+}
+
 func getAllFilesInDirectory(directory string) ([]string, error) {
 	var files []string
 
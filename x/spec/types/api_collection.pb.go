@@ -651,6 +651,9 @@ type Api struct {
 	Category          SpecCategory `protobuf:"bytes,6,opt,name=category,proto3" json:"category"`
 	BlockParsing      BlockParser  `protobuf:"bytes,7,opt,name=block_parsing,json=blockParsing,proto3" json:"block_parsing"`
 	TimeoutMs         uint64       `protobuf:"varint,8,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	ReliabilityLevel  string       `protobuf:"bytes,9,opt,name=reliability_level,json=reliabilityLevel,proto3" json:"reliability_level,omitempty"`
+	Paginated         bool         `protobuf:"varint,10,opt,name=paginated,proto3" json:"paginated,omitempty"`
+	ReplySchema       string       `protobuf:"bytes,11,opt,name=reply_schema,json=replySchema,proto3" json:"reply_schema,omitempty"`
 }
 
 func (m *Api) Reset()         { *m = Api{} }
@@ -735,6 +738,27 @@ func (m *Api) GetTimeoutMs() uint64 {
 	return 0
 }
 
+func (m *Api) GetReliabilityLevel() string {
+	if m != nil {
+		return m.ReliabilityLevel
+	}
+	return ""
+}
+
+func (m *Api) GetPaginated() bool {
+	if m != nil {
+		return m.Paginated
+	}
+	return false
+}
+
+func (m *Api) GetReplySchema() string {
+	if m != nil {
+		return m.ReplySchema
+	}
+	return ""
+}
+
 type ParseDirective struct {
 	FunctionTag      FUNCTION_TAG `protobuf:"varint,1,opt,name=function_tag,json=functionTag,proto3,enum=lavanet.lava.spec.FUNCTION_TAG" json:"function_tag,omitempty"`
 	FunctionTemplate string       `protobuf:"bytes,2,opt,name=function_template,json=functionTemplate,proto3" json:"function_template,omitempty"`
@@ -971,96 +995,99 @@ func init() {
 }
 
 var fileDescriptor_c9f7567a181f534f = []byte{
-	// 1417 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0x4f, 0x6f, 0xdb, 0xc6,
-	0x12, 0x37, 0x25, 0x5a, 0x96, 0x46, 0x7f, 0xcc, 0x6c, 0xfc, 0xf2, 0x94, 0x3c, 0x47, 0xf2, 0x63,
-	0xf2, 0x5e, 0x0d, 0x07, 0xb5, 0x51, 0x07, 0x05, 0x8a, 0xa0, 0x40, 0x41, 0x49, 0x74, 0xa2, 0x44,
-	0x96, 0x8c, 0xb5, 0xec, 0xd6, 0xbd, 0x10, 0x6b, 0x6a, 0x2d, 0x2f, 0x42, 0x91, 0x2c, 0xb9, 0x34,
-	0xec, 0x73, 0xbf, 0x40, 0x3f, 0x43, 0x4f, 0x05, 0x0a, 0x14, 0xe8, 0xa1, 0xdf, 0x21, 0xc7, 0x1c,
-	0x7b, 0x12, 0x0a, 0xe7, 0x50, 0x34, 0xc7, 0xdc, 0x7a, 0x28, 0x50, 0xec, 0x92, 0x92, 0x45, 0x47,
-	0x49, 0x91, 0x93, 0x34, 0xbf, 0xf9, 0xed, 0x6f, 0x67, 0x67, 0x66, 0x67, 0x09, 0xff, 0x77, 0xc8,
-	0x19, 0x71, 0x29, 0xdf, 0x12, 0xbf, 0x5b, 0xa1, 0x4f, 0xed, 0x2d, 0xe2, 0x33, 0xcb, 0xf6, 0x1c,
-	0x87, 0xda, 0x9c, 0x79, 0xee, 0xa6, 0x1f, 0x78, 0xdc, 0x43, 0x37, 0x12, 0xde, 0xa6, 0xf8, 0xdd,
-	0x14, 0xbc, 0x3b, 0x2b, 0x43, 0x6f, 0xe8, 0x49, 0xef, 0x96, 0xf8, 0x17, 0x13, 0xf5, 0xbf, 0xb2,
-	0x50, 0x36, 0x7c, 0xd6, 0x9c, 0x0a, 0xa0, 0x2a, 0x2c, 0x51, 0x97, 0x1c, 0x3b, 0x74, 0x50, 0x55,
-	0xd6, 0x94, 0xf5, 0x3c, 0x9e, 0x98, 0x68, 0x0f, 0x96, 0xaf, 0x36, 0xb2, 0x06, 0x84, 0x93, 0x6a,
-	0x66, 0x4d, 0x59, 0x2f, 0x6e, 0xff, 0x77, 0xf3, 0xad, 0xed, 0x36, 0xaf, 0x14, 0x5b, 0x84, 0x93,
-	0x86, 0xfa, 0x62, 0x5c, 0x5f, 0xc0, 0x15, 0x3b, 0x85, 0xa2, 0x0d, 0x50, 0x89, 0xcf, 0xc2, 0x6a,
-	0x76, 0x2d, 0xbb, 0x5e, 0xdc, 0xbe, 0x35, 0x47, 0xc6, 0xf0, 0x19, 0x96, 0x1c, 0xf4, 0x10, 0x96,
-	0x4e, 0x29, 0x19, 0xd0, 0x20, 0xac, 0xaa, 0x92, 0x7e, 0x7b, 0x0e, 0xfd, 0x89, 0x64, 0xe0, 0x09,
-	0x13, 0x75, 0x40, 0x63, 0xee, 0x29, 0x0d, 0x18, 0x27, 0xae, 0x4d, 0x2d, 0xb9, 0xd9, 0xa2, 0x5c,
-	0xfd, 0xcf, 0x31, 0xe3, 0xe5, 0x99, 0xa5, 0x86, 0x08, 0xa1, 0x03, 0x9a, 0x4f, 0x82, 0x90, 0x5a,
-	0x03, 0x16, 0x08, 0xde, 0x19, 0x0d, 0xab, 0xb9, 0x77, 0xaa, 0xed, 0x09, 0x6a, 0x6b, 0xc2, 0xc4,
-	0xcb, 0x7e, 0xca, 0x0e, 0xd1, 0xe7, 0x00, 0xf4, 0x9c, 0x53, 0x37, 0x64, 0x9e, 0x1b, 0x56, 0x97,
-	0xa4, 0xce, 0xea, 0x1c, 0x1d, 0x73, 0x42, 0xc2, 0x33, 0x7c, 0x64, 0x42, 0xf9, 0x8c, 0x06, 0xec,
-	0x84, 0xd9, 0x84, 0x4b, 0x81, 0xbc, 0x14, 0xa8, 0xcf, 0x11, 0x38, 0x9c, 0xe1, 0xe1, 0xf4, 0x2a,
-	0xfd, 0x1b, 0x28, 0x4c, 0xf5, 0x11, 0x02, 0xd5, 0x25, 0x23, 0x2a, 0xeb, 0x5e, 0xc0, 0xf2, 0x3f,
-	0xba, 0x07, 0x65, 0x3b, 0xb2, 0x46, 0x91, 0xc3, 0x99, 0xef, 0x30, 0x1a, 0xc8, 0x92, 0x67, 0x70,
-	0xc9, 0x8e, 0x76, 0xa7, 0x18, 0x7a, 0x00, 0x6a, 0x10, 0x39, 0xb4, 0x9a, 0x95, 0xed, 0xf0, 0xef,
-	0x39, 0x31, 0xe0, 0xc8, 0xa1, 0x58, 0x92, 0xf4, 0x55, 0x50, 0x85, 0x85, 0x56, 0x60, 0xf1, 0xd8,
-	0xf1, 0xec, 0xe7, 0x72, 0x3b, 0x15, 0xc7, 0x86, 0xfe, 0xa3, 0x02, 0xa5, 0xd9, 0x80, 0xe7, 0x06,
-	0xf5, 0x14, 0x96, 0xaf, 0x15, 0xe2, 0x3d, 0x9d, 0x78, 0xad, 0x0e, 0x95, 0x74, 0x1d, 0xd0, 0xa7,
-	0x90, 0x3b, 0x23, 0x4e, 0x44, 0x27, 0x5d, 0x78, 0xf7, 0x5d, 0x12, 0x87, 0x82, 0x85, 0x13, 0xf2,
-	0x53, 0x35, 0xaf, 0x6a, 0x8b, 0xfa, 0x9f, 0x0a, 0xc0, 0x95, 0x13, 0xad, 0x42, 0x61, 0x5a, 0xa2,
-	0x24, 0xe0, 0x2b, 0x00, 0xfd, 0x0f, 0x2a, 0xf4, 0xdc, 0xa7, 0x36, 0xa7, 0x03, 0x4b, 0xaa, 0xc8,
-	0xa0, 0x0b, 0xb8, 0x3c, 0x41, 0x63, 0x91, 0x8f, 0x60, 0xd9, 0x21, 0x9c, 0x86, 0xdc, 0x1a, 0xb0,
-	0x50, 0x36, 0x9f, 0xcc, 0xab, 0x8a, 0x2b, 0x31, 0xdc, 0x4a, 0x50, 0xd4, 0x85, 0x7c, 0x48, 0x45,
-	0x39, 0xf9, 0x45, 0x55, 0x5d, 0x53, 0xd6, 0x2b, 0xdb, 0xdb, 0xef, 0x8d, 0x3d, 0xd5, 0x08, 0xfb,
-	0xc9, 0x4a, 0x3c, 0xd5, 0xd0, 0x3f, 0x86, 0x95, 0x79, 0x0c, 0x94, 0x07, 0x75, 0x87, 0x30, 0x47,
-	0x5b, 0x40, 0x45, 0x58, 0xfa, 0x92, 0x04, 0x2e, 0x73, 0x87, 0x9a, 0xa2, 0xff, 0x9c, 0x81, 0x4a,
-	0xfa, 0xc6, 0xa0, 0x43, 0x28, 0x8b, 0x71, 0xc4, 0x5c, 0x4e, 0x83, 0x13, 0x62, 0x27, 0x45, 0x6b,
-	0x7c, 0xf2, 0x7a, 0x5c, 0x4f, 0x3b, 0xde, 0x8c, 0xeb, 0xab, 0x23, 0xe2, 0x87, 0x3c, 0x88, 0x6c,
-	0x1e, 0x05, 0xf4, 0x91, 0x9e, 0x72, 0xeb, 0xb8, 0x44, 0x7c, 0xd6, 0x9e, 0x98, 0x42, 0x57, 0xfa,
-	0x5c, 0xe2, 0x58, 0x3e, 0xe1, 0xa7, 0x71, 0xe2, 0x62, 0xdd, 0x94, 0xe3, 0x6d, 0xdd, 0x94, 0x5b,
-	0xc7, 0xa5, 0x89, 0xbd, 0x47, 0xf8, 0x29, 0x7a, 0x08, 0x2a, 0xbf, 0xf0, 0xe3, 0xfc, 0x16, 0x1a,
-	0xf5, 0xd7, 0xe3, 0xba, 0xb4, 0xdf, 0x8c, 0xeb, 0x37, 0xd3, 0x2a, 0x02, 0xd5, 0xb1, 0x74, 0xa2,
-	0x47, 0x90, 0x23, 0x83, 0x81, 0xe5, 0xb9, 0x32, 0xe9, 0x85, 0xc6, 0xbd, 0xd7, 0xe3, 0x7a, 0x82,
-	0xbc, 0x19, 0xd7, 0xff, 0x75, 0xed, 0x58, 0x12, 0xd7, 0xf1, 0x22, 0x19, 0x0c, 0x7a, 0xae, 0xfe,
-	0xbb, 0x02, 0xb9, 0x78, 0x46, 0xcd, 0xed, 0xeb, 0xcf, 0x40, 0x7d, 0xce, 0xdc, 0x81, 0x3c, 0x5e,
-	0x65, 0xfb, 0xfe, 0x3b, 0x07, 0x5c, 0xf2, 0xd3, 0xbf, 0xf0, 0x29, 0x96, 0x2b, 0x50, 0x03, 0x4a,
-	0x27, 0x91, 0x1b, 0x4f, 0x66, 0x4e, 0x86, 0xf2, 0x44, 0x95, 0xb9, 0xd3, 0x60, 0xe7, 0xa0, 0xdb,
-	0xec, 0xb7, 0x7b, 0x5d, 0xab, 0x6f, 0x3c, 0xc6, 0xc5, 0xc9, 0xa2, 0x3e, 0x19, 0xea, 0xcf, 0x00,
-	0xae, 0x74, 0x51, 0x19, 0x0a, 0x3e, 0x09, 0x43, 0x2b, 0xa4, 0xee, 0x40, 0x5b, 0x40, 0x15, 0x00,
-	0x69, 0x06, 0xd4, 0x77, 0x2e, 0x34, 0x65, 0xea, 0x3e, 0xf6, 0xf8, 0xa9, 0x96, 0x41, 0xcb, 0x50,
-	0x94, 0x26, 0x1b, 0xba, 0x5e, 0x40, 0xb5, 0xac, 0xfe, 0x4b, 0x06, 0xb2, 0x86, 0xcf, 0xde, 0xf3,
-	0x9c, 0x4c, 0x12, 0x90, 0xb9, 0x36, 0x6d, 0xbc, 0x91, 0x1f, 0x71, 0x6a, 0x45, 0x2e, 0xe3, 0x61,
-	0xd2, 0xf9, 0xa5, 0x04, 0x3c, 0x10, 0x18, 0xda, 0x84, 0x9b, 0xf4, 0x9c, 0x07, 0xc4, 0x4a, 0x53,
-	0x55, 0x49, 0xbd, 0x21, 0x5d, 0xcd, 0x59, 0xbe, 0x01, 0x79, 0x9b, 0x70, 0x3a, 0xf4, 0x82, 0x8b,
-	0x6a, 0x4e, 0x8e, 0x89, 0x79, 0x79, 0xd9, 0xf7, 0xa9, 0xdd, 0x4c, 0x68, 0xc9, 0x73, 0x35, 0x5d,
-	0x86, 0xda, 0x50, 0x96, 0xe3, 0xc9, 0x12, 0xc3, 0x83, 0xb9, 0xc3, 0xea, 0x92, 0xd4, 0xa9, 0xcd,
-	0xd1, 0x69, 0x08, 0x9e, 0xbc, 0x74, 0x41, 0x22, 0x53, 0x3a, 0x9e, 0x40, 0xcc, 0x1d, 0xa2, 0xbb,
-	0x00, 0x9c, 0x8d, 0xa8, 0x17, 0x71, 0x6b, 0x24, 0xa6, 0xb6, 0x08, 0xba, 0x90, 0x20, 0xbb, 0xa1,
-	0xfe, 0x87, 0x02, 0x95, 0xf4, 0xc4, 0x7a, 0xab, 0xb6, 0xca, 0x87, 0xd7, 0x16, 0x3d, 0x80, 0x1b,
-	0x57, 0x1a, 0x74, 0xe4, 0x8b, 0x51, 0x92, 0x64, 0x5e, 0x9b, 0xf2, 0x12, 0x1c, 0x3d, 0x83, 0x4a,
-	0x40, 0xc3, 0xc8, 0xe1, 0xd3, 0xe3, 0x66, 0x3f, 0xe0, 0xb8, 0xe5, 0x78, 0xed, 0xe4, 0xbc, 0xb7,
-	0x21, 0x2f, 0xee, 0xb6, 0x2c, 0xb5, 0xbc, 0x30, 0x78, 0x89, 0xf8, 0xac, 0x4b, 0x46, 0x54, 0xff,
-	0x49, 0x81, 0xe2, 0xcc, 0x7a, 0x91, 0x1a, 0x39, 0x9c, 0x03, 0x8b, 0x04, 0xe2, 0x98, 0x59, 0x31,
-	0x3f, 0x63, 0xc4, 0x08, 0x86, 0xe8, 0x0b, 0xd1, 0x63, 0xd2, 0x2d, 0x22, 0x4e, 0x2e, 0xc9, 0xbc,
-	0x98, 0xf6, 0x0c, 0xbc, 0x6f, 0x62, 0x4b, 0x64, 0x03, 0x27, 0x8a, 0x3b, 0x91, 0x6b, 0x8b, 0xee,
-	0x1a, 0xd0, 0x13, 0x22, 0x0e, 0x16, 0xcf, 0x5f, 0x79, 0xef, 0x71, 0x29, 0x01, 0xe3, 0xf1, 0x7b,
-	0x07, 0xf2, 0xd4, 0xb5, 0xbd, 0x81, 0x38, 0x76, 0x1c, 0xef, 0xd4, 0x96, 0x8f, 0xd3, 0x6c, 0x9f,
-	0xa0, 0xfb, 0x42, 0x91, 0xd3, 0x60, 0xc4, 0x5c, 0x16, 0x72, 0x66, 0x27, 0x3d, 0x9e, 0x06, 0xc5,
-	0x4b, 0xe7, 0x78, 0x36, 0x71, 0x64, 0xc8, 0x79, 0x1c, 0x1b, 0x48, 0x87, 0x52, 0x18, 0x1d, 0x87,
-	0x76, 0xc0, 0x7c, 0x91, 0x7d, 0x19, 0x4c, 0x1e, 0xa7, 0x30, 0x11, 0x4c, 0xc8, 0x09, 0xa7, 0x27,
-	0x91, 0x23, 0x83, 0x29, 0xe3, 0xa9, 0x8d, 0xea, 0x50, 0x3c, 0x25, 0xee, 0x90, 0xb9, 0x43, 0xf1,
-	0x5d, 0x53, 0x5d, 0x94, 0xcb, 0x21, 0x81, 0x0c, 0x9f, 0x6d, 0xe8, 0x50, 0x30, 0xbf, 0xea, 0x9b,
-	0xdd, 0xfd, 0x76, 0xaf, 0x2b, 0x86, 0x78, 0xb7, 0xd7, 0x35, 0xe3, 0x21, 0x6e, 0xe0, 0xe6, 0x93,
-	0xf6, 0xa1, 0xa9, 0x29, 0x1b, 0xdf, 0x2b, 0x50, 0x9a, 0xed, 0x1a, 0x54, 0x82, 0x7c, 0xab, 0xbd,
-	0x6f, 0x34, 0x3a, 0x66, 0x4b, 0x5b, 0x40, 0x1a, 0x94, 0x1e, 0x9b, 0x7d, 0xab, 0xd1, 0xe9, 0x35,
-	0x9f, 0x75, 0x0f, 0x76, 0x35, 0x05, 0xad, 0x80, 0x36, 0x45, 0xac, 0xc6, 0x91, 0x25, 0xd0, 0x0c,
-	0xba, 0x03, 0xb7, 0xf6, 0xcd, 0xbe, 0xd5, 0x31, 0xfa, 0xe6, 0x7e, 0xdf, 0x6a, 0x77, 0xad, 0x5d,
-	0xb3, 0x6f, 0xb4, 0x8c, 0xbe, 0xa1, 0x65, 0xd1, 0x2d, 0x40, 0x69, 0x5f, 0xa3, 0xd7, 0x3a, 0xd2,
-	0x54, 0xa1, 0x7d, 0x68, 0xe2, 0xf6, 0x4e, 0xbb, 0x69, 0x88, 0xdd, 0xb5, 0x45, 0xc1, 0x14, 0xda,
-	0xa6, 0x81, 0x3b, 0x6d, 0xc1, 0x95, 0x9b, 0x68, 0xb9, 0x8d, 0x6f, 0x15, 0x28, 0xce, 0xd4, 0x14,
-	0x15, 0x60, 0xd1, 0xdc, 0xdd, 0xeb, 0x1f, 0xc5, 0x01, 0x4a, 0x8f, 0x08, 0xc5, 0xc0, 0x8f, 0x35,
-	0x05, 0xdd, 0x84, 0xe5, 0x18, 0x69, 0x1a, 0xdd, 0x5e, 0xb7, 0xdd, 0x34, 0x3a, 0x5a, 0x46, 0x44,
-	0x1d, 0x83, 0xad, 0xb6, 0x3c, 0xaa, 0x81, 0x8f, 0xb4, 0x2c, 0xaa, 0xc3, 0x7f, 0xae, 0xa3, 0x56,
-	0x0f, 0x5b, 0x3d, 0xdc, 0x32, 0xb1, 0xd9, 0xd2, 0x54, 0x91, 0xaa, 0x96, 0xb9, 0x63, 0x1c, 0x74,
-	0xfa, 0x5a, 0xae, 0xd1, 0xf8, 0xe1, 0xb2, 0xa6, 0xbc, 0xb8, 0xac, 0x29, 0x2f, 0x2f, 0x6b, 0xca,
-	0x6f, 0x97, 0x35, 0xe5, 0xbb, 0x57, 0xb5, 0x85, 0x97, 0xaf, 0x6a, 0x0b, 0xbf, 0xbe, 0xaa, 0x2d,
-	0x7c, 0x7d, 0x7f, 0xc8, 0xf8, 0x69, 0x74, 0xbc, 0x69, 0x7b, 0xa3, 0xad, 0xd4, 0x47, 0xfa, 0x79,
-	0xfc, 0x99, 0x2e, 0x9e, 0x8e, 0xf0, 0x38, 0x27, 0xbf, 0xba, 0x1f, 0xfe, 0x1d, 0x00, 0x00, 0xff,
-	0xff, 0xb6, 0xa4, 0x86, 0xcc, 0xc8, 0x0b, 0x00, 0x00,
+	// 1470 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x57, 0x4f, 0x6f, 0xdb, 0xc8,
+	0x15, 0x37, 0x25, 0x5a, 0x96, 0x9e, 0xfe, 0x98, 0x99, 0xb8, 0x29, 0x37, 0xf5, 0x4a, 0x5e, 0x6e,
+	0xda, 0x1a, 0x5e, 0xd4, 0x46, 0x1d, 0x14, 0x28, 0x16, 0x05, 0x0a, 0x4a, 0xa2, 0xb3, 0xda, 0xc8,
+	0x92, 0x31, 0x92, 0xdd, 0xba, 0x17, 0x62, 0x44, 0x8d, 0xa5, 0x41, 0x28, 0x92, 0x25, 0x87, 0x86,
+	0x7d, 0xee, 0x17, 0xe8, 0x67, 0x68, 0x2f, 0x05, 0x0a, 0x14, 0xe8, 0xb7, 0xc8, 0x31, 0xc7, 0x9e,
+	0x8c, 0xc2, 0x39, 0x14, 0xcd, 0x31, 0xb7, 0x1e, 0x0a, 0x14, 0x33, 0xa4, 0xfe, 0xd0, 0x51, 0x82,
+	0xe6, 0x44, 0xbe, 0xdf, 0xfb, 0xcd, 0x6f, 0xde, 0xbc, 0xf7, 0xf8, 0x46, 0x82, 0x9f, 0xb8, 0xe4,
+	0x9a, 0x78, 0x94, 0x1f, 0x89, 0xe7, 0x51, 0x14, 0x50, 0xe7, 0x88, 0x04, 0xcc, 0x76, 0x7c, 0xd7,
+	0xa5, 0x0e, 0x67, 0xbe, 0x77, 0x18, 0x84, 0x3e, 0xf7, 0xd1, 0xa3, 0x94, 0x77, 0x28, 0x9e, 0x87,
+	0x82, 0xf7, 0x74, 0x67, 0xe2, 0x4f, 0x7c, 0xe9, 0x3d, 0x12, 0x6f, 0x09, 0xd1, 0xf8, 0x6f, 0x1e,
+	0xaa, 0x66, 0xc0, 0x5a, 0x0b, 0x01, 0xa4, 0xc3, 0x16, 0xf5, 0xc8, 0xc8, 0xa5, 0x63, 0x5d, 0xd9,
+	0x53, 0xf6, 0x8b, 0x78, 0x6e, 0xa2, 0x33, 0xd8, 0x5e, 0x6e, 0x64, 0x8f, 0x09, 0x27, 0x7a, 0x6e,
+	0x4f, 0xd9, 0x2f, 0x1f, 0x7f, 0x75, 0xf8, 0xc1, 0x76, 0x87, 0x4b, 0xc5, 0x36, 0xe1, 0xa4, 0xa9,
+	0xbe, 0xbe, 0x6b, 0x6c, 0xe0, 0x9a, 0x93, 0x41, 0xd1, 0x01, 0xa8, 0x24, 0x60, 0x91, 0x9e, 0xdf,
+	0xcb, 0xef, 0x97, 0x8f, 0x9f, 0xac, 0x91, 0x31, 0x03, 0x86, 0x25, 0x07, 0x3d, 0x87, 0xad, 0x29,
+	0x25, 0x63, 0x1a, 0x46, 0xba, 0x2a, 0xe9, 0x5f, 0xac, 0xa1, 0x7f, 0x27, 0x19, 0x78, 0xce, 0x44,
+	0x5d, 0xd0, 0x98, 0x37, 0xa5, 0x21, 0xe3, 0xc4, 0x73, 0xa8, 0x2d, 0x37, 0xdb, 0xdc, 0xcb, 0xff,
+	0x5f, 0x31, 0xe3, 0xed, 0x95, 0xa5, 0xa6, 0x08, 0xa1, 0x0b, 0x5a, 0x40, 0xc2, 0x88, 0xda, 0x63,
+	0x16, 0x0a, 0xde, 0x35, 0x8d, 0xf4, 0xc2, 0x47, 0xd5, 0xce, 0x04, 0xb5, 0x3d, 0x67, 0xe2, 0xed,
+	0x20, 0x63, 0x47, 0xe8, 0x57, 0x00, 0xf4, 0x86, 0x53, 0x2f, 0x62, 0xbe, 0x17, 0xe9, 0x5b, 0x52,
+	0x67, 0x77, 0x8d, 0x8e, 0x35, 0x27, 0xe1, 0x15, 0x3e, 0xb2, 0xa0, 0x7a, 0x4d, 0x43, 0x76, 0xc5,
+	0x1c, 0xc2, 0xa5, 0x40, 0x51, 0x0a, 0x34, 0xd6, 0x08, 0x5c, 0xac, 0xf0, 0x70, 0x76, 0x95, 0xf1,
+	0x7b, 0x28, 0x2d, 0xf4, 0x11, 0x02, 0xd5, 0x23, 0x33, 0x2a, 0xeb, 0x5e, 0xc2, 0xf2, 0x1d, 0x7d,
+	0x0d, 0x55, 0x27, 0xb6, 0x67, 0xb1, 0xcb, 0x59, 0xe0, 0x32, 0x1a, 0xca, 0x92, 0xe7, 0x70, 0xc5,
+	0x89, 0x4f, 0x17, 0x18, 0xfa, 0x06, 0xd4, 0x30, 0x76, 0xa9, 0x9e, 0x97, 0xed, 0xf0, 0xc3, 0x35,
+	0x31, 0xe0, 0xd8, 0xa5, 0x58, 0x92, 0x8c, 0x5d, 0x50, 0x85, 0x85, 0x76, 0x60, 0x73, 0xe4, 0xfa,
+	0xce, 0x2b, 0xb9, 0x9d, 0x8a, 0x13, 0xc3, 0xf8, 0xab, 0x02, 0x95, 0xd5, 0x80, 0xd7, 0x06, 0xf5,
+	0x3d, 0x6c, 0x3f, 0x28, 0xc4, 0x27, 0x3a, 0xf1, 0x41, 0x1d, 0x6a, 0xd9, 0x3a, 0xa0, 0x5f, 0x40,
+	0xe1, 0x9a, 0xb8, 0x31, 0x9d, 0x77, 0xe1, 0x97, 0x1f, 0x93, 0xb8, 0x10, 0x2c, 0x9c, 0x92, 0xbf,
+	0x57, 0x8b, 0xaa, 0xb6, 0x69, 0xfc, 0x47, 0x01, 0x58, 0x3a, 0xd1, 0x2e, 0x94, 0x16, 0x25, 0x4a,
+	0x03, 0x5e, 0x02, 0xe8, 0xc7, 0x50, 0xa3, 0x37, 0x01, 0x75, 0x38, 0x1d, 0xdb, 0x52, 0x45, 0x06,
+	0x5d, 0xc2, 0xd5, 0x39, 0x9a, 0x88, 0xfc, 0x14, 0xb6, 0x5d, 0xc2, 0x69, 0xc4, 0xed, 0x31, 0x8b,
+	0x64, 0xf3, 0xc9, 0xbc, 0xaa, 0xb8, 0x96, 0xc0, 0xed, 0x14, 0x45, 0x3d, 0x28, 0x46, 0x54, 0x94,
+	0x93, 0xdf, 0xea, 0xea, 0x9e, 0xb2, 0x5f, 0x3b, 0x3e, 0xfe, 0x64, 0xec, 0x99, 0x46, 0x18, 0xa4,
+	0x2b, 0xf1, 0x42, 0xc3, 0xf8, 0x19, 0xec, 0xac, 0x63, 0xa0, 0x22, 0xa8, 0x27, 0x84, 0xb9, 0xda,
+	0x06, 0x2a, 0xc3, 0xd6, 0x6f, 0x48, 0xe8, 0x31, 0x6f, 0xa2, 0x29, 0xc6, 0xdf, 0x73, 0x50, 0xcb,
+	0x7e, 0x31, 0xe8, 0x02, 0xaa, 0x62, 0x1c, 0x31, 0x8f, 0xd3, 0xf0, 0x8a, 0x38, 0x69, 0xd1, 0x9a,
+	0x3f, 0x7f, 0x77, 0xd7, 0xc8, 0x3a, 0xde, 0xdf, 0x35, 0x76, 0x67, 0x24, 0x88, 0x78, 0x18, 0x3b,
+	0x3c, 0x0e, 0xe9, 0xb7, 0x46, 0xc6, 0x6d, 0xe0, 0x0a, 0x09, 0x58, 0x67, 0x6e, 0x0a, 0x5d, 0xe9,
+	0xf3, 0x88, 0x6b, 0x07, 0x84, 0x4f, 0xf5, 0xdc, 0x52, 0x37, 0xe3, 0xf8, 0x50, 0x37, 0xe3, 0x36,
+	0x70, 0x65, 0x6e, 0x9f, 0x11, 0x3e, 0x45, 0xcf, 0x41, 0xe5, 0xb7, 0x41, 0x92, 0xdf, 0x52, 0xb3,
+	0xf1, 0xee, 0xae, 0x21, 0xed, 0xf7, 0x77, 0x8d, 0xc7, 0x59, 0x15, 0x81, 0x1a, 0x58, 0x3a, 0xd1,
+	0xb7, 0x50, 0x20, 0xe3, 0xb1, 0xed, 0x7b, 0x32, 0xe9, 0xa5, 0xe6, 0xd7, 0xef, 0xee, 0x1a, 0x29,
+	0xf2, 0xfe, 0xae, 0xf1, 0x83, 0x07, 0xc7, 0x92, 0xb8, 0x81, 0x37, 0xc9, 0x78, 0xdc, 0xf7, 0x8c,
+	0x7f, 0x29, 0x50, 0x48, 0x66, 0xd4, 0xda, 0xbe, 0xfe, 0x25, 0xa8, 0xaf, 0x98, 0x37, 0x96, 0xc7,
+	0xab, 0x1d, 0x3f, 0xfb, 0xe8, 0x80, 0x4b, 0x1f, 0xc3, 0xdb, 0x80, 0x62, 0xb9, 0x02, 0x35, 0xa1,
+	0x72, 0x15, 0x7b, 0xc9, 0x64, 0xe6, 0x64, 0x22, 0x4f, 0x54, 0x5b, 0x3b, 0x0d, 0x4e, 0xce, 0x7b,
+	0xad, 0x61, 0xa7, 0xdf, 0xb3, 0x87, 0xe6, 0x0b, 0x5c, 0x9e, 0x2f, 0x1a, 0x92, 0x89, 0xf1, 0x12,
+	0x60, 0xa9, 0x8b, 0xaa, 0x50, 0x0a, 0x48, 0x14, 0xd9, 0x11, 0xf5, 0xc6, 0xda, 0x06, 0xaa, 0x01,
+	0x48, 0x33, 0xa4, 0x81, 0x7b, 0xab, 0x29, 0x0b, 0xf7, 0xc8, 0xe7, 0x53, 0x2d, 0x87, 0xb6, 0xa1,
+	0x2c, 0x4d, 0x36, 0xf1, 0xfc, 0x90, 0x6a, 0x79, 0xe3, 0xcf, 0x79, 0xc8, 0x9b, 0x01, 0xfb, 0xc4,
+	0x75, 0x32, 0x4f, 0x40, 0xee, 0xc1, 0xb4, 0xf1, 0x67, 0x41, 0xcc, 0xa9, 0x1d, 0x7b, 0x8c, 0x47,
+	0x69, 0xe7, 0x57, 0x52, 0xf0, 0x5c, 0x60, 0xe8, 0x10, 0x1e, 0xd3, 0x1b, 0x1e, 0x12, 0x3b, 0x4b,
+	0x55, 0x25, 0xf5, 0x91, 0x74, 0xb5, 0x56, 0xf9, 0x26, 0x14, 0x1d, 0xc2, 0xe9, 0xc4, 0x0f, 0x6f,
+	0xf5, 0x82, 0x1c, 0x13, 0xeb, 0xf2, 0x32, 0x08, 0xa8, 0xd3, 0x4a, 0x69, 0xe9, 0x75, 0xb5, 0x58,
+	0x86, 0x3a, 0x50, 0x95, 0xe3, 0xc9, 0x16, 0xc3, 0x83, 0x79, 0x13, 0x7d, 0x4b, 0xea, 0xd4, 0xd7,
+	0xe8, 0x34, 0x05, 0x4f, 0x7e, 0x74, 0x61, 0x2a, 0x53, 0x19, 0xcd, 0x21, 0xe6, 0x4d, 0xd0, 0x97,
+	0x00, 0x9c, 0xcd, 0xa8, 0x1f, 0x73, 0x7b, 0x26, 0xa6, 0xb6, 0x08, 0xba, 0x94, 0x22, 0xa7, 0x11,
+	0xfa, 0x06, 0x1e, 0x85, 0xd4, 0x65, 0x64, 0xc4, 0x5c, 0xc6, 0x6f, 0x6d, 0x97, 0x5e, 0x53, 0x57,
+	0x2f, 0xc9, 0x14, 0x69, 0x2b, 0x8e, 0xae, 0xc0, 0xc5, 0xbc, 0x09, 0xc8, 0x84, 0x79, 0x84, 0xd3,
+	0xb1, 0x0e, 0x32, 0xbd, 0x4b, 0x00, 0x7d, 0x05, 0x15, 0x59, 0x2d, 0x3b, 0x72, 0xa6, 0x74, 0x46,
+	0xf4, 0xb2, 0x54, 0x29, 0x4b, 0x6c, 0x20, 0x21, 0xe3, 0xdf, 0x0a, 0xd4, 0xb2, 0xf3, 0xf1, 0x83,
+	0x4e, 0x52, 0x3e, 0xbf, 0x93, 0xc4, 0x21, 0x96, 0x1a, 0x74, 0x16, 0x88, 0xc1, 0x95, 0xd6, 0x59,
+	0x5b, 0xf0, 0x52, 0x1c, 0xbd, 0x84, 0x5a, 0x48, 0xa3, 0xd8, 0xe5, 0x8b, 0xe4, 0xe6, 0x3f, 0x23,
+	0xb9, 0xd5, 0x64, 0xed, 0x3c, 0xbb, 0x5f, 0x40, 0x51, 0x4c, 0x12, 0xd9, 0x58, 0xf2, 0xf3, 0xc4,
+	0x5b, 0x24, 0x60, 0x3d, 0x32, 0xa3, 0xc6, 0xdf, 0x14, 0x28, 0xaf, 0xac, 0x17, 0x85, 0x08, 0xe4,
+	0x9b, 0x4d, 0x42, 0x71, 0xcc, 0xbc, 0x98, 0xd6, 0x09, 0x62, 0x86, 0x13, 0xf4, 0x6b, 0x28, 0x27,
+	0x86, 0x2d, 0x22, 0x4e, 0x3f, 0xc9, 0x75, 0x31, 0x9d, 0x99, 0x78, 0x60, 0x61, 0x5b, 0x64, 0x03,
+	0xa7, 0x8a, 0x27, 0xb1, 0xe7, 0x88, 0x5e, 0x1e, 0xd3, 0x2b, 0x22, 0x0e, 0x96, 0x4c, 0x7b, 0x39,
+	0x65, 0x70, 0x25, 0x05, 0x93, 0x61, 0xff, 0x14, 0x8a, 0xd4, 0x73, 0xfc, 0xb1, 0x38, 0x76, 0x12,
+	0xef, 0xc2, 0x96, 0x57, 0xe1, 0x6a, 0x57, 0xa2, 0x67, 0x42, 0x91, 0xd3, 0x70, 0xc6, 0x3c, 0x16,
+	0x71, 0xe6, 0xa4, 0x5f, 0x54, 0x16, 0x14, 0xf7, 0xaa, 0xeb, 0x3b, 0xc4, 0x95, 0x21, 0x17, 0x71,
+	0x62, 0x20, 0x03, 0x2a, 0x51, 0x3c, 0x8a, 0x9c, 0x90, 0x05, 0x22, 0xfb, 0x32, 0x98, 0x22, 0xce,
+	0x60, 0x22, 0x98, 0x88, 0x13, 0x4e, 0xaf, 0x62, 0x57, 0x06, 0x53, 0xc5, 0x0b, 0x1b, 0x35, 0xa0,
+	0x3c, 0x25, 0xde, 0x84, 0x79, 0x13, 0xf1, 0x2b, 0x4a, 0xdf, 0x94, 0xcb, 0x21, 0x85, 0xcc, 0x80,
+	0x1d, 0x18, 0x50, 0xb2, 0x7e, 0x3b, 0xb4, 0x7a, 0x83, 0x4e, 0xbf, 0x27, 0xae, 0x8c, 0x5e, 0xbf,
+	0x67, 0x25, 0x57, 0x86, 0x89, 0x5b, 0xdf, 0x75, 0x2e, 0x2c, 0x4d, 0x39, 0xf8, 0x93, 0x02, 0x95,
+	0xd5, 0xae, 0x41, 0x15, 0x28, 0xb6, 0x3b, 0x03, 0xb3, 0xd9, 0xb5, 0xda, 0xda, 0x06, 0xd2, 0xa0,
+	0xf2, 0xc2, 0x1a, 0xda, 0xcd, 0x6e, 0xbf, 0xf5, 0xb2, 0x77, 0x7e, 0xaa, 0x29, 0x68, 0x07, 0xb4,
+	0x05, 0x62, 0x37, 0x2f, 0x6d, 0x81, 0xe6, 0xd0, 0x53, 0x78, 0x32, 0xb0, 0x86, 0x76, 0xd7, 0x1c,
+	0x5a, 0x83, 0xa1, 0xdd, 0xe9, 0xd9, 0xa7, 0xd6, 0xd0, 0x6c, 0x9b, 0x43, 0x53, 0xcb, 0xa3, 0x27,
+	0x80, 0xb2, 0xbe, 0x66, 0xbf, 0x7d, 0xa9, 0xa9, 0x42, 0xfb, 0xc2, 0xc2, 0x9d, 0x93, 0x4e, 0xcb,
+	0x14, 0xbb, 0x6b, 0x9b, 0x82, 0x29, 0xb4, 0x2d, 0x13, 0x77, 0x3b, 0xd6, 0x20, 0xdd, 0x44, 0x2b,
+	0x1c, 0xfc, 0x41, 0x81, 0xf2, 0x4a, 0x4d, 0x51, 0x09, 0x36, 0xad, 0xd3, 0xb3, 0xe1, 0x65, 0x12,
+	0xa0, 0xf4, 0x88, 0x50, 0x4c, 0xfc, 0x42, 0x53, 0xd0, 0x63, 0xd8, 0x4e, 0x90, 0x96, 0xd9, 0xeb,
+	0xf7, 0x3a, 0x2d, 0xb3, 0xab, 0xe5, 0x44, 0xd4, 0x09, 0xd8, 0xee, 0xc8, 0xa3, 0x9a, 0xf8, 0x52,
+	0xcb, 0xa3, 0x06, 0xfc, 0xe8, 0x21, 0x6a, 0xf7, 0xb1, 0xdd, 0xc7, 0x6d, 0x0b, 0x5b, 0x6d, 0x4d,
+	0x15, 0xa9, 0x6a, 0x5b, 0x27, 0xe6, 0x79, 0x77, 0xa8, 0x15, 0x9a, 0xcd, 0xbf, 0xdc, 0xd7, 0x95,
+	0xd7, 0xf7, 0x75, 0xe5, 0xcd, 0x7d, 0x5d, 0xf9, 0xe7, 0x7d, 0x5d, 0xf9, 0xe3, 0xdb, 0xfa, 0xc6,
+	0x9b, 0xb7, 0xf5, 0x8d, 0x7f, 0xbc, 0xad, 0x6f, 0xfc, 0xee, 0xd9, 0x84, 0xf1, 0x69, 0x3c, 0x3a,
+	0x74, 0xfc, 0xd9, 0x51, 0xe6, 0x2f, 0xc1, 0x4d, 0xf2, 0xa7, 0x40, 0x5c, 0x54, 0xd1, 0xa8, 0x20,
+	0x7f, 0xe3, 0x3f, 0xff, 0xdf, 0x00, 0x8a, 0xc1, 0x6f, 0xcc, 0x36, 0x0c, 0x00, 0x00,
 }
 
 func (this *ApiCollection) Equal(that interface{}) bool {
@@ -1363,6 +1390,15 @@ func (this *Api) Equal(that interface{}) bool {
 	if this.TimeoutMs != that1.TimeoutMs {
 		return false
 	}
+	if this.ReliabilityLevel != that1.ReliabilityLevel {
+		return false
+	}
+	if this.Paginated != that1.Paginated {
+		return false
+	}
+	if this.ReplySchema != that1.ReplySchema {
+		return false
+	}
 	return true
 }
 func (this *ParseDirective) Equal(that interface{}) bool {
@@ -1889,6 +1925,30 @@ func (m *Api) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.ReplySchema) > 0 {
+		i -= len(m.ReplySchema)
+		copy(dAtA[i:], m.ReplySchema)
+		i = encodeVarintApiCollection(dAtA, i, uint64(len(m.ReplySchema)))
+		i--
+		dAtA[i] = 0x5a
+	}
+	if m.Paginated {
+		i--
+		if m.Paginated {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x50
+	}
+	if len(m.ReliabilityLevel) > 0 {
+		i -= len(m.ReliabilityLevel)
+		copy(dAtA[i:], m.ReliabilityLevel)
+		i = encodeVarintApiCollection(dAtA, i, uint64(len(m.ReliabilityLevel)))
+		i--
+		dAtA[i] = 0x4a
+	}
 	if m.TimeoutMs != 0 {
 		i = encodeVarintApiCollection(dAtA, i, uint64(m.TimeoutMs))
 		i--
@@ -2324,6 +2384,17 @@ func (m *Api) Size() (n int) {
 	if m.TimeoutMs != 0 {
 		n += 1 + sovApiCollection(uint64(m.TimeoutMs))
 	}
+	l = len(m.ReliabilityLevel)
+	if l > 0 {
+		n += 1 + l + sovApiCollection(uint64(l))
+	}
+	if m.Paginated {
+		n += 2
+	}
+	l = len(m.ReplySchema)
+	if l > 0 {
+		n += 1 + l + sovApiCollection(uint64(l))
+	}
 	return n
 }
 
@@ -3716,6 +3787,90 @@ func (m *Api) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReliabilityLevel", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowApiCollection
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthApiCollection
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthApiCollection
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ReliabilityLevel = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Paginated", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowApiCollection
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Paginated = bool(v != 0)
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplySchema", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowApiCollection
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthApiCollection
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthApiCollection
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ReplySchema = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipApiCollection(dAtA[iNdEx:])
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	fmt "fmt"
 	"sort"
 	"strconv"
@@ -125,6 +126,20 @@ func (spec Spec) ValidateSpec(maxCU uint64) (map[string]string, error) {
 				details["api"] = api.Name
 				return details, fmt.Errorf("api name includes a space character %s", api.Name)
 			}
+			switch api.ReliabilityLevel {
+			case "", ReliabilityLevelNever, ReliabilityLevelProbabilistic, ReliabilityLevelAlways:
+			default:
+				details["api"] = api.Name
+				return details, fmt.Errorf("unknown reliability level %s for api %s", api.ReliabilityLevel, api.Name)
+			}
+			if api.Paginated && apiCollection.CollectionData.ApiInterface != APIInterfaceRest {
+				details["api"] = api.Name
+				return details, fmt.Errorf("only rest apis can be paginated %s", api.Name)
+			}
+			if api.ReplySchema != "" && !json.Valid([]byte(api.ReplySchema)) {
+				details["api"] = api.Name
+				return details, fmt.Errorf("reply schema is not valid json %s", api.Name)
+			}
 		}
 		currentHeaders := map[string]struct{}{}
 		for _, header := range apiCollection.Headers {
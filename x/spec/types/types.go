@@ -19,6 +19,14 @@ const (
 	SpecRefreshEventName = "spec_refresh"
 )
 
+// the api's reliability level, how often its relays are cross checked with a second provider. an empty level is
+// probabilistic
+const (
+	ReliabilityLevelNever         = "never"
+	ReliabilityLevelProbabilistic = "probabilistic"
+	ReliabilityLevelAlways        = "always"
+)
+
 const (
 	EncodingBase64 = "base64"
 	EncodingHex    = "hex"